@@ -0,0 +1,171 @@
+// Copyright 2024 Adevinta
+
+// Package badge implements the badge command.
+package badge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/adevinta/lava/cmd/lava/internal/base"
+	"github.com/adevinta/lava/internal/badge"
+)
+
+// CmdBadge represents the badge command.
+var CmdBadge = &base.Command{
+	UsageLine: "badge [flags] metrics",
+	Short:     "generate SVG badge",
+	Long: `
+Generate a shields.io-style SVG badge from a metrics file.
+
+Badge accepts one argument: the path of the metrics file generated by
+a previous scan. For more details about how to generate a metrics
+file, use "lava help metrics".
+
+The -o flag specifies the output file. If not specified, the standard
+output is used.
+
+The -label flag sets the text shown on the left side of the badge. If
+not specified, "security" is used.
+
+The -type flag determines the information shown by the badge. Valid
+values are:
+
+  - status: "passing" if the scan exited with code zero, "failing"
+    otherwise.
+  - counts: number of vulnerabilities grouped by severity.
+  - grade: security grade of the scan. It requires the security grade
+    to be enabled in the configuration file.
+
+If not specified, "status" is used.
+
+For instance, the following command generates a badge with the number
+of vulnerabilities found by the last scan:
+
+	lava badge -type=counts -o badge.svg metrics.json
+	`,
+}
+
+// Command-line flags.
+var (
+	badgeO     string // -o flag
+	badgeLabel string // -label flag
+	badgeType  string // -type flag
+)
+
+func init() {
+	CmdBadge.Run = runBadge // Break initialization cycle.
+	CmdBadge.Flag.StringVar(&badgeO, "o", "", "output file")
+	CmdBadge.Flag.StringVar(&badgeLabel, "label", "security", "badge label")
+	CmdBadge.Flag.StringVar(&badgeType, "type", "status", "badge type")
+}
+
+// metricsData contains the metrics used to generate the badge.
+type metricsData struct {
+	ExitCode           *int           `json:"exit_code"`
+	VulnerabilityCount map[string]int `json:"vulnerability_count"`
+	Grade              *struct {
+		Score  float64 `json:"score"`
+		Letter string  `json:"grade"`
+	} `json:"grade"`
+}
+
+// severities is the list of severities sorted by decreasing
+// importance and the badge color associated to each one.
+var severities = []struct {
+	name  string
+	color string
+}{
+	{"critical", "red"},
+	{"high", "orange"},
+	{"medium", "yellow"},
+	{"low", "yellowgreen"},
+	{"info", "green"},
+}
+
+// runBadge is the entry point of the badge command.
+func runBadge(args []string) error {
+	if len(args) != 1 {
+		return errors.New("invalid number of arguments")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read metrics file: %w", err)
+	}
+
+	var md metricsData
+	if err := json.Unmarshal(data, &md); err != nil {
+		return fmt.Errorf("decode metrics: %w", err)
+	}
+
+	b, err := mkBadge(md)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if badgeO != "" {
+		f, err := os.Create(badgeO)
+		if err != nil {
+			return fmt.Errorf("create file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := b.Render(w); err != nil {
+		return fmt.Errorf("render badge: %w", err)
+	}
+	return nil
+}
+
+// mkBadge generates a badge from the provided metrics according to
+// the command-line flags.
+func mkBadge(md metricsData) (badge.Badge, error) {
+	b := badge.Badge{Label: badgeLabel}
+	switch badgeType {
+	case "status":
+		if md.ExitCode == nil {
+			return badge.Badge{}, errors.New("missing exit code metric")
+		}
+		if *md.ExitCode == 0 {
+			b.Message = "passing"
+			b.Color = "brightgreen"
+		} else {
+			b.Message = "failing"
+			b.Color = "red"
+		}
+	case "counts":
+		var counts []string
+		for _, sev := range severities {
+			n := md.VulnerabilityCount[sev.name]
+			if n == 0 {
+				continue
+			}
+			if b.Color == "" {
+				b.Color = sev.color
+			}
+			counts = append(counts, fmt.Sprintf("%v %v", n, sev.name))
+		}
+		if len(counts) == 0 {
+			b.Message = "no vulnerabilities"
+			b.Color = "brightgreen"
+		} else {
+			b.Message = strings.Join(counts, " | ")
+		}
+	case "grade":
+		if md.Grade == nil {
+			return badge.Badge{}, errors.New("missing grade metric")
+		}
+		b.Message = fmt.Sprintf("%v (%v)", md.Grade.Letter, md.Grade.Score)
+		b.Color = badge.GradeColor(md.Grade.Letter)
+	default:
+		return badge.Badge{}, fmt.Errorf("invalid badge type: %v", badgeType)
+	}
+	return b, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Adevinta
+
+package badge
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/badge"
+)
+
+func TestMkBadge(t *testing.T) {
+	tests := []struct {
+		name       string
+		typ        string
+		metrics    string
+		want       badge.Badge
+		wantNilErr bool
+	}{
+		{
+			name:       "status passing",
+			typ:        "status",
+			metrics:    `{"exit_code": 0}`,
+			want:       badge.Badge{Label: "security", Message: "passing", Color: "brightgreen"},
+			wantNilErr: true,
+		},
+		{
+			name:       "status failing",
+			typ:        "status",
+			metrics:    `{"exit_code": 103}`,
+			want:       badge.Badge{Label: "security", Message: "failing", Color: "red"},
+			wantNilErr: true,
+		},
+		{
+			name:       "counts",
+			typ:        "counts",
+			metrics:    `{"vulnerability_count": {"low": 3, "high": 1}}`,
+			want:       badge.Badge{Label: "security", Message: "1 high | 3 low", Color: "orange"},
+			wantNilErr: true,
+		},
+		{
+			name:       "no counts",
+			typ:        "counts",
+			metrics:    `{}`,
+			want:       badge.Badge{Label: "security", Message: "no vulnerabilities", Color: "brightgreen"},
+			wantNilErr: true,
+		},
+		{
+			name:       "grade",
+			typ:        "grade",
+			metrics:    `{"grade": {"score": 85, "grade": "B"}}`,
+			want:       badge.Badge{Label: "security", Message: "B (85)", Color: "green"},
+			wantNilErr: true,
+		},
+		{
+			name:       "missing grade",
+			typ:        "grade",
+			metrics:    `{}`,
+			wantNilErr: false,
+		},
+		{
+			name:       "invalid type",
+			typ:        "invalid",
+			metrics:    `{}`,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldBadgeO := badgeO
+			oldBadgeLabel := badgeLabel
+			oldBadgeType := badgeType
+			defer func() {
+				badgeO = oldBadgeO
+				badgeLabel = oldBadgeLabel
+				badgeType = oldBadgeType
+			}()
+
+			tmpPath := t.TempDir()
+			metricsFile := path.Join(tmpPath, "metrics.json")
+			if err := os.WriteFile(metricsFile, []byte(tt.metrics), 0644); err != nil {
+				t.Fatalf("write metrics file: %v", err)
+			}
+
+			badgeO = path.Join(tmpPath, "badge.svg")
+			badgeLabel = "security"
+			badgeType = tt.typ
+
+			err := runBadge([]string{metricsFile})
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+
+			data, err := os.ReadFile(badgeO)
+			if err != nil {
+				t.Fatalf("read badge: %v", err)
+			}
+			if !strings.Contains(string(data), tt.want.Message) {
+				t.Errorf("message not found: %v", tt.want.Message)
+			}
+
+			var md metricsData
+			if err := json.Unmarshal([]byte(tt.metrics), &md); err != nil {
+				t.Fatalf("decode metrics: %v", err)
+			}
+			got, err := mkBadge(md)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("badge mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -5,16 +5,112 @@
 package base
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+
+	"github.com/jroimartin/clilog"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/warnings"
 )
 
 // LogLevel is the level of the default logger.
 var LogLevel = &slog.LevelVar{}
 
+// SetLogger sets a default logger that writes to stderr using the
+// specified format. If logFile is not nil, the logs are also written
+// to it regardless of [LogLevel]. The warnings are recorded in
+// [warnings.DefaultCollector] regardless of [LogLevel], so they can
+// be summarized at the end of the run.
+func SetLogger(format config.LogFormat, logFile io.Writer) {
+	h := multiHandler{
+		NewLogHandler(os.Stderr, format, LogLevel),
+		warnings.NewHandler(warnings.DefaultCollector),
+	}
+	if logFile != nil {
+		h = append(h, newFileLogHandler(logFile, format))
+	}
+	slog.SetDefault(slog.New(h))
+}
+
+// NewLogHandler returns a [slog.Handler] that writes to w using the
+// specified format and level. [config.LogFormatText] generates
+// human-oriented output, while [config.LogFormatJSON] generates one
+// JSON object per line.
+func NewLogHandler(w io.Writer, format config.LogFormat, level slog.Leveler) slog.Handler {
+	if format == config.LogFormatJSON {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	}
+	return clilog.NewCLIHandler(w, &clilog.HandlerOptions{Level: level})
+}
+
+// newFileLogHandler returns a [slog.Handler] that writes debug logs
+// to w. Text logs are written using the logfmt format, so they do
+// not contain terminal escape sequences.
+func newFileLogHandler(w io.Writer, format config.LogFormat) slog.Handler {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if format == config.LogFormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// multiHandler is a [slog.Handler] that dispatches log records to
+// multiple handlers.
+type multiHandler []slog.Handler
+
+// Enabled reports whether any of the handlers handles records at
+// the given level.
+func (mh multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range mh {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle dispatches the record to the handlers that are enabled for
+// its level.
+func (mh multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range mh {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs returns a new [multiHandler] whose handlers have the
+// provided attributes.
+func (mh multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var hs multiHandler
+	for _, h := range mh {
+		hs = append(hs, h.WithAttrs(attrs))
+	}
+	return hs
+}
+
+// WithGroup returns a new [multiHandler] whose handlers have the
+// provided group.
+func (mh multiHandler) WithGroup(name string) slog.Handler {
+	var hs multiHandler
+	for _, h := range mh {
+		hs = append(hs, h.WithGroup(name))
+	}
+	return hs
+}
+
 // Command represents a Lava command.
 type Command struct {
 	Run       func(args []string) error
@@ -0,0 +1,41 @@
+// Copyright 2024 Adevinta
+
+package base
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestMultiHandler(t *testing.T) {
+	var stderr, file bytes.Buffer
+
+	h := multiHandler{
+		slog.NewTextHandler(&stderr, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		newFileLogHandler(&file, config.LogFormatJSON),
+	}
+	logger := slog.New(h).With("scanID", "1234")
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+
+	if strings.Contains(stderr.String(), "debug message") {
+		t.Errorf("debug message written to stderr: %q", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "info message") {
+		t.Errorf("info message not written to stderr: %q", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "scanID=1234") {
+		t.Errorf("missing attribute in stderr: %q", stderr.String())
+	}
+
+	for _, want := range []string{`"msg":"debug message"`, `"msg":"info message"`, `"scanID":"1234"`} {
+		if !strings.Contains(file.String(), want) {
+			t.Errorf("file output does not contain %q: %q", want, file.String())
+		}
+	}
+}
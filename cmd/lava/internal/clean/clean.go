@@ -0,0 +1,264 @@
+// Copyright 2024 Adevinta
+
+// Package clean implements the clean command.
+package clean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+
+	"github.com/adevinta/lava/cmd/lava/internal/base"
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/containers"
+	"github.com/adevinta/lava/internal/engine"
+	"github.com/adevinta/lava/internal/gitserver"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// CmdClean represents the clean command.
+var CmdClean = &base.Command{
+	UsageLine: "clean [flags]",
+	Short:     "remove Lava artifacts",
+	Long: `
+Clean removes the artifacts left behind by previous Lava runs.
+
+It removes the containers, images, volumes and networks labeled by
+Lava with the "lava.managed" label, and the temporary files and
+directories created by Lava, like the repositories served by its
+internal Git server and the reports of the checks. Besides
+"lava.managed", the resources created by Lava are labeled with
+"lava.version" (the version of Lava), "lava.scan-id" (the ID of the
+scan) and "lava.checktype" (the name of the checktype). The temporary
+directories are looked up in the directory specified by the
+"agent.tmpDir" setting of the configuration file, the LAVA_TMPDIR
+environment variable or, if none of them is set, the default directory
+for temporary files of the system.
+
+The -c flag allows to specify a configuration file. By default, "lava
+clean" looks for a configuration file with the name "lava.yaml" in the
+current directory. If the file does not exist, the default
+configuration is used.
+
+Clean must not be run while other Lava commands are running, because
+it would remove the resources they are using.
+
+The -n flag prints the artifacts that would be removed, without
+removing them.
+
+The environment variable LAVA_RUNTIME allows to select which
+container runtime is in use. For more details, use "lava help
+environment".
+	`,
+}
+
+// Command-line flags.
+var (
+	cleanC string // -c flag
+	cleanN bool   // -n flag
+)
+
+func init() {
+	CmdClean.Run = runClean // Break initialization cycle.
+	CmdClean.Flag.StringVar(&cleanC, "c", "lava.yaml", "config file")
+	CmdClean.Flag.BoolVar(&cleanN, "n", false, "dry run")
+}
+
+// osStdout is used by tests to capture the output of the command.
+var osStdout io.Writer = os.Stdout
+
+// runClean is the entry point of the clean command.
+func runClean(args []string) error {
+	if len(args) > 0 {
+		return errors.New("too many arguments")
+	}
+
+	cfg, err := config.ParseFile(cleanC)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("parse config file: %w", err)
+	}
+
+	rt, err := containers.GetenvRuntime()
+	if err != nil {
+		return fmt.Errorf("get env runtime: %w", err)
+	}
+
+	cli, err := containers.NewDockerdClient(rt)
+	if err != nil {
+		return fmt.Errorf("new dockerd client: %w", err)
+	}
+	defer cli.Close()
+
+	ctx := context.Background()
+
+	if err := cleanContainers(ctx, cli); err != nil {
+		return fmt.Errorf("clean containers: %w", err)
+	}
+
+	if err := cleanImages(ctx, cli); err != nil {
+		return fmt.Errorf("clean images: %w", err)
+	}
+
+	if err := cleanVolumes(ctx, cli); err != nil {
+		return fmt.Errorf("clean volumes: %w", err)
+	}
+
+	if err := cleanNetworks(ctx, cli); err != nil {
+		return fmt.Errorf("clean networks: %w", err)
+	}
+
+	if err := cleanTempPaths(config.TmpDir(cfg.AgentConfig), tempDirPatterns); err != nil {
+		return fmt.Errorf("clean temporary directories: %w", err)
+	}
+
+	if err := cleanTempPaths(os.TempDir(), tempFilePatterns); err != nil {
+		return fmt.Errorf("clean temporary files: %w", err)
+	}
+
+	return nil
+}
+
+// cleanContainers removes the containers created by Lava.
+func cleanContainers(ctx context.Context, cli containers.DockerdClient) error {
+	ctrs, err := cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: containers.ManagedFilter(),
+	})
+	if err != nil {
+		return fmt.Errorf("container list: %w", err)
+	}
+
+	for _, ctr := range ctrs {
+		report("container", ctr.ID)
+		if cleanN {
+			continue
+		}
+		rmOpts := container.RemoveOptions{Force: true, RemoveVolumes: true}
+		if err := cli.ContainerRemove(ctx, ctr.ID, rmOpts); err != nil {
+			return fmt.Errorf("container remove: %w", err)
+		}
+	}
+	return nil
+}
+
+// cleanImages removes the images built by Lava.
+func cleanImages(ctx context.Context, cli containers.DockerdClient) error {
+	imgs, err := cli.ImageList(ctx, image.ListOptions{
+		All:     true,
+		Filters: containers.ManagedFilter(),
+	})
+	if err != nil {
+		return fmt.Errorf("image list: %w", err)
+	}
+
+	for _, img := range imgs {
+		report("image", img.ID)
+		if cleanN {
+			continue
+		}
+		rmOpts := image.RemoveOptions{Force: true, PruneChildren: true}
+		if _, err := cli.ImageRemove(ctx, img.ID, rmOpts); err != nil {
+			return fmt.Errorf("image remove: %w", err)
+		}
+	}
+	return nil
+}
+
+// cleanVolumes removes the volumes created by Lava.
+func cleanVolumes(ctx context.Context, cli containers.DockerdClient) error {
+	resp, err := cli.VolumeList(ctx, volume.ListOptions{
+		Filters: containers.ManagedFilter(),
+	})
+	if err != nil {
+		return fmt.Errorf("volume list: %w", err)
+	}
+
+	for _, vol := range resp.Volumes {
+		report("volume", vol.Name)
+		if cleanN {
+			continue
+		}
+		if err := cli.VolumeRemove(ctx, vol.Name, true); err != nil {
+			return fmt.Errorf("volume remove: %w", err)
+		}
+	}
+	return nil
+}
+
+// cleanNetworks removes the networks created by Lava.
+func cleanNetworks(ctx context.Context, cli containers.DockerdClient) error {
+	nets, err := cli.NetworkList(ctx, network.ListOptions{
+		Filters: containers.ManagedFilter(),
+	})
+	if err != nil {
+		return fmt.Errorf("network list: %w", err)
+	}
+
+	for _, net := range nets {
+		report("network", net.Name)
+		if cleanN {
+			continue
+		}
+		if err := cli.NetworkRemove(ctx, net.ID); err != nil {
+			return fmt.Errorf("network remove: %w", err)
+		}
+	}
+	return nil
+}
+
+// tempDirPatterns are the patterns of the temporary directories
+// created by Lava in the directory used to store temporary files.
+var tempDirPatterns = []string{
+	gitserver.TempDirPattern,
+	engine.ReportsDirPattern,
+}
+
+// tempFilePatterns are the patterns of the temporary files created
+// by Lava in the default directory for temporary files.
+var tempFilePatterns = []string{
+	urlutil.TempFilePattern,
+}
+
+// cleanTempPaths removes the files and directories in dir that match
+// any of the provided patterns. If dir is the empty string, the
+// default directory for temporary files is used.
+func cleanTempPaths(dir string, patterns []string) error {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return fmt.Errorf("glob: %w", err)
+		}
+
+		for _, path := range paths {
+			report("temporary path", path)
+			if cleanN {
+				continue
+			}
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("remove temporary path: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// report prints the artifact being removed.
+func report(kind, name string) {
+	if cleanN {
+		fmt.Fprintf(osStdout, "would remove %v %v\n", kind, name)
+		return
+	}
+	fmt.Fprintf(osStdout, "removing %v %v\n", kind, name)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Adevinta
+
+package clean
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanTempPaths(t *testing.T) {
+	tests := []struct {
+		name       string
+		dryRun     bool
+		wantExists bool
+	}{
+		{
+			name:       "remove",
+			dryRun:     false,
+			wantExists: false,
+		},
+		{
+			name:       "dry run",
+			dryRun:     true,
+			wantExists: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldCleanN := cleanN
+			oldOsStdout := osStdout
+			defer func() {
+				cleanN = oldCleanN
+				osStdout = oldOsStdout
+			}()
+
+			var buf bytes.Buffer
+			cleanN = tt.dryRun
+			osStdout = &buf
+
+			dir := t.TempDir()
+			lavaDirs := []string{
+				filepath.Join(dir, "lava-gitserver-1234"),
+				filepath.Join(dir, "lava-reports-1234"),
+			}
+			otherDir := filepath.Join(dir, "other")
+			for _, d := range append(lavaDirs, otherDir) {
+				if err := os.MkdirAll(filepath.Join(d, "repo.git"), 0755); err != nil {
+					t.Fatalf("unable to create dir: %v", err)
+				}
+			}
+
+			if err := cleanTempPaths(dir, tempDirPatterns); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, d := range lavaDirs {
+				if _, err := os.Stat(d); (err == nil) != tt.wantExists {
+					t.Errorf("unexpected Lava dir state: %v", err)
+				}
+				if !bytes.Contains(buf.Bytes(), []byte(d)) {
+					t.Errorf("Lava dir was not reported: %q", buf.String())
+				}
+			}
+			if _, err := os.Stat(otherDir); err != nil {
+				t.Errorf("unrelated dir was removed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 Adevinta
+
+// Package configcmd implements the config command.
+package configcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/adevinta/lava/cmd/lava/internal/base"
+	"github.com/adevinta/lava/internal/config"
+)
+
+// CmdConfig represents the config command.
+var CmdConfig = &base.Command{
+	UsageLine: "config show [flags]",
+	Short:     "show effective configuration",
+	Long: `
+Show the effective configuration.
+
+Config show prints the configuration used by "lava scan" after
+replacing the environment variables and setting the default value of
+every unset setting that has one. It allows to debug why a scan
+behaves differently from what is expected.
+
+The secrets of the configuration are masked. These are the passwords
+of the container registries, the tokens and passwords of the target
+authentication, the values of the agent variables and the environment
+of the services. Empty values are not masked, so they can be told
+apart from the set ones.
+
+The -c flag allows to specify the configuration file. If not
+specified, "lava.yaml" is used.
+
+The -fmt flag specifies the output format. Valid values are "yaml"
+and "json". If not specified, "yaml" is used.
+
+The configuration fields that are not set and do not have a default
+value are omitted.
+	`,
+}
+
+// Command-line flags.
+var (
+	configC   string // -c flag
+	configFmt string // -fmt flag
+)
+
+// stdout is used by tests to capture the output of the command.
+var stdout io.Writer = os.Stdout
+
+func init() {
+	CmdConfig.Run = runConfig // Break initialization cycle.
+	CmdConfig.Flag.StringVar(&configC, "c", "lava.yaml", "config file")
+	CmdConfig.Flag.StringVar(&configFmt, "fmt", "yaml", "output format")
+}
+
+// runConfig is the entry point of the config command.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return errors.New("unknown subcommand")
+	}
+
+	// Flags can be specified after the subcommand.
+	if err := CmdConfig.Flag.Parse(args[1:]); err != nil {
+		return fmt.Errorf("parse flags: %w", err)
+	}
+	if CmdConfig.Flag.NArg() > 0 {
+		return errors.New("too many arguments")
+	}
+
+	if configFmt != "yaml" && configFmt != "json" {
+		return fmt.Errorf("invalid output format: %q", configFmt)
+	}
+
+	cfg, err := config.ParseFile(configC)
+	if err != nil {
+		return fmt.Errorf("parse config file: %w", err)
+	}
+
+	if err := show(stdout, cfg.WithDefaults().Masked(), configFmt); err != nil {
+		return fmt.Errorf("show config: %w", err)
+	}
+	return nil
+}
+
+// show writes the provided configuration to w using the specified
+// format.
+func show(w io.Writer, cfg config.Config, format string) error {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	prune(&doc)
+
+	if format == "json" {
+		var v any
+		if err := doc.Decode(&v); err != nil {
+			return fmt.Errorf("decode config: %w", err)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// prune removes the null values, empty strings and empty collections
+// from the mappings of the provided YAML node. It reports whether the
+// node is empty after pruning. The empty values of the variables
+// and environments are kept, so they can be told apart from the
+// missing ones.
+func prune(n *yaml.Node) bool {
+	return pruneNode(n, false)
+}
+
+// keepEmpty contains the keys of the mappings whose empty values are
+// not pruned.
+var keepEmpty = []string{"vars", "env"}
+
+// pruneNode implements prune. If keep is true, the empty scalar
+// values of the provided mapping node are kept.
+func pruneNode(n *yaml.Node, keep bool) bool {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			pruneNode(c, false)
+		}
+		return false
+	case yaml.MappingNode:
+		var content []*yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			empty := pruneNode(value, slices.Contains(keepEmpty, key.Value))
+			if empty && !(keep && value.Kind == yaml.ScalarNode) {
+				continue
+			}
+			content = append(content, key, value)
+		}
+		n.Content = content
+		return len(n.Content) == 0
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			pruneNode(item, false)
+		}
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		return n.Tag == "!!null" || (n.Tag == "!!str" && n.Value == "")
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Adevinta
+
+package configcmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "yaml",
+			args: []string{"show", "-c", "testdata/lava.yaml"},
+			want: `lava: v1.0.0
+pathBase: config
+agent:
+  pullPolicy: IfNotPresent
+  parallel: 2
+  vars:
+    EMPTY: ""
+    TOKEN: '*****'
+  timeout: 3m0s
+  maxNoMsgsInterval: 5s
+  registryBackoff:
+    maxRetries: 5
+    interval: 5s
+  pullRetry:
+    maxRetries: 2
+    interval: 30s
+report:
+  severity: high
+  show: high
+  format: human
+  theme: default
+  errorOnStaleExclusions: false
+  requireExclusionMetadata: false
+  errorOnInconclusive: true
+  sanitize: true
+checktypes:
+  - testdata/checktypes.json
+targets:
+  - identifier: https://example.com
+    type: WebAddress
+    auth:
+      type: header
+      token: '*****'
+log: INFO
+logFormat: text
+`,
+		},
+		{
+			name: "json",
+			args: []string{"show", "-c", "testdata/lava.yaml", "-fmt", "json"},
+			want: `{
+  "agent": {
+    "maxNoMsgsInterval": "5s",
+    "parallel": 2,
+    "pullPolicy": "IfNotPresent",
+    "pullRetry": {
+      "interval": "30s",
+      "maxRetries": 2
+    },
+    "registryBackoff": {
+      "interval": "5s",
+      "maxRetries": 5
+    },
+    "timeout": "3m0s",
+    "vars": {
+      "EMPTY": "",
+      "TOKEN": "*****"
+    }
+  },
+  "checktypes": [
+    "testdata/checktypes.json"
+  ],
+  "lava": "v1.0.0",
+  "log": "INFO",
+  "logFormat": "text",
+  "pathBase": "config",
+  "report": {
+    "errorOnInconclusive": true,
+    "errorOnStaleExclusions": false,
+    "format": "human",
+    "requireExclusionMetadata": false,
+    "sanitize": true,
+    "severity": "high",
+    "show": "high",
+    "theme": "default"
+  },
+  "targets": [
+    {
+      "auth": {
+        "token": "*****",
+        "type": "header"
+      },
+      "identifier": "https://example.com",
+      "type": "WebAddress"
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStdout := stdout
+			oldConfigC := configC
+			oldConfigFmt := configFmt
+			defer func() {
+				stdout = oldStdout
+				configC = oldConfigC
+				configFmt = oldConfigFmt
+			}()
+
+			t.Setenv("LAVA_TEST_TOKEN", "secret")
+
+			var buf bytes.Buffer
+			stdout = &buf
+
+			if err := runConfig(tt.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestRunConfig_invalid_args(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "no subcommand",
+			args: nil,
+		},
+		{
+			name: "unknown subcommand",
+			args: []string{"edit"},
+		},
+		{
+			name: "too many arguments",
+			args: []string{"show", "extra"},
+		},
+		{
+			name: "invalid format",
+			args: []string{"show", "-c", "testdata/lava.yaml", "-fmt", "toml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldConfigC := configC
+			oldConfigFmt := configFmt
+			defer func() {
+				configC = oldConfigC
+				configFmt = oldConfigFmt
+			}()
+
+			if err := runConfig(tt.args); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
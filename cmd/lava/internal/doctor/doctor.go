@@ -0,0 +1,523 @@
+// Copyright 2024 Adevinta
+
+// Package doctor implements the doctor command.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+
+	"github.com/adevinta/lava/cmd/lava/internal/base"
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/containers"
+)
+
+// CmdDoctor represents the doctor command.
+var CmdDoctor = &base.Command{
+	UsageLine: "doctor [flags]",
+	Short:     "check the environment",
+	Long: `
+Doctor checks that the environment is ready to run Lava and prints
+hints to fix the detected problems.
+
+It checks that:
+
+  - The container runtime is reachable. Its version is reported.
+  - Lava is able to work with the container runtime when it runs
+    inside a container (Docker-out-of-Docker or Docker-in-Docker).
+  - Git is installed.
+  - The gateway of the Docker network used by the checks is a local
+    address, so the checks can reach the services exposed by Lava.
+  - There is enough free disk space in the directory used to store
+    temporary files.
+  - DNS names can be resolved from a container. The image %v
+    is pulled if it is not present.
+  - The container registries are reachable. Docker Hub and the
+    registries configured in "agent.registries" are checked.
+
+The -c flag allows to specify a configuration file. By default, "lava
+doctor" looks for a configuration file with the name "lava.yaml" in
+the current directory. If the file does not exist, the default
+configuration is used. The configuration is used to get the Docker
+network, the temporary directory and the container registries.
+
+The -json flag prints the results in JSON format.
+
+The command exits with error if any of the checks fails. Warnings do
+not make the command fail.
+
+The environment variable LAVA_RUNTIME allows to select which
+container runtime is in use. For more details, use "lava help
+environment".
+	`,
+}
+
+// Command-line flags.
+var (
+	doctorC    string // -c flag
+	doctorJSON bool   // -json flag
+)
+
+func init() {
+	CmdDoctor.Run = runDoctor // Break initialization cycle.
+	CmdDoctor.Long = fmt.Sprintf(CmdDoctor.Long, dnsImage)
+	CmdDoctor.Flag.StringVar(&doctorC, "c", "lava.yaml", "config file")
+	CmdDoctor.Flag.BoolVar(&doctorJSON, "json", false, "JSON output")
+}
+
+// dnsImage is the image of the container used to check DNS
+// resolution.
+const dnsImage = "busybox:stable"
+
+// dnsHost is the name resolved from a container to check DNS
+// resolution.
+const dnsHost = "example.com"
+
+// dockerHubRegistry is the URL of the Docker Hub registry.
+const dockerHubRegistry = "https://registry-1.docker.io"
+
+// minFreeSpace is the minimum free disk space in bytes of the
+// temporary directory.
+const minFreeSpace = 2 << 30
+
+// Status is the status of a doctor check.
+type Status string
+
+// Check statuses.
+const (
+	StatusOK      Status = "ok"
+	StatusWarning Status = "warning"
+	StatusError   Status = "error"
+)
+
+// result is the result of a doctor check.
+type result struct {
+	// Name is the name of the check.
+	Name string `json:"name"`
+
+	// Status is the status of the check.
+	Status Status `json:"status"`
+
+	// Message describes the result of the check.
+	Message string `json:"message"`
+
+	// Hint explains how to fix the detected problem.
+	Hint string `json:"hint,omitempty"`
+}
+
+// osStdout is used by tests to capture the output of the command.
+var osStdout io.Writer = os.Stdout
+
+// execLookPath is used by tests to mock [exec.LookPath].
+var execLookPath = exec.LookPath
+
+// netInterfaceAddrs is used by tests to mock [net.InterfaceAddrs].
+var netInterfaceAddrs = net.InterfaceAddrs
+
+// httpClient is the HTTP client used to check the registries.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// runDoctor is the entry point of the doctor command.
+func runDoctor(args []string) error {
+	if len(args) > 0 {
+		return errors.New("too many arguments")
+	}
+
+	cfg, err := parseConfig(doctorC)
+	if err != nil {
+		return fmt.Errorf("parse config file: %w", err)
+	}
+
+	ctx := context.Background()
+	results := doctor(ctx, cfg)
+
+	if err := printResults(osStdout, results, doctorJSON); err != nil {
+		return fmt.Errorf("print results: %w", err)
+	}
+
+	var failed int
+	for _, r := range results {
+		if r.Status == StatusError {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%v checks failed", failed)
+	}
+	return nil
+}
+
+// parseConfig parses the specified configuration file. If the file
+// does not exist, an empty configuration is returned.
+func parseConfig(path string) (config.Config, error) {
+	cfg, err := config.ParseFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config.Config{}, nil
+	}
+	return cfg, err
+}
+
+// doctor runs all the checks using the provided configuration.
+func doctor(ctx context.Context, cfg config.Config) []result {
+	var results []result
+
+	cli, res := checkRuntime(ctx)
+	results = append(results, res)
+	if cli != nil {
+		defer cli.Close()
+		dcli := cli.WithNetwork(config.Get(cfg.AgentConfig.Network))
+		results = append(results, checkContainer(&dcli), checkNetwork(&dcli), checkDNS(ctx, &dcli))
+	} else {
+		hint := "fix the container runtime first"
+		results = append(results,
+			result{Name: "container", Status: StatusError, Message: "skipped", Hint: hint},
+			result{Name: "network", Status: StatusError, Message: "skipped", Hint: hint},
+			result{Name: "dns", Status: StatusError, Message: "skipped", Hint: hint},
+		)
+	}
+
+	results = append(results, checkGit(), checkDisk(config.TmpDir(cfg.AgentConfig), minFreeSpace))
+
+	registries := []string{dockerHubRegistry}
+	for _, ra := range cfg.AgentConfig.RegistryAuths {
+		registries = append(registries, ra.Server)
+	}
+	for _, reg := range registries {
+		results = append(results, checkRegistry(ctx, reg))
+	}
+
+	return results
+}
+
+// checkRuntime checks that the container runtime is reachable. It
+// returns a client of the container runtime if it is reachable.
+// Otherwise, the returned client is nil.
+func checkRuntime(ctx context.Context) (*containers.DockerdClient, result) {
+	res := result{Name: "runtime"}
+
+	rt, err := containers.GetenvRuntime()
+	if err != nil {
+		res.Status = StatusError
+		res.Message = err.Error()
+		res.Hint = `set LAVA_RUNTIME to a supported runtime, use "lava help environment" for details`
+		return nil, res
+	}
+
+	cli, err := containers.NewDockerdClient(rt)
+	if err != nil {
+		res.Status = StatusError
+		res.Message = err.Error()
+		res.Hint = "review the Docker client configuration, like DOCKER_HOST and DOCKER_CERT_PATH"
+		return nil, res
+	}
+
+	v, err := cli.ServerVersion(ctx)
+	if err != nil {
+		cli.Close()
+		res.Status = StatusError
+		res.Message = err.Error()
+		res.Hint = "make sure that the container runtime is running and that DOCKER_HOST and LAVA_RUNTIME are set correctly"
+		return nil, res
+	}
+
+	res.Status = StatusOK
+	res.Message = fmt.Sprintf("%v %v (API %v, %v/%v) at %v", v.Platform.Name, v.Version, v.APIVersion, v.Os, v.Arch, cli.DaemonHost())
+	return &cli, res
+}
+
+// checkContainer reports whether Lava runs inside a container and
+// how it reaches the container runtime.
+func checkContainer(cli *containers.DockerdClient) result {
+	res := result{Name: "container"}
+
+	switch mode := cli.Mode(); mode {
+	case containers.ModeHost:
+		res.Status = StatusOK
+		res.Message = "running in the host"
+	case containers.ModeDooD:
+		res.Status = StatusOK
+		res.Message = fmt.Sprintf("running in a container (%v)", mode)
+		res.Hint = `run the Lava container with "--network host" and map the mounted paths with "agent.volumes"`
+	default:
+		res.Status = StatusWarning
+		res.Message = fmt.Sprintf("running in a container (%v)", mode)
+		res.Hint = "checks against local Docker images require a remote Docker daemon reachable through TCP without TLS"
+	}
+	return res
+}
+
+// checkNetwork checks that the gateway of the Docker network used by
+// the checks is a local address.
+func checkNetwork(cli *containers.DockerdClient) result {
+	res := result{Name: "network"}
+
+	gw, err := cli.HostGatewayInterfaceAddr()
+	if err != nil {
+		res.Status = StatusError
+		res.Message = err.Error()
+		res.Hint = `make sure that the Docker network in "agent.network" exists and has a gateway`
+		return res
+	}
+
+	if !isLocalAddr(gw) {
+		res.Status = StatusWarning
+		res.Message = fmt.Sprintf("gateway %v is not a local address", gw)
+		res.Hint = `if the container runtime runs in a virtual machine, set LAVA_RUNTIME accordingly, use "lava help environment" for details`
+		return res
+	}
+
+	res.Status = StatusOK
+	res.Message = fmt.Sprintf("gateway %v", gw)
+	return res
+}
+
+// isLocalAddr reports whether the provided IP address is assigned to
+// a local interface.
+func isLocalAddr(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+
+	addrs, err := netInterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDNS checks that [dnsHost] can be resolved from a container
+// attached to the network used by the checks.
+func checkDNS(ctx context.Context, cli *containers.DockerdClient) result {
+	res := result{Name: "dns"}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if err := resolveFromContainer(ctx, cli); err != nil {
+		res.Status = StatusError
+		res.Message = err.Error()
+		res.Hint = "review the DNS configuration of the container runtime and the proxy settings"
+		return res
+	}
+
+	res.Status = StatusOK
+	res.Message = fmt.Sprintf("resolved %v from a container", dnsHost)
+	return res
+}
+
+// resolveFromContainer runs a container that resolves [dnsHost].
+func resolveFromContainer(ctx context.Context, cli *containers.DockerdClient) error {
+	if _, _, err := cli.ImageInspectWithRaw(ctx, dnsImage); err != nil {
+		if !errdefs.IsNotFound(err) {
+			return fmt.Errorf("image inspect: %w", err)
+		}
+		rc, err := cli.ImagePull(ctx, dnsImage, image.PullOptions{})
+		if err != nil {
+			return fmt.Errorf("image pull: %w", err)
+		}
+		defer rc.Close()
+		if _, err := io.Copy(io.Discard, rc); err != nil {
+			return fmt.Errorf("image pull: %w", err)
+		}
+	}
+
+	contCfg := &container.Config{
+		Image:  dnsImage,
+		Cmd:    []string{"nslookup", dnsHost},
+		Labels: containers.Labels(nil),
+	}
+	hostCfg := &container.HostConfig{
+		NetworkMode: container.NetworkMode(cli.Network()),
+	}
+	resp, err := cli.ContainerCreate(ctx, contCfg, hostCfg, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("container create: %w", err)
+	}
+	defer cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true}) //nolint:errcheck
+
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("container start: %w", err)
+	}
+
+	statusc, errc := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errc:
+		return fmt.Errorf("container wait: %w", err)
+	case status := <-statusc:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("could not resolve %v: exit code %v", dnsHost, status.StatusCode)
+		}
+	}
+	return nil
+}
+
+// checkGit checks that Git is installed.
+func checkGit() result {
+	res := result{Name: "git"}
+
+	path, err := execLookPath("git")
+	if err != nil {
+		res.Status = StatusError
+		res.Message = "git not found"
+		res.Hint = "install Git and make sure that it is in the PATH"
+		return res
+	}
+
+	out, err := exec.Command(path, "version").Output()
+	if err != nil {
+		res.Status = StatusError
+		res.Message = fmt.Sprintf("git version: %v", err)
+		res.Hint = "reinstall Git"
+		return res
+	}
+
+	res.Status = StatusOK
+	res.Message = strings.TrimSpace(string(out))
+	return res
+}
+
+// checkDisk checks that the free disk space in the specified
+// directory is at least min bytes. If dir is the empty string, the
+// default directory for temporary files is used.
+func checkDisk(dir string, min uint64) result {
+	res := result{Name: "disk"}
+
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		res.Status = StatusError
+		res.Message = fmt.Sprintf("statfs %v: %v", dir, err)
+		res.Hint = `make sure that the temporary directory exists, it can be set with "agent.tmpDir" or LAVA_TMPDIR`
+		return res
+	}
+
+	free := uint64(st.Bavail) * uint64(st.Bsize) //nolint:unconvert
+	msg := fmt.Sprintf("%v free in %v", formatBytes(free), dir)
+	if free < min {
+		res.Status = StatusWarning
+		res.Message = msg
+		res.Hint = fmt.Sprintf(`free at least %v or choose another temporary directory with "agent.tmpDir" or LAVA_TMPDIR`, formatBytes(min))
+		return res
+	}
+
+	res.Status = StatusOK
+	res.Message = msg
+	return res
+}
+
+// formatBytes formats the provided number of bytes using binary
+// prefixes.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%v B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// checkRegistry checks that the specified container registry is
+// reachable. The registry is considered reachable if its API base
+// endpoint replies with a status code of 200 or 401.
+func checkRegistry(ctx context.Context, server string) result {
+	u := registryURL(server)
+	res := result{Name: "registry " + strings.TrimSuffix(u, "/v2/")}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		res.Status = StatusError
+		res.Message = err.Error()
+		res.Hint = `review the server of the registry in "agent.registries"`
+		return res
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		res.Status = StatusError
+		res.Message = err.Error()
+		res.Hint = "review the network connectivity and the proxy settings (HTTPS_PROXY, NO_PROXY)"
+		return res
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusUnauthorized:
+		res.Status = StatusOK
+		res.Message = "reachable"
+	default:
+		res.Status = StatusWarning
+		res.Message = fmt.Sprintf("unexpected status: %v", resp.Status)
+		res.Hint = "make sure that the server is a container registry"
+	}
+	return res
+}
+
+// registryURL returns the URL of the API base endpoint of the
+// provided registry server. If the server does not specify a scheme,
+// HTTPS is used.
+func registryURL(server string) string {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	return strings.TrimSuffix(server, "/") + "/v2/"
+}
+
+// printResults prints the provided results to w. If asJSON is true,
+// the results are encoded as JSON.
+func printResults(w io.Writer, results []result, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	for _, r := range results {
+		if _, err := fmt.Fprintf(w, "%-8v %v: %v\n", statusLabels[r.Status], r.Name, r.Message); err != nil {
+			return err
+		}
+		if r.Hint != "" {
+			if _, err := fmt.Fprintf(w, "%-8v hint: %v\n", "", r.Hint); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// statusLabels contains the labels used to print the statuses in the
+// human-readable output.
+var statusLabels = map[Status]string{
+	StatusOK:      "[ok]",
+	StatusWarning: "[warn]",
+	StatusError:   "[error]",
+}
@@ -0,0 +1,210 @@
+// Copyright 2024 Adevinta
+
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckRegistry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		wantStatus Status
+	}{
+		{
+			name:       "ok",
+			statusCode: http.StatusOK,
+			wantStatus: StatusOK,
+		},
+		{
+			name:       "unauthorized",
+			statusCode: http.StatusUnauthorized,
+			wantStatus: StatusOK,
+		},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			wantStatus: StatusWarning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer srv.Close()
+
+			res := checkRegistry(context.Background(), srv.URL)
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("unexpected status: got: %v, want: %v", res.Status, tt.wantStatus)
+			}
+			if gotPath != "/v2/" {
+				t.Errorf("unexpected path: %v", gotPath)
+			}
+			if want := "registry " + srv.URL; res.Name != want {
+				t.Errorf("unexpected name: got: %v, want: %v", res.Name, want)
+			}
+		})
+	}
+}
+
+func TestCheckRegistry_unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	res := checkRegistry(context.Background(), srv.URL)
+
+	if res.Status != StatusError {
+		t.Errorf("unexpected status: %v", res.Status)
+	}
+	if res.Hint == "" {
+		t.Errorf("empty hint")
+	}
+}
+
+func TestRegistryURL(t *testing.T) {
+	tests := []struct {
+		server string
+		want   string
+	}{
+		{server: "registry.example.com", want: "https://registry.example.com/v2/"},
+		{server: "http://localhost:5000/", want: "http://localhost:5000/v2/"},
+		{server: "https://registry-1.docker.io", want: "https://registry-1.docker.io/v2/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			if got := registryURL(tt.server); got != tt.want {
+				t.Errorf("unexpected URL: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDisk(t *testing.T) {
+	dir := t.TempDir()
+
+	if res := checkDisk(dir, 0); res.Status != StatusOK {
+		t.Errorf("unexpected status: %v: %v", res.Status, res.Message)
+	}
+	if res := checkDisk(dir, 1<<62); res.Status != StatusWarning {
+		t.Errorf("unexpected status: %v: %v", res.Status, res.Message)
+	}
+	if res := checkDisk(dir+"/not-exist", 0); res.Status != StatusError {
+		t.Errorf("unexpected status: %v: %v", res.Status, res.Message)
+	}
+}
+
+func TestCheckGit_notFound(t *testing.T) {
+	oldExecLookPath := execLookPath
+	defer func() { execLookPath = oldExecLookPath }()
+
+	execLookPath = func(string) (string, error) {
+		return "", errors.New("not found")
+	}
+
+	res := checkGit()
+	if res.Status != StatusError {
+		t.Errorf("unexpected status: %v", res.Status)
+	}
+}
+
+func TestIsLocalAddr(t *testing.T) {
+	oldNetInterfaceAddrs := netInterfaceAddrs
+	defer func() { netInterfaceAddrs = oldNetInterfaceAddrs }()
+
+	netInterfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{
+			&net.IPNet{IP: net.ParseIP("172.17.0.1"), Mask: net.CIDRMask(16, 32)},
+		}, nil
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "127.0.0.1", want: true},
+		{addr: "172.17.0.1", want: true},
+		{addr: "192.168.1.1", want: false},
+		{addr: "invalid", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isLocalAddr(tt.addr); got != tt.want {
+				t.Errorf("unexpected result: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{n: 512, want: "512 B"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 2 << 30, want: "2.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatBytes(tt.n); got != tt.want {
+				t.Errorf("unexpected result: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintResults(t *testing.T) {
+	results := []result{
+		{Name: "git", Status: StatusOK, Message: "git version 2.45.0"},
+		{Name: "disk", Status: StatusWarning, Message: "1.0 GiB free in /tmp", Hint: "free some space"},
+	}
+
+	t.Run("human", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printResults(&buf, results, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := strings.Join([]string{
+			"[ok]     git: git version 2.45.0",
+			"[warn]   disk: 1.0 GiB free in /tmp",
+			"         hint: free some space",
+			"",
+		}, "\n")
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Errorf("output mismatch (-want +got):\n%v", diff)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printResults(&buf, results, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []result
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("unmarshal error: %v", err)
+		}
+		if diff := cmp.Diff(results, got); diff != "" {
+			t.Errorf("results mismatch (-want +got):\n%v", diff)
+		}
+	})
+}
@@ -61,13 +61,13 @@ Usage:
 
 The commands are:
 {{range .}}{{ if .Run}}
-	{{.Name | printf "%-11s"}} {{.Short}}{{end}}{{end}}
+	{{.Name | printf "%-13s"}} {{.Short}}{{end}}{{end}}
 
 Use "lava help <command>" for more information about a command.
 
 Additional help topics:
 {{range .}}{{if not .Run}}
-	{{.Name | printf "%-11s"}} {{.Short}}{{end}}{{end}}
+	{{.Name | printf "%-13s"}} {{.Short}}{{end}}{{end}}
 
 Use "lava help <topic>" for more information about that topic.
 `
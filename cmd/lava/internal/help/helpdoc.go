@@ -15,6 +15,11 @@ lava.yaml) that defines the parameters of the security scan.
 A Lava configuration file is a YAML document that supports environment
 variable substitution with ${ENVVAR_NAME} notation.
 
+The "lava config show" command prints the effective configuration,
+after replacing the environment variables and setting the default
+values, with the secrets masked. For more details, use "lava help
+config".
+
 # Example
 
 A Lava configuration file is a YAML document as shown in the following
@@ -50,15 +55,74 @@ command. For instance,
 Using a Lava command whose version is lower than the minimum version
 required by the configuration file returns an error.
 
+If the configuration file contains a field that is not supported by
+the Lava command, for instance because it was introduced in a newer
+version, Lava reports the field, its line and the minimum version
+required by the configuration file. Conversely, Lava logs a warning
+for every field introduced in a version newer than the one specified
+in the "lava" field, because older versions of Lava would not be able
+to parse the configuration file.
+
 This field is mandatory.
 
+# includes
+
+The optional "includes" field contains a list of paths of
+configuration files that are merged into the configuration. Paths can
+be glob patterns and are resolved relative to the directory of the
+including file. This allows to maintain drop-in configuration
+directories, like "conf.d", whose files are owned by different teams.
+For instance,
+
+	includes:
+	  - conf.d/*.yaml
+
+The files matching a pattern are merged in lexical order. Included
+files can include other files, but include cycles are not allowed.
+Included files do not need to be complete configurations. Lists, like
+"targets", are concatenated with the items of the included files
+first. Other settings of the including file take precedence over the
+included ones. A path without glob metacharacters must exist, while a
+glob pattern may not match any file.
+
+# pathBase
+
+The optional "pathBase" field sets the directory the relative paths
+of the configuration file are resolved against. This covers the
+checktype catalogs and their integrity data, the identifiers of the
+Path, GitRepository, DockerImageArchive, OCILayout and TerraformModule
+targets, the files referenced by the target authentication, the files
+of "targetsFrom" and "discovery", the files of the report, the log
+file and the directories and files of the agent. Supported values:
+
+  - config: relative paths are resolved against the directory of the
+    configuration file that contains them. It is the default value.
+  - cwd: relative paths are resolved against the current working
+    directory of the Lava command.
+
+For instance,
+
+	pathBase: cwd
+
+The default value allows to run "lava scan -c path/to/lava.yaml" from
+any directory. The identifier of a GitRepository target is only
+resolved if it points to an existing local directory, so remote
+repositories are not affected. URLs, absolute paths, the targets
+passed with the -targets flag, the targets read from "targetsFrom"
+sources and the "varFiles" of Terraform targets, which are relative
+to the module, are never modified. The setting only applies to the
+file where it is specified, so every included file can set its own
+path base. Includes are always resolved against the directory of the
+including file.
+
 # checktypes
 
 The "checktypes" field contains a list of URLs that point to checktype
 catalogs.
 
-If the URL omits the scheme, it is considered a file path relative to
-the current working directory of the Lava command. For instance,
+If the URL omits the scheme, it is considered a file path. Relative
+paths are resolved as described in the "pathBase" section. For
+instance,
 
 	checktypes:
 	  - checktypes.json
@@ -68,8 +132,39 @@ HTTP and HTTPS URLs are supported. For instance,
 	checktypes:
 	  - https://example.com/checktypes.json
 
+File paths can be glob patterns. The matching catalogs are loaded in
+lexical order. For instance,
+
+	checktypes:
+	  - catalogs/*.json
+
 At least one catalog must be specified.
 
+The "checktypesIntegrity" field allows to verify the checktype catalogs
+before using them, protecting against catalog tampering. It maps
+catalog URLs to the following properties:
+
+  - digest: expected SHA-256 digest of the catalog with the format
+    "sha256:<hex>".
+  - signature: URL of the signature of the catalog generated by
+    "cosign sign-blob". It must be specified along with publicKey.
+  - publicKey: path of the PEM-encoded public key used to verify the
+    signature, or the PEM-encoded key itself. URLs are not allowed,
+    because a key retrieved from the network could be tampered with
+    along with the catalog. ECDSA, RSA and Ed25519 keys are
+    supported.
+
+At least one of digest and signature must be specified. If the
+verification fails, Lava exits with error. For instance,
+
+	checktypes:
+	  - https://example.com/checktypes.json
+	checktypesIntegrity:
+	  https://example.com/checktypes.json:
+	    digest: sha256:e028377a26b427990030a465787253271e4084be012c4e229dabe4b9acecf5e3
+	    signature: https://example.com/checktypes.json.sig
+	    publicKey: cosign.pub
+
 # targets
 
 The "targets" field contains the list of targets to scan. Every target
@@ -79,9 +174,45 @@ is defined by the following properties:
     path, a URL, a container image, etc. It is mandatory.
   - type: the asset type of the target. Valid values are "AWSAccount",
     "DockerImage", "GitRepository", "IP", "IPRange", "DomainName",
-    "Hostname", "WebAddress" and "Path". It is mandatory.
+    "Hostname", "WebAddress", "Path", "DockerImageArchive",
+    "OCILayout" and "TerraformModule". It is mandatory.
   - options: map of target-specific options. These options are merged
-    with the options coming from the checktype catalog.
+    with the options coming from the checktype catalog. The "env"
+    option is a map of environment variables set in the containers
+    of the checks run against the target. It is merged with the "env"
+    option of the checktype catalog and takes precedence over
+    "agent.vars". It is not passed to the checks as an option, so it
+    is not included in the reports.
+  - auth: authentication used by the checks to scan the target. It
+    is usually specified for "WebAddress" targets.
+  - rateLimit: maximum rate at which the checks send requests to the
+    target. It is useful to protect fragile environments.
+  - terraform: configuration passed to the IaC checks. It is only
+    valid for "TerraformModule" targets.
+  - udpPorts: list of UDP ports of the target, like 53 or 161, that
+    are forwarded to the checks. By default, only the TCP port of
+    local services is proxied, so the checks that probe UDP services
+    running on the local host cannot reach them. It is only used if
+    the target is a local service or its address is allowed by
+    "agent.proxyAllow". "IP" and "Hostname" targets without port only
+    expose the specified UDP ports.
+  - mapTarget: whether the target is handled by the internal target
+    server. If true, the default, local services are proxied and
+    local Git repositories are served to the checks, which receive an
+    identifier pointing to the host gateway, like
+    "http://host.docker.internal:8080", instead of the original one.
+    If false, the checks receive the original identifier, which is
+    useful for the checks that must see it, like the ones that
+    validate the host name. It cannot be false for "Path",
+    "DockerImageArchive", "OCILayout" and "TerraformModule" targets.
+  - exclusions: list of exclusion rules that only apply to the
+    findings of the target. They support the same filters as the
+    exclusions of the report configuration, except "target", which is
+    set automatically to match exactly the identifier of the target.
+  - severity: minimum severity of the findings of the target required
+    to exit with error. Valid values are "critical", "high",
+    "medium", "low" and "info". If not specified, the severity of the
+    report configuration is used.
 
 For instance,
 
@@ -90,9 +221,325 @@ For instance,
 	    type: GitRepository
 	    options:
 	      branch: master
+	      env:
+	        SEMGREP_RULES: p/golang
 
 At least one target must be specified.
 
+Local "GitRepository" targets can be regular repositories, bare
+repositories or linked worktrees created with "git worktree add".
+Bare repositories are served to the checks as they are, without
+cloning them, so the "depth" option does not reduce the disk usage.
+Linked worktrees are served with the history of the repository they
+belong to and the HEAD of the worktree.
+
+The identifiers of the targets are normalized, so equivalent
+identifiers are scanned only once and appear in the same form in the
+reports. Paths are cleaned, so "./." becomes "."; the scheme and host
+of URLs are lower-cased and their default port is removed, as well
+as the trailing slash of "GitRepository" URLs and the root path of
+"WebAddress" URLs; hostnames and domain names are lower-cased and
+their trailing dot is removed; and IP addresses and ranges are
+written in their canonical notation. The identifiers of "IP",
+"IPRange" and "WebAddress" targets must be valid IP addresses, CIDR
+ranges and absolute URLs respectively. Keep in mind that exclusion
+target expressions are matched against the normalized identifiers.
+
+The tag of a "DockerImage" target can be a glob pattern, like
+"myapp:release-*". In that case, Lava lists the tags of the
+repository using the registry API and scans every matching tag. The
+syntax of the patterns is described in the documentation of the Go
+function "path.Match". The registry credentials are taken from
+"agent.registries" or, if none matches, from the Docker CLI
+configuration. Lava fails if a pattern does not match any tag. For
+instance,
+
+	targets:
+	  - identifier: example.com/myapp:release-*
+	    type: DockerImage
+
+The checks against "DockerImage" targets access the Docker daemon
+used by Lava to scan local images. If the daemon is reached through a
+Unix socket, the socket is mounted into the check containers. If it is
+a remote daemon reached through TCP (e.g. "DOCKER_HOST=tcp://...")
+the checks receive the address of the daemon in the DOCKER_HOST
+environment variable. Daemons listening on a loopback address are
+served through Lava's internal proxy on the same port, and the checks
+reach them through the hostname of the host gateway. Remote daemons
+protected with TLS are not supported, because the client certificates
+cannot be shared with the checks.
+
+The identifier of a "DockerImageArchive" target is the path of an
+image tarball, like those generated by "docker save" or by kaniko with
+the "--tar-path" flag. The identifier of an "OCILayout" target is the
+path of a directory with an OCI image layout, like those exported by
+BuildKit. These images are loaded into the container runtime before
+running the checks and are scanned as "DockerImage" targets, so images
+built in CI can be scanned without pushing them to a registry. The
+archive must contain exactly one image. Loading OCI layouts requires a
+container runtime that supports them, like Docker 25 or later. The
+loaded images are not removed after the scan. For instance,
+
+	targets:
+	  - identifier: image.tar
+	    type: DockerImageArchive
+
+The identifier of a "TerraformModule" target is the path of a
+directory with Terraform files. The directory must contain at least
+one "*.tf" file, ignoring the ".git" and ".terraform" directories. It
+is scanned as a "GitRepository" target by the checktypes that accept
+that asset type. The "terraform" field accepts the following
+properties:
+
+  - varFiles: list of variable definition files. The paths are
+    relative to the module directory and cannot point outside of it.
+  - workspaces: list of Terraform workspaces to scan.
+
+These properties are passed to the checks in the "terraform" option
+as "var_files" and "workspaces", so all the IaC checktypes share the
+same configuration. For instance,
+
+	targets:
+	  - identifier: ./infra
+	    type: TerraformModule
+	    terraform:
+	      varFiles:
+	        - env/prod.tfvars
+	      workspaces:
+	        - prod
+
+The "auth" field accepts the following properties:
+
+  - type: type of authentication. Valid values are "header" (a token
+    sent in an HTTP header), "basic" (HTTP basic authentication) and
+    "script" (a login script or recorded login flow). It is
+    mandatory.
+  - header: name of the HTTP header used by the "header" type. If not
+    specified, "Authorization" is used.
+  - token: token used by the "header" type.
+  - tokenFile: path of a file that contains the token. It is mutually
+    exclusive with "token".
+  - username: username used by the "basic" type.
+  - password: password used by the "basic" type.
+  - passwordFile: path of a file that contains the password. It is
+    mutually exclusive with "password".
+  - script: path of the login script or recorded login flow used by
+    the "script" type.
+
+Secrets can be read from environment variables using the "${VAR}"
+syntax or from files using "tokenFile" and "passwordFile". The
+trailing newline of secret files is removed.
+
+The authentication is passed to the checks using the following
+environment variables, so the secrets are not included in the
+reports: LAVA_AUTH_TYPE, LAVA_AUTH_HEADER, LAVA_AUTH_TOKEN,
+LAVA_AUTH_USERNAME, LAVA_AUTH_PASSWORD and LAVA_AUTH_SCRIPT (the
+contents of the script). The secrets are not included in the
+collected metrics either. For instance,
+
+	targets:
+	  - identifier: https://example.com
+	    type: WebAddress
+	    auth:
+	      type: header
+	      token: Bearer ${API_TOKEN}
+
+The "rateLimit" field accepts the following properties:
+
+  - requestsPerSecond: maximum number of requests per second.
+  - concurrency: maximum number of concurrent requests.
+  - enforce: if true, the internal proxy of Lava enforces the limit
+    for local targets (those pointing to a loopback address). The
+    proxy works at the connection level, so it limits the number of
+    new connections per second and the number of open connections.
+
+At least one of "requestsPerSecond" and "concurrency" must be
+specified. The limit is passed to the checks using the "rate_limit"
+check option, which contains the properties "requests_per_second",
+"concurrency" and "enforce". It is up to the checks to honor it. For
+instance,
+
+	targets:
+	  - identifier: http://localhost:8080
+	    type: WebAddress
+	    rateLimit:
+	      requestsPerSecond: 5
+	      concurrency: 2
+	      enforce: true
+
+# targetsFrom
+
+The optional "targetsFrom" field contains a list of sources of
+targets generated outside the configuration file, for instance, by
+previous steps of a CI pipeline. This way, pipelines do not need to
+template the configuration file. The targets read from these sources
+are appended to the "targets" list, which is not required if at least
+one target is read. Every source accepts the following properties:
+
+  - file: path of a file that contains the targets.
+  - env: name of an environment variable that contains the targets.
+  - type: asset type of the targets that do not specify one.
+
+Exactly one of "file" and "env" must be specified. The content of a
+source is a JSON or YAML list. Its elements can be targets, with the
+same format as the elements of the "targets" field, or target
+identifiers. In the latter case, "type" is mandatory. An empty source
+contains no targets. For instance, if the environment variable
+BUILT_IMAGES contains '["example.com/app:1.0", "example.com/api:1.0"]',
+the following configuration scans both images:
+
+	targetsFrom:
+	  - env: BUILT_IMAGES
+	    type: DockerImage
+
+# defaults
+
+The optional "defaults" field contains default settings of the
+targets indexed by asset type. They are merged into every target of
+the asset type, including the targets read from "targetsFrom" and
+the -targets flag of "lava scan", so common settings do not need to
+be repeated. The defaults of an asset type accept the following
+properties, with the same format as the corresponding properties of
+the targets: "options", "auth", "rateLimit" and "severity". The
+settings of the target take precedence over the defaults. Options
+whose values are maps, like "env", are merged key by key. For
+instance, the following configuration shallow clones all the Git
+repositories:
+
+	defaults:
+	  GitRepository:
+	    options:
+	      depth: 1
+
+# scope
+
+The optional "scope" field restricts the network targets that can be
+scanned. It prevents scanning third-party infrastructure by accident,
+for instance, when a configuration is shared. It accepts the
+following properties:
+
+  - cidrs: list of allowed network ranges in CIDR notation.
+  - domains: list of allowed domains. A domain also allows all its
+    subdomains.
+
+At least one CIDR or domain must be specified. When a scope is
+configured, the targets of type "IP", "IPRange", "Hostname" and
+"WebAddress" are checked against it before running any check. IP
+addresses and ranges must be contained in one of the CIDRs, while
+hostnames must match one of the domains. Lava fails with an error if
+any target is outside the scope. Targets of other types are not
+affected. Note that local targets, like "http://localhost:8080", and
+services are also checked, so their hosts must be allowed
+explicitly. For instance,
+
+	scope:
+	  cidrs:
+	    - 10.0.0.0/8
+	    - 127.0.0.0/8
+	  domains:
+	    - example.com
+	    - localhost
+
+# discovery
+
+The optional "discovery" field enables the discovery of the hosts
+behind the "DomainName" targets using DNS enumeration. For every
+domain, Lava looks up the domain itself, the configured subdomains,
+the subdomains found in the Certificate Transparency logs and the
+canonical names (CNAME records) of all of them. Every host with A or
+AAAA records is added as a "Hostname" target. It accepts the following
+properties:
+
+  - subdomains: list of subdomain names to look up, like "www" or
+    "api".
+  - wordlist: path of a file with subdomain names to look up, one per
+    line. Empty lines and lines starting with "#" are ignored.
+  - ctLogs: if true, the subdomains found in the Certificate
+    Transparency logs (crt.sh) are looked up.
+  - webAddresses: if true, a "WebAddress" target with the URL
+    "https://<host>" is also added for every discovered host.
+
+If a scope is configured, the discovered hosts outside the scope are
+ignored. For instance,
+
+	discovery:
+	  subdomains:
+	    - www
+	    - api
+	  ctLogs: true
+
+# services
+
+The "services" field contains the list of auxiliary containers, like
+the application under test or a database, that are started before
+running the checks. Every service is defined by the following
+properties:
+
+  - name: name of the service. The checks can reach the service
+    using its name as hostname. It is mandatory.
+  - image: container image of the service. If it is not present in
+    the system, it is pulled without registry authentication. It is
+    mandatory.
+  - command: overrides the default command of the image.
+  - env: map with the environment variables of the service.
+  - healthcheck: overrides the health check of the image. It accepts
+    the following properties: "test" (command in the Docker health
+    check format), "interval", "timeout" and "retries".
+  - startTimeout: maximum time to wait for the service to be healthy,
+    like "1m". If the image does not define a health check, Lava waits
+    for the container to be running. If not specified, "2m" is used.
+
+The services are attached to the Docker network configured in
+"agent.network". If it is not specified, Lava creates a dedicated
+network and attaches the checks to it. The services are removed when
+the scan finishes. The command "lava clean" removes the services and
+networks left behind by interrupted scans.
+
+The identifiers of the targets can reference the running services
+using Go templates. The ".Services" map contains the "Host" and "IP"
+of every service indexed by name. For instance,
+
+	targets:
+	  - identifier: http://{{.Services.app.Host}}:8080
+	    type: WebAddress
+	services:
+	  - name: app
+	    image: example/app:latest
+	    healthcheck:
+	      test: ["CMD", "curl", "-f", "http://localhost:8080"]
+
+# stages
+
+The "stages" field splits the scan into stages that run in dependency
+order. Every stage accepts the following properties:
+
+  - name: name of the stage. It is required and must be unique.
+  - checktypes: names of the checktypes whose checks run in the
+    stage. It is required. A checktype can only belong to one stage.
+  - dependsOn: names of the stages that must finish before the stage
+    starts. Dependency cycles are not allowed.
+  - outputType: asset type of the targets generated by the stage. If
+    specified, the affected resources of the findings of the stage
+    are passed as targets of that type to the stages that depend on
+    it, along with the targets of the configuration. Generated
+    targets outside of "scope" or unreachable are ignored.
+
+The checktypes that do not belong to any stage run in an initial
+stage. The findings of all the stages are merged into a single
+report. For instance, the following configuration runs a discovery
+check whose findings are scanned by a DAST check:
+
+	stages:
+	  - name: discovery
+	    checktypes:
+	      - vulcan-discovery
+	    outputType: WebAddress
+	  - name: dast
+	    checktypes:
+	      - vulcan-zap
+	    dependsOn:
+	      - discovery
+
 # agent
 
 The "agent" field contains the configuration passed to the Vulcan
@@ -101,14 +548,159 @@ properties:
 
   - pullPolicy: policy used to decide when to pull a required
     container image. Valid values are "Always", "IfNotPresent" and
-    "Never". If not specified, "IfNotPresent" is used.
+    "Never". If not specified, "IfNotPresent" is used. With
+    "IfNotPresent", Lava pulls the missing images before running the
+    checks and reports the progress of the pulls (layers, bytes and
+    estimated remaining time).
   - parallel: maximum number of checks that can run in parallel. If
-    not specified, this limit is set to one.
+    not specified, this limit is set to one. If set to "auto", Lava
+    chooses the limit based on the CPUs and memory available to the
+    container runtime and the asset types of the checks to run.
+    Checks against Git repositories, paths and container images are
+    considered more expensive than checks against network targets.
+    The chosen limit is never greater than 16 nor the number of
+    checks.
   - vars: map with the environment variables passed to the executed
-    checktypes.
+    checktypes. Before running the scan, Lava checks that the variables
+    required by the selected checktypes are set and not empty. If any
+    of them is missing, Lava exits with error listing the missing
+    variables of every checktype. The -var flag of "lava scan"
+    overrides and extends these variables.
   - registries: configuration of the required container registries. It
     requires the following properties: "server", "username" and
     "password".
+  - registryMirrors: list of rules used to pull the check images from
+    registry mirrors or proxies, like Artifactory or Harbor, instead
+    of their original registries. Every rule requires the properties
+    "registry" (registry of the images, "docker.io" for Docker Hub or
+    "*" for any registry) and "mirror" (registry the images are
+    pulled from, optionally followed by a path prefix). The first
+    rule matching the registry of an image is applied. For instance,
+    with the mirror "artifactory.example.com/dockerhub", the image
+    "vulcansec/vulcan-nmap:1" is pulled as
+    "artifactory.example.com/dockerhub/vulcansec/vulcan-nmap:1". The
+    credentials of the mirrors are configured in "registries".
+  - tmpDir: directory used to store temporary files, like the local
+    repositories and paths served to the checks. It takes precedence
+    over the LAVA_TMPDIR environment variable. If not specified, the
+    default directory for temporary files of the system is used. Lava
+    fails early if there is not enough free space to serve a target.
+  - network: name of an existing Docker network the checks are
+    attached to. For instance, the network of a Docker Compose
+    project where the application under test lives. This allows to
+    scan containerized applications without publishing their ports in
+    the host. The agent and the target server listen on the gateway of
+    this network. In dual-stack networks, the IPv4 gateway is used.
+    IPv6-only networks are also supported. If not specified, the
+    default bridge network is used.
+  - platform: platform of the check images with the format
+    "os/arch[/variant]", like "linux/amd64". Lava pulls the check
+    images for this platform honoring "pullPolicy" and creates the
+    check containers from them. It allows to run the checks under
+    emulation, for instance on Apple Silicon when the arm64 variant of
+    a check image lacks some tools. If not specified, the platform of
+    the container runtime is used.
+  - hangTimeout: maximum time a check can run without sending any
+    state update, like "10m". Checks exceeding it are killed and
+    reported with the status "HUNG". If not specified, hang detection
+    is disabled.
+  - timeout: default timeout of the checks, like "30m". It is used
+    when the checktype does not define its own timeout. If not
+    specified, "3m" is used.
+  - maxNoMsgsInterval: maximum time the agent waits for new checks to
+    run before stopping. If not specified, "5s" is used.
+  - registryBackoff: configuration of the retries of the requests sent
+    to the container registries. It accepts the following properties:
+    "maxRetries" (maximum number of retries, 5 by default) and
+    "interval" (initial time between retries, "5s" by default).
+  - pullRetry: configuration of the retries of the checks that fail
+    because their image could not be pulled, for instance due to
+    registry rate limits or stale manifests. It accepts the following
+    properties: "maxRetries" (maximum number of retries, 2 by
+    default, 0 disables the retries), "interval" (time before the
+    first retry, which is doubled after every retry, "30s" by
+    default) and "mirror" (registry the images are pulled from when
+    retrying, like "mirror.example.com"). If the retries are
+    exhausted, the checks are reported as failed with the cause of
+    the pull failure. If "mirror" is set, the images that are not
+    available in their registry do not abort the scan as long as
+    they are available in the mirror.
+  - volumes: list of mappings between the paths of the container
+    Lava is running in and the paths of the host of the container
+    runtime. Every mapping requires the properties "host" and
+    "container", which must be absolute paths. They are used to
+    translate the paths bind-mounted into the check containers, like
+    the Docker socket shared with the checks that scan Docker images.
+  - imageCache: configuration of the cache of the results of the
+    checks against local Docker images. It accepts the following
+    properties: "dir" (directory where the results are stored) and
+    "force" (whether the cached results are ignored, false by
+    default). If "dir" is set, Lava records the digest of every
+    scanned image and reuses the results of the checks whose image,
+    checktype image and options did not change, so rebuilds that do
+    not modify the image do not pay the full cost of the scan. The
+    checktype images are compared by digest, so a new checktype image
+    pushed with the same tag invalidates the cached results. The
+    checks whose checktype image digest cannot be resolved are not
+    cached. Only the results of the finished checks are cached. The
+    -force flag of "lava scan" takes precedence over "force".
+  - state: configuration of the persistence of the state of the
+    scan. It accepts the following properties: "file" (path of the
+    state file) and "resume" (whether the reports stored in the state
+    file are reused, false by default). While the scan runs, the
+    reports of the finished checks are appended to the state file. If
+    the scan is interrupted, it can be resumed and only the checks
+    that did not finish are run again. The state file is removed when
+    the scan completes. If "file" is not set, "lava scan" stores the
+    state file in the user cache directory. The -resume flag of "lava
+    scan" takes precedence over "resume".
+  - watchdog: configuration of the monitoring of the resources of
+    the host. It accepts the following properties: "cpu", "memory"
+    and "disk" (maximum usage in percentage, like 90) and "action"
+    (what to do when a threshold is exceeded). The disk usage is the
+    usage of the file system of "tmpDir". If "action" is "throttle",
+    the default, Lava waits until the usage is below the thresholds
+    before launching new checks. At least one check is always
+    running, so the scan progresses. If "action" is "abort", the
+    checks that have not been launched yet are reported as failed.
+    The CPU and memory usage are only monitored on Linux. If no
+    threshold is specified, the watchdog is disabled.
+  - proxyAllow: list of IP addresses and CIDR ranges, like
+    "192.168.1.10" or "172.18.0.0/16", of the non-loopback services
+    that are proxied to the checks. By default, Lava only proxies the
+    services listening on a loopback address, so the targets bound to
+    a LAN address or to the network of a Docker Compose project are
+    scanned directly. The targets whose address is in one of these
+    ranges are served through the internal proxy instead, like
+    loopback services, which is needed when the checks cannot reach
+    them directly.
+
+Durations must be at least one second.
+
+Lava detects when it runs inside a container. If the Docker socket
+of the host is mounted into the container (Docker-out-of-Docker),
+the paths of the container may differ from the paths of the host. In
+that case, the mounted paths must be declared in "agent.volumes".
+Local Git repositories and paths are served to the checks through
+the network, so they do not need to be mapped. The checks reach Lava
+through the gateway of the Docker network, so the Lava container must
+share the network namespace of the host (e.g. "docker run --network
+host"). If Lava uses a remote Docker daemon (Docker-in-Docker), the
+checks against local Docker images reach it through the network. The
+"lava doctor" command reports the detected mode. For instance, if
+the Docker socket of a rootless Docker daemon is mounted as follows:
+
+	docker run --network host \
+	  -v /run/user/1000/docker.sock:/var/run/docker.sock \
+	  -v "$PWD:/workspace" -w /workspace \
+	  <lava image> scan
+
+The configuration file must contain:
+
+	agent:
+	  volumes:
+	    - host: /run/user/1000/docker.sock
+	      container: /var/run/docker.sock
 
 The sample below is a full agent configuration:
 
@@ -138,18 +730,188 @@ the following properties.
   - show: minimum severity required to show a finding. Valid values
     are "critical", "high", "medium", "low" and "info". If not
     specified, the severity value is used.
-  - format: output format. Valid values are "human" and "json". If not
-    specified, "human" is used.
+  - format: output format. Valid values are "human", "plain",
+    "json", "csv" and "template". If not specified, "human" is used.
+    The "plain" format renders the same information as "human" as
+    linear text, with one "label: value" line per element and
+    without colors, tables nor decorative characters. It is meant to
+    be used with screen readers and to paste the report into tools
+    that do not preserve the layout of the text, like ticketing
+    systems. The "csv" format writes one row per finding, which is
+    convenient to track the findings in a spreadsheet. Every finding
+    of the "json" output includes the "check_key" field, a stable
+    identifier of the check derived from the checktype image, the
+    target and the check options. Unlike the check ID, it does not
+    change between scans, so it can be used to correlate the same
+    check across runs. The findings that reference a CWE (Common
+    Weakness Enumeration) also include the "cwe" field with the
+    "id", "name" and "description" of the weakness, which are shown
+    as "CWE-79: Cross-site Scripting" in the "human" and "plain"
+    formats and are available to the "csv" columns and the templates.
+    The name and description are only available for the most common
+    weaknesses.
+    The structure of the "json" output is defined by the Go package
+    "github.com/adevinta/lava/report", which also provides functions
+    to load it.
+  - columns: list of columns of the CSV output. Valid values are
+    "target", "checktype", "severity", "score", "summary",
+    "affected_resource", "fingerprint", "cwe", "cwe_name",
+    "description", "details", "impact_details", "recommendations",
+    "references", "labels", "due", "overdue", "check_key", "parent"
+    and "slug". The column "cwe" contains the CWE identifier of the
+    finding and "cwe_name" its normalized name, like "CWE-79:
+    Cross-site Scripting". The columns "due" and "overdue" are only
+    filled if an SLA is configured for the severity of the finding.
+    The column "check_key" contains the stable identifier of the
+    check that reported the finding. The column "parent" contains the
+    summary of the aggregate finding that grouped the finding, if
+    any. The column "slug" contains the slug of the finding. Fields
+    with multiple values, like "recommendations", are separated by
+    new lines. If not specified, "target", "checktype", "severity",
+    "score", "summary", "affected_resource", "fingerprint" and "slug"
+    are used.
+  - template: path of the Go template used to render the output when
+    the format is "template". It is required by that format. The
+    template is executed with a value that has the following fields:
+    "Vulnerabilities" (list of findings, with the same fields as the
+    JSON output), "Summary" (with the fields "Count", number of
+    findings per severity, "Total", "Excluded", "Omitted", "Truncated",
+    "Coverage", list of targets with the coverage of every control
+    family, and "Grade"), "Status" (list of checks with the fields
+    "Key", "Checktype", "Target", "Status" and "Reason"),
+    "StaleExclusions" (list of exclusions with the fields of the
+    exclusion rules and "LastMatched", nil if "history" is not
+    configured), "Warnings" and "Metadata" (nil if "metadata" is not
+    enabled). Besides the built-in functions of Go templates,
+    the functions "json", "csv", "join", "upper", "lower" and "trim"
+    are available. For instance, the following template generates a
+    CSV file:
+
+	{{csv "target" "severity" "summary"}}
+	{{range .Vulnerabilities -}}
+	{{csv .CheckData.Target .Severity.String .Summary}}
+	{{end -}}
+
+  - theme: color theme of the human-readable output. Valid values
+    are "default", "high-contrast" and "monochrome". If not specified,
+    "default" is used. The "monochrome" theme does not use colors,
+    only bold, underlined and reversed text. Regardless of the theme,
+    colors are disabled if the NO_COLOR environment variable is set,
+    the terminal is "dumb" or the output is not a terminal. For more
+    details, use "lava help environment".
+  - width: width in columns of the human-readable output. Long
+    descriptions are wrapped to fit in it. Resources are rendered as
+    tables when the width is at least 100 columns and as key-value
+    lists otherwise. If not specified, the width of the terminal is
+    used. When the output is written to a file and no width is
+    specified, text is not wrapped and resources are rendered as
+    tables. It can be overridden with the -width flag of "lava
+    scan".
   - output: path of the output file. If not specified, stdout is used.
   - metrics: path of the file where the metrics report will be
     written. If not specified, then the metrics report is not
     generated. For more details, use "lava help metrics".
+  - upload: configuration used to upload the output and metrics
+    files to cloud storage. It accepts the following properties:
+    "sse" (server-side encryption algorithm, only honored by S3) and
+    "kmsKey" (KMS key used to encrypt the uploaded objects).
+  - encryption: configuration used to encrypt the output files. It
+    accepts either the property "age" (list of age recipients) or the
+    property "openpgp" (list of OpenPGP recipients, like key IDs,
+    fingerprints or email addresses). The output files, including
+    the one specified by "fullOutput", are encrypted with the "age"
+    and "gpg" commands respectively, so they must be installed. In
+    the case of OpenPGP, the public keys of the recipients must be in
+    the keyring of the user. It requires the "output" property, even
+    if "fullOutput" is specified.
   - exclusions: list of rules that define what findings should be
     excluded from the report. It allows to ignore findings because of
     accepted risks, false positives, etc.
   - errorOnStaleExclusions: boolean specifying whether Lava should
     exit with error when stale exclusions are detected. If not
     specified, the default value is false.
+  - exclusionExpiry: configuration of the warnings about the
+    exclusions that are about to expire. It accepts the following
+    properties: "warnDays" (number of days before the expiration
+    date of an exclusion when Lava starts warning about it) and
+    "errorOnExpiring" (whether Lava should exit with error when an
+    exclusion expires within "warnDays" days, false by default). The
+    warnings are shown in the "WARNINGS" section of the report, so
+    accepted risks can be reviewed before their exclusions expire
+    and the excluded findings start failing the scan. If "warnDays"
+    is not specified, no warnings are emitted.
+  - requireExclusionMetadata: boolean specifying whether every
+    exclusion, including the exclusions declared under the targets,
+    must have a description, an expiration date and an owner. If an
+    exclusion lacks any of them, the configuration is not valid. It
+    ensures that accepted risks are documented and time-boxed. If not
+    specified, the default value is false.
+  - exclusionKeys: list of public keys allowed to sign exclusions. If
+    specified, the exclusions without a valid signature by any of
+    these keys are ignored and reported as warnings. See below.
+  - errorOnInconclusive: boolean specifying whether Lava should exit
+    with error when a check is inconclusive. The reason of the
+    inconclusive checks is shown in the status section of the report.
+    If not specified, the default value is true.
+  - history: path of the history database. If specified, the summary
+    of the scan, the fingerprints of the non-excluded findings and the
+    exclusions that matched any finding are appended to it. The "lava
+    history" command uses this database to show trends and
+    remediation times. The report shows when every stale exclusion
+    last matched a finding, or "never" if it did not match any
+    finding of the recorded scans. An exclusion is identified by its
+    "target", "resource", "fingerprint" and "summary", so changing
+    other properties keeps its history. If not specified, the results
+    are not recorded.
+  - grade: configuration of the security grade. See below.
+  - sla: configuration of the deadlines to fix the findings. See
+    below.
+  - advisory: list of checktypes whose results are advisory. The
+    findings of advisory checktypes are reported and counted
+    separately, and they never affect the exit code. Similarly, the
+    advisory checks that fail or are inconclusive do not make Lava
+    exit with error. It allows to quarantine flaky checktypes without
+    removing them from the scan.
+  - severityRules: list of rules that cap and floor the severity of
+    the findings of specific checktypes. See below.
+  - metadata: boolean specifying whether the report includes the
+    metadata of the scan, so archived reports are self-describing.
+    The metadata contains the Lava version, the path and the SHA-256
+    hash of the configuration file, the scan ID, the start and end
+    times of the scan, the operating system and architecture of the
+    host, the container runtime and the labels passed with the -label
+    flag of "lava scan". If enabled, the "json" output is an object
+    with the fields "metadata" and "findings" instead of a list of
+    findings, and the "human" and "plain" formats show it at the
+    beginning of the report. The "csv" format does not include it. If
+    not specified, the default value is false.
+  - sanitize: boolean specifying whether the ANSI escape sequences,
+    like color codes, and the control characters embedded by the
+    checks in the text fields of the findings are removed. New lines
+    and tabs are kept. Otherwise, they could corrupt the rendered
+    report and the logs. The fingerprints of the findings are never
+    modified. If not specified, the default value is true.
+  - maxFindings: maximum number of findings included in the report.
+    The findings with higher severity are kept. The summary shows the
+    number of omitted findings. Zero means no limit. If not
+    specified, the default value is 0.
+  - maxDetailSize: maximum size in bytes of the description, details
+    and impact details of every finding. Longer texts are truncated
+    and marked with "[truncated]". Zero means no limit. If not
+    specified, the default value is 0.
+  - fullOutput: path of the file where the report is written in JSON
+    format without applying "maxFindings" and "maxDetailSize". It
+    allows to keep every finding while the primary output stays
+    small. Like "output", it accepts cloud storage URLs and it is
+    encrypted if "encryption" is specified. If not specified, the
+    full report is not written.
+
+Every finding has a slug, a short identifier derived from its
+fingerprint, checktype and target. The slug is stable across scans
+and is included in all the output formats, so it can be used to
+reference a specific finding in tickets or as an anchor in documents
+rendered with templates. The "lava report show" command looks up a
+finding by slug in a saved report.
 
 The sample below is a full report configuration:
 
@@ -177,9 +939,159 @@ The exclusion rules support the following filters:
   - expiration: is the date on which the exclusion becomes inactive.
     The format is YYYY/MM/DD.
 
+Besides the filters, exclusion rules support the following metadata:
+
+  - description: description of the exclusion. For instance, why the
+    risk is accepted.
+  - owner: person or team accountable for the exclusion.
+  - signature: signature of the exclusion. It is only checked if the
+    "exclusionKeys" property is specified.
+
+Exclusion signatures allow regulated teams to enforce an approval
+workflow, so findings cannot be excluded without the consent of the
+owners of the signing keys. The keys are Ed25519 public keys encoded
+in base64. Both raw keys and DER-encoded keys, like the body of a
+PEM "PUBLIC KEY" block, are supported. The signature is the
+base64-encoded Ed25519 signature of the compact JSON encoding of the
+exclusion with the fields "target", "resource", "fingerprint",
+"summary", "expiration", "description" and "owner" in that order.
+Unset fields are encoded as empty strings and HTML characters are not
+escaped. Exclusions declared under a target are signed with the
+"target" field set to the quoted identifier of the target anchored
+with "^" and "$", for instance "^example\\.com$", so they cannot be
+moved to another target. For instance, the following commands sign an
+exclusion with OpenSSL:
+
+	printf '%s' '{"target":"","resource":"","fingerprint":"",'\
+	'"summary":"Secret Leaked in Git Repository","expiration":"2024/07/05",'\
+	'"description":"Ignore test certificates.","owner":"security-team"}' > excl.json
+	openssl pkeyutl -sign -inkey key.pem -rawin -in excl.json | base64 -w0
+
 A finding is excluded if it matches all the filters of an exclusion
 rule.
 
+Exclusions and severity thresholds can also be declared under a
+target. In that case, they only apply to the findings of that target.
+For instance,
+
+	targets:
+	  - identifier: .
+	    type: GitRepository
+	    severity: medium
+	    exclusions:
+	      - description: Ignore test certificates.
+	        summary: 'Secret Leaked in Git Repository'
+	        resource: '/testdata/certs/'
+
+The reports sent by the checks are validated against the
+vulcan-report schema. If a report is not valid, for instance because
+a finding has no summary, its findings are discarded and the check is
+reported with the status "MALFORMED". The scan continues, but Lava
+exits with error, as it does for any check that does not finish
+successfully.
+
+The security grade summarizes the results of the scan in a score
+between 0 and 100 and a letter between "A" and "F". Every
+non-excluded finding subtracts the weight of its severity from the
+score. The grade configuration supports the following properties:
+
+  - enabled: boolean specifying whether the security grade is
+    computed. If not specified, the default value is false.
+  - weights: map with the number of points subtracted for every
+    finding of a given severity. The default weights are critical:
+    40, high: 20, medium: 5, low: 1 and info: 0.
+  - controls: map with the multipliers applied to the weight of the
+    findings with a given label. Checktypes use labels to identify
+    the control family of a finding (e.g. "secret", "dependency",
+    "iac"). If several labels match, the highest multiplier is used.
+  - badge: path of the file where a badge-friendly JSON document with
+    the grade is written. It follows the shields.io endpoint schema.
+
+When enabled, the grade is shown in the summary of the report and
+included in the metrics file. For instance,
+
+	report:
+	  grade:
+	    enabled: true
+	    weights:
+	      medium: 10
+	    controls:
+	      secret: 2
+	    badge: badge.json
+
+The SLA (service level agreement) configuration defines how long the
+findings can stay open. The age of a finding is the time since the
+first scan recorded in the history database that detected it, so the
+"history" property is required. Findings not present in the history
+database are considered detected in the current scan. If a finding
+is fixed and detected again later, its age is reset. The SLA
+configuration supports the following properties:
+
+  - deadlines: map with the maximum time allowed to fix the findings
+    of a given severity. Durations accept the unit "d" for days
+    besides the units supported by Go durations, like "h" for hours.
+    Findings of severities with no deadline are not tracked.
+  - errorOnBreach: minimum severity of the overdue findings required
+    to exit with error. If not specified, overdue findings do not
+    cause Lava to exit with error.
+
+The deadline of every tracked finding is shown in the report, along
+with the number of overdue findings. For instance,
+
+	report:
+	  history: history.jsonl
+	  sla:
+	    deadlines:
+	      critical: 7d
+	      high: 30d
+	    errorOnBreach: critical
+
+Some checktypes report aggregate findings, that is, findings that
+group other findings. For instance, a vulnerable dependency with
+several known vulnerabilities. Aggregate findings are replaced by the
+findings they group, which are shown, counted and matched against the
+exclusion rules like any other finding. The summary of the aggregate
+finding is reported as their parent. The findings that do not
+specify an affected resource inherit the one of their parent.
+
+Checktypes are calibrated differently and their criteria may not
+match the criteria of an organization. Severity rules adjust the
+severity of the findings of a checktype after parsing the results of
+the checks and before applying any threshold. Every rule supports the
+following properties:
+
+  - checktype: name of the checktype the rule applies to. There can
+    be only one rule per checktype.
+  - max: maximum severity of the findings. Findings with a higher
+    severity are lowered to it.
+  - min: minimum severity of the findings. Findings with a lower
+    severity are raised to it.
+
+For instance, the following configuration reports the findings of
+"vulcan-semgrep" with a severity of at most medium and the findings
+of "vulcan-gitleaks" with a severity of at least high:
+
+	report:
+	  severityRules:
+	    - checktype: vulcan-semgrep
+	      max: medium
+	    - checktype: vulcan-gitleaks
+	      min: high
+
+The "output" and "metrics" properties also accept Amazon S3
+(s3://bucket/key) and Google Cloud Storage (gs://bucket/object)
+URLs. In that case, the files are uploaded using the "aws" and
+"gcloud" commands respectively, so the ambient cloud credentials are
+used. Valid values for "upload.sse" are "AES256", "aws:kms" and
+"aws:kms:dsse". For instance,
+
+	report:
+	  output: s3://example-bucket/lava/report.json
+	  metrics: s3://example-bucket/lava/metrics.json
+	  upload:
+	    sse: aws:kms
+	    kmsKey: arn:aws:kms:eu-west-1:123456789012:key/example
+
 It is possible to provide a human-friendly description of an exclusion
 rule using its "description" property.
 
@@ -190,6 +1102,33 @@ values are "debug", "info", "warn" and "error". If not specified,
 "info" is used. For instance,
 
 	log: error
+
+# logFormat
+
+The "logFormat" field describes the format of the logs generated by
+the Lava command. Valid values are "text" and "json". If not
+specified, "text" is used. The "json" format emits one JSON object per
+line, which includes attributes like the scan ID, the check ID and the
+target. This is useful to ingest the logs into log aggregation
+systems. For instance,
+
+	logFormat: json
+
+# logFile
+
+The "logFile" field specifies the path of a file where the logs are
+written in addition to stderr. Debug logs are always written to this
+file, independently of the "log" field. This allows to archive
+verbose logs without polluting the console. For instance,
+
+	logFile: lava.log
+
+When the log file would exceed the size in bytes specified by the
+"logFileMaxSize" field, it is renamed adding the suffix ".1" and a new
+log file is created. Only one rotated file is kept. If not specified,
+the maximum size is 100 MiB. A value of 0 disables rotation.
+
+	logFileMaxSize: 10485760
 	`,
 }
 
@@ -214,6 +1153,9 @@ example.
 	  "checktype_urls": [
 	    "https://example.com/checktypes.json"
 	  ],
+	  "checktype_digests": {
+	    "https://example.com/checktypes.json": "sha256:e028377a26b427990030a465787253271e4084be012c4e229dabe4b9acecf5e3"
+	  },
 	  "checktypes": {
 	    "vulcan-example": {
 	      "name": "vulcan-example",
@@ -222,9 +1164,25 @@ example.
 	      "assets": ["GitRepository"]
 	    }
 	  },
+	  "controls": ["sast"],
 	  "lava_version": "v0.4.2",
+	  "scan_id": "5f2b1b6e-4f5a-4a8e-9d1c-3b7f0e2c9a41",
 	  "config_version": "v0.0.0",
 	  "duration": 10.986237086,
+	  "catalog_fetch_duration": 0.412503377,
+	  "agent_startup_duration": 0.053913206,
+	  "image_pull_durations": {
+	    "vulcan-example:latest": 4.190320443
+	  },
+	  "check_timings": {
+	    "0c4a2b1e-5d0f-4c1b-8a2e-6f3d9b7e1a20": {
+	      "key": "0d5c4f5ad2d4d4a5e3b9a1f0c6e7b8d9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5",
+	      "checktype": "vulcan-example",
+	      "target": ".",
+	      "queue_wait": 0,
+	      "pull": 4.190320443
+	    }
+	  },
 	  "excluded_vulnerability_count": 3,
 	  "exclusion_count": 2,
 	  "exit_code": 0,
@@ -246,10 +1204,46 @@ example.
 
 A Lava metrics file contains the following data:
 
+  - agent_startup_duration: Time in seconds from the start of the
+    agent until the first check is run. If the scan has stages or
+    retries checks, the startup times of all the agents are added
+    up.
+  - auto_parallel: Maximum number of checks that can run in parallel
+    chosen by Lava. Only present if "agent.parallel" is "auto".
+  - catalog_fetch_duration: Time in seconds spent fetching and
+    merging the checktype catalogs.
+  - check_timings: Timing metrics of every check indexed by check ID.
+    They include the stable key of the check (key), the checktype,
+    the target, the time in seconds the check waited in the queue
+    after the first check was run (queue_wait) and the time in
+    seconds spent pulling its image (pull). The timings of the checks
+    retried because their image could not be pulled are the ones of
+    the last attempt.
+  - check_resources: Resource usage of every check indexed by check
+    ID. It includes the checktype, the maximum memory in bytes used
+    by the check container (max_memory) and the CPU time in seconds
+    it consumed (cpu_seconds). It is collected from the container
+    stats reported by the container runtime, so the checks that
+    finish very quickly may not be present.
+  - checktype_digests: SHA-256 digests of the retrieved checktype
+    catalogs indexed by URL.
   - checktype_urls: List of URLs pointing to checktype catalogs.
   - checktypes: Checktype catalog used during the scan. It is computed
     by merging all the checktype catalogs specified in checktype_urls.
+  - control_coverage: Coverage status of every control family
+    ("covered", "failed" or "not covered") indexed by target. Only
+    present if the catalog labels its checktypes with control
+    families.
+  - controls: Security control families covered by the checktypes
+    of the catalog used during the scan, according to their labels.
   - lava_version: Version of the Lava command.
+  - scan_id: Unique ID of the scan. It is also set as the
+    "lava.scan-id" label of the containers created during the scan
+    and exposed to the checks through the LAVA_SCAN_ID environment
+    variable.
+  - advisory_vulnerability_count: Number of vulnerabilities found by
+    advisory checktypes grouped by severity. Only present if advisory
+    checktypes are configured.
   - config_version: Minimum version of Lava required by the
     configuration file.
   - duration: Duration of the scan.
@@ -257,11 +1251,36 @@ A Lava metrics file contains the following data:
     due to matching one or more exclusion rules.
   - exclusion_count: Number of exclusion rules.
   - exit_code: Exit code returned by the Lava command.
+  - omitted_vulnerability_count: Number of vulnerabilities not
+    included in the report due to the "maxFindings" limit. Only
+    present if the report is truncated.
+  - overdue_vulnerability_count: Number of vulnerabilities not fixed
+    within their deadline grouped by severity. Only present if an SLA
+    is configured.
+  - grade: Security grade of the scan. Only present if the security
+    grade is enabled.
+  - image_pull_durations: Time in seconds spent pulling every check
+    image. If an image is pulled several times, the longest pull is
+    reported.
+  - pull_retries: Number of checks run again because their image
+    could not be pulled.
+  - watchdog: Peak usage in percentage of the CPU (max_cpu), memory
+    (max_memory) and disk (max_disk) of the host, and number of
+    checks throttled (throttled_checks) and not run
+    (aborted_checks) by the watchdog. Only present if
+    "agent.watchdog" is configured.
   - severity: Minimum severity required to report a finding.
   - start_time: When the scan started.
   - targets: List of targets to scan.
+  - truncated_text_count: Number of texts of the vulnerabilities
+    truncated due to the "maxDetailSize" limit. Only present if the
+    report is truncated.
   - vulnerability_count: Number of vulnerabilities grouped by
     severity.
+  - warnings: Warnings logged during the run. Every warning contains
+    its message, its attributes and the number of times it was
+    logged. The human-readable report also lists them in the
+    "WARNINGS" section at the end of the output.
 	`,
 }
 
@@ -288,6 +1307,12 @@ General-purpose environment variables:
 		used. The values "DockerdRancherDesktop" and
 		"DockerdPodmanDesktop" are also valid, but they are
 		considered experimental.
+	LAVA_TMPDIR
+		Directory used to store temporary files, like the
+		local repositories and paths served to the checks. It
+		is overridden by the "agent.tmpDir" configuration
+		setting. If not specified, the default directory for
+		temporary files of the system is used.
 	`,
 }
 
@@ -345,6 +1370,10 @@ example:
 	                "REQUIRED_VARIABLE_1",
 	                "REQUIRED_VARIABLE_2"
 	            ],
+	            "labels": [
+	                "sast",
+	                "secrets"
+	            ],
 	            "assets": [
 	                "GitRepository"
 	            ]
@@ -362,11 +1391,34 @@ A checktype catalog entry specifies the following parameters:
     are defined in the checktype's manifest.toml file.
   - assets: Asset types accepted as target by the check. They are
     defined in the checktype's manifest.toml file.
+  - labels: Security control families covered by the checktype. The
+    supported families are "sast", "sca", "dast" and "secrets". Other
+    labels are ignored. The -controls flag of "lava scan" allows to
+    run only the checktypes labeled with specific families.
   - options:
     - depth: Number of commits to fetch when the asset type is a git
-      repository.
+      repository. Local repositories are shallow cloned with the same
+      depth before being served to the check.
     - branch: Branch to check out when the asset type is a git
       repository.
+    - subdir: Subdirectory of a local git repository, relative to its
+      root. Only the contents of the subdirectory at HEAD are read
+      from the repository and served to the check, as a repository
+      with a single commit whose root is the subdirectory. It keeps
+      disk usage and startup time bounded with huge monorepos. The
+      "depth" option is ignored when "subdir" is set.
+    - symlinks: Policy applied to the symbolic links found under a
+      "Path" target. It can be "skip", "follow" or "error". "skip",
+      the default, ignores symbolic links. "follow" follows the
+      symbolic links that point to files or directories under the
+      target path, ignoring dangling links, links that point outside
+      of the target path and links that would produce a loop.
+      "error" makes the scan fail if the target path contains
+      symbolic links. If the target path itself is a symbolic link,
+      it is always resolved.
+    - env: Map of environment variables set in the check container.
+      The variables set in the "env" option of the target take
+      precedence. The values must be strings.
     - Others options defined in the checktype's manifest.toml file of
       the check.
 
@@ -380,6 +1432,9 @@ checktype includes a manifest.toml file, which contains all the
 information required to configure a check.
 
 Users may also develop their own checktypes. For more details, visit
-https://adevinta.github.io/vulcan-docs/developing-checks.
+https://adevinta.github.io/vulcan-docs/developing-checks. The "lava
+new-checktype" command creates the skeleton of a new checktype that
+can be run with "lava run". For more details, use "lava help
+new-checktype".
 	`,
 }
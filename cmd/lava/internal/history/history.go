@@ -0,0 +1,147 @@
+// Copyright 2024 Adevinta
+
+// Package history implements the history command.
+package history
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adevinta/lava/cmd/lava/internal/base"
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/history"
+)
+
+// CmdHistory represents the history command.
+var CmdHistory = &base.Command{
+	UsageLine: "history [flags] trend | mttr",
+	Short:     "show scan history",
+	Long: `
+Show the evolution of the findings using the history database.
+
+The history database is enabled with the "report.history" field of
+the configuration file. When it is enabled, every scan appends its
+summary and the fingerprints of the findings to the database. For more
+details, use "lava help lava.yaml".
+
+History accepts one of the following subcommands:
+
+  - trend: shows, for every target and scan, the total number of
+    findings, the number of new findings (not present in the previous
+    scan of the target) and the number of fixed findings (present in
+    the previous scan of the target but not anymore).
+  - mttr: shows, for every target, the number of fixed findings and
+    the mean time to remediate them.
+
+The -c flag allows to specify a configuration file. By default, "lava
+history" looks for a configuration file with the name "lava.yaml" in
+the current directory.
+
+The -db flag allows to specify the path of the history database. It
+takes precedence over the configuration file.
+
+The -target flag allows to specify a regular expression that filters
+the targets shown.
+	`,
+}
+
+// Command-line flags.
+var (
+	historyC      string // -c flag
+	historyDB     string // -db flag
+	historyTarget string // -target flag
+)
+
+func init() {
+	CmdHistory.Run = runHistory // Break initialization cycle.
+	CmdHistory.Flag.StringVar(&historyC, "c", "lava.yaml", "config file")
+	CmdHistory.Flag.StringVar(&historyDB, "db", "", "history database")
+	CmdHistory.Flag.StringVar(&historyTarget, "target", "", "target regular expression")
+}
+
+// osStdout is used by tests to capture the output of the command.
+var osStdout io.Writer = os.Stdout
+
+// runHistory is the entry point of the history command.
+func runHistory(args []string) error {
+	if len(args) != 1 {
+		return errors.New("invalid number of arguments")
+	}
+
+	re, err := regexp.Compile(historyTarget)
+	if err != nil {
+		return fmt.Errorf("invalid target regexp: %w", err)
+	}
+
+	dbPath := historyDB
+	if dbPath == "" {
+		cfg, err := config.ParseFile(historyC)
+		if err != nil {
+			return fmt.Errorf("parse config file: %w", err)
+		}
+		dbPath = config.Get(cfg.ReportConfig.History)
+	}
+	if dbPath == "" {
+		return errors.New("history database not configured")
+	}
+
+	entries, err := history.Open(dbPath).Entries()
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+
+	switch args[0] {
+	case "trend":
+		return printTrends(osStdout, history.Trends(entries), re)
+	case "mttr":
+		return printRemediations(osStdout, history.Remediations(entries), re)
+	}
+	return fmt.Errorf("unknown subcommand: %v", args[0])
+}
+
+// printTrends prints the trends of the targets matching re.
+func printTrends(w io.Writer, trends map[string][]history.TrendPoint, re *regexp.Regexp) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tTIME\tTOTAL\tNEW\tFIXED")
+	for _, target := range sortedKeys(trends) {
+		if !re.MatchString(target) {
+			continue
+		}
+		for _, p := range trends[target] {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", target, p.Time.Format(time.RFC3339), p.Total, p.New, p.Fixed)
+		}
+	}
+	return tw.Flush()
+}
+
+// printRemediations prints the remediation statistics of the targets
+// matching re.
+func printRemediations(w io.Writer, rems map[string]history.Remediation, re *regexp.Regexp) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tFIXED\tMTTR")
+	for _, target := range sortedKeys(rems) {
+		if !re.MatchString(target) {
+			continue
+		}
+		rem := rems[target]
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", target, rem.Fixed, rem.MTTR.Round(time.Second))
+	}
+	return tw.Flush()
+}
+
+// sortedKeys returns the keys of the provided map sorted in
+// increasing order.
+func sortedKeys[V any](m map[string]V) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Adevinta
+
+package history
+
+import (
+	"bytes"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/history"
+)
+
+func TestRunHistory(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []history.Entry{
+		{
+			Time:    t0,
+			Targets: []string{"target1", "target2"},
+			Findings: []history.Finding{
+				{Target: "target1", Checktype: "ct", Fingerprint: "a"},
+				{Target: "target2", Checktype: "ct", Fingerprint: "b"},
+			},
+		},
+		{
+			Time:    t0.Add(time.Hour),
+			Targets: []string{"target1", "target2"},
+			Findings: []history.Finding{
+				{Target: "target2", Checktype: "ct", Fingerprint: "b"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		target string
+		want   string
+	}{
+		{
+			name: "trend",
+			args: []string{"trend"},
+			want: "TARGET   TIME                  TOTAL  NEW  FIXED\n" +
+				"target1  2024-01-01T00:00:00Z  1      1    0\n" +
+				"target1  2024-01-01T01:00:00Z  0      0    1\n" +
+				"target2  2024-01-01T00:00:00Z  1      1    0\n" +
+				"target2  2024-01-01T01:00:00Z  1      0    0\n",
+		},
+		{
+			name:   "mttr with target",
+			args:   []string{"mttr"},
+			target: "target1",
+			want: "TARGET   FIXED  MTTR\n" +
+				"target1  1      1h0m0s\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldHistoryDB := historyDB
+			oldHistoryTarget := historyTarget
+			oldOsStdout := osStdout
+			defer func() {
+				historyDB = oldHistoryDB
+				historyTarget = oldHistoryTarget
+				osStdout = oldOsStdout
+			}()
+
+			historyDB = path.Join(t.TempDir(), "history.jsonl")
+			historyTarget = tt.target
+			buf := &bytes.Buffer{}
+			osStdout = buf
+
+			db := history.Open(historyDB)
+			for _, e := range entries {
+				if err := db.Append(e); err != nil {
+					t.Fatalf("append error: %v", err)
+				}
+			}
+
+			if err := runHistory(tt.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestRunHistory_InvalidSubcommand(t *testing.T) {
+	oldHistoryDB := historyDB
+	defer func() { historyDB = oldHistoryDB }()
+
+	historyDB = path.Join(t.TempDir(), "history.jsonl")
+
+	if err := runHistory([]string{"invalid"}); err == nil {
+		t.Errorf("expected error")
+	}
+}
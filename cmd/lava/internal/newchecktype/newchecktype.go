@@ -0,0 +1,142 @@
+// Copyright 2024 Adevinta
+
+// Package newchecktype implements the new-checktype command.
+package newchecktype
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/adevinta/lava/cmd/lava/internal/base"
+)
+
+// CmdNewChecktype represents the new-checktype command.
+var CmdNewChecktype = &base.Command{
+	UsageLine: "new-checktype [flags] name",
+	Short:     "create a checktype skeleton",
+	Long: `
+Create the skeleton of a new checktype.
+
+New-checktype accepts one argument: the name of the checktype. The
+name must only contain lowercase letters, digits, dots, dashes and
+underscores, and must start with a letter or digit.
+
+This command creates a directory with the name of the checktype in
+the current directory. It contains the following files:
+
+  - main.go: Go source code of the check using the Vulcan check SDK.
+  - go.mod: Go module of the check.
+  - Dockerfile: Dockerfile of the check image.
+  - manifest.toml: manifest with the metadata of the checktype.
+  - testdata/target: test target of the check.
+
+Before running the checktype for the first time, the dependencies of
+the Go module must be downloaded with "go mod tidy". Then, the
+checktype can be run with "lava run". For instance,
+
+	lava new-checktype vulcan-example
+	cd vulcan-example
+	go mod tidy
+	lava run . testdata/target
+
+For more details about path checktypes, use "lava help run".
+
+The -f flag allows to overwrite the files of the checktype directory
+if it exists.
+	`,
+}
+
+// Command-line flags.
+var (
+	newF bool // -f flag
+)
+
+//go:embed all:skel
+var skel embed.FS
+
+// skelDir is the directory of the checktype skeleton in [skel].
+const skelDir = "skel"
+
+// reName matches a valid checktype name.
+var reName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+func init() {
+	CmdNewChecktype.Run = runNewChecktype // Break initialization cycle.
+	CmdNewChecktype.Flag.BoolVar(&newF, "f", false, "overwrite checktype directory")
+}
+
+// runNewChecktype is the entry point of the new-checktype command.
+func runNewChecktype(args []string) error {
+	if len(args) != 1 {
+		return errors.New("invalid number of arguments")
+	}
+	name := args[0]
+
+	if !reName.MatchString(name) {
+		return fmt.Errorf("invalid checktype name: %q", name)
+	}
+
+	if !newF {
+		_, err := os.Stat(name)
+		if err == nil {
+			return fs.ErrExist
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("stat: %w", err)
+		}
+	}
+
+	if err := writeSkel(name, name); err != nil {
+		return fmt.Errorf("write skeleton: %w", err)
+	}
+	return nil
+}
+
+// writeSkel writes the skeleton of the checktype with the provided
+// name into dir. The files of the skeleton are templates that are
+// executed with the name of the checktype.
+func writeSkel(dir, name string) error {
+	data := struct{ Name string }{Name: name}
+
+	return fs.WalkDir(skel, skelDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(skelDir, path)
+		if err != nil {
+			return fmt.Errorf("relative path: %w", err)
+		}
+		dst := filepath.Join(dir, strings.TrimSuffix(rel, ".tmpl"))
+
+		if d.IsDir() {
+			if err := os.MkdirAll(dst, 0755); err != nil {
+				return fmt.Errorf("make directory: %w", err)
+			}
+			return nil
+		}
+
+		tmpl, err := template.ParseFS(skel, path)
+		if err != nil {
+			return fmt.Errorf("parse template: %w", err)
+		}
+
+		f, err := os.Create(dst)
+		if err != nil {
+			return fmt.Errorf("create file: %w", err)
+		}
+		defer f.Close()
+
+		if err := tmpl.Execute(f, data); err != nil {
+			return fmt.Errorf("execute template %v: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Adevinta
+
+package newchecktype
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteSkel(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "vulcan-example")
+
+	if err := writeSkel(dir, "vulcan-example"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk error: %v", err)
+	}
+
+	want := []string{
+		".dockerignore",
+		"Dockerfile",
+		"go.mod",
+		"main.go",
+		"manifest.toml",
+		"testdata/target/README.md",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("files mismatch (-want +got):\n%v", diff)
+	}
+
+	for _, file := range []string{"main.go", "go.mod", "Dockerfile"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("error reading file: %v", err)
+		}
+		if !strings.Contains(string(data), "vulcan-example") {
+			t.Errorf("checktype name not found in %v:\n%s", file, data)
+		}
+		if strings.Contains(string(data), "{{") {
+			t.Errorf("unexpanded template action in %v:\n%s", file, data)
+		}
+	}
+}
+
+func TestRunNewChecktype(t *testing.T) {
+	oldNewF := newF
+	defer func() { newF = oldNewF }()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("error getting working directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("error changing directory: %v", err)
+	}
+
+	if err := runNewChecktype([]string{"vulcan-example"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join("vulcan-example", "main.go")); err != nil {
+		t.Errorf("stat error: %v", err)
+	}
+
+	if err := runNewChecktype([]string{"vulcan-example"}); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("unexpected error: want: %v, got: %v", fs.ErrExist, err)
+	}
+
+	newF = true
+	if err := runNewChecktype([]string{"vulcan-example"}); err != nil {
+		t.Errorf("unexpected error overwriting checktype: %v", err)
+	}
+}
+
+func TestRunNewChecktype_invalid_args(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "no args",
+			args: nil,
+		},
+		{
+			name: "too many args",
+			args: []string{"a", "b"},
+		},
+		{
+			name: "uppercase name",
+			args: []string{"Vulcan-Example"},
+		},
+		{
+			name: "path name",
+			args: []string{"../vulcan-example"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runNewChecktype(tt.args); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Adevinta
+
+// Package reportcmd implements the report command.
+package reportcmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/adevinta/lava/cmd/lava/internal/base"
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/report"
+)
+
+// CmdReport represents the report command.
+var CmdReport = &base.Command{
+	UsageLine: "report show [flags] report.json [slug]",
+	Short:     "show and filter findings of a saved report",
+	Long: `
+Show the findings of a saved report.
+
+Report show reads a report generated by "lava scan" in JSON format
+and prints its findings in human-readable format. The report is
+usually the one written to "report.fullOutput", which contains all
+the findings of the scan. For more details, use "lava help lava.yaml".
+
+Every finding has a slug that identifies it. The slug is derived
+from the fingerprint of the finding, so it is stable across scans
+and can be used to reference a specific finding in tickets. If a slug
+is specified, only the finding with that slug is printed. For
+instance:
+
+	lava report show findings.json 4f2a9c01b7e3
+
+The -severity flag sets the minimum severity of the printed findings.
+Valid values are "critical", "high", "medium", "low" and "info". If
+not specified, "info" is used.
+
+The -target and -checktype flags allow to specify regular expressions
+that filter the findings by target and checktype respectively.
+
+The flags can be specified after the report. For instance:
+
+	lava report show findings.json -severity high -target 'api.*' -checktype trivy
+
+The findings are printed sorted by severity in descending order.
+
+If the report is "-", it is read from the standard input.
+	`,
+}
+
+// Command-line flags.
+var (
+	reportSeverity  config.Severity // -severity flag
+	reportTarget    string          // -target flag
+	reportChecktype string          // -checktype flag
+)
+
+// osStdin is used by tests to provide the report.
+var osStdin io.Reader = os.Stdin
+
+// osStdout is used by tests to capture the output of the command.
+var osStdout io.Writer = os.Stdout
+
+func init() {
+	CmdReport.Run = runReport // Break initialization cycle.
+	CmdReport.Flag.TextVar(&reportSeverity, "severity", config.SeverityInfo, "minimum severity of the findings")
+	CmdReport.Flag.StringVar(&reportTarget, "target", "", "target regular expression")
+	CmdReport.Flag.StringVar(&reportChecktype, "checktype", "", "checktype regular expression")
+}
+
+// runReport is the entry point of the report command.
+func runReport(args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return errors.New("unknown subcommand")
+	}
+
+	// Flags can be specified before and after the positional
+	// arguments.
+	var pos []string
+	rest := args[1:]
+	for {
+		if err := CmdReport.Flag.Parse(rest); err != nil {
+			return fmt.Errorf("parse flags: %w", err)
+		}
+		if CmdReport.Flag.NArg() == 0 {
+			break
+		}
+		pos = append(pos, CmdReport.Flag.Arg(0))
+		rest = CmdReport.Flag.Args()[1:]
+	}
+	if len(pos) == 0 || len(pos) > 2 {
+		return errors.New("invalid number of arguments")
+	}
+
+	filter := report.Filter{Severity: &reportSeverity}
+	if len(pos) == 2 {
+		filter.Slug = pos[1]
+	}
+	if reportTarget != "" {
+		re, err := regexp.Compile(reportTarget)
+		if err != nil {
+			return fmt.Errorf("invalid target regexp: %w", err)
+		}
+		filter.Target = re
+	}
+	if reportChecktype != "" {
+		re, err := regexp.Compile(reportChecktype)
+		if err != nil {
+			return fmt.Errorf("invalid checktype regexp: %w", err)
+		}
+		filter.Checktype = re
+	}
+
+	r := osStdin
+	if path := pos[0]; path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("open report: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if err := report.Show(osStdout, r, filter); err != nil {
+		return fmt.Errorf("show report: %w", err)
+	}
+	return nil
+}
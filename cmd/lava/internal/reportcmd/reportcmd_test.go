@@ -0,0 +1,109 @@
+// Copyright 2024 Adevinta
+
+package reportcmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/report"
+)
+
+func TestRunReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+		wantErr error
+	}{
+		{
+			name:    "all findings",
+			args:    []string{"show", "testdata/report.json"},
+			want:    []string{"Secret found", "Outdated package"},
+			notWant: nil,
+		},
+		{
+			name:    "slug",
+			args:    []string{"show", "testdata/report.json", "ba9876543210"},
+			want:    []string{"Outdated package", "ba9876543210"},
+			notWant: []string{"Secret found"},
+		},
+		{
+			name:    "unknown slug",
+			args:    []string{"show", "testdata/report.json", "000000000000"},
+			wantErr: report.ErrNoFindings,
+		},
+		{
+			name:    "severity",
+			args:    []string{"show", "testdata/report.json", "-severity", "high"},
+			want:    []string{"Secret found"},
+			notWant: []string{"Outdated package"},
+		},
+		{
+			name:    "target and checktype",
+			args:    []string{"show", "-target", `^\.$`, "testdata/report.json", "-checktype", "trivy"},
+			want:    []string{"Outdated package"},
+			notWant: []string{"Secret found"},
+		},
+		{
+			name:    "no matching checktype",
+			args:    []string{"show", "testdata/report.json", "-checktype", "zap"},
+			wantErr: report.ErrNoFindings,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldOsStdout := osStdout
+			defer func() { osStdout = oldOsStdout }()
+			defer resetFlags()
+
+			var buf bytes.Buffer
+			osStdout = &buf
+
+			if err := runReport(tt.args); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+
+			got := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("output does not contain %q:\n%v", s, got)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("output contains %q:\n%v", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRunReport_invalid(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"list"},
+		{"show"},
+		{"show", "a.json", "slug", "extra"},
+		{"show", "testdata/report.json", "-severity", "unknown"},
+		{"show", "testdata/report.json", "-target", "("},
+		{"show", "testdata/report.json", "-checktype", "("},
+	} {
+		if err := runReport(args); err == nil {
+			t.Errorf("expected error for %q", args)
+		}
+		resetFlags()
+	}
+}
+
+// resetFlags sets the command-line flags to their default values.
+func resetFlags() {
+	reportSeverity = config.SeverityInfo
+	reportTarget = ""
+	reportChecktype = ""
+}
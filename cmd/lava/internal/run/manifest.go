@@ -0,0 +1,66 @@
+// Copyright 2024 Adevinta
+
+package run
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// manifestFile is the name of the manifest file of a path checktype.
+const manifestFile = "manifest.toml"
+
+// manifest is the manifest of a checktype. It follows the format of
+// the manifest.toml files used to generate the published checktype
+// catalogs.
+type manifest struct {
+	// Description is the description of the checktype.
+	Description string `toml:"Description"`
+
+	// Timeout is the timeout of the checktype in seconds.
+	Timeout int `toml:"Timeout"`
+
+	// AssetTypes is the list of Vulcan asset types accepted by the
+	// checktype.
+	AssetTypes []string `toml:"AssetTypes"`
+
+	// RequiredVars is the list of environment variables required
+	// by the checktype.
+	RequiredVars []string `toml:"RequiredVars"`
+
+	// Options contains the default options of the checktype in
+	// JSON format.
+	Options string `toml:"Options"`
+}
+
+// readManifest reads the manifest of the checktype in the provided
+// directory. It returns a nil manifest if the directory does not
+// contain a manifest file.
+func readManifest(dir string) (*manifest, error) {
+	var m manifest
+	if _, err := toml.DecodeFile(filepath.Join(dir, manifestFile), &m); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// options returns the default options of the checktype.
+func (m manifest) options() (map[string]any, error) {
+	if m.Options == "" {
+		return nil, nil
+	}
+
+	var opts map[string]any
+	if err := json.Unmarshal([]byte(m.Options), &opts); err != nil {
+		return nil, fmt.Errorf("JSON unmarshal options: %w", err)
+	}
+	return opts, nil
+}
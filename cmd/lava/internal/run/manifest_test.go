@@ -0,0 +1,104 @@
+// Copyright 2024 Adevinta
+
+package run
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/adevinta/lava/internal/checktypes"
+)
+
+func TestMkChecktypeCatalog_manifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		vars    varFlag
+		want    checktypes.Catalog
+		wantErr bool
+	}{
+		{
+			name: "manifest",
+			dir:  "testdata/manifest",
+			want: checktypes.Catalog{
+				"example:lava-run": {
+					Name:         "example:lava-run",
+					Description:  "Example checktype",
+					Image:        "example:lava-run",
+					Timeout:      300,
+					Options:      map[string]any{"depth": float64(1)},
+					RequiredVars: []any{"REQUIRED_VAR"},
+					Assets:       []string{"GitRepository", "DockerImage"},
+				},
+			},
+		},
+		{
+			name: "manifest and vars",
+			dir:  "testdata/manifest",
+			vars: varFlag{"REQUIRED_VAR": "value", "DEBUG": "true"},
+			want: checktypes.Catalog{
+				"example:lava-run": {
+					Name:         "example:lava-run",
+					Description:  "Example checktype",
+					Image:        "example:lava-run",
+					Timeout:      300,
+					Options:      map[string]any{"depth": float64(1)},
+					RequiredVars: []any{"DEBUG", "REQUIRED_VAR"},
+					Assets:       []string{"GitRepository", "DockerImage"},
+				},
+			},
+		},
+		{
+			name: "no manifest",
+			dir:  "testdata/goodpath",
+			want: checktypes.Catalog{
+				"example:lava-run": {
+					Name:         "example:lava-run",
+					Image:        "example:lava-run",
+					Timeout:      600,
+					RequiredVars: []any(nil),
+					Assets:       []string{"GitRepository"},
+				},
+			},
+		},
+		{
+			name:    "invalid options",
+			dir:     "testdata/invalid_manifest",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldRunVar := runVar
+			oldRunTimeout := runTimeout
+			oldRunType := runType
+			defer func() {
+				runVar = oldRunVar
+				runTimeout = oldRunTimeout
+				runType = oldRunType
+			}()
+
+			runVar = tt.vars
+			runTimeout = 600 * time.Second
+			runType = "Path"
+
+			m, err := readManifest(tt.dir)
+			if err != nil {
+				t.Fatalf("read manifest error: %v", err)
+			}
+
+			got, err := mkChecktypeCatalog("example:lava-run", m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got, cmpopts.SortSlices(func(a, b any) bool { return a.(string) < b.(string) })); diff != "" {
+				t.Errorf("catalog mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -7,13 +7,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime/debug"
+	"slices"
+	"strings"
 	"time"
 
 	agentconfig "github.com/adevinta/vulcan-agent/config"
@@ -28,7 +32,9 @@ import (
 	"github.com/adevinta/lava/internal/config"
 	"github.com/adevinta/lava/internal/containers"
 	"github.com/adevinta/lava/internal/engine"
+	"github.com/adevinta/lava/internal/logfile"
 	"github.com/adevinta/lava/internal/metrics"
+	"github.com/adevinta/lava/internal/profile"
 	"github.com/adevinta/lava/internal/report"
 )
 
@@ -47,7 +53,8 @@ supported by the -type flag.
 
 The -type flag determines the type of the provided target. Valid
 values are "AWSAccount", "DockerImage", "GitRepository", "IP",
-"IPRange", "DomainName", "Hostname", "WebAddress" and "Path". If not
+"IPRange", "DomainName", "Hostname", "WebAddress", "Path",
+"DockerImageArchive", "OCILayout" and "TerraformModule". If not
 specified, "Path" is used. For more details, use "lava help
 lava.yaml".
 
@@ -91,8 +98,28 @@ finding. Valid values are "critical", "high", "medium", "low" and
 The -o flag specifies the output file to write the results of the
 scan. If not specified, the standard output is used. The format of the
 output is defined by the -fmt flag. The -fmt flag accepts the values
-"human" for human-readable output and "json" for JSON-encoded
-output. If not specified, "human" is used.
+"human" for human-readable output, "plain" for linear plain-text
+output suitable for screen readers, "json" for JSON-encoded output,
+"csv" for CSV-encoded output with one row per finding and "template"
+for output rendered with a user-provided Go template. If not
+specified, "human" is used.
+
+The -columns flag specifies the comma-separated list of columns of the
+output when the -fmt flag is "csv". Run "lava help lava.yaml" for the
+list of supported columns. If not specified, the columns "target",
+"checktype", "severity", "score", "summary", "affected_resource",
+"fingerprint" and "slug" are used.
+
+The -template flag specifies the file containing the Go template used
+to render the output when the -fmt flag is "template". The template
+receives the vulnerabilities, the summary and the status of the
+checks. Run "lava help lava.yaml" for more details about the data
+passed to the template.
+
+The -width flag sets the width in columns of the human-readable
+output. If not specified, the width of the terminal is used.
+Resources are rendered as tables when the width is at least 100
+columns and as key-value lists otherwise.
 
 The -metrics flag specifies the file to write the security,
 operational and configuration metrics of the scan. For more details,
@@ -101,6 +128,15 @@ use "lava help metrics".
 The -log flag defines the logging level. Valid values are "debug",
 "info", "warn" and "error". If not specified, "info" is used.
 
+The -log-format flag defines the format of the logs. Valid values are
+"text" and "json". If not specified, "text" is used. The "json" format
+is meant to be ingested by log aggregation systems.
+
+The -log-file flag specifies a file where the logs are written in
+addition to stderr. Debug logs are always written to this file,
+independently of the -log flag. The file is rotated when it reaches
+100 MiB.
+
 Lava supports several container runtimes. The environment variable
 LAVA_RUNTIME allows to select which one is in use. For more details,
 use "lava help environment".
@@ -130,6 +166,14 @@ Thus, the following command:
 would generate a Docker image with the reference
 "vulcan-trivy:lava-run".
 
+If the directory contains a manifest.toml file, the asset types,
+default options, required variables and timeout of the checktype are
+read from it, so the checktype behaves as it would in the published
+checktype catalog. The -timeout flag takes precedence over the timeout
+of the manifest, and the variables set with the -var flag are always
+passed to the checktype. If there is no manifest, the checktype only
+accepts the type specified by the -type flag.
+
 Finally, the generated Docker image is used as checktype to run a scan
 against the provided target with the specified options.
 
@@ -180,8 +224,14 @@ var (
 	runShow     showFlag                        // -show flag
 	runO        string                          // -o flag
 	runFmt      config.OutputFormat             // -fmt flag
+	runWidth    int                             // -width flag
+	runTemplate string                          // -template flag
+	runColumns  string                          // -columns flag
 	runMetrics  string                          // -metrics flag
 	runLog      slog.Level                      // -log flag
+	runLogFmt   config.LogFormat                // -log-format flag
+	runLogFile  string                          // -log-file flag
+	runProfile  string                          // -profile flag
 )
 
 func init() {
@@ -212,10 +262,45 @@ func run(args []string) (int, error) {
 	checktype := args[0]
 	targetIdent := args[1]
 
+	if runWidth < 0 {
+		return 0, fmt.Errorf("%w: %v", config.ErrInvalidWidth, runWidth)
+	}
+
+	if runFmt == config.OutputFormatTemplate && runTemplate == "" {
+		return 0, fmt.Errorf("%w: no template", config.ErrInvalidOutputFormat)
+	}
+
+	for _, col := range mkColumns() {
+		if !slices.Contains(config.CSVColumns, col) {
+			return 0, fmt.Errorf("%w: %v", config.ErrInvalidColumn, col)
+		}
+	}
+
 	startTime := time.Now()
 	metrics.Collect("start_time", startTime)
 
+	if runProfile != "" {
+		if err := profile.Start(runProfile); err != nil {
+			return 0, fmt.Errorf("start profiling: %w", err)
+		}
+		defer func() {
+			if err := profile.Stop(); err != nil {
+				slog.Error("could not stop profiling", "err", err)
+			}
+		}()
+	}
+
 	base.LogLevel.Set(runLog)
+	var logFile io.Writer
+	if runLogFile != "" {
+		lf, err := logfile.Open(runLogFile, logfile.DefaultMaxSize)
+		if err != nil {
+			return 0, fmt.Errorf("open log file: %w", err)
+		}
+		defer lf.Close()
+		logFile = lf
+	}
+	base.SetLogger(runLogFmt, logFile)
 
 	bi, ok := debug.ReadBuildInfo()
 	if !ok {
@@ -232,6 +317,7 @@ func run(args []string) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("write report: %w", err)
 	}
+	profile.EndPhase("write outputs")
 
 	metrics.Collect("exit_code", exitCode)
 	metrics.Collect("duration", time.Since(startTime).Seconds())
@@ -250,6 +336,8 @@ func engineRun(targetIdent string, checktype string) (engine.Report, error) {
 	metrics.Collect("targets", []config.Target{target})
 
 	agentConfig := mkAgentConfig()
+
+	var m *manifest
 	info, err := os.Stat(checktype)
 	switch {
 	case err != nil && !errors.Is(err, fs.ErrNotExist):
@@ -259,19 +347,31 @@ func engineRun(targetIdent string, checktype string) (engine.Report, error) {
 			return nil, errors.New("path checktypes only allow IfNotPresent and Never pull policies")
 		}
 
+		if m, err = readManifest(checktype); err != nil {
+			return nil, fmt.Errorf("read manifest: %w", err)
+		}
+
 		ct, err := buildChecktype(checktype)
 		if err != nil {
 			return nil, fmt.Errorf("build checktype: %w", err)
 		}
 		checktype = ct
+		profile.EndPhase("build checktype")
 	}
 
-	checktypeCatalog := mkChecktypeCatalog(checktype)
+	checktypeCatalog, err := mkChecktypeCatalog(checktype, m)
+	if err != nil {
+		return nil, fmt.Errorf("generate checktype catalog: %w", err)
+	}
 	eng, err := engine.NewWithCatalog(agentConfig, checktypeCatalog)
 	if err != nil {
 		return nil, fmt.Errorf("engine initialization: %w", err)
 	}
 	defer eng.Close()
+	eng = eng.WithLogFormat(runLogFmt)
+	profile.EndPhase("init engine")
+
+	metrics.Collect("scan_id", eng.ScanID())
 
 	rep, err := eng.Run([]config.Target{target})
 	if err != nil {
@@ -323,7 +423,8 @@ func buildChecktype(path string) (string, error) {
 
 	slog.Info("building Docker image", "ref", ref)
 
-	newID, err := cli.ImageBuild(context.Background(), path, "Dockerfile", ref)
+	labels := map[string]string{containers.LabelChecktype: dirname}
+	newID, err := cli.ImageBuild(context.Background(), path, "Dockerfile", ref, labels)
 	if err != nil {
 		return "", fmt.Errorf("image build: %w", err)
 	}
@@ -399,9 +500,13 @@ func mkAgentConfig() config.AgentConfig {
 }
 
 // mkChecktypeCatalog generates a checktype catalog from the provided
-// flags and positional arguments.
-func mkChecktypeCatalog(checktype string) checktypes.Catalog {
-	vulcanAssetType := assettypes.ToVulcan(types.AssetType(runType))
+// flags and positional arguments. If m is not nil, the asset types,
+// options, required vars and timeout of the checktype are taken from
+// the provided manifest. The -timeout flag takes precedence over the
+// timeout of the manifest and the variables set with the -var flag
+// are always passed to the checktype.
+func mkChecktypeCatalog(checktype string, m *manifest) (checktypes.Catalog, error) {
+	vulcanAssetType := assettypes.ToVulcan(types.AssetType(runType)).String()
 	var reqVars []any
 	for k := range runVar {
 		reqVars = append(reqVars, k)
@@ -410,10 +515,56 @@ func mkChecktypeCatalog(checktype string) checktypes.Catalog {
 		Name:         checktype,
 		Image:        checktype,
 		Timeout:      int(runTimeout.Seconds()),
-		Assets:       []string{vulcanAssetType.String()},
+		Assets:       []string{vulcanAssetType},
 		RequiredVars: reqVars,
 	}
-	return checktypes.Catalog{checktype: ct}
+
+	if m != nil {
+		opts, err := m.options()
+		if err != nil {
+			return nil, fmt.Errorf("manifest options: %w", err)
+		}
+
+		if !slices.Contains(m.AssetTypes, vulcanAssetType) {
+			slog.Warn("the target type is not accepted by the checktype", "type", runType, "assetTypes", m.AssetTypes)
+		}
+
+		for _, v := range m.RequiredVars {
+			if _, ok := runVar[v]; !ok {
+				reqVars = append(reqVars, v)
+			}
+		}
+
+		ct.Description = m.Description
+		ct.Assets = m.AssetTypes
+		ct.Options = opts
+		ct.RequiredVars = reqVars
+		if m.Timeout != 0 && !isFlagSet("timeout") {
+			ct.Timeout = m.Timeout
+		}
+	}
+	return checktypes.Catalog{checktype: ct}, nil
+}
+
+// isFlagSet reports whether the specified flag of the run command has
+// been set.
+func isFlagSet(name string) bool {
+	set := false
+	CmdRun.Flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// mkColumns returns the columns of the CSV output provided with the
+// -columns flag.
+func mkColumns() []string {
+	if runColumns == "" {
+		return nil
+	}
+	return strings.Split(runColumns, ",")
 }
 
 // writeOutputs writes the provided report and the metrics file. It
@@ -431,12 +582,15 @@ func writeOutputs(rep engine.Report) (report.ExitCode, error) {
 		Severity:     &runSeverity,
 		ShowSeverity: showSeverity,
 		Format:       &runFmt,
+		Width:        &runWidth,
+		Template:     &runTemplate,
+		Columns:      mkColumns(),
 		OutputFile:   &runO,
 		Metrics:      &runMetrics,
 	}
 	metrics.Collect("severity", reportConfig.Severity)
 
-	rw, err := report.NewWriter(reportConfig)
+	rw, err := report.NewWriter(reportConfig, nil)
 	if err != nil {
 		return 0, fmt.Errorf("new writer: %w", err)
 	}
@@ -448,7 +602,7 @@ func writeOutputs(rep engine.Report) (report.ExitCode, error) {
 	}
 
 	if metricsFile := config.Get(reportConfig.Metrics); metricsFile != "" {
-		if err = metrics.WriteFile(metricsFile); err != nil {
+		if err = metrics.WriteURL(metricsFile, report.UploadOptions(reportConfig.Upload)); err != nil {
 			return 0, fmt.Errorf("write metrics: %w", err)
 		}
 	}
@@ -162,6 +162,15 @@ func init() {
 	CmdRun.Flag.Var(&runShow, "show", "minimum severity required to show a finding")
 	CmdRun.Flag.StringVar(&runO, "o", "", "output file")
 	CmdRun.Flag.TextVar(&runFmt, "fmt", config.OutputFormatHuman, "output format")
+	CmdRun.Flag.IntVar(&runWidth, "width", 0, "output width")
+	CmdRun.Flag.StringVar(&runTemplate, "template", "", "output template")
+	CmdRun.Flag.StringVar(&runColumns, "columns", "", "CSV output columns")
 	CmdRun.Flag.StringVar(&runMetrics, "metrics", "", "metrics file")
 	CmdRun.Flag.TextVar(&runLog, "log", slog.LevelInfo, "log level")
+	CmdRun.Flag.TextVar(&runLogFmt, "log-format", config.LogFormatText, "log format")
+	CmdRun.Flag.StringVar(&runLogFile, "log-file", "", "log file")
+
+	// The -profile flag is not documented, because it is meant
+	// to be used by Lava developers.
+	CmdRun.Flag.StringVar(&runProfile, "profile", "", "profiles directory")
 }
@@ -4,17 +4,34 @@
 package scan
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"runtime"
 	"runtime/debug"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/adevinta/lava/cmd/lava/internal/base"
+	"github.com/adevinta/lava/internal/checktypes"
 	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/containers"
+	"github.com/adevinta/lava/internal/discovery"
 	"github.com/adevinta/lava/internal/engine"
+	"github.com/adevinta/lava/internal/imagetags"
+	"github.com/adevinta/lava/internal/logfile"
 	"github.com/adevinta/lava/internal/metrics"
+	"github.com/adevinta/lava/internal/profile"
 	"github.com/adevinta/lava/internal/report"
+	"github.com/adevinta/lava/internal/services"
 )
 
 // CmdScan represents the scan command.
@@ -26,7 +43,102 @@ Run a scan using the provided config file.
 
 The -c flag allows to specify a configuration file. By default, "lava
 scan" looks for a configuration file with the name "lava.yaml" in the
-current directory.
+current directory. If the file is "-", the configuration is read from
+the standard input and its relative paths are resolved against the
+current directory. The -c flag can be specified multiple times. In
+that case, the configuration files are merged in order, as if every
+file included the previous ones. This allows wrapper tools to
+generate configurations on the fly without temporary files. For
+instance:
+
+	generate-overrides | lava scan -c lava.yaml -c -
+
+The standard input cannot be used for both the configuration and the
+target list.
+
+The -width flag sets the width in columns of the human-readable
+output. It takes precedence over the "report.width" setting of the
+configuration file. If neither is specified, the width of the
+terminal is used. Resources are rendered as tables when the width is
+at least 100 columns or unknown, for instance, because the output is
+written to a file, and as key-value lists otherwise.
+
+The -targets flag allows to specify a file with a list of targets
+that are merged with the targets of the configuration file. If the
+file is "-", the list is read from the standard input. Every line of
+the list contains the asset type and the identifier of a target
+separated by white space, like "DockerImage alpine:latest". Empty
+lines and lines starting with "#" are ignored. This allows to pipe
+the output of other tools into Lava. For instance:
+
+	docker images --format 'DockerImage {{.Repository}}:{{.Tag}}' | lava scan -targets -
+
+If targets are provided with this flag, the configuration file does
+not need to declare any target.
+
+The -label flag adds a label with the format "key=value" to the
+metadata of the report. It can be specified multiple times and
+implies "report.metadata". The metadata of the report contains the
+Lava version, the path and the hash of the configuration file, the
+scan ID, the start and end times of the scan, the operating system
+and architecture of the host, the container runtime and the labels.
+For instance:
+
+	lava scan -label pipeline=nightly -label commit=$(git rev-parse HEAD)
+
+The -force flag makes Lava run the checks against Docker images even
+if their results are cached. It only has effect if
+"agent.imageCache.dir" is set. The cache is updated with the new
+results.
+
+The -resume flag resumes a scan that was interrupted. For instance,
+because of a CI timeout. The reports of the checks that finished are
+persisted in a state file while the scan runs, so only the checks
+that did not finish are run again and their results are merged with
+the previous ones. The state file is removed when the scan
+completes. Its path is set by "agent.state.file". If not specified,
+the state file is stored in the user cache directory and depends on
+the path of the configuration file.
+
+The -severity, -show, -fmt, -o and -metrics flags override the
+"report.severity", "report.show", "report.format", "report.output"
+and "report.metrics" settings of the configuration file respectively.
+The paths passed to -o and -metrics are relative to the current
+directory. They allow to use the same configuration file in
+different pipelines. For instance, to gate on high severity findings
+while reporting all of them:
+
+	lava scan -severity high -show info -fmt json -o findings.json
+
+The -var flag sets an environment variable passed to the checks. It
+overrides the variable with the same name in "agent.vars" or extends
+them if it is not defined. The variables must be provided using the
+format "name[=value]". If there is no equal sign, the value of the
+variable is got from the environment. This flag can be specified
+multiple times. It allows to inject one-off credentials without
+editing the configuration file. For instance:
+
+	lava scan -var GITHUB_ENTERPRISE_TOKEN
+
+The -controls flag allows to run only the checktypes that implement
+the specified control families. It accepts a comma-separated list of
+families and can be specified multiple times. The supported families
+are "sast", "sca", "dast" and "secrets". Catalogs tag their
+checktypes with the families they implement using the "labels"
+field. Checktypes without any matching label are not run. The
+control families covered by the scan are recorded in the "controls"
+metric. For instance:
+
+	lava scan -controls sast,sca
+
+If the catalogs label their checktypes with control families, the
+report includes the control coverage of every target. For every
+family, a target is "covered" if at least one check of the family
+finished successfully against it, "failed" if the checks of the
+family did not finish successfully and "not covered" if no check of
+the family was run against it. The coverage is also recorded in the
+"control_coverage" metric. It allows to find the targets that lack a
+security control, like secret detection.
 
 The exit code of the command depends on the correct execution of the
 security scan and the highest severity among all the vulnerabilities
@@ -37,12 +149,26 @@ that have been found.
   -   2: Syntax error
   -   3: Check error
   -   4: Stale exclusions
+  -   5: SLA breaches
+  -   6: Exclusions about to expire
+  -  10: Container runtime unreachable
+  -  11: Registry authentication failure
+  -  12: Image pull denied
+  -  13: Check container out of memory
   - 100: Informational vulnerabilities found
   - 101: Low severity vulnerabilities found
   - 102: Medium severity vulnerabilities found
   - 103: High severity vulnerabilities found
   - 104: Critical severity vulnerabilities found
 
+The exit codes 10 to 13 are returned when the scan cannot be
+completed because of a known infrastructure failure. In that case, the
+error message includes a hint about how to fix it.
+
+Before running the checks, Lava verifies that all the check images
+exist and are accessible. If any of them is missing or forbidden, the
+command exits with code 12 listing all the unavailable images.
+
 Those vulnerabilities that has been excluded in the configuration are
 not considered in the computation of the exit code. In other words,
 vulnerabilities with a severity that is lower than "report.severity"
@@ -56,29 +182,106 @@ use "lava help environment".
 }
 
 // Command-line flags.
-var scanC string // -c flag
+var (
+	scanC        configFiles     // -c flag
+	scanWidth    int             // -width flag
+	scanProfile  string          // -profile flag
+	scanForce    bool            // -force flag
+	scanTargets  string          // -targets flag
+	scanLabels   labels          // -label flag
+	scanResume   bool            // -resume flag
+	scanControls controlFamilies // -controls flag
+	scanVars     vars            // -var flag
+	scanSeverity severityFlag    // -severity flag
+	scanShow     severityFlag    // -show flag
+	scanFmt      formatFlag      // -fmt flag
+	scanO        string          // -o flag
+	scanMetrics  string          // -metrics flag
+)
 
 func init() {
 	CmdScan.Run = runScan // Break initialization cycle.
-	CmdScan.Flag.StringVar(&scanC, "c", "lava.yaml", "config file")
+	CmdScan.Flag.Var(&scanC, "c", "config file (default \"lava.yaml\")")
+	CmdScan.Flag.IntVar(&scanWidth, "width", 0, "output width")
+	CmdScan.Flag.BoolVar(&scanForce, "force", false, "ignore cached results")
+	CmdScan.Flag.StringVar(&scanTargets, "targets", "", "target list file")
+	scanLabels = make(labels)
+	CmdScan.Flag.Var(scanLabels, "label", "report label (key=value)")
+	CmdScan.Flag.BoolVar(&scanResume, "resume", false, "resume interrupted scan")
+	CmdScan.Flag.Var(&scanControls, "controls", "control families to run (comma-separated)")
+	scanVars = make(vars)
+	CmdScan.Flag.Var(scanVars, "var", "checktype environment variable (name[=value])")
+	CmdScan.Flag.Var(&scanSeverity, "severity", "minimum severity required to exit with error")
+	CmdScan.Flag.Var(&scanShow, "show", "minimum severity required to show a finding")
+	CmdScan.Flag.Var(&scanFmt, "fmt", "output format")
+	CmdScan.Flag.StringVar(&scanO, "o", "", "output file")
+	CmdScan.Flag.StringVar(&scanMetrics, "metrics", "", "metrics file")
+
+	// The -profile flag is not documented, because it is meant
+	// to be used by Lava developers.
+	CmdScan.Flag.StringVar(&scanProfile, "profile", "", "profiles directory")
 }
 
 // osExit is used by tests to capture the exit code.
 var osExit = os.Exit
 
+// osStdin is used by tests to provide the target list and the
+// configuration.
+var osStdin io.Reader = os.Stdin
+
 // debugReadBuildInfo is used by tests to set the command version.
 var debugReadBuildInfo = debug.ReadBuildInfo
 
+// Exit codes returned when the scan fails because of a known
+// infrastructure failure.
+const (
+	exitCodeRuntimeUnreachable = 10
+	exitCodeRegistryAuth       = 11
+	exitCodeImagePullDenied    = 12
+	exitCodeOOMKilled          = 13
+)
+
+// failureExitCodes maps the known failures of the engine to exit
+// codes.
+var failureExitCodes = []struct {
+	err  error
+	code int
+}{
+	{engine.ErrRuntimeUnreachable, exitCodeRuntimeUnreachable},
+	{engine.ErrRegistryAuth, exitCodeRegistryAuth},
+	{engine.ErrImagePullDenied, exitCodeImagePullDenied},
+	{engine.ErrUnavailableImages, exitCodeImagePullDenied},
+	{engine.ErrOOMKilled, exitCodeOOMKilled},
+}
+
 // runScan is the entry point of the scan command.
 func runScan(args []string) error {
 	exitCode, err := scan(args)
 	if err != nil {
-		return err
+		code, ok := failureExitCode(err)
+		if !ok {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		osExit(code)
+		return nil
 	}
 	osExit(exitCode)
 	return nil
 }
 
+// failureExitCode returns the exit code corresponding to the provided
+// error. The returned bool is false if the error is not caused by a
+// known failure.
+func failureExitCode(err error) (int, bool) {
+	for _, fe := range failureExitCodes {
+		if errors.Is(err, fe.err) {
+			return fe.code, true
+		}
+	}
+	return 0, false
+}
+
 // scan contains the logic of the [CmdScan] command. It is wrapped by
 // the run function, so the deferred functions can be executed
 // before calling [os.Exit]. It returns the exit code that must be
@@ -88,16 +291,99 @@ func scan(args []string) (int, error) {
 		return 0, errors.New("too many arguments")
 	}
 
+	if scanWidth < 0 {
+		return 0, fmt.Errorf("%w: %v", config.ErrInvalidWidth, scanWidth)
+	}
+
 	startTime := time.Now()
 	metrics.Collect("start_time", startTime)
 
-	cfg, err := config.ParseFile(scanC)
+	if scanProfile != "" {
+		if err := profile.Start(scanProfile); err != nil {
+			return 0, fmt.Errorf("start profiling: %w", err)
+		}
+		defer func() {
+			if err := profile.Stop(); err != nil {
+				slog.Error("could not stop profiling", "err", err)
+			}
+		}()
+	}
+
+	paths := scanC.paths()
+
+	var stdinConfig []byte
+	if slices.Contains(paths, config.Stdin) {
+		if scanTargets == "-" {
+			return 0, errors.New("stdin cannot be used for both the config and the target list")
+		}
+		b, err := io.ReadAll(osStdin)
+		if err != nil {
+			return 0, fmt.Errorf("read config from stdin: %w", err)
+		}
+		stdinConfig = b
+	}
+
+	var extraTargets []config.Target
+	if scanTargets != "" {
+		targets, err := readTargetList(scanTargets)
+		if err != nil {
+			return 0, fmt.Errorf("read target list: %w", err)
+		}
+		extraTargets = targets
+	}
+
+	// The report flags are applied before validating the
+	// configuration, so they are validated along with it.
+	override := func(cfg *config.Config) { applyReportFlags(&cfg.ReportConfig) }
+	cfg, err := config.ParseFilesWithOverride(paths, bytes.NewReader(stdinConfig), extraTargets, override)
 	if err != nil {
+		if bi, ok := debugReadBuildInfo(); ok && errors.Is(err, config.ErrUnknownField) {
+			return 0, fmt.Errorf("parse config file: %w (running Lava %v)", err, bi.Main.Version)
+		}
 		return 0, fmt.Errorf("parse config file: %w", err)
 	}
 
+	if scanForce {
+		cfg.AgentConfig.ImageCache.Force = &scanForce
+	}
+
+	if cfg.AgentConfig.State.File == nil {
+		if path, err := defaultStateFile(paths); err != nil {
+			slog.Warn("scan state is not persisted", "err", err)
+		} else {
+			cfg.AgentConfig.State.File = &path
+		}
+	}
+
+	if scanResume {
+		cfg.AgentConfig.State.Resume = &scanResume
+	}
+
+	if len(scanVars) > 0 {
+		if cfg.AgentConfig.Vars == nil {
+			cfg.AgentConfig.Vars = make(map[string]string)
+		}
+		maps.Copy(cfg.AgentConfig.Vars, scanVars)
+	}
+
 	base.LogLevel.Set(config.Get(cfg.LogLevel))
 
+	var logFile io.Writer
+	if path := config.Get(cfg.LogFile); path != "" {
+		maxSize := int64(logfile.DefaultMaxSize)
+		if cfg.LogFileMaxSize != nil {
+			maxSize = *cfg.LogFileMaxSize
+		}
+		lf, err := logfile.Open(path, maxSize)
+		if err != nil {
+			return 0, fmt.Errorf("open log file: %w", err)
+		}
+		defer lf.Close()
+		logFile = lf
+	}
+	base.SetLogger(config.Get(cfg.LogFormat), logFile)
+	profile.EndPhase("parse config")
+
 	bi, ok := debugReadBuildInfo()
 	if !ok {
 		return 0, errors.New("could not read build info")
@@ -108,43 +394,379 @@ func scan(args []string) (int, error) {
 		return 0, fmt.Errorf("minimum required version %v", cfg.LavaVersion)
 	}
 
+	targets := cfg.Targets
+	if len(cfg.Services) > 0 {
+		rt, err := containers.GetenvRuntime()
+		if err != nil {
+			return 0, fmt.Errorf("get env runtime: %w", err)
+		}
+
+		sg, err := services.Start(rt, config.Get(cfg.AgentConfig.Network), cfg.Services)
+		if err != nil {
+			return 0, fmt.Errorf("start services: %w", err)
+		}
+		defer sg.Close()
+
+		// The checks must be attached to the network of the
+		// services.
+		network := sg.Network()
+		cfg.AgentConfig.Network = &network
+
+		if targets, err = sg.RenderTargets(cfg.Targets); err != nil {
+			return 0, fmt.Errorf("render targets: %w", err)
+		}
+		profile.EndPhase("start services")
+	}
+
+	if targets, err = imagetags.Expand(targets, cfg.AgentConfig.RegistryAuths); err != nil {
+		return 0, fmt.Errorf("expand image targets: %w", err)
+	}
+
+	if cfg.Scope != nil {
+		for _, t := range targets {
+			if err := cfg.Scope.Check(t); err != nil {
+				return 0, fmt.Errorf("check scope: %w", err)
+			}
+		}
+	}
+
+	if cfg.Discovery != nil {
+		discovered, err := discovery.Discover(targets, *cfg.Discovery, cfg.Scope)
+		if err != nil {
+			return 0, fmt.Errorf("discover targets: %w", err)
+		}
+		targets = append(targets, discovered...)
+	}
+	profile.EndPhase("resolve targets")
+
 	metrics.Collect("lava_version", bi.Main.Version)
 	metrics.Collect("config_version", config.Get(cfg.LavaVersion))
 	metrics.Collect("checktype_urls", cfg.ChecktypeURLs)
-	metrics.Collect("targets", cfg.Targets)
+	metrics.Collect("targets", targets)
 	metrics.Collect("severity", config.Get(cfg.ReportConfig.Severity))
 	metrics.Collect("exclusion_count", len(cfg.ReportConfig.Exclusions))
 
-	eng, err := engine.New(cfg.AgentConfig, cfg.ChecktypeURLs)
+	eng, err := engine.New(cfg.AgentConfig, cfg.ChecktypeURLs, cfg.ChecktypesIntegrity, scanControls)
 	if err != nil {
 		return 0, fmt.Errorf("engine initialization: %w", err)
 	}
 	defer eng.Close()
+	eng = eng.WithLogFormat(config.Get(cfg.LogFormat))
+	profile.EndPhase("init engine")
+
+	metrics.Collect("scan_id", eng.ScanID())
 
-	er, err := eng.Run(cfg.Targets)
+	er, err := eng.RunStages(targets, cfg.Stages, cfg.Scope)
 	if err != nil {
 		return 0, fmt.Errorf("engine run: %w", err)
 	}
 
-	rw, err := report.NewWriter(cfg.ReportConfig)
+	endTime := time.Now()
+
+	rw, err := report.NewWriter(cfg.ReportConfig, targets)
 	if err != nil {
 		return 0, fmt.Errorf("new writer: %w", err)
 	}
 	defer rw.Close()
 
+	rw = rw.WithControls(eng.Controls())
+
+	if config.Get(cfg.ReportConfig.Metadata) || len(scanLabels) > 0 {
+		md, err := mkMetadata(bi.Main.Version, eng.ScanID(), paths, stdinConfig, startTime, endTime)
+		if err != nil {
+			return 0, fmt.Errorf("generate metadata: %w", err)
+		}
+		rw = rw.WithMetadata(md)
+	}
+
 	exitCode, err := rw.Write(er)
 	if err != nil {
 		return 0, fmt.Errorf("render report: %w", err)
 	}
+	profile.EndPhase("write report")
 
 	metrics.Collect("exit_code", exitCode)
 	metrics.Collect("duration", time.Since(startTime).Seconds())
 
 	if metricsFile := config.Get(cfg.ReportConfig.Metrics); metricsFile != "" {
-		if err = metrics.WriteFile(metricsFile); err != nil {
+		if err = metrics.WriteURL(metricsFile, report.UploadOptions(cfg.ReportConfig.Upload)); err != nil {
 			return 0, fmt.Errorf("write metrics: %w", err)
 		}
+		profile.EndPhase("write metrics")
 	}
 
 	return int(exitCode), nil
 }
+
+// applyReportFlags overrides the report configuration with the
+// report flags.
+func applyReportFlags(rc *config.ReportConfig) {
+	if scanWidth > 0 {
+		rc.Width = &scanWidth
+	}
+
+	if scanSeverity.IsSet {
+		rc.Severity = &scanSeverity.Value
+	}
+
+	if scanShow.IsSet {
+		rc.ShowSeverity = &scanShow.Value
+	}
+
+	if scanFmt.IsSet {
+		rc.Format = &scanFmt.Value
+	}
+
+	if scanO != "" {
+		rc.OutputFile = &scanO
+	}
+
+	if scanMetrics != "" {
+		rc.Metrics = &scanMetrics
+	}
+}
+
+// defaultStateFile returns the default path of the state file of the
+// scans run with the specified configuration files. It is stored in
+// the user cache directory and depends on the absolute paths of the
+// configuration files.
+func defaultStateFile(configFiles []string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("get user cache dir: %w", err)
+	}
+
+	h := sha256.New()
+	for i, path := range configFiles {
+		if path != config.Stdin {
+			if path, err = filepath.Abs(path); err != nil {
+				return "", fmt.Errorf("absolute path: %w", err)
+			}
+		}
+		if i > 0 {
+			h.Write([]byte{0})
+		}
+		h.Write([]byte(path))
+	}
+	return filepath.Join(cacheDir, "lava", "state", hex.EncodeToString(h.Sum(nil))+".json"), nil
+}
+
+// mkMetadata returns the metadata of the scan. configFiles are the
+// paths of the configuration files and stdinConfig is the
+// configuration read from the standard input, if any.
+func mkMetadata(version, scanID string, configFiles []string, stdinConfig []byte, startTime, endTime time.Time) (report.Metadata, error) {
+	rt, err := containers.GetenvRuntime()
+	if err != nil {
+		return report.Metadata{}, fmt.Errorf("get env runtime: %w", err)
+	}
+
+	md := report.Metadata{
+		LavaVersion: version,
+		ConfigFile:  strings.Join(configFiles, ","),
+		ScanID:      scanID,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Runtime:     rt.String(),
+	}
+
+	h := sha256.New()
+	for _, path := range configFiles {
+		b := stdinConfig
+		if path != config.Stdin {
+			if b, err = os.ReadFile(path); err != nil {
+				return report.Metadata{}, fmt.Errorf("read config file: %w", err)
+			}
+		}
+		h.Write(b)
+	}
+	md.ConfigHash = hex.EncodeToString(h.Sum(nil))
+
+	if len(scanLabels) > 0 {
+		md.Labels = make(map[string]string)
+		for k, v := range scanLabels {
+			md.Labels[k] = v
+		}
+	}
+	return md, nil
+}
+
+// configFiles is a [flag.Value] that collects the paths of the
+// configuration files.
+type configFiles []string
+
+// String returns the paths of the configuration files separated by
+// commas.
+func (c configFiles) String() string {
+	return strings.Join(c, ",")
+}
+
+// Set appends a path to the list of configuration files.
+func (c *configFiles) Set(s string) error {
+	if s == "" {
+		return errors.New("empty config file path")
+	}
+	*c = append(*c, s)
+	return nil
+}
+
+// paths returns the paths of the configuration files. If no path
+// has been specified, it returns the default configuration file.
+func (c configFiles) paths() []string {
+	if len(c) == 0 {
+		return []string{"lava.yaml"}
+	}
+	return c
+}
+
+// labels is a [flag.Value] that collects labels with the format
+// "key=value".
+type labels map[string]string
+
+// String returns the labels as a comma-separated list of "key=value"
+// pairs sorted by key.
+func (l labels) String() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + l[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set parses a label with the format "key=value".
+func (l labels) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("invalid label: %q", s)
+	}
+	l[k] = v
+	return nil
+}
+
+// vars is a [flag.Value] that collects environment variables with
+// the format "name[=value]".
+type vars map[string]string
+
+// String returns the names of the variables sorted alphabetically and
+// separated by commas. The values are not included, because they
+// usually contain secrets.
+func (v vars) String() string {
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ",")
+}
+
+// Set parses an environment variable with the format "name[=value]".
+// If there is no equal sign, the value of the variable is got from
+// the environment.
+func (v vars) Set(s string) error {
+	name, value, found := strings.Cut(s, "=")
+	if name == "" {
+		return fmt.Errorf("invalid variable: %q", s)
+	}
+	if !found {
+		value = os.Getenv(name)
+	}
+	v[name] = value
+	return nil
+}
+
+// severityFlag is a [flag.Value] that parses a severity and records
+// whether it has been set.
+type severityFlag struct {
+	Value config.Severity
+	IsSet bool
+}
+
+// String returns the string representation of the severity. It
+// returns an empty string if the flag has not been set.
+func (f severityFlag) String() string {
+	if f.IsSet {
+		return f.Value.String()
+	}
+	return ""
+}
+
+// Set parses the provided severity. It returns error if it is not a
+// known severity.
+func (f *severityFlag) Set(s string) error {
+	if err := f.Value.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	f.IsSet = true
+	return nil
+}
+
+// formatFlag is a [flag.Value] that parses an output format and
+// records whether it has been set.
+type formatFlag struct {
+	Value config.OutputFormat
+	IsSet bool
+}
+
+// String returns the string representation of the output format. It
+// returns an empty string if the flag has not been set.
+func (f formatFlag) String() string {
+	if f.IsSet {
+		return f.Value.String()
+	}
+	return ""
+}
+
+// Set parses the provided output format. It returns error if it is
+// not a known output format.
+func (f *formatFlag) Set(s string) error {
+	if err := f.Value.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	f.IsSet = true
+	return nil
+}
+
+// controlFamilies is a [flag.Value] that collects control families.
+type controlFamilies []string
+
+// String returns the control families separated by commas.
+func (c controlFamilies) String() string {
+	return strings.Join(c, ",")
+}
+
+// Set parses a comma-separated list of control families and appends
+// them to the list.
+func (c *controlFamilies) Set(s string) error {
+	controls, err := checktypes.ParseControls(s)
+	if err != nil {
+		return err
+	}
+	for _, ctrl := range controls {
+		if !slices.Contains(*c, ctrl) {
+			*c = append(*c, ctrl)
+		}
+	}
+	return nil
+}
+
+// readTargetList reads the target list stored in the specified file.
+// If path is "-", the list is read from the standard input.
+func readTargetList(path string) ([]config.Target, error) {
+	if path == "-" {
+		return config.ParseTargetList(osStdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+	return config.ParseTargetList(f)
+}
@@ -3,13 +3,23 @@
 package scan
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"runtime/debug"
+	"strings"
 	"testing"
 
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
 	"github.com/jroimartin/clilog"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/engine"
 )
 
 func TestMain(m *testing.M) {
@@ -76,7 +86,7 @@ func TestRunScan(t *testing.T) {
 				debugReadBuildInfo = oldDebugReadBuildInfo
 			}()
 
-			scanC = "lava.yaml"
+			scanC = configFiles{"lava.yaml"}
 
 			var exitCode int
 			osExit = func(status int) {
@@ -121,3 +131,252 @@ func mustChdir(path string) {
 		panic(err)
 	}
 }
+
+func TestFailureExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantOK   bool
+	}{
+		{
+			name:     "runtime unreachable",
+			err:      fmt.Errorf("engine initialization: %w", engine.ErrRuntimeUnreachable),
+			wantCode: exitCodeRuntimeUnreachable,
+			wantOK:   true,
+		},
+		{
+			name:     "registry auth",
+			err:      fmt.Errorf("engine run: %w", engine.ErrRegistryAuth),
+			wantCode: exitCodeRegistryAuth,
+			wantOK:   true,
+		},
+		{
+			name:     "image pull denied",
+			err:      fmt.Errorf("engine run: %w", engine.ErrImagePullDenied),
+			wantCode: exitCodeImagePullDenied,
+			wantOK:   true,
+		},
+		{
+			name:     "unavailable images",
+			err:      fmt.Errorf("engine run: %w", engine.ErrUnavailableImages),
+			wantCode: exitCodeImagePullDenied,
+			wantOK:   true,
+		},
+		{
+			name:     "OOM killed",
+			err:      fmt.Errorf("engine run: %w", engine.ErrOOMKilled),
+			wantCode: exitCodeOOMKilled,
+			wantOK:   true,
+		},
+		{
+			name:     "unknown",
+			err:      errors.New("engine run: run agent: exit code 1"),
+			wantCode: 0,
+			wantOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, ok := failureExitCode(tt.err)
+			if code != tt.wantCode || ok != tt.wantOK {
+				t.Errorf("unexpected exit code: want: %v, %v, got: %v, %v", tt.wantCode, tt.wantOK, code, ok)
+			}
+		})
+	}
+}
+
+func TestReadTargetList_stdin(t *testing.T) {
+	oldOsStdin := osStdin
+	defer func() { osStdin = oldOsStdin }()
+
+	osStdin = strings.NewReader("DockerImage alpine:latest\nPath .\n")
+
+	got, err := readTargetList("-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []config.Target{
+		{Identifier: "alpine:latest", AssetType: types.DockerImage},
+		{Identifier: ".", AssetType: assettypes.Path},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("targets mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	l := labels{}
+	for _, s := range []string{"pipeline=nightly", "commit=0123", "empty="} {
+		if err := l.Set(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := labels{"pipeline": "nightly", "commit": "0123", "empty": ""}
+	if diff := cmp.Diff(want, l); diff != "" {
+		t.Errorf("labels mismatch (-want +got):\n%v", diff)
+	}
+
+	if got, want := l.String(), "commit=0123,empty=,pipeline=nightly"; got != want {
+		t.Errorf("unexpected string: got: %q, want: %q", got, want)
+	}
+
+	for _, s := range []string{"invalid", "=value"} {
+		if err := l.Set(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	var c configFiles
+	if diff := cmp.Diff([]string{"lava.yaml"}, c.paths()); diff != "" {
+		t.Errorf("default paths mismatch (-want +got):\n%v", diff)
+	}
+
+	for _, s := range []string{"lava.yaml", "-"} {
+		if err := c.Set(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"lava.yaml", "-"}, c.paths()); diff != "" {
+		t.Errorf("paths mismatch (-want +got):\n%v", diff)
+	}
+
+	if got, want := c.String(), "lava.yaml,-"; got != want {
+		t.Errorf("unexpected string: got: %q, want: %q", got, want)
+	}
+
+	if err := c.Set(""); err == nil {
+		t.Errorf("expected error for empty path")
+	}
+}
+
+func TestVars(t *testing.T) {
+	t.Setenv("LAVA_TEST_VAR", "env")
+
+	v := vars{}
+	for _, s := range []string{"TOKEN=secret", "EMPTY=", "LAVA_TEST_VAR", "EQUALS=a=b"} {
+		if err := v.Set(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := vars{"TOKEN": "secret", "EMPTY": "", "LAVA_TEST_VAR": "env", "EQUALS": "a=b"}
+	if diff := cmp.Diff(want, v); diff != "" {
+		t.Errorf("vars mismatch (-want +got):\n%v", diff)
+	}
+
+	if got, want := v.String(), "EMPTY,EQUALS,LAVA_TEST_VAR,TOKEN"; got != want {
+		t.Errorf("unexpected string: got: %q, want: %q", got, want)
+	}
+
+	for _, s := range []string{"", "=value"} {
+		if err := v.Set(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestSeverityFlag(t *testing.T) {
+	var f severityFlag
+	if got := f.String(); got != "" {
+		t.Errorf("unexpected string: %q", got)
+	}
+
+	if err := f.Set("medium"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (severityFlag{Value: config.SeverityMedium, IsSet: true}); f != want {
+		t.Errorf("unexpected value: got: %v, want: %v", f, want)
+	}
+	if got, want := f.String(), "medium"; got != want {
+		t.Errorf("unexpected string: got: %q, want: %q", got, want)
+	}
+
+	if err := f.Set("unknown"); !errors.Is(err, config.ErrInvalidSeverity) {
+		t.Errorf("unexpected error: want: %v, got: %v", config.ErrInvalidSeverity, err)
+	}
+}
+
+func TestFormatFlag(t *testing.T) {
+	var f formatFlag
+	if got := f.String(); got != "" {
+		t.Errorf("unexpected string: %q", got)
+	}
+
+	if err := f.Set("json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (formatFlag{Value: config.OutputFormatJSON, IsSet: true}); f != want {
+		t.Errorf("unexpected value: got: %v, want: %v", f, want)
+	}
+	if got, want := f.String(), "json"; got != want {
+		t.Errorf("unexpected string: got: %q, want: %q", got, want)
+	}
+
+	if err := f.Set("unknown"); !errors.Is(err, config.ErrInvalidOutputFormat) {
+		t.Errorf("unexpected error: want: %v, got: %v", config.ErrInvalidOutputFormat, err)
+	}
+}
+
+func TestApplyReportFlags(t *testing.T) {
+	oldScanFmt, oldScanO := scanFmt, scanO
+	defer func() {
+		scanFmt, scanO = oldScanFmt, oldScanO
+	}()
+
+	scanFmt = formatFlag{Value: config.OutputFormatTemplate, IsSet: true}
+	scanO = "output.txt"
+
+	stdin := strings.NewReader(`
+lava: v1.0.0
+checktypes:
+  - checktypes.json
+targets:
+  - identifier: example.com
+    type: DomainName
+report:
+  format: json
+`)
+	override := func(cfg *config.Config) { applyReportFlags(&cfg.ReportConfig) }
+	_, err := config.ParseFilesWithOverride([]string{config.Stdin}, stdin, nil, override)
+	if !errors.Is(err, config.ErrInvalidOutputFormat) {
+		t.Errorf("unexpected error: want: %v, got: %v", config.ErrInvalidOutputFormat, err)
+	}
+
+	var rc config.ReportConfig
+	applyReportFlags(&rc)
+	want := config.ReportConfig{
+		Format:     &scanFmt.Value,
+		OutputFile: &scanO,
+	}
+	if diff := cmp.Diff(want, rc); diff != "" {
+		t.Errorf("report configs mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestControlFamilies(t *testing.T) {
+	var c controlFamilies
+	for _, s := range []string{"sast,sca", "secrets,sast"} {
+		if err := c.Set(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if diff := cmp.Diff(controlFamilies{"sast", "sca", "secrets"}, c); diff != "" {
+		t.Errorf("controls mismatch (-want +got):\n%v", diff)
+	}
+
+	if got, want := c.String(), "sast,sca,secrets"; got != want {
+		t.Errorf("unexpected string: got: %q, want: %q", got, want)
+	}
+
+	if err := c.Set("iast"); !errors.Is(err, checktypes.ErrUnknownControl) {
+		t.Errorf("unexpected error: want: %v, got: %v", checktypes.ErrUnknownControl, err)
+	}
+}
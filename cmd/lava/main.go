@@ -7,19 +7,25 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log/slog"
 	"os"
 	"strconv"
 
 	"github.com/fatih/color"
-	"github.com/jroimartin/clilog"
 
+	"github.com/adevinta/lava/cmd/lava/internal/badge"
 	"github.com/adevinta/lava/cmd/lava/internal/base"
+	"github.com/adevinta/lava/cmd/lava/internal/clean"
+	"github.com/adevinta/lava/cmd/lava/internal/configcmd"
+	"github.com/adevinta/lava/cmd/lava/internal/doctor"
 	"github.com/adevinta/lava/cmd/lava/internal/help"
+	"github.com/adevinta/lava/cmd/lava/internal/history"
 	"github.com/adevinta/lava/cmd/lava/internal/initialize"
+	"github.com/adevinta/lava/cmd/lava/internal/newchecktype"
+	"github.com/adevinta/lava/cmd/lava/internal/reportcmd"
 	"github.com/adevinta/lava/cmd/lava/internal/run"
 	"github.com/adevinta/lava/cmd/lava/internal/scan"
 	"github.com/adevinta/lava/cmd/lava/internal/version"
+	"github.com/adevinta/lava/internal/config"
 )
 
 func init() {
@@ -27,6 +33,13 @@ func init() {
 		scan.CmdScan,
 		run.CmdRun,
 		initialize.CmdInit,
+		newchecktype.CmdNewChecktype,
+		configcmd.CmdConfig,
+		doctor.CmdDoctor,
+		history.CmdHistory,
+		reportcmd.CmdReport,
+		badge.CmdBadge,
+		clean.CmdClean,
 		version.CmdVersion,
 
 		help.HelpEnvironment,
@@ -37,8 +50,7 @@ func init() {
 }
 
 func main() {
-	h := clilog.NewCLIHandler(os.Stderr, &clilog.HandlerOptions{Level: base.LogLevel})
-	slog.SetDefault(slog.New(h))
+	base.SetLogger(config.LogFormatText, nil)
 
 	flag.Usage = func() {
 		help.PrintUsage(os.Stderr)
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"slices"
 
 	types "github.com/adevinta/vulcan-types"
@@ -20,16 +21,22 @@ var ErrUnsupported = errors.New("unsupported asset type")
 
 // Lava asset types.
 const (
-	Path = types.AssetType("Path")
+	Path               = types.AssetType("Path")
+	DockerImageArchive = types.AssetType("DockerImageArchive")
+	OCILayout          = types.AssetType("OCILayout")
+	TerraformModule    = types.AssetType("TerraformModule")
 )
 
 // vulcanMap is the mapping between Lava and Vulcan asset types.
 var vulcanMap = map[types.AssetType]types.AssetType{
-	Path: types.GitRepository,
+	Path:               types.GitRepository,
+	DockerImageArchive: types.DockerImage,
+	OCILayout:          types.DockerImage,
+	TerraformModule:    types.GitRepository,
 }
 
 // lavaTypes is the list of all Lava asset types.
-var lavaTypes = []types.AssetType{Path}
+var lavaTypes = []types.AssetType{Path, DockerImageArchive, OCILayout, TerraformModule}
 
 // IsValid reports whether the provided asset type is valid in the
 // context of Lava.
@@ -74,8 +81,66 @@ func CheckReachable(typ types.AssetType, ident string) error {
 		if _, err := os.Stat(ident); err != nil {
 			return err
 		}
+	case DockerImageArchive:
+		info, err := os.Stat(ident)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("is a directory")
+		}
+	case OCILayout:
+		info, err := os.Stat(ident)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("not a directory")
+		}
+		if _, err := os.Stat(filepath.Join(ident, "oci-layout")); err != nil {
+			return fmt.Errorf("not an OCI layout: %w", err)
+		}
+	case TerraformModule:
+		info, err := os.Stat(ident)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("not a directory")
+		}
+		found, err := hasTerraformFiles(ident)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("no Terraform files")
+		}
 	default:
 		return ErrUnsupported
 	}
 	return nil
 }
+
+// hasTerraformFiles reports whether the provided directory or any of
+// its subdirectories contains Terraform files (*.tf). The ".git" and
+// ".terraform" directories are ignored.
+func hasTerraformFiles(dir string) (bool, error) {
+	var found bool
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (d.Name() == ".git" || d.Name() == ".terraform") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".tf" {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
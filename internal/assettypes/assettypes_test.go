@@ -55,6 +55,11 @@ func TestToVulcan(t *testing.T) {
 			at:   Path,
 			want: types.GitRepository,
 		},
+		{
+			name: "image archive",
+			at:   DockerImageArchive,
+			want: types.DockerImage,
+		},
 		{
 			name: "vulcan type",
 			at:   types.Hostname,
@@ -121,6 +126,66 @@ func TestCheckReachable(t *testing.T) {
 			ident:   "notexists",
 			wantErr: fs.ErrNotExist,
 		},
+		{
+			name:    "image archive file",
+			typ:     DockerImageArchive,
+			ident:   "testdata/foo.txt",
+			wantErr: nil,
+		},
+		{
+			name:          "image archive folder",
+			typ:           DockerImageArchive,
+			ident:         "testdata",
+			wantErrRegexp: regexp.MustCompile(`^is a directory$`),
+		},
+		{
+			name:    "image archive not exists",
+			typ:     DockerImageArchive,
+			ident:   "notexists",
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name:    "OCI layout",
+			typ:     OCILayout,
+			ident:   "testdata/ocilayout",
+			wantErr: nil,
+		},
+		{
+			name:    "OCI layout without oci-layout file",
+			typ:     OCILayout,
+			ident:   "testdata",
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name:          "OCI layout file",
+			typ:           OCILayout,
+			ident:         "testdata/foo.txt",
+			wantErrRegexp: regexp.MustCompile(`^not a directory$`),
+		},
+		{
+			name:    "terraform module",
+			typ:     TerraformModule,
+			ident:   "testdata/terraform",
+			wantErr: nil,
+		},
+		{
+			name:          "terraform module without tf files",
+			typ:           TerraformModule,
+			ident:         "testdata/ocilayout",
+			wantErrRegexp: regexp.MustCompile(`^no Terraform files$`),
+		},
+		{
+			name:          "terraform module file",
+			typ:           TerraformModule,
+			ident:         "testdata/foo.txt",
+			wantErrRegexp: regexp.MustCompile(`^not a directory$`),
+		},
+		{
+			name:    "terraform module not exists",
+			typ:     TerraformModule,
+			ident:   "notexists",
+			wantErr: fs.ErrNotExist,
+		},
 		{
 			name:    "unsupported asset type",
 			typ:     types.AWSAccount,
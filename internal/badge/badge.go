@@ -0,0 +1,137 @@
+// Copyright 2024 Adevinta
+
+// Package badge renders shields.io-style SVG badges.
+package badge
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Badge represents a badge.
+type Badge struct {
+	// Label is the text shown on the left side of the badge.
+	Label string
+
+	// Message is the text shown on the right side of the badge.
+	Message string
+
+	// Color is the background color of the message. It can be
+	// one of the named colors supported by shields.io (e.g.
+	// "brightgreen", "red") or a hexadecimal color (e.g.
+	// "#4c1").
+	Color string
+}
+
+// namedColors maps the named colors supported by shields.io with
+// their hexadecimal value.
+var namedColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"lightgrey":   "#9f9f9f",
+}
+
+// GradeColor returns the color corresponding to a security grade
+// letter.
+func GradeColor(letter string) string {
+	switch letter {
+	case "A":
+		return "brightgreen"
+	case "B":
+		return "green"
+	case "C":
+		return "yellow"
+	case "D":
+		return "orange"
+	default:
+		return "red"
+	}
+}
+
+// horizPadding is the horizontal padding of every side of the badge
+// in pixels.
+const horizPadding = 6
+
+// svgTmpl is the template used to render the badge.
+var svgTmpl = template.Must(template.New("").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Message}}">
+<title>{{.Label}}: {{.Message}}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="{{.LabelWidth}}" height="20" fill="#555"/><rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/><rect width="{{.Width}}" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text><text x="{{.LabelX}}" y="14">{{.Label}}</text>
+<text x="{{.MessageX}}" y="15" fill="#010101" fill-opacity=".3">{{.Message}}</text><text x="{{.MessageX}}" y="14">{{.Message}}</text>
+</g>
+</svg>
+`))
+
+// Render renders the badge as SVG and writes it to w.
+func (b Badge) Render(w io.Writer) error {
+	color := b.Color
+	if c, ok := namedColors[color]; ok {
+		color = c
+	}
+
+	labelWidth := textWidth(b.Label) + 2*horizPadding
+	msgWidth := textWidth(b.Message) + 2*horizPadding
+
+	data := struct {
+		Label        string
+		Message      string
+		Color        string
+		Width        int
+		LabelWidth   int
+		MessageWidth int
+		LabelX       float64
+		MessageX     float64
+	}{
+		Label:        escape(b.Label),
+		Message:      escape(b.Message),
+		Color:        escape(color),
+		Width:        labelWidth + msgWidth,
+		LabelWidth:   labelWidth,
+		MessageWidth: msgWidth,
+		LabelX:       float64(labelWidth) / 2,
+		MessageX:     float64(labelWidth) + float64(msgWidth)/2,
+	}
+
+	if err := svgTmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+	return nil
+}
+
+// textWidth returns an approximation of the width in pixels of the
+// provided text rendered with an 11px Verdana font.
+func textWidth(s string) int {
+	var width int
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("fijlrt.,:;!|' ", r):
+			width += 4
+		case strings.ContainsRune("mwMW", r):
+			width += 11
+		case r >= 'A' && r <= 'Z':
+			width += 8
+		default:
+			width += 7
+		}
+	}
+	return width
+}
+
+// escape escapes the XML special characters of s.
+func escape(s string) string {
+	var b strings.Builder
+	// EscapeText only fails if the writer fails.
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
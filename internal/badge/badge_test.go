@@ -0,0 +1,82 @@
+// Copyright 2024 Adevinta
+
+package badge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBadge_Render(t *testing.T) {
+	tests := []struct {
+		name      string
+		badge     Badge
+		wantColor string
+		wantTexts []string
+	}{
+		{
+			name: "named color",
+			badge: Badge{
+				Label:   "security",
+				Message: "passing",
+				Color:   "brightgreen",
+			},
+			wantColor: `fill="#4c1"`,
+			wantTexts: []string{">security<", ">passing<"},
+		},
+		{
+			name: "hex color",
+			badge: Badge{
+				Label:   "security",
+				Message: "1 critical",
+				Color:   "#123456",
+			},
+			wantColor: `fill="#123456"`,
+			wantTexts: []string{">1 critical<"},
+		},
+		{
+			name: "escaped text",
+			badge: Badge{
+				Label:   "a<b",
+				Message: "c&d",
+				Color:   "red",
+			},
+			wantColor: `fill="#e05d44"`,
+			wantTexts: []string{">a&lt;b<", ">c&amp;d<"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.badge.Render(&buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			svg := buf.String()
+
+			// Check that the output is well-formed XML.
+			dec := xml.NewDecoder(strings.NewReader(svg))
+			for {
+				if _, err := dec.Token(); err != nil {
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					t.Fatalf("malformed SVG: %v", err)
+				}
+			}
+
+			if !strings.Contains(svg, tt.wantColor) {
+				t.Errorf("color not found: %v", tt.wantColor)
+			}
+			for _, text := range tt.wantTexts {
+				if !strings.Contains(svg, text) {
+					t.Errorf("text not found: %v", text)
+				}
+			}
+		})
+	}
+}
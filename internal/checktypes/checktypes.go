@@ -8,10 +8,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
 	types "github.com/adevinta/vulcan-types"
 
+	"github.com/adevinta/lava/internal/metrics"
 	"github.com/adevinta/lava/internal/urlutil"
 )
 
@@ -19,6 +22,39 @@ import (
 // the retrieved catalog is not valid.
 var ErrMalformedCatalog = errors.New("malformed catalog")
 
+// ErrUnknownControl is returned when a control family is not known.
+var ErrUnknownControl = errors.New("unknown control")
+
+// Control families. Catalogs tag their checktypes with the control
+// families they implement using the "labels" field of the
+// checktypes.
+const (
+	ControlSAST    = "sast"
+	ControlSCA     = "sca"
+	ControlDAST    = "dast"
+	ControlSecrets = "secrets"
+)
+
+// Controls contains all the known control families.
+var Controls = []string{ControlSAST, ControlSCA, ControlDAST, ControlSecrets}
+
+// ParseControls parses a comma-separated list of control families.
+// It returns an error wrapping [ErrUnknownControl] if any of them is
+// not known.
+func ParseControls(s string) ([]string, error) {
+	var controls []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !slices.Contains(Controls, c) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownControl, c)
+		}
+		if !slices.Contains(controls, c) {
+			controls = append(controls, c)
+		}
+	}
+	return controls, nil
+}
+
 // Accepts reports whether the specified checktype accepts an asset
 // type.
 func Accepts(ct checkcatalog.Checktype, at types.AssetType) bool {
@@ -33,29 +69,100 @@ func Accepts(ct checkcatalog.Checktype, at types.AssetType) bool {
 // Catalog represents a collection of Vulcan checktypes.
 type Catalog map[string]checkcatalog.Checktype
 
+// ChecktypeControls contains the control families covered by the
+// checktypes of a catalog indexed by checktype name.
+type ChecktypeControls map[string][]string
+
 // NewCatalog retrieves the specified checktype catalogs and
 // consolidates them in a single catalog with all the checktypes
 // indexed by name. If a checktype is duplicated it is overridden with
-// the last one.
-func NewCatalog(urls []string) (Catalog, error) {
+// the last one. The catalogs present in integrity are verified before
+// being used. If controls is not empty, only the checktypes labeled
+// with any of the provided control families are kept. It also returns
+// the control families covered by every checktype of the catalog,
+// according to their labels. The digests of all the retrieved
+// catalogs are recorded in the "checktype_digests" metric and the
+// control families covered by the resulting catalog are recorded in
+// the "controls" metric.
+func NewCatalog(urls []string, integrity map[string]Integrity, controls []string) (Catalog, ChecktypeControls, error) {
 	catalog := make(Catalog)
+	ctControls := make(ChecktypeControls)
+	digests := make(map[string]string)
 	for _, url := range urls {
 		data, err := urlutil.Get(url)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		if in, ok := integrity[url]; ok {
+			if err := in.verify(data); err != nil {
+				return nil, nil, fmt.Errorf("verify catalog %v: %w", url, err)
+			}
 		}
+		digests[url] = digest(data)
 
 		var decData struct {
-			Checktypes []checkcatalog.Checktype `json:"checktypes"`
+			Checktypes []struct {
+				checkcatalog.Checktype
+				Labels []string `json:"labels"`
+			} `json:"checktypes"`
 		}
 		err = json.Unmarshal(data, &decData)
 		if err != nil {
-			return nil, fmt.Errorf("%w: %w", ErrMalformedCatalog, err)
+			return nil, nil, fmt.Errorf("%w: %w", ErrMalformedCatalog, err)
 		}
 
 		for _, checktype := range decData.Checktypes {
-			catalog[checktype.Name] = checktype
+			catalog[checktype.Name] = checktype.Checktype
+			if families := labelControls(checktype.Labels); len(families) > 0 {
+				ctControls[checktype.Name] = families
+			} else {
+				delete(ctControls, checktype.Name)
+			}
+		}
+	}
+	metrics.Collect("checktype_digests", digests)
+
+	if len(controls) > 0 {
+		for name := range catalog {
+			if !slices.ContainsFunc(ctControls[name], func(f string) bool {
+				return slices.Contains(controls, f)
+			}) {
+				delete(catalog, name)
+				delete(ctControls, name)
+			}
+		}
+	}
+	metrics.Collect("controls", ctControls.covered())
+
+	return catalog, ctControls, nil
+}
+
+// labelControls returns the control families in the provided list of
+// checktype labels. Labels are case insensitive and the ones that are
+// not control families are ignored.
+func labelControls(labels []string) []string {
+	var families []string
+	for _, l := range labels {
+		l = strings.ToLower(l)
+		if slices.Contains(Controls, l) && !slices.Contains(families, l) {
+			families = append(families, l)
+		}
+	}
+	return families
+}
+
+// covered returns the control families covered by any of the
+// checktypes sorted alphabetically.
+func (ctc ChecktypeControls) covered() []string {
+	covered := []string{}
+	for _, families := range ctc {
+		for _, f := range families {
+			if !slices.Contains(covered, f) {
+				covered = append(covered, f)
+			}
 		}
 	}
-	return catalog, nil
+	slices.Sort(covered)
+	return covered
 }
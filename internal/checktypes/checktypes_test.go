@@ -5,6 +5,7 @@ package checktypes
 import (
 	"errors"
 	"os"
+	"slices"
 	"testing"
 
 	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
@@ -59,10 +60,11 @@ func TestAccepts(t *testing.T) {
 
 func TestNewCatalog(t *testing.T) {
 	tests := []struct {
-		name    string
-		urls    []string
-		want    Catalog
-		wantErr error
+		name      string
+		urls      []string
+		integrity map[string]Integrity
+		want      Catalog
+		wantErr   error
 	}{
 		{
 			name: "valid file",
@@ -102,6 +104,98 @@ func TestNewCatalog(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "valid digest",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+			},
+			integrity: map[string]Integrity{
+				"testdata/checktype_catalog.json": {
+					Digest: "sha256:e028377a26b427990030a465787253271e4084be012c4e229dabe4b9acecf5e3",
+				},
+			},
+			want: Catalog{
+				"vulcan-drupal": {
+					Name:        "vulcan-drupal",
+					Description: "Checks for some vulnerable versions of Drupal.",
+					Image:       "vulcansec/vulcan-drupal:edge",
+					Assets: []string{
+						"Hostname",
+					},
+					RequiredVars: []any{
+						"REQUIRED_VAR_1",
+					},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "digest mismatch",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+			},
+			integrity: map[string]Integrity{
+				"testdata/checktype_catalog.json": {
+					Digest: "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+				},
+			},
+			want:    nil,
+			wantErr: ErrIntegrity,
+		},
+		{
+			name: "valid signature",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+			},
+			integrity: map[string]Integrity{
+				"testdata/checktype_catalog.json": {
+					Signature: "testdata/checktype_catalog.json.sig",
+					PublicKey: "testdata/cosign.pub",
+				},
+			},
+			want: Catalog{
+				"vulcan-drupal": {
+					Name:        "vulcan-drupal",
+					Description: "Checks for some vulnerable versions of Drupal.",
+					Image:       "vulcansec/vulcan-drupal:edge",
+					Assets: []string{
+						"Hostname",
+					},
+					RequiredVars: []any{
+						"REQUIRED_VAR_1",
+					},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid signature",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+			},
+			integrity: map[string]Integrity{
+				"testdata/checktype_catalog.json": {
+					Signature: "testdata/checktype_catalog_override.json.sig",
+					PublicKey: "testdata/cosign.pub",
+				},
+			},
+			want:    nil,
+			wantErr: ErrIntegrity,
+		},
+		{
+			name: "remote public key",
+			urls: []string{
+				"testdata/checktype_catalog.json",
+			},
+			integrity: map[string]Integrity{
+				"testdata/checktype_catalog.json": {
+					Signature: "testdata/checktype_catalog.json.sig",
+					PublicKey: "https://example.com/cosign.pub",
+				},
+			},
+			want:    nil,
+			wantErr: ErrRemotePublicKey,
+		},
 		{
 			name: "wrong file",
 			urls: []string{
@@ -122,7 +216,7 @@ func TestNewCatalog(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewCatalog(tt.urls)
+			got, _, err := NewCatalog(tt.urls, tt.integrity, nil)
 
 			if !errors.Is(err, tt.wantErr) {
 				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
@@ -134,3 +228,132 @@ func TestNewCatalog(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCatalog_controls(t *testing.T) {
+	tests := []struct {
+		name         string
+		controls     []string
+		want         []string
+		wantControls ChecktypeControls
+	}{
+		{
+			name:     "no controls",
+			controls: nil,
+			want:     []string{"vulcan-nmap", "vulcan-semgrep", "vulcan-trivy", "vulcan-zap"},
+			wantControls: ChecktypeControls{
+				"vulcan-semgrep": {"sast"},
+				"vulcan-trivy":   {"sca", "secrets"},
+				"vulcan-zap":     {"dast"},
+			},
+		},
+		{
+			name:     "single control",
+			controls: []string{ControlSAST},
+			want:     []string{"vulcan-semgrep"},
+			wantControls: ChecktypeControls{
+				"vulcan-semgrep": {"sast"},
+			},
+		},
+		{
+			name:     "multiple controls",
+			controls: []string{ControlSecrets, ControlDAST},
+			want:     []string{"vulcan-trivy", "vulcan-zap"},
+			wantControls: ChecktypeControls{
+				"vulcan-trivy": {"sca", "secrets"},
+				"vulcan-zap":   {"dast"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog, ctControls, err := NewCatalog([]string{"testdata/labeled_checktype_catalog.json"}, nil, tt.controls)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for name := range catalog {
+				got = append(got, name)
+			}
+			slices.Sort(got)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("checktypes mismatch (-want +got):\n%v", diff)
+			}
+			if diff := cmp.Diff(tt.wantControls, ctControls); diff != "" {
+				t.Errorf("controls mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestChecktypeControls_covered(t *testing.T) {
+	ctc := ChecktypeControls{
+		"vulcan-semgrep": {"sast"},
+		"vulcan-trivy":   {"sca", "secrets", "sast"},
+	}
+
+	want := []string{"sast", "sca", "secrets"}
+	if diff := cmp.Diff(want, ctc.covered()); diff != "" {
+		t.Errorf("controls mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestParseControls(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "valid controls",
+			s:    "sast, SCA,sast",
+			want: []string{"sast", "sca"},
+		},
+		{
+			name:    "unknown control",
+			s:       "sast,iast",
+			want:    nil,
+			wantErr: ErrUnknownControl,
+		},
+		{
+			name:    "empty control",
+			s:       "",
+			want:    nil,
+			wantErr: ErrUnknownControl,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseControls(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("controls mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestIntegrity_verify_inlineKey(t *testing.T) {
+	data, err := os.ReadFile("testdata/checktype_catalog.json")
+	if err != nil {
+		t.Fatalf("read catalog: %v", err)
+	}
+	key, err := os.ReadFile("testdata/cosign.pub")
+	if err != nil {
+		t.Fatalf("read public key: %v", err)
+	}
+
+	integrity := Integrity{
+		Signature: "testdata/checktype_catalog.json.sig",
+		PublicKey: string(key),
+	}
+	if err := integrity.verify(data); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
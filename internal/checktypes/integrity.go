@@ -0,0 +1,155 @@
+// Copyright 2024 Adevinta
+
+package checktypes
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// ErrIntegrity is returned by [NewCatalog] when a catalog does not
+// match its expected digest or signature.
+var ErrIntegrity = errors.New("catalog integrity check failed")
+
+// Integrity contains the data used to verify a checktype catalog.
+type Integrity struct {
+	// Digest is the expected digest of the catalog with the format
+	// "sha256:<hex>". If empty, the digest is not verified.
+	Digest string
+
+	// Signature is the URL of the base64-encoded signature of the
+	// catalog, as generated by "cosign sign-blob". If empty, the
+	// signature is not verified.
+	Signature string
+
+	// PublicKey is the PEM-encoded public key used to verify the
+	// signature or the path of a local file that contains it. Remote
+	// keys are not supported, because a key retrieved from the
+	// network could be tampered with along with the catalog.
+	PublicKey string
+}
+
+// ErrRemotePublicKey is returned by [NewCatalog] when the public key
+// used to verify the signature of a catalog is a URL.
+var ErrRemotePublicKey = errors.New("remote public keys are not supported")
+
+// IsInlineKey reports whether the provided public key is an inline
+// PEM-encoded key instead of the path of a file.
+func IsInlineKey(key string) bool {
+	return strings.HasPrefix(strings.TrimSpace(key), "-----BEGIN ")
+}
+
+// IsRemoteKey reports whether the provided public key is a URL.
+func IsRemoteKey(key string) bool {
+	if IsInlineKey(key) {
+		return false
+	}
+	u, err := url.Parse(key)
+	return err == nil && len(u.Scheme) > 1
+}
+
+// digest returns the SHA-256 digest of the provided data with the
+// format "sha256:<hex>".
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// verify verifies the provided catalog data. It returns an error
+// wrapping [ErrIntegrity] if the data does not match the expected
+// digest or signature.
+func (integrity Integrity) verify(data []byte) error {
+	if integrity.Digest != "" {
+		if d := digest(data); d != integrity.Digest {
+			return fmt.Errorf("%w: digest mismatch: got %v, want %v", ErrIntegrity, d, integrity.Digest)
+		}
+	}
+
+	if integrity.Signature == "" {
+		return nil
+	}
+
+	encSig, err := urlutil.Get(integrity.Signature)
+	if err != nil {
+		return fmt.Errorf("get signature: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encSig)))
+	if err != nil {
+		return fmt.Errorf("%w: decode signature: %w", ErrIntegrity, err)
+	}
+
+	pemKey, err := integrity.publicKey()
+	if err != nil {
+		return fmt.Errorf("get public key: %w", err)
+	}
+	pub, err := parsePublicKey(pemKey)
+	if err != nil {
+		return fmt.Errorf("parse public key: %w", err)
+	}
+
+	if err := verifySignature(pub, data, sig); err != nil {
+		return fmt.Errorf("%w: %w", ErrIntegrity, err)
+	}
+	return nil
+}
+
+// publicKey returns the PEM-encoded public key. It is either inline
+// or read from a local file. It returns [ErrRemotePublicKey] if the
+// public key is a URL.
+func (integrity Integrity) publicKey() ([]byte, error) {
+	switch {
+	case IsInlineKey(integrity.PublicKey):
+		return []byte(integrity.PublicKey), nil
+	case IsRemoteKey(integrity.PublicKey):
+		return nil, fmt.Errorf("%w: %v", ErrRemotePublicKey, integrity.PublicKey)
+	}
+	return os.ReadFile(integrity.PublicKey)
+}
+
+// parsePublicKey parses a PEM-encoded PKIX public key.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// verifySignature verifies the signature of the provided data. The
+// signatures generated with ECDSA and RSA keys are computed over the
+// SHA-256 hash of the data, like cosign does.
+func verifySignature(pub crypto.PublicKey, data, sig []byte) error {
+	hash := sha256.Sum256(data)
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, hash[:], sig) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig); err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, sig) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type: %T", pub)
+	}
+	return nil
+}
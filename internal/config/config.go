@@ -8,8 +8,15 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +26,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/checktypes"
 )
 
 var (
@@ -27,10 +35,18 @@ var (
 	// Specification.
 	ErrInvalidLavaVersion = errors.New("invalid Lava version")
 
+	// ErrUnknownField means that the configuration contains a
+	// field that is not supported by this version of Lava.
+	ErrUnknownField = errors.New("unknown configuration field")
+
 	// ErrNoChecktypeURLs means that no checktypes URLs were
 	// specified.
 	ErrNoChecktypeURLs = errors.New("no checktype catalogs")
 
+	// ErrInvalidChecktypesIntegrity means that the integrity
+	// configuration of the checktype catalogs is invalid.
+	ErrInvalidChecktypesIntegrity = errors.New("invalid checktypes integrity")
+
 	// ErrNoTargets means that no targets were specified.
 	ErrNoTargets = errors.New("no targets")
 
@@ -45,6 +61,10 @@ var (
 	// ErrInvalidAssetType means that the asset type is invalid.
 	ErrInvalidAssetType = errors.New("invalid asset type")
 
+	// ErrInvalidTargetIdentifier means that the target identifier
+	// is not valid for its asset type.
+	ErrInvalidTargetIdentifier = errors.New("invalid target identifier")
+
 	// ErrInvalidSeverity means that the severity is invalid.
 	ErrInvalidSeverity = errors.New("invalid severity")
 
@@ -52,9 +72,106 @@ var (
 	// invalid.
 	ErrInvalidOutputFormat = errors.New("invalid output format")
 
+	// ErrInvalidColumn means that a column of the CSV output is
+	// invalid.
+	ErrInvalidColumn = errors.New("invalid column")
+
+	// ErrInvalidTheme means that the color theme is invalid.
+	ErrInvalidTheme = errors.New("invalid theme")
+
+	// ErrInvalidWidth means that the width of the output is
+	// invalid.
+	ErrInvalidWidth = errors.New("invalid width")
+
+	// ErrInvalidReportLimit means that a size limit of the report
+	// is invalid.
+	ErrInvalidReportLimit = errors.New("invalid report limit")
+
 	// ErrInvalidExpirationDate means that the expiration date is
 	// invalid.
 	ErrInvalidExpirationDate = errors.New("invalid expiration date")
+
+	// ErrInvalidSSE means that the server-side encryption
+	// algorithm is invalid.
+	ErrInvalidSSE = errors.New("invalid server-side encryption")
+
+	// ErrInvalidExclusion means that the exclusion contains an
+	// invalid regular expression.
+	ErrInvalidExclusion = errors.New("invalid exclusion")
+
+	// ErrMissingExclusionMetadata means that an exclusion does not
+	// have the metadata required by the report configuration.
+	ErrMissingExclusionMetadata = errors.New("missing exclusion metadata")
+
+	// ErrInvalidExclusionKey means that a key used to verify the
+	// signatures of the exclusions is invalid.
+	ErrInvalidExclusionKey = errors.New("invalid exclusion key")
+
+	// ErrInvalidExclusionExpiry means that the configuration of
+	// the exclusion expiration warnings is invalid.
+	ErrInvalidExclusionExpiry = errors.New("invalid exclusion expiry configuration")
+
+	// ErrInvalidSLA means that the SLA configuration is invalid.
+	ErrInvalidSLA = errors.New("invalid SLA configuration")
+
+	// ErrInvalidSeverityRule means that a severity rule is
+	// invalid.
+	ErrInvalidSeverityRule = errors.New("invalid severity rule")
+
+	// ErrInvalidEncryption means that the encryption
+	// configuration is invalid.
+	ErrInvalidEncryption = errors.New("invalid encryption configuration")
+
+	// ErrInvalidAgentConfig means that the agent configuration
+	// is invalid.
+	ErrInvalidAgentConfig = errors.New("invalid agent configuration")
+
+	// ErrInvalidTargetAuth means that the authentication of a
+	// target is invalid.
+	ErrInvalidTargetAuth = errors.New("invalid target authentication")
+
+	// ErrInvalidRateLimit means that the rate limit of a target is
+	// invalid.
+	ErrInvalidRateLimit = errors.New("invalid rate limit")
+
+	// ErrInvalidUDPPort means that a UDP port of a target is
+	// invalid.
+	ErrInvalidUDPPort = errors.New("invalid UDP port")
+
+	// ErrInvalidMapTarget means that the target mapping cannot be
+	// disabled for the asset type of a target.
+	ErrInvalidMapTarget = errors.New("invalid target mapping")
+
+	// ErrInvalidTerraformConfig means that the Terraform
+	// configuration of a target is invalid.
+	ErrInvalidTerraformConfig = errors.New("invalid Terraform configuration")
+
+	// ErrInvalidTargetSource means that a source of targets is
+	// invalid.
+	ErrInvalidTargetSource = errors.New("invalid target source")
+
+	// ErrInvalidScope means that the scope is invalid.
+	ErrInvalidScope = errors.New("invalid scope")
+
+	// ErrOutOfScope means that a target is outside the configured
+	// scope.
+	ErrOutOfScope = errors.New("target out of scope")
+
+	// ErrInvalidService means that the service is invalid.
+	ErrInvalidService = errors.New("invalid service")
+
+	// ErrInvalidLogFormat means that the log format is invalid.
+	ErrInvalidLogFormat = errors.New("invalid log format")
+
+	// ErrInvalidStage means that a stage is invalid.
+	ErrInvalidStage = errors.New("invalid stage")
+
+	// ErrInvalidInclude means that an included configuration file
+	// is invalid.
+	ErrInvalidInclude = errors.New("invalid include")
+
+	// ErrInvalidPathBase means that the path base is invalid.
+	ErrInvalidPathBase = errors.New("invalid path base")
 )
 
 // Config represents a Lava configuration.
@@ -62,6 +179,18 @@ type Config struct {
 	// LavaVersion is the minimum required version of Lava.
 	LavaVersion *string `yaml:"lava"`
 
+	// Includes is a list of paths or glob patterns of
+	// configuration files that are merged into this one. Relative
+	// paths are resolved against the directory of the including
+	// file.
+	Includes []string `yaml:"includes"`
+
+	// PathBase is the directory the relative paths of the
+	// configuration file are resolved against. If not specified,
+	// they are resolved against the directory of the
+	// configuration file.
+	PathBase *PathBase `yaml:"pathBase"`
+
 	// AgentConfig is the configuration of the vulcan-agent.
 	AgentConfig AgentConfig `yaml:"agent"`
 
@@ -72,161 +201,1519 @@ type Config struct {
 	// catalogs.
 	ChecktypeURLs []string `yaml:"checktypes"`
 
+	// ChecktypesIntegrity contains the data used to verify the
+	// checktype catalogs indexed by URL.
+	ChecktypesIntegrity map[string]CatalogIntegrity `yaml:"checktypesIntegrity"`
+
 	// Targets is the list of targets.
 	Targets []Target `yaml:"targets"`
 
+	// TargetsFrom is a list of sources of targets. The targets
+	// read from these sources are appended to Targets.
+	TargetsFrom []TargetSource `yaml:"targetsFrom"`
+
+	// Defaults contains the default settings of the targets
+	// indexed by asset type.
+	Defaults map[types.AssetType]TargetDefaults `yaml:"defaults"`
+
+	// Scope restricts the network targets that can be scanned.
+	Scope *Scope `yaml:"scope"`
+
+	// Discovery enables the discovery of new targets from the
+	// DomainName targets.
+	Discovery *DiscoveryConfig `yaml:"discovery"`
+
+	// Services is the list of auxiliary services that are started
+	// before running the checks.
+	Services []Service `yaml:"services"`
+
+	// Stages is the list of stages of the scan. If empty, all the
+	// checks run in a single stage.
+	Stages []Stage `yaml:"stages"`
+
 	// LogLevel is the logging level.
 	LogLevel *slog.Level `yaml:"log"`
+
+	// LogFormat is the format of the logs.
+	LogFormat *LogFormat `yaml:"logFormat"`
+
+	// LogFile is the path of a file where the logs are written
+	// in addition to stderr.
+	LogFile *string `yaml:"logFile"`
+
+	// LogFileMaxSize is the size in bytes that the log file can
+	// reach before being rotated.
+	LogFileMaxSize *int64 `yaml:"logFileMaxSize"`
+}
+
+// reEnv is used to replace embedded environment variables.
+var reEnv = regexp.MustCompile(`\$\{[a-zA-Z_][a-zA-Z_0-9]*\}`)
+
+// Parse returns a parsed Lava configuration given an [io.Reader].
+// Relative paths are resolved against the current working
+// directory.
+func Parse(r io.Reader) (Config, error) {
+	return parse(r, nil, "")
+}
+
+// parse returns a parsed Lava configuration given an [io.Reader].
+// The provided extra targets are appended to the targets of the
+// configuration before validating it. dir is the directory used to
+// resolve relative paths. If empty, relative paths are left
+// unchanged.
+func parse(r io.Reader, extra []Target, dir string) (Config, error) {
+	cfg, err := load(r, dir)
+	if err != nil {
+		return Config{}, err
+	}
+	return cfg.finalize(extra)
+}
+
+// load decodes the Lava configuration read from r and resolves its
+// includes. dir is the directory used to resolve relative paths.
+// The returned configuration is not validated.
+func load(r io.Reader, dir string) (Config, error) {
+	cfg, err := decode(r)
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg, err = cfg.resolveIncludes(dir, make(map[string]bool)); err != nil {
+		return Config{}, fmt.Errorf("resolve includes: %w", err)
+	}
+	return cfg, nil
+}
+
+// finalize expands the target sources of the configuration, appends
+// the provided extra targets, applies the target defaults and
+// normalizes and validates the resulting configuration.
+func (c Config) finalize(extra []Target) (Config, error) {
+	if err := c.expandTargetSources(); err != nil {
+		return Config{}, fmt.Errorf("expand target sources: %w", err)
+	}
+	c.Targets = append(c.Targets, extra...)
+	c.applyDefaults()
+	c.normalizeTargets()
+	if err := c.validate(); err != nil {
+		return Config{}, fmt.Errorf("validate config: %w", err)
+	}
+	return c, nil
+}
+
+// decode decodes the Lava configuration read from r. Embedded
+// environment variables are replaced before decoding. The returned
+// configuration is not validated.
+func decode(r io.Reader) (Config, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+
+	s := reEnv.ReplaceAllStringFunc(string(b), func(match string) string {
+		return os.Getenv(match[2 : len(match)-1])
+	})
+
+	// Check the fields before decoding the configuration, so the
+	// user gets a meaningful error if the configuration uses
+	// fields introduced in a newer version of Lava.
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+		return Config{}, fmt.Errorf("decode config: %w", err)
+	}
+	if err := checkFields(&doc); err != nil {
+		return Config{}, fmt.Errorf("decode config: %w", err)
+	}
+
+	dec := yaml.NewDecoder(strings.NewReader(s))
+
+	// Ensure that the keys in the read data exist as fields in
+	// the struct being decoded into.
+	dec.KnownFields(true)
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("decode config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ParseFile returns a parsed Lava configuration given a path to a
+// file.
+func ParseFile(path string) (Config, error) {
+	return ParseFileWithTargets(path, nil)
+}
+
+// ParseFileWithTargets returns a parsed Lava configuration given a
+// path to a file. The provided extra targets are merged with the
+// targets of the configuration, so the configuration file does not
+// need to declare any target if extra is not empty. Relative paths
+// are resolved against the directory of the file, unless the
+// configuration sets its path base to [PathBaseCWD]. The paths of
+// the extra targets are not modified.
+func ParseFileWithTargets(path string, extra []Target) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+	return parse(f, extra, filepath.Dir(path))
+}
+
+// Stdin is the path that refers to the standard input in the
+// arguments of [ParseFilesWithTargets].
+const Stdin = "-"
+
+// ParseFilesWithTargets returns a parsed Lava configuration given
+// the paths to a list of files. The configuration files are merged
+// in order, as if every file included the previous ones. So, the
+// settings of a file take precedence over the settings of the
+// previous files and lists are concatenated. If a path is [Stdin],
+// the configuration is read from stdin and its relative paths are
+// resolved against the current working directory. The provided
+// extra targets are merged with the targets of the configuration
+// like in [ParseFileWithTargets].
+func ParseFilesWithTargets(paths []string, stdin io.Reader, extra []Target) (Config, error) {
+	return ParseFilesWithOverride(paths, stdin, extra, nil)
+}
+
+// ParseFilesWithOverride is like [ParseFilesWithTargets], but it
+// calls override with the merged configuration before validating
+// it. It allows to override the settings of the configuration
+// files, for instance, with command-line flags, so the resulting
+// configuration is validated as a whole. If override is nil, the
+// configuration is not modified.
+func ParseFilesWithOverride(paths []string, stdin io.Reader, extra []Target, override func(*Config)) (Config, error) {
+	if len(paths) == 0 {
+		return Config{}, errors.New("no configuration files")
+	}
+
+	var cfg Config
+	for i, path := range paths {
+		if path == Stdin && slices.Index(paths, Stdin) != i {
+			return Config{}, errors.New("stdin specified more than once")
+		}
+
+		c, err := loadFile(path, stdin)
+		if err != nil {
+			return Config{}, fmt.Errorf("load %v: %w", path, err)
+		}
+
+		if i == 0 {
+			cfg = c
+			continue
+		}
+		if cfg, err = merge(cfg, c); err != nil {
+			return Config{}, fmt.Errorf("merge %v: %w", path, err)
+		}
+	}
+	if override != nil {
+		override(&cfg)
+	}
+	return cfg.finalize(extra)
+}
+
+// loadFile decodes the configuration file at the specified path and
+// resolves its includes. If path is [Stdin], the configuration is
+// read from stdin.
+func loadFile(path string, stdin io.Reader) (Config, error) {
+	if path == Stdin {
+		return load(stdin, "")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+	return load(f, filepath.Dir(path))
+}
+
+// validate validates the Lava configuration.
+func (c Config) validate() error {
+	// Lava version validation.
+	if !semver.IsValid(Get(c.LavaVersion)) {
+		return ErrInvalidLavaVersion
+	}
+
+	// Checktype URLs validation.
+	if len(c.ChecktypeURLs) == 0 {
+		return ErrNoChecktypeURLs
+	}
+	for url, integrity := range c.ChecktypesIntegrity {
+		if !slices.Contains(c.ChecktypeURLs, url) {
+			return fmt.Errorf("%w: unknown catalog: %v", ErrInvalidChecktypesIntegrity, url)
+		}
+		if err := integrity.validate(); err != nil {
+			return fmt.Errorf("catalog %v: %w", url, err)
+		}
+	}
+
+	// Targets validation.
+	if len(c.Targets) == 0 {
+		return ErrNoTargets
+	}
+	if err := c.validateDefaults(); err != nil {
+		return err
+	}
+	for _, t := range c.Targets {
+		if err := t.validate(); err != nil {
+			return err
+		}
+	}
+
+	// Scope validation.
+	if c.Scope != nil {
+		if err := c.Scope.validate(); err != nil {
+			return err
+		}
+	}
+
+	// Services validation.
+	names := make(map[string]bool)
+	for _, svc := range c.Services {
+		if err := svc.validate(); err != nil {
+			return err
+		}
+		if names[svc.Name] {
+			return fmt.Errorf("%w: duplicated name: %v", ErrInvalidService, svc.Name)
+		}
+		names[svc.Name] = true
+	}
+
+	// Stages validation.
+	if _, err := SortStages(c.Stages); err != nil {
+		return err
+	}
+
+	// Agent validation.
+	if err := c.AgentConfig.validate(); err != nil {
+		return err
+	}
+
+	// Report validation.
+	if err := c.ReportConfig.validate(); err != nil {
+		return err
+	}
+	if Get(c.ReportConfig.RequireExclusionMetadata) {
+		for _, t := range c.Targets {
+			for i, excl := range t.Exclusions {
+				if err := excl.checkMetadata(); err != nil {
+					return fmt.Errorf("%v: exclusion %v: %w", t, i, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validate validates the report configuration.
+func (c ReportConfig) validate() error {
+	if Get(c.Format) == OutputFormatTemplate && Get(c.Template) == "" {
+		return fmt.Errorf("%w: no template", ErrInvalidOutputFormat)
+	}
+	for _, col := range c.Columns {
+		if !slices.Contains(CSVColumns, col) {
+			return fmt.Errorf("%w: %v", ErrInvalidColumn, col)
+		}
+	}
+	if w := Get(c.Width); w < 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidWidth, w)
+	}
+	if n := Get(c.MaxFindings); n < 0 {
+		return fmt.Errorf("%w: maxFindings: %v", ErrInvalidReportLimit, n)
+	}
+	if n := Get(c.MaxDetailSize); n < 0 {
+		return fmt.Errorf("%w: maxDetailSize: %v", ErrInvalidReportLimit, n)
+	}
+	if err := c.Upload.validate(); err != nil {
+		return err
+	}
+	if err := c.Encryption.validate(); err != nil {
+		return err
+	}
+	if c.Encryption.IsEnabled() && Get(c.OutputFile) == "" {
+		// Both the output and the full output files are
+		// encrypted, but a full output file alone is not
+		// enough, because the primary report would be printed
+		// in plaintext.
+		return fmt.Errorf("%w: no output file", ErrInvalidEncryption)
+	}
+	for i, excl := range c.Exclusions {
+		if err := excl.validate(); err != nil {
+			return fmt.Errorf("exclusion %v: %w", i, err)
+		}
+	}
+	if err := c.ExclusionExpiry.validate(); err != nil {
+		return err
+	}
+	if err := c.SLA.validate(); err != nil {
+		return err
+	}
+	if len(c.SLA.Deadlines) > 0 && Get(c.History) == "" {
+		return fmt.Errorf("%w: no history database", ErrInvalidSLA)
+	}
+	ruleChecktypes := make(map[string]bool)
+	for _, rule := range c.SeverityRules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+		if ruleChecktypes[rule.Checktype] {
+			return fmt.Errorf("%w: duplicated checktype: %v", ErrInvalidSeverityRule, rule.Checktype)
+		}
+		ruleChecktypes[rule.Checktype] = true
+	}
+	for i, key := range c.ExclusionKeys {
+		if _, err := ParseExclusionKey(key); err != nil {
+			return fmt.Errorf("exclusion key %v: %w", i, err)
+		}
+	}
+	if Get(c.RequireExclusionMetadata) {
+		for i, excl := range c.Exclusions {
+			if err := excl.checkMetadata(); err != nil {
+				return fmt.Errorf("exclusion %v: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// IsCompatible reports whether the configuration is compatible with
+// the specified version. An invalid semantic version string is
+// considered incompatible.
+func (c Config) IsCompatible(v string) bool {
+	return semver.Compare(v, Get(c.LavaVersion)) >= 0
+}
+
+// CatalogIntegrity contains the data used to verify a checktype
+// catalog before using it.
+type CatalogIntegrity struct {
+	// Digest is the expected digest of the catalog with the format
+	// "sha256:<hex>".
+	Digest *string `yaml:"digest"`
+
+	// Signature is the URL of the cosign signature of the
+	// catalog. It is the base64-encoded signature generated by
+	// "cosign sign-blob".
+	Signature *string `yaml:"signature"`
+
+	// PublicKey is the path of the PEM-encoded public key used to
+	// verify the signature or the PEM-encoded key itself. URLs are
+	// not allowed.
+	PublicKey *string `yaml:"publicKey"`
+}
+
+// reDigest matches a valid SHA-256 digest.
+var reDigest = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// validate validates the integrity configuration of a checktype
+// catalog.
+func (c CatalogIntegrity) validate() error {
+	if c.Digest == nil && c.Signature == nil {
+		return fmt.Errorf("%w: no digest or signature", ErrInvalidChecktypesIntegrity)
+	}
+	if c.Digest != nil && !reDigest.MatchString(*c.Digest) {
+		return fmt.Errorf("%w: invalid digest: %q", ErrInvalidChecktypesIntegrity, *c.Digest)
+	}
+	if (c.Signature == nil) != (c.PublicKey == nil) {
+		return fmt.Errorf("%w: signature and publicKey must be specified together", ErrInvalidChecktypesIntegrity)
+	}
+	if c.PublicKey != nil && checktypes.IsRemoteKey(*c.PublicKey) {
+		return fmt.Errorf("%w: publicKey must be a local path or a PEM-encoded key: %v", ErrInvalidChecktypesIntegrity, *c.PublicKey)
+	}
+	return nil
+}
+
+// AgentConfig is the configuration passed to the vulcan-agent.
+type AgentConfig struct {
+	// PullPolicy is the pull policy passed to vulcan-agent.
+	PullPolicy *agentconfig.PullPolicy `yaml:"pullPolicy"`
+
+	// Parallel is the maximum number of checks that can run in
+	// parallel.
+	Parallel *Parallel `yaml:"parallel"`
+
+	// Vars is the environment variables required by the Vulcan
+	// checktypes.
+	Vars map[string]string `yaml:"vars"`
+
+	// RegistryAuths contains the credentials for a set of
+	// container registries.
+	RegistryAuths []RegistryAuth `yaml:"registries"`
+
+	// RegistryMirrors contains the rules used to pull the check
+	// images from registry mirrors instead of their original
+	// registries.
+	RegistryMirrors []RegistryMirror `yaml:"registryMirrors"`
+
+	// TmpDir is the directory used to store temporary files, like
+	// the repositories served to the checks.
+	TmpDir *string `yaml:"tmpDir"`
+
+	// Network is the name of the Docker network the checks are
+	// attached to. If not specified, the default bridge network
+	// is used.
+	Network *string `yaml:"network"`
+
+	// Platform is the platform of the check images with the
+	// format "os/arch[/variant]". For instance, "linux/amd64". If
+	// not specified, the platform of the container runtime is
+	// used.
+	Platform *string `yaml:"platform"`
+
+	// HangTimeout is the maximum time a check can run without
+	// sending any state update. Checks exceeding it are killed.
+	HangTimeout *time.Duration `yaml:"hangTimeout"`
+
+	// Timeout is the default timeout of the checks. It is used
+	// when the checktype does not define its own timeout.
+	Timeout *time.Duration `yaml:"timeout"`
+
+	// MaxNoMsgsInterval is the maximum time the agent waits for
+	// new jobs before stopping.
+	MaxNoMsgsInterval *time.Duration `yaml:"maxNoMsgsInterval"`
+
+	// RegistryBackoff is the configuration of the retries of the
+	// requests sent to the container registries.
+	RegistryBackoff BackoffConfig `yaml:"registryBackoff"`
+
+	// PullRetry is the configuration of the retries of the checks
+	// that fail because their image could not be pulled.
+	PullRetry PullRetryConfig `yaml:"pullRetry"`
+
+	// Volumes maps the paths of the container Lava is running in
+	// to the corresponding paths in the host of the container
+	// runtime. They are used to translate the paths bind-mounted
+	// into the check containers when Lava runs inside a
+	// container.
+	Volumes []VolumeMapping `yaml:"volumes"`
+
+	// ImageCache is the configuration of the cache of the results
+	// of the checks against Docker images.
+	ImageCache ImageCacheConfig `yaml:"imageCache"`
+
+	// State is the configuration of the persistence of the
+	// state of the scan.
+	State StateConfig `yaml:"state"`
+
+	// Watchdog is the configuration of the watchdog that monitors
+	// the resources of the host during the scan.
+	Watchdog WatchdogConfig `yaml:"watchdog"`
+
+	// ProxyAllow is the list of IP addresses and CIDR ranges of
+	// the non-loopback services that are proxied by the internal
+	// target server, so the checks can access them. Loopback
+	// services are always proxied.
+	ProxyAllow []string `yaml:"proxyAllow"`
+}
+
+// TmpDir returns the directory used to store temporary files. The
+// "agent.tmpDir" setting takes precedence over the LAVA_TMPDIR
+// environment variable. If none of them is set, it returns the empty
+// string, which means that the default directory for temporary files
+// is used.
+func TmpDir(cfg AgentConfig) string {
+	if dir := Get(cfg.TmpDir); dir != "" {
+		return dir
+	}
+	return os.Getenv("LAVA_TMPDIR")
+}
+
+// ImageCacheConfig is the configuration of the cache of the results
+// of the checks against Docker images.
+type ImageCacheConfig struct {
+	// Dir is the directory where the results are cached. If not
+	// specified, the cache is disabled.
+	Dir *string `yaml:"dir"`
+
+	// Force makes Lava run the checks even if their results are
+	// cached. The cache is updated with the new results.
+	Force *bool `yaml:"force"`
+}
+
+// StateConfig is the configuration of the persistence of the state
+// of the scan. The reports of the finished checks are stored in a
+// state file, so an interrupted scan can be resumed.
+type StateConfig struct {
+	// File is the path of the state file. If not specified, the
+	// state of the scan is not persisted.
+	File *string `yaml:"file"`
+
+	// Resume makes Lava reuse the reports stored in the state
+	// file and only run the checks that did not finish.
+	Resume *bool `yaml:"resume"`
+}
+
+// WatchdogConfig is the configuration of the host resource watchdog.
+// The thresholds are percentages of usage of the corresponding
+// resource. If no threshold is specified, the watchdog is disabled.
+type WatchdogConfig struct {
+	// CPU is the maximum CPU usage of the host.
+	CPU *float64 `yaml:"cpu"`
+
+	// Memory is the maximum memory usage of the host.
+	Memory *float64 `yaml:"memory"`
+
+	// Disk is the maximum usage of the file system of the
+	// directory used to store temporary files.
+	Disk *float64 `yaml:"disk"`
+
+	// Action is the action taken when a threshold is exceeded.
+	Action *WatchdogAction `yaml:"action"`
+}
+
+// Enabled reports whether any threshold is specified.
+func (c WatchdogConfig) Enabled() bool {
+	return c.CPU != nil || c.Memory != nil || c.Disk != nil
+}
+
+// WatchdogAction is the action taken by the host resource watchdog
+// when a threshold is exceeded.
+type WatchdogAction int
+
+// Watchdog actions available.
+const (
+	// WatchdogActionThrottle delays the launch of new checks
+	// until the usage of the resources is below the thresholds.
+	WatchdogActionThrottle WatchdogAction = iota
+
+	// WatchdogActionAbort stops launching new checks. The
+	// running checks are allowed to finish and the remaining
+	// checks are reported as failed.
+	WatchdogActionAbort
+)
+
+var watchdogActionNames = map[string]WatchdogAction{
+	"throttle": WatchdogActionThrottle,
+	"abort":    WatchdogActionAbort,
+}
+
+// parseWatchdogAction converts a string into a [WatchdogAction]
+// value.
+func parseWatchdogAction(action string) (WatchdogAction, error) {
+	if val, ok := watchdogActionNames[strings.ToLower(action)]; ok {
+		return val, nil
+	}
+	return WatchdogAction(0), fmt.Errorf("%w: invalid watchdog action: %v", ErrInvalidAgentConfig, action)
+}
+
+// String returns the string representation of the watchdog action.
+func (a WatchdogAction) String() string {
+	for k, v := range watchdogActionNames {
+		if v == a {
+			return k
+		}
+	}
+	return ""
+}
+
+// IsValid reports whether the watchdog action is known.
+func (a WatchdogAction) IsValid() bool {
+	for _, v := range watchdogActionNames {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalText encodes a [WatchdogAction] as text. It returns error if
+// the watchdog action is not valid.
+func (a WatchdogAction) MarshalText() (text []byte, err error) {
+	if !a.IsValid() {
+		return nil, fmt.Errorf("%w: invalid watchdog action", ErrInvalidAgentConfig)
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes a [WatchdogAction] text into a
+// [WatchdogAction] value. It returns error if the provided string
+// does not match any known watchdog action.
+func (a *WatchdogAction) UnmarshalText(text []byte) error {
+	action, err := parseWatchdogAction(string(text))
+	if err != nil {
+		return err
+	}
+	*a = action
+	return nil
+}
+
+// VolumeMapping maps a path of the container Lava is running in to
+// the corresponding path in the host of the container runtime.
+type VolumeMapping struct {
+	// Host is the path in the host of the container runtime.
+	Host string `yaml:"host"`
+
+	// Container is the path in the container Lava is running in.
+	Container string `yaml:"container"`
+}
+
+// BackoffConfig is the configuration of a retry strategy.
+type BackoffConfig struct {
+	// MaxRetries is the maximum number of retries.
+	MaxRetries *int `yaml:"maxRetries"`
+
+	// Interval is the initial time between retries.
+	Interval *time.Duration `yaml:"interval"`
+}
+
+// RegistryMirror maps a container registry to a registry mirror.
+type RegistryMirror struct {
+	// Registry is the registry whose images are pulled from the
+	// mirror. Docker Hub is identified by "docker.io". The value
+	// "*" matches any registry.
+	Registry string `yaml:"registry"`
+
+	// Mirror is the registry the images are pulled from. It can
+	// include a path prefix. For instance,
+	// "artifactory.example.com/dockerhub".
+	Mirror string `yaml:"mirror"`
+}
+
+// PullRetryConfig is the configuration of the retries of the checks
+// that fail because their image could not be pulled, like those
+// affected by registry rate limits or stale manifests.
+type PullRetryConfig struct {
+	// MaxRetries is the maximum number of retries. If zero, the
+	// checks are not retried.
+	MaxRetries *int `yaml:"maxRetries"`
+
+	// Interval is the time before the first retry. It is doubled
+	// after every retry.
+	Interval *time.Duration `yaml:"interval"`
+
+	// Mirror is the registry the images of the retried checks are
+	// pulled from. If not specified, they are pulled from their
+	// original registry.
+	Mirror *string `yaml:"mirror"`
+}
+
+// validate validates the agent configuration.
+func (c AgentConfig) validate() error {
+	durations := []struct {
+		name string
+		d    *time.Duration
+	}{
+		{"timeout", c.Timeout},
+		{"maxNoMsgsInterval", c.MaxNoMsgsInterval},
+		{"registryBackoff.interval", c.RegistryBackoff.Interval},
+	}
+	for _, d := range durations {
+		// The Vulcan agent works with seconds.
+		if d.d != nil && *d.d < time.Second {
+			return fmt.Errorf("%w: %v must be at least 1s", ErrInvalidAgentConfig, d.name)
+		}
+	}
+
+	if c.HangTimeout != nil && *c.HangTimeout < 0 {
+		return fmt.Errorf("%w: negative hangTimeout", ErrInvalidAgentConfig)
+	}
+
+	if c.RegistryBackoff.MaxRetries != nil && *c.RegistryBackoff.MaxRetries < 0 {
+		return fmt.Errorf("%w: negative registryBackoff.maxRetries", ErrInvalidAgentConfig)
+	}
+
+	for _, m := range c.RegistryMirrors {
+		if m.Registry == "" || m.Mirror == "" {
+			return fmt.Errorf("%w: registry mirrors require registry and mirror", ErrInvalidAgentConfig)
+		}
+	}
+
+	if c.PullRetry.MaxRetries != nil && *c.PullRetry.MaxRetries < 0 {
+		return fmt.Errorf("%w: negative pullRetry.maxRetries", ErrInvalidAgentConfig)
+	}
+
+	if c.PullRetry.Interval != nil && *c.PullRetry.Interval < 0 {
+		return fmt.Errorf("%w: negative pullRetry.interval", ErrInvalidAgentConfig)
+	}
+
+	thresholds := []struct {
+		name string
+		v    *float64
+	}{
+		{"watchdog.cpu", c.Watchdog.CPU},
+		{"watchdog.memory", c.Watchdog.Memory},
+		{"watchdog.disk", c.Watchdog.Disk},
+	}
+	for _, t := range thresholds {
+		if t.v != nil && (*t.v <= 0 || *t.v > 100) {
+			return fmt.Errorf("%w: %v must be greater than 0 and less than or equal to 100", ErrInvalidAgentConfig, t.name)
+		}
+	}
+
+	if p := Get(c.Platform); p != "" && !rePlatform.MatchString(p) {
+		return fmt.Errorf("%w: invalid platform: %q", ErrInvalidAgentConfig, p)
+	}
+
+	for _, v := range c.Volumes {
+		if !path.IsAbs(v.Host) || !path.IsAbs(v.Container) {
+			return fmt.Errorf("%w: volume paths must be absolute: %q:%q", ErrInvalidAgentConfig, v.Host, v.Container)
+		}
+	}
+
+	if _, err := ParsePrefixes(c.ProxyAllow); err != nil {
+		return fmt.Errorf("%w: proxyAllow: %w", ErrInvalidAgentConfig, err)
+	}
+	return nil
+}
+
+// ParsePrefixes parses a list of IP addresses and CIDR ranges. IP
+// addresses are returned as single-address prefixes.
+func ParsePrefixes(ss []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, s := range ss {
+		if addr, err := netip.ParseAddr(s); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address or CIDR range: %q", s)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Parallel is the maximum number of checks that can run in
+// parallel. It is either a non-negative integer or [ParallelAuto].
+type Parallel int
+
+// ParallelAuto means that the number of checks that can run in
+// parallel is chosen by Lava based on the resources of the host and
+// the checks to run. It is represented by the string "auto".
+const ParallelAuto Parallel = -1
+
+// UnmarshalText decodes a [Parallel] text into a [Parallel] value.
+// It accepts the string "auto" and non-negative integers.
+func (p *Parallel) UnmarshalText(text []byte) error {
+	if string(text) == "auto" {
+		*p = ParallelAuto
+		return nil
+	}
+	n, err := strconv.Atoi(string(text))
+	if err != nil || n < 0 {
+		return fmt.Errorf("%w: invalid parallel: %q", ErrInvalidAgentConfig, text)
+	}
+	*p = Parallel(n)
+	return nil
+}
+
+// MarshalYAML encodes a [Parallel] value as a YAML integer or as the
+// string "auto".
+func (p Parallel) MarshalYAML() (any, error) {
+	if p == ParallelAuto {
+		return "auto", nil
+	}
+	return int(p), nil
+}
+
+// rePlatform matches a valid platform with the format
+// "os/arch[/variant]".
+var rePlatform = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+
+// ReportConfig is the configuration of the report.
+type ReportConfig struct {
+	// Severity is the minimum severity required to exit with
+	// error.
+	Severity *Severity `yaml:"severity"`
+
+	// ShowSeverity is the minimum severity required to show a
+	// finding.
+	ShowSeverity *Severity `yaml:"show"`
+
+	// Format is the output format.
+	Format *OutputFormat `yaml:"format"`
+
+	// Theme is the color theme of the human-readable output.
+	Theme *Theme `yaml:"theme"`
+
+	// Template is the path of the Go template used to render the
+	// report when the output format is [OutputFormatTemplate].
+	Template *string `yaml:"template"`
+
+	// Columns is the list of columns of the CSV output. If not
+	// specified, [DefaultCSVColumns] is used.
+	Columns []string `yaml:"columns"`
+
+	// Width is the width in columns of the human-readable
+	// output. If Width is zero or not specified in the yaml file,
+	// then the width of the terminal is used.
+	Width *int `yaml:"width"`
+
+	// OutputFile is the path of the output file.
+	OutputFile *string `yaml:"output"`
+
+	// Exclusions is a list of findings that will be ignored. For
+	// instance, accepted risks, false positives, etc.
+	Exclusions []Exclusion `yaml:"exclusions"`
+
+	// ErrorOnStaleExclusions specifies whether Lava should exit
+	// with error when stale exclusions are detected.
+	ErrorOnStaleExclusions *bool `yaml:"errorOnStaleExclusions"`
+
+	// RequireExclusionMetadata specifies whether every exclusion
+	// must have a description, an expiration date and an owner.
+	// If not specified, it defaults to false.
+	RequireExclusionMetadata *bool `yaml:"requireExclusionMetadata"`
+
+	// ExclusionKeys is the list of public keys allowed to sign
+	// exclusions. If not empty, the exclusions that are not
+	// signed by any of these keys are ignored. See
+	// [ParseExclusionKey] for the format of the keys.
+	ExclusionKeys []string `yaml:"exclusionKeys"`
+
+	// ExclusionExpiry is the configuration of the warnings about
+	// the exclusions that are about to expire.
+	ExclusionExpiry ExclusionExpiryConfig `yaml:"exclusionExpiry"`
+
+	// ErrorOnInconclusive specifies whether Lava should exit with
+	// error when a check is inconclusive. If not specified, it
+	// defaults to true.
+	ErrorOnInconclusive *bool `yaml:"errorOnInconclusive"`
+
+	// Metrics is the file where the metrics will be written.
+	// If Metrics is an empty string or not specified in the yaml file, then
+	// the metrics report is not saved.
+	Metrics *string `yaml:"metrics"`
+
+	// Upload is the configuration used to upload the output and
+	// metrics files when they point to cloud storage.
+	Upload UploadConfig `yaml:"upload"`
+
+	// History is the path of the history database. If History is
+	// an empty string or not specified in the yaml file, then the
+	// results of the scan are not recorded.
+	History *string `yaml:"history"`
+
+	// Grade is the configuration of the security grade.
+	Grade GradeConfig `yaml:"grade"`
+
+	// SLA is the configuration of the deadlines to fix the
+	// findings.
+	SLA SLAConfig `yaml:"sla"`
+
+	// Advisory is the list of checktypes whose results are
+	// advisory. Their findings are reported and counted
+	// separately, but they never affect the exit code.
+	Advisory []string `yaml:"advisory"`
+
+	// SeverityRules is the list of rules that adjust the severity
+	// of the findings of specific checktypes.
+	SeverityRules []SeverityRule `yaml:"severityRules"`
+
+	// Metadata specifies whether the report includes the metadata
+	// of the scan. If not specified, it defaults to false.
+	Metadata *bool `yaml:"metadata"`
+
+	// Encryption is the configuration used to encrypt the output
+	// file.
+	Encryption EncryptionConfig `yaml:"encryption"`
+
+	// MaxFindings is the maximum number of findings rendered in
+	// the report. The findings are sorted by severity, so the
+	// most severe ones are kept. If MaxFindings is zero or not
+	// specified, the number of findings is not limited.
+	MaxFindings *int `yaml:"maxFindings"`
+
+	// MaxDetailSize is the maximum size in bytes of the
+	// description, details and impact details of every finding
+	// rendered in the report. Longer texts are truncated. If
+	// MaxDetailSize is zero or not specified, the texts are not
+	// truncated.
+	MaxDetailSize *int `yaml:"maxDetailSize"`
+
+	// FullOutput is the path of the file where the full report
+	// is written in JSON format. The limits of the report are not
+	// applied to it.
+	FullOutput *string `yaml:"fullOutput"`
+
+	// Sanitize specifies whether the ANSI escape sequences and
+	// control characters of the text fields of the findings are
+	// removed. If not specified, it defaults to true.
+	Sanitize *bool `yaml:"sanitize"`
+}
+
+// SeverityRule caps and floors the severity of the findings of a
+// checktype. It allows to calibrate the checktypes according to the
+// criteria of every organization.
+type SeverityRule struct {
+	// Checktype is the name of the checktype the rule applies to.
+	Checktype string `yaml:"checktype"`
+
+	// Max is the maximum severity of the findings of the
+	// checktype. Findings with a higher severity are lowered to
+	// it.
+	Max *Severity `yaml:"max"`
+
+	// Min is the minimum severity of the findings of the
+	// checktype. Findings with a lower severity are raised to it.
+	Min *Severity `yaml:"min"`
+}
+
+// validate reports whether the severity rule is valid.
+func (rule SeverityRule) validate() error {
+	if rule.Checktype == "" {
+		return fmt.Errorf("%w: no checktype", ErrInvalidSeverityRule)
+	}
+	if rule.Max == nil && rule.Min == nil {
+		return fmt.Errorf("%w: %v: no max or min severity", ErrInvalidSeverityRule, rule.Checktype)
+	}
+	if rule.Max != nil && rule.Min != nil && *rule.Min > *rule.Max {
+		return fmt.Errorf("%w: %v: min severity higher than max severity", ErrInvalidSeverityRule, rule.Checktype)
+	}
+	return nil
+}
+
+// Apply returns the provided severity adjusted to the limits of the
+// rule.
+func (rule SeverityRule) Apply(sev Severity) Severity {
+	if rule.Max != nil {
+		sev = min(sev, *rule.Max)
+	}
+	if rule.Min != nil {
+		sev = max(sev, *rule.Min)
+	}
+	return sev
+}
+
+// GradeConfig is the configuration of the security grade.
+type GradeConfig struct {
+	// Enabled specifies whether the security grade is computed.
+	Enabled *bool `yaml:"enabled"`
+
+	// Weights is the number of points subtracted from the score
+	// for every finding of a given severity.
+	Weights map[Severity]float64 `yaml:"weights"`
+
+	// Controls contains the multipliers applied to the weight of
+	// the findings with a given label. Labels are used by
+	// checktypes to identify the control family of a finding
+	// (e.g. "secret", "dependency", "iac").
+	Controls map[string]float64 `yaml:"controls"`
+
+	// Badge is the file where a badge-friendly JSON document
+	// with the security grade will be written.
+	Badge *string `yaml:"badge"`
+}
+
+// ExclusionExpiryConfig is the configuration of the warnings about
+// the exclusions that are about to expire.
+type ExclusionExpiryConfig struct {
+	// WarnDays is the number of days before the expiration date
+	// of an exclusion when Lava starts warning about it. If not
+	// specified or zero, no warnings are emitted.
+	WarnDays *int `yaml:"warnDays"`
+
+	// ErrorOnExpiring specifies whether Lava should exit with
+	// error when an exclusion expires within WarnDays days.
+	ErrorOnExpiring *bool `yaml:"errorOnExpiring"`
+}
+
+// validate reports whether the exclusion expiry configuration is
+// valid.
+func (ee ExclusionExpiryConfig) validate() error {
+	n := Get(ee.WarnDays)
+	if n < 0 {
+		return fmt.Errorf("%w: negative warnDays: %v", ErrInvalidExclusionExpiry, n)
+	}
+	if Get(ee.ErrorOnExpiring) && n == 0 {
+		return fmt.Errorf("%w: errorOnExpiring requires warnDays", ErrInvalidExclusionExpiry)
+	}
+	return nil
+}
+
+// SLAConfig is the configuration of the service level agreements
+// to fix the findings. The age of the findings is calculated using
+// the history database.
+type SLAConfig struct {
+	// Deadlines is the maximum time allowed to fix the findings
+	// of a given severity. Findings of severities with no
+	// deadline are not tracked.
+	Deadlines map[Severity]Duration `yaml:"deadlines"`
+
+	// ErrorOnBreach is the minimum severity of the overdue
+	// findings required to exit with error. If not specified,
+	// overdue findings do not cause Lava to exit with error.
+	ErrorOnBreach *Severity `yaml:"errorOnBreach"`
+}
+
+// validate reports whether the SLA configuration is valid.
+func (sla SLAConfig) validate() error {
+	for sev, d := range sla.Deadlines {
+		if d <= 0 {
+			return fmt.Errorf("%w: non-positive deadline: %v", ErrInvalidSLA, sev)
+		}
+	}
+	return nil
+}
+
+// Duration is a duration that, besides the units supported by
+// [time.ParseDuration], supports the unit "d" for days.
+type Duration time.Duration
+
+// UnmarshalText decodes a [Duration] text into a [Duration] value. A
+// number of days is specified with the format "<n>d". Otherwise, the
+// text is parsed with [time.ParseDuration].
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := string(text)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %v", s)
+		}
+		*d = Duration(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %v", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText encodes a [Duration] as text. Durations that are a
+// whole number of days are encoded with the format "<n>d".
+func (d Duration) MarshalText() (text []byte, err error) {
+	return []byte(d.String()), nil
+}
+
+// String returns the string representation of the duration.
+func (d Duration) String() string {
+	const day = 24 * time.Hour
+	if d != 0 && time.Duration(d)%day == 0 {
+		return fmt.Sprintf("%dd", time.Duration(d)/day)
+	}
+	return time.Duration(d).String()
+}
+
+// UploadConfig is the configuration used to upload files to cloud
+// storage.
+type UploadConfig struct {
+	// SSE is the server-side encryption algorithm applied to the
+	// uploaded objects. It is only honored by S3.
+	SSE *string `yaml:"sse"`
+
+	// KMSKey is the identifier of the KMS key used to encrypt the
+	// uploaded objects.
+	KMSKey *string `yaml:"kmsKey"`
+}
+
+// sseAlgorithms contains the valid server-side encryption
+// algorithms.
+var sseAlgorithms = []string{"AES256", "aws:kms", "aws:kms:dsse"}
+
+// validate reports whether the upload configuration is valid.
+func (c UploadConfig) validate() error {
+	if sse := Get(c.SSE); sse != "" && !slices.Contains(sseAlgorithms, sse) {
+		return fmt.Errorf("%w: %v", ErrInvalidSSE, sse)
+	}
+	return nil
+}
+
+// EncryptionConfig is the configuration used to encrypt the output
+// file. Only one kind of recipients can be specified.
+type EncryptionConfig struct {
+	// Age is the list of age recipients. The output file is
+	// encrypted with the "age" command.
+	Age []string `yaml:"age"`
+
+	// OpenPGP is the list of OpenPGP recipients, like key IDs,
+	// fingerprints or email addresses. The output file is
+	// encrypted with the "gpg" command.
+	OpenPGP []string `yaml:"openpgp"`
+}
+
+// IsEnabled reports whether the encryption is enabled.
+func (c EncryptionConfig) IsEnabled() bool {
+	return len(c.Age) > 0 || len(c.OpenPGP) > 0
+}
+
+// validate reports whether the encryption configuration is valid.
+func (c EncryptionConfig) validate() error {
+	if len(c.Age) > 0 && len(c.OpenPGP) > 0 {
+		return fmt.Errorf("%w: both age and OpenPGP recipients specified", ErrInvalidEncryption)
+	}
+	for _, recipients := range [][]string{c.Age, c.OpenPGP} {
+		for _, r := range recipients {
+			if strings.TrimSpace(r) == "" {
+				return fmt.Errorf("%w: empty recipient", ErrInvalidEncryption)
+			}
+		}
+	}
+	return nil
+}
+
+// Target represents the target of a scan.
+type Target struct {
+	// Identifier is a string that identifies the target. For
+	// instance, a path, a URL, a container image, etc.
+	Identifier string `yaml:"identifier"`
+
+	// AssetType is the asset type of the target.
+	AssetType types.AssetType `yaml:"type"`
+
+	// Options is a list of specific options for the target.
+	Options map[string]any `yaml:"options"`
+
+	// Auth is the authentication used by the checks to scan the
+	// target.
+	Auth *TargetAuth `yaml:"auth"`
+
+	// RateLimit is the maximum rate at which the checks send
+	// requests to the target.
+	RateLimit *RateLimit `yaml:"rateLimit"`
+
+	// Terraform is the configuration passed to the IaC checks
+	// when scanning a TerraformModule target.
+	Terraform *TerraformConfig `yaml:"terraform"`
+
+	// UDPPorts is the list of UDP ports of the target that are
+	// forwarded to the checks when the target is a local service.
+	UDPPorts []int `yaml:"udpPorts"`
+
+	// MapTarget specifies whether local targets are served to the
+	// checks through the internal target server, which replaces
+	// their identifiers. If not specified, it is true.
+	MapTarget *bool `yaml:"mapTarget"`
+
+	// Exclusions is a list of findings of the target that will be
+	// ignored. They are scoped to the target, so they cannot
+	// specify a target expression.
+	Exclusions []Exclusion `yaml:"exclusions"`
+
+	// Severity is the minimum severity of the findings of the
+	// target required to exit with error. If not specified, the
+	// severity of the report configuration is used.
+	Severity *Severity `yaml:"severity"`
+}
+
+// String returns the string representation of the [Target].
+func (t Target) String() string {
+	return fmt.Sprintf("%v(%v)", t.AssetType, t.Identifier)
+}
+
+// validate reports whether the target is a valid configuration value.
+func (t Target) validate() error {
+	if t.Identifier == "" {
+		return ErrNoTargetIdentifier
+	}
+	if t.AssetType == "" {
+		return ErrNoTargetAssetType
+	}
+	if !t.AssetType.IsValid() && !assettypes.IsValid(t.AssetType) {
+		return fmt.Errorf("%w: %v", ErrInvalidAssetType, t.AssetType)
+	}
+	if err := t.validateIdentifier(); err != nil {
+		return fmt.Errorf("%w: %v: %w", ErrInvalidTargetIdentifier, t, err)
+	}
+	if t.Auth != nil {
+		if err := t.Auth.validate(); err != nil {
+			return fmt.Errorf("%w: %v: %w", ErrInvalidTargetAuth, t, err)
+		}
+	}
+	if t.RateLimit != nil {
+		if err := t.RateLimit.validate(); err != nil {
+			return fmt.Errorf("%w: %v: %w", ErrInvalidRateLimit, t, err)
+		}
+	}
+	for _, port := range t.UDPPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("%w: %v: %v", ErrInvalidUDPPort, t, port)
+		}
+	}
+	// The targets with Lava asset types can only be scanned
+	// through the target server.
+	if t.MapTarget != nil && !*t.MapTarget && assettypes.IsValid(t.AssetType) {
+		return fmt.Errorf("%w: %v: unsupported asset type", ErrInvalidMapTarget, t)
+	}
+	if t.Terraform != nil {
+		if t.AssetType != assettypes.TerraformModule {
+			return fmt.Errorf("%w: %v: unsupported asset type", ErrInvalidTerraformConfig, t)
+		}
+		if err := t.Terraform.validate(); err != nil {
+			return fmt.Errorf("%w: %v: %w", ErrInvalidTerraformConfig, t, err)
+		}
+	}
+	for i, excl := range t.Exclusions {
+		if excl.Target != "" {
+			return fmt.Errorf("%w: %v: exclusion %v: target not allowed", ErrInvalidExclusion, t, i)
+		}
+		if err := excl.validate(); err != nil {
+			return fmt.Errorf("%v: exclusion %v: %w", t, i, err)
+		}
+	}
+	return nil
 }
 
-// reEnv is used to replace embedded environment variables.
-var reEnv = regexp.MustCompile(`\$\{[a-zA-Z_][a-zA-Z_0-9]*\}`)
-
-// Parse returns a parsed Lava configuration given an [io.Reader].
-func Parse(r io.Reader) (Config, error) {
-	b, err := io.ReadAll(r)
-	if err != nil {
-		return Config{}, fmt.Errorf("read config: %w", err)
+// ScopedExclusions returns the exclusions of the target with their
+// target expression set to match only the identifier of the target.
+func (t Target) ScopedExclusions() []Exclusion {
+	if len(t.Exclusions) == 0 {
+		return nil
 	}
 
-	s := reEnv.ReplaceAllStringFunc(string(b), func(match string) string {
-		return os.Getenv(match[2 : len(match)-1])
-	})
-
-	dec := yaml.NewDecoder(strings.NewReader(s))
+	target := "^" + regexp.QuoteMeta(t.Identifier) + "$"
+	excls := make([]Exclusion, len(t.Exclusions))
+	for i, excl := range t.Exclusions {
+		excl.Target = target
+		excls[i] = excl
+	}
+	return excls
+}
 
-	// Ensure that the keys in the read data exist as fields in
-	// the struct being decoded into.
-	dec.KnownFields(true)
+// Scope contains the network ranges and domains that can be
+// scanned. It only applies to network targets, that is, targets of
+// type IP, IPRange, Hostname and WebAddress.
+type Scope struct {
+	// CIDRs is the list of allowed network ranges in CIDR
+	// notation.
+	CIDRs []string `yaml:"cidrs"`
+
+	// Domains is the list of allowed domains. A domain also
+	// allows its subdomains.
+	Domains []string `yaml:"domains"`
+}
 
-	var cfg Config
-	if err := dec.Decode(&cfg); err != nil {
-		return Config{}, fmt.Errorf("decode config: %w", err)
+// validate reports whether the scope is a valid configuration value.
+func (s Scope) validate() error {
+	if len(s.CIDRs) == 0 && len(s.Domains) == 0 {
+		return fmt.Errorf("%w: no CIDRs or domains", ErrInvalidScope)
 	}
-	if err := cfg.validate(); err != nil {
-		return Config{}, fmt.Errorf("validate config: %w", err)
+	for _, cidr := range s.CIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidScope, err)
+		}
 	}
-	return cfg, nil
+	for _, domain := range s.Domains {
+		if strings.Trim(domain, ".") == "" {
+			return fmt.Errorf("%w: invalid domain: %q", ErrInvalidScope, domain)
+		}
+	}
+	return nil
 }
 
-// ParseFile returns a parsed Lava configuration given a path to a
-// file.
-func ParseFile(path string) (Config, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return Config{}, fmt.Errorf("open config file: %w", err)
+// Check returns an error wrapping [ErrOutOfScope] if the provided
+// target is a network target outside the scope. Targets of other
+// asset types are always in scope.
+func (s Scope) Check(t Target) error {
+	var ok bool
+	switch t.AssetType {
+	case types.IP:
+		ip := net.ParseIP(t.Identifier)
+		ok = ip != nil && s.containsIP(ip)
+	case types.IPRange:
+		_, ipnet, err := net.ParseCIDR(t.Identifier)
+		ok = err == nil && s.containsIPNet(ipnet)
+	case types.Hostname:
+		ok = s.containsHost(t.Identifier)
+	case types.WebAddress:
+		u, err := url.Parse(t.Identifier)
+		ok = err == nil && s.containsHost(u.Hostname())
+	default:
+		return nil
 	}
-	defer f.Close()
-	return Parse(f)
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrOutOfScope, t)
+	}
+	return nil
 }
 
-// validate validates the Lava configuration.
-func (c Config) validate() error {
-	// Lava version validation.
-	if !semver.IsValid(Get(c.LavaVersion)) {
-		return ErrInvalidLavaVersion
+// containsHost reports whether the provided host, which can be a
+// hostname or an IP address, is in scope.
+func (s Scope) containsHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return s.containsIP(ip)
 	}
 
-	// Checktype URLs validation.
-	if len(c.ChecktypeURLs) == 0 {
-		return ErrNoChecktypeURLs
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return false
+	}
+	for _, domain := range s.Domains {
+		domain = strings.ToLower(strings.Trim(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
 	}
+	return false
+}
 
-	// Targets validation.
-	if len(c.Targets) == 0 {
-		return ErrNoTargets
+// containsIP reports whether the provided IP address is in scope.
+func (s Scope) containsIP(ip net.IP) bool {
+	for _, cidr := range s.CIDRs {
+		if _, ipnet, err := net.ParseCIDR(cidr); err == nil && ipnet.Contains(ip) {
+			return true
+		}
 	}
-	for _, t := range c.Targets {
-		if err := t.validate(); err != nil {
-			return err
+	return false
+}
+
+// containsIPNet reports whether the provided network range is fully
+// in scope.
+func (s Scope) containsIPNet(n *net.IPNet) bool {
+	nOnes, nBits := n.Mask.Size()
+	for _, cidr := range s.CIDRs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		ones, bits := ipnet.Mask.Size()
+		if bits == nBits && ones <= nOnes && ipnet.Contains(n.IP) {
+			return true
 		}
 	}
-	return nil
+	return false
 }
 
-// IsCompatible reports whether the configuration is compatible with
-// the specified version. An invalid semantic version string is
-// considered incompatible.
-func (c Config) IsCompatible(v string) bool {
-	return semver.Compare(v, Get(c.LavaVersion)) >= 0
+// DiscoveryConfig configures the discovery of the hosts of the
+// DomainName targets.
+type DiscoveryConfig struct {
+	// Subdomains is a list of subdomain names to look up. For
+	// instance, "www" or "api".
+	Subdomains []string `yaml:"subdomains"`
+
+	// Wordlist is the path of a file with subdomain names to look
+	// up, one per line.
+	Wordlist *string `yaml:"wordlist"`
+
+	// CTLogs specifies whether the subdomains found in the
+	// Certificate Transparency logs must be looked up.
+	CTLogs bool `yaml:"ctLogs"`
+
+	// WebAddresses specifies whether a WebAddress target must be
+	// added for every discovered host, in addition to the
+	// Hostname target.
+	WebAddresses bool `yaml:"webAddresses"`
 }
 
-// AgentConfig is the configuration passed to the vulcan-agent.
-type AgentConfig struct {
-	// PullPolicy is the pull policy passed to vulcan-agent.
-	PullPolicy *agentconfig.PullPolicy `yaml:"pullPolicy"`
+// RateLimit is the maximum rate at which the checks send requests to
+// a target.
+type RateLimit struct {
+	// RequestsPerSecond is the maximum number of requests per
+	// second. Zero means no limit.
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
 
-	// Parallel is the maximum number of checks that can run in
-	// parallel.
-	Parallel *int `yaml:"parallel"`
+	// Concurrency is the maximum number of concurrent requests.
+	// Zero means no limit.
+	Concurrency int `yaml:"concurrency"`
 
-	// Vars is the environment variables required by the Vulcan
-	// checktypes.
-	Vars map[string]string `yaml:"vars"`
+	// Enforce specifies whether the internal proxy of Lava must
+	// enforce the limit for local targets.
+	Enforce bool `yaml:"enforce"`
+}
 
-	// RegistryAuths contains the credentials for a set of
-	// container registries.
-	RegistryAuths []RegistryAuth `yaml:"registries"`
+// validate reports whether the rate limit is a valid configuration
+// value.
+func (rl RateLimit) validate() error {
+	if rl.RequestsPerSecond < 0 {
+		return errors.New("negative requests per second")
+	}
+	if rl.Concurrency < 0 {
+		return errors.New("negative concurrency")
+	}
+	if rl.RequestsPerSecond == 0 && rl.Concurrency == 0 {
+		return errors.New("no limit")
+	}
+	return nil
 }
 
-// ReportConfig is the configuration of the report.
-type ReportConfig struct {
-	// Severity is the minimum severity required to exit with
-	// error.
-	Severity *Severity `yaml:"severity"`
+// TerraformConfig is the configuration passed to the IaC checks when
+// scanning a Terraform module.
+type TerraformConfig struct {
+	// VarFiles is the list of variable definition files. The
+	// paths are relative to the root of the module.
+	VarFiles []string `yaml:"varFiles"`
 
-	// ShowSeverity is the minimum severity required to show a
-	// finding.
-	ShowSeverity *Severity `yaml:"show"`
+	// Workspaces is the list of Terraform workspaces to scan.
+	Workspaces []string `yaml:"workspaces"`
+}
 
-	// Format is the output format.
-	Format *OutputFormat `yaml:"format"`
+// validate reports whether the Terraform configuration is a valid
+// configuration value.
+func (tc TerraformConfig) validate() error {
+	for _, f := range tc.VarFiles {
+		if !filepath.IsLocal(f) {
+			return fmt.Errorf("var file outside the module: %q", f)
+		}
+	}
+	for _, ws := range tc.Workspaces {
+		if ws == "" {
+			return errors.New("empty workspace")
+		}
+	}
+	return nil
+}
 
-	// OutputFile is the path of the output file.
-	OutputFile *string `yaml:"output"`
+// AuthType is the type of authentication used to scan a target.
+type AuthType string
 
-	// Exclusions is a list of findings that will be ignored. For
-	// instance, accepted risks, false positives, etc.
-	Exclusions []Exclusion `yaml:"exclusions"`
+// Authentication types.
+const (
+	// AuthTypeHeader sends a token in an HTTP header.
+	AuthTypeHeader AuthType = "header"
 
-	// ErrorOnStaleExclusions specifies whether Lava should exit
-	// with error when stale exclusions are detected.
-	ErrorOnStaleExclusions *bool `yaml:"errorOnStaleExclusions"`
+	// AuthTypeBasic uses HTTP basic authentication.
+	AuthTypeBasic AuthType = "basic"
 
-	// Metrics is the file where the metrics will be written.
-	// If Metrics is an empty string or not specified in the yaml file, then
-	// the metrics report is not saved.
-	Metrics *string `yaml:"metrics"`
-}
+	// AuthTypeScript runs a login script or a recorded login
+	// flow to obtain an authenticated session.
+	AuthTypeScript AuthType = "script"
+)
 
-// Target represents the target of a scan.
-type Target struct {
-	// Identifier is a string that identifies the target. For
-	// instance, a path, a URL, a container image, etc.
-	Identifier string `yaml:"identifier"`
+// TargetAuth contains the authentication used by the checks to scan
+// a target. The secrets are not marshaled to JSON, so they are not
+// leaked to the metrics.
+type TargetAuth struct {
+	// Type is the type of authentication.
+	Type AuthType `yaml:"type" json:"type"`
 
-	// AssetType is the asset type of the target.
-	AssetType types.AssetType `yaml:"type"`
+	// Header is the name of the HTTP header used to send the
+	// token. If not specified, "Authorization" is used.
+	Header string `yaml:"header" json:"header,omitempty"`
 
-	// Options is a list of specific options for the target.
-	Options map[string]any `yaml:"options"`
-}
+	// Token is the token sent in the header.
+	Token string `yaml:"token" json:"-"`
 
-// String returns the string representation of the [Target].
-func (t Target) String() string {
-	return fmt.Sprintf("%v(%v)", t.AssetType, t.Identifier)
+	// TokenFile is the path of a file that contains the token.
+	TokenFile string `yaml:"tokenFile" json:"tokenFile,omitempty"`
+
+	// Username is the username used for basic authentication.
+	Username string `yaml:"username" json:"username,omitempty"`
+
+	// Password is the password used for basic authentication.
+	Password string `yaml:"password" json:"-"`
+
+	// PasswordFile is the path of a file that contains the
+	// password.
+	PasswordFile string `yaml:"passwordFile" json:"passwordFile,omitempty"`
+
+	// Script is the path of the login script or recorded login
+	// flow.
+	Script string `yaml:"script" json:"script,omitempty"`
 }
 
-// validate reports whether the target is a valid configuration value.
-func (t Target) validate() error {
-	if t.Identifier == "" {
-		return ErrNoTargetIdentifier
-	}
-	if t.AssetType == "" {
-		return ErrNoTargetAssetType
-	}
-	if !t.AssetType.IsValid() && !assettypes.IsValid(t.AssetType) {
-		return fmt.Errorf("%w: %v", ErrInvalidAssetType, t.AssetType)
+// validate reports whether the target authentication is a valid
+// configuration value.
+func (auth TargetAuth) validate() error {
+	switch auth.Type {
+	case AuthTypeHeader:
+		if (auth.Token == "") == (auth.TokenFile == "") {
+			return errors.New("exactly one of token and tokenFile must be specified")
+		}
+	case AuthTypeBasic:
+		if auth.Username == "" {
+			return errors.New("no username")
+		}
+		if auth.Password != "" && auth.PasswordFile != "" {
+			return errors.New("password and passwordFile are mutually exclusive")
+		}
+	case AuthTypeScript:
+		if auth.Script == "" {
+			return errors.New("no script")
+		}
+	default:
+		return fmt.Errorf("unknown type: %q", auth.Type)
 	}
 	return nil
 }
@@ -248,7 +1735,7 @@ type RegistryAuth struct {
 func (auth RegistryAuth) String() string {
 	var s string
 	if auth.Username != "" {
-		s = auth.Username + ":*****@"
+		s = auth.Username + ":" + secretMask + "@"
 	}
 	return s + auth.Server
 }
@@ -325,11 +1812,54 @@ type OutputFormat int
 const (
 	OutputFormatHuman OutputFormat = iota
 	OutputFormatJSON
+	OutputFormatTemplate
+	OutputFormatCSV
+	OutputFormatPlain
 )
 
 var outputFormatNames = map[string]OutputFormat{
-	"human": OutputFormatHuman,
-	"json":  OutputFormatJSON,
+	"human":    OutputFormatHuman,
+	"json":     OutputFormatJSON,
+	"template": OutputFormatTemplate,
+	"csv":      OutputFormatCSV,
+	"plain":    OutputFormatPlain,
+}
+
+// CSVColumns is the list of columns supported by the CSV output.
+var CSVColumns = []string{
+	"target",
+	"checktype",
+	"severity",
+	"score",
+	"summary",
+	"affected_resource",
+	"fingerprint",
+	"cwe",
+	"cwe_name",
+	"description",
+	"details",
+	"impact_details",
+	"recommendations",
+	"references",
+	"labels",
+	"due",
+	"overdue",
+	"check_key",
+	"parent",
+	"slug",
+}
+
+// DefaultCSVColumns is the list of columns of the CSV output when no
+// columns are configured.
+var DefaultCSVColumns = []string{
+	"target",
+	"checktype",
+	"severity",
+	"score",
+	"summary",
+	"affected_resource",
+	"fingerprint",
+	"slug",
 }
 
 // parseOutputFormat converts a string into an [OutputFormat] value.
@@ -381,6 +1911,191 @@ func (f *OutputFormat) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// Theme is the color theme of the human-readable report.
+type Theme int
+
+// Color themes available for the human-readable report.
+const (
+	ThemeDefault Theme = iota
+	ThemeHighContrast
+	ThemeMonochrome
+)
+
+var themeNames = map[string]Theme{
+	"default":       ThemeDefault,
+	"high-contrast": ThemeHighContrast,
+	"monochrome":    ThemeMonochrome,
+}
+
+// parseTheme converts a string into a [Theme] value.
+func parseTheme(theme string) (Theme, error) {
+	if val, ok := themeNames[strings.ToLower(theme)]; ok {
+		return val, nil
+	}
+	return Theme(0), fmt.Errorf("%w: %v", ErrInvalidTheme, theme)
+}
+
+// String returns the string representation of the theme.
+func (t Theme) String() string {
+	for k, v := range themeNames {
+		if v == t {
+			return k
+		}
+	}
+	return ""
+}
+
+// IsValid reports whether the theme is known.
+func (t Theme) IsValid() bool {
+	for _, v := range themeNames {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalText encodes a [Theme] as text. It returns error if the
+// theme is not valid.
+func (t Theme) MarshalText() (text []byte, err error) {
+	if !t.IsValid() {
+		return nil, ErrInvalidTheme
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes a [Theme] text into a [Theme] value. It
+// returns error if the provided string does not match any known
+// theme.
+func (t *Theme) UnmarshalText(text []byte) error {
+	theme, err := parseTheme(string(text))
+	if err != nil {
+		return err
+	}
+	*t = theme
+	return nil
+}
+
+// Service is an auxiliary container, like the application under
+// test or a database, that is started before running the checks.
+type Service struct {
+	// Name is the name of the service. The service is reachable
+	// from the checks using its name as hostname.
+	Name string `yaml:"name"`
+
+	// Image is the container image of the service.
+	Image string `yaml:"image"`
+
+	// Command overrides the default command of the image.
+	Command []string `yaml:"command"`
+
+	// Env is the environment of the service.
+	Env map[string]string `yaml:"env"`
+
+	// HealthCheck overrides the health check of the image.
+	HealthCheck *HealthCheck `yaml:"healthcheck"`
+
+	// StartTimeout is the maximum time to wait for the service to
+	// be healthy.
+	StartTimeout *time.Duration `yaml:"startTimeout"`
+}
+
+// reServiceName matches valid service names.
+var reServiceName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// validate reports whether the service is a valid configuration
+// value.
+func (svc Service) validate() error {
+	if !reServiceName.MatchString(svc.Name) {
+		return fmt.Errorf("%w: invalid name: %q", ErrInvalidService, svc.Name)
+	}
+	if svc.Image == "" {
+		return fmt.Errorf("%w: %v: no image", ErrInvalidService, svc.Name)
+	}
+	return nil
+}
+
+// HealthCheck describes how to check that a service is healthy.
+type HealthCheck struct {
+	// Test is the command used to check the health of the
+	// service. It follows the format of the Docker health checks.
+	// For instance, ["CMD", "curl", "-f", "http://localhost"].
+	Test []string `yaml:"test"`
+
+	// Interval is the time between checks.
+	Interval *time.Duration `yaml:"interval"`
+
+	// Timeout is the maximum time a check can take.
+	Timeout *time.Duration `yaml:"timeout"`
+
+	// Retries is the number of consecutive failures needed to
+	// consider the service unhealthy.
+	Retries *int `yaml:"retries"`
+}
+
+// LogFormat is the format of the logs.
+type LogFormat int
+
+// Log formats available.
+const (
+	LogFormatText LogFormat = iota
+	LogFormatJSON
+)
+
+var logFormatNames = map[string]LogFormat{
+	"text": LogFormatText,
+	"json": LogFormatJSON,
+}
+
+// parseLogFormat converts a string into a [LogFormat] value.
+func parseLogFormat(format string) (LogFormat, error) {
+	if val, ok := logFormatNames[strings.ToLower(format)]; ok {
+		return val, nil
+	}
+	return LogFormat(0), fmt.Errorf("%w: %v", ErrInvalidLogFormat, format)
+}
+
+// String returns the string representation of the log format.
+func (f LogFormat) String() string {
+	for k, v := range logFormatNames {
+		if v == f {
+			return k
+		}
+	}
+	return ""
+}
+
+// IsValid reports whether the log format is known.
+func (f LogFormat) IsValid() bool {
+	for _, v := range logFormatNames {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalText encodes a [LogFormat] as text. It returns error if the
+// log format is not valid.
+func (f LogFormat) MarshalText() (text []byte, err error) {
+	if !f.IsValid() {
+		return nil, ErrInvalidLogFormat
+	}
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText decodes a [LogFormat] text into a [LogFormat] value.
+// It returns error if the provided string does not match any known
+// log format.
+func (f *LogFormat) UnmarshalText(text []byte) error {
+	format, err := parseLogFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
+
 // Exclusion represents the criteria to exclude a given finding.
 type Exclusion struct {
 	// Target is a regular expression that matches the name of the
@@ -406,6 +2121,52 @@ type Exclusion struct {
 
 	// Description describes the exclusion.
 	Description string `yaml:"description"`
+
+	// Owner is the person or team accountable for the exclusion.
+	Owner string `yaml:"owner"`
+
+	// Signature is the signature of the exclusion. It is required
+	// when [ReportConfig.ExclusionKeys] is not empty. See
+	// [Exclusion.VerifySignature].
+	Signature string `yaml:"signature"`
+}
+
+// validate reports whether the regular expressions of the exclusion
+// are valid.
+func (excl Exclusion) validate() error {
+	exprs := []struct {
+		field string
+		expr  string
+	}{
+		{"target", excl.Target},
+		{"resource", excl.Resource},
+		{"summary", excl.Summary},
+	}
+	for _, e := range exprs {
+		if _, err := regexp.Compile(e.expr); err != nil {
+			return fmt.Errorf("%w: %v: %w", ErrInvalidExclusion, e.field, err)
+		}
+	}
+	return nil
+}
+
+// checkMetadata reports whether the exclusion is documented, that is,
+// whether it has a description, an expiration date and an owner.
+func (excl Exclusion) checkMetadata() error {
+	var missing []string
+	if strings.TrimSpace(excl.Description) == "" {
+		missing = append(missing, "description")
+	}
+	if excl.ExpirationDate.IsZero() {
+		missing = append(missing, "expiration")
+	}
+	if strings.TrimSpace(excl.Owner) == "" {
+		missing = append(missing, "owner")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %v", ErrMissingExclusionMetadata, strings.Join(missing, ", "))
+	}
+	return nil
 }
 
 // ExpirationDateLayout is the input format for the [ExpirationDate].
@@ -7,13 +7,18 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"net/netip"
 	"regexp"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	agentconfig "github.com/adevinta/vulcan-agent/config"
 	types "github.com/adevinta/vulcan-types"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/assettypes"
 )
 
 func TestParse(t *testing.T) {
@@ -31,7 +36,7 @@ func TestParse(t *testing.T) {
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
 				ChecktypeURLs: []string{
-					"checktypes.json",
+					"testdata/checktypes.json",
 				},
 				Targets: []Target{
 					{
@@ -47,7 +52,7 @@ func TestParse(t *testing.T) {
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
 				ChecktypeURLs: []string{
-					"checktypes.json",
+					"testdata/checktypes.json",
 				},
 				Targets: []Target{
 					{
@@ -67,11 +72,11 @@ func TestParse(t *testing.T) {
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
 				ChecktypeURLs: []string{
-					"checktypes.json",
+					"testdata/checktypes.json",
 				},
 				Targets: []Target{
 					{
-						Identifier: "${1NVALID}",
+						Identifier: "${1nvalid}",
 						AssetType:  types.DomainName,
 					},
 				},
@@ -119,7 +124,7 @@ func TestParse(t *testing.T) {
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
 				ChecktypeURLs: []string{
-					"checktypes.json",
+					"testdata/checktypes.json",
 				},
 				ReportConfig: ReportConfig{
 					Severity: ptr(SeverityCritical),
@@ -144,7 +149,7 @@ func TestParse(t *testing.T) {
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
 				ChecktypeURLs: []string{
-					"checktypes.json",
+					"testdata/checktypes.json",
 				},
 				ReportConfig: ReportConfig{
 					ShowSeverity: ptr(SeverityLow),
@@ -163,7 +168,7 @@ func TestParse(t *testing.T) {
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
 				ChecktypeURLs: []string{
-					"checktypes.json",
+					"testdata/checktypes.json",
 				},
 				AgentConfig: AgentConfig{
 					PullPolicy: ptr(agentconfig.PullPolicyNever),
@@ -177,24 +182,1127 @@ func TestParse(t *testing.T) {
 			},
 		},
 		{
-			name:          "invalid pull policy",
-			file:          "testdata/invalid_pull_policy.yaml",
-			want:          Config{},
-			wantErrRegexp: regexp.MustCompile(`value .* is not a valid PullPolicy value`),
+			name:          "invalid pull policy",
+			file:          "testdata/invalid_pull_policy.yaml",
+			want:          Config{},
+			wantErrRegexp: regexp.MustCompile(`value .* is not a valid PullPolicy value`),
+		},
+		{
+			name:    "invalid target asset type",
+			file:    "testdata/invalid_target_asset_type.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAssetType,
+		},
+		{
+			name:    "invalid target identifier",
+			file:    "testdata/invalid_target_identifier.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidTargetIdentifier,
+		},
+		{
+			name: "JSON output format",
+			file: "testdata/json_output_format.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					Format: ptr(OutputFormatJSON),
+				},
+			},
+		},
+		{
+			name:    "invalid output format",
+			file:    "testdata/invalid_output_format.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidOutputFormat,
+		},
+		{
+			name: "template output format",
+			file: "testdata/template_output_format.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					Format:   ptr(OutputFormatTemplate),
+					Template: ptr("testdata/report.tmpl"),
+				},
+			},
+		},
+		{
+			name:    "no template",
+			file:    "testdata/no_template.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidOutputFormat,
+		},
+		{
+			name: "csv output format",
+			file: "testdata/csv_output_format.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					Format:  ptr(OutputFormatCSV),
+					Columns: []string{"target", "severity", "summary"},
+				},
+			},
+		},
+		{
+			name: "plain output format",
+			file: "testdata/plain_output_format.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					Format: ptr(OutputFormatPlain),
+				},
+			},
+		},
+		{
+			name:    "invalid column",
+			file:    "testdata/invalid_column.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidColumn,
+		},
+		{
+			name: "high contrast theme",
+			file: "testdata/high_contrast_theme.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					Theme: ptr(ThemeHighContrast),
+				},
+			},
+		},
+		{
+			name:    "invalid theme",
+			file:    "testdata/invalid_theme.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidTheme,
+		},
+		{
+			name: "width",
+			file: "testdata/width.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					Width: ptr(80),
+				},
+			},
+		},
+		{
+			name:    "invalid width",
+			file:    "testdata/invalid_width.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidWidth,
+		},
+		{
+			name: "debug log level",
+			file: "testdata/debug_log_level.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				LogLevel: ptr(slog.LevelDebug),
+			},
+		},
+		{
+			name:          "unknown field",
+			file:          "testdata/unknown_field.yaml",
+			want:          Config{},
+			wantErrRegexp: regexp.MustCompile(`unknown configuration field: targets\.unknown \(line 7\): the configuration requires Lava v9\.0\.0 or later$`),
+		},
+		{
+			name:          "invalid log level",
+			file:          "testdata/invalid_log_level.yaml",
+			want:          Config{},
+			wantErrRegexp: regexp.MustCompile(`level string ".*": unknown name`),
+		},
+		{
+			name: "json log format",
+			file: "testdata/json_log_format.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				LogFormat: ptr(LogFormatJSON),
+			},
+		},
+		{
+			name: "hang timeout",
+			file: "testdata/hang_timeout.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					HangTimeout: ptr(10 * time.Minute),
+				},
+			},
+		},
+		{
+			name: "agent timeouts",
+			file: "testdata/agent_timeouts.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Timeout:           ptr(30 * time.Minute),
+					MaxNoMsgsInterval: ptr(10 * time.Second),
+					RegistryBackoff: BackoffConfig{
+						MaxRetries: ptr(10),
+						Interval:   ptr(2 * time.Second),
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid agent timeout",
+			file:    "testdata/invalid_agent_timeout.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name: "auto parallel",
+			file: "testdata/auto_parallel.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				AgentConfig: AgentConfig{
+					Parallel: ptr(ParallelAuto),
+				},
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid parallel",
+			file:    "testdata/invalid_parallel.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name:    "negative parallel",
+			file:    "testdata/negative_parallel.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name:    "invalid agent backoff",
+			file:    "testdata/invalid_agent_backoff.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name: "pull retry",
+			file: "testdata/pull_retry.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					PullRetry: PullRetryConfig{
+						MaxRetries: ptr(3),
+						Interval:   ptr(time.Minute),
+						Mirror:     ptr("mirror.example.com"),
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid pull retry",
+			file:    "testdata/invalid_pull_retry.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name: "registry mirrors",
+			file: "testdata/registry_mirrors.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					RegistryMirrors: []RegistryMirror{
+						{
+							Registry: "docker.io",
+							Mirror:   "artifactory.example.com/dockerhub",
+						},
+						{
+							Registry: "*",
+							Mirror:   "harbor.example.com",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid registry mirror",
+			file:    "testdata/invalid_registry_mirror.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name: "watchdog",
+			file: "testdata/watchdog.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Watchdog: WatchdogConfig{
+						CPU:    ptr(90.0),
+						Memory: ptr(85.5),
+						Action: ptr(WatchdogActionAbort),
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid watchdog",
+			file:    "testdata/invalid_watchdog.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name:    "invalid watchdog action",
+			file:    "testdata/invalid_watchdog_action.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name: "proxy allow",
+			file: "testdata/proxy_allow.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					ProxyAllow: []string{
+						"192.168.1.10",
+						"172.18.0.0/16",
+						"fd00::/8",
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid proxy allow",
+			file:    "testdata/invalid_proxy_allow.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name: "agent platform",
+			file: "testdata/agent_platform.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Platform: ptr("linux/amd64"),
+				},
+			},
+		},
+		{
+			name:    "invalid agent platform",
+			file:    "testdata/invalid_agent_platform.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name: "agent volumes",
+			file: "testdata/agent_volumes.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Volumes: []VolumeMapping{
+						{Host: "/home/runner/work", Container: "/workspace"},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid agent volumes",
+			file:    "testdata/invalid_agent_volumes.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAgentConfig,
+		},
+		{
+			name: "checktypes integrity",
+			file: "testdata/checktypes_integrity.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+					"https://example.com/checktypes.json",
+				},
+				ChecktypesIntegrity: map[string]CatalogIntegrity{
+					"testdata/checktypes.json": {
+						Digest: ptr("sha256:e028377a26b427990030a465787253271e4084be012c4e229dabe4b9acecf5e3"),
+					},
+					"https://example.com/checktypes.json": {
+						Signature: ptr("https://example.com/checktypes.json.sig"),
+						PublicKey: ptr("testdata/cosign.pub"),
+					},
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid checktypes integrity",
+			file:    "testdata/invalid_checktypes_integrity.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidChecktypesIntegrity,
+		},
+		{
+			name:    "remote public key in checktypes integrity",
+			file:    "testdata/remote_key_checktypes_integrity.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidChecktypesIntegrity,
+		},
+		{
+			name:    "unknown catalog in checktypes integrity",
+			file:    "testdata/unknown_checktypes_integrity.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidChecktypesIntegrity,
+		},
+		{
+			name: "target auth",
+			file: "testdata/target_auth.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "https://example.com",
+						AssetType:  types.WebAddress,
+						Auth: &TargetAuth{
+							Type:   AuthTypeHeader,
+							Header: "X-API-Key",
+							Token:  "token",
+						},
+					},
+					{
+						Identifier: "https://example.org",
+						AssetType:  types.WebAddress,
+						Auth: &TargetAuth{
+							Type:         AuthTypeBasic,
+							Username:     "user",
+							PasswordFile: "testdata/password.txt",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid target auth",
+			file:    "testdata/invalid_target_auth.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidTargetAuth,
+		},
+		{
+			name: "rate limit",
+			file: "testdata/rate_limit.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "http://localhost:8080",
+						AssetType:  types.WebAddress,
+						RateLimit: &RateLimit{
+							RequestsPerSecond: 5,
+							Concurrency:       2,
+							Enforce:           true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid rate limit",
+			file:    "testdata/invalid_rate_limit.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidRateLimit,
+		},
+		{
+			name: "UDP ports",
+			file: "testdata/udp_ports.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "127.0.0.1",
+						AssetType:  types.IP,
+						UDPPorts:   []int{53, 161},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid UDP ports",
+			file:    "testdata/invalid_udp_ports.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidUDPPort,
+		},
+		{
+			name: "map target",
+			file: "testdata/map_target.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "http://localhost:8080",
+						AssetType:  types.WebAddress,
+						MapTarget:  ptr(false),
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid map target",
+			file:    "testdata/invalid_map_target.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidMapTarget,
+		},
+		{
+			name: "terraform",
+			file: "testdata/terraform.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "testdata/infra",
+						AssetType:  assettypes.TerraformModule,
+						Terraform: &TerraformConfig{
+							VarFiles:   []string{"env/prod.tfvars"},
+							Workspaces: []string{"prod", "staging"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid terraform",
+			file:    "testdata/invalid_terraform.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidTerraformConfig,
+		},
+		{
+			name: "scope",
+			file: "testdata/scope.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "https://www.example.com",
+						AssetType:  types.WebAddress,
+					},
+				},
+				Scope: &Scope{
+					CIDRs:   []string{"10.0.0.0/8"},
+					Domains: []string{"example.com"},
+				},
+			},
+		},
+		{
+			name:    "invalid scope",
+			file:    "testdata/invalid_scope.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidScope,
+		},
+		{
+			name: "discovery",
+			file: "testdata/discovery.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				Discovery: &DiscoveryConfig{
+					Subdomains:   []string{"www"},
+					Wordlist:     ptr("testdata/wordlist.txt"),
+					CTLogs:       true,
+					WebAddresses: true,
+				},
+			},
+		},
+		{
+			name: "services",
+			file: "testdata/services.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "http://{{.Services.app.Host}}:8080",
+						AssetType:  types.WebAddress,
+					},
+				},
+				Services: []Service{
+					{
+						Name:    "app",
+						Image:   "example/app:latest",
+						Command: []string{"serve", "-addr", ":8080"},
+						Env: map[string]string{
+							"DB_HOST": "db",
+						},
+						HealthCheck: &HealthCheck{
+							Test:     []string{"CMD", "curl", "-f", "http://localhost:8080/health"},
+							Interval: ptr(5 * time.Second),
+							Timeout:  ptr(2 * time.Second),
+							Retries:  ptr(3),
+						},
+						StartTimeout: ptr(time.Minute),
+					},
+					{
+						Name:  "db",
+						Image: "postgres:16",
+					},
+				},
+			},
+		},
+		{
+			name:    "service without image",
+			file:    "testdata/invalid_service_no_image.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidService,
+		},
+		{
+			name:    "invalid service name",
+			file:    "testdata/invalid_service_name.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidService,
+		},
+		{
+			name:    "duplicated service name",
+			file:    "testdata/duplicated_service_name.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidService,
+		},
+		{
+			name:    "invalid log format",
+			file:    "testdata/invalid_log_format.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidLogFormat,
+		},
+		{
+			name: "valid expiration date",
+			file: "testdata/valid_expiration_date.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					Exclusions: []Exclusion{
+						{
+							Summary:        "Secret Leaked in Git Repository",
+							Description:    "Ignore test certificates.",
+							ExpirationDate: mustParseExpDate("2024/07/05"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid expiration date",
+			file:    "testdata/invalid_expiration_date.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidExpirationDate,
+		},
+		{
+			name: "exclusion metadata",
+			file: "testdata/exclusion_metadata.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					RequireExclusionMetadata: ptr(true),
+					Exclusions: []Exclusion{
+						{
+							Summary:        "Secret Leaked in Git Repository",
+							Description:    "Ignore test certificates.",
+							ExpirationDate: mustParseExpDate("2024/07/05"),
+							Owner:          "security-team",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "missing exclusion metadata",
+			file:    "testdata/missing_exclusion_metadata.yaml",
+			want:    Config{},
+			wantErr: ErrMissingExclusionMetadata,
+		},
+		{
+			name: "SLA",
+			file: "testdata/sla.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					History: ptr("testdata/history.jsonl"),
+					SLA: SLAConfig{
+						Deadlines: map[Severity]Duration{
+							SeverityCritical: Duration(7 * 24 * time.Hour),
+							SeverityHigh:     Duration(720 * time.Hour),
+						},
+						ErrorOnBreach: ptr(SeverityCritical),
+					},
+				},
+			},
+		},
+		{
+			name: "exclusion expiry",
+			file: "testdata/exclusion_expiry.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					ExclusionExpiry: ExclusionExpiryConfig{
+						WarnDays:        ptr(14),
+						ErrorOnExpiring: ptr(true),
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid exclusion expiry",
+			file:    "testdata/invalid_exclusion_expiry.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidExclusionExpiry,
+		},
+		{
+			name:    "exclusion expiry without days",
+			file:    "testdata/exclusion_expiry_no_days.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidExclusionExpiry,
+		},
+		{
+			name:    "SLA without history",
+			file:    "testdata/sla_no_history.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidSLA,
+		},
+		{
+			name:    "invalid SLA deadline",
+			file:    "testdata/invalid_sla_deadline.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidSLA,
+		},
+		{
+			name:          "invalid SLA duration",
+			file:          "testdata/invalid_sla_duration.yaml",
+			want:          Config{},
+			wantErrRegexp: regexp.MustCompile(`invalid duration: one week`),
+		},
+		{
+			name: "severity rules",
+			file: "testdata/severity_rules.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					SeverityRules: []SeverityRule{
+						{
+							Checktype: "vulcan-semgrep",
+							Max:       ptr(SeverityMedium),
+						},
+						{
+							Checktype: "vulcan-gitleaks",
+							Min:       ptr(SeverityHigh),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "encryption",
+			file: "testdata/encryption.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				ReportConfig: ReportConfig{
+					OutputFile: ptr("testdata/report.json.age"),
+					Encryption: EncryptionConfig{
+						Age: []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid encryption",
+			file:    "testdata/invalid_encryption.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidEncryption,
+		},
+		{
+			name: "stages",
+			file: "testdata/stages.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				Stages: []Stage{
+					{
+						Name:       "discovery",
+						Checktypes: []string{"vulcan-subdomain-takeover"},
+						OutputType: types.WebAddress,
+					},
+					{
+						Name:       "dast",
+						Checktypes: []string{"vulcan-zap"},
+						DependsOn:  []string{"discovery"},
+					},
+				},
+			},
+		},
+		{
+			name:    "stages with cycle",
+			file:    "testdata/stages_cycle.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidStage,
+		},
+		{
+			name: "target defaults",
+			file: "testdata/target_defaults.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				ChecktypeURLs: []string{
+					"testdata/checktypes.json",
+				},
+				Defaults: map[types.AssetType]TargetDefaults{
+					types.GitRepository: {
+						Options: map[string]any{
+							"depth": 1,
+							"env": map[string]any{
+								"GIT_SSL_NO_VERIFY": "true",
+							},
+						},
+						Severity: ptr(SeverityHigh),
+					},
+				},
+				Targets: []Target{
+					{
+						Identifier: "https://example.com/repo1.git",
+						AssetType:  types.GitRepository,
+						Options: map[string]any{
+							"depth": 1,
+							"env": map[string]any{
+								"GIT_SSL_NO_VERIFY": "true",
+							},
+						},
+						Severity: ptr(SeverityHigh),
+					},
+					{
+						Identifier: "https://example.com/repo2.git",
+						AssetType:  types.GitRepository,
+						Options: map[string]any{
+							"depth": 10,
+							"env": map[string]any{
+								"GIT_SSL_NO_VERIFY": "true",
+								"SEMGREP_RULES":     "p/golang",
+							},
+						},
+						Severity: ptr(SeverityHigh),
+					},
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid target defaults",
+			file:    "testdata/invalid_target_defaults.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidAssetType,
+		},
+		{
+			name: "includes",
+			file: "testdata/includes/lava.yaml",
+			want: Config{
+				LavaVersion: ptr("v1.0.0"),
+				Includes:    []string{"conf.d/*.yaml"},
+				ChecktypeURLs: []string{
+					"testdata/includes/catalogs/a.json",
+					"testdata/includes/catalogs/b.json",
+				},
+				Targets: []Target{
+					{
+						Identifier: "example.org",
+						AssetType:  types.DomainName,
+					},
+					{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+					},
+				},
+				AgentConfig: AgentConfig{
+					Parallel: ptr(Parallel(4)),
+				},
+				ReportConfig: ReportConfig{
+					Severity: ptr(SeverityHigh),
+				},
+			},
+		},
+		{
+			name:    "include cycle",
+			file:    "testdata/includes_cycle/lava.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidInclude,
+		},
+		{
+			name:    "encryption without output file",
+			file:    "testdata/encryption_no_output.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidEncryption,
+		},
+		{
+			name:    "encryption with only full output file",
+			file:    "testdata/encryption_full_output.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidEncryption,
+		},
+		{
+			name:    "invalid severity rule",
+			file:    "testdata/invalid_severity_rule.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidSeverityRule,
+		},
+		{
+			name:    "invalid exclusion key",
+			file:    "testdata/invalid_exclusion_key.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidExclusionKey,
 		},
 		{
-			name:    "invalid target asset type",
-			file:    "testdata/invalid_target_asset_type.yaml",
+			name:    "missing target exclusion metadata",
+			file:    "testdata/missing_target_exclusion_metadata.yaml",
 			want:    Config{},
-			wantErr: ErrInvalidAssetType,
+			wantErr: ErrMissingExclusionMetadata,
 		},
 		{
-			name: "JSON output format",
-			file: "testdata/json_output_format.yaml",
+			name: "upload SSE",
+			file: "testdata/upload_sse.yaml",
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
 				ChecktypeURLs: []string{
-					"checktypes.json",
+					"testdata/checktypes.json",
 				},
 				Targets: []Target{
 					{
@@ -203,69 +1311,92 @@ func TestParse(t *testing.T) {
 					},
 				},
 				ReportConfig: ReportConfig{
-					Format: ptr(OutputFormatJSON),
+					OutputFile: ptr("s3://bucket/report.json"),
+					Upload: UploadConfig{
+						SSE:    ptr("aws:kms"),
+						KMSKey: ptr("key"),
+					},
 				},
 			},
 		},
 		{
-			name:    "invalid output format",
-			file:    "testdata/invalid_output_format.yaml",
+			name:    "invalid upload SSE",
+			file:    "testdata/invalid_upload_sse.yaml",
 			want:    Config{},
-			wantErr: ErrInvalidOutputFormat,
+			wantErr: ErrInvalidSSE,
 		},
 		{
-			name: "debug log level",
-			file: "testdata/debug_log_level.yaml",
+			name:    "invalid exclusion regexp",
+			file:    "testdata/invalid_exclusion_regexp.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidExclusion,
+		},
+		{
+			name: "target exclusions",
+			file: "testdata/target_exclusions.yaml",
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
 				ChecktypeURLs: []string{
-					"checktypes.json",
+					"testdata/checktypes.json",
 				},
 				Targets: []Target{
 					{
 						Identifier: "example.com",
 						AssetType:  types.DomainName,
+						Severity:   ptr(SeverityMedium),
+						Exclusions: []Exclusion{
+							{
+								Summary:     "Vulnerability Summary",
+								Description: "Accepted risk.",
+							},
+						},
 					},
 				},
-				LogLevel: ptr(slog.LevelDebug),
 			},
 		},
 		{
-			name:          "invalid log level",
-			file:          "testdata/invalid_log_level.yaml",
-			want:          Config{},
-			wantErrRegexp: regexp.MustCompile(`level string ".*": unknown name`),
+			name:    "target exclusion with target",
+			file:    "testdata/target_exclusion_with_target.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidExclusion,
 		},
 		{
-			name: "valid expiration date",
-			file: "testdata/valid_expiration_date.yaml",
+			name:    "invalid target severity",
+			file:    "testdata/invalid_target_severity.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidSeverity,
+		},
+		{
+			name: "path base cwd",
+			file: "testdata/path_base_cwd.yaml",
 			want: Config{
 				LavaVersion: ptr("v1.0.0"),
+				PathBase:    ptr(PathBaseCWD),
 				ChecktypeURLs: []string{
 					"checktypes.json",
 				},
 				Targets: []Target{
 					{
-						Identifier: "example.com",
-						AssetType:  types.DomainName,
+						Identifier: ".",
+						AssetType:  assettypes.Path,
 					},
 				},
 				ReportConfig: ReportConfig{
-					Exclusions: []Exclusion{
-						{
-							Summary:        "Secret Leaked in Git Repository",
-							Description:    "Ignore test certificates.",
-							ExpirationDate: mustParseExpDate("2024/07/05"),
-						},
-					},
+					OutputFile: ptr("report.json"),
 				},
 			},
 		},
 		{
-			name:    "invalid expiration date",
-			file:    "testdata/invalid_expiration_date.yaml",
+			name:    "invalid path base",
+			file:    "testdata/invalid_path_base.yaml",
 			want:    Config{},
-			wantErr: ErrInvalidExpirationDate,
+			wantErr: ErrInvalidPathBase,
+		},
+		{
+			name:    "invalid report limit",
+			file:    "testdata/invalid_report_limit.yaml",
+			want:    Config{},
+			wantErr: ErrInvalidReportLimit,
 		},
 	}
 
@@ -299,6 +1430,135 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParse_fieldVersion(t *testing.T) {
+	var buf bytes.Buffer
+	oldLogger := slog.Default()
+	defer slog.SetDefault(oldLogger)
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	if _, err := ParseFile("testdata/field_version.yaml"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := regexp.MustCompile(`level=WARN .* field=targets\.rateLimit line=7 version=v0\.8\.0 lava=v0\.1\.0`)
+	if !want.Match(buf.Bytes()) {
+		t.Errorf("unexpected log output: %q", buf.String())
+	}
+}
+
+func TestParseFilesWithTargets(t *testing.T) {
+	stdin := strings.NewReader(`
+report:
+  severity: low
+targets:
+  - identifier: example.org
+    type: DomainName
+`)
+	extra := []Target{{Identifier: "example.com", AssetType: types.DomainName}}
+
+	got, err := ParseFilesWithTargets([]string{"testdata/no_targets.yaml", Stdin}, stdin, extra)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Config{
+		LavaVersion:   ptr("v1.0.0"),
+		ChecktypeURLs: []string{"testdata/checktypes.json"},
+		Targets: []Target{
+			{Identifier: "example.org", AssetType: types.DomainName},
+			{Identifier: "example.com", AssetType: types.DomainName},
+		},
+		ReportConfig: ReportConfig{
+			Severity: ptr(SeverityLow),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("configs mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestParseFilesWithTargets_invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+	}{
+		{
+			name:  "no files",
+			paths: nil,
+		},
+		{
+			name:  "stdin twice",
+			paths: []string{Stdin, Stdin},
+		},
+		{
+			name:  "missing file",
+			paths: []string{"testdata/no_targets.yaml", "testdata/missing.yaml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin := strings.NewReader("lava: v1.0.0\nchecktypes: [checktypes.json]\n")
+			if _, err := ParseFilesWithTargets(tt.paths, stdin, nil); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestParseFilesWithOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    []string
+		override func(*Config)
+		want     ReportConfig
+		wantErr  error
+	}{
+		{
+			name:  "output file",
+			paths: []string{"testdata/encryption_no_output.yaml"},
+			override: func(c *Config) {
+				c.ReportConfig.OutputFile = ptr("output.gpg")
+			},
+			want: ReportConfig{
+				OutputFile: ptr("output.gpg"),
+				Encryption: EncryptionConfig{
+					OpenPGP: []string{"security@example.com"},
+				},
+			},
+		},
+		{
+			name:  "template format without template",
+			paths: []string{"testdata/json_output_format.yaml"},
+			override: func(c *Config) {
+				c.ReportConfig.Format = ptr(OutputFormatTemplate)
+			},
+			wantErr: ErrInvalidOutputFormat,
+		},
+		{
+			name:     "nil override",
+			paths:    []string{"testdata/encryption_no_output.yaml"},
+			override: nil,
+			wantErr:  ErrInvalidEncryption,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilesWithOverride(tt.paths, nil, nil, tt.override)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got.ReportConfig); diff != "" {
+				t.Errorf("report configs mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
 func TestConfig_IsCompatible(t *testing.T) {
 	tests := []struct {
 		name string
@@ -348,6 +1608,131 @@ func TestConfig_IsCompatible(t *testing.T) {
 	}
 }
 
+func TestTmpDir(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  AgentConfig
+		env  string
+		want string
+	}{
+		{
+			name: "default",
+			cfg:  AgentConfig{},
+			env:  "",
+			want: "",
+		},
+		{
+			name: "env",
+			cfg:  AgentConfig{},
+			env:  "/env/tmp",
+			want: "/env/tmp",
+		},
+		{
+			name: "config takes precedence",
+			cfg:  AgentConfig{TmpDir: ptr("/config/tmp")},
+			env:  "/env/tmp",
+			want: "/config/tmp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LAVA_TMPDIR", tt.env)
+			if got := TmpDir(tt.cfg); got != tt.want {
+				t.Errorf("unexpected tmp dir: got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScope_Check(t *testing.T) {
+	scope := Scope{
+		CIDRs:   []string{"10.0.0.0/8", "2001:db8::/32"},
+		Domains: []string{"example.com", "Example.ORG."},
+	}
+
+	tests := []struct {
+		name    string
+		target  Target
+		wantErr error
+	}{
+		{
+			name:    "IP in scope",
+			target:  Target{Identifier: "10.1.2.3", AssetType: types.IP},
+			wantErr: nil,
+		},
+		{
+			name:    "IPv6 in scope",
+			target:  Target{Identifier: "2001:db8::1", AssetType: types.IP},
+			wantErr: nil,
+		},
+		{
+			name:    "IP out of scope",
+			target:  Target{Identifier: "192.168.1.1", AssetType: types.IP},
+			wantErr: ErrOutOfScope,
+		},
+		{
+			name:    "IP range in scope",
+			target:  Target{Identifier: "10.1.0.0/16", AssetType: types.IPRange},
+			wantErr: nil,
+		},
+		{
+			name:    "IP range partially out of scope",
+			target:  Target{Identifier: "10.0.0.0/7", AssetType: types.IPRange},
+			wantErr: ErrOutOfScope,
+		},
+		{
+			name:    "hostname in scope",
+			target:  Target{Identifier: "example.com", AssetType: types.Hostname},
+			wantErr: nil,
+		},
+		{
+			name:    "subdomain in scope",
+			target:  Target{Identifier: "www.EXAMPLE.com", AssetType: types.Hostname},
+			wantErr: nil,
+		},
+		{
+			name:    "domain with trailing dot in scope",
+			target:  Target{Identifier: "www.example.org", AssetType: types.Hostname},
+			wantErr: nil,
+		},
+		{
+			name:    "hostname with same suffix out of scope",
+			target:  Target{Identifier: "badexample.com", AssetType: types.Hostname},
+			wantErr: ErrOutOfScope,
+		},
+		{
+			name:    "web address in scope",
+			target:  Target{Identifier: "https://www.example.com:8443/path", AssetType: types.WebAddress},
+			wantErr: nil,
+		},
+		{
+			name:    "web address with IP in scope",
+			target:  Target{Identifier: "http://10.0.0.1:8080", AssetType: types.WebAddress},
+			wantErr: nil,
+		},
+		{
+			name:    "web address out of scope",
+			target:  Target{Identifier: "https://example.net", AssetType: types.WebAddress},
+			wantErr: ErrOutOfScope,
+		},
+		{
+			name:    "non-network target",
+			target:  Target{Identifier: "example.net", AssetType: types.DomainName},
+			wantErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scope.Check(tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestSeverity_MarshalText(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -410,6 +1795,54 @@ func ptr[V any](v V) *V {
 	return &v
 }
 
+func TestSeverityRule_Apply(t *testing.T) {
+	tests := []struct {
+		name string
+		rule SeverityRule
+		sev  Severity
+		want Severity
+	}{
+		{
+			name: "capped",
+			rule: SeverityRule{Max: ptr(SeverityMedium)},
+			sev:  SeverityCritical,
+			want: SeverityMedium,
+		},
+		{
+			name: "below cap",
+			rule: SeverityRule{Max: ptr(SeverityMedium)},
+			sev:  SeverityLow,
+			want: SeverityLow,
+		},
+		{
+			name: "floored",
+			rule: SeverityRule{Min: ptr(SeverityHigh)},
+			sev:  SeverityInfo,
+			want: SeverityHigh,
+		},
+		{
+			name: "above floor",
+			rule: SeverityRule{Min: ptr(SeverityHigh)},
+			sev:  SeverityCritical,
+			want: SeverityCritical,
+		},
+		{
+			name: "fixed",
+			rule: SeverityRule{Min: ptr(SeverityLow), Max: ptr(SeverityLow)},
+			sev:  SeverityCritical,
+			want: SeverityLow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Apply(tt.sev); got != tt.want {
+				t.Errorf("unexpected severity: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseExpirationDate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -443,6 +1876,49 @@ func TestParseExpirationDate(t *testing.T) {
 	}
 }
 
+func TestParsePrefixes(t *testing.T) {
+	tests := []struct {
+		name       string
+		ss         []string
+		want       []netip.Prefix
+		wantNilErr bool
+	}{
+		{
+			name: "addresses and ranges",
+			ss:   []string{"192.168.1.10", "172.18.1.0/16", "fd00::1"},
+			want: []netip.Prefix{
+				netip.MustParsePrefix("192.168.1.10/32"),
+				netip.MustParsePrefix("172.18.0.0/16"),
+				netip.MustParsePrefix("fd00::1/128"),
+			},
+			wantNilErr: true,
+		},
+		{
+			name:       "empty",
+			ss:         nil,
+			want:       nil,
+			wantNilErr: true,
+		},
+		{
+			name:       "invalid",
+			ss:         []string{"192.168.1.10", "example.com"},
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrefixes(tt.ss)
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("unexpected prefixes: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestExpirationDate_MarshalText(t *testing.T) {
 	date := mustParseExpDate("2024/07/05")
 	want := []byte("2024/07/05")
@@ -463,3 +1939,73 @@ func mustParseExpDate(date string) ExpirationDate {
 	}
 	return ExpirationDate{Time: t}
 }
+
+func TestTarget_ScopedExclusions(t *testing.T) {
+	target := Target{
+		Identifier: "https://example.com/path?q=1",
+		AssetType:  types.WebAddress,
+		Exclusions: []Exclusion{
+			{
+				Summary:     "Vulnerability Summary",
+				Description: "Accepted risk.",
+			},
+			{
+				Fingerprint: "fingerprint",
+			},
+		},
+	}
+
+	want := []Exclusion{
+		{
+			Target:      `^https://example\.com/path\?q=1$`,
+			Summary:     "Vulnerability Summary",
+			Description: "Accepted risk.",
+		},
+		{
+			Target:      `^https://example\.com/path\?q=1$`,
+			Fingerprint: "fingerprint",
+		},
+	}
+
+	got := target.ScopedExclusions()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("exclusions mismatch (-want +got):\n%v", diff)
+	}
+
+	// The exclusions of the target are not modified.
+	if target.Exclusions[0].Target != "" {
+		t.Errorf("target exclusions modified: %v", target.Exclusions[0].Target)
+	}
+}
+
+func TestDuration_String(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Duration
+		want string
+	}{
+		{
+			name: "days",
+			d:    Duration(30 * 24 * time.Hour),
+			want: "30d",
+		},
+		{
+			name: "hours",
+			d:    Duration(36 * time.Hour),
+			want: "36h0m0s",
+		},
+		{
+			name: "zero",
+			d:    0,
+			want: "0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.String(); got != tt.want {
+				t.Errorf("unexpected string: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Adevinta
+
+// Package dag implements directed acyclic graphs of named nodes.
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrDuplicatedNode is returned when a node is added twice to
+	// a graph.
+	ErrDuplicatedNode = errors.New("duplicated node")
+
+	// ErrUnknownNode is returned when an edge references a node
+	// that is not in the graph.
+	ErrUnknownNode = errors.New("unknown node")
+
+	// ErrCycle is returned when the graph contains a cycle.
+	ErrCycle = errors.New("cycle detected")
+)
+
+// Graph is a directed graph whose nodes are identified by name. An
+// edge from a node to another means that the former must be
+// processed before the latter.
+type Graph struct {
+	nodes []string
+	edges map[string][]string
+}
+
+// New returns an empty [Graph].
+func New() *Graph {
+	return &Graph{edges: make(map[string][]string)}
+}
+
+// AddNode adds a node with the specified name to the graph.
+func (g *Graph) AddNode(name string) error {
+	if _, ok := g.edges[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicatedNode, name)
+	}
+	g.nodes = append(g.nodes, name)
+	g.edges[name] = nil
+	return nil
+}
+
+// AddEdge adds an edge from the node from to the node to. Both nodes
+// must be in the graph.
+func (g *Graph) AddEdge(from, to string) error {
+	for _, name := range []string{from, to} {
+		if _, ok := g.edges[name]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownNode, name)
+		}
+	}
+	g.edges[from] = append(g.edges[from], to)
+	return nil
+}
+
+// Sort returns the nodes of the graph in topological order. Every
+// node is returned after all the nodes with an edge pointing to it.
+// Ties are broken by the order in which the nodes were added, so the
+// result is deterministic. It returns an error wrapping [ErrCycle]
+// if the graph is not acyclic.
+func (g *Graph) Sort() ([]string, error) {
+	indegree := make(map[string]int)
+	for _, tos := range g.edges {
+		for _, to := range tos {
+			indegree[to]++
+		}
+	}
+
+	var (
+		sorted []string
+		done   = make(map[string]bool)
+	)
+	for len(sorted) < len(g.nodes) {
+		progress := false
+		for _, name := range g.nodes {
+			if done[name] || indegree[name] > 0 {
+				continue
+			}
+			done[name] = true
+			sorted = append(sorted, name)
+			for _, to := range g.edges[name] {
+				indegree[to]--
+			}
+			progress = true
+		}
+		if !progress {
+			var cycle []string
+			for _, name := range g.nodes {
+				if !done[name] {
+					cycle = append(cycle, fmt.Sprintf("%q", name))
+				}
+			}
+			return nil, fmt.Errorf("%w: %v", ErrCycle, strings.Join(cycle, ", "))
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Adevinta
+
+package dag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGraph_Sort(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []string
+		edges   [][2]string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "no edges",
+			nodes: []string{"a", "b", "c"},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "chain",
+			nodes: []string{"c", "b", "a"},
+			edges: [][2]string{{"a", "b"}, {"b", "c"}},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "diamond",
+			nodes: []string{"d", "c", "b", "a"},
+			edges: [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}},
+			want:  []string{"a", "c", "b", "d"},
+		},
+		{
+			name:    "cycle",
+			nodes:   []string{"a", "b", "c"},
+			edges:   [][2]string{{"a", "b"}, {"b", "c"}, {"c", "b"}},
+			wantErr: ErrCycle,
+		},
+		{
+			name:    "self loop",
+			nodes:   []string{"a"},
+			edges:   [][2]string{{"a", "a"}},
+			wantErr: ErrCycle,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New()
+			for _, n := range tt.nodes {
+				if err := g.AddNode(n); err != nil {
+					t.Fatalf("add node error: %v", err)
+				}
+			}
+			for _, e := range tt.edges {
+				if err := g.AddEdge(e[0], e[1]); err != nil {
+					t.Fatalf("add edge error: %v", err)
+				}
+			}
+
+			got, err := g.Sort()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("nodes mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestGraph_errors(t *testing.T) {
+	g := New()
+	if err := g.AddNode("a"); err != nil {
+		t.Fatalf("add node error: %v", err)
+	}
+	if err := g.AddNode("a"); !errors.Is(err, ErrDuplicatedNode) {
+		t.Errorf("unexpected error: got: %v, want: %v", err, ErrDuplicatedNode)
+	}
+	if err := g.AddEdge("a", "b"); !errors.Is(err, ErrUnknownNode) {
+		t.Errorf("unexpected error: got: %v, want: %v", err, ErrUnknownNode)
+	}
+}
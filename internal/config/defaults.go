@@ -0,0 +1,88 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/adevinta/lava/internal/assettypes"
+)
+
+// TargetDefaults are the default settings of the targets of an asset
+// type. They are merged into every target of that asset type. The
+// settings of the target take precedence.
+type TargetDefaults struct {
+	// Options is a list of default options. Nested maps are
+	// merged recursively.
+	Options map[string]any `yaml:"options"`
+
+	// Auth is the default authentication.
+	Auth *TargetAuth `yaml:"auth"`
+
+	// RateLimit is the default rate limit.
+	RateLimit *RateLimit `yaml:"rateLimit"`
+
+	// Severity is the default minimum severity required to exit
+	// with error.
+	Severity *Severity `yaml:"severity"`
+}
+
+// validateDefaults reports whether the target defaults are valid
+// configuration values.
+func (c Config) validateDefaults() error {
+	for at := range c.Defaults {
+		if !at.IsValid() && !assettypes.IsValid(at) {
+			return fmt.Errorf("defaults: %w: %v", ErrInvalidAssetType, at)
+		}
+	}
+	return nil
+}
+
+// applyDefaults merges the target defaults into the targets of the
+// configuration.
+func (c *Config) applyDefaults() {
+	for i, t := range c.Targets {
+		if d, ok := c.Defaults[t.AssetType]; ok {
+			c.Targets[i] = d.apply(t)
+		}
+	}
+}
+
+// apply returns a copy of the target with the defaults merged into
+// it.
+func (d TargetDefaults) apply(t Target) Target {
+	t.Options = mergeOptions(d.Options, t.Options)
+	if t.Auth == nil {
+		t.Auth = d.Auth
+	}
+	if t.RateLimit == nil {
+		t.RateLimit = d.RateLimit
+	}
+	if t.Severity == nil {
+		t.Severity = d.Severity
+	}
+	return t
+}
+
+// mergeOptions returns the result of merging opts into defaults.
+// The values of opts take precedence, except when both values are
+// maps, which are merged recursively. The provided maps are not
+// modified.
+func mergeOptions(defaults, opts map[string]any) map[string]any {
+	if len(defaults) == 0 {
+		return opts
+	}
+
+	merged := maps.Clone(defaults)
+	for k, v := range opts {
+		dm, dok := merged[k].(map[string]any)
+		om, ook := v.(map[string]any)
+		if dok && ook {
+			merged[k] = mergeOptions(dm, om)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
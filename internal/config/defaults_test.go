@@ -0,0 +1,66 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults map[string]any
+		opts     map[string]any
+		want     map[string]any
+	}{
+		{
+			name: "no defaults",
+			opts: map[string]any{"depth": 1},
+			want: map[string]any{"depth": 1},
+		},
+		{
+			name:     "no options",
+			defaults: map[string]any{"depth": 1},
+			want:     map[string]any{"depth": 1},
+		},
+		{
+			name:     "options take precedence",
+			defaults: map[string]any{"depth": 1, "branch": "main"},
+			opts:     map[string]any{"depth": 10},
+			want:     map[string]any{"depth": 10, "branch": "main"},
+		},
+		{
+			name:     "nested maps",
+			defaults: map[string]any{"env": map[string]any{"A": "1", "B": "2"}},
+			opts:     map[string]any{"env": map[string]any{"B": "3", "C": "4"}},
+			want:     map[string]any{"env": map[string]any{"A": "1", "B": "3", "C": "4"}},
+		},
+		{
+			name:     "map replaced by scalar",
+			defaults: map[string]any{"env": map[string]any{"A": "1"}},
+			opts:     map[string]any{"env": "none"},
+			want:     map[string]any{"env": "none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeOptions(tt.defaults, tt.opts)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("options mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestMergeOptions_does_not_modify_defaults(t *testing.T) {
+	defaults := map[string]any{"env": map[string]any{"A": "1"}}
+	mergeOptions(defaults, map[string]any{"env": map[string]any{"B": "2"}})
+
+	want := map[string]any{"env": map[string]any{"A": "1"}}
+	if diff := cmp.Diff(want, defaults); diff != "" {
+		t.Errorf("defaults were modified (-want +got):\n%v", diff)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/logfile"
+)
+
+// Default values of the configuration.
+const (
+	// DefaultAgentParallel is the default maximum number of checks
+	// that can run in parallel.
+	DefaultAgentParallel Parallel = 1
+
+	// DefaultAgentTimeout is the default timeout of the checks.
+	DefaultAgentTimeout = 3 * time.Minute
+
+	// DefaultAgentMaxNoMsgsInterval is the default time the agent
+	// waits for new jobs. It is low because all the jobs are in
+	// the queue before starting the agent.
+	DefaultAgentMaxNoMsgsInterval = 5 * time.Second
+
+	// DefaultRegistryBackoffMaxRetries is the default maximum
+	// number of retries of the requests sent to the container
+	// registries.
+	DefaultRegistryBackoffMaxRetries = 5
+
+	// DefaultRegistryBackoffInterval is the default initial time
+	// between retries of the requests sent to the container
+	// registries.
+	DefaultRegistryBackoffInterval = 5 * time.Second
+
+	// DefaultPullRetryMaxRetries is the default maximum number of
+	// retries of the checks that fail because their image could
+	// not be pulled.
+	DefaultPullRetryMaxRetries = 2
+
+	// DefaultPullRetryInterval is the default time before the
+	// first retry of the checks that fail because their image
+	// could not be pulled.
+	DefaultPullRetryInterval = 30 * time.Second
+)
+
+// secretMask replaces the secrets of the configuration.
+const secretMask = "*****"
+
+// WithDefaults returns a copy of the configuration with the default
+// value of every unset setting that has one.
+func (c Config) WithDefaults() Config {
+	setDefault(&c.PathBase, PathBaseConfig)
+
+	setDefault(&c.AgentConfig.PullPolicy, agentconfig.PullPolicyIfNotPresent)
+	setDefault(&c.AgentConfig.Parallel, DefaultAgentParallel)
+	setDefault(&c.AgentConfig.Timeout, DefaultAgentTimeout)
+	setDefault(&c.AgentConfig.MaxNoMsgsInterval, DefaultAgentMaxNoMsgsInterval)
+	setDefault(&c.AgentConfig.RegistryBackoff.MaxRetries, DefaultRegistryBackoffMaxRetries)
+	setDefault(&c.AgentConfig.RegistryBackoff.Interval, DefaultRegistryBackoffInterval)
+	setDefault(&c.AgentConfig.PullRetry.MaxRetries, DefaultPullRetryMaxRetries)
+	setDefault(&c.AgentConfig.PullRetry.Interval, DefaultPullRetryInterval)
+
+	setDefault(&c.ReportConfig.Severity, SeverityHigh)
+	setDefault(&c.ReportConfig.ShowSeverity, Get(c.ReportConfig.Severity))
+	setDefault(&c.ReportConfig.Format, OutputFormatHuman)
+	setDefault(&c.ReportConfig.Theme, ThemeDefault)
+	setDefault(&c.ReportConfig.ErrorOnStaleExclusions, false)
+	setDefault(&c.ReportConfig.ErrorOnInconclusive, true)
+	setDefault(&c.ReportConfig.RequireExclusionMetadata, false)
+	setDefault(&c.ReportConfig.Sanitize, true)
+
+	setDefault(&c.LogLevel, slog.LevelInfo)
+	setDefault(&c.LogFormat, LogFormatText)
+	if c.LogFile != nil {
+		setDefault(&c.LogFileMaxSize, int64(logfile.DefaultMaxSize))
+	}
+	return c
+}
+
+// setDefault sets *p to a pointer to v if *p is nil.
+func setDefault[T any](p **T, v T) {
+	if *p == nil {
+		*p = &v
+	}
+}
+
+// Masked returns a copy of the configuration with the secrets
+// masked. The values of the agent variables, the environment of the
+// services and the "env" option of the targets and the target
+// defaults are considered secrets, so only their names are kept.
+// Empty values are not masked, so they can be told apart from the
+// set ones.
+func (c Config) Masked() Config {
+	c.AgentConfig.Vars = maskValues(c.AgentConfig.Vars)
+
+	c.AgentConfig.RegistryAuths = slices.Clone(c.AgentConfig.RegistryAuths)
+	for i, auth := range c.AgentConfig.RegistryAuths {
+		c.AgentConfig.RegistryAuths[i].Password = mask(auth.Password)
+	}
+
+	c.Targets = slices.Clone(c.Targets)
+	for i, t := range c.Targets {
+		c.Targets[i].Options = maskOptions(t.Options)
+		c.Targets[i].Auth = maskAuth(t.Auth)
+	}
+
+	if c.Defaults != nil {
+		defaults := make(map[types.AssetType]TargetDefaults, len(c.Defaults))
+		for at, d := range c.Defaults {
+			d.Options = maskOptions(d.Options)
+			d.Auth = maskAuth(d.Auth)
+			defaults[at] = d
+		}
+		c.Defaults = defaults
+	}
+
+	c.Services = slices.Clone(c.Services)
+	for i, svc := range c.Services {
+		c.Services[i].Env = maskValues(svc.Env)
+	}
+	return c
+}
+
+// maskOptions returns a copy of the provided target options with the
+// values of the "env" option masked. If there is no "env" option, the
+// options are returned unmodified.
+func maskOptions(opts map[string]any) map[string]any {
+	env, ok := opts["env"].(map[string]any)
+	if !ok {
+		return opts
+	}
+
+	masked := make(map[string]any)
+	for k, v := range env {
+		if s, ok := v.(string); ok {
+			v = mask(s)
+		}
+		masked[k] = v
+	}
+	opts = maps.Clone(opts)
+	opts["env"] = masked
+	return opts
+}
+
+// maskAuth returns a copy of the provided target authentication with
+// its secrets masked.
+func maskAuth(auth *TargetAuth) *TargetAuth {
+	if auth == nil {
+		return nil
+	}
+	masked := *auth
+	masked.Token = mask(auth.Token)
+	masked.Password = mask(auth.Password)
+	return &masked
+}
+
+// maskValues returns a copy of the provided map with its values
+// masked.
+func maskValues(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	masked := maps.Clone(m)
+	for k, v := range masked {
+		masked[k] = mask(v)
+	}
+	return masked
+}
+
+// mask returns the masked representation of the provided secret. It
+// returns an empty string if the secret is empty.
+func mask(s string) string {
+	if s == "" {
+		return ""
+	}
+	return secretMask
+}
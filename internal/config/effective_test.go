@@ -0,0 +1,198 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"log/slog"
+	"testing"
+
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConfig_WithDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want Config
+	}{
+		{
+			name: "empty",
+			cfg:  Config{},
+			want: Config{
+				PathBase: ptr(PathBaseConfig),
+				AgentConfig: AgentConfig{
+					PullPolicy:        ptr(agentconfig.PullPolicyIfNotPresent),
+					Parallel:          ptr(DefaultAgentParallel),
+					Timeout:           ptr(DefaultAgentTimeout),
+					MaxNoMsgsInterval: ptr(DefaultAgentMaxNoMsgsInterval),
+					RegistryBackoff: BackoffConfig{
+						MaxRetries: ptr(DefaultRegistryBackoffMaxRetries),
+						Interval:   ptr(DefaultRegistryBackoffInterval),
+					},
+					PullRetry: PullRetryConfig{
+						MaxRetries: ptr(DefaultPullRetryMaxRetries),
+						Interval:   ptr(DefaultPullRetryInterval),
+					},
+				},
+				ReportConfig: ReportConfig{
+					Severity:                 ptr(SeverityHigh),
+					ShowSeverity:             ptr(SeverityHigh),
+					Format:                   ptr(OutputFormatHuman),
+					Theme:                    ptr(ThemeDefault),
+					ErrorOnStaleExclusions:   ptr(false),
+					RequireExclusionMetadata: ptr(false),
+					ErrorOnInconclusive:      ptr(true),
+					Sanitize:                 ptr(true),
+				},
+				LogLevel:  ptr(slog.LevelInfo),
+				LogFormat: ptr(LogFormatText),
+			},
+		},
+		{
+			name: "set values",
+			cfg: Config{
+				AgentConfig: AgentConfig{
+					Parallel: ptr(Parallel(4)),
+				},
+				ReportConfig: ReportConfig{
+					Severity:            ptr(SeverityLow),
+					ErrorOnInconclusive: ptr(false),
+				},
+				LogFile: ptr("lava.log"),
+			},
+			want: Config{
+				PathBase: ptr(PathBaseConfig),
+				AgentConfig: AgentConfig{
+					PullPolicy:        ptr(agentconfig.PullPolicyIfNotPresent),
+					Parallel:          ptr(Parallel(4)),
+					Timeout:           ptr(DefaultAgentTimeout),
+					MaxNoMsgsInterval: ptr(DefaultAgentMaxNoMsgsInterval),
+					RegistryBackoff: BackoffConfig{
+						MaxRetries: ptr(DefaultRegistryBackoffMaxRetries),
+						Interval:   ptr(DefaultRegistryBackoffInterval),
+					},
+					PullRetry: PullRetryConfig{
+						MaxRetries: ptr(DefaultPullRetryMaxRetries),
+						Interval:   ptr(DefaultPullRetryInterval),
+					},
+				},
+				ReportConfig: ReportConfig{
+					Severity:                 ptr(SeverityLow),
+					ShowSeverity:             ptr(SeverityLow),
+					Format:                   ptr(OutputFormatHuman),
+					Theme:                    ptr(ThemeDefault),
+					ErrorOnStaleExclusions:   ptr(false),
+					RequireExclusionMetadata: ptr(false),
+					ErrorOnInconclusive:      ptr(false),
+					Sanitize:                 ptr(true),
+				},
+				LogLevel:       ptr(slog.LevelInfo),
+				LogFormat:      ptr(LogFormatText),
+				LogFile:        ptr("lava.log"),
+				LogFileMaxSize: ptr(int64(100 << 20)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.WithDefaults()
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("config mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestConfig_Masked(t *testing.T) {
+	cfg := Config{
+		AgentConfig: AgentConfig{
+			Vars: map[string]string{
+				"TOKEN": "secret",
+				"EMPTY": "",
+			},
+			RegistryAuths: []RegistryAuth{
+				{
+					Server:   "example.com",
+					Username: "user",
+					Password: "secret",
+				},
+			},
+		},
+		Targets: []Target{
+			{
+				Identifier: "https://example.com",
+				Auth: &TargetAuth{
+					Type:  AuthTypeHeader,
+					Token: "secret",
+				},
+			},
+			{
+				Identifier: "https://example.org",
+				Options: map[string]any{
+					"branch": "main",
+					"env":    map[string]any{"API_KEY": "secret"},
+				},
+			},
+		},
+		Services: []Service{
+			{
+				Name: "db",
+				Env:  map[string]string{"PASSWORD": "secret"},
+			},
+		},
+	}
+
+	want := Config{
+		AgentConfig: AgentConfig{
+			Vars: map[string]string{
+				"TOKEN": "*****",
+				"EMPTY": "",
+			},
+			RegistryAuths: []RegistryAuth{
+				{
+					Server:   "example.com",
+					Username: "user",
+					Password: "*****",
+				},
+			},
+		},
+		Targets: []Target{
+			{
+				Identifier: "https://example.com",
+				Auth: &TargetAuth{
+					Type:  AuthTypeHeader,
+					Token: "*****",
+				},
+			},
+			{
+				Identifier: "https://example.org",
+				Options: map[string]any{
+					"branch": "main",
+					"env":    map[string]any{"API_KEY": "*****"},
+				},
+			},
+		},
+		Services: []Service{
+			{
+				Name: "db",
+				Env:  map[string]string{"PASSWORD": "*****"},
+			},
+		},
+	}
+
+	got := cfg.Masked()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("config mismatch (-want +got):\n%v", diff)
+	}
+
+	// The original configuration must not be modified.
+	if cfg.AgentConfig.Vars["TOKEN"] != "secret" ||
+		cfg.AgentConfig.RegistryAuths[0].Password != "secret" ||
+		cfg.Targets[0].Auth.Token != "secret" ||
+		cfg.Targets[1].Options["env"].(map[string]any)["API_KEY"] != "secret" ||
+		cfg.Services[0].Env["PASSWORD"] != "secret" {
+		t.Errorf("original config modified: %+v", cfg)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// fieldVersions maps the configuration fields to the Lava version that
+// introduced them. Fields are identified by their path, with the keys
+// separated by dots. Fields nested in a listed field are not listed.
+// Fields not present in the table are available since the first
+// version of Lava.
+var fieldVersions = map[string]string{
+	"scope":                           "v0.8.0",
+	"includes":                        "v0.8.0",
+	"pathBase":                        "v0.8.0",
+	"discovery":                       "v0.8.0",
+	"checktypesIntegrity":             "v0.8.0",
+	"services":                        "v0.8.0",
+	"stages":                          "v0.8.0",
+	"targetsFrom":                     "v0.8.0",
+	"defaults":                        "v0.8.0",
+	"logFormat":                       "v0.8.0",
+	"logFile":                         "v0.8.0",
+	"logFileMaxSize":                  "v0.8.0",
+	"agent.registryMirrors":           "v0.8.0",
+	"agent.tmpDir":                    "v0.8.0",
+	"agent.network":                   "v0.8.0",
+	"agent.platform":                  "v0.8.0",
+	"agent.hangTimeout":               "v0.8.0",
+	"agent.timeout":                   "v0.8.0",
+	"agent.maxNoMsgsInterval":         "v0.8.0",
+	"agent.registryBackoff":           "v0.8.0",
+	"agent.pullRetry":                 "v0.8.0",
+	"agent.volumes":                   "v0.8.0",
+	"agent.imageCache":                "v0.8.0",
+	"agent.state":                     "v0.8.0",
+	"agent.watchdog":                  "v0.8.0",
+	"agent.proxyAllow":                "v0.8.0",
+	"report.upload":                   "v0.8.0",
+	"report.errorOnInconclusive":      "v0.8.0",
+	"report.theme":                    "v0.8.0",
+	"report.width":                    "v0.8.0",
+	"report.template":                 "v0.8.0",
+	"report.columns":                  "v0.8.0",
+	"report.history":                  "v0.8.0",
+	"report.requireExclusionMetadata": "v0.8.0",
+	"report.exclusions.owner":         "v0.8.0",
+	"report.exclusions.signature":     "v0.8.0",
+	"report.exclusionKeys":            "v0.8.0",
+	"report.exclusionExpiry":          "v0.8.0",
+	"report.grade":                    "v0.8.0",
+	"report.sla":                      "v0.8.0",
+	"report.advisory":                 "v0.8.0",
+	"report.severityRules":            "v0.8.0",
+	"report.metadata":                 "v0.8.0",
+	"report.encryption":               "v0.8.0",
+	"report.sanitize":                 "v0.8.0",
+	"report.maxFindings":              "v0.8.0",
+	"report.maxDetailSize":            "v0.8.0",
+	"report.fullOutput":               "v0.8.0",
+	"targets.auth":                    "v0.8.0",
+	"targets.rateLimit":               "v0.8.0",
+	"targets.terraform":               "v0.8.0",
+	"targets.udpPorts":                "v0.8.0",
+	"targets.mapTarget":               "v0.8.0",
+	"targets.exclusions":              "v0.8.0",
+	"targets.severity":                "v0.8.0",
+}
+
+// checkFields checks the fields of the provided configuration
+// document. It returns an error wrapping [ErrUnknownField] if the
+// document contains a field that is not supported by this version of
+// Lava. It logs a warning for every field that was introduced in a
+// version of Lava newer than the minimum version required by the
+// document.
+func checkFields(doc *yaml.Node) error {
+	version := lavaVersion(doc)
+
+	var unknown error
+	walkFields(doc, reflect.TypeOf(Config{}), "", func(path string, key *yaml.Node, known bool) {
+		if !known {
+			if unknown == nil {
+				unknown = unknownFieldError(path, key.Line, version)
+			}
+			return
+		}
+
+		minVersion, ok := fieldVersions[path]
+		if !ok || !semver.IsValid(version) || semver.Compare(version, minVersion) >= 0 {
+			return
+		}
+		slog.Warn("configuration field requires a newer Lava version than the one specified in the configuration",
+			"field", path, "line", key.Line, "version", minVersion, "lava", version)
+	})
+	return unknown
+}
+
+// unknownFieldError returns an error wrapping [ErrUnknownField] for
+// the specified field.
+func unknownFieldError(path string, line int, version string) error {
+	if !semver.IsValid(version) {
+		return fmt.Errorf("%w: %v (line %v)", ErrUnknownField, path, line)
+	}
+	return fmt.Errorf("%w: %v (line %v): the configuration requires Lava %v or later", ErrUnknownField, path, line, version)
+}
+
+// lavaVersion returns the value of the "lava" field of the provided
+// configuration document. It returns an empty string if the field is
+// missing.
+func lavaVersion(doc *yaml.Node) string {
+	n := resolve(doc)
+	if n == nil || n.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == "lava" {
+			return n.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// walkFields walks the fields of the provided YAML node, which is
+// decoded into a value of type t, and calls fn for every mapping key
+// that corresponds to a struct field. The known argument of fn
+// reports whether t has a field for the key. Map values are not
+// walked.
+func walkFields(n *yaml.Node, t reflect.Type, path string, fn func(path string, key *yaml.Node, known bool)) {
+	n = resolve(n)
+	if n == nil {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for _, item := range n.Content {
+			walkFields(item, t.Elem(), path, fn)
+		}
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+
+			// Merge keys ("<<") insert the fields of the
+			// referenced mapping.
+			if key.Tag == "!!merge" {
+				walkFields(value, t, path, fn)
+				continue
+			}
+
+			fieldPath := key.Value
+			if path != "" {
+				fieldPath = path + "." + key.Value
+			}
+
+			ft, ok := fields[key.Value]
+			fn(fieldPath, key, ok)
+			if ok {
+				walkFields(value, ft, fieldPath, fn)
+			}
+		}
+	}
+}
+
+// yamlFields returns the types of the fields of the provided struct
+// type indexed by their YAML name.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// resolve returns the content of the provided document node or the
+// node referenced by the provided alias node. Otherwise, it returns
+// the provided node.
+func resolve(n *yaml.Node) *yaml.Node {
+	for n != nil {
+		switch {
+		case n.Kind == yaml.DocumentNode && len(n.Content) > 0:
+			n = n.Content[0]
+		case n.Kind == yaml.AliasNode:
+			n = n.Alias
+		default:
+			return n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveIncludes merges the configuration files referenced by the
+// includes of c into it and expands the glob patterns of its
+// checktype catalogs. Relative includes are resolved against dir,
+// which is the directory of the file that contains c. The other
+// relative paths are resolved as described in
+// [Config.resolvePaths]. The values of c take
+// precedence over the included ones and lists are concatenated,
+// with the items of the included files first. visited contains the
+// absolute paths of the files being included, which allows to detect
+// include cycles.
+func (c Config) resolveIncludes(dir string, visited map[string]bool) (Config, error) {
+	c = c.resolvePaths(dir)
+
+	urls, err := expandChecktypeURLs(c.ChecktypeURLs)
+	if err != nil {
+		return Config{}, fmt.Errorf("expand checktypes: %w", err)
+	}
+	c.ChecktypeURLs = urls
+
+	if len(c.Includes) == 0 {
+		return c, nil
+	}
+
+	var merged Config
+	for _, pattern := range c.Includes {
+		paths, err := expandGlob(dir, pattern)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: %w", ErrInvalidInclude, err)
+		}
+		for _, path := range paths {
+			inc, err := includeFile(path, visited)
+			if err != nil {
+				return Config{}, fmt.Errorf("include %v: %w", path, err)
+			}
+			if merged, err = merge(merged, inc); err != nil {
+				return Config{}, fmt.Errorf("merge %v: %w", path, err)
+			}
+		}
+	}
+
+	includes := c.Includes
+	if merged, err = merge(merged, c); err != nil {
+		return Config{}, fmt.Errorf("merge includes: %w", err)
+	}
+	merged.Includes = includes
+	return merged, nil
+}
+
+// includeFile reads the configuration file at path and resolves its
+// includes.
+func includeFile(path string, visited map[string]bool) (Config, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("absolute path: %w", err)
+	}
+	if visited[absPath] {
+		return Config{}, fmt.Errorf("%w: include cycle", ErrInvalidInclude)
+	}
+	visited[absPath] = true
+	defer delete(visited, absPath)
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		return Config{}, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	inc, err := decode(f)
+	if err != nil {
+		return Config{}, err
+	}
+	return inc.resolveIncludes(filepath.Dir(absPath), visited)
+}
+
+// expandChecktypeURLs expands the checktype catalog entries that are
+// local glob patterns. The matching files are sorted lexically.
+// Other entries are returned unmodified. Relative patterns must be
+// already resolved, so they are matched against the current working
+// directory.
+func expandChecktypeURLs(urls []string) ([]string, error) {
+	var expanded []string
+	for _, u := range urls {
+		if isURL(u) || !isGlob(u) {
+			expanded = append(expanded, u)
+			continue
+		}
+		paths, err := expandGlob("", u)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, paths...)
+	}
+	return expanded, nil
+}
+
+// expandGlob returns the paths matching the provided glob pattern.
+// If the pattern is relative, it is resolved against dir. A pattern
+// without glob metacharacters must match an existing file. A glob
+// pattern may match no files.
+func expandGlob(dir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	if !isGlob(pattern) {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("glob %q: %w", pattern, err)
+	}
+	return paths, nil
+}
+
+// isGlob reports whether s contains glob metacharacters.
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// isURL reports whether s is a URL with a scheme other than a
+// Windows drive letter.
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && len(u.Scheme) > 1
+}
@@ -41,7 +41,7 @@ func TestLavaMerger_Merge(t *testing.T) {
 				LavaVersion: ptr("v1.0.0"),
 				AgentConfig: AgentConfig{
 					PullPolicy: ptr(agentconfig.PullPolicyAlways),
-					Parallel:   ptr(4),
+					Parallel:   ptr(Parallel(4)),
 					Vars: map[string]string{
 						"VAR1": "value1",
 						"VAR2": "value2",
@@ -71,7 +71,7 @@ func TestLavaMerger_Merge(t *testing.T) {
 				LavaVersion: ptr("v1.0.0"),
 				AgentConfig: AgentConfig{
 					PullPolicy: ptr(agentconfig.PullPolicyAlways),
-					Parallel:   ptr(4),
+					Parallel:   ptr(Parallel(4)),
 					Vars: map[string]string{
 						"VAR1": "value1",
 						"VAR2": "value2",
@@ -106,7 +106,7 @@ func TestLavaMerger_Merge(t *testing.T) {
 				LavaVersion: ptr("v1.0.0"),
 				AgentConfig: AgentConfig{
 					PullPolicy: ptr(agentconfig.PullPolicyAlways),
-					Parallel:   ptr(4),
+					Parallel:   ptr(Parallel(4)),
 					Vars: map[string]string{
 						"VAR3": "value3",
 						"VAR4": "value4",
@@ -137,7 +137,7 @@ func TestLavaMerger_Merge(t *testing.T) {
 				LavaVersion: ptr("v1.0.1"),
 				AgentConfig: AgentConfig{
 					PullPolicy: ptr(agentconfig.PullPolicyNever),
-					Parallel:   ptr(3),
+					Parallel:   ptr(Parallel(3)),
 					Vars: map[string]string{
 						"VAR1": "value1",
 						"VAR2": "value2",
@@ -168,7 +168,7 @@ func TestLavaMerger_Merge(t *testing.T) {
 				LavaVersion: ptr("v1.0.1"),
 				AgentConfig: AgentConfig{
 					PullPolicy: ptr(agentconfig.PullPolicyNever),
-					Parallel:   ptr(3),
+					Parallel:   ptr(Parallel(3)),
 					Vars: map[string]string{
 						"VAR1": "value1",
 						"VAR2": "value2",
@@ -0,0 +1,151 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/assettypes"
+)
+
+// defaultPorts contains the default port of the URL schemes whose
+// port is removed from the normalized identifiers.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ssh":   "22",
+	"git":   "9418",
+}
+
+// Normalize returns a copy of the target with its identifier in
+// canonical form, so equivalent identifiers are equal. Paths are
+// cleaned, the scheme and host of URLs are lower-cased and their
+// default port is removed, hostnames are lower-cased and IP
+// addresses and ranges are formatted in their canonical notation.
+// Identifiers that cannot be parsed and identifiers with template
+// actions, which are rendered later, are left unchanged.
+func (t Target) Normalize() Target {
+	if isTemplate(t.Identifier) {
+		return t
+	}
+	t.Identifier = normalizeIdentifier(t.AssetType, t.Identifier)
+	return t
+}
+
+// normalizeIdentifier returns the canonical form of the provided
+// target identifier.
+func normalizeIdentifier(at types.AssetType, ident string) string {
+	switch at {
+	case assettypes.Path, assettypes.DockerImageArchive, assettypes.OCILayout, assettypes.TerraformModule:
+		return filepath.Clean(ident)
+	case types.GitRepository:
+		if !strings.Contains(ident, "://") {
+			return filepath.Clean(ident)
+		}
+		u, err := normalizeURL(ident)
+		if err != nil {
+			return ident
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+		return u.String()
+	case types.WebAddress:
+		u, err := normalizeURL(ident)
+		if err != nil {
+			return ident
+		}
+		if u.Path == "/" {
+			u.Path = ""
+			u.RawPath = ""
+		}
+		return u.String()
+	case types.Hostname, types.DomainName:
+		return normalizeHost(ident)
+	case types.IP:
+		addr, err := netip.ParseAddr(ident)
+		if err != nil {
+			return ident
+		}
+		return addr.String()
+	case types.IPRange:
+		prefix, err := netip.ParsePrefix(ident)
+		if err != nil {
+			return ident
+		}
+		return prefix.Masked().String()
+	}
+	return ident
+}
+
+// normalizeURL parses the provided absolute URL and normalizes its
+// scheme and host.
+func normalizeURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("not an absolute URL")
+	}
+
+	host := normalizeHost(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[u.Scheme] {
+		host += ":" + port
+	}
+	u.Host = host
+	return u, nil
+}
+
+// normalizeHost lower-cases the provided host and removes its
+// trailing dot.
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// validateIdentifier reports whether the identifier of the target
+// is valid for its asset type. Only the asset types with a well
+// defined syntax are checked.
+func (t Target) validateIdentifier() error {
+	if isTemplate(t.Identifier) {
+		return nil
+	}
+
+	switch t.AssetType {
+	case types.IP:
+		if _, err := netip.ParseAddr(t.Identifier); err != nil {
+			return err
+		}
+	case types.IPRange:
+		if _, err := netip.ParsePrefix(t.Identifier); err != nil {
+			return err
+		}
+	case types.WebAddress:
+		if _, err := normalizeURL(t.Identifier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTemplate reports whether the provided identifier contains
+// template actions.
+func isTemplate(ident string) bool {
+	return strings.Contains(ident, "{{")
+}
+
+// normalizeTargets normalizes the identifiers of the targets of the
+// configuration.
+func (c *Config) normalizeTargets() {
+	for i, t := range c.Targets {
+		c.Targets[i] = t.Normalize()
+	}
+}
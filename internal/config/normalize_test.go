@@ -0,0 +1,107 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/assettypes"
+)
+
+func TestTarget_Normalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		target Target
+		want   string
+	}{
+		{
+			name:   "path",
+			target: Target{Identifier: "./.", AssetType: assettypes.Path},
+			want:   ".",
+		},
+		{
+			name:   "path with trailing slash",
+			target: Target{Identifier: "./src/../infra/", AssetType: assettypes.TerraformModule},
+			want:   "infra",
+		},
+		{
+			name:   "local git repository",
+			target: Target{Identifier: "/repo/./", AssetType: types.GitRepository},
+			want:   "/repo",
+		},
+		{
+			name:   "remote git repository",
+			target: Target{Identifier: "HTTPS://GitHub.com:443/adevinta/lava/", AssetType: types.GitRepository},
+			want:   "https://github.com/adevinta/lava",
+		},
+		{
+			name:   "scp-like git repository",
+			target: Target{Identifier: "git@github.com:adevinta/lava.git", AssetType: types.GitRepository},
+			want:   "git@github.com:adevinta/lava.git",
+		},
+		{
+			name:   "web address",
+			target: Target{Identifier: "https://Example.COM/", AssetType: types.WebAddress},
+			want:   "https://example.com",
+		},
+		{
+			name:   "web address with default port",
+			target: Target{Identifier: "http://example.com:80/Path/?q=A", AssetType: types.WebAddress},
+			want:   "http://example.com/Path/?q=A",
+		},
+		{
+			name:   "web address with port",
+			target: Target{Identifier: "http://[2001:DB8::1]:8080", AssetType: types.WebAddress},
+			want:   "http://[2001:db8::1]:8080",
+		},
+		{
+			name:   "invalid web address",
+			target: Target{Identifier: "Example.com", AssetType: types.WebAddress},
+			want:   "Example.com",
+		},
+		{
+			name:   "hostname",
+			target: Target{Identifier: "WWW.Example.com.", AssetType: types.Hostname},
+			want:   "www.example.com",
+		},
+		{
+			name:   "domain name",
+			target: Target{Identifier: "Example.com", AssetType: types.DomainName},
+			want:   "example.com",
+		},
+		{
+			name:   "IPv6",
+			target: Target{Identifier: "2001:DB8:0:0::1", AssetType: types.IP},
+			want:   "2001:db8::1",
+		},
+		{
+			name:   "IP range",
+			target: Target{Identifier: "10.1.2.3/8", AssetType: types.IPRange},
+			want:   "10.0.0.0/8",
+		},
+		{
+			name:   "docker image",
+			target: Target{Identifier: "Alpine:Latest", AssetType: types.DockerImage},
+			want:   "Alpine:Latest",
+		},
+		{
+			name:   "template",
+			target: Target{Identifier: "{{.Services.App.Host}}", AssetType: types.Hostname},
+			want:   "{{.Services.App.Host}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.target.Normalize()
+			if got.Identifier != tt.want {
+				t.Errorf("unexpected identifier: want: %q, got: %q", tt.want, got.Identifier)
+			}
+			if got.AssetType != tt.target.AssetType {
+				t.Errorf("unexpected asset type: want: %v, got: %v", tt.target.AssetType, got.AssetType)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/checktypes"
+)
+
+// PathBase is the directory relative paths of a configuration file
+// are resolved against.
+type PathBase int
+
+// Path bases available.
+const (
+	// PathBaseConfig resolves relative paths against the
+	// directory of the configuration file.
+	PathBaseConfig PathBase = iota
+
+	// PathBaseCWD resolves relative paths against the current
+	// working directory.
+	PathBaseCWD
+)
+
+var pathBaseNames = map[string]PathBase{
+	"config": PathBaseConfig,
+	"cwd":    PathBaseCWD,
+}
+
+// parsePathBase converts a string into a [PathBase] value.
+func parsePathBase(base string) (PathBase, error) {
+	if val, ok := pathBaseNames[strings.ToLower(base)]; ok {
+		return val, nil
+	}
+	return PathBase(0), fmt.Errorf("%w: %v", ErrInvalidPathBase, base)
+}
+
+// String returns the string representation of the path base.
+func (b PathBase) String() string {
+	for k, v := range pathBaseNames {
+		if v == b {
+			return k
+		}
+	}
+	return ""
+}
+
+// IsValid reports whether the path base is known.
+func (b PathBase) IsValid() bool {
+	for _, v := range pathBaseNames {
+		if v == b {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalText encodes a [PathBase] as text. It returns error if the
+// path base is not valid.
+func (b PathBase) MarshalText() (text []byte, err error) {
+	if !b.IsValid() {
+		return nil, ErrInvalidPathBase
+	}
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText decodes a [PathBase] text into a [PathBase] value.
+// It returns error if the provided string does not match any known
+// path base.
+func (b *PathBase) UnmarshalText(text []byte) error {
+	base, err := parsePathBase(string(text))
+	if err != nil {
+		return err
+	}
+	*b = base
+	return nil
+}
+
+// resolvePaths returns a copy of c with its relative local paths
+// resolved against dir, which is the directory of the file that
+// contains c. The paths are left unchanged if dir is empty or the
+// path base of c is [PathBaseCWD]. URLs, absolute paths and the
+// variable files of the Terraform targets, which are relative to
+// the module, are never modified.
+func (c Config) resolvePaths(dir string) Config {
+	if dir == "" || Get(c.PathBase) == PathBaseCWD {
+		return c
+	}
+	r := pathResolver(dir)
+
+	var urls []string
+	for _, u := range c.ChecktypeURLs {
+		urls = append(urls, r.resolve(u))
+	}
+	c.ChecktypeURLs = urls
+
+	if c.ChecktypesIntegrity != nil {
+		integrity := make(map[string]CatalogIntegrity, len(c.ChecktypesIntegrity))
+		for u, ci := range c.ChecktypesIntegrity {
+			ci.Signature = r.resolvePtr(ci.Signature)
+			if ci.PublicKey != nil && !checktypes.IsInlineKey(*ci.PublicKey) {
+				ci.PublicKey = r.resolvePtr(ci.PublicKey)
+			}
+			integrity[r.resolve(u)] = ci
+		}
+		c.ChecktypesIntegrity = integrity
+	}
+
+	var targets []Target
+	for _, t := range c.Targets {
+		targets = append(targets, r.resolveTarget(t))
+	}
+	c.Targets = targets
+
+	if c.Defaults != nil {
+		defaults := make(map[types.AssetType]TargetDefaults, len(c.Defaults))
+		for at, d := range c.Defaults {
+			d.Auth = r.resolveAuth(d.Auth)
+			defaults[at] = d
+		}
+		c.Defaults = defaults
+	}
+
+	var sources []TargetSource
+	for _, src := range c.TargetsFrom {
+		src.File = r.resolvePtr(src.File)
+		sources = append(sources, src)
+	}
+	c.TargetsFrom = sources
+
+	if c.Discovery != nil {
+		discovery := *c.Discovery
+		discovery.Wordlist = r.resolvePtr(discovery.Wordlist)
+		c.Discovery = &discovery
+	}
+
+	c.ReportConfig.Template = r.resolvePtr(c.ReportConfig.Template)
+	c.ReportConfig.OutputFile = r.resolvePtr(c.ReportConfig.OutputFile)
+	c.ReportConfig.FullOutput = r.resolvePtr(c.ReportConfig.FullOutput)
+	c.ReportConfig.Metrics = r.resolvePtr(c.ReportConfig.Metrics)
+	c.ReportConfig.History = r.resolvePtr(c.ReportConfig.History)
+	c.ReportConfig.Grade.Badge = r.resolvePtr(c.ReportConfig.Grade.Badge)
+
+	c.AgentConfig.TmpDir = r.resolvePtr(c.AgentConfig.TmpDir)
+	c.AgentConfig.ImageCache.Dir = r.resolvePtr(c.AgentConfig.ImageCache.Dir)
+	c.AgentConfig.State.File = r.resolvePtr(c.AgentConfig.State.File)
+
+	c.LogFile = r.resolvePtr(c.LogFile)
+
+	return c
+}
+
+// pathResolver resolves relative paths against a base directory.
+type pathResolver string
+
+// resolve joins the provided path with the base directory if it is
+// a relative local path. Empty paths, URLs and absolute paths are
+// returned unmodified.
+func (r pathResolver) resolve(path string) string {
+	if path == "" || isURL(path) || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(string(r), path)
+}
+
+// resolvePtr is like [pathResolver.resolve] but works with pointers
+// to paths.
+func (r pathResolver) resolvePtr(path *string) *string {
+	if path == nil {
+		return nil
+	}
+	resolved := r.resolve(*path)
+	return &resolved
+}
+
+// resolveTarget returns a copy of the target with its local paths
+// resolved. The identifiers of GitRepository targets are only
+// resolved if they point to an existing local path, so remote
+// repositories like "git@example.com:repo.git" are not modified.
+func (r pathResolver) resolveTarget(t Target) Target {
+	t.Auth = r.resolveAuth(t.Auth)
+
+	if isTemplate(t.Identifier) {
+		return t
+	}
+
+	switch t.AssetType {
+	case assettypes.Path, assettypes.DockerImageArchive, assettypes.OCILayout, assettypes.TerraformModule:
+		t.Identifier = r.resolve(t.Identifier)
+	case types.GitRepository:
+		if strings.Contains(t.Identifier, "://") {
+			break
+		}
+		path := r.resolve(t.Identifier)
+		if _, err := os.Stat(path); err == nil {
+			t.Identifier = path
+		}
+	}
+	return t
+}
+
+// resolveAuth returns a copy of the provided target authentication
+// with its local paths resolved.
+func (r pathResolver) resolveAuth(auth *TargetAuth) *TargetAuth {
+	if auth == nil {
+		return nil
+	}
+	resolved := *auth
+	resolved.TokenFile = r.resolve(resolved.TokenFile)
+	resolved.PasswordFile = r.resolve(resolved.PasswordFile)
+	resolved.Script = r.resolve(resolved.Script)
+	return &resolved
+}
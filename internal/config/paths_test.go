@@ -0,0 +1,163 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/assettypes"
+)
+
+func TestConfig_resolvePaths(t *testing.T) {
+	// Inline public keys are not paths, so they are not resolved.
+	const inlineKey = "-----BEGIN PUBLIC KEY-----\nMCowBQYDK2VwAyEA\n-----END PUBLIC KEY-----\n"
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "repo"), 0o755); err != nil {
+		t.Fatalf("mkdir error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		cfg  Config
+		want Config
+	}{
+		{
+			name: "relative paths",
+			dir:  dir,
+			cfg: Config{
+				ChecktypeURLs: []string{
+					"checktypes.json",
+					"catalogs/*.json",
+					"/opt/lava/checktypes.json",
+					"https://example.com/checktypes.json",
+				},
+				ChecktypesIntegrity: map[string]CatalogIntegrity{
+					"checktypes.json": {
+						Signature: ptr("checktypes.json.sig"),
+						PublicKey: ptr(inlineKey),
+					},
+					"https://example.com/checktypes.json": {
+						Signature: ptr("https://example.com/checktypes.json.sig"),
+						PublicKey: ptr("cosign.pub"),
+					},
+				},
+				Targets: []Target{
+					{Identifier: ".", AssetType: assettypes.Path},
+					{Identifier: "image.tar", AssetType: assettypes.DockerImageArchive},
+					{Identifier: "repo", AssetType: types.GitRepository},
+					{Identifier: "missing", AssetType: types.GitRepository},
+					{Identifier: "git@example.com:org/repo.git", AssetType: types.GitRepository},
+					{Identifier: "{{ .Env.SRC }}", AssetType: assettypes.Path},
+					{
+						Identifier: "https://example.com",
+						AssetType:  types.WebAddress,
+						Auth:       &TargetAuth{Type: AuthTypeBasic, Username: "user", PasswordFile: "password.txt"},
+					},
+					{
+						Identifier: "infra",
+						AssetType:  assettypes.TerraformModule,
+						Terraform:  &TerraformConfig{VarFiles: []string{"prod.tfvars"}},
+					},
+				},
+				TargetsFrom: []TargetSource{{File: ptr("targets.yaml"), AssetType: types.DockerImage}},
+				ReportConfig: ReportConfig{
+					OutputFile: ptr("s3://bucket/report.json"),
+					Metrics:    ptr(""),
+					History:    ptr("history.jsonl"),
+				},
+				AgentConfig: AgentConfig{
+					State: StateConfig{File: ptr("state.json")},
+				},
+				LogFile: ptr("/var/log/lava.log"),
+			},
+			want: Config{
+				ChecktypeURLs: []string{
+					filepath.Join(dir, "checktypes.json"),
+					filepath.Join(dir, "catalogs/*.json"),
+					"/opt/lava/checktypes.json",
+					"https://example.com/checktypes.json",
+				},
+				ChecktypesIntegrity: map[string]CatalogIntegrity{
+					filepath.Join(dir, "checktypes.json"): {
+						Signature: ptr(filepath.Join(dir, "checktypes.json.sig")),
+						PublicKey: ptr(inlineKey),
+					},
+					"https://example.com/checktypes.json": {
+						Signature: ptr("https://example.com/checktypes.json.sig"),
+						PublicKey: ptr(filepath.Join(dir, "cosign.pub")),
+					},
+				},
+				Targets: []Target{
+					{Identifier: dir, AssetType: assettypes.Path},
+					{Identifier: filepath.Join(dir, "image.tar"), AssetType: assettypes.DockerImageArchive},
+					{Identifier: filepath.Join(dir, "repo"), AssetType: types.GitRepository},
+					{Identifier: "missing", AssetType: types.GitRepository},
+					{Identifier: "git@example.com:org/repo.git", AssetType: types.GitRepository},
+					{Identifier: "{{ .Env.SRC }}", AssetType: assettypes.Path},
+					{
+						Identifier: "https://example.com",
+						AssetType:  types.WebAddress,
+						Auth:       &TargetAuth{Type: AuthTypeBasic, Username: "user", PasswordFile: filepath.Join(dir, "password.txt")},
+					},
+					{
+						Identifier: filepath.Join(dir, "infra"),
+						AssetType:  assettypes.TerraformModule,
+						Terraform:  &TerraformConfig{VarFiles: []string{"prod.tfvars"}},
+					},
+				},
+				TargetsFrom: []TargetSource{{File: ptr(filepath.Join(dir, "targets.yaml")), AssetType: types.DockerImage}},
+				ReportConfig: ReportConfig{
+					OutputFile: ptr("s3://bucket/report.json"),
+					Metrics:    ptr(""),
+					History:    ptr(filepath.Join(dir, "history.jsonl")),
+				},
+				AgentConfig: AgentConfig{
+					State: StateConfig{File: ptr(filepath.Join(dir, "state.json"))},
+				},
+				LogFile: ptr("/var/log/lava.log"),
+			},
+		},
+		{
+			name: "path base cwd",
+			dir:  dir,
+			cfg: Config{
+				PathBase:      ptr(PathBaseCWD),
+				ChecktypeURLs: []string{"checktypes.json"},
+				Targets:       []Target{{Identifier: ".", AssetType: assettypes.Path}},
+			},
+			want: Config{
+				PathBase:      ptr(PathBaseCWD),
+				ChecktypeURLs: []string{"checktypes.json"},
+				Targets:       []Target{{Identifier: ".", AssetType: assettypes.Path}},
+			},
+		},
+		{
+			name: "empty dir",
+			dir:  "",
+			cfg: Config{
+				ChecktypeURLs: []string{"checktypes.json"},
+				Targets:       []Target{{Identifier: ".", AssetType: assettypes.Path}},
+			},
+			want: Config{
+				ChecktypeURLs: []string{"checktypes.json"},
+				Targets:       []Target{{Identifier: ".", AssetType: assettypes.Path}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.resolvePaths(tt.dir)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("config mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// ParseExclusionKey parses a public key used to verify the signatures
+// of the exclusions. The key is an Ed25519 public key encoded in
+// base64. Both raw keys and DER-encoded PKIX keys, like the body of a
+// PEM "PUBLIC KEY" block, are supported.
+func ParseExclusionKey(s string) (ed25519.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode base64: %w", ErrInvalidExclusionKey, err)
+	}
+
+	if len(der) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(der), nil
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parse key: %w", ErrInvalidExclusionKey, err)
+	}
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an Ed25519 key", ErrInvalidExclusionKey)
+	}
+	return key, nil
+}
+
+// SignedMessage returns the message signed by the signature of the
+// exclusion. It is the compact JSON encoding of the exclusion without
+// the signature, with the fields "target", "resource", "fingerprint",
+// "summary", "expiration", "description" and "owner" in that order.
+// Unset fields are encoded as empty strings. HTML characters are not
+// escaped.
+func (excl Exclusion) SignedMessage() ([]byte, error) {
+	var expiration string
+	if !excl.ExpirationDate.IsZero() {
+		expiration = excl.ExpirationDate.String()
+	}
+
+	msg := struct {
+		Target      string `json:"target"`
+		Resource    string `json:"resource"`
+		Fingerprint string `json:"fingerprint"`
+		Summary     string `json:"summary"`
+		Expiration  string `json:"expiration"`
+		Description string `json:"description"`
+		Owner       string `json:"owner"`
+	}{
+		Target:      excl.Target,
+		Resource:    excl.Resource,
+		Fingerprint: excl.Fingerprint,
+		Summary:     excl.Summary,
+		Expiration:  expiration,
+		Description: excl.Description,
+		Owner:       excl.Owner,
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(msg); err != nil {
+		return nil, fmt.Errorf("encode JSON: %w", err)
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// VerifySignature reports whether the exclusion is signed by any of
+// the provided keys. The signature is an Ed25519 signature of
+// [Exclusion.SignedMessage] encoded in base64.
+func (excl Exclusion) VerifySignature(keys []ed25519.PublicKey) bool {
+	if excl.Signature == "" {
+		return false
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(excl.Signature)
+	if err != nil {
+		return false
+	}
+
+	msg, err := excl.SignedMessage()
+	if err != nil {
+		return false
+	}
+
+	for _, key := range keys {
+		if ed25519.Verify(key, msg, sig) {
+			return true
+		}
+	}
+	return false
+}
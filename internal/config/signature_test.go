@@ -0,0 +1,201 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseExclusionKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("error marshaling key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating ECDSA key: %v", err)
+	}
+	ecDER, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatalf("error marshaling ECDSA key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		want    ed25519.PublicKey
+		wantErr error
+	}{
+		{
+			name: "raw key",
+			key:  base64.StdEncoding.EncodeToString(pub),
+			want: pub,
+		},
+		{
+			name: "PKIX key",
+			key:  base64.StdEncoding.EncodeToString(der),
+			want: pub,
+		},
+		{
+			name:    "invalid base64",
+			key:     "not base64!",
+			wantErr: ErrInvalidExclusionKey,
+		},
+		{
+			name:    "invalid key",
+			key:     base64.StdEncoding.EncodeToString([]byte("key")),
+			wantErr: ErrInvalidExclusionKey,
+		},
+		{
+			name:    "ECDSA key",
+			key:     base64.StdEncoding.EncodeToString(ecDER),
+			wantErr: ErrInvalidExclusionKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExclusionKey(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("key mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestExclusion_SignedMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		excl Exclusion
+		want string
+	}{
+		{
+			name: "all fields",
+			excl: Exclusion{
+				Target:         `^example\.com$`,
+				Resource:       "<resource>",
+				Fingerprint:    "fingerprint",
+				Summary:        "Summary & more",
+				ExpirationDate: mustParseExpDate("2024/07/05"),
+				Description:    "Accepted risk.",
+				Owner:          "security-team",
+				Signature:      "ignored",
+			},
+			want: `{"target":"^example\\.com$","resource":"<resource>","fingerprint":"fingerprint","summary":"Summary & more","expiration":"2024/07/05","description":"Accepted risk.","owner":"security-team"}`,
+		},
+		{
+			name: "empty",
+			excl: Exclusion{},
+			want: `{"target":"","resource":"","fingerprint":"","summary":"","expiration":"","description":"","owner":""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.excl.SignedMessage()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("message mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestExclusion_VerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	otherPub, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	sign := func(excl Exclusion, key ed25519.PrivateKey) Exclusion {
+		msg, err := excl.SignedMessage()
+		if err != nil {
+			t.Fatalf("error getting message: %v", err)
+		}
+		excl.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, msg))
+		return excl
+	}
+
+	excl := Exclusion{
+		Summary:     "Secret Leaked in Git Repository",
+		Description: "Ignore test certificates.",
+	}
+
+	tampered := sign(excl, priv)
+	tampered.Summary = ".*"
+
+	tests := []struct {
+		name string
+		excl Exclusion
+		keys []ed25519.PublicKey
+		want bool
+	}{
+		{
+			name: "valid signature",
+			excl: sign(excl, priv),
+			keys: []ed25519.PublicKey{pub},
+			want: true,
+		},
+		{
+			name: "any key",
+			excl: sign(excl, otherPriv),
+			keys: []ed25519.PublicKey{pub, otherPub},
+			want: true,
+		},
+		{
+			name: "unknown key",
+			excl: sign(excl, otherPriv),
+			keys: []ed25519.PublicKey{pub},
+			want: false,
+		},
+		{
+			name: "unsigned",
+			excl: excl,
+			keys: []ed25519.PublicKey{pub},
+			want: false,
+		},
+		{
+			name: "tampered",
+			excl: tampered,
+			keys: []ed25519.PublicKey{pub},
+			want: false,
+		},
+		{
+			name: "invalid base64",
+			excl: Exclusion{Summary: "Summary", Signature: "not base64!"},
+			keys: []ed25519.PublicKey{pub},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.excl.VerifySignature(tt.keys); got != tt.want {
+				t.Errorf("unexpected result: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
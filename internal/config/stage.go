@@ -0,0 +1,95 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"fmt"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/config/dag"
+)
+
+// Stage is a group of checktypes whose checks run together. Stages
+// run in dependency order, so the findings of the checks of a stage
+// can be used as targets by the checks of the stages that depend on
+// it.
+type Stage struct {
+	// Name is the name of the stage.
+	Name string `yaml:"name"`
+
+	// Checktypes is the list of the names of the checktypes of
+	// the stage. A checktype can only belong to one stage.
+	Checktypes []string `yaml:"checktypes"`
+
+	// DependsOn is the list of the names of the stages that must
+	// finish before this stage starts.
+	DependsOn []string `yaml:"dependsOn"`
+
+	// OutputType is the asset type of the targets generated from
+	// the findings of the stage. The affected resources of the
+	// findings are passed as targets to the stages that depend on
+	// this one. If empty, the stage does not generate targets.
+	OutputType types.AssetType `yaml:"outputType"`
+}
+
+// validate reports whether the stage is a valid configuration value.
+func (st Stage) validate() error {
+	if st.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidStage)
+	}
+	if len(st.Checktypes) == 0 {
+		return fmt.Errorf("%w: %v: no checktypes", ErrInvalidStage, st.Name)
+	}
+	if st.OutputType != "" && !st.OutputType.IsValid() && !assettypes.IsValid(st.OutputType) {
+		return fmt.Errorf("%w: %v: %w: %v", ErrInvalidStage, st.Name, ErrInvalidAssetType, st.OutputType)
+	}
+	return nil
+}
+
+// SortStages validates the provided stages and returns them in
+// dependency order. Every stage is returned after the stages it
+// depends on.
+func SortStages(stages []Stage) ([]Stage, error) {
+	var (
+		g          = dag.New()
+		byName     = make(map[string]Stage)
+		checktypes = make(map[string]string)
+	)
+	for _, st := range stages {
+		if err := st.validate(); err != nil {
+			return nil, err
+		}
+		if err := g.AddNode(st.Name); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidStage, err)
+		}
+		byName[st.Name] = st
+
+		for _, ct := range st.Checktypes {
+			if other, ok := checktypes[ct]; ok {
+				return nil, fmt.Errorf("%w: checktype %v in stages %v and %v", ErrInvalidStage, ct, other, st.Name)
+			}
+			checktypes[ct] = st.Name
+		}
+	}
+
+	for _, st := range stages {
+		for _, dep := range st.DependsOn {
+			if err := g.AddEdge(dep, st.Name); err != nil {
+				return nil, fmt.Errorf("%w: %v: %w", ErrInvalidStage, st.Name, err)
+			}
+		}
+	}
+
+	names, err := g.Sort()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidStage, err)
+	}
+
+	sorted := make([]Stage, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, byName[name])
+	}
+	return sorted, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSortStages(t *testing.T) {
+	tests := []struct {
+		name      string
+		stages    []Stage
+		wantNames []string
+		wantErr   error
+	}{
+		{
+			name: "dependency order",
+			stages: []Stage{
+				{Name: "report", Checktypes: []string{"c"}, DependsOn: []string{"dast", "discovery"}},
+				{Name: "dast", Checktypes: []string{"b"}, DependsOn: []string{"discovery"}},
+				{Name: "discovery", Checktypes: []string{"a"}},
+			},
+			wantNames: []string{"discovery", "dast", "report"},
+		},
+		{
+			name: "unknown dependency",
+			stages: []Stage{
+				{Name: "dast", Checktypes: []string{"b"}, DependsOn: []string{"discovery"}},
+			},
+			wantErr: ErrInvalidStage,
+		},
+		{
+			name: "duplicated checktype",
+			stages: []Stage{
+				{Name: "discovery", Checktypes: []string{"a"}},
+				{Name: "dast", Checktypes: []string{"a"}},
+			},
+			wantErr: ErrInvalidStage,
+		},
+		{
+			name: "duplicated name",
+			stages: []Stage{
+				{Name: "discovery", Checktypes: []string{"a"}},
+				{Name: "discovery", Checktypes: []string{"b"}},
+			},
+			wantErr: ErrInvalidStage,
+		},
+		{
+			name: "no checktypes",
+			stages: []Stage{
+				{Name: "discovery"},
+			},
+			wantErr: ErrInvalidStage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SortStages(tt.stages)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+
+			var names []string
+			for _, st := range got {
+				names = append(names, st.Name)
+			}
+			if diff := cmp.Diff(tt.wantNames, names); diff != "" {
+				t.Errorf("stages mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	types "github.com/adevinta/vulcan-types"
+	"gopkg.in/yaml.v3"
+)
+
+// TargetSource is a source of targets generated outside the
+// configuration file. For instance, by a previous step of a CI
+// pipeline. The source contains a JSON or YAML list whose elements
+// are targets or target identifiers.
+type TargetSource struct {
+	// File is the path of the file that contains the targets.
+	File *string `yaml:"file"`
+
+	// Env is the name of the environment variable that contains
+	// the targets.
+	Env *string `yaml:"env"`
+
+	// AssetType is the asset type of the targets that do not
+	// specify one. It is required if the source contains target
+	// identifiers.
+	AssetType types.AssetType `yaml:"type"`
+}
+
+// String returns the string representation of the [TargetSource].
+func (src TargetSource) String() string {
+	if src.File != nil {
+		return "file " + *src.File
+	}
+	return "env " + Get(src.Env)
+}
+
+// validate reports whether the target source is a valid
+// configuration value.
+func (src TargetSource) validate() error {
+	if (src.File == nil) == (src.Env == nil) {
+		return fmt.Errorf("%w: exactly one of file and env must be specified", ErrInvalidTargetSource)
+	}
+	return nil
+}
+
+// Targets reads the targets of the source.
+func (src TargetSource) Targets() ([]Target, error) {
+	if err := src.validate(); err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	if src.File != nil {
+		var err error
+		if b, err = os.ReadFile(*src.File); err != nil {
+			return nil, fmt.Errorf("read file: %w", err)
+		}
+	} else {
+		b = []byte(os.Getenv(*src.Env))
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v: %w", ErrInvalidTargetSource, src, err)
+	}
+
+	// An empty source contains no targets.
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	list := doc.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%w: %v: not a list", ErrInvalidTargetSource, src)
+	}
+
+	var targets []Target
+	for i, n := range list.Content {
+		var t Target
+		switch n.Kind {
+		case yaml.ScalarNode:
+			if src.AssetType == "" {
+				return nil, fmt.Errorf("%w: %v: element %v: identifier without asset type", ErrInvalidTargetSource, src, i)
+			}
+			t.Identifier = n.Value
+		case yaml.MappingNode:
+			if err := n.Decode(&t); err != nil {
+				return nil, fmt.Errorf("%w: %v: element %v: %w", ErrInvalidTargetSource, src, i, err)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %v: element %v: not a target", ErrInvalidTargetSource, src, i)
+		}
+		if t.AssetType == "" {
+			t.AssetType = src.AssetType
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// expandTargetSources appends the targets read from the target
+// sources of the configuration to its list of targets.
+func (c *Config) expandTargetSources() error {
+	for _, src := range c.TargetsFrom {
+		targets, err := src.Targets()
+		if err != nil {
+			return fmt.Errorf("read targets from %v: %w", src, err)
+		}
+		c.Targets = append(c.Targets, targets...)
+	}
+	return nil
+}
+
+// ParseTargetList parses a list of targets. Every line of the list
+// contains the asset type and the identifier of a target separated by
+// white space, like "DockerImage alpine:latest". Empty lines and
+// lines starting with "#" are ignored.
+func ParseTargetList(r io.Reader) ([]Target, error) {
+	var (
+		targets []Target
+		n       int
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		at := strings.Fields(line)[0]
+		identifier := strings.TrimSpace(line[len(at):])
+		if identifier == "" {
+			return nil, fmt.Errorf("%w: line %v: expected \"type identifier\"", ErrInvalidTargetSource, n)
+		}
+
+		targets = append(targets, Target{
+			Identifier: identifier,
+			AssetType:  types.AssetType(at),
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read target list: %w", err)
+	}
+	return targets, nil
+}
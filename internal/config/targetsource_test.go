@@ -0,0 +1,176 @@
+// Copyright 2024 Adevinta
+
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/assettypes"
+)
+
+func TestTargetSource_Targets(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     TargetSource
+		env     string
+		want    []Target
+		wantErr error
+	}{
+		{
+			name: "file",
+			src: TargetSource{
+				File:      ptr("testdata/targetsource/targets.yaml"),
+				AssetType: types.DockerImage,
+			},
+			want: []Target{
+				{Identifier: "example.com/app:1.0", AssetType: types.DockerImage},
+				{Identifier: "example.com/app:2.0", AssetType: types.DockerImage},
+				{Identifier: "https://example.com", AssetType: types.WebAddress},
+			},
+		},
+		{
+			name: "env json",
+			src: TargetSource{
+				Env:       ptr("LAVA_TEST_TARGETS"),
+				AssetType: assettypes.Path,
+			},
+			env: `["src", {"identifier": "infra", "type": "TerraformModule"}]`,
+			want: []Target{
+				{Identifier: "src", AssetType: assettypes.Path},
+				{Identifier: "infra", AssetType: assettypes.TerraformModule},
+			},
+		},
+		{
+			name: "empty env",
+			src: TargetSource{
+				Env:       ptr("LAVA_TEST_TARGETS"),
+				AssetType: assettypes.Path,
+			},
+			env:  "",
+			want: nil,
+		},
+		{
+			name: "identifier without asset type",
+			src: TargetSource{
+				Env: ptr("LAVA_TEST_TARGETS"),
+			},
+			env:     `["src"]`,
+			wantErr: ErrInvalidTargetSource,
+		},
+		{
+			name: "not a list",
+			src: TargetSource{
+				Env:       ptr("LAVA_TEST_TARGETS"),
+				AssetType: assettypes.Path,
+			},
+			env:     `{"identifier": "src"}`,
+			wantErr: ErrInvalidTargetSource,
+		},
+		{
+			name: "file and env",
+			src: TargetSource{
+				File: ptr("targets.yaml"),
+				Env:  ptr("LAVA_TEST_TARGETS"),
+			},
+			wantErr: ErrInvalidTargetSource,
+		},
+		{
+			name:    "no file nor env",
+			src:     TargetSource{},
+			wantErr: ErrInvalidTargetSource,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LAVA_TEST_TARGETS", tt.env)
+
+			got, err := tt.src.Targets()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestParseFile_targetsFrom(t *testing.T) {
+	t.Setenv("LAVA_TEST_TARGETS", `["./src/"]`)
+
+	cfg, err := ParseFile("testdata/targets_from.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Target{
+		{Identifier: "example.com/app:1.0", AssetType: types.DockerImage},
+		{Identifier: "example.com/app:2.0", AssetType: types.DockerImage},
+		{Identifier: "https://example.com", AssetType: types.WebAddress},
+		{Identifier: "src", AssetType: assettypes.Path},
+	}
+	if diff := cmp.Diff(want, cfg.Targets); diff != "" {
+		t.Errorf("targets mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestParseTargetList(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []Target
+		wantErr error
+	}{
+		{
+			name: "valid",
+			list: "DockerImage alpine:latest\n\n# comment\nPath\t./src\n  WebAddress   https://example.com  \n",
+			want: []Target{
+				{Identifier: "alpine:latest", AssetType: types.DockerImage},
+				{Identifier: "./src", AssetType: assettypes.Path},
+				{Identifier: "https://example.com", AssetType: types.WebAddress},
+			},
+		},
+		{
+			name: "empty",
+			list: "",
+			want: nil,
+		},
+		{
+			name:    "missing identifier",
+			list:    "DockerImage\n",
+			wantErr: ErrInvalidTargetSource,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTargetList(strings.NewReader(tt.list))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestParseFileWithTargets(t *testing.T) {
+	extra := []Target{{Identifier: "./src/", AssetType: assettypes.Path}}
+
+	cfg, err := ParseFileWithTargets("testdata/no_targets.yaml", extra)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Target{{Identifier: "src", AssetType: assettypes.Path}}
+	if diff := cmp.Diff(want, cfg.Targets); diff != "" {
+		t.Errorf("targets mismatch (-want +got):\n%v", diff)
+	}
+}
@@ -6,15 +6,19 @@ package containers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime/debug"
+	"strings"
 
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/config"
@@ -32,6 +36,56 @@ import (
 // supported.
 var ErrInvalidRuntime = errors.New("invalid runtime")
 
+// Labels set on the container resources created by Lava. They allow
+// to identify and garbage-collect these resources.
+const (
+	// LabelManaged is set on every resource created by Lava.
+	LabelManaged = "lava.managed"
+
+	// LabelVersion is the version of Lava that created the
+	// resource.
+	LabelVersion = "lava.version"
+
+	// LabelScanID is the ID of the scan that created the
+	// resource.
+	LabelScanID = "lava.scan-id"
+
+	// LabelChecktype is the name of the checktype related to the
+	// resource.
+	LabelChecktype = "lava.checktype"
+
+	// LabelCheckID is the ID of the check related to the
+	// resource.
+	LabelCheckID = "lava.check-id"
+)
+
+// Labels returns the labels that must be set on a container resource
+// created by Lava. The returned labels contain [LabelManaged],
+// [LabelVersion] and the provided extra labels.
+func Labels(extra map[string]string) map[string]string {
+	labels := map[string]string{
+		LabelManaged: "true",
+		LabelVersion: lavaVersion(),
+	}
+	maps.Copy(labels, extra)
+	return labels
+}
+
+// lavaVersion returns the version of the running Lava binary.
+func lavaVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	return bi.Main.Version
+}
+
+// ManagedFilter returns the filters that match the container
+// resources created by Lava.
+func ManagedFilter() filters.Args {
+	return filters.NewArgs(filters.Arg("label", LabelManaged))
+}
+
 // Runtime is the container runtime.
 type Runtime int
 
@@ -75,6 +129,16 @@ func GetenvRuntime() (Runtime, error) {
 	return rt, nil
 }
 
+// String returns the name of the container runtime.
+func (rt Runtime) String() string {
+	for k, v := range runtimeNames {
+		if v == rt {
+			return k
+		}
+	}
+	return ""
+}
+
 // UnmarshalText decodes a runtime name into a [Runtime] value. It
 // returns error if the provided name does not match any known
 // container runtime.
@@ -87,10 +151,53 @@ func (rt *Runtime) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// Mode describes where Lava runs in relation to the container
+// runtime.
+type Mode int
+
+// Execution modes.
+const (
+	ModeHost Mode = iota // Lava runs in the host
+	ModeDooD             // Lava runs in a container and shares the Docker socket of the host
+	ModeDinD             // Lava runs in a container and uses a remote Docker daemon
+)
+
+var modeNames = map[Mode]string{
+	ModeHost: "host",
+	ModeDooD: "docker-out-of-docker",
+	ModeDinD: "docker-in-docker",
+}
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// containerEnvFiles contains the files created by the container
+// runtimes in the root of the containers.
+var containerEnvFiles = []string{
+	"/.dockerenv",        // Docker
+	"/run/.containerenv", // Podman
+}
+
+// InContainer reports whether Lava is running inside a container.
+func InContainer() bool {
+	for _, f := range containerEnvFiles {
+		if _, err := os.Stat(f); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // DockerdClient represents a Docker API client.
 type DockerdClient struct {
 	client.APIClient
-	rt Runtime
+	rt      Runtime
+	network string
 }
 
 // NewDockerdClient returns a new container runtime client compatible
@@ -136,6 +243,22 @@ func NewDockerdClient(rt Runtime) (DockerdClient, error) {
 	return cli, nil
 }
 
+// WithNetwork returns a copy of the client that considers that the
+// containers are attached to the specified Docker network. If name
+// is empty, the default bridge network is used.
+func (cli *DockerdClient) WithNetwork(name string) DockerdClient {
+	c := *cli
+	c.network = name
+	return c
+}
+
+// Network returns the name of the Docker network the containers are
+// attached to. It returns an empty string if the default bridge
+// network is used.
+func (cli *DockerdClient) Network() string {
+	return cli.network
+}
+
 // Close closes the transport used by the client.
 func (cli *DockerdClient) Close() error {
 	return cli.APIClient.Close()
@@ -161,6 +284,22 @@ func (cli *DockerdClient) DaemonHost() string {
 	return daemonHost
 }
 
+// Mode returns the execution mode of Lava. If Lava runs in a
+// container and the Docker daemon is reached through a Unix socket,
+// it is assumed that the socket of the host has been mounted into
+// the container ([ModeDooD]). If Lava runs in a container and the
+// Docker daemon is reached through the network, it is assumed that
+// the daemon runs in another container ([ModeDinD]).
+func (cli *DockerdClient) Mode() Mode {
+	if !InContainer() {
+		return ModeHost
+	}
+	if strings.HasPrefix(cli.DaemonHost(), "unix://") {
+		return ModeDooD
+	}
+	return ModeDinD
+}
+
 // HostGatewayHostname returns a hostname that points to the container
 // engine host and is reachable from the containers.
 func (cli *DockerdClient) HostGatewayHostname() string {
@@ -172,12 +311,23 @@ func (cli *DockerdClient) HostGatewayHostname() string {
 
 // HostGatewayMapping returns the host-to-IP mapping required by the
 // containers to reach the container engine host. It returns an empty
-// string if this mapping is not required.
-func (cli *DockerdClient) HostGatewayMapping() string {
-	if cli.rt == RuntimeDockerd {
-		return cli.HostGatewayHostname() + ":host-gateway"
+// string if this mapping is not required. If the client uses a
+// custom network, the hostname is mapped to the gateway of that
+// network.
+func (cli *DockerdClient) HostGatewayMapping() (string, error) {
+	if cli.rt != RuntimeDockerd {
+		return "", nil
 	}
-	return ""
+
+	if cli.network == "" {
+		return cli.HostGatewayHostname() + ":host-gateway", nil
+	}
+
+	gw, err := cli.bridgeGateway()
+	if err != nil {
+		return "", fmt.Errorf("get bridge gateway: %w", err)
+	}
+	return cli.HostGatewayHostname() + ":" + gw.IP.String(), nil
 }
 
 // HostGatewayInterfaceAddr returns the address of a local interface
@@ -197,17 +347,37 @@ func (cli *DockerdClient) HostGatewayInterfaceAddr() (string, error) {
 // network in Docker.
 const defaultDockerBridgeNetwork = "bridge"
 
-// bridgeGateway returns the gateway of the default Docker bridge
-// network.
+// bridgeGateway returns the gateway of the Docker network used by
+// the client. If no network has been specified, the default Docker
+// bridge network is used. In dual-stack networks, the IPv4 gateway
+// is returned. In IPv6-only networks, the IPv6 gateway is returned.
 func (cli *DockerdClient) bridgeGateway() (*net.IPNet, error) {
-	gws, err := cli.gateways(context.Background(), defaultDockerBridgeNetwork)
+	dockerNetwork := cli.network
+	if dockerNetwork == "" {
+		dockerNetwork = defaultDockerBridgeNetwork
+	}
+
+	gws, err := cli.gateways(context.Background(), dockerNetwork)
 	if err != nil {
 		return nil, fmt.Errorf("could not get Docker network gateway: %w", err)
 	}
-	if len(gws) != 1 {
-		return nil, fmt.Errorf("unexpected number of gateways: %v", len(gws))
+
+	var v4, v6 []*net.IPNet
+	for _, gw := range gws {
+		if gw.IP.To4() != nil {
+			v4 = append(v4, gw)
+		} else {
+			v6 = append(v6, gw)
+		}
+	}
+
+	switch {
+	case len(v4) == 1:
+		return v4[0], nil
+	case len(v4) == 0 && len(v6) == 1:
+		return v6[0], nil
 	}
-	return gws[0], nil
+	return nil, fmt.Errorf("unexpected number of gateways: IPv4: %v, IPv6: %v", len(v4), len(v6))
 }
 
 // gateways returns the gateways of the specified Docker network.
@@ -240,9 +410,10 @@ func (cli *DockerdClient) gateways(ctx context.Context, dockerNetwork string) ([
 }
 
 // ImageBuild builds a Docker image in the context of a path using the
-// provided dockerfile and assigns it the specified reference. It
-// returns the ID of the new image.
-func (cli *DockerdClient) ImageBuild(ctx context.Context, path, dockerfile, ref string) (id string, err error) {
+// provided dockerfile and assigns it the specified reference. The
+// image is labeled with the labels returned by [Labels] for the
+// provided extra labels. It returns the ID of the new image.
+func (cli *DockerdClient) ImageBuild(ctx context.Context, path, dockerfile, ref string, labels map[string]string) (id string, err error) {
 	tar, err := archive.TarWithOptions(path, &archive.TarOptions{})
 	if err != nil {
 		return "", fmt.Errorf("new tar: %w", err)
@@ -252,6 +423,7 @@ func (cli *DockerdClient) ImageBuild(ctx context.Context, path, dockerfile, ref
 		Tags:       []string{ref},
 		Dockerfile: dockerfile,
 		Remove:     true,
+		Labels:     Labels(labels),
 	}
 	resp, err := cli.APIClient.ImageBuild(ctx, tar, opts)
 	if err != nil {
@@ -276,3 +448,80 @@ func (cli *DockerdClient) ImageBuild(ctx context.Context, path, dockerfile, ref
 
 	return summ[0].ID, nil
 }
+
+// ImageLoad loads the image stored in the provided path into the
+// container runtime. The path can be an image tarball, like those
+// generated by "docker save", or a directory with an OCI image
+// layout. It returns the reference of the loaded image or, if it is
+// untagged, its ID. It returns an error if the path contains more
+// than one image.
+func (cli *DockerdClient) ImageLoad(ctx context.Context, path string) (ref string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("stat: %w", err)
+	}
+
+	var r io.ReadCloser
+	if info.IsDir() {
+		r, err = archive.TarWithOptions(path, &archive.TarOptions{})
+		if err != nil {
+			return "", fmt.Errorf("new tar: %w", err)
+		}
+	} else {
+		r, err = os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("open file: %w", err)
+		}
+	}
+	defer r.Close()
+
+	resp, err := cli.APIClient.ImageLoad(ctx, r, true)
+	if err != nil {
+		return "", fmt.Errorf("image load: %w", err)
+	}
+	defer resp.Body.Close()
+
+	refs, err := parseLoadResponse(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("parse response: %w", err)
+	}
+
+	if len(refs) != 1 {
+		return "", fmt.Errorf("unexpected number of loaded images: %v", len(refs))
+	}
+	return refs[0], nil
+}
+
+// loadMessage is a message of the response of an image load
+// request.
+type loadMessage struct {
+	Stream string `json:"stream"`
+	Error  string `json:"error"`
+}
+
+// parseLoadResponse returns the references of the images loaded by an
+// image load request given its response.
+func parseLoadResponse(r io.Reader) ([]string, error) {
+	var refs []string
+	dec := json.NewDecoder(r)
+	for {
+		var msg loadMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decode message: %w", err)
+		}
+		if msg.Error != "" {
+			return nil, errors.New(msg.Error)
+		}
+
+		stream := strings.TrimSpace(msg.Stream)
+		if ref, ok := strings.CutPrefix(stream, "Loaded image: "); ok {
+			refs = append(refs, ref)
+		} else if id, ok := strings.CutPrefix(stream, "Loaded image ID: "); ok {
+			refs = append(refs, id)
+		}
+	}
+	return refs, nil
+}
@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -182,6 +183,14 @@ var (
 					{IP: net.ParseIP("172.19.0.10"), Mask: net.CIDRMask(16, 32)},
 				},
 			},
+			"custom": {
+				cfgs: []mockDockerdIPAMConfig{
+					{Subnet: "172.20.0.0/16", Gateway: "172.20.0.1"},
+				},
+				gateways: []*net.IPNet{
+					{IP: net.ParseIP("172.20.0.1"), Mask: net.CIDRMask(16, 32)},
+				},
+			},
 			"empty": {},
 			"mismatch": {
 				cfgs: []mockDockerdIPAMConfig{
@@ -294,6 +303,61 @@ func TestDockerdClient_DaemonHost(t *testing.T) {
 	}
 }
 
+func TestDockerdClient_Mode(t *testing.T) {
+	tests := []struct {
+		name        string
+		dockerHost  string
+		inContainer bool
+		want        Mode
+	}{
+		{
+			name:        "host",
+			dockerHost:  "unix:///var/run/docker.sock",
+			inContainer: false,
+			want:        ModeHost,
+		},
+		{
+			name:        "docker out of docker",
+			dockerHost:  "unix:///var/run/docker.sock",
+			inContainer: true,
+			want:        ModeDooD,
+		},
+		{
+			name:        "docker in docker",
+			dockerHost:  "tcp://docker:2375",
+			inContainer: true,
+			want:        ModeDinD,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldContainerEnvFiles := containerEnvFiles
+			defer func() { containerEnvFiles = oldContainerEnvFiles }()
+
+			envFile := filepath.Join(t.TempDir(), ".dockerenv")
+			if tt.inContainer {
+				if err := os.WriteFile(envFile, nil, 0600); err != nil {
+					t.Fatalf("unable to create env file: %v", err)
+				}
+			}
+			containerEnvFiles = []string{envFile}
+
+			t.Setenv("DOCKER_HOST", tt.dockerHost)
+
+			cli, err := NewDockerdClient(RuntimeDockerd)
+			if err != nil {
+				t.Fatalf("could not create API client: %v", err)
+			}
+			defer cli.Close()
+
+			if got := cli.Mode(); got != tt.want {
+				t.Errorf("unexpected mode: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestDockerdClient_HostGatewayHostname(t *testing.T) {
 	tests := []struct {
 		name string
@@ -364,7 +428,10 @@ func TestDockerdClient_HostGatewayMapping(t *testing.T) {
 			}
 			defer cli.Close()
 
-			got := cli.HostGatewayMapping()
+			got, err := cli.HostGatewayMapping()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			if got != tt.want {
 				t.Errorf("unexpected hostname: got: %v, want: %v", got, tt.want)
 			}
@@ -372,6 +439,68 @@ func TestDockerdClient_HostGatewayMapping(t *testing.T) {
 	}
 }
 
+func TestDockerdClient_WithNetwork(t *testing.T) {
+	tests := []struct {
+		name        string
+		network     string
+		wantMapping string
+		wantAddr    string
+		wantNilErr  bool
+	}{
+		{
+			name:        "default bridge network",
+			network:     "",
+			wantMapping: "host.docker.internal:host-gateway",
+			wantAddr:    "172.17.0.1",
+			wantNilErr:  true,
+		},
+		{
+			name:        "custom network",
+			network:     "custom",
+			wantMapping: "host.docker.internal:172.20.0.1",
+			wantAddr:    "172.20.0.1",
+			wantNilErr:  true,
+		},
+		{
+			name:       "unknown network",
+			network:    "notfound",
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockcli, err := newMockDockerdClient(t, RuntimeDockerd, defaultAPITestdata)
+			if err != nil {
+				t.Fatalf("could not create test client: %v", err)
+			}
+			defer mockcli.Close()
+
+			cli := mockcli.WithNetwork(tt.network)
+
+			if got := cli.Network(); got != tt.network {
+				t.Errorf("unexpected network: got: %v, want: %v", got, tt.network)
+			}
+
+			gotMapping, err := cli.HostGatewayMapping()
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected mapping error: %v", err)
+			}
+			if gotMapping != tt.wantMapping {
+				t.Errorf("unexpected mapping: got: %v, want: %v", gotMapping, tt.wantMapping)
+			}
+
+			gotAddr, err := cli.HostGatewayInterfaceAddr()
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected address error: %v", err)
+			}
+			if gotAddr != tt.wantAddr {
+				t.Errorf("unexpected address: got: %v, want: %v", gotAddr, tt.wantAddr)
+			}
+		})
+	}
+}
+
 func TestDockerdClient_gateways(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -471,6 +600,49 @@ func TestDockerdClient_bridgeGateway(t *testing.T) {
 			},
 			wantNilErr: false,
 		},
+		{
+			name: "dual-stack",
+			td: mockDockerdTestdata{
+				networks: map[string]mockDockerdNetworkTestdata{
+					defaultDockerBridgeNetwork: {
+						cfgs: []mockDockerdIPAMConfig{
+							{Subnet: "fd00:1::/64", Gateway: "fd00:1::1"},
+							{Subnet: "172.18.0.0/16", Gateway: "172.18.0.1"},
+						},
+						bridgeGateway: &net.IPNet{IP: net.ParseIP("172.18.0.1"), Mask: net.CIDRMask(16, 32)},
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "IPv6-only",
+			td: mockDockerdTestdata{
+				networks: map[string]mockDockerdNetworkTestdata{
+					defaultDockerBridgeNetwork: {
+						cfgs: []mockDockerdIPAMConfig{
+							{Subnet: "fd00:1::/64", Gateway: "fd00:1::1"},
+						},
+						bridgeGateway: &net.IPNet{IP: net.ParseIP("fd00:1::1"), Mask: net.CIDRMask(64, 128)},
+					},
+				},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "multiple IPv6 gateways",
+			td: mockDockerdTestdata{
+				networks: map[string]mockDockerdNetworkTestdata{
+					defaultDockerBridgeNetwork: {
+						cfgs: []mockDockerdIPAMConfig{
+							{Subnet: "fd00:1::/64", Gateway: "fd00:1::1"},
+							{Subnet: "fd00:2::/64", Gateway: "fd00:2::1"},
+						},
+					},
+				},
+			},
+			wantNilErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -542,7 +714,8 @@ func TestDockerdClient_ImageBuild(t *testing.T) {
 
 	const imgRef = "lava-internal-containers-test:go-test"
 
-	imgID, err := cli.ImageBuild(context.Background(), "testdata/image", "Dockerfile", imgRef)
+	labels := map[string]string{LabelChecktype: "lava-internal-containers-test"}
+	imgID, err := cli.ImageBuild(context.Background(), "testdata/image", "Dockerfile", imgRef, labels)
 	if err != nil {
 		t.Fatalf("image build error: %v", err)
 	}
@@ -561,7 +734,11 @@ func TestDockerdClient_ImageBuild(t *testing.T) {
 	}
 
 	if len(summ) != 1 {
-		t.Errorf("unexpected number of images: %v", len(summ))
+		t.Fatalf("unexpected number of images: %v", len(summ))
+	}
+
+	if diff := cmp.Diff(Labels(labels), summ[0].Labels); diff != "" {
+		t.Errorf("labels mismatch (-want +got):\n%v", diff)
 	}
 
 	const want = "image build test"
@@ -727,3 +904,74 @@ func (api mockDockerd) handleInfo(w http.ResponseWriter, _ *http.Request) {
 		http.Error(w, fmt.Sprintf("marshal: %v", err), http.StatusInternalServerError)
 	}
 }
+
+func TestLabels(t *testing.T) {
+	extra := map[string]string{
+		LabelScanID:    "scan-id",
+		LabelChecktype: "vulcan-trivy",
+	}
+
+	got := Labels(extra)
+
+	want := map[string]string{
+		LabelManaged:   "true",
+		LabelVersion:   lavaVersion(),
+		LabelScanID:    "scan-id",
+		LabelChecktype: "vulcan-trivy",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("labels mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestParseLoadResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		resp       string
+		want       []string
+		wantNilErr bool
+	}{
+		{
+			name:       "tagged image",
+			resp:       `{"stream":"Loaded image: example.com/image:tag\n"}`,
+			want:       []string{"example.com/image:tag"},
+			wantNilErr: true,
+		},
+		{
+			name:       "untagged image",
+			resp:       `{"stream":"Loaded image ID: sha256:1234\n"}`,
+			want:       []string{"sha256:1234"},
+			wantNilErr: true,
+		},
+		{
+			name:       "several images",
+			resp:       `{"stream":"Loaded image: image1:tag\n"}` + "\n" + `{"stream":"Loaded image: image2:tag\n"}`,
+			want:       []string{"image1:tag", "image2:tag"},
+			wantNilErr: true,
+		},
+		{
+			name:       "error",
+			resp:       `{"errorDetail":{"message":"invalid archive"},"error":"invalid archive"}`,
+			want:       nil,
+			wantNilErr: false,
+		},
+		{
+			name:       "invalid JSON",
+			resp:       `{`,
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLoadResponse(strings.NewReader(tt.resp))
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("refs mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 Adevinta
+
+// Package discovery discovers the hosts behind DomainName targets
+// using DNS enumeration.
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// maxConcurrentLookups is the maximum number of DNS lookups that run
+// concurrently.
+const maxConcurrentLookups = 16
+
+// lookupTimeout is the maximum time a DNS lookup can take.
+const lookupTimeout = 5 * time.Second
+
+// These variables are used by tests to replace the DNS resolver and
+// the Certificate Transparency logs service.
+var (
+	lookupHost  = net.DefaultResolver.LookupHost
+	lookupCNAME = net.DefaultResolver.LookupCNAME
+	ctLogsURL   = "https://crt.sh/"
+	httpClient  = &http.Client{Timeout: time.Minute}
+)
+
+// Discover looks up the hosts of the DomainName targets and returns
+// the discovered Hostname and, if enabled, WebAddress targets. The
+// candidate hosts are the domain itself, the subdomains built from
+// the configured names and wordlist, the subdomains found in the
+// Certificate Transparency logs and the canonical names of all of
+// them. Only the candidates with A or AAAA records are returned.
+// If scope is not nil, the hosts outside the scope are ignored. The
+// returned list does not contain targets present in targets.
+func Discover(targets []config.Target, cfg config.DiscoveryConfig, scope *config.Scope) ([]config.Target, error) {
+	words := slices.Clone(cfg.Subdomains)
+	if wordlist := config.Get(cfg.Wordlist); wordlist != "" {
+		w, err := readWordlist(wordlist)
+		if err != nil {
+			return nil, fmt.Errorf("read wordlist: %w", err)
+		}
+		words = append(words, w...)
+	}
+
+	var candidates []string
+	for _, t := range targets {
+		if t.AssetType != types.DomainName {
+			continue
+		}
+
+		domain := normalize(t.Identifier)
+		candidates = append(candidates, domain)
+		for _, w := range words {
+			candidates = append(candidates, w+"."+domain)
+		}
+
+		if cfg.CTLogs {
+			names, err := queryCTLogs(domain)
+			if err != nil {
+				return nil, fmt.Errorf("query CT logs: %w", err)
+			}
+			candidates = append(candidates, names...)
+		}
+	}
+
+	var discovered []config.Target
+	for _, host := range resolve(dedup(candidates)) {
+		hostTargets := []config.Target{{Identifier: host, AssetType: types.Hostname}}
+		if cfg.WebAddresses {
+			hostTargets = append(hostTargets, config.Target{Identifier: "https://" + host, AssetType: types.WebAddress})
+		}
+
+		for _, t := range hostTargets {
+			if slices.ContainsFunc(targets, func(e config.Target) bool { return sameTarget(e, t) }) {
+				continue
+			}
+			if scope != nil {
+				if err := scope.Check(t); err != nil {
+					slog.Info("ignoring discovered target", "target", t, "err", err)
+					continue
+				}
+			}
+			slog.Info("discovered target", "target", t)
+			discovered = append(discovered, t)
+		}
+	}
+	return discovered, nil
+}
+
+// resolve returns the hosts with A or AAAA records among the provided
+// candidates and their canonical names. The returned hosts are
+// sorted.
+func resolve(candidates []string) []string {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, maxConcurrentLookups)
+		hosts = make(map[string]bool)
+	)
+
+	for _, candidate := range candidates {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(candidate string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			for _, host := range lookup(candidate) {
+				mu.Lock()
+				hosts[host] = true
+				mu.Unlock()
+			}
+		}(candidate)
+	}
+	wg.Wait()
+
+	var ret []string
+	for host := range hosts {
+		ret = append(ret, host)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// lookup returns the provided host and its canonical name if they
+// have A or AAAA records. Lookup errors are considered as missing
+// records.
+func lookup(host string) []string {
+	names := []string{host}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	if cname, err := lookupCNAME(ctx, host); err == nil {
+		if cname = normalize(cname); cname != "" && cname != host {
+			names = append(names, cname)
+		}
+	}
+
+	var found []string
+	for _, name := range names {
+		if addrs, err := lookupHost(ctx, name); err == nil && len(addrs) > 0 {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+// ctLogsEntry is an entry of the response of the Certificate
+// Transparency logs service.
+type ctLogsEntry struct {
+	NameValue string `json:"name_value"`
+}
+
+// queryCTLogs returns the subdomains of the provided domain found in
+// the Certificate Transparency logs.
+func queryCTLogs(domain string) ([]string, error) {
+	u, err := url.Parse(ctLogsURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("q", "%."+domain)
+	q.Set("output", "json")
+	u.RawQuery = q.Encode()
+
+	resp, err := httpClient.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP status: %v", resp.Status)
+	}
+
+	var entries []ctLogsEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		// An entry can contain several names separated by
+		// new lines.
+		for _, name := range strings.Split(e.NameValue, "\n") {
+			name = normalize(strings.TrimPrefix(strings.TrimSpace(name), "*."))
+			if name == domain || strings.HasSuffix(name, "."+domain) {
+				names = append(names, name)
+			}
+		}
+	}
+	return dedup(names), nil
+}
+
+// readWordlist returns the subdomain names in the provided file. Empty
+// lines and lines starting with "#" are ignored.
+func readWordlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, errors.New("empty wordlist")
+	}
+	return words, nil
+}
+
+// normalize returns the provided host in lowercase and without the
+// trailing dot.
+func normalize(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// sameTarget reports whether the provided targets have the same
+// identifier and asset type.
+func sameTarget(a, b config.Target) bool {
+	return a.Identifier == b.Identifier && a.AssetType == b.AssetType
+}
+
+// dedup returns the provided strings without duplicates, preserving
+// the order.
+func dedup(s []string) []string {
+	seen := make(map[string]bool)
+	var ret []string
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 Adevinta
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// fakeDNS contains the A/AAAA and CNAME records returned by the fake
+// resolver.
+var fakeDNS = struct {
+	hosts  map[string][]string
+	cnames map[string]string
+}{
+	hosts: map[string][]string{
+		"example.com":         {"192.0.2.1"},
+		"www.example.com":     {"192.0.2.2"},
+		"ct.example.com":      {"192.0.2.3"},
+		"cdn.thirdparty.net":  {"198.51.100.1"},
+		"other.example.com":   {"192.0.2.4"},
+		"example.org":         {"192.0.2.5"},
+		"unrelated.localhost": {"127.0.0.1"},
+	},
+	cnames: map[string]string{
+		"www.example.com": "cdn.thirdparty.net.",
+	},
+}
+
+func fakeLookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, ok := fakeDNS.hosts[host]
+	if !ok {
+		return nil, fmt.Errorf("lookup %v: no such host", host)
+	}
+	return addrs, nil
+}
+
+func fakeLookupCNAME(ctx context.Context, host string) (string, error) {
+	cname, ok := fakeDNS.cnames[host]
+	if !ok {
+		return host + ".", nil
+	}
+	return cname, nil
+}
+
+func TestDiscover(t *testing.T) {
+	oldLookupHost, oldLookupCNAME, oldCTLogsURL := lookupHost, lookupCNAME, ctLogsURL
+	defer func() {
+		lookupHost, lookupCNAME, ctLogsURL = oldLookupHost, oldLookupCNAME, oldCTLogsURL
+	}()
+	lookupHost = fakeLookupHost
+	lookupCNAME = fakeLookupCNAME
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "%.example.com" {
+			w.Write([]byte(`[]`)) //nolint:errcheck
+			return
+		}
+		w.Write([]byte(`[{"name_value":"*.example.com\nct.example.com"},{"name_value":"missing.example.com"},{"name_value":"example.net"}]`)) //nolint:errcheck
+	}))
+	defer ts.Close()
+	ctLogsURL = ts.URL
+
+	wordlist := filepath.Join(t.TempDir(), "wordlist.txt")
+	if err := os.WriteFile(wordlist, []byte("# comment\n\nother\nmissing\n"), 0o644); err != nil {
+		t.Fatalf("error writing wordlist: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		targets []config.Target
+		cfg     config.DiscoveryConfig
+		scope   *config.Scope
+		want    []config.Target
+		wantErr bool
+	}{
+		{
+			name: "subdomains",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+			},
+			cfg: config.DiscoveryConfig{
+				Subdomains: []string{"www", "missing"},
+			},
+			want: []config.Target{
+				{Identifier: "cdn.thirdparty.net", AssetType: types.Hostname},
+				{Identifier: "example.com", AssetType: types.Hostname},
+				{Identifier: "www.example.com", AssetType: types.Hostname},
+			},
+		},
+		{
+			name: "scope",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+			},
+			cfg: config.DiscoveryConfig{
+				Subdomains: []string{"www"},
+			},
+			scope: &config.Scope{
+				Domains: []string{"example.com"},
+			},
+			want: []config.Target{
+				{Identifier: "example.com", AssetType: types.Hostname},
+				{Identifier: "www.example.com", AssetType: types.Hostname},
+			},
+		},
+		{
+			name: "wordlist and CT logs",
+			targets: []config.Target{
+				{Identifier: "Example.com.", AssetType: types.DomainName},
+			},
+			cfg: config.DiscoveryConfig{
+				Wordlist: &wordlist,
+				CTLogs:   true,
+			},
+			want: []config.Target{
+				{Identifier: "ct.example.com", AssetType: types.Hostname},
+				{Identifier: "example.com", AssetType: types.Hostname},
+				{Identifier: "other.example.com", AssetType: types.Hostname},
+			},
+		},
+		{
+			name: "web addresses and existing targets",
+			targets: []config.Target{
+				{Identifier: "example.org", AssetType: types.DomainName},
+				{Identifier: "example.org", AssetType: types.Hostname},
+			},
+			cfg: config.DiscoveryConfig{
+				WebAddresses: true,
+			},
+			want: []config.Target{
+				{Identifier: "https://example.org", AssetType: types.WebAddress},
+			},
+		},
+		{
+			name: "non-domain targets",
+			targets: []config.Target{
+				{Identifier: "unrelated.localhost", AssetType: types.Hostname},
+			},
+			cfg: config.DiscoveryConfig{
+				Subdomains: []string{"www"},
+			},
+			want: nil,
+		},
+		{
+			name: "missing wordlist",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+			},
+			cfg: config.DiscoveryConfig{
+				Wordlist: ptr(filepath.Join(t.TempDir(), "notfound")),
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Discover(tt.targets, tt.cfg, tt.scope)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestQueryCTLogs_error(t *testing.T) {
+	oldCTLogsURL := ctLogsURL
+	defer func() { ctLogsURL = oldCTLogsURL }()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	ctLogsURL = ts.URL
+
+	if _, err := queryCTLogs("example.com"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func ptr[V any](v V) *V {
+	return &v
+}
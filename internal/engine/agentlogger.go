@@ -7,18 +7,41 @@ import (
 	"fmt"
 	"log/slog"
 	"runtime"
+	"sync"
 	"time"
 )
 
 // agentLogger wraps [slog] to implement
-// [github.com/adevinta/vulcan-agent/log.Logger].
+// [github.com/adevinta/vulcan-agent/log.Logger]. It keeps the last
+// message logged at [slog.LevelError], so the cause of an agent
+// failure can be reported to the user.
 type agentLogger struct {
-	logger *slog.Logger
+	logger  *slog.Logger
+	lastErr *lastMessage
+}
+
+// lastMessage is a message that can be updated concurrently.
+type lastMessage struct {
+	mu  sync.Mutex
+	msg string
 }
 
 // newAgentLogger creates a new [agentLogger].
 func newAgentLogger(l *slog.Logger) agentLogger {
-	return agentLogger{logger: l}
+	return agentLogger{logger: l, lastErr: &lastMessage{}}
+}
+
+// LastError returns the last message logged at [slog.LevelError]. It
+// returns the empty string if no error has been logged.
+func (l agentLogger) LastError() string {
+	if l.lastErr == nil {
+		return ""
+	}
+
+	l.lastErr.mu.Lock()
+	defer l.lastErr.mu.Unlock()
+
+	return l.lastErr.msg
 }
 
 // Debugf formats according to a format specifier and logs at
@@ -42,11 +65,18 @@ func (l agentLogger) Errorf(format string, args ...any) {
 // log formats according to a format specifier and logs at the
 // specified [slog.Level].
 func (l agentLogger) log(level slog.Level, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if level >= slog.LevelError && l.lastErr != nil {
+		l.lastErr.mu.Lock()
+		l.lastErr.msg = msg
+		l.lastErr.mu.Unlock()
+	}
+
 	if !l.logger.Enabled(context.Background(), level) {
 		return
 	}
 	var pcs [1]uintptr
 	runtime.Callers(3, pcs[:]) // skip [Callers, agentLogger.log, agentLogger.Levelf]
-	r := slog.NewRecord(time.Now(), level, fmt.Sprintf(format, args...), pcs[0])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
 	_ = l.logger.Handler().Handle(context.Background(), r)
 }
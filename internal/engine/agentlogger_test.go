@@ -152,3 +152,23 @@ func TestAgentLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestAgentLogger_LastError(t *testing.T) {
+	var buf bytes.Buffer
+	l := newAgentLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError + 1})))
+
+	if got := l.LastError(); got != "" {
+		t.Errorf("unexpected last error: %q", got)
+	}
+
+	l.Errorf("error %v", 1)
+	l.Infof("info")
+	l.Errorf("error %v", 2)
+
+	if got, want := l.LastError(), "error 2"; got != want {
+		t.Errorf("unexpected last error: want: %q, got: %q", want, got)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
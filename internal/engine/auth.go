@@ -0,0 +1,68 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// defaultAuthHeader is the HTTP header used to send the token when
+// the target authentication does not specify one.
+const defaultAuthHeader = "Authorization"
+
+// authEnv resolves the secrets of the provided target authentication
+// and returns the environment variables that pass it to the checks.
+// Authentication is passed using environment variables instead of
+// check options, so the secrets are not included in the reports.
+func authEnv(auth config.TargetAuth) (map[string]string, error) {
+	env := map[string]string{
+		"LAVA_AUTH_TYPE": string(auth.Type),
+	}
+
+	switch auth.Type {
+	case config.AuthTypeHeader:
+		token, err := readSecret(auth.Token, auth.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("read token: %w", err)
+		}
+		header := auth.Header
+		if header == "" {
+			header = defaultAuthHeader
+		}
+		env["LAVA_AUTH_HEADER"] = header
+		env["LAVA_AUTH_TOKEN"] = token
+	case config.AuthTypeBasic:
+		password, err := readSecret(auth.Password, auth.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("read password: %w", err)
+		}
+		env["LAVA_AUTH_USERNAME"] = auth.Username
+		env["LAVA_AUTH_PASSWORD"] = password
+	case config.AuthTypeScript:
+		script, err := os.ReadFile(auth.Script)
+		if err != nil {
+			return nil, fmt.Errorf("read script: %w", err)
+		}
+		env["LAVA_AUTH_SCRIPT"] = string(script)
+	default:
+		return nil, fmt.Errorf("unknown authentication type: %q", auth.Type)
+	}
+	return env, nil
+}
+
+// readSecret returns value if file is the empty string. Otherwise,
+// it returns the contents of file without the trailing newline.
+func readSecret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
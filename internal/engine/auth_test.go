@@ -0,0 +1,133 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestAuthEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	secretFile := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretFile, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatalf("error writing secret file: %v", err)
+	}
+
+	scriptFile := filepath.Join(dir, "login.js")
+	if err := os.WriteFile(scriptFile, []byte("login();\n"), 0o600); err != nil {
+		t.Fatalf("error writing script file: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		auth       config.TargetAuth
+		want       map[string]string
+		wantNilErr bool
+	}{
+		{
+			name: "header",
+			auth: config.TargetAuth{
+				Type:  config.AuthTypeHeader,
+				Token: "Bearer token",
+			},
+			want: map[string]string{
+				"LAVA_AUTH_TYPE":   "header",
+				"LAVA_AUTH_HEADER": "Authorization",
+				"LAVA_AUTH_TOKEN":  "Bearer token",
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "header with token file",
+			auth: config.TargetAuth{
+				Type:      config.AuthTypeHeader,
+				Header:    "X-API-Key",
+				TokenFile: secretFile,
+			},
+			want: map[string]string{
+				"LAVA_AUTH_TYPE":   "header",
+				"LAVA_AUTH_HEADER": "X-API-Key",
+				"LAVA_AUTH_TOKEN":  "s3cr3t",
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "basic",
+			auth: config.TargetAuth{
+				Type:     config.AuthTypeBasic,
+				Username: "user",
+				Password: "pass",
+			},
+			want: map[string]string{
+				"LAVA_AUTH_TYPE":     "basic",
+				"LAVA_AUTH_USERNAME": "user",
+				"LAVA_AUTH_PASSWORD": "pass",
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "basic with password file",
+			auth: config.TargetAuth{
+				Type:         config.AuthTypeBasic,
+				Username:     "user",
+				PasswordFile: secretFile,
+			},
+			want: map[string]string{
+				"LAVA_AUTH_TYPE":     "basic",
+				"LAVA_AUTH_USERNAME": "user",
+				"LAVA_AUTH_PASSWORD": "s3cr3t",
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "script",
+			auth: config.TargetAuth{
+				Type:   config.AuthTypeScript,
+				Script: scriptFile,
+			},
+			want: map[string]string{
+				"LAVA_AUTH_TYPE":   "script",
+				"LAVA_AUTH_SCRIPT": "login();\n",
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "missing secret file",
+			auth: config.TargetAuth{
+				Type:      config.AuthTypeHeader,
+				TokenFile: filepath.Join(dir, "notfound"),
+			},
+			want:       nil,
+			wantNilErr: false,
+		},
+		{
+			name: "unknown type",
+			auth: config.TargetAuth{
+				Type: "unknown",
+			},
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := authEnv(tt.auth)
+
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("env mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
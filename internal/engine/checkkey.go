@@ -0,0 +1,31 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// CheckKey returns a stable identifier of the check that generated
+// the provided check data. Unlike the check ID, which is random, the
+// key only depends on the checktype image, the target and the
+// options of the check. So, it can be used to correlate the same
+// check across scans.
+func CheckKey(cd report.CheckData) string {
+	return checkKey(cd.ChecktypeName, cd.ChecktypeVersion, cd.Target, cd.Options)
+}
+
+// checkKey returns the key of a check. The checktype image is
+// identified by its name and version, as reported by the Vulcan
+// agent.
+func checkKey(checktypeName, checktypeVersion, target, options string) string {
+	h := sha256.New()
+	for _, s := range []string{checktypeName + ":" + checktypeVersion, target, options} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
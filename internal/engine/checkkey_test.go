@@ -0,0 +1,85 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestCheckKey(t *testing.T) {
+	cd := report.CheckData{
+		CheckID:          "b7fd3d5e-5e4c-4b4b-9f77-5f0e6f7c0a1d",
+		ChecktypeName:    "vulcansec/vulcan-trivy",
+		ChecktypeVersion: "edge",
+		Target:           "alpine:3.18",
+		Options:          `{"depth":1}`,
+	}
+
+	tests := []struct {
+		name string
+		cd   func(cd report.CheckData) report.CheckData
+		same bool
+	}{
+		{
+			name: "different check ID",
+			cd: func(cd report.CheckData) report.CheckData {
+				cd.CheckID = "9b4a8c1e-1d2f-4e3a-8b5c-6d7e8f9a0b1c"
+				return cd
+			},
+			same: true,
+		},
+		{
+			name: "different status",
+			cd: func(cd report.CheckData) report.CheckData {
+				cd.Status = "FAILED"
+				return cd
+			},
+			same: true,
+		},
+		{
+			name: "different checktype version",
+			cd: func(cd report.CheckData) report.CheckData {
+				cd.ChecktypeVersion = "latest"
+				return cd
+			},
+			same: false,
+		},
+		{
+			name: "different target",
+			cd: func(cd report.CheckData) report.CheckData {
+				cd.Target = "alpine:3.19"
+				return cd
+			},
+			same: false,
+		},
+		{
+			name: "different options",
+			cd: func(cd report.CheckData) report.CheckData {
+				cd.Options = `{"depth":2}`
+				return cd
+			},
+			same: false,
+		},
+		{
+			name: "ambiguous concatenation",
+			cd: func(cd report.CheckData) report.CheckData {
+				cd.Target = "alpine:3.18{"
+				cd.Options = `"depth":1}`
+				return cd
+			},
+			same: false,
+		},
+	}
+
+	key := CheckKey(cd)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckKey(tt.cd(cd))
+			if same := got == key; same != tt.same {
+				t.Errorf("unexpected result: key: %v, got: %v, want same: %v", key, got, tt.same)
+			}
+		})
+	}
+}
@@ -5,11 +5,18 @@
 package engine
 
 import (
-	"context"
+	"cmp"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,22 +25,50 @@ import (
 	"github.com/adevinta/vulcan-agent/backend/docker"
 	agentconfig "github.com/adevinta/vulcan-agent/config"
 	"github.com/adevinta/vulcan-agent/jobrunner"
-	"github.com/adevinta/vulcan-agent/queue"
 	"github.com/adevinta/vulcan-agent/queue/chanqueue"
+	"github.com/adevinta/vulcan-agent/stateupdater"
 	report "github.com/adevinta/vulcan-report"
 	types "github.com/adevinta/vulcan-types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/google/uuid"
 
 	"github.com/adevinta/lava/internal/assettypes"
 	"github.com/adevinta/lava/internal/checktypes"
 	"github.com/adevinta/lava/internal/config"
 	"github.com/adevinta/lava/internal/containers"
 	"github.com/adevinta/lava/internal/metrics"
+	"github.com/adevinta/lava/internal/profile"
 )
 
 // Report is a collection of reports returned by Vulcan checks and
 // indexed by check ID.
 type Report map[string]report.Report
 
+// CheckIDs returns the check IDs of the report in a deterministic
+// order. They are sorted by checktype name, target and check options.
+// The check ID is used to break ties.
+func (r Report) CheckIDs() []string {
+	ids := make([]string, 0, len(r))
+	for id := range r {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		ca, cb := r[a].CheckData, r[b].CheckData
+		if c := cmp.Compare(ca.ChecktypeName, cb.ChecktypeName); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(ca.Target, cb.Target); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(ca.Options, cb.Options); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+	return ids
+}
+
 // Engine represents a Lava engine able to run Vulcan checks and
 // retrieve the generated reports.
 type Engine struct {
@@ -41,17 +76,118 @@ type Engine struct {
 	catalog checktypes.Catalog
 	cfg     agentconfig.Config
 	runtime containers.Runtime
+	tmpDir  string
+	scanID  string
+	logger  *slog.Logger
+
+	hangTimeout time.Duration
+
+	// autoParallel specifies whether the number of checks that
+	// can run in parallel is chosen based on the resources of the
+	// host and the checks to run.
+	autoParallel bool
+
+	// platform is the platform of the check images. If empty,
+	// the images are pulled by the agent.
+	platform   string
+	pullPolicy agentconfig.PullPolicy
+
+	// pullRetries is the maximum number of retries of the checks
+	// that fail because their image could not be pulled.
+	// pullRetryInterval is the time before the first retry and
+	// pullMirror is the registry the images are pulled from when
+	// retrying. See [config.PullRetryConfig].
+	pullRetries       int
+	pullRetryInterval time.Duration
+	pullMirror        string
+
+	// mirrors contains the rules used to pull the check images
+	// from registry mirrors.
+	mirrors []config.RegistryMirror
+
+	// watchdog is the configuration of the host resource
+	// watchdog.
+	watchdog config.WatchdogConfig
+
+	// mode is the execution mode of Lava. volumes is used to
+	// translate the paths bind-mounted into the check containers
+	// when Lava runs inside a container.
+	mode    containers.Mode
+	volumes []config.VolumeMapping
+
+	// imageCache stores the results of the checks against Docker
+	// images. If nil, the cache is disabled. If forceScan is
+	// true, the cached results are ignored.
+	imageCache *imageCache
+	forceScan  bool
+
+	// statePath is the path of the state file where the reports
+	// of the finished checks are persisted. If empty, the state
+	// of the scan is not persisted. If resume is true, the
+	// checks that finished in a previous execution of the scan
+	// are not run again.
+	statePath string
+	resume    bool
+
+	// controls contains the control families covered by the
+	// checktypes of the catalog indexed by checktype name.
+	controls checktypes.ChecktypeControls
+
+	// proxyAllow contains the IP ranges of the non-loopback
+	// services that are proxied by the target server.
+	proxyAllow []netip.Prefix
+
+	// srv is the target server shared by the scans run by a
+	// [Pool]. If nil, a new target server is created for every
+	// run of the agent.
+	srv *targetServer
+
+	// logFormat is the format of the logs written to stderr. It
+	// is used to decide how the progress of the scan is
+	// reported.
+	logFormat config.LogFormat
+
+	// state and scanMetrics are the state and the metrics of the
+	// scan being run. They are set when the scan begins and are
+	// shared by all its stages and agent runs. state is nil if
+	// the state of the scan is not persisted.
+	state       *scanState
+	scanMetrics *scanMetrics
 }
 
 // New returns a new [Engine]. It retrieves and merges the checktype
 // catalogs from the provided checktype URLs to generate the catalog
-// that will be used to configure the scans.
-func New(cfg config.AgentConfig, checktypeURLs []string) (eng Engine, err error) {
-	catalog, err := checktypes.NewCatalog(checktypeURLs)
+// that will be used to configure the scans. The catalogs present in
+// integrity are verified before being used. If controls is not
+// empty, only the checktypes labeled with any of the provided control
+// families are run.
+func New(cfg config.AgentConfig, checktypeURLs []string, integrity map[string]config.CatalogIntegrity, controls []string) (eng Engine, err error) {
+	start := time.Now()
+	catalog, ctControls, err := checktypes.NewCatalog(checktypeURLs, catalogIntegrity(integrity), controls)
 	if err != nil {
 		return Engine{}, fmt.Errorf("get checkype catalog: %w", err)
 	}
-	return NewWithCatalog(cfg, catalog)
+	metrics.Collect("catalog_fetch_duration", time.Since(start).Seconds())
+
+	if eng, err = NewWithCatalog(cfg, catalog); err != nil {
+		return Engine{}, err
+	}
+	eng.controls = ctControls
+	return eng, nil
+}
+
+// catalogIntegrity converts the provided integrity configuration into
+// the format expected by [checktypes.NewCatalog].
+func catalogIntegrity(integrity map[string]config.CatalogIntegrity) map[string]checktypes.Integrity {
+	ci := make(map[string]checktypes.Integrity)
+	for url, in := range integrity {
+		ci[url] = checktypes.Integrity{
+			Digest:    config.Get(in.Digest),
+			Signature: config.Get(in.Signature),
+			PublicKey: config.Get(in.PublicKey),
+		}
+	}
+	return ci
 }
 
 // NewWithCatalog returns a new [Engine] from a provided agent
@@ -64,14 +200,42 @@ func NewWithCatalog(cfg config.AgentConfig, catalog checktypes.Catalog) (eng Eng
 		return Engine{}, fmt.Errorf("get env runtime: %w", err)
 	}
 
-	cli, err := containers.NewDockerdClient(rt)
+	dockerdCli, err := containers.NewDockerdClient(rt)
 	if err != nil {
 		return Engine{}, fmt.Errorf("new dockerd client: %w", err)
 	}
+	cli := dockerdCli.WithNetwork(config.Get(cfg.Network))
+
+	mode := cli.Mode()
+	if mode != containers.ModeHost {
+		slog.Info("running inside a container", "mode", mode, "daemonHost", cli.DaemonHost())
+	}
 
 	agentCfg, err := newAgentConfig(cli, cfg)
 	if err != nil {
-		return Engine{}, fmt.Errorf("get agent config: %w", err)
+		return Engine{}, classifyError(fmt.Errorf("get agent config: %w", err))
+	}
+
+	var ic *imageCache
+	if dir := config.Get(cfg.ImageCache.Dir); dir != "" {
+		if ic, err = newImageCache(dir); err != nil {
+			return Engine{}, fmt.Errorf("new image cache: %w", err)
+		}
+	}
+
+	pullRetries := config.DefaultPullRetryMaxRetries
+	if cfg.PullRetry.MaxRetries != nil {
+		pullRetries = *cfg.PullRetry.MaxRetries
+	}
+
+	pullRetryInterval := config.DefaultPullRetryInterval
+	if cfg.PullRetry.Interval != nil {
+		pullRetryInterval = *cfg.PullRetry.Interval
+	}
+
+	proxyAllow, err := config.ParsePrefixes(cfg.ProxyAllow)
+	if err != nil {
+		return Engine{}, fmt.Errorf("parse proxy allow list: %w", err)
 	}
 
 	eng = Engine{
@@ -79,8 +243,39 @@ func NewWithCatalog(cfg config.AgentConfig, catalog checktypes.Catalog) (eng Eng
 		catalog: catalog,
 		cfg:     agentCfg,
 		runtime: rt,
+		tmpDir:  config.TmpDir(cfg),
+
+		hangTimeout:  config.Get(cfg.HangTimeout),
+		autoParallel: config.Get(cfg.Parallel) == config.ParallelAuto,
+		platform:     config.Get(cfg.Platform),
+		pullPolicy:   config.Get(cfg.PullPolicy),
+
+		pullRetries:       pullRetries,
+		pullRetryInterval: pullRetryInterval,
+		pullMirror:        config.Get(cfg.PullRetry.Mirror),
+		mirrors:           cfg.RegistryMirrors,
+		watchdog:          cfg.Watchdog,
+
+		mode:    mode,
+		volumes: cfg.Volumes,
+
+		imageCache: ic,
+		forceScan:  config.Get(cfg.ImageCache.Force),
+
+		statePath: config.Get(cfg.State.File),
+		resume:    config.Get(cfg.State.Resume),
+
+		proxyAllow: proxyAllow,
 	}
-	return eng, nil
+	return eng.withScanID(uuid.New().String()), nil
+}
+
+// withScanID returns a copy of the engine that runs the scan with
+// the specified ID.
+func (eng Engine) withScanID(scanID string) Engine {
+	eng.scanID = scanID
+	eng.logger = slog.With("scanID", scanID)
+	return eng
 }
 
 // newAgentConfig creates a new [agentconfig.Config] based on the
@@ -91,13 +286,41 @@ func newAgentConfig(cli containers.DockerdClient, cfg config.AgentConfig) (agent
 		return agentconfig.Config{}, fmt.Errorf("get gateway interface address: %w", err)
 	}
 
+	// If the parallelism is auto-tuned, it is set when the jobs
+	// to run are known.
 	parallel := config.Get(cfg.Parallel)
-	if parallel == 0 {
-		parallel = 1
+	if parallel <= 0 {
+		parallel = config.DefaultAgentParallel
+	}
+
+	timeout := config.DefaultAgentTimeout
+	if cfg.Timeout != nil {
+		timeout = *cfg.Timeout
+	}
+
+	maxNoMsgsInterval := config.DefaultAgentMaxNoMsgsInterval
+	if cfg.MaxNoMsgsInterval != nil {
+		maxNoMsgsInterval = *cfg.MaxNoMsgsInterval
+	}
+
+	backoffMaxRetries := config.DefaultRegistryBackoffMaxRetries
+	if cfg.RegistryBackoff.MaxRetries != nil {
+		backoffMaxRetries = *cfg.RegistryBackoff.MaxRetries
+	}
+
+	backoffInterval := config.DefaultRegistryBackoffInterval
+	if cfg.RegistryBackoff.Interval != nil {
+		backoffInterval = *cfg.RegistryBackoff.Interval
 	}
 
 	ln, err := net.Listen("tcp", net.JoinHostPort(listenHost, "0"))
 	if err != nil {
+		// The gateway of the Docker network is not a local
+		// address when Lava runs in a container that does
+		// not share the network namespace of the host.
+		if containers.InContainer() {
+			return agentconfig.Config{}, fmt.Errorf("listen: %w (run the Lava container with \"--network host\")", err)
+		}
 		return agentconfig.Config{}, fmt.Errorf("listen: %w", err)
 	}
 
@@ -110,12 +333,20 @@ func newAgentConfig(cli containers.DockerdClient, cfg config.AgentConfig) (agent
 		})
 	}
 
+	// If a platform is configured, Lava pulls the check images
+	// before running the agent, because the agent does not
+	// support selecting the platform of the images.
+	pullPolicy := config.Get(cfg.PullPolicy)
+	if config.Get(cfg.Platform) != "" {
+		pullPolicy = agentconfig.PullPolicyNever
+	}
+
 	acfg := agentconfig.Config{
 		Agent: agentconfig.AgentConfig{
-			ConcurrentJobs:         parallel,
-			MaxNoMsgsInterval:      5,   // Low as all the messages will be in the queue before starting the agent.
-			MaxProcessMessageTimes: 1,   // No retry.
-			Timeout:                180, // Default timeout of 3 minutes.
+			ConcurrentJobs:         int(parallel),
+			MaxNoMsgsInterval:      int(maxNoMsgsInterval.Seconds()),
+			MaxProcessMessageTimes: 1, // No retry.
+			Timeout:                int(timeout.Seconds()),
 		},
 		API: agentconfig.APIConfig{
 			Host:     cli.HostGatewayHostname(),
@@ -127,9 +358,9 @@ func newAgentConfig(cli containers.DockerdClient, cfg config.AgentConfig) (agent
 		Runtime: agentconfig.RuntimeConfig{
 			Docker: agentconfig.DockerConfig{
 				Registry: agentconfig.RegistryConfig{
-					PullPolicy:          config.Get(cfg.PullPolicy),
-					BackoffMaxRetries:   5,
-					BackoffInterval:     5,
+					PullPolicy:          pullPolicy,
+					BackoffMaxRetries:   backoffMaxRetries,
+					BackoffInterval:     int(backoffInterval.Seconds()),
 					BackoffJitterFactor: 0.5,
 					Auths:               auths,
 				},
@@ -139,6 +370,28 @@ func newAgentConfig(cli containers.DockerdClient, cfg config.AgentConfig) (agent
 	return acfg, nil
 }
 
+// ScanID returns the unique ID of the scan run by the engine. It is
+// generated when the engine is created and allows to correlate the
+// artifacts generated by the scan.
+func (eng Engine) ScanID() string {
+	return eng.scanID
+}
+
+// Controls returns the control families covered by the checktypes of
+// the catalog used by the engine indexed by checktype name.
+func (eng Engine) Controls() checktypes.ChecktypeControls {
+	return eng.controls
+}
+
+// WithLogFormat returns a copy of the engine that assumes that the
+// logs written to stderr use the specified format. The progress of
+// the scans is only rendered as a single updating line if the logs
+// use the text format.
+func (eng Engine) WithLogFormat(format config.LogFormat) Engine {
+	eng.logFormat = format
+	return eng
+}
+
 // Close releases the internal resources used by the Lava engine.
 func (eng Engine) Close() error {
 	if err := eng.cli.Close(); err != nil {
@@ -149,46 +402,219 @@ func (eng Engine) Close() error {
 
 // Run runs vulcan checks and returns the generated report. Before
 // running the scan, it checks that all the provided targets are
-// reachable and returns an error if any of them is not. The check
-// list is based on the configured checktype catalogs and the provided
-// targets. These checks are run by a Vulcan agent, which is
-// configured using the specified configuration.
+// reachable and returns an error if any of them is not. It also
+// checks that the variables required by the selected checktypes are
+// configured and returns an error wrapping [ErrMissingVars]
+// otherwise. Similarly, it checks that the check images exist and are
+// accessible and returns an error wrapping [ErrUnavailableImages]
+// otherwise. The check list is based on the configured checktype
+// catalogs and the provided targets. The "env" option of the
+// checktypes and the targets sets environment variables in the
+// containers of the corresponding checks. These checks are run by a
+// Vulcan agent, which is configured using the specified
+// configuration.
 func (eng Engine) Run(targets []config.Target) (Report, error) {
+	eng, err := eng.beginScan()
+	if err != nil {
+		return nil, err
+	}
+
+	rep, err := eng.run(targets)
+	if err != nil {
+		eng.endScan(false)
+		return nil, err
+	}
+	if err := eng.endScan(true); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// beginScan returns a copy of the engine ready to run a scan. It
+// opens the scan state, if it is persisted, and initializes the
+// metrics of the scan. [Engine.endScan] must be called when the scan
+// finishes.
+func (eng Engine) beginScan() (Engine, error) {
+	eng.scanMetrics = newScanMetrics()
+	if eng.statePath != "" {
+		state, err := openScanState(eng.statePath, eng.resume)
+		if err != nil {
+			return Engine{}, fmt.Errorf("scan state: %w", err)
+		}
+		eng.state = state
+	}
+	return eng, nil
+}
+
+// endScan collects the metrics of the scan and closes its state. If
+// completed is true, the state file is removed, so the next scan
+// starts from scratch. Otherwise, it is kept, so the scan can be
+// resumed.
+func (eng Engine) endScan(completed bool) error {
+	eng.scanMetrics.Collect()
+
+	if eng.state == nil {
+		return nil
+	}
+	if !completed {
+		eng.state.Close()
+		return nil
+	}
+	if err := eng.state.Remove(); err != nil {
+		return fmt.Errorf("scan state: %w", err)
+	}
+	return nil
+}
+
+// run runs the checks of the provided targets as part of the scan
+// started by [Engine.beginScan]. See [Engine.Run].
+func (eng Engine) run(targets []config.Target) (Report, error) {
 	for _, t := range targets {
 		err := assettypes.CheckReachable(t.AssetType, t.Identifier)
 		if err != nil && !errors.Is(err, assettypes.ErrUnsupported) {
 			return nil, fmt.Errorf("unreachable target: %v: %w", t, err)
 		}
 	}
+	profile.EndPhase("check targets")
 
-	jobs, err := generateJobs(eng.catalog, targets)
+	checks := generateChecks(eng.catalog, targets)
+
+	// The reports of the finished checks are persisted, so the
+	// scan can be resumed if it is interrupted. When resuming,
+	// the checks that already finished are not run.
+	var resumed Report
+	if eng.state != nil {
+		var err error
+		if resumed, checks, err = eng.state.resumedReports(checks); err != nil {
+			return nil, fmt.Errorf("scan state: %w", err)
+		}
+		eng.scanMetrics.add("resumed_checks", len(resumed))
+	}
+
+	// The checks against Docker images whose results are cached
+	// are not run.
+	var (
+		cached    Report
+		cacheKeys map[string]string
+	)
+	if eng.imageCache != nil {
+		var err error
+		if cached, checks, cacheKeys, err = eng.cachedReports(checks); err != nil {
+			return nil, fmt.Errorf("image cache: %w", err)
+		}
+		eng.scanMetrics.add("cached_checks", len(cached))
+	}
+
+	jobs, err := generateJobs(checks)
 	if err != nil {
 		return nil, fmt.Errorf("generate jobs: %w", err)
 	}
 
+	if jobs, err = mirrorJobs(jobs, eng.mirrors); err != nil {
+		return nil, fmt.Errorf("registry mirrors: %w", err)
+	}
+
 	if len(jobs) == 0 {
-		return nil, nil
+		return mergeReports(cached, resumed), nil
 	}
 
-	return eng.runAgent(jobs)
+	envs, err := generateEnvs(checks)
+	if err != nil {
+		return nil, fmt.Errorf("generate environments: %w", err)
+	}
+	unmapped := unmappedChecks(checks)
+
+	if err := checkRequiredVars(checks, jobs, eng.cfg.Check.Vars, envs); err != nil {
+		return nil, err
+	}
+
+	if err := eng.checkImages(jobs); err != nil {
+		return nil, err
+	}
+
+	if eng.autoParallel {
+		eng.cfg.Agent.ConcurrentJobs = eng.tuneParallel(jobs)
+	}
+	profile.EndPhase("generate jobs")
+
+	rep, err := eng.runAgent(jobs, envs, unmapped)
+	if err != nil {
+		return nil, err
+	}
+	rep = eng.retryPullFailures(rep, jobs, envs, unmapped)
+
+	if eng.imageCache != nil {
+		if err := eng.cacheReports(rep, cacheKeys); err != nil {
+			return nil, fmt.Errorf("image cache: %w", err)
+		}
+	}
+
+	maps.Copy(rep, cached)
+	maps.Copy(rep, resumed)
+	return rep, nil
 }
 
-// summaryInterval is the time between summary logs.
-const summaryInterval = 15 * time.Second
+// mergeReports merges the provided reports. It returns nil if all of
+// them are empty.
+func mergeReports(reps ...Report) Report {
+	var merged Report
+	for _, rep := range reps {
+		if len(rep) == 0 {
+			continue
+		}
+		if merged == nil {
+			merged = make(Report)
+		}
+		maps.Copy(merged, rep)
+	}
+	return merged
+}
 
 // runAgent creates a Vulcan agent using the configured Vulcan agent
-// config and uses it to run the provided jobs.
-func (eng Engine) runAgent(jobs []jobrunner.Job) (Report, error) {
-	srv, err := newTargetServer(eng.runtime)
-	if err != nil {
-		return nil, fmt.Errorf("new target server: %w", err)
+// config and uses it to run the provided jobs. envs contains the
+// environment variables of the checks indexed by check ID. unmapped
+// contains the IDs of the checks whose targets are not handled by
+// the target server. If the state of the scan is persisted, the
+// reports of the finished checks are saved into it as soon as they
+// are received. The metrics of the agent are added to the metrics
+// of the scan.
+func (eng Engine) runAgent(jobs []jobrunner.Job, envs map[string]map[string]string, unmapped map[string]bool) (Report, error) {
+	eng.logger.Info("running scan")
+
+	var err error
+
+	srv := eng.srv
+	if srv == nil {
+		if srv, err = newTargetServer(eng.runtime, eng.cli.Network(), eng.tmpDir, eng.proxyAllow); err != nil {
+			return nil, classifyError(fmt.Errorf("new target server: %w", err))
+		}
+		defer srv.Close()
+	}
+
+	var (
+		images        map[string]string
+		pullDurations map[string]float64
+	)
+	switch {
+	case eng.platform != "":
+		if images, pullDurations, err = eng.pullImages(jobs); err != nil {
+			return nil, classifyError(fmt.Errorf("pull images: %w", err))
+		}
+		profile.EndPhase("pull images")
+	case eng.pullPolicy == agentconfig.PullPolicyIfNotPresent:
+		pullDurations = eng.prePullImages(jobs)
+		profile.EndPhase("pull images")
 	}
-	defer srv.Close()
 
-	alogger := newAgentLogger(slog.Default())
+	alogger := newAgentLogger(eng.logger)
 
 	br := func(params backend.RunParams, rc *docker.RunConfig) error {
-		return eng.beforeRun(params, rc, srv)
+		// Create the check container from the image of the
+		// configured platform.
+		if id, ok := images[rc.ContainerConfig.Image]; ok {
+			rc.ContainerConfig.Image = id
+		}
+		return eng.beforeRun(params, rc, srv, envs[params.CheckID], !unmapped[params.CheckID])
 	}
 
 	backend, err := docker.NewBackend(alogger, eng.cfg, br)
@@ -196,75 +622,200 @@ func (eng Engine) runAgent(jobs []jobrunner.Job) (Report, error) {
 		return nil, fmt.Errorf("new Docker backend: %w", err)
 	}
 
-	// Create a state queue and discard all messages.
-	stateQueue := chanqueue.New(queue.Discard())
-	stateQueue.StartReading(context.Background())
+	// The check monitor receives the state updates of the checks
+	// and kills the checks that hang.
+	cm := newCheckMonitor(eng.logger, backend, eng.hangTimeout)
 
 	jobsQueue := chanqueue.New(nil)
+	jobsQueue.MaxTimeNoRead = time.Duration(eng.cfg.Agent.MaxNoMsgsInterval) * time.Second
 	if err := sendJobs(jobs, jobsQueue); err != nil {
 		return nil, fmt.Errorf("send jobs: %w", err)
 	}
 
-	rs := &reportStore{}
-
-	done := make(chan bool)
-	go func() {
-		for {
-			select {
-			case <-done:
-				return
-			case <-time.After(summaryInterval):
-				sums := rs.Summary()
-				if len(sums) == 0 {
-					slog.Info("waiting for updates")
-					break
-				}
-				for _, s := range sums {
-					slog.Info(s)
-				}
+	rs, err := newReportStore(eng.tmpDir)
+	if err != nil {
+		return nil, fmt.Errorf("new report store: %w", err)
+	}
+	defer rs.Close()
+	rs.logger = eng.logger
+	if eng.state != nil {
+		rs.onReport = func(checkID string, r report.Report) {
+			if err := eng.state.Save(checkID, eng.mapTarget(srv, checkID, r)); err != nil {
+				eng.logger.Warn("could not save scan state", "checkID", checkID, "err", err)
 			}
 		}
-	}()
+	}
 
-	exitCode := agent.RunWithQueues(eng.cfg, rs, backend, stateQueue, jobsQueue, alogger)
+	done := make(chan struct{})
+	pr := newProgressReporter(eng.logger, rs, len(jobs), eng.cfg.Agent.ConcurrentJobs, progressTTY(eng.logFormat))
+	go pr.Run(done)
+	go cm.Monitor(done)
+
+	// The timed backend measures the time the checks wait in the
+	// queue and the time spent pulling their images.
+	tb := newTimedBackend(cm)
+	maps.Copy(tb.pulls, pullDurations)
+
+	// The watchdog delays or stops the launch of new checks when
+	// the resources of the host are exhausted.
+	wd := newWatchdog(eng.logger, tb, eng.watchdog, eng.tmpDir, cm.Skip)
+	go wd.Monitor(done)
+
+	// The resource monitor collects the CPU and memory used by
+	// the check containers.
+	rm := newResourceMonitor(eng.logger, eng.cli, eng.scanID)
+	go rm.Monitor(done)
+
+	exitCode := agent.RunWithQueues(eng.cfg, rs, wd, cm, jobsQueue, alogger)
+	close(done)
+	tb.Collect(eng.scanMetrics)
+	wd.Collect(eng.scanMetrics)
+	rm.Collect(eng.scanMetrics)
 	if exitCode != 0 {
-		return nil, fmt.Errorf("run agent: exit code %v", exitCode)
+		return nil, agentError(exitCode, alogger.LastError(), cm.Failed())
 	}
+	profile.EndPhase("run checks")
 
-	done <- true
-
-	return eng.mkReport(srv, rs), nil
+	rep, err := eng.mkReport(srv, rs, cm)
+	if err != nil {
+		return nil, fmt.Errorf("make report: %w", err)
+	}
+	profile.EndPhase("make report")
+	return rep, nil
 }
 
 // mkReport generates a report from the information stored in the
-// provided [reportStore]. It uses the specified [targetServer] to
-// replace the targets sent to the checks with the original targets.
-func (eng Engine) mkReport(srv *targetServer, rs *reportStore) Report {
+// provided [reportStore]. The reports are read from disk one by one,
+// but all of them are kept in the returned report, so the whole
+// report of the agent run is held in memory. The status of the
+// checks killed by the provided [checkMonitor] is set to [StatusHung]
+// and the check data of the malformed reports is completed with the
+// data known by the monitor. The error of the
+// inconclusive reports is set to the reason why the check was
+// inconclusive, if the check did not provide it. It uses the specified
+// [targetServer] to replace the targets sent to the checks with the
+// original targets.
+func (eng Engine) mkReport(srv *targetServer, rs *reportStore, cm *checkMonitor) (Report, error) {
+	hung := cm.Hung()
+
+	checkIDs := make(map[string]struct{})
+	for checkID := range rs.CheckData() {
+		checkIDs[checkID] = struct{}{}
+	}
+	for checkID := range hung {
+		checkIDs[checkID] = struct{}{}
+	}
+
+	// The checks that could not be run by the backend do not
+	// send any report. They are reported as failed with the
+	// error returned by the backend.
+	failed := make(map[string]report.Report)
+	for checkID, err := range cm.Failed() {
+		if _, ok := checkIDs[checkID]; ok {
+			continue
+		}
+		if params, ok := cm.Params(checkID); ok {
+			failed[checkID] = failedReport(params, classifyError(err))
+			checkIDs[checkID] = struct{}{}
+		}
+	}
+
 	rep := make(Report)
-	for checkID, r := range rs.Reports() {
-		tm, ok := srv.TargetMap(checkID)
+	for checkID := range checkIDs {
+		r, ok := failed[checkID]
 		if !ok {
-			rep[checkID] = r
-			continue
+			var err error
+			if r, err = eng.checkReport(rs, hung, checkID); err != nil {
+				return nil, fmt.Errorf("read report %v: %w", checkID, err)
+			}
+		}
+
+		if params, ok := cm.Params(checkID); ok {
+			switch r.Status {
+			case StatusMalformed:
+				r.ChecktypeName = params.CheckTypeName
+				r.ChecktypeVersion = params.ChecktypeVersion
+				r.Target = params.Target
+				r.Options = params.Options
+			case StatusInconclusive:
+				if r.Error == "" {
+					r.Error = inconclusiveReason(r, params, eng.cfg.Check.Vars)
+				}
+			}
 		}
 
-		tmAddrs := tm.Addrs()
+		rep[checkID] = eng.mapTarget(srv, checkID, r)
+	}
+	return rep, nil
+}
+
+// mapTarget replaces the target sent to the specified check with the
+// original target in the provided report, using the target map of
+// the [targetServer]. The report is returned unmodified if the
+// target of the check was not mapped.
+func (eng Engine) mapTarget(srv *targetServer, checkID string, r report.Report) report.Report {
+	tm, ok := srv.TargetMap(checkID)
+	if !ok {
+		return r
+	}
 
-		slog.Info("applying target map", "check", checkID, "tm", tm, "tmAddr", tmAddrs)
+	tmAddrs := tm.Addrs()
 
-		r.Target = tm.OldIdentifier
+	eng.logger.Info("applying target map", "checkID", checkID, "target", tm.OldIdentifier, "tm", tm, "tmAddr", tmAddrs)
 
-		var vulns []report.Vulnerability
-		for _, vuln := range r.Vulnerabilities {
-			vuln = vulnReplaceAll(vuln, tm.NewIdentifier, tm.OldIdentifier)
-			vuln = vulnReplaceAll(vuln, tmAddrs.NewIdentifier, tmAddrs.OldIdentifier)
-			vulns = append(vulns, vuln)
+	r.Target = tm.OldIdentifier
+
+	var vulns []report.Vulnerability
+	for _, vuln := range r.Vulnerabilities {
+		vuln = vulnReplaceAll(vuln, tm.NewIdentifier, tm.OldIdentifier)
+		vuln = vulnReplaceAll(vuln, tmAddrs.NewIdentifier, tmAddrs.OldIdentifier)
+		vulns = append(vulns, vuln)
+	}
+	r.Vulnerabilities = vulns
+	return r
+}
+
+// checkReport returns the report of the specified check. If the check
+// is hung and did not send any report, the report is generated from
+// the check data in hung.
+func (eng Engine) checkReport(rs *reportStore, hung map[string]report.CheckData, checkID string) (report.Report, error) {
+	cd, isHung := hung[checkID]
+	if isHung {
+		if _, ok := rs.CheckData()[checkID]; !ok {
+			return report.Report{CheckData: cd}, nil
 		}
-		r.Vulnerabilities = vulns
+	}
 
-		rep[checkID] = r
+	r, err := rs.Report(checkID)
+	if err != nil {
+		return report.Report{}, err
+	}
+
+	if isHung {
+		r.Status = StatusHung
+		r.EndTime = cd.EndTime
+	}
+	return r, nil
+}
+
+// failedReport returns a report with status "FAILED" for the check
+// with the provided parameters. The error of the report is set to
+// the provided error.
+func failedReport(params backend.RunParams, err error) report.Report {
+	now := time.Now()
+	return report.Report{
+		CheckData: report.CheckData{
+			CheckID:          params.CheckID,
+			ChecktypeName:    params.CheckTypeName,
+			ChecktypeVersion: params.ChecktypeVersion,
+			Target:           params.Target,
+			Options:          params.Options,
+			Status:           stateupdater.StatusFailed,
+			StartTime:        now,
+			EndTime:          now,
+		},
+		ResultData: report.ResultData{Error: err.Error()},
 	}
-	return rep
 }
 
 // vulnReplaceAll returns a copy of the vulnerability vuln with all
@@ -337,17 +888,57 @@ func rscReplaceAll(rsc report.ResourcesGroup, old, new string) report.ResourcesG
 }
 
 // beforeRun is called by the agent before creating each check
-// container.
-func (eng Engine) beforeRun(params backend.RunParams, rc *docker.RunConfig, srv *targetServer) error {
+// container. env contains the environment variables of the check.
+// mapTarget specifies whether the target of the check is handled by
+// the target server.
+func (eng Engine) beforeRun(params backend.RunParams, rc *docker.RunConfig, srv *targetServer, env map[string]string, mapTarget bool) error {
+	eng.logger.Debug("running check",
+		"checkID", params.CheckID,
+		"checktype", params.CheckTypeName,
+		"target", params.Target,
+		"assetType", params.AssetType,
+	)
+
 	// Register a host pointing to the host gateway.
-	if gwmap := eng.cli.HostGatewayMapping(); gwmap != "" {
+	gwmap, err := eng.cli.HostGatewayMapping()
+	if err != nil {
+		return fmt.Errorf("get host gateway mapping: %w", err)
+	}
+	if gwmap != "" {
 		rc.HostConfig.ExtraHosts = []string{gwmap}
 	}
 
+	// Attach the check container to the configured network.
+	if network := eng.cli.Network(); network != "" {
+		rc.HostConfig.NetworkMode = container.NetworkMode(network)
+	}
+
+	// Label the check container, so it can be identified as a
+	// Lava resource.
+	labels := containers.Labels(map[string]string{
+		containers.LabelScanID:    eng.scanID,
+		containers.LabelChecktype: params.CheckTypeName,
+		containers.LabelCheckID:   params.CheckID,
+	})
+	if rc.ContainerConfig.Labels == nil {
+		rc.ContainerConfig.Labels = make(map[string]string)
+	}
+	maps.Copy(rc.ContainerConfig.Labels, labels)
+
+	// Expose the scan ID to the checks.
+	rc.ContainerConfig.Env = setenv(rc.ContainerConfig.Env, "LAVA_SCAN_ID", eng.scanID)
+
+	// Pass the environment variables of the check, including the
+	// authentication of the target. They take precedence over the
+	// agent variables.
+	for k, v := range env {
+		rc.ContainerConfig.Env = setenv(rc.ContainerConfig.Env, k, v)
+	}
+
 	// Allow all checks to scan local assets.
 	rc.ContainerConfig.Env = setenv(rc.ContainerConfig.Env, "VULCAN_ALLOW_PRIVATE_IPS", "true")
 
-	if params.AssetType == string(types.DockerImage) {
+	if assettypes.ToVulcan(types.AssetType(params.AssetType)) == types.DockerImage {
 		// Due to how reachability is defined by the Vulcan
 		// check SDK, local Docker images would be identified
 		// as unreachable. So, we disable reachability checks
@@ -359,17 +950,42 @@ func (eng Engine) beforeRun(params backend.RunParams, rc *docker.RunConfig, srv
 		// the Docker socket with them.
 		dockerHost := eng.cli.DaemonHost()
 
-		// Remote Docker daemons are not supported.
+		// If Lava runs in a container, the path of the socket
+		// must be translated into the corresponding path in
+		// the host. Remote Docker daemons are shared through
+		// the network.
 		if dockerVol, found := strings.CutPrefix(dockerHost, "unix://"); found {
+			if eng.mode == containers.ModeDooD {
+				dockerVol = hostPath(eng.volumes, dockerVol)
+			}
 			rc.HostConfig.Binds = append(rc.HostConfig.Binds, dockerVol+":/var/run/docker.sock")
+		} else {
+			checkHost, err := checkDockerHost(dockerHost, eng.cli.HostGatewayHostname())
+			if err != nil {
+				return fmt.Errorf("get check Docker host: %w", err)
+			}
+			if err := serveDockerHost(srv, dockerHost); err != nil {
+				return fmt.Errorf("serve Docker daemon: %w", err)
+			}
+			rc.ContainerConfig.Env = setenv(rc.ContainerConfig.Env, "DOCKER_HOST", checkHost)
 		}
 	}
 
+	if !mapTarget {
+		eng.logger.Debug("target mapping disabled", "checkID", params.CheckID, "target", params.Target)
+		return nil
+	}
+
 	// Proxy local targets and serve Git repositories.
 	target := config.Target{
 		Identifier: params.Target,
 		AssetType:  types.AssetType(params.AssetType),
 	}
+	if params.Options != "" {
+		if err := json.Unmarshal([]byte(params.Options), &target.Options); err != nil {
+			return fmt.Errorf("decode check options: %w", err)
+		}
+	}
 	tm, err := srv.Handle(params.CheckID, target)
 	if err != nil {
 		return fmt.Errorf("handle target: %w", err)
@@ -382,6 +998,94 @@ func (eng Engine) beforeRun(params backend.RunParams, rc *docker.RunConfig, srv
 	return nil
 }
 
+// checkDockerHost returns the address the checks must use to reach
+// the remote Docker daemon with the provided host address. The check
+// containers are created by the same daemon. So, if the daemon is
+// reached through a loopback address, the host is replaced with the
+// provided hostname of the host gateway, where the daemon is served
+// by [serveDockerHost]. Only TCP daemons without TLS are supported,
+// because the client certificates cannot be shared with the checks.
+func checkDockerHost(daemonHost, gwHostname string) (string, error) {
+	u, err := url.Parse(daemonHost)
+	if err != nil {
+		return "", fmt.Errorf("parse daemon host: %w", err)
+	}
+	if u.Scheme != "tcp" {
+		return "", fmt.Errorf("unsupported Docker daemon host: %v", daemonHost)
+	}
+	if os.Getenv(client.EnvTLSVerify) != "" {
+		return "", errors.New("remote Docker daemons with TLS are not supported")
+	}
+
+	if isLoopback(u.Hostname()) {
+		host := gwHostname
+		if port := u.Port(); port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		u.Host = host
+	}
+	return u.String(), nil
+}
+
+// serveDockerHost serves the Docker daemon with the provided TCP host
+// address through the specified target server if it is reached
+// through a loopback address, so the checks can reach it through the
+// host gateway. The daemon is served using the same port. Other
+// daemons are not proxied.
+func serveDockerHost(srv *targetServer, daemonHost string) error {
+	u, err := url.Parse(daemonHost)
+	if err != nil {
+		return fmt.Errorf("parse daemon host: %w", err)
+	}
+	if !isLoopback(u.Hostname()) {
+		return nil
+	}
+
+	target := config.Target{
+		Identifier: u.Host,
+		AssetType:  types.Hostname,
+	}
+	if _, err := srv.Handle("docker:"+daemonHost, target); err != nil {
+		return fmt.Errorf("handle daemon host: %w", err)
+	}
+	return nil
+}
+
+// hostPath translates the provided path of the container Lava is
+// running in into the corresponding path in the host of the
+// container runtime using the specified volume mappings. The most
+// specific mapping takes precedence. If no mapping matches, the path
+// is returned unchanged.
+func hostPath(volumes []config.VolumeMapping, p string) string {
+	var (
+		best    config.VolumeMapping
+		matched bool
+	)
+	for _, v := range volumes {
+		if !isSubpath(v.Container, p) {
+			continue
+		}
+		if !matched || len(v.Container) > len(best.Container) {
+			best = v
+			matched = true
+		}
+	}
+	if !matched {
+		return p
+	}
+	rel := strings.TrimPrefix(path.Clean(p), path.Clean(best.Container))
+	return path.Join(best.Host, rel)
+}
+
+// isSubpath reports whether p is dir or is contained in dir.
+func isSubpath(dir, p string) bool {
+	dir, p = path.Clean(dir), path.Clean(p)
+	if dir == "/" {
+		return true
+	}
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
 // setenv sets the value of the variable named by the key in the
 // provided environment. An environment consists on a slice of strings
 // with the format "key=value".
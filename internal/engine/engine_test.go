@@ -19,6 +19,7 @@ import (
 	report "github.com/adevinta/vulcan-report"
 	types "github.com/adevinta/vulcan-types"
 	"github.com/docker/docker/api/types/image"
+	"github.com/google/go-cmp/cmp"
 	"github.com/jroimartin/clilog"
 
 	"github.com/adevinta/lava/internal/assettypes"
@@ -58,7 +59,7 @@ func TestEngine_Run(t *testing.T) {
 
 	const imgRef = "lava-internal-engine-test:go-test"
 
-	if _, err := cli.ImageBuild(context.Background(), "testdata/engine/lava-engine-test", "Dockerfile", imgRef); err != nil {
+	if _, err := cli.ImageBuild(context.Background(), "testdata/engine/lava-engine-test", "Dockerfile", imgRef, nil); err != nil {
 		t.Fatalf("could build Docker image: %v", err)
 	}
 	defer func() {
@@ -90,7 +91,7 @@ func TestEngine_Run(t *testing.T) {
 		}
 	)
 
-	eng, err := New(agentConfig, checktypeURLs)
+	eng, err := New(agentConfig, checktypeURLs, nil, nil)
 	if err != nil {
 		t.Fatalf("engine initialization error: %v", err)
 	}
@@ -147,7 +148,7 @@ func TestEngine_Run_docker_image(t *testing.T) {
 		}
 	)
 
-	eng, err := New(agentConfig, checktypeURLs)
+	eng, err := New(agentConfig, checktypeURLs, nil, nil)
 	if err != nil {
 		t.Fatalf("engine initialization error: %v", err)
 	}
@@ -218,7 +219,7 @@ func TestEngine_Run_path(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			eng, err := New(agentConfig, checktypeURLs)
+			eng, err := New(agentConfig, checktypeURLs, nil, nil)
 			if err != nil {
 				t.Fatalf("engine initialization error: %v", err)
 			}
@@ -267,7 +268,7 @@ func TestEngine_Run_unreachable_target(t *testing.T) {
 		}
 	)
 
-	eng, err := New(agentConfig, checktypeURLs)
+	eng, err := New(agentConfig, checktypeURLs, nil, nil)
 	if err != nil {
 		t.Fatalf("engine initialization error: %v", err)
 	}
@@ -290,7 +291,7 @@ func TestEngine_Run_not_repo(t *testing.T) {
 		}
 	)
 
-	eng, err := New(agentConfig, checktypeURLs)
+	eng, err := New(agentConfig, checktypeURLs, nil, nil)
 	if err != nil {
 		t.Fatalf("engine initialization error: %v", err)
 	}
@@ -321,7 +322,7 @@ func TestEngine_Run_no_jobs(t *testing.T) {
 		}
 	)
 
-	eng, err := New(agentConfig, checktypeURLs)
+	eng, err := New(agentConfig, checktypeURLs, nil, nil)
 	if err != nil {
 		t.Fatalf("engine initialization error: %v", err)
 	}
@@ -354,3 +355,125 @@ func checkReportTarget(t *testing.T, report Report, substr string) {
 func ptr[V any](v V) *V {
 	return &v
 }
+
+func TestCheckDockerHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		daemonHost string
+		tlsVerify  string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "remote",
+			daemonHost: "tcp://docker.example.com:2375",
+			want:       "tcp://docker.example.com:2375",
+		},
+		{
+			name:       "loopback",
+			daemonHost: "tcp://127.0.0.1:2375",
+			want:       "tcp://host.docker.internal:2375",
+		},
+		{
+			name:       "tls",
+			daemonHost: "tcp://docker.example.com:2376",
+			tlsVerify:  "1",
+			wantErr:    true,
+		},
+		{
+			name:       "ssh",
+			daemonHost: "ssh://user@docker.example.com",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_TLS_VERIFY", tt.tlsVerify)
+
+			got, err := checkDockerHost(tt.daemonHost, "host.docker.internal")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected Docker host: got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostPath(t *testing.T) {
+	volumes := []config.VolumeMapping{
+		{Host: "/home/runner/work", Container: "/workspace"},
+		{Host: "/mnt/project", Container: "/workspace/project"},
+		{Host: "/run/user/1000/docker.sock", Container: "/var/run/docker.sock"},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "exact",
+			path: "/var/run/docker.sock",
+			want: "/run/user/1000/docker.sock",
+		},
+		{
+			name: "subpath",
+			path: "/workspace/src/main.go",
+			want: "/home/runner/work/src/main.go",
+		},
+		{
+			name: "most specific",
+			path: "/workspace/project/go.mod",
+			want: "/mnt/project/go.mod",
+		},
+		{
+			name: "common prefix",
+			path: "/workspace2/file",
+			want: "/workspace2/file",
+		},
+		{
+			name: "no match",
+			path: "/tmp/file",
+			want: "/tmp/file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostPath(volumes, tt.path); got != tt.want {
+				t.Errorf("unexpected path: got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport_CheckIDs(t *testing.T) {
+	mkReport := func(checktype, target, options string) report.Report {
+		return report.Report{
+			CheckData: report.CheckData{
+				ChecktypeName: checktype,
+				Target:        target,
+				Options:       options,
+			},
+		}
+	}
+
+	er := Report{
+		"id1": mkReport("lava-semgrep", "b", "{}"),
+		"id2": mkReport("lava-trivy", "a", "{}"),
+		"id3": mkReport("lava-semgrep", "a", "{}"),
+		"id4": mkReport("lava-semgrep", "a", `{"branch":"main"}`),
+		"id5": mkReport("lava-semgrep", "b", "{}"),
+		"id0": mkReport("lava-semgrep", "b", "{}"),
+	}
+
+	want := []string{"id4", "id3", "id0", "id1", "id5", "id2"}
+	for i := 0; i < 10; i++ {
+		if diff := cmp.Diff(want, er.CheckIDs()); diff != "" {
+			t.Fatalf("check IDs mismatch (-want +got):\n%v", diff)
+		}
+	}
+}
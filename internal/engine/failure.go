@@ -0,0 +1,210 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+var (
+	// ErrRuntimeUnreachable means that the container runtime
+	// cannot be reached.
+	ErrRuntimeUnreachable = errors.New("container runtime unreachable")
+
+	// ErrRegistryAuth means that the authentication against a
+	// container registry failed.
+	ErrRegistryAuth = errors.New("registry authentication failed")
+
+	// ErrImagePullDenied means that the registry denied the pull
+	// of a check image.
+	ErrImagePullDenied = errors.New("image pull denied")
+
+	// ErrRegistryRateLimit means that a container registry
+	// rejected the pull of a check image because the pull rate
+	// limit was exceeded.
+	ErrRegistryRateLimit = errors.New("registry rate limit exceeded")
+
+	// ErrImageManifest means that the manifest of a check image
+	// could not be found in the registry. It usually happens when
+	// a tag has been moved and the referenced manifest is stale.
+	ErrImageManifest = errors.New("image manifest not found")
+
+	// ErrOOMKilled means that a check container was killed
+	// because it ran out of memory.
+	ErrOOMKilled = errors.New("check container out of memory")
+)
+
+// failureHints contains an actionable message for every known kind
+// of failure.
+var failureHints = map[error]string{
+	ErrRuntimeUnreachable: "make sure that the container runtime is running and that DOCKER_HOST and LAVA_RUNTIME are set correctly",
+	ErrRegistryAuth:       `review the credentials in "agent.registries" or log in to the registry with "docker login"`,
+	ErrImagePullDenied:    "make sure that the image exists and that the registry credentials grant access to it",
+	ErrRegistryRateLimit:  `wait for the rate limit to be reset, log in to the registry or configure "agent.pullRetry.mirror"`,
+	ErrImageManifest:      "make sure that the image reference in the checktype catalog is up to date",
+	ErrOOMKilled:          `increase the memory available to the container runtime or reduce "agent.parallel"`,
+}
+
+// pullFailures contains the kinds of failure caused by transient
+// errors pulling a check image. The checks failing with them are
+// retried. See [config.PullRetryConfig].
+var pullFailures = []error{
+	ErrRegistryRateLimit,
+	ErrImageManifest,
+}
+
+// failurePatterns contains the messages used to classify the errors
+// that do not wrap a typed Docker error. The Vulcan agent usually
+// reports errors as plain text. The patterns are matched in order
+// against the lower-cased error message.
+var failurePatterns = []struct {
+	kind     error
+	patterns []string
+}{
+	{
+		kind: ErrRuntimeUnreachable,
+		patterns: []string{
+			"cannot connect to the docker daemon",
+			"is the docker daemon running",
+		},
+	},
+	{
+		kind: ErrRegistryRateLimit,
+		patterns: []string{
+			"toomanyrequests",
+			"too many requests",
+			"pull rate limit",
+		},
+	},
+	{
+		kind: ErrRegistryAuth,
+		patterns: []string{
+			"unauthorized",
+			"authentication required",
+			"no basic auth credentials",
+			"wrong credentials",
+		},
+	},
+	{
+		kind: ErrImagePullDenied,
+		patterns: []string{
+			"pull access denied",
+			"requested access to the resource is denied",
+		},
+	},
+	{
+		kind: ErrImageManifest,
+		patterns: []string{
+			"manifest unknown",
+			"no matching manifest",
+			"manifest not found",
+			"not found: manifest",
+		},
+	},
+	{
+		kind: ErrOOMKilled,
+		patterns: []string{
+			"oomkilled",
+			"out of memory",
+		},
+	},
+}
+
+// oomExitCode is the exit code of the containers killed by the
+// kernel OOM killer.
+const oomExitCode = 137
+
+// classifyError wraps the provided error with the kind of failure
+// and an actionable message if its cause is known. Otherwise, it
+// returns the error unchanged.
+func classifyError(err error) error {
+	kind := failureKind(err)
+	if kind == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w (%v)", kind, err, failureHints[kind])
+}
+
+// failureKind returns the kind of failure of the provided error. It
+// returns nil if the error is nil, its cause is unknown or it has
+// already been classified.
+func failureKind(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for kind := range failureHints {
+		if errors.Is(err, kind) {
+			return nil
+		}
+	}
+
+	switch {
+	case client.IsErrConnectionFailed(err):
+		return ErrRuntimeUnreachable
+	case errdefs.IsUnauthorized(err):
+		return ErrRegistryAuth
+	case errdefs.IsForbidden(err):
+		return ErrImagePullDenied
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, fp := range failurePatterns {
+		for _, p := range fp.patterns {
+			if strings.Contains(msg, p) {
+				return fp.kind
+			}
+		}
+	}
+
+	if errors.Is(err, backend.ErrNonZeroExitCode) && strings.HasSuffix(msg, fmt.Sprintf("exit: %v", oomExitCode)) {
+		return ErrOOMKilled
+	}
+	return nil
+}
+
+// isPullFailure reports whether the provided error is caused by a
+// transient error pulling a check image. See [pullFailures].
+func isPullFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, kind := range pullFailures {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return slices.Contains(pullFailures, failureKind(err))
+}
+
+// agentError returns the error reported when the Vulcan agent exits
+// with a non-zero exit code. lastErr is the last error logged by the
+// agent and failed contains the errors returned by the backend when
+// running the checks, indexed by check ID. If the failure of a check
+// has a known cause, it is reported. Otherwise, the last error
+// logged by the agent is used.
+func agentError(exitCode int, lastErr string, failed map[string]error) error {
+	checkIDs := make([]string, 0, len(failed))
+	for checkID := range failed {
+		checkIDs = append(checkIDs, checkID)
+	}
+	slices.Sort(checkIDs)
+
+	for _, checkID := range checkIDs {
+		if err := failed[checkID]; failureKind(err) != nil {
+			return fmt.Errorf("run agent: exit code %v: check %v: %w", exitCode, checkID, classifyError(err))
+		}
+	}
+
+	if lastErr == "" {
+		return fmt.Errorf("run agent: exit code %v", exitCode)
+	}
+	return classifyError(fmt.Errorf("run agent: exit code %v: %v", exitCode, lastErr))
+}
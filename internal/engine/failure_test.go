@@ -0,0 +1,207 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	"github.com/docker/docker/errdefs"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+		wantHint bool
+	}{
+		{
+			name:     "nil",
+			err:      nil,
+			wantKind: nil,
+		},
+		{
+			name:     "unknown",
+			err:      errors.New("run agent: exit code 1"),
+			wantKind: nil,
+		},
+		{
+			name:     "runtime unreachable message",
+			err:      errors.New("network inspect: Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?"),
+			wantKind: ErrRuntimeUnreachable,
+			wantHint: true,
+		},
+		{
+			name:     "unauthorized",
+			err:      fmt.Errorf("image pull: %w", errdefs.Unauthorized(errors.New("access forbidden"))),
+			wantKind: ErrRegistryAuth,
+			wantHint: true,
+		},
+		{
+			name:     "forbidden",
+			err:      fmt.Errorf("image pull: %w", errdefs.Forbidden(errors.New("forbidden"))),
+			wantKind: ErrImagePullDenied,
+			wantHint: true,
+		},
+		{
+			name:     "registry auth message",
+			err:      errors.New("Error response from daemon: Head \"https://registry.example.com/v2/check/manifests/latest\": no basic auth credentials"),
+			wantKind: ErrRegistryAuth,
+			wantHint: true,
+		},
+		{
+			name:     "pull denied message",
+			err:      errors.New("Error response from daemon: pull access denied for check, repository does not exist or may require 'docker login'"),
+			wantKind: ErrImagePullDenied,
+			wantHint: true,
+		},
+		{
+			name:     "rate limit message",
+			err:      errors.New("Error response from daemon: toomanyrequests: You have reached your pull rate limit"),
+			wantKind: ErrRegistryRateLimit,
+			wantHint: true,
+		},
+		{
+			name:     "manifest message",
+			err:      errors.New("Error response from daemon: manifest for vulcansec/vulcan-nmap:edge not found: manifest unknown: manifest unknown"),
+			wantKind: ErrImageManifest,
+			wantHint: true,
+		},
+		{
+			name:     "OOM exit code",
+			err:      fmt.Errorf("%w exit: %d", backend.ErrNonZeroExitCode, 137),
+			wantKind: ErrOOMKilled,
+			wantHint: true,
+		},
+		{
+			name:     "non-zero exit code",
+			err:      fmt.Errorf("%w exit: %d", backend.ErrNonZeroExitCode, 1),
+			wantKind: nil,
+		},
+		{
+			name:     "already classified",
+			err:      fmt.Errorf("%w: cause", ErrOOMKilled),
+			wantKind: ErrOOMKilled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+			if tt.wantKind == nil {
+				if got != tt.err {
+					t.Fatalf("unexpected error: want: %v, got: %v", tt.err, got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantKind) {
+				t.Errorf("unexpected kind: want: %v, got: %v", tt.wantKind, got)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("original error not wrapped: %v", got)
+			}
+			if hasHint := strings.Contains(got.Error(), failureHints[tt.wantKind]); hasHint != tt.wantHint {
+				t.Errorf("unexpected hint: want: %v, got: %v", tt.wantHint, got)
+			}
+		})
+	}
+}
+
+func TestAgentError(t *testing.T) {
+	pullErr := errors.New("pull access denied for check")
+
+	tests := []struct {
+		name     string
+		lastErr  string
+		failed   map[string]error
+		wantKind error
+		wantMsg  string
+	}{
+		{
+			name:    "no information",
+			wantMsg: "run agent: exit code 1",
+		},
+		{
+			name:    "unknown last error",
+			lastErr: "error running agent: stream start",
+			wantMsg: "run agent: exit code 1: error running agent: stream start",
+		},
+		{
+			name:     "known last error",
+			lastErr:  "Cannot connect to the Docker daemon",
+			wantKind: ErrRuntimeUnreachable,
+		},
+		{
+			name:    "failed check takes precedence",
+			lastErr: "Cannot connect to the Docker daemon",
+			failed: map[string]error{
+				"check1": errors.New("unknown"),
+				"check2": pullErr,
+			},
+			wantKind: ErrImagePullDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := agentError(1, tt.lastErr, tt.failed)
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("unexpected kind: want: %v, got: %v", tt.wantKind, err)
+			}
+			if tt.wantMsg != "" && err.Error() != tt.wantMsg {
+				t.Errorf("unexpected message: want: %q, got: %q", tt.wantMsg, err)
+			}
+		})
+	}
+}
+
+func TestIsPullFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "rate limit",
+			err:  errors.New("toomanyrequests: You have reached your pull rate limit"),
+			want: true,
+		},
+		{
+			name: "classified manifest error",
+			err:  classifyError(errors.New("manifest unknown")),
+			want: true,
+		},
+		{
+			name: "classified message",
+			err:  errors.New(classifyError(errors.New("manifest unknown")).Error()),
+			want: true,
+		},
+		{
+			name: "pull denied",
+			err:  errors.New("pull access denied for check"),
+			want: false,
+		},
+		{
+			name: "unknown",
+			err:  errors.New("unknown"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPullFailure(tt.err); got != tt.want {
+				t.Errorf("unexpected result: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// hostUsage contains the usage of the resources of the host in
+// percentage.
+type hostUsage struct {
+	CPU    float64
+	Memory float64
+	Disk   float64
+}
+
+// hostSampler samples the usage of the resources of the host. The
+// CPU and memory usage are read from the proc file system, so they
+// are only available on Linux. The disk usage is the usage of the
+// file system of the configured path.
+type hostSampler struct {
+	procDir  string
+	diskPath string
+
+	// prevIdle and prevTotal are the CPU times of the previous
+	// sample. They are used to compute the CPU usage between
+	// samples.
+	prevIdle  uint64
+	prevTotal uint64
+}
+
+// newHostSampler returns a [hostSampler] that reports the disk usage
+// of the file system of the provided path.
+func newHostSampler(diskPath string) *hostSampler {
+	if diskPath == "" {
+		diskPath = os.TempDir()
+	}
+	return &hostSampler{procDir: "/proc", diskPath: diskPath}
+}
+
+// Sample returns the current usage of the resources of the host. The
+// CPU usage is computed since the previous sample, so it is zero in
+// the first one. The usage of the resources that cannot be sampled
+// is zero and the corresponding errors are returned.
+func (s *hostSampler) Sample() (hostUsage, error) {
+	var (
+		usage hostUsage
+		errs  []error
+	)
+
+	if cpu, err := s.cpu(); err != nil {
+		errs = append(errs, fmt.Errorf("cpu: %w", err))
+	} else {
+		usage.CPU = cpu
+	}
+
+	if mem, err := s.memory(); err != nil {
+		errs = append(errs, fmt.Errorf("memory: %w", err))
+	} else {
+		usage.Memory = mem
+	}
+
+	if disk, err := s.disk(); err != nil {
+		errs = append(errs, fmt.Errorf("disk: %w", err))
+	} else {
+		usage.Disk = disk
+	}
+
+	return usage, errors.Join(errs...)
+}
+
+// cpu returns the CPU usage since the previous call.
+func (s *hostSampler) cpu() (float64, error) {
+	f, err := os.Open(s.procDir + "/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	idle, total, err := parseProcStat(f)
+	if err != nil {
+		return 0, fmt.Errorf("parse stat: %w", err)
+	}
+
+	prevIdle, prevTotal := s.prevIdle, s.prevTotal
+	s.prevIdle, s.prevTotal = idle, total
+	if prevTotal == 0 || total <= prevTotal {
+		return 0, nil
+	}
+	return 100 * (1 - float64(idle-prevIdle)/float64(total-prevTotal)), nil
+}
+
+// memory returns the memory usage.
+func (s *hostSampler) memory() (float64, error) {
+	f, err := os.Open(s.procDir + "/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	total, avail, err := parseMeminfo(f)
+	if err != nil {
+		return 0, fmt.Errorf("parse meminfo: %w", err)
+	}
+	return 100 * (1 - float64(avail)/float64(total)), nil
+}
+
+// disk returns the usage of the file system of the disk path.
+func (s *hostSampler) disk() (float64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(s.diskPath, &st); err != nil {
+		return 0, err
+	}
+	if st.Blocks == 0 {
+		return 0, errors.New("empty file system")
+	}
+	return 100 * (1 - float64(st.Bavail)/float64(st.Blocks)), nil
+}
+
+// parseProcStat returns the idle and total CPU times from the
+// contents of /proc/stat. The idle time includes the time waiting
+// for I/O.
+func parseProcStat(r io.Reader) (idle, total uint64, err error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		for i, f := range fields[1:] {
+			// Guest times are already included in user
+			// times.
+			if i >= 8 {
+				break
+			}
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("invalid CPU time: %w", err)
+			}
+			total += v
+			// The fourth and fifth values are idle and
+			// iowait.
+			if i == 3 || i == 4 {
+				idle += v
+			}
+		}
+		return idle, total, nil
+	}
+	if err := sc.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, errors.New("missing cpu line")
+}
+
+// parseMeminfo returns the total and available memory in kB from the
+// contents of /proc/meminfo.
+func parseMeminfo(r io.Reader) (total, avail uint64, err error) {
+	var foundTotal, foundAvail bool
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		var dst *uint64
+		switch fields[0] {
+		case "MemTotal:":
+			dst, foundTotal = &total, true
+		case "MemAvailable:":
+			dst, foundAvail = &avail, true
+		default:
+			continue
+		}
+		if *dst, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid %v: %w", fields[0], err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return 0, 0, err
+	}
+	if !foundTotal || !foundAvail || total == 0 {
+		return 0, 0, errors.New("missing MemTotal or MemAvailable")
+	}
+	return total, avail, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProcStat(t *testing.T) {
+	tests := []struct {
+		name       string
+		stat       string
+		wantIdle   uint64
+		wantTotal  uint64
+		wantNilErr bool
+	}{
+		{
+			name: "valid",
+			stat: "cpu  100 10 50 800 40 0 5 0 20 0\n" +
+				"cpu0 50 5 25 400 20 0 2 0 10 0\n" +
+				"intr 12345\n",
+			wantIdle:   840,
+			wantTotal:  1005,
+			wantNilErr: true,
+		},
+		{
+			name:       "missing cpu line",
+			stat:       "cpu0 50 5 25 400 20 0 2 0 10 0\n",
+			wantNilErr: false,
+		},
+		{
+			name:       "invalid value",
+			stat:       "cpu  100 10 50 abc 40 0 5 0 20 0\n",
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idle, total, err := parseProcStat(strings.NewReader(tt.stat))
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if idle != tt.wantIdle || total != tt.wantTotal {
+				t.Errorf("unexpected CPU times: want: %v/%v, got: %v/%v", tt.wantIdle, tt.wantTotal, idle, total)
+			}
+		})
+	}
+}
+
+func TestParseMeminfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		meminfo    string
+		wantTotal  uint64
+		wantAvail  uint64
+		wantNilErr bool
+	}{
+		{
+			name: "valid",
+			meminfo: "MemTotal:       16000000 kB\n" +
+				"MemFree:         1000000 kB\n" +
+				"MemAvailable:    4000000 kB\n",
+			wantTotal:  16000000,
+			wantAvail:  4000000,
+			wantNilErr: true,
+		},
+		{
+			name:       "missing available",
+			meminfo:    "MemTotal:       16000000 kB\n",
+			wantNilErr: false,
+		},
+		{
+			name:       "invalid value",
+			meminfo:    "MemTotal:       abc kB\nMemAvailable:    4000000 kB\n",
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, avail, err := parseMeminfo(strings.NewReader(tt.meminfo))
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if total != tt.wantTotal || avail != tt.wantAvail {
+				t.Errorf("unexpected memory: want: %v/%v, got: %v/%v", tt.wantTotal, tt.wantAvail, total, avail)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+	"github.com/adevinta/vulcan-agent/stateupdater"
+	report "github.com/adevinta/vulcan-report"
+	types "github.com/adevinta/vulcan-types"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/errdefs"
+
+	"github.com/adevinta/lava/internal/assettypes"
+)
+
+// imageCache stores the reports of the checks run against Docker
+// images. The reports are indexed by a key derived from the digest
+// of the scanned image, the digest of the checktype image and the
+// options of the check. So, the results can be reused as long as
+// neither the image nor the checktype change, even if the checktype
+// image is referenced by a mutable tag.
+type imageCache struct {
+	dir string
+}
+
+// newImageCache returns a new [imageCache] that stores the reports in
+// the specified directory. The directory is created if it does not
+// exist.
+func newImageCache(dir string) (*imageCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("make cache dir: %w", err)
+	}
+	return &imageCache{dir: dir}, nil
+}
+
+// Get returns the report stored with the provided key. The returned
+// bool is false if the key is not in the cache.
+func (c *imageCache) Get(key string) (report.Report, bool, error) {
+	content, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return report.Report{}, false, nil
+		}
+		return report.Report{}, false, fmt.Errorf("read report: %w", err)
+	}
+
+	var r report.Report
+	if err := r.UnmarshalJSONTimeAsString(content); err != nil {
+		return report.Report{}, false, fmt.Errorf("decode report: %w", err)
+	}
+	return r, true, nil
+}
+
+// Put stores the provided report with the specified key.
+func (c *imageCache) Put(key string, r report.Report) error {
+	content, err := r.MarshalJSONTimeAsString()
+	if err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), content, 0o644); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+// path returns the path of the file that contains the report with
+// the specified key.
+func (c *imageCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// imageCacheKey returns the cache key of a check with the provided
+// checktype image digest and options run against the image with the
+// specified digest.
+func imageCacheKey(checktypeDigest, imageDigest, options string) string {
+	h := sha256.New()
+	for _, s := range []string{checktypeDigest, imageDigest, options} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedReports looks up the reports of the provided checks in the
+// image cache. It returns the cached reports indexed by check ID, the
+// checks that must be run and the cache keys of the checks that must
+// be run and whose results can be cached, indexed by check ID. Only
+// checks against local Docker images are cached. The checks whose
+// checktype image digest cannot be resolved are not cached. If the
+// engine is forced to scan, the cache is not read.
+func (eng Engine) cachedReports(checks []check) (cached Report, pending []check, keys map[string]string, err error) {
+	cached = make(Report)
+	keys = make(map[string]string)
+	digests := make(map[string]string)
+	ctDigests := make(map[string]string)
+	for _, check := range checks {
+		if assettypes.ToVulcan(check.target.AssetType) != types.DockerImage {
+			pending = append(pending, check)
+			continue
+		}
+
+		digest, ok := digests[check.target.Identifier]
+		if !ok {
+			digest, err = eng.imageDigest(check.target.Identifier)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("get image digest: %w", err)
+			}
+			digests[check.target.Identifier] = digest
+		}
+
+		// The image is not available locally.
+		if digest == "" {
+			pending = append(pending, check)
+			continue
+		}
+
+		ctDigest, ok := ctDigests[check.checktype.Image]
+		if !ok {
+			ctDigest = eng.checktypeDigest(check.checktype.Image)
+			ctDigests[check.checktype.Image] = ctDigest
+		}
+		if ctDigest == "" {
+			pending = append(pending, check)
+			continue
+		}
+
+		opts, err := json.Marshal(check.options)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("encode check options: %w", err)
+		}
+		key := imageCacheKey(ctDigest, digest, string(opts))
+
+		if !eng.forceScan {
+			r, ok, err := eng.imageCache.Get(key)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("get cached report: %w", err)
+			}
+			if ok {
+				eng.logger.Info("using cached results",
+					"checktype", check.checktype.Name,
+					"target", check.target.Identifier,
+					"digest", digest,
+				)
+				r.CheckID = check.id
+				r.Target = check.target.Identifier
+				cached[check.id] = r
+				continue
+			}
+		}
+
+		keys[check.id] = key
+		pending = append(pending, check)
+	}
+	return cached, pending, keys, nil
+}
+
+// imageDigest returns the digest of the specified local Docker
+// image. It returns an empty string if the image is not present in
+// the container runtime.
+func (eng Engine) imageDigest(ref string) (string, error) {
+	inspect, _, err := eng.cli.ImageInspectWithRaw(context.Background(), ref)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("image inspect: %w", err)
+	}
+	return inspect.ID, nil
+}
+
+// checktypeDigest returns the digest of the specified checktype
+// image. If the reference contains a digest, it is returned. If the
+// image is present in the container runtime and the pull policy is
+// not Always, the digest it was pulled with is returned, or its ID if
+// it was not pulled from a registry. Otherwise, the digest is
+// requested to the registry. It returns an empty string if the
+// digest cannot be resolved.
+func (eng Engine) checktypeDigest(ref string) string {
+	if digest := refDigest(ref); digest != "" {
+		return digest
+	}
+
+	ctx := context.Background()
+
+	if eng.pullPolicy != agentconfig.PullPolicyAlways {
+		inspect, _, err := eng.cli.ImageInspectWithRaw(ctx, ref)
+		if err == nil {
+			for _, rd := range inspect.RepoDigests {
+				if digest := refDigest(rd); digest != "" {
+					return digest
+				}
+			}
+			return inspect.ID
+		}
+		if !errdefs.IsNotFound(err) {
+			eng.logger.Warn("could not resolve checktype image digest", "image", ref, "err", err)
+			return ""
+		}
+	}
+
+	var encoded string
+	if auth, ok := eng.registryAuth(ref); ok {
+		var err error
+		if encoded, err = registry.EncodeAuthConfig(auth); err != nil {
+			eng.logger.Warn("could not resolve checktype image digest", "image", ref, "err", err)
+			return ""
+		}
+	}
+	inspect, err := eng.cli.DistributionInspect(ctx, ref, encoded)
+	if err != nil {
+		eng.logger.Warn("could not resolve checktype image digest", "image", ref, "err", err)
+		return ""
+	}
+	return inspect.Descriptor.Digest.String()
+}
+
+// refDigest returns the digest of the provided image reference. It
+// returns an empty string if the reference does not contain a
+// digest.
+func refDigest(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		return ""
+	}
+	return canonical.Digest().String()
+}
+
+// cacheReports stores the finished reports of rep whose check ID is
+// in keys into the image cache.
+func (eng Engine) cacheReports(rep Report, keys map[string]string) error {
+	for checkID, key := range keys {
+		r, ok := rep[checkID]
+		if !ok || r.Status != stateupdater.StatusFinished {
+			continue
+		}
+		if err := eng.imageCache.Put(key, r); err != nil {
+			return fmt.Errorf("cache report %v: %w", checkID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImageCache(t *testing.T) {
+	ic, err := newImageCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("error creating image cache: %v", err)
+	}
+
+	key := imageCacheKey("vulcansec/vulcan-trivy:1", "sha256:0123", `{"depth":1}`)
+
+	if _, ok, err := ic.Get(key); err != nil || ok {
+		t.Fatalf("unexpected result for missing key: ok: %v, err: %v", ok, err)
+	}
+
+	want := report.Report{
+		CheckData: report.CheckData{
+			CheckID:       "check1",
+			ChecktypeName: "vulcan-trivy",
+			Target:        "example.com/image:latest",
+			Status:        "FINISHED",
+			StartTime:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:       time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+		},
+		ResultData: report.ResultData{
+			Vulnerabilities: []report.Vulnerability{
+				{Summary: "Vulnerability", Score: 6.7},
+			},
+		},
+	}
+
+	if err := ic.Put(key, want); err != nil {
+		t.Fatalf("put error: %v", err)
+	}
+
+	got, ok, err := ic.Get(key)
+	if err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("key not found")
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestImageCacheKey(t *testing.T) {
+	key := imageCacheKey("sha256:89ab", "sha256:0123", "{}")
+
+	others := []string{
+		imageCacheKey("sha256:cdef", "sha256:0123", "{}"),
+		imageCacheKey("sha256:89ab", "sha256:4567", "{}"),
+		imageCacheKey("sha256:89ab", "sha256:0123", `{"depth":1}`),
+	}
+	for _, other := range others {
+		if other == key {
+			t.Errorf("unexpected key collision: %v", key)
+		}
+	}
+
+	if got := imageCacheKey("sha256:89ab", "sha256:0123", "{}"); got != key {
+		t.Errorf("unstable key: got: %v, want: %v", got, key)
+	}
+}
+
+func TestRefDigest(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{
+			name: "digest",
+			ref:  "example.com/checktype@" + digest,
+			want: digest,
+		},
+		{
+			name: "tag and digest",
+			ref:  "example.com/checktype:1@" + digest,
+			want: digest,
+		},
+		{
+			name: "tag",
+			ref:  "example.com/checktype:1",
+			want: "",
+		},
+		{
+			name: "invalid",
+			ref:  "Example/Checktype",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := refDigest(tt.ref); got != tt.want {
+				t.Errorf("unexpected digest: got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngine_cacheReports(t *testing.T) {
+	ic, err := newImageCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("error creating image cache: %v", err)
+	}
+	eng := Engine{imageCache: ic}
+
+	rep := Report{
+		"finished": report.Report{CheckData: report.CheckData{CheckID: "finished", Status: "FINISHED"}},
+		"failed":   report.Report{CheckData: report.CheckData{CheckID: "failed", Status: "FAILED"}},
+		"uncached": report.Report{CheckData: report.CheckData{CheckID: "uncached", Status: "FINISHED"}},
+	}
+	keys := map[string]string{
+		"finished": "key1",
+		"failed":   "key2",
+	}
+
+	if err := eng.cacheReports(rep, keys); err != nil {
+		t.Fatalf("cache reports error: %v", err)
+	}
+
+	if _, ok, _ := ic.Get("key1"); !ok {
+		t.Errorf("finished report was not cached")
+	}
+	if _, ok, _ := ic.Get("key2"); ok {
+		t.Errorf("failed report was cached")
+	}
+}
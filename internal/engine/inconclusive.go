@@ -0,0 +1,33 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	report "github.com/adevinta/vulcan-report"
+)
+
+// StatusInconclusive is the status of the checks that could not
+// determine whether the target is affected. For instance, because
+// the target is unreachable.
+const StatusInconclusive = "INCONCLUSIVE"
+
+// inconclusiveReason returns the reason why the check that generated
+// the provided report was inconclusive. The reason is inferred from
+// the report, the parameters of the check and the variables passed to
+// the checks. The Vulcan check SDK only reports a check as
+// inconclusive if the target is unreachable or not public, so this is
+// the default reason.
+func inconclusiveReason(r report.Report, params backend.RunParams, vars map[string]string) string {
+	if missing := missingVars(params.RequiredVars, vars); len(missing) > 0 {
+		return fmt.Sprintf("missing required vars: %v", strings.Join(missing, ", "))
+	}
+
+	if r.NotApplicable {
+		return "unsupported target"
+	}
+	return "unreachable or non-public target"
+}
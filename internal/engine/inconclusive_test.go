@@ -0,0 +1,46 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestInconclusiveReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		report report.Report
+		params backend.RunParams
+		vars   map[string]string
+		want   string
+	}{
+		{
+			name:   "missing required vars",
+			params: backend.RunParams{RequiredVars: []string{"VAR_B", "VAR_A", "VAR_C"}},
+			vars:   map[string]string{"VAR_C": "value"},
+			want:   "missing required vars: VAR_A, VAR_B",
+		},
+		{
+			name:   "not applicable",
+			report: report.Report{ResultData: report.ResultData{NotApplicable: true}},
+			params: backend.RunParams{RequiredVars: []string{"VAR_A"}},
+			vars:   map[string]string{"VAR_A": "value"},
+			want:   "unsupported target",
+		},
+		{
+			name: "default",
+			want: "unreachable or non-public target",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inconclusiveReason(tt.report, tt.params, tt.vars); got != tt.want {
+				t.Errorf("unexpected reason: got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@
 package engine
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"maps"
@@ -20,10 +21,11 @@ import (
 	"github.com/adevinta/lava/internal/config"
 )
 
-// generateJobs generates the jobs to be sent to the agent.
-func generateJobs(catalog checktypes.Catalog, targets []config.Target) ([]jobrunner.Job, error) {
+// generateJobs generates the jobs to be sent to the agent from the
+// provided checks.
+func generateJobs(checks []check) ([]jobrunner.Job, error) {
 	var jobs []jobrunner.Job
-	for _, check := range generateChecks(catalog, targets) {
+	for _, check := range checks {
 		// Convert the options to a marshalled json string.
 		jsonOpts, err := json.Marshal(check.options)
 		if err != nil {
@@ -68,13 +70,22 @@ type check struct {
 	checktype checkcatalog.Checktype
 	target    config.Target
 	options   map[string]interface{}
+	envOpts   []any // values of the envOption option of the checktype and the target
 }
 
 // generateChecks generates a list of checks combining a map of
-// checktypes and a list of targets.
+// checktypes and a list of targets. The identifiers of the targets
+// are normalized before removing duplicates, so equivalent targets
+// are only scanned once. The returned checks are sorted by
+// checktype name, target identifier and target asset type.
 func generateChecks(catalog checktypes.Catalog, targets []config.Target) []check {
+	normalized := make([]config.Target, len(targets))
+	for i, t := range targets {
+		normalized[i] = t.Normalize()
+	}
+
 	var checks []check
-	for _, t := range dedup(targets) {
+	for _, t := range dedup(normalized) {
 		for _, ct := range catalog {
 			at := assettypes.ToVulcan(t.AssetType)
 			if !checktypes.Accepts(ct, at) {
@@ -87,17 +98,70 @@ func generateChecks(catalog checktypes.Catalog, targets []config.Target) []check
 			opts := make(map[string]interface{})
 			maps.Copy(opts, ct.Options)
 			maps.Copy(opts, t.Options)
+
+			// The environment variables are not passed to
+			// the check as options, so they are not
+			// included in the reports.
+			var envOpts []any
+			for _, o := range []map[string]any{ct.Options, t.Options} {
+				if v, ok := o[envOption]; ok {
+					envOpts = append(envOpts, v)
+				}
+			}
+			delete(opts, envOption)
+
+			if t.RateLimit != nil {
+				opts[rateLimitOption] = rateLimitOptionValue(*t.RateLimit)
+			}
+			if t.Terraform != nil {
+				opts[terraformOption] = terraformOptionValue(*t.Terraform)
+			}
+			if len(t.UDPPorts) > 0 {
+				opts[udpPortsOption] = t.UDPPorts
+			}
 			checks = append(checks, check{
 				id:        uuid.New().String(),
 				checktype: ct,
 				target:    t,
 				options:   opts,
+				envOpts:   envOpts,
 			})
 		}
 	}
+	slices.SortStableFunc(checks, compareChecks)
 	return checks
 }
 
+// compareChecks compares two checks by checktype name, target
+// identifier and target asset type.
+func compareChecks(a, b check) int {
+	if c := cmp.Compare(a.checktype.Name, b.checktype.Name); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.target.Identifier, b.target.Identifier); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.target.AssetType, b.target.AssetType)
+}
+
+// envOption is the name of the catalog and target option used to
+// set environment variables in the check containers.
+const envOption = "env"
+
+// terraformOption is the name of the check option used to pass the
+// Terraform configuration of the target to the checks.
+const terraformOption = "terraform"
+
+// terraformOptionValue returns the value of the [terraformOption]
+// check option corresponding to the provided Terraform
+// configuration.
+func terraformOptionValue(tc config.TerraformConfig) map[string]any {
+	return map[string]any{
+		"var_files":  tc.VarFiles,
+		"workspaces": tc.Workspaces,
+	}
+}
+
 // dedup returns a deduplicated slice.
 func dedup[S ~[]E, E any](s S) S {
 	var ret S
@@ -117,6 +181,60 @@ func contains[S ~[]E, E any](s S, v E) bool {
 	})
 }
 
+// generateEnvs returns the environment variables of the provided
+// checks indexed by check ID. They are the variables set with the
+// [envOption] option of the checktype and the target, and the
+// authentication variables of the target. The target variables take
+// precedence over the checktype ones and the authentication
+// variables take precedence over both. Checks without environment
+// variables are omitted.
+func generateEnvs(checks []check) (map[string]map[string]string, error) {
+	envs := make(map[string]map[string]string)
+	for _, check := range checks {
+		env := make(map[string]string)
+		for _, opt := range check.envOpts {
+			vars, ok := opt.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: %v: %v: not a map: %#v", ErrInvalidEnv, check.checktype.Name, check.target, opt)
+			}
+			for k, v := range vars {
+				s, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: %v: %v: %v: not a string: %#v", ErrInvalidEnv, check.checktype.Name, check.target, k, v)
+				}
+				env[k] = s
+			}
+		}
+
+		if check.target.Auth != nil {
+			authEnv, err := authEnv(*check.target.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("target %v: %w", check.target, err)
+			}
+			maps.Copy(env, authEnv)
+		}
+
+		if len(env) > 0 {
+			envs[check.id] = env
+		}
+	}
+	return envs, nil
+}
+
+// unmappedChecks returns the IDs of the provided checks whose
+// targets must not be handled by the target server. The target
+// mapping is not passed to the checks as an option, so it cannot be
+// set by checktypes nor included in the reports.
+func unmappedChecks(checks []check) map[string]bool {
+	unmapped := make(map[string]bool)
+	for _, check := range checks {
+		if check.target.MapTarget != nil && !*check.target.MapTarget {
+			unmapped[check.id] = true
+		}
+	}
+	return unmapped
+}
+
 // sendJobs feeds the provided queue with jobs.
 func sendJobs(jobs []jobrunner.Job, qw queue.Writer) error {
 	for _, job := range jobs {
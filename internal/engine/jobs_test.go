@@ -3,6 +3,7 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -436,6 +437,86 @@ func TestGenerateChecks(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "equivalent targets",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Name:        "checktype1",
+					Description: "checktype1 description",
+					Image:       "namespace/repository:tag",
+					Assets: []string{
+						"WebAddress",
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "https://Example.com/",
+					AssetType:  types.WebAddress,
+				},
+				{
+					Identifier: "https://example.com:443",
+					AssetType:  types.WebAddress,
+				},
+			},
+			want: []check{
+				{
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"WebAddress",
+						},
+					},
+					target: config.Target{
+						Identifier: "https://example.com",
+						AssetType:  types.WebAddress,
+					},
+					options: map[string]any{},
+				},
+			},
+		},
+		{
+			name: "equivalent paths",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Name:        "checktype1",
+					Description: "checktype1 description",
+					Image:       "namespace/repository:tag",
+					Assets: []string{
+						"GitRepository",
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "./.",
+					AssetType:  assettypes.Path,
+				},
+				{
+					Identifier: ".",
+					AssetType:  assettypes.Path,
+				},
+			},
+			want: []check{
+				{
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"GitRepository",
+						},
+					},
+					target: config.Target{
+						Identifier: ".",
+						AssetType:  assettypes.Path,
+					},
+					options: map[string]any{},
+				},
+			},
+		},
 		{
 			name: "lava asset type",
 			catalog: checktypes.Catalog{
@@ -472,6 +553,213 @@ func TestGenerateChecks(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "target with rate limit",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Name:        "checktype1",
+					Description: "checktype1 description",
+					Image:       "namespace/repository:tag",
+					Assets: []string{
+						"WebAddress",
+					},
+					Options: map[string]any{
+						"rate_limit": "checktype value",
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "http://localhost:8080",
+					AssetType:  types.WebAddress,
+					RateLimit: &config.RateLimit{
+						RequestsPerSecond: 10,
+						Concurrency:       2,
+					},
+				},
+			},
+			want: []check{
+				{
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"WebAddress",
+						},
+						Options: map[string]any{
+							"rate_limit": "checktype value",
+						},
+					},
+					target: config.Target{
+						Identifier: "http://localhost:8080",
+						AssetType:  types.WebAddress,
+						RateLimit: &config.RateLimit{
+							RequestsPerSecond: 10,
+							Concurrency:       2,
+						},
+					},
+					options: map[string]any{
+						"rate_limit": map[string]any{
+							"requests_per_second": float64(10),
+							"concurrency":         2,
+							"enforce":             false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "target mapping disabled",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Name:        "checktype1",
+					Description: "checktype1 description",
+					Image:       "namespace/repository:tag",
+					Assets: []string{
+						"WebAddress",
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "http://localhost:8080",
+					AssetType:  types.WebAddress,
+					MapTarget:  ptr(false),
+				},
+			},
+			want: []check{
+				{
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"WebAddress",
+						},
+					},
+					target: config.Target{
+						Identifier: "http://localhost:8080",
+						AssetType:  types.WebAddress,
+						MapTarget:  ptr(false),
+					},
+					options: map[string]any{},
+				},
+			},
+		},
+		{
+			name: "terraform module",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Name:        "checktype1",
+					Description: "checktype1 description",
+					Image:       "namespace/repository:tag",
+					Assets: []string{
+						"GitRepository",
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "./infra",
+					AssetType:  assettypes.TerraformModule,
+					Terraform: &config.TerraformConfig{
+						VarFiles:   []string{"prod.tfvars"},
+						Workspaces: []string{"prod"},
+					},
+				},
+			},
+			want: []check{
+				{
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"GitRepository",
+						},
+					},
+					target: config.Target{
+						Identifier: "infra",
+						AssetType:  assettypes.TerraformModule,
+						Terraform: &config.TerraformConfig{
+							VarFiles:   []string{"prod.tfvars"},
+							Workspaces: []string{"prod"},
+						},
+					},
+					options: map[string]any{
+						"terraform": map[string]any{
+							"var_files":  []string{"prod.tfvars"},
+							"workspaces": []string{"prod"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "env option",
+			catalog: checktypes.Catalog{
+				"checktype1": {
+					Name:        "checktype1",
+					Description: "checktype1 description",
+					Image:       "namespace/repository:tag",
+					Assets: []string{
+						"DomainName",
+					},
+					Options: map[string]any{
+						"option1": "checktype value 1",
+						"env": map[string]any{
+							"VAR_A": "checktype A",
+						},
+					},
+				},
+			},
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Options: map[string]any{
+						"env": map[string]any{
+							"VAR_A": "target A",
+						},
+					},
+				},
+			},
+			want: []check{
+				{
+					checktype: checkcatalog.Checktype{
+						Name:        "checktype1",
+						Description: "checktype1 description",
+						Image:       "namespace/repository:tag",
+						Assets: []string{
+							"DomainName",
+						},
+						Options: map[string]any{
+							"option1": "checktype value 1",
+							"env": map[string]any{
+								"VAR_A": "checktype A",
+							},
+						},
+					},
+					target: config.Target{
+						Identifier: "example.com",
+						AssetType:  types.DomainName,
+						Options: map[string]any{
+							"env": map[string]any{
+								"VAR_A": "target A",
+							},
+						},
+					},
+					options: map[string]any{
+						"option1": "checktype value 1",
+					},
+					envOpts: []any{
+						map[string]any{"VAR_A": "checktype A"},
+						map[string]any{"VAR_A": "target A"},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -489,6 +777,38 @@ func TestGenerateChecks(t *testing.T) {
 	}
 }
 
+func TestGenerateChecks_order(t *testing.T) {
+	catalog := checktypes.Catalog{
+		"checktype2": {Name: "checktype2", Assets: []string{"DomainName", "Hostname"}},
+		"checktype1": {Name: "checktype1", Assets: []string{"DomainName", "Hostname"}},
+		"checktype3": {Name: "checktype3", Assets: []string{"DomainName", "Hostname"}},
+	}
+	targets := []config.Target{
+		{Identifier: "example.org", AssetType: types.DomainName},
+		{Identifier: "example.com", AssetType: types.Hostname},
+		{Identifier: "example.com", AssetType: types.DomainName},
+	}
+
+	var want []string
+	for _, ct := range []string{"checktype1", "checktype2", "checktype3"} {
+		want = append(want,
+			ct+" DomainName(example.com)",
+			ct+" Hostname(example.com)",
+			ct+" DomainName(example.org)",
+		)
+	}
+
+	for i := 0; i < 10; i++ {
+		var got []string
+		for _, c := range generateChecks(catalog, targets) {
+			got = append(got, fmt.Sprintf("%v %v", c.checktype.Name, c.target))
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("checks order mismatch (-want +got):\n%v", diff)
+		}
+	}
+}
+
 func TestGenerateJobs(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -632,7 +952,7 @@ func TestGenerateJobs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := generateJobs(tt.catalog, tt.targets)
+			got, err := generateJobs(generateChecks(tt.catalog, tt.targets))
 			if (err == nil) != tt.wantNilErr {
 				t.Fatalf("unexpected error value: %v", err)
 			}
@@ -647,6 +967,158 @@ func TestGenerateJobs(t *testing.T) {
 	}
 }
 
+func TestGenerateEnvs(t *testing.T) {
+	tests := []struct {
+		name    string
+		checks  []check
+		want    map[string]map[string]string
+		wantErr error
+	}{
+		{
+			name: "no env",
+			checks: []check{
+				{id: "check1"},
+			},
+			want: map[string]map[string]string{},
+		},
+		{
+			name: "target env overrides checktype env",
+			checks: []check{
+				{
+					id: "check1",
+					envOpts: []any{
+						map[string]any{"VAR_A": "checktype A", "VAR_B": "checktype B"},
+						map[string]any{"VAR_A": "target A"},
+					},
+				},
+				{id: "check2"},
+			},
+			want: map[string]map[string]string{
+				"check1": {"VAR_A": "target A", "VAR_B": "checktype B"},
+			},
+		},
+		{
+			name: "auth env overrides env option",
+			checks: []check{
+				{
+					id: "check1",
+					target: config.Target{
+						Auth: &config.TargetAuth{
+							Type:  config.AuthTypeHeader,
+							Token: "Bearer token",
+						},
+					},
+					envOpts: []any{
+						map[string]any{"LAVA_AUTH_TOKEN": "other", "VAR_A": "A"},
+					},
+				},
+			},
+			want: map[string]map[string]string{
+				"check1": {
+					"LAVA_AUTH_TYPE":   "header",
+					"LAVA_AUTH_HEADER": "Authorization",
+					"LAVA_AUTH_TOKEN":  "Bearer token",
+					"VAR_A":            "A",
+				},
+			},
+		},
+		{
+			name: "env option is not a map",
+			checks: []check{
+				{
+					id:      "check1",
+					envOpts: []any{"VAR_A=A"},
+				},
+			},
+			wantErr: ErrInvalidEnv,
+		},
+		{
+			name: "env value is not a string",
+			checks: []check{
+				{
+					id:      "check1",
+					envOpts: []any{map[string]any{"VAR_A": float64(1)}},
+				},
+			},
+			wantErr: ErrInvalidEnv,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generateEnvs(tt.checks)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("envs mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestUnmappedChecks(t *testing.T) {
+	checks := []check{
+		{
+			id:     "check1",
+			target: config.Target{Identifier: "http://localhost:8080", AssetType: types.WebAddress},
+		},
+		{
+			id:     "check2",
+			target: config.Target{Identifier: "http://localhost:8081", AssetType: types.WebAddress, MapTarget: ptr(true)},
+		},
+		{
+			id:     "check3",
+			target: config.Target{Identifier: "http://localhost:8082", AssetType: types.WebAddress, MapTarget: ptr(false)},
+		},
+	}
+
+	want := map[string]bool{"check3": true}
+	if diff := cmp.Diff(want, unmappedChecks(checks)); diff != "" {
+		t.Errorf("unmapped checks mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func BenchmarkGenerateJobs(b *testing.B) {
+	catalog := make(checktypes.Catalog)
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("checktype%v", i)
+		catalog[name] = checkcatalog.Checktype{
+			Name:         name,
+			Image:        fmt.Sprintf("namespace/%v:tag", name),
+			Assets:       []string{"DomainName", "GitRepository"},
+			RequiredVars: []any{"VAR_A", "VAR_B"},
+			Options: map[string]any{
+				"depth":  1,
+				"branch": "main",
+			},
+		}
+	}
+
+	var targets []config.Target
+	for i := 0; i < 100; i++ {
+		targets = append(targets, config.Target{
+			Identifier: fmt.Sprintf("example%v.com", i),
+			AssetType:  types.DomainName,
+			Options: map[string]any{
+				"env": map[string]any{"VAR_A": "a"},
+			},
+		})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		checks := generateChecks(catalog, targets)
+		if _, err := generateJobs(checks); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := generateEnvs(checks); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
 func checkLess(a, b check) bool {
 	h := func(c check) string {
 		c.id = ""
@@ -0,0 +1,66 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adevinta/vulcan-agent/jobrunner"
+	"github.com/distribution/reference"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// mirrorJobs returns a copy of the provided jobs with their images
+// rewritten to be pulled from the registry mirrors. The first rule
+// matching the registry of an image is applied. The images whose
+// registry does not match any rule are not modified.
+func mirrorJobs(jobs []jobrunner.Job, mirrors []config.RegistryMirror) ([]jobrunner.Job, error) {
+	if len(mirrors) == 0 {
+		return jobs, nil
+	}
+
+	var mirrored []jobrunner.Job
+	for _, job := range jobs {
+		img, err := mirrorRef(job.Image, mirrors)
+		if err != nil {
+			return nil, fmt.Errorf("check %v: %w", job.CheckID, err)
+		}
+		job.Image = img
+		mirrored = append(mirrored, job)
+	}
+	return mirrored, nil
+}
+
+// mirrorRef rewrites the provided image reference using the first
+// registry mirror rule that matches its registry. If no rule matches,
+// the reference is returned unmodified.
+func mirrorRef(ref string, mirrors []config.RegistryMirror) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("parse image reference: %w", err)
+	}
+
+	domain := reference.Domain(named)
+	for _, m := range mirrors {
+		if m.Registry == "*" || m.Registry == domain {
+			return mirrorImage(ref, m.Mirror)
+		}
+	}
+	return ref, nil
+}
+
+// mirrorImage replaces the registry of the provided image reference
+// with the specified registry mirror. The path, tag and digest of the
+// image are kept. For instance, the image "vulcansec/vulcan-nmap:1"
+// is pulled from "mirror.example.com" as
+// "mirror.example.com/vulcansec/vulcan-nmap:1".
+func mirrorImage(ref, mirror string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("parse image reference: %w", err)
+	}
+	mirror = strings.TrimSuffix(mirror, "/")
+	return mirror + strings.TrimPrefix(named.String(), reference.Domain(named)), nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/adevinta/vulcan-agent/jobrunner"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestMirrorImage(t *testing.T) {
+	tests := []struct {
+		name       string
+		ref        string
+		mirror     string
+		want       string
+		wantNilErr bool
+	}{
+		{
+			name:       "docker hub image",
+			ref:        "vulcansec/vulcan-nmap:1",
+			mirror:     "mirror.example.com",
+			want:       "mirror.example.com/vulcansec/vulcan-nmap:1",
+			wantNilErr: true,
+		},
+		{
+			name:       "official image",
+			ref:        "alpine",
+			mirror:     "mirror.example.com/",
+			want:       "mirror.example.com/library/alpine",
+			wantNilErr: true,
+		},
+		{
+			name:       "mirror with path",
+			ref:        "registry.example.com/checks/check@sha256:9d8b1a2d1c1f3bd7a52b4a7d1c8ff4e4fd1c3c2ed1e2b0e8a5b4a3c2d1e0f9a8",
+			mirror:     "mirror.example.com/registry",
+			want:       "mirror.example.com/registry/checks/check@sha256:9d8b1a2d1c1f3bd7a52b4a7d1c8ff4e4fd1c3c2ed1e2b0e8a5b4a3c2d1e0f9a8",
+			wantNilErr: true,
+		},
+		{
+			name:       "invalid reference",
+			ref:        "Invalid:Image:Ref",
+			mirror:     "mirror.example.com",
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mirrorImage(tt.ref, tt.mirror)
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected image: want: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMirrorJobs(t *testing.T) {
+	jobs := []jobrunner.Job{
+		{CheckID: "check1", Image: "vulcansec/vulcan-nmap:1"},
+		{CheckID: "check2", Image: "registry.example.com/check:edge"},
+		{CheckID: "check3", Image: "ghcr.io/example/check:edge"},
+	}
+	mirrors := []config.RegistryMirror{
+		{Registry: "docker.io", Mirror: "artifactory.example.com/dockerhub"},
+		{Registry: "registry.example.com", Mirror: "harbor.example.com"},
+	}
+
+	got, err := mirrorJobs(jobs, mirrors)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []jobrunner.Job{
+		{CheckID: "check1", Image: "artifactory.example.com/dockerhub/vulcansec/vulcan-nmap:1"},
+		{CheckID: "check2", Image: "harbor.example.com/check:edge"},
+		{CheckID: "check3", Image: "ghcr.io/example/check:edge"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("jobs mismatch (-want +got):\n%v", diff)
+	}
+	if jobs[0].Image != "vulcansec/vulcan-nmap:1" {
+		t.Errorf("original jobs were modified")
+	}
+}
+
+func TestMirrorJobs_invalid_image(t *testing.T) {
+	jobs := []jobrunner.Job{{CheckID: "check1", Image: "Invalid:Image:Ref"}}
+	mirrors := []config.RegistryMirror{{Registry: "*", Mirror: "mirror.example.com"}}
+
+	if _, err := mirrorJobs(jobs, mirrors); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestMirrorRef(t *testing.T) {
+	mirrors := []config.RegistryMirror{
+		{Registry: "docker.io", Mirror: "dockerhub.example.com"},
+		{Registry: "*", Mirror: "mirror.example.com"},
+	}
+
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{
+			name: "docker hub",
+			ref:  "vulcansec/vulcan-nmap:1",
+			want: "dockerhub.example.com/vulcansec/vulcan-nmap:1",
+		},
+		{
+			name: "explicit docker hub",
+			ref:  "docker.io/vulcansec/vulcan-nmap:1",
+			want: "dockerhub.example.com/vulcansec/vulcan-nmap:1",
+		},
+		{
+			name: "wildcard",
+			ref:  "ghcr.io/example/check:edge",
+			want: "mirror.example.com/example/check:edge",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mirrorRef(tt.ref, mirrors)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected image: want: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
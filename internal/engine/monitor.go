@@ -0,0 +1,236 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	"github.com/adevinta/vulcan-agent/queue"
+	"github.com/adevinta/vulcan-agent/stateupdater"
+	report "github.com/adevinta/vulcan-report"
+)
+
+// StatusHung is the status of the checks that have been killed
+// because they did not send any state update for longer than the
+// configured hang timeout.
+const StatusHung = "HUNG"
+
+// maxMonitorInterval is the maximum time between two consecutive
+// hang detection passes.
+const maxMonitorInterval = 30 * time.Second
+
+// checkMonitor tracks the state updates sent by the running checks
+// and kills those that do not send any update for longer than a
+// timeout. It implements [queue.Writer], so it can be used as the
+// state queue of the Vulcan agent, and [backend.Backend], so it can
+// cancel the checks it kills.
+type checkMonitor struct {
+	backend backend.Backend
+	timeout time.Duration
+	logger  *slog.Logger
+
+	// mu protects the fields below.
+	mu      sync.Mutex
+	running map[string]*monitoredCheck
+	hung    map[string]report.CheckData
+	started map[string]backend.RunParams
+	failed  map[string]error
+}
+
+var (
+	_ queue.Writer    = &checkMonitor{}
+	_ backend.Backend = &checkMonitor{}
+)
+
+// monitoredCheck is a running check tracked by a [checkMonitor].
+type monitoredCheck struct {
+	params     backend.RunParams
+	startTime  time.Time
+	lastUpdate time.Time
+	cancel     context.CancelFunc
+}
+
+// newCheckMonitor returns a [checkMonitor] that runs checks with the
+// provided backend. If timeout is zero, hang detection is disabled.
+func newCheckMonitor(logger *slog.Logger, b backend.Backend, timeout time.Duration) *checkMonitor {
+	return &checkMonitor{
+		backend: b,
+		timeout: timeout,
+		logger:  logger,
+		running: make(map[string]*monitoredCheck),
+		hung:    make(map[string]report.CheckData),
+		started: make(map[string]backend.RunParams),
+		failed:  make(map[string]error),
+	}
+}
+
+// Write receives a state update from the Vulcan agent. It updates
+// the time of the last update of the corresponding check.
+func (cm *checkMonitor) Write(body string) error {
+	var state stateupdater.CheckState
+	if err := json.Unmarshal([]byte(body), &state); err != nil {
+		return fmt.Errorf("decode check state: %w", err)
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if mc, ok := cm.running[state.ID]; ok {
+		mc.lastUpdate = time.Now()
+	}
+	return nil
+}
+
+// Run runs the check with the underlying backend. The context passed
+// to the backend is canceled if the check is considered hung.
+func (cm *checkMonitor) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	now := time.Now()
+	cm.mu.Lock()
+	cm.running[params.CheckID] = &monitoredCheck{
+		params:     params,
+		startTime:  now,
+		lastUpdate: now,
+		cancel:     cancel,
+	}
+	cm.started[params.CheckID] = params
+	cm.mu.Unlock()
+
+	finished, err := cm.backend.Run(ctx, params)
+	if err != nil {
+		cm.remove(params.CheckID)
+		cm.fail(params.CheckID, err)
+		cancel()
+		return nil, err
+	}
+
+	c := make(chan backend.RunResult, 1)
+	go func() {
+		res := <-finished
+		cm.remove(params.CheckID)
+		if res.Error != nil {
+			cm.fail(params.CheckID, res.Error)
+		}
+		cancel()
+		c <- res
+	}()
+	return c, nil
+}
+
+// fail records the error returned by the backend when running the
+// specified check. Errors of hung checks are ignored, because they
+// are caused by the monitor killing the check.
+func (cm *checkMonitor) fail(checkID string, err error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, ok := cm.hung[checkID]; ok {
+		return
+	}
+	cm.failed[checkID] = err
+}
+
+// Skip records that the specified check was not run because of the
+// provided error. The check is reported as failed.
+func (cm *checkMonitor) Skip(params backend.RunParams, err error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.started[params.CheckID] = params
+	cm.failed[params.CheckID] = err
+}
+
+// remove stops tracking the specified check.
+func (cm *checkMonitor) remove(checkID string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	delete(cm.running, checkID)
+}
+
+// Monitor kills the hung checks until done is closed. It returns
+// immediately if hang detection is disabled.
+func (cm *checkMonitor) Monitor(done <-chan struct{}) {
+	if cm.timeout <= 0 {
+		return
+	}
+
+	interval := min(cm.timeout/4, maxMonitorInterval)
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(interval):
+			cm.killHung(time.Now())
+		}
+	}
+}
+
+// killHung kills the checks whose last update is older than the
+// timeout at the provided time.
+func (cm *checkMonitor) killHung(now time.Time) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for checkID, mc := range cm.running {
+		if now.Sub(mc.lastUpdate) <= cm.timeout {
+			continue
+		}
+
+		cm.logger.Warn("killing hung check",
+			"checkID", checkID,
+			"checktype", mc.params.CheckTypeName,
+			"target", mc.params.Target,
+			"lastUpdate", mc.lastUpdate,
+		)
+
+		cm.hung[checkID] = report.CheckData{
+			CheckID:          checkID,
+			ChecktypeName:    mc.params.CheckTypeName,
+			ChecktypeVersion: mc.params.ChecktypeVersion,
+			Target:           mc.params.Target,
+			Options:          mc.params.Options,
+			Status:           StatusHung,
+			StartTime:        mc.startTime,
+			EndTime:          now,
+		}
+		mc.cancel()
+		delete(cm.running, checkID)
+	}
+}
+
+// Hung returns the check data of the checks that have been killed
+// because they were hung.
+func (cm *checkMonitor) Hung() map[string]report.CheckData {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	return maps.Clone(cm.hung)
+}
+
+// Failed returns the errors returned by the backend when running the
+// checks, indexed by check ID.
+func (cm *checkMonitor) Failed() map[string]error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	return maps.Clone(cm.failed)
+}
+
+// Params returns the parameters of the specified check. The returned
+// bool is false if the check has not been started.
+func (cm *checkMonitor) Params(checkID string) (backend.RunParams, bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	params, ok := cm.started[checkID]
+	return params, ok
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+)
+
+// blockingBackend is a [backend.Backend] whose checks run until
+// their context is canceled.
+type blockingBackend struct{}
+
+func (blockingBackend) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	c := make(chan backend.RunResult, 1)
+	go func() {
+		<-ctx.Done()
+		c <- backend.RunResult{Error: ctx.Err()}
+	}()
+	return c, nil
+}
+
+func TestCheckMonitor(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cm := newCheckMonitor(logger, blockingBackend{}, time.Minute)
+
+	params := []backend.RunParams{
+		{CheckID: "check1", CheckTypeName: "checktype1", Target: "target1"},
+		{CheckID: "check2", CheckTypeName: "checktype2", Target: "target2"},
+	}
+
+	var results []<-chan backend.RunResult
+	for _, p := range params {
+		c, err := cm.Run(context.Background(), p)
+		if err != nil {
+			t.Fatalf("run error: %v", err)
+		}
+		results = append(results, c)
+	}
+
+	// Simulate that check1 was started long ago and only check2
+	// has sent a state update since then.
+	start := time.Now().Add(-2 * time.Minute)
+	cm.mu.Lock()
+	for _, mc := range cm.running {
+		mc.startTime = start
+		mc.lastUpdate = start
+	}
+	cm.mu.Unlock()
+
+	if err := cm.Write(`{"id":"check2","status":"RUNNING"}`); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+
+	cm.killHung(time.Now())
+
+	select {
+	case res := <-results[0]:
+		if !errors.Is(res.Error, context.Canceled) {
+			t.Errorf("unexpected error: %v", res.Error)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("hung check was not killed")
+	}
+
+	select {
+	case res := <-results[1]:
+		t.Errorf("check killed unexpectedly: %v", res.Error)
+	default:
+	}
+
+	hung := cm.Hung()
+	if len(hung) != 1 {
+		t.Fatalf("unexpected number of hung checks: %v", len(hung))
+	}
+	cd, ok := hung["check1"]
+	if !ok {
+		t.Fatalf("check1 is not hung: %v", hung)
+	}
+	if cd.Status != StatusHung || cd.ChecktypeName != "checktype1" || cd.Target != "target1" || !cd.StartTime.Equal(start) {
+		t.Errorf("unexpected check data: %+v", cd)
+	}
+}
+
+func TestCheckMonitor_Write_invalid(t *testing.T) {
+	cm := newCheckMonitor(slog.Default(), blockingBackend{}, time.Minute)
+	if err := cm.Write("invalid"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestCheckMonitor_Failed(t *testing.T) {
+	errRun := errors.New("pull access denied")
+	errResult := errors.New("container finished unexpectedly")
+
+	b := backendFunc(func(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+		if params.CheckID == "check1" {
+			return nil, errRun
+		}
+		c := make(chan backend.RunResult, 1)
+		c <- backend.RunResult{Error: errResult}
+		return c, nil
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cm := newCheckMonitor(logger, b, 0)
+
+	if _, err := cm.Run(context.Background(), backend.RunParams{CheckID: "check1"}); !errors.Is(err, errRun) {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	c, err := cm.Run(context.Background(), backend.RunParams{CheckID: "check2"})
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if res := <-c; !errors.Is(res.Error, errResult) {
+		t.Fatalf("unexpected result error: %v", res.Error)
+	}
+
+	failed := cm.Failed()
+	if len(failed) != 2 || !errors.Is(failed["check1"], errRun) || !errors.Is(failed["check2"], errResult) {
+		t.Errorf("unexpected failed checks: %v", failed)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/adevinta/vulcan-agent/jobrunner"
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/metrics"
+)
+
+// maxAutoParallel is the maximum number of checks that can run in
+// parallel when the parallelism is auto-tuned.
+const maxAutoParallel = 16
+
+// checkResources contains the resources that are expected to be used
+// by a check.
+type checkResources struct {
+	cpus float64
+	mem  int64
+}
+
+var (
+	// heavyCheck are the resources expected to be used by the
+	// checks that analyze local assets, like Git repositories and
+	// container images. These checks usually run tools that are
+	// CPU and memory intensive.
+	heavyCheck = checkResources{cpus: 1, mem: 1 << 30}
+
+	// lightCheck are the resources expected to be used by the
+	// rest of checks, which mostly send requests over the
+	// network.
+	lightCheck = checkResources{cpus: 0.5, mem: 256 << 20}
+)
+
+// tuneParallel returns the number of checks that can run in parallel
+// based on the resources available to the container runtime and the
+// provided jobs.
+func (eng Engine) tuneParallel(jobs []jobrunner.Job) int {
+	cpus := runtime.NumCPU()
+	var mem int64
+	if info, err := eng.cli.Info(context.Background()); err != nil {
+		eng.logger.Warn("could not get container runtime info", "err", err)
+	} else {
+		cpus = info.NCPU
+		mem = info.MemTotal
+	}
+
+	parallel := autoParallel(cpus, mem, jobs)
+	eng.logger.Info("auto-tuned parallelism", "parallel", parallel, "cpus", cpus, "mem", mem)
+	metrics.Collect("auto_parallel", parallel)
+	return parallel
+}
+
+// autoParallel returns the number of checks that can run in parallel
+// given the number of CPUs and the memory in bytes available to the
+// checks. A memory of zero means that it is unknown, so it is not
+// considered. The resources required by the checks are estimated
+// from the asset types of the provided jobs. The returned value is
+// between 1 and [maxAutoParallel], and it is never greater than the
+// number of jobs.
+func autoParallel(cpus int, mem int64, jobs []jobrunner.Job) int {
+	if len(jobs) == 0 {
+		return 1
+	}
+
+	var heavy int
+	for _, job := range jobs {
+		at := assettypes.ToVulcan(types.AssetType(job.AssetType))
+		if at == types.GitRepository || at == types.DockerImage {
+			heavy++
+		}
+	}
+
+	// Average resources used by a check.
+	ratio := float64(heavy) / float64(len(jobs))
+	avgCPUs := ratio*heavyCheck.cpus + (1-ratio)*lightCheck.cpus
+	avgMem := ratio*float64(heavyCheck.mem) + (1-ratio)*float64(lightCheck.mem)
+
+	parallel := int(float64(cpus) / avgCPUs)
+	if mem > 0 {
+		// Part of the memory is left to Lava and the rest of
+		// processes of the host.
+		parallel = min(parallel, int(float64(mem)*0.75/avgMem))
+	}
+	return max(1, min(parallel, len(jobs), maxAutoParallel))
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/adevinta/vulcan-agent/jobrunner"
+)
+
+func TestAutoParallel(t *testing.T) {
+	mkJobs := func(n int, assetType string) []jobrunner.Job {
+		jobs := make([]jobrunner.Job, n)
+		for i := range jobs {
+			jobs[i].AssetType = assetType
+		}
+		return jobs
+	}
+
+	tests := []struct {
+		name string
+		cpus int
+		mem  int64
+		jobs []jobrunner.Job
+		want int
+	}{
+		{
+			name: "no jobs",
+			cpus: 8,
+			mem:  16 << 30,
+			jobs: nil,
+			want: 1,
+		},
+		{
+			name: "heavy jobs limited by CPU",
+			cpus: 4,
+			mem:  16 << 30,
+			jobs: mkJobs(10, "GitRepository"),
+			want: 4,
+		},
+		{
+			name: "heavy jobs limited by memory",
+			cpus: 8,
+			mem:  4 << 30,
+			jobs: mkJobs(10, "Path"),
+			want: 3,
+		},
+		{
+			name: "light jobs",
+			cpus: 4,
+			mem:  16 << 30,
+			jobs: mkJobs(10, "WebAddress"),
+			want: 8,
+		},
+		{
+			name: "mixed jobs",
+			cpus: 6,
+			mem:  16 << 30,
+			jobs: append(mkJobs(5, "DockerImage"), mkJobs(5, "Hostname")...),
+			want: 8,
+		},
+		{
+			name: "unknown memory",
+			cpus: 4,
+			mem:  0,
+			jobs: mkJobs(10, "WebAddress"),
+			want: 8,
+		},
+		{
+			name: "limited by number of jobs",
+			cpus: 64,
+			mem:  64 << 30,
+			jobs: mkJobs(3, "WebAddress"),
+			want: 3,
+		},
+		{
+			name: "limited by cap",
+			cpus: 64,
+			mem:  128 << 30,
+			jobs: mkJobs(100, "WebAddress"),
+			want: maxAutoParallel,
+		},
+		{
+			name: "at least one",
+			cpus: 1,
+			mem:  512 << 20,
+			jobs: mkJobs(10, "OCILayout"),
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoParallel(tt.cpus, tt.mem, tt.jobs); got != tt.want {
+				t.Errorf("unexpected parallel: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
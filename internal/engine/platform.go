@@ -0,0 +1,177 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+	"github.com/adevinta/vulcan-agent/jobrunner"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/errdefs"
+)
+
+// errImageNotPresent is returned when an image is not present in the
+// container runtime and the pull policy is Never.
+var errImageNotPresent = errors.New("image not present with pull policy Never")
+
+// pullImages pulls the images of the provided jobs for the configured
+// platform honoring the configured pull policy. It returns the IDs of
+// the images indexed by image reference, so the check containers are
+// created from the image of the configured platform even if the
+// container runtime stores images of other platforms with the same
+// reference. The pulls failing because of transient errors are
+// retried. It also returns the time in seconds spent pulling every
+// image.
+func (eng Engine) pullImages(jobs []jobrunner.Job) (ids map[string]string, durations map[string]float64, err error) {
+	ctx := context.Background()
+
+	ids = make(map[string]string)
+	durations = make(map[string]float64)
+	for _, job := range jobs {
+		if _, ok := ids[job.Image]; ok {
+			continue
+		}
+
+		start := time.Now()
+		id, err := eng.pullImageRetry(ctx, job.Image)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pull image %v: %w", job.Image, err)
+		}
+		ids[job.Image] = id
+		durations[job.Image] = time.Since(start).Seconds()
+	}
+	return ids, durations, nil
+}
+
+// prePullImages pulls the images of the provided jobs that are not
+// present in the container runtime before running the agent, so the
+// progress of the pulls is reported. It is only used with the pull
+// policy IfNotPresent and when no platform is configured. Otherwise,
+// the images are pulled by [Engine.pullImages] or by the agent. The
+// errors are logged and ignored, because the agent pulls the missing
+// images again when running the checks and handles the failures. It
+// returns the time in seconds spent pulling every image.
+func (eng Engine) prePullImages(jobs []jobrunner.Job) map[string]float64 {
+	ctx := context.Background()
+
+	durations := make(map[string]float64)
+	for _, job := range jobs {
+		if _, ok := durations[job.Image]; ok {
+			continue
+		}
+
+		start := time.Now()
+		if _, err := eng.pullImage(ctx, job.Image); err != nil {
+			eng.logger.Debug("could not pre-pull image", "image", job.Image, "err", err)
+		}
+		durations[job.Image] = time.Since(start).Seconds()
+	}
+	return durations
+}
+
+// pullImage pulls the specified image for the configured platform and
+// returns its ID. If no platform is configured, the image is pulled
+// for the platform of the container runtime. The progress of the pull
+// is reported.
+func (eng Engine) pullImage(ctx context.Context, ref string) (string, error) {
+	if eng.pullPolicy != agentconfig.PullPolicyAlways {
+		img, _, err := eng.cli.ImageInspectWithRaw(ctx, ref)
+		switch {
+		case err == nil:
+			if matchPlatform(img, eng.platform) {
+				return img.ID, nil
+			}
+			if eng.pullPolicy == agentconfig.PullPolicyNever {
+				eng.logger.Warn("image platform does not match the configured platform",
+					"image", ref, "imagePlatform", imagePlatform(img), "platform", eng.platform)
+				return img.ID, nil
+			}
+		case !errdefs.IsNotFound(err):
+			return "", fmt.Errorf("image inspect: %w", err)
+		case eng.pullPolicy == agentconfig.PullPolicyNever:
+			return "", errImageNotPresent
+		}
+	}
+
+	opts := image.PullOptions{Platform: eng.platform}
+	if auth, ok := eng.registryAuth(ref); ok {
+		encoded, err := registry.EncodeAuthConfig(auth)
+		if err != nil {
+			return "", fmt.Errorf("encode auth config: %w", err)
+		}
+		opts.RegistryAuth = encoded
+	}
+
+	eng.logger.Info("pulling image", "image", ref, "platform", eng.platform)
+	rc, err := eng.cli.ImagePull(ctx, ref, opts)
+	if err != nil {
+		return "", fmt.Errorf("image pull: %w", err)
+	}
+	defer rc.Close()
+
+	// The pull finishes when the response body has been read.
+	if _, err := newPullReporter(eng.logger, progressTTY(eng.logFormat)).Read(ref, rc); err != nil {
+		return "", fmt.Errorf("read pull response: %w", err)
+	}
+
+	img, _, err := eng.cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("image inspect: %w", err)
+	}
+	if !matchPlatform(img, eng.platform) {
+		return "", fmt.Errorf("unexpected image platform: %v", imagePlatform(img))
+	}
+	return img.ID, nil
+}
+
+// registryAuth returns the credentials configured for the registry of
+// the specified image.
+func (eng Engine) registryAuth(ref string) (registry.AuthConfig, bool) {
+	domain, _, _, err := backend.ParseImage(ref)
+	if err != nil {
+		return registry.AuthConfig{}, false
+	}
+	for _, auth := range eng.cfg.Runtime.Docker.Registry.Auths {
+		if auth.Server == domain {
+			return registry.AuthConfig{
+				Username:      auth.User,
+				Password:      auth.Pass,
+				ServerAddress: auth.Server,
+			}, true
+		}
+	}
+	return registry.AuthConfig{}, false
+}
+
+// matchPlatform reports whether the provided image matches the
+// specified platform with the format "os/arch[/variant]". The variant
+// is only compared if it is specified. If the platform is empty, any
+// image matches.
+func matchPlatform(img dockertypes.ImageInspect, platform string) bool {
+	if platform == "" {
+		return true
+	}
+	parts := strings.SplitN(platform, "/", 3)
+	if len(parts) < 2 || img.Os != parts[0] || img.Architecture != parts[1] {
+		return false
+	}
+	return len(parts) < 3 || img.Variant == parts[2]
+}
+
+// imagePlatform returns the platform of the provided image with the
+// format "os/arch[/variant]".
+func imagePlatform(img dockertypes.ImageInspect) string {
+	platform := img.Os + "/" + img.Architecture
+	if img.Variant != "" {
+		platform += "/" + img.Variant
+	}
+	return platform
+}
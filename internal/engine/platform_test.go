@@ -0,0 +1,75 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"testing"
+
+	dockertypes "github.com/docker/docker/api/types"
+)
+
+func TestMatchPlatform(t *testing.T) {
+	tests := []struct {
+		name     string
+		img      dockertypes.ImageInspect
+		platform string
+		want     bool
+	}{
+		{
+			name:     "same platform",
+			img:      dockertypes.ImageInspect{Os: "linux", Architecture: "amd64"},
+			platform: "linux/amd64",
+			want:     true,
+		},
+		{
+			name:     "different architecture",
+			img:      dockertypes.ImageInspect{Os: "linux", Architecture: "arm64"},
+			platform: "linux/amd64",
+			want:     false,
+		},
+		{
+			name:     "different os",
+			img:      dockertypes.ImageInspect{Os: "windows", Architecture: "amd64"},
+			platform: "linux/amd64",
+			want:     false,
+		},
+		{
+			name:     "same variant",
+			img:      dockertypes.ImageInspect{Os: "linux", Architecture: "arm64", Variant: "v8"},
+			platform: "linux/arm64/v8",
+			want:     true,
+		},
+		{
+			name:     "different variant",
+			img:      dockertypes.ImageInspect{Os: "linux", Architecture: "arm", Variant: "v6"},
+			platform: "linux/arm/v7",
+			want:     false,
+		},
+		{
+			name:     "unspecified variant",
+			img:      dockertypes.ImageInspect{Os: "linux", Architecture: "arm", Variant: "v7"},
+			platform: "linux/arm",
+			want:     true,
+		},
+		{
+			name:     "empty platform",
+			img:      dockertypes.ImageInspect{Os: "linux", Architecture: "arm64"},
+			platform: "",
+			want:     true,
+		},
+		{
+			name:     "invalid platform",
+			img:      dockertypes.ImageInspect{Os: "linux", Architecture: "amd64"},
+			platform: "amd64",
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPlatform(tt.img, tt.platform); got != tt.want {
+				t.Errorf("unexpected result: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/config"
+)
+
+// ErrPoolClosed is returned by [Pool.Run] and [Pool.RunStages] when
+// the pool has been closed.
+var ErrPoolClosed = errors.New("pool closed")
+
+// Pool is a long-lived Lava engine that runs successive scans. Only
+// the container runtime client, the checktype catalog and the
+// internal target server are set up once and shared by all the
+// scans. The Vulcan agent and its Docker backend are not reused: every
+// scan creates its own, because the agent stops when its jobs queue
+// is drained. Every scan also has its own scan ID. The scans are run
+// one at a time. Pool is meant to be used by programs that embed Lava
+// and scan repeatedly, possibly with different targets. The lava
+// command does not use it.
+type Pool struct {
+	eng Engine
+
+	// runMu serializes the scans.
+	runMu sync.Mutex
+
+	// mu protects the fields below.
+	mu     sync.Mutex
+	runs   int
+	scanID string
+	closed bool
+}
+
+// NewPool returns a new [Pool]. The checktype catalog is retrieved
+// as in [New].
+func NewPool(cfg config.AgentConfig, checktypeURLs []string, integrity map[string]config.CatalogIntegrity, controls []string) (*Pool, error) {
+	eng, err := New(cfg, checktypeURLs, integrity, controls)
+	if err != nil {
+		return nil, err
+	}
+	return newPool(eng)
+}
+
+// NewPoolWithCatalog returns a new [Pool] from a provided agent
+// configuration and checktype catalog.
+func NewPoolWithCatalog(cfg config.AgentConfig, catalog checktypes.Catalog) (*Pool, error) {
+	eng, err := NewWithCatalog(cfg, catalog)
+	if err != nil {
+		return nil, err
+	}
+	return newPool(eng)
+}
+
+// newPool returns a [Pool] that runs the scans with the provided
+// engine. It takes ownership of the engine, which is closed if the
+// pool cannot be created.
+func newPool(eng Engine) (*Pool, error) {
+	srv, err := newTargetServer(eng.runtime, eng.cli.Network(), eng.tmpDir, eng.proxyAllow)
+	if err != nil {
+		eng.Close() //nolint:errcheck
+		return nil, classifyError(fmt.Errorf("new target server: %w", err))
+	}
+	eng.srv = srv
+
+	return &Pool{eng: eng, scanID: eng.scanID}, nil
+}
+
+// Run runs a scan like [Engine.Run]. The local targets are served
+// again with their current contents, so the changes made since the
+// previous scan are scanned.
+func (p *Pool) Run(targets []config.Target) (Report, error) {
+	eng, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	defer p.runMu.Unlock()
+
+	return eng.Run(targets)
+}
+
+// RunStages runs a scan like [Engine.RunStages]. The local targets
+// are served again with their current contents, so the changes made
+// since the previous scan are scanned.
+func (p *Pool) RunStages(targets []config.Target, stages []config.Stage, scope *config.Scope) (Report, error) {
+	eng, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	defer p.runMu.Unlock()
+
+	return eng.RunStages(targets, stages, scope)
+}
+
+// next prepares the next scan and returns the engine that must run
+// it. The first scan keeps the scan ID of the engine, while the
+// following ones get a new one. If no error is returned, the scan
+// lock of the pool is held and must be released by the caller when
+// the scan finishes.
+func (p *Pool) next() (Engine, error) {
+	p.runMu.Lock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		p.runMu.Unlock()
+		return Engine{}, ErrPoolClosed
+	}
+
+	if err := p.eng.srv.Reset(); err != nil {
+		p.runMu.Unlock()
+		return Engine{}, fmt.Errorf("reset target server: %w", err)
+	}
+
+	eng := p.eng
+	if p.runs > 0 {
+		eng = eng.withScanID(uuid.New().String())
+	}
+	p.runs++
+	p.scanID = eng.scanID
+	return eng, nil
+}
+
+// ScanID returns the ID of the last scan run by the pool. Before the
+// first scan, it returns the ID that will be used by it.
+func (p *Pool) ScanID() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.scanID
+}
+
+// Controls returns the control families covered by the checktypes of
+// the catalog used by the pool indexed by checktype name.
+func (p *Pool) Controls() checktypes.ChecktypeControls {
+	return p.eng.Controls()
+}
+
+// Close releases the internal resources used by the pool. It waits
+// for the running scan to finish.
+func (p *Pool) Close() error {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+
+	if err := p.eng.srv.Close(); err != nil {
+		return fmt.Errorf("close target server: %w", err)
+	}
+	return p.eng.Close()
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestPool_Run(t *testing.T) {
+	var (
+		checktypeURLs = []string{"testdata/engine/checktypes_lava_engine_test.json"}
+		agentConfig   = config.AgentConfig{
+			PullPolicy: ptr(agentconfig.PullPolicyNever),
+		}
+	)
+
+	pool, err := NewPool(agentConfig, checktypeURLs, nil, nil)
+	if err != nil {
+		t.Fatalf("pool initialization error: %v", err)
+	}
+	defer pool.Close()
+
+	var scanIDs []string
+	for i := 0; i < 2; i++ {
+		rep, err := pool.Run(nil)
+		if err != nil {
+			t.Fatalf("pool run error: %v", err)
+		}
+		if len(rep) != 0 {
+			t.Fatalf("unexpected number of reports: %v", len(rep))
+		}
+		scanIDs = append(scanIDs, pool.ScanID())
+	}
+
+	if scanIDs[0] == scanIDs[1] {
+		t.Errorf("scans share the same ID: %v", scanIDs[0])
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("pool close error: %v", err)
+	}
+
+	if _, err := pool.Run(nil); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("unexpected error: want: %v, got: %v", ErrPoolClosed, err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	agentconfig "github.com/adevinta/vulcan-agent/config"
+	"github.com/adevinta/vulcan-agent/jobrunner"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/errdefs"
+)
+
+// ErrUnavailableImages is returned by [Engine.Run] when some of the
+// check images do not exist or cannot be accessed.
+var ErrUnavailableImages = errors.New("unavailable check images")
+
+// preflightConcurrency is the maximum number of images checked
+// concurrently.
+const preflightConcurrency = 8
+
+// checkImages checks that the images of the provided jobs exist and
+// are accessible before running the checks. The images present in the
+// container runtime are not checked against the registry unless the
+// pull policy is Always. The manifests of the other images are
+// requested to their registry using the configured credentials. It
+// returns an error wrapping [ErrUnavailableImages] that lists all the
+// missing and forbidden images. Transient errors, like rate limits,
+// are logged and ignored, because the pulls are retried. If the pull
+// retries use a registry mirror, the images that are not available
+// in their registry are also looked up in the mirror.
+func (eng Engine) checkImages(jobs []jobrunner.Job) error {
+	var images []string
+	for _, job := range jobs {
+		if !slices.Contains(images, job.Image) {
+			images = append(images, job.Image)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures = make(map[string]error)
+		sem      = make(chan struct{}, preflightConcurrency)
+	)
+	for _, img := range images {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(img string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			err := eng.checkRetryImage(context.Background(), img)
+			if err == nil {
+				return
+			}
+			if !isUnavailableImage(err) {
+				eng.logger.Warn("could not check image", "image", img, "err", err)
+				return
+			}
+
+			mu.Lock()
+			failures[img] = err
+			mu.Unlock()
+		}(img)
+	}
+	wg.Wait()
+
+	return unavailableImagesError(failures)
+}
+
+// checkImage checks that the specified image exists and is
+// accessible.
+func (eng Engine) checkImage(ctx context.Context, ref string) error {
+	if eng.pullPolicy != agentconfig.PullPolicyAlways {
+		_, _, err := eng.cli.ImageInspectWithRaw(ctx, ref)
+		switch {
+		case err == nil:
+			return nil
+		case !errdefs.IsNotFound(err):
+			return fmt.Errorf("image inspect: %w", err)
+		case eng.pullPolicy == agentconfig.PullPolicyNever:
+			return errImageNotPresent
+		}
+	}
+
+	var encoded string
+	if auth, ok := eng.registryAuth(ref); ok {
+		var err error
+		if encoded, err = registry.EncodeAuthConfig(auth); err != nil {
+			return fmt.Errorf("encode auth config: %w", err)
+		}
+	}
+
+	if _, err := eng.cli.DistributionInspect(ctx, ref, encoded); err != nil {
+		return fmt.Errorf("distribution inspect: %w", err)
+	}
+	return nil
+}
+
+// checkRetryImage checks the specified image like
+// [Engine.checkImage]. If the image is not available and the pull
+// retries are configured with a registry mirror, the mirrored image is
+// checked instead, because the checks that cannot pull their image
+// are retried pulling it from the mirror.
+func (eng Engine) checkRetryImage(ctx context.Context, ref string) error {
+	err := eng.checkImage(ctx, ref)
+	if err == nil || !isUnavailableImage(err) || eng.pullRetries == 0 || eng.pullMirror == "" {
+		return err
+	}
+
+	mirrored, merr := mirrorImage(ref, eng.pullMirror)
+	if merr != nil {
+		return err
+	}
+	if merr := eng.checkImage(ctx, mirrored); merr != nil {
+		return err
+	}
+	eng.logger.Warn("check image only available in the pull retry mirror", "image", ref, "mirror", mirrored, "err", err)
+	return nil
+}
+
+// isUnavailableImage reports whether the provided error means that
+// the image does not exist or cannot be accessed, as opposed to a
+// transient error.
+func isUnavailableImage(err error) bool {
+	if errors.Is(err, errImageNotPresent) || errdefs.IsNotFound(err) || errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) {
+		return true
+	}
+	switch failureKind(err) {
+	case ErrRegistryAuth, ErrImagePullDenied, ErrImageManifest:
+		return true
+	}
+	return false
+}
+
+// unavailableImagesError returns an error wrapping
+// [ErrUnavailableImages] that lists the provided images sorted
+// alphabetically with the cause of their failure. failures contains
+// the errors indexed by image. It returns nil if failures is empty.
+func unavailableImagesError(failures map[string]error) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	images := make([]string, 0, len(failures))
+	for img := range failures {
+		images = append(images, img)
+	}
+	slices.Sort(images)
+
+	var msgs []string
+	for _, img := range images {
+		reason := failures[img].Error()
+		if kind := failureKind(failures[img]); kind != nil {
+			reason = fmt.Sprintf("%v: %v", kind, failureHints[kind])
+		}
+		msgs = append(msgs, fmt.Sprintf("%v (%v)", img, reason))
+	}
+	return fmt.Errorf("%w: %v", ErrUnavailableImages, strings.Join(msgs, "; "))
+}
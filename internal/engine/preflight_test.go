@@ -0,0 +1,80 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+)
+
+func TestIsUnavailableImage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "not present",
+			err:  errImageNotPresent,
+			want: true,
+		},
+		{
+			name: "not found",
+			err:  fmt.Errorf("distribution inspect: %w", errdefs.NotFound(errors.New("not found"))),
+			want: true,
+		},
+		{
+			name: "unauthorized",
+			err:  fmt.Errorf("distribution inspect: %w", errdefs.Unauthorized(errors.New("unauthorized"))),
+			want: true,
+		},
+		{
+			name: "manifest unknown message",
+			err:  errors.New("distribution inspect: Error response from daemon: manifest unknown"),
+			want: true,
+		},
+		{
+			name: "rate limit",
+			err:  errors.New("distribution inspect: toomanyrequests: You have reached your pull rate limit"),
+			want: false,
+		},
+		{
+			name: "unknown",
+			err:  errors.New("distribution inspect: EOF"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnavailableImage(tt.err); got != tt.want {
+				t.Errorf("unexpected result: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUnavailableImagesError(t *testing.T) {
+	if err := unavailableImagesError(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := unavailableImagesError(map[string]error{
+		"example/b:latest": errImageNotPresent,
+		"example/a:latest": errors.New("pull access denied for example/a"),
+	})
+	if !errors.Is(err, ErrUnavailableImages) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg := err.Error()
+	a := strings.Index(msg, "example/a:latest ("+ErrImagePullDenied.Error())
+	b := strings.Index(msg, "example/b:latest ("+errImageNotPresent.Error())
+	if a < 0 || b < 0 || a > b {
+		t.Errorf("unexpected message: %v", msg)
+	}
+}
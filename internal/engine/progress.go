@@ -0,0 +1,187 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/stateupdater"
+	report "github.com/adevinta/vulcan-report"
+	"golang.org/x/term"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+const (
+	// progressLogInterval is the time between progress logs.
+	progressLogInterval = 15 * time.Second
+
+	// progressTTYInterval is the time between progress updates
+	// when the output is attached to a terminal.
+	progressTTYInterval = time.Second
+)
+
+// progress represents the progress of a scan.
+type progress struct {
+	// Total is the total number of checks.
+	Total int
+
+	// Running is the number of checks that have reported a
+	// non-terminal status.
+	Running int
+
+	// Finished is the number of checks that finished
+	// successfully.
+	Finished int
+
+	// Failed is the number of checks that ended with a terminal
+	// status other than FINISHED.
+	Failed int
+
+	// AvgDuration is the average duration of the completed
+	// checks.
+	AvgDuration time.Duration
+
+	// ETA is the estimated remaining time. It is zero if it
+	// cannot be estimated yet.
+	ETA time.Duration
+}
+
+// mkProgress computes the progress of a scan with the specified
+// total number of checks from the check data of the reports. parallel is the
+// number of checks that can run concurrently and is used to estimate
+// the remaining time.
+func mkProgress(checkData map[string]report.CheckData, total, parallel int) progress {
+	p := progress{Total: total}
+
+	var elapsed time.Duration
+	for _, r := range checkData {
+		if _, ok := stateupdater.TerminalStatuses[r.Status]; !ok {
+			p.Running++
+			continue
+		}
+
+		if r.Status == stateupdater.StatusFinished {
+			p.Finished++
+		} else {
+			p.Failed++
+		}
+
+		if !r.StartTime.IsZero() && r.EndTime.After(r.StartTime) {
+			elapsed += r.EndTime.Sub(r.StartTime)
+		}
+	}
+
+	completed := p.Finished + p.Failed
+	if completed == 0 {
+		return p
+	}
+	p.AvgDuration = elapsed / time.Duration(completed)
+
+	if parallel < 1 {
+		parallel = 1
+	}
+	remaining := total - completed
+	if remaining > 0 {
+		rounds := (remaining + parallel - 1) / parallel
+		p.ETA = p.AvgDuration * time.Duration(rounds)
+	}
+	return p
+}
+
+// String returns a single-line human-readable representation of the
+// progress.
+func (p progress) String() string {
+	eta := "unknown"
+	if p.ETA > 0 || p.Finished+p.Failed == p.Total {
+		eta = p.ETA.Round(time.Second).String()
+	}
+	return fmt.Sprintf("checks: %v/%v completed, %v running, %v failed, avg duration %v, eta %v",
+		p.Finished+p.Failed, p.Total, p.Running, p.Failed, p.AvgDuration.Round(time.Second), eta)
+}
+
+// stderrIsTerminal reports whether stderr is attached to a terminal.
+// It is set by tests to mock the terminal.
+var stderrIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// progressTTY returns the writer used to render the progress as a
+// single updating line. The line is only rendered if the logs, which
+// are written to stderr, use the text format, stderr is attached to a
+// terminal and escape sequences are not disabled with the NO_COLOR
+// environment variable or a "dumb" terminal. Otherwise, it returns
+// nil.
+func progressTTY(format config.LogFormat) io.Writer {
+	if format != config.LogFormatText || !stderrIsTerminal() {
+		return nil
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return nil
+	}
+	return os.Stderr
+}
+
+// progressReporter periodically reports the progress of a scan.
+type progressReporter struct {
+	logger   *slog.Logger
+	rs       *reportStore
+	total    int
+	parallel int
+	tty      io.Writer
+}
+
+// newProgressReporter returns a [progressReporter] that computes the
+// progress from the reports stored in rs. If tty is not nil, the
+// progress is rendered into it as a single updating line. Otherwise,
+// it is logged using the provided logger. See [progressTTY].
+func newProgressReporter(logger *slog.Logger, rs *reportStore, total, parallel int, tty io.Writer) *progressReporter {
+	return &progressReporter{
+		logger:   logger,
+		rs:       rs,
+		total:    total,
+		parallel: parallel,
+		tty:      tty,
+	}
+}
+
+// Run reports the progress until done is closed.
+func (pr *progressReporter) Run(done <-chan struct{}) {
+	interval := progressLogInterval
+	if pr.tty != nil {
+		interval = progressTTYInterval
+	}
+
+	for {
+		select {
+		case <-done:
+			if pr.tty != nil {
+				fmt.Fprintf(pr.tty, "\r\033[K%v\n", pr.progress())
+			}
+			return
+		case <-time.After(interval):
+			p := pr.progress()
+			if pr.tty != nil {
+				fmt.Fprintf(pr.tty, "\r\033[K%v", p)
+				break
+			}
+			pr.logger.Info("scan progress",
+				"total", p.Total,
+				"running", p.Running,
+				"finished", p.Finished,
+				"failed", p.Failed,
+				"avg_duration", p.AvgDuration.Round(time.Second),
+				"eta", p.ETA.Round(time.Second),
+			)
+		}
+	}
+}
+
+// progress returns the current progress.
+func (pr *progressReporter) progress() progress {
+	return mkProgress(pr.rs.CheckData(), pr.total, pr.parallel)
+}
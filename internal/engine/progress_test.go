@@ -0,0 +1,185 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestMkProgress(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		checkData map[string]report.CheckData
+		total     int
+		parallel  int
+		want      progress
+	}{
+		{
+			name:      "no reports",
+			checkData: nil,
+			total:     4,
+			parallel:  2,
+			want:      progress{Total: 4},
+		},
+		{
+			name: "running checks",
+			checkData: map[string]report.CheckData{
+				"check1": {Status: "RUNNING", StartTime: start},
+				"check2": {Status: "RUNNING", StartTime: start},
+			},
+			total:    4,
+			parallel: 2,
+			want:     progress{Total: 4, Running: 2},
+		},
+		{
+			name: "completed checks",
+			checkData: map[string]report.CheckData{
+				"check1": {Status: "FINISHED", StartTime: start, EndTime: start.Add(1 * time.Minute)},
+				"check2": {Status: "FAILED", StartTime: start, EndTime: start.Add(3 * time.Minute)},
+				"check3": {Status: "RUNNING", StartTime: start},
+			},
+			total:    7,
+			parallel: 2,
+			want: progress{
+				Total:       7,
+				Running:     1,
+				Finished:    1,
+				Failed:      1,
+				AvgDuration: 2 * time.Minute,
+				ETA:         6 * time.Minute,
+			},
+		},
+		{
+			name: "all completed",
+			checkData: map[string]report.CheckData{
+				"check1": {Status: "FINISHED", StartTime: start, EndTime: start.Add(1 * time.Minute)},
+				"check2": {Status: "TIMEOUT", StartTime: start, EndTime: start.Add(3 * time.Minute)},
+			},
+			total:    2,
+			parallel: 0,
+			want: progress{
+				Total:       2,
+				Finished:    1,
+				Failed:      1,
+				AvgDuration: 2 * time.Minute,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mkProgress(tt.checkData, tt.total, tt.parallel)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("progress mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestProgress_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress progress
+		want     string
+	}{
+		{
+			name:     "unknown eta",
+			progress: progress{Total: 4, Running: 2},
+			want:     "checks: 0/4 completed, 2 running, 0 failed, avg duration 0s, eta unknown",
+		},
+		{
+			name:     "eta",
+			progress: progress{Total: 7, Running: 1, Finished: 1, Failed: 1, AvgDuration: 2 * time.Minute, ETA: 6 * time.Minute},
+			want:     "checks: 2/7 completed, 1 running, 1 failed, avg duration 2m0s, eta 6m0s",
+		},
+		{
+			name:     "completed",
+			progress: progress{Total: 2, Finished: 2, AvgDuration: time.Minute},
+			want:     "checks: 2/2 completed, 0 running, 0 failed, avg duration 1m0s, eta 0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.progress.String(); got != tt.want {
+				t.Errorf("unexpected string: got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressTTY(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   config.LogFormat
+		terminal bool
+		noColor  string
+		term     string
+		want     bool
+	}{
+		{
+			name:     "text logs on terminal",
+			format:   config.LogFormatText,
+			terminal: true,
+			term:     "xterm",
+			want:     true,
+		},
+		{
+			name:     "JSON logs",
+			format:   config.LogFormatJSON,
+			terminal: true,
+			term:     "xterm",
+			want:     false,
+		},
+		{
+			name:     "not a terminal",
+			format:   config.LogFormatText,
+			terminal: false,
+			term:     "xterm",
+			want:     false,
+		},
+		{
+			name:     "NO_COLOR",
+			format:   config.LogFormatText,
+			terminal: true,
+			noColor:  "1",
+			term:     "xterm",
+			want:     false,
+		},
+		{
+			name:     "dumb terminal",
+			format:   config.LogFormatText,
+			terminal: true,
+			term:     "dumb",
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStderrIsTerminal := stderrIsTerminal
+			defer func() { stderrIsTerminal = oldStderrIsTerminal }()
+
+			stderrIsTerminal = func() bool { return tt.terminal }
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("TERM", tt.term)
+
+			got := progressTTY(tt.format)
+			if (got != nil) != tt.want {
+				t.Fatalf("unexpected writer: got: %v, want TTY: %v", got, tt.want)
+			}
+			if got != nil && got != os.Stderr {
+				t.Errorf("unexpected writer: got: %v, want: %v", got, os.Stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/go-units"
+)
+
+// Status of the layers reported by the container runtime when
+// pulling an image.
+const (
+	layerPullingFSLayer    = "Pulling fs layer"
+	layerWaiting           = "Waiting"
+	layerDownloading       = "Downloading"
+	layerVerifyingChecksum = "Verifying Checksum"
+	layerDownloadComplete  = "Download complete"
+	layerExtracting        = "Extracting"
+	layerPullComplete      = "Pull complete"
+	layerAlreadyExists     = "Already exists"
+)
+
+// pullProgress represents the progress of an image pull.
+type pullProgress struct {
+	// Image is the reference of the pulled image.
+	Image string
+
+	// Layers is the number of layers of the image.
+	Layers int
+
+	// LayersDone is the number of layers that have been pulled
+	// or were already present.
+	LayersDone int
+
+	// Current is the number of bytes downloaded.
+	Current int64
+
+	// Total is the number of bytes to download. It only includes
+	// the layers whose size is already known.
+	Total int64
+
+	// ETA is the estimated remaining time. It is zero if it
+	// cannot be estimated yet.
+	ETA time.Duration
+}
+
+// String returns a single-line human-readable representation of the
+// pull progress.
+func (p pullProgress) String() string {
+	eta := "unknown"
+	if p.ETA > 0 || (p.Layers > 0 && p.LayersDone == p.Layers) {
+		eta = p.ETA.Round(time.Second).String()
+	}
+	return fmt.Sprintf("pulling %v: %v/%v layers, %v/%v, eta %v",
+		p.Image, p.LayersDone, p.Layers, units.HumanSize(float64(p.Current)), units.HumanSize(float64(p.Total)), eta)
+}
+
+// layerProgress represents the progress of the pull of an image
+// layer.
+type layerProgress struct {
+	current int64
+	total   int64
+	done    bool
+}
+
+// pullTracker tracks the progress of an image pull from the messages
+// sent by the container runtime.
+type pullTracker struct {
+	image  string
+	start  time.Time
+	layers map[string]layerProgress
+}
+
+// newPullTracker returns a [pullTracker] for the specified image. It
+// considers that the pull starts at the provided time.
+func newPullTracker(image string, start time.Time) *pullTracker {
+	return &pullTracker{
+		image:  image,
+		start:  start,
+		layers: make(map[string]layerProgress),
+	}
+}
+
+// update updates the progress with the provided message. The messages
+// that do not refer to a layer are ignored.
+func (t *pullTracker) update(msg jsonmessage.JSONMessage) {
+	if msg.ID == "" {
+		return
+	}
+
+	lp := t.layers[msg.ID]
+	switch msg.Status {
+	case layerPullingFSLayer, layerWaiting, layerVerifyingChecksum, layerExtracting:
+	case layerDownloading:
+		if msg.Progress != nil {
+			lp.current = msg.Progress.Current
+			lp.total = msg.Progress.Total
+		}
+	case layerDownloadComplete:
+		lp.current = lp.total
+	case layerPullComplete, layerAlreadyExists:
+		lp.current = lp.total
+		lp.done = true
+	default:
+		return
+	}
+
+	t.layers[msg.ID] = lp
+}
+
+// progress returns the progress of the pull at the specified time.
+func (t *pullTracker) progress(now time.Time) pullProgress {
+	p := pullProgress{Image: t.image, Layers: len(t.layers)}
+	for _, lp := range t.layers {
+		if lp.done {
+			p.LayersDone++
+		}
+		p.Current += lp.current
+		p.Total += lp.total
+	}
+
+	elapsed := now.Sub(t.start)
+	if p.Current > 0 && p.Total > p.Current && elapsed > 0 {
+		rate := float64(p.Current) / elapsed.Seconds()
+		p.ETA = time.Duration(float64(p.Total-p.Current) / rate * float64(time.Second))
+	}
+	return p
+}
+
+// pullReporter reports the progress of image pulls.
+type pullReporter struct {
+	logger   *slog.Logger
+	tty      io.Writer
+	interval time.Duration
+	now      func() time.Time
+}
+
+// newPullReporter returns a [pullReporter]. If tty is not nil, the
+// progress is rendered into it as a single updating line. Otherwise,
+// it is logged using the provided logger. See [progressTTY].
+func newPullReporter(logger *slog.Logger, tty io.Writer) *pullReporter {
+	pr := &pullReporter{
+		logger:   logger,
+		interval: progressLogInterval,
+		now:      time.Now,
+	}
+	if tty != nil {
+		pr.tty = tty
+		pr.interval = progressTTYInterval
+	}
+	return pr
+}
+
+// Read reads the response of the pull of the specified image from r
+// until EOF and reports its progress. It returns the final progress
+// of the pull. If the container runtime reports an error, it is
+// returned.
+func (pr *pullReporter) Read(image string, r io.Reader) (pullProgress, error) {
+	tracker := newPullTracker(image, pr.now())
+	last := pr.now()
+
+	dec := json.NewDecoder(r)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return pullProgress{}, fmt.Errorf("decode message: %w", err)
+		}
+		if msg.Error != nil {
+			return pullProgress{}, msg.Error
+		}
+		tracker.update(msg)
+
+		if now := pr.now(); now.Sub(last) >= pr.interval {
+			pr.report(tracker.progress(now), false)
+			last = now
+		}
+	}
+
+	p := tracker.progress(pr.now())
+	pr.report(p, true)
+	return p, nil
+}
+
+// report reports the provided progress. final specifies whether the
+// pull has finished.
+func (pr *pullReporter) report(p pullProgress, final bool) {
+	if pr.tty != nil {
+		if final {
+			fmt.Fprintf(pr.tty, "\r\033[K%v\n", p)
+		} else {
+			fmt.Fprintf(pr.tty, "\r\033[K%v", p)
+		}
+		return
+	}
+
+	msg := "image pull progress"
+	if final {
+		msg = "image pull finished"
+	}
+	pr.logger.Info(msg,
+		"image", p.Image,
+		"layers", p.Layers,
+		"layers_done", p.LayersDone,
+		"bytes", p.Current,
+		"total_bytes", p.Total,
+		"eta", p.ETA.Round(time.Second),
+	)
+}
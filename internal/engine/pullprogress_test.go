@@ -0,0 +1,135 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPullTracker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tracker := newPullTracker("example/image:latest", start)
+	for _, msg := range []jsonmessage.JSONMessage{
+		{ID: "latest", Status: "Pulling from example/image"},
+		{ID: "layer1", Status: layerAlreadyExists},
+		{ID: "layer2", Status: layerPullingFSLayer},
+		{ID: "layer3", Status: layerPullingFSLayer},
+		{ID: "layer2", Status: layerDownloading, Progress: &jsonmessage.JSONProgress{Current: 50, Total: 100}},
+		{ID: "layer3", Status: layerDownloading, Progress: &jsonmessage.JSONProgress{Current: 100, Total: 300}},
+		{ID: "layer2", Status: layerDownloadComplete},
+		{ID: "layer2", Status: layerPullComplete},
+		{Status: "Digest: sha256:0123"},
+	} {
+		tracker.update(msg)
+	}
+
+	got := tracker.progress(start.Add(10 * time.Second))
+	want := pullProgress{
+		Image:      "example/image:latest",
+		Layers:     3,
+		LayersDone: 2,
+		Current:    200,
+		Total:      400,
+		ETA:        10 * time.Second,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("progress mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestPullProgress_String(t *testing.T) {
+	tests := []struct {
+		name string
+		p    pullProgress
+		want string
+	}{
+		{
+			name: "unknown eta",
+			p:    pullProgress{Image: "image", Layers: 2},
+			want: "pulling image: 0/2 layers, 0B/0B, eta unknown",
+		},
+		{
+			name: "in progress",
+			p:    pullProgress{Image: "image", Layers: 2, LayersDone: 1, Current: 1000, Total: 3000, ETA: 90 * time.Second},
+			want: "pulling image: 1/2 layers, 1kB/3kB, eta 1m30s",
+		},
+		{
+			name: "done",
+			p:    pullProgress{Image: "image", Layers: 2, LayersDone: 2, Current: 3000, Total: 3000},
+			want: "pulling image: 2/2 layers, 3kB/3kB, eta 0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.String(); got != tt.want {
+				t.Errorf("unexpected string: want: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPullReporter_Read(t *testing.T) {
+	const stream = `{"status":"Pulling from example/image","id":"latest"}
+{"status":"Pulling fs layer","id":"layer1"}
+{"status":"Downloading","progressDetail":{"current":10,"total":20},"id":"layer1"}
+{"status":"Pull complete","id":"layer1"}
+{"status":"Status: Downloaded newer image for example/image:latest"}
+`
+
+	var buf bytes.Buffer
+	pr := &pullReporter{
+		logger:   slog.New(slog.NewTextHandler(&buf, nil)),
+		interval: time.Hour,
+		now:      time.Now,
+	}
+
+	got, err := pr.Read("example/image:latest", strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := pullProgress{
+		Image:      "example/image:latest",
+		Layers:     1,
+		LayersDone: 1,
+		Current:    20,
+		Total:      20,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("progress mismatch (-want +got):\n%v", diff)
+	}
+	if !strings.Contains(buf.String(), "image pull finished") {
+		t.Errorf("final progress not reported:\n%v", buf.String())
+	}
+}
+
+func TestPullReporter_Read_error(t *testing.T) {
+	const stream = `{"status":"Pulling from example/image","id":"latest"}
+{"errorDetail":{"message":"toomanyrequests: You have reached your pull rate limit"},"error":"toomanyrequests: You have reached your pull rate limit"}
+`
+
+	pr := &pullReporter{
+		logger:   slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+		interval: time.Hour,
+		now:      time.Now,
+	}
+
+	_, err := pr.Read("example/image:latest", strings.NewReader(stream))
+	var jerr *jsonmessage.JSONError
+	if !errors.As(err, &jerr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !isPullFailure(err) {
+		t.Errorf("error is not a pull failure: %v", err)
+	}
+}
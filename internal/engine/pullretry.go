@@ -0,0 +1,99 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/jobrunner"
+	"github.com/adevinta/vulcan-agent/stateupdater"
+	report "github.com/adevinta/vulcan-report"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// retryPullFailures runs again the checks of the provided report that
+// failed because their image could not be pulled. The checks are
+// retried up to the configured number of times, doubling the time
+// between retries. If a registry mirror is configured, the images of
+// the retried checks are pulled from it. The reports of the retried
+// checks replace the original ones, so the checks that keep failing
+// are reported with the cause of the last pull failure. The metrics
+// of the retries are merged into the metrics of the scan.
+func (eng Engine) retryPullFailures(rep Report, jobs []jobrunner.Job, envs map[string]map[string]string, unmapped map[string]bool) Report {
+	interval := eng.pullRetryInterval
+	retries := 0
+	for attempt := 1; attempt <= eng.pullRetries; attempt++ {
+		var retry []jobrunner.Job
+		for _, job := range jobs {
+			if r, ok := rep[job.CheckID]; ok && isPullFailureReport(r) {
+				retry = append(retry, job)
+			}
+		}
+		if len(retry) == 0 {
+			break
+		}
+
+		eng.logger.Warn("retrying checks that could not pull their image",
+			"checks", len(retry), "attempt", attempt, "interval", interval)
+		time.Sleep(interval)
+		interval *= 2
+
+		if eng.pullMirror != "" {
+			var err error
+			if retry, err = mirrorJobs(retry, []config.RegistryMirror{{Registry: "*", Mirror: eng.pullMirror}}); err != nil {
+				eng.logger.Warn("could not retry checks", "attempt", attempt, "err", err)
+				break
+			}
+		}
+
+		retried, err := eng.runAgent(retry, envs, unmapped)
+		if err != nil {
+			eng.logger.Warn("could not retry checks", "attempt", attempt, "err", err)
+			break
+		}
+		maps.Copy(rep, retried)
+		retries += len(retry)
+	}
+	eng.scanMetrics.add("pull_retries", retries)
+	return rep
+}
+
+// pullImageRetry pulls the specified image like [Engine.pullImage]
+// and retries the pull if it fails because of a transient error. If a
+// registry mirror is configured, the retries pull the image from it.
+func (eng Engine) pullImageRetry(ctx context.Context, ref string) (string, error) {
+	id, err := eng.pullImage(ctx, ref)
+	if !isPullFailure(err) || eng.pullRetries == 0 {
+		return id, err
+	}
+
+	retryRef := ref
+	if eng.pullMirror != "" {
+		mirrored, merr := mirrorImage(ref, eng.pullMirror)
+		if merr != nil {
+			return "", fmt.Errorf("mirror image: %w", merr)
+		}
+		retryRef = mirrored
+	}
+
+	interval := eng.pullRetryInterval
+	for attempt := 1; attempt <= eng.pullRetries && isPullFailure(err); attempt++ {
+		eng.logger.Warn("retrying image pull", "image", retryRef, "attempt", attempt, "interval", interval, "err", err)
+		time.Sleep(interval)
+		interval *= 2
+
+		id, err = eng.pullImage(ctx, retryRef)
+	}
+	return id, err
+}
+
+// isPullFailureReport reports whether the provided report belongs to
+// a check that failed because its image could not be pulled.
+func isPullFailureReport(r report.Report) bool {
+	return r.Status == stateupdater.StatusFailed && isPullFailure(errors.New(r.Error))
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/adevinta/vulcan-agent/stateupdater"
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestIsPullFailureReport(t *testing.T) {
+	tests := []struct {
+		name string
+		r    report.Report
+		want bool
+	}{
+		{
+			name: "pull failure",
+			r: report.Report{
+				CheckData:  report.CheckData{Status: stateupdater.StatusFailed},
+				ResultData: report.ResultData{Error: "toomanyrequests: You have reached your pull rate limit"},
+			},
+			want: true,
+		},
+		{
+			name: "other failure",
+			r: report.Report{
+				CheckData:  report.CheckData{Status: stateupdater.StatusFailed},
+				ResultData: report.ResultData{Error: "exit code 1"},
+			},
+			want: false,
+		},
+		{
+			name: "finished",
+			r: report.Report{
+				CheckData:  report.CheckData{Status: stateupdater.StatusFinished},
+				ResultData: report.ResultData{Error: "manifest unknown"},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPullFailureReport(tt.r); got != tt.want {
+				t.Errorf("unexpected result: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
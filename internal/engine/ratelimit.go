@@ -0,0 +1,148 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// rateLimitOption is the name of the check option used to pass the
+// rate limit of the target to the checks.
+const rateLimitOption = "rate_limit"
+
+// rateLimitOptionValue returns the value of the [rateLimitOption]
+// check option corresponding to the provided rate limit.
+func rateLimitOptionValue(rl config.RateLimit) map[string]any {
+	return map[string]any{
+		"requests_per_second": rl.RequestsPerSecond,
+		"concurrency":         rl.Concurrency,
+		"enforce":             rl.Enforce,
+	}
+}
+
+// optionRateLimit returns the rate limit in the [rateLimitOption]
+// check option. The returned bool is false if the option is missing
+// or invalid.
+func optionRateLimit(opts map[string]any) (config.RateLimit, bool) {
+	v, ok := opts[rateLimitOption].(map[string]any)
+	if !ok {
+		return config.RateLimit{}, false
+	}
+
+	var rl config.RateLimit
+	switch rps := v["requests_per_second"].(type) {
+	case float64:
+		rl.RequestsPerSecond = rps
+	case int:
+		rl.RequestsPerSecond = float64(rps)
+	}
+	rl.Concurrency = optionInt(v, "concurrency")
+	rl.Enforce, _ = v["enforce"].(bool)
+	return rl, true
+}
+
+// limitListener is a [net.Listener] that limits the rate at which
+// connections are accepted and the number of concurrent connections.
+type limitListener struct {
+	net.Listener
+
+	interval  time.Duration
+	sem       chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// mu protects next.
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newLimitListener returns a [limitListener] that wraps l and
+// enforces the provided rate limit. Connections are used as an
+// approximation of requests.
+func newLimitListener(l net.Listener, rl config.RateLimit) *limitListener {
+	ll := &limitListener{
+		Listener: l,
+		done:     make(chan struct{}),
+	}
+	if rl.RequestsPerSecond > 0 {
+		ll.interval = time.Duration(float64(time.Second) / rl.RequestsPerSecond)
+	}
+	if rl.Concurrency > 0 {
+		ll.sem = make(chan struct{}, rl.Concurrency)
+	}
+	return ll
+}
+
+// Accept waits until the limits allow a new connection and then
+// accepts it.
+func (ll *limitListener) Accept() (net.Conn, error) {
+	if ll.sem != nil {
+		select {
+		case ll.sem <- struct{}{}:
+		case <-ll.done:
+			return nil, net.ErrClosed
+		}
+	}
+
+	if d := ll.delay(time.Now()); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ll.done:
+			ll.release()
+			return nil, net.ErrClosed
+		}
+	}
+
+	conn, err := ll.Listener.Accept()
+	if err != nil {
+		ll.release()
+		return nil, err
+	}
+	return &limitConn{Conn: conn, release: ll.release}, nil
+}
+
+// delay returns the time to wait at the provided time before
+// accepting a new connection and reserves the corresponding slot.
+func (ll *limitListener) delay(now time.Time) time.Duration {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	if ll.next.Before(now) {
+		ll.next = now
+	}
+	d := ll.next.Sub(now)
+	ll.next = ll.next.Add(ll.interval)
+	return d
+}
+
+// release frees a concurrency slot.
+func (ll *limitListener) release() {
+	if ll.sem != nil {
+		<-ll.sem
+	}
+}
+
+// Close closes the listener and unblocks any blocked
+// [limitListener.Accept] call.
+func (ll *limitListener) Close() error {
+	ll.closeOnce.Do(func() { close(ll.done) })
+	return ll.Listener.Close()
+}
+
+// limitConn is a connection accepted by a [limitListener]. It frees
+// its concurrency slot when it is closed.
+type limitConn struct {
+	net.Conn
+	release   func()
+	closeOnce sync.Once
+}
+
+// Close closes the connection.
+func (c *limitConn) Close() error {
+	c.closeOnce.Do(c.release)
+	return c.Conn.Close()
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestOptionRateLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   map[string]any
+		want   config.RateLimit
+		wantOK bool
+	}{
+		{
+			name: "valid",
+			opts: map[string]any{
+				"rate_limit": rateLimitOptionValue(config.RateLimit{
+					RequestsPerSecond: 0.5,
+					Concurrency:       2,
+					Enforce:           true,
+				}),
+			},
+			want: config.RateLimit{
+				RequestsPerSecond: 0.5,
+				Concurrency:       2,
+				Enforce:           true,
+			},
+			wantOK: true,
+		},
+		{
+			name:   "missing",
+			opts:   map[string]any{},
+			want:   config.RateLimit{},
+			wantOK: false,
+		},
+		{
+			name: "invalid",
+			opts: map[string]any{
+				"rate_limit": "invalid",
+			},
+			want:   config.RateLimit{},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Check options are sent to the checks encoded
+			// as JSON.
+			b, err := json.Marshal(tt.opts)
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+			var opts map[string]any
+			if err := json.Unmarshal(b, &opts); err != nil {
+				t.Fatalf("unmarshal error: %v", err)
+			}
+
+			got, ok := optionRateLimit(opts)
+			if ok != tt.wantOK {
+				t.Errorf("unexpected ok: got: %v, want: %v", ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("rate limit mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestLimitListener_delay(t *testing.T) {
+	ll := newLimitListener(nil, config.RateLimit{RequestsPerSecond: 2})
+
+	now := time.Now()
+	want := []time.Duration{0, 500 * time.Millisecond, time.Second}
+	for i, w := range want {
+		if got := ll.delay(now); got != w {
+			t.Errorf("unexpected delay %v: got: %v, want: %v", i, got, w)
+		}
+	}
+
+	// Slots are not accumulated while the listener is idle.
+	if got := ll.delay(now.Add(time.Minute)); got != 0 {
+		t.Errorf("unexpected delay after idle period: %v", got)
+	}
+}
+
+func TestLimitListener_concurrency(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	ll := newLimitListener(l, config.RateLimit{Concurrency: 1})
+	defer ll.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		for {
+			conn, err := ll.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("dial error: %v", err)
+		}
+		defer conn.Close()
+	}
+
+	first := <-accepted
+
+	select {
+	case <-accepted:
+		t.Fatal("concurrency limit exceeded")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Close()
+
+	select {
+	case <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not accepted after releasing slot")
+	}
+}
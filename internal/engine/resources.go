@@ -0,0 +1,182 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/adevinta/lava/internal/containers"
+)
+
+// checkUsage contains the resource usage metrics of a check.
+type checkUsage struct {
+	// Checktype is the name of the checktype.
+	Checktype string `json:"checktype"`
+
+	// MaxMemory is the maximum memory in bytes used by the check
+	// container. The page cache that can be reclaimed is not
+	// included.
+	MaxMemory uint64 `json:"max_memory"`
+
+	// CPU is the CPU time in seconds consumed by the check
+	// container.
+	CPU float64 `json:"cpu_seconds"`
+}
+
+// containerStats contains the fields of the container stats returned
+// by the Docker API that are used to compute the resource usage of
+// the checks.
+type containerStats struct {
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"`
+		} `json:"cpu_usage"`
+	} `json:"cpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
+
+// memory returns the memory used by the container without the
+// inactive page cache, like the Docker CLI does.
+func (s containerStats) memory() uint64 {
+	usage := s.MemoryStats.Usage
+
+	// cgroup v1 reports total_inactive_file while cgroup v2
+	// reports inactive_file.
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if v, ok := s.MemoryStats.Stats[key]; ok {
+			if v < usage {
+				return usage - v
+			}
+			return usage
+		}
+	}
+	return usage
+}
+
+// resourceMonitor collects the resource usage of the check
+// containers of a scan using the Docker API.
+type resourceMonitor struct {
+	cli    containers.DockerdClient
+	logger *slog.Logger
+	scanID string
+
+	// mu protects the fields below.
+	mu     sync.Mutex
+	checks map[string]checkUsage
+}
+
+// newResourceMonitor returns a [resourceMonitor] that collects the
+// resource usage of the check containers of the specified scan.
+func newResourceMonitor(logger *slog.Logger, cli containers.DockerdClient, scanID string) *resourceMonitor {
+	return &resourceMonitor{
+		cli:    cli,
+		logger: logger,
+		scanID: scanID,
+		checks: make(map[string]checkUsage),
+	}
+}
+
+// Monitor listens for the start of the check containers of the scan
+// and collects their stats until they exit. It returns when done is
+// closed.
+func (rm *resourceMonitor) Monitor(done <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs, errs := rm.cli.Events(ctx, events.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("event", string(events.ActionStart)),
+			filters.Arg("label", containers.LabelScanID+"="+rm.scanID),
+		),
+	})
+
+	for {
+		select {
+		case <-done:
+			return
+		case err := <-errs:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				rm.logger.Warn("could not monitor check resources", "err", err)
+			}
+			return
+		case msg := <-msgs:
+			checkID := msg.Actor.Attributes[containers.LabelCheckID]
+			if checkID == "" {
+				continue
+			}
+			checktype := msg.Actor.Attributes[containers.LabelChecktype]
+			go rm.track(ctx, msg.Actor.ID, checkID, checktype)
+		}
+	}
+}
+
+// track collects the stats of the specified container until it
+// exits or ctx is canceled.
+func (rm *resourceMonitor) track(ctx context.Context, containerID, checkID, checktype string) {
+	resp, err := rm.cli.ContainerStats(ctx, containerID, true)
+	if err != nil {
+		if ctx.Err() == nil {
+			rm.logger.Debug("could not get container stats", "checkID", checkID, "err", err)
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	err = readContainerStats(resp.Body, func(cr checkUsage) {
+		cr.Checktype = checktype
+
+		rm.mu.Lock()
+		rm.checks[checkID] = cr
+		rm.mu.Unlock()
+	})
+	if err != nil && ctx.Err() == nil {
+		rm.logger.Debug("could not read container stats", "checkID", checkID, "err", err)
+	}
+}
+
+// readContainerStats reads the stream of container stats from r until
+// EOF. After every sample, it calls fn with the resource usage
+// accumulated so far.
+func readContainerStats(r io.Reader, fn func(checkUsage)) error {
+	var cr checkUsage
+
+	dec := json.NewDecoder(r)
+	for {
+		var s containerStats
+		if err := dec.Decode(&s); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("decode stats: %w", err)
+		}
+
+		// The stats of a stopped container are zero, so the
+		// maximum values are kept.
+		cr.MaxMemory = max(cr.MaxMemory, s.memory())
+		cr.CPU = max(cr.CPU, float64(s.CPUStats.CPUUsage.TotalUsage)/1e9)
+		fn(cr)
+	}
+}
+
+// Collect adds the resource usage metrics to the provided
+// [scanMetrics]. The metrics of the checks whose container did not
+// start are not collected.
+func (rm *resourceMonitor) Collect(sm *scanMetrics) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	sm.addResources(rm.checks)
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadContainerStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      string
+		want       checkUsage
+		wantNilErr bool
+	}{
+		{
+			name: "cgroup v2",
+			stats: `{"cpu_stats":{"cpu_usage":{"total_usage":1500000000}},"memory_stats":{"usage":4096,"stats":{"inactive_file":1024}}}` + "\n" +
+				`{"cpu_stats":{"cpu_usage":{"total_usage":2500000000}},"memory_stats":{"usage":10240,"stats":{"inactive_file":2048}}}` + "\n" +
+				`{"cpu_stats":{"cpu_usage":{"total_usage":3000000000}},"memory_stats":{"usage":6144,"stats":{"inactive_file":2048}}}` + "\n",
+			want:       checkUsage{MaxMemory: 8192, CPU: 3},
+			wantNilErr: true,
+		},
+		{
+			name:       "cgroup v1",
+			stats:      `{"cpu_stats":{"cpu_usage":{"total_usage":500000000}},"memory_stats":{"usage":4096,"stats":{"total_inactive_file":1024}}}`,
+			want:       checkUsage{MaxMemory: 3072, CPU: 0.5},
+			wantNilErr: true,
+		},
+		{
+			name: "stopped container",
+			stats: `{"cpu_stats":{"cpu_usage":{"total_usage":2000000000}},"memory_stats":{"usage":4096}}` + "\n" +
+				`{"cpu_stats":{},"memory_stats":{}}` + "\n",
+			want:       checkUsage{MaxMemory: 4096, CPU: 2},
+			wantNilErr: true,
+		},
+		{
+			name:       "invalid stats",
+			stats:      `{"cpu_stats":`,
+			want:       checkUsage{},
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got checkUsage
+			err := readContainerStats(strings.NewReader(tt.stats), func(cr checkUsage) {
+				got = cr
+			})
+			if (err == nil) != tt.wantNilErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected resources: want: %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
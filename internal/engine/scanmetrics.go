@@ -0,0 +1,138 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/adevinta/lava/internal/metrics"
+)
+
+// scanMetrics accumulates the metrics of a scan. A scan may run
+// several Vulcan agents, one per stage and one per pull retry, so
+// the metrics of every agent are merged and collected once when the
+// scan finishes.
+type scanMetrics struct {
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// counts contains the counters of the scan indexed by
+	// metric name.
+	counts map[string]int
+
+	// agents is the number of agents run during the scan.
+	agents int
+
+	// startup is the accumulated startup time in seconds of the
+	// agents. hasStartup reports whether any agent ran a check.
+	startup    float64
+	hasStartup bool
+
+	checks    map[string]checkTiming
+	pulls     map[string]float64
+	resources map[string]checkUsage
+
+	// watchdog contains the watchdog metrics. It is nil if the
+	// watchdog is disabled.
+	watchdog *watchdogMetrics
+}
+
+// watchdogMetrics contains the metrics of the watchdog.
+type watchdogMetrics struct {
+	peak      hostUsage
+	throttled int
+	skipped   int
+}
+
+// newScanMetrics returns an empty [scanMetrics].
+func newScanMetrics() *scanMetrics {
+	return &scanMetrics{
+		counts:    make(map[string]int),
+		checks:    make(map[string]checkTiming),
+		pulls:     make(map[string]float64),
+		resources: make(map[string]checkUsage),
+	}
+}
+
+// add adds n to the counter with the provided metric name.
+func (sm *scanMetrics) add(name string, n int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.counts[name] += n
+}
+
+// addTimings merges the timing metrics of an agent. The startup
+// times are added up. The timings of a check that was run several
+// times are the ones of the last run and, if an image was pulled
+// several times, the longest pull is kept.
+func (sm *scanMetrics) addTimings(startup float64, ran bool, checks map[string]checkTiming, pulls map[string]float64) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.agents++
+	if ran {
+		sm.startup += startup
+		sm.hasStartup = true
+	}
+	maps.Copy(sm.checks, checks)
+	for image, d := range pulls {
+		sm.pulls[image] = max(sm.pulls[image], d)
+	}
+}
+
+// addResources merges the resource usage metrics of an agent.
+func (sm *scanMetrics) addResources(checks map[string]checkUsage) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	maps.Copy(sm.resources, checks)
+}
+
+// addWatchdog merges the metrics of the watchdog of an agent. The
+// peak usage is the maximum of the peaks and the number of
+// throttled and skipped checks are added up.
+func (sm *scanMetrics) addWatchdog(wm watchdogMetrics) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.watchdog == nil {
+		sm.watchdog = &watchdogMetrics{}
+	}
+	sm.watchdog.peak = hostUsage{
+		CPU:    max(sm.watchdog.peak.CPU, wm.peak.CPU),
+		Memory: max(sm.watchdog.peak.Memory, wm.peak.Memory),
+		Disk:   max(sm.watchdog.peak.Disk, wm.peak.Disk),
+	}
+	sm.watchdog.throttled += wm.throttled
+	sm.watchdog.skipped += wm.skipped
+}
+
+// Collect records the accumulated metrics. The agent metrics are
+// not collected if no agent was run.
+func (sm *scanMetrics) Collect() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	for name, n := range sm.counts {
+		metrics.Collect(name, n)
+	}
+	if sm.hasStartup {
+		metrics.Collect("agent_startup_duration", sm.startup)
+	}
+	if sm.agents > 0 {
+		metrics.Collect("image_pull_durations", maps.Clone(sm.pulls))
+		metrics.Collect("check_timings", maps.Clone(sm.checks))
+		metrics.Collect("check_resources", maps.Clone(sm.resources))
+	}
+	if sm.watchdog != nil {
+		metrics.Collect("watchdog", map[string]any{
+			"max_cpu":          sm.watchdog.peak.CPU,
+			"max_memory":       sm.watchdog.peak.Memory,
+			"max_disk":         sm.watchdog.peak.Disk,
+			"throttled_checks": sm.watchdog.throttled,
+			"aborted_checks":   sm.watchdog.skipped,
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanMetrics(t *testing.T) {
+	sm := newScanMetrics()
+
+	// First stage.
+	sm.add("resumed_checks", 1)
+	sm.addTimings(2, true,
+		map[string]checkTiming{
+			"check1": {Checktype: "checktype1", Pull: 3},
+			"check2": {Checktype: "checktype2", Pull: 0},
+		},
+		map[string]float64{"image1": 3, "image2": 1},
+	)
+	sm.addResources(map[string]checkUsage{
+		"check1": {Checktype: "checktype1", MaxMemory: 100},
+	})
+	sm.addWatchdog(watchdogMetrics{peak: hostUsage{CPU: 50, Memory: 20, Disk: 10}, throttled: 1})
+
+	// Second stage.
+	sm.add("resumed_checks", 2)
+	sm.addTimings(1, true,
+		map[string]checkTiming{
+			"check2": {Checktype: "checktype2", Pull: 4},
+			"check3": {Checktype: "checktype3", Pull: 1},
+		},
+		map[string]float64{"image1": 2, "image3": 1},
+	)
+	sm.addResources(map[string]checkUsage{
+		"check3": {Checktype: "checktype3", MaxMemory: 200},
+	})
+	sm.addWatchdog(watchdogMetrics{peak: hostUsage{CPU: 40, Memory: 30, Disk: 10}, skipped: 2})
+
+	// Agent that did not run any check.
+	sm.addTimings(10, false, nil, nil)
+
+	if got, want := sm.counts, map[string]int{"resumed_checks": 3}; !cmp.Equal(got, want) {
+		t.Errorf("unexpected counts (-want +got):\n%v", cmp.Diff(want, got))
+	}
+
+	if !sm.hasStartup || sm.startup != 3 {
+		t.Errorf("unexpected startup: got %v (%v), want 3 (true)", sm.startup, sm.hasStartup)
+	}
+
+	wantChecks := map[string]checkTiming{
+		"check1": {Checktype: "checktype1", Pull: 3},
+		"check2": {Checktype: "checktype2", Pull: 4},
+		"check3": {Checktype: "checktype3", Pull: 1},
+	}
+	if diff := cmp.Diff(wantChecks, sm.checks); diff != "" {
+		t.Errorf("check timings mismatch (-want +got):\n%v", diff)
+	}
+
+	wantPulls := map[string]float64{"image1": 3, "image2": 1, "image3": 1}
+	if diff := cmp.Diff(wantPulls, sm.pulls); diff != "" {
+		t.Errorf("pull durations mismatch (-want +got):\n%v", diff)
+	}
+
+	wantResources := map[string]checkUsage{
+		"check1": {Checktype: "checktype1", MaxMemory: 100},
+		"check3": {Checktype: "checktype3", MaxMemory: 200},
+	}
+	if diff := cmp.Diff(wantResources, sm.resources); diff != "" {
+		t.Errorf("check resources mismatch (-want +got):\n%v", diff)
+	}
+
+	wantWatchdog := &watchdogMetrics{peak: hostUsage{CPU: 50, Memory: 30, Disk: 10}, throttled: 1, skipped: 2}
+	if diff := cmp.Diff(wantWatchdog, sm.watchdog, cmp.AllowUnexported(watchdogMetrics{})); diff != "" {
+		t.Errorf("watchdog metrics mismatch (-want +got):\n%v", diff)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/assettypes"
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/config"
+)
+
+// RunStages runs the checks of the provided stages in dependency
+// order and returns the merged report. The checktypes of the catalog
+// that do not belong to any stage run in an initial stage. If a stage
+// defines an output type, the affected resources of its findings are
+// added to the targets of the stages that depend on it. The generated
+// targets that are outside of the provided scope or are not
+// reachable are ignored. The stages are part of the same scan, so
+// they share the scan state and metrics. If there are no stages, it
+// is equivalent to [Engine.Run].
+func (eng Engine) RunStages(targets []config.Target, stages []config.Stage, scope *config.Scope) (Report, error) {
+	if len(stages) == 0 {
+		return eng.Run(targets)
+	}
+
+	sorted, err := config.SortStages(stages)
+	if err != nil {
+		return nil, fmt.Errorf("sort stages: %w", err)
+	}
+
+	assigned := make(map[string]bool)
+	for _, st := range sorted {
+		for _, ct := range st.Checktypes {
+			assigned[ct] = true
+		}
+	}
+	var unassigned []string
+	for name := range eng.catalog {
+		if !assigned[name] {
+			unassigned = append(unassigned, name)
+		}
+	}
+	if len(unassigned) > 0 {
+		slices.Sort(unassigned)
+		sorted = append([]config.Stage{{Checktypes: unassigned}}, sorted...)
+	}
+
+	eng, err = eng.beginScan()
+	if err != nil {
+		return nil, err
+	}
+
+	rep := make(Report)
+	outputs := make(map[string][]config.Target)
+	for _, st := range sorted {
+		stTargets := slices.Clone(targets)
+		for _, dep := range st.DependsOn {
+			stTargets = append(stTargets, outputs[dep]...)
+		}
+
+		seng := eng
+		seng.catalog = stageCatalog(eng.catalog, st.Checktypes)
+		seng.logger = eng.logger.With("stage", st.Name)
+
+		seng.logger.Info("running stage", "checktypes", len(seng.catalog), "targets", len(stTargets))
+
+		r, err := seng.run(stTargets)
+		if err != nil {
+			eng.endScan(false)
+			return nil, fmt.Errorf("stage %q: %w", st.Name, err)
+		}
+		maps.Copy(rep, r)
+
+		if st.OutputType != "" {
+			outputs[st.Name] = seng.stageOutputs(r, st.OutputType, scope)
+		}
+	}
+
+	if err := eng.endScan(true); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// stageCatalog returns the checktypes of catalog whose name is in
+// names. Unknown names are ignored.
+func stageCatalog(catalog checktypes.Catalog, names []string) checktypes.Catalog {
+	sub := make(checktypes.Catalog)
+	for _, name := range names {
+		if ct, ok := catalog[name]; ok {
+			sub[name] = ct
+		}
+	}
+	return sub
+}
+
+// stageOutputs returns the targets generated from the findings of
+// the provided report. The identifier of every target is the
+// affected resource of a finding and its asset type is at.
+func (eng Engine) stageOutputs(rep Report, at types.AssetType, scope *config.Scope) []config.Target {
+	var (
+		targets []config.Target
+		seen    = make(map[string]bool)
+	)
+	for _, checkID := range rep.CheckIDs() {
+		for _, vuln := range rep[checkID].Vulnerabilities {
+			ident := strings.TrimSpace(vuln.AffectedResource)
+			if ident == "" || seen[ident] {
+				continue
+			}
+			seen[ident] = true
+
+			t := config.Target{Identifier: ident, AssetType: at}
+			if scope != nil {
+				if err := scope.Check(t); err != nil {
+					eng.logger.Warn("ignoring stage output", "target", t, "err", err)
+					continue
+				}
+			}
+			if err := assettypes.CheckReachable(at, ident); err != nil && !errors.Is(err, assettypes.ErrUnsupported) {
+				eng.logger.Warn("ignoring stage output", "target", t, "err", err)
+				continue
+			}
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
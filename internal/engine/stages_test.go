@@ -0,0 +1,60 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"log/slog"
+	"testing"
+
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+	report "github.com/adevinta/vulcan-report"
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestStageCatalog(t *testing.T) {
+	catalog := checktypes.Catalog{
+		"vulcan-zap":    checkcatalog.Checktype{Name: "vulcan-zap"},
+		"vulcan-nuclei": checkcatalog.Checktype{Name: "vulcan-nuclei"},
+	}
+
+	got := stageCatalog(catalog, []string{"vulcan-zap", "vulcan-unknown"})
+	want := checktypes.Catalog{
+		"vulcan-zap": checkcatalog.Checktype{Name: "vulcan-zap"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("catalog mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestEngine_stageOutputs(t *testing.T) {
+	rep := Report{
+		"check1": report.Report{
+			CheckData: report.CheckData{CheckID: "check1", ChecktypeName: "vulcan-discovery"},
+			ResultData: report.ResultData{
+				Vulnerabilities: []report.Vulnerability{
+					{Summary: "Web service", AffectedResource: "https://www.example.com"},
+					{Summary: "Web service", AffectedResource: "https://api.example.com"},
+					{Summary: "Duplicated", AffectedResource: " https://www.example.com "},
+					{Summary: "No resource"},
+					{Summary: "Out of scope", AffectedResource: "https://www.example.org"},
+				},
+			},
+		},
+	}
+
+	scope := &config.Scope{Domains: []string{"example.com"}}
+
+	eng := Engine{logger: slog.Default()}
+	got := eng.stageOutputs(rep, types.WebAddress, scope)
+	want := []config.Target{
+		{Identifier: "https://www.example.com", AssetType: types.WebAddress},
+		{Identifier: "https://api.example.com", AssetType: types.WebAddress},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("targets mismatch (-want +got):\n%v", diff)
+	}
+}
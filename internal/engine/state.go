@@ -0,0 +1,218 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/adevinta/vulcan-agent/stateupdater"
+	report "github.com/adevinta/vulcan-report"
+)
+
+// scanState persists the reports of the finished checks of a scan
+// into a state file, so an interrupted scan can be resumed. The state
+// file contains one JSON record per line. Every record contains the
+// key of a check and its report, so an incomplete record written when
+// the scan is interrupted only affects the last check.
+type scanState struct {
+	path string
+
+	mu   sync.Mutex
+	f    *os.File
+	keys map[string]string
+
+	// reports contains the reports loaded from the state file
+	// indexed by check key.
+	reports map[string]report.Report
+}
+
+// stateRecord is a record of the state file.
+type stateRecord struct {
+	Key    string          `json:"key"`
+	Report json.RawMessage `json:"report"`
+}
+
+// openScanState opens the state file at the specified path. If
+// resume is true, the reports already stored in the state file are
+// loaded and kept in it. Otherwise, the state file is truncated.
+func openScanState(path string, resume bool) (*scanState, error) {
+	st := &scanState{
+		path:    path,
+		keys:    make(map[string]string),
+		reports: make(map[string]report.Report),
+	}
+
+	var recs []stateRecord
+	if resume {
+		var err error
+		if recs, err = st.load(); err != nil {
+			return nil, fmt.Errorf("load state: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("make state dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open state file: %w", err)
+	}
+	st.f = f
+
+	// The valid records are written back, so the records saved
+	// from now on are not appended to an incomplete one.
+	for _, rec := range recs {
+		if err := st.write(rec); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+// load reads the reports stored in the state file and returns the
+// valid records. A missing state file is not an error. Decoding
+// stops at the first malformed record, which is usually the result
+// of an interrupted write.
+func (st *scanState) load() ([]stateRecord, error) {
+	f, err := os.Open(st.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			slog.Info("no previous scan state found", "path", st.path)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open state file: %w", err)
+	}
+	defer f.Close()
+
+	var recs []stateRecord
+	dec := json.NewDecoder(f)
+	for {
+		var rec stateRecord
+		if err := dec.Decode(&rec); err != nil {
+			if err != io.EOF {
+				slog.Warn("ignoring malformed scan state record", "path", st.path, "err", err)
+			}
+			break
+		}
+
+		var r report.Report
+		if err := r.UnmarshalJSONTimeAsString(rec.Report); err != nil {
+			slog.Warn("ignoring malformed scan state report", "path", st.path, "key", rec.Key, "err", err)
+			continue
+		}
+		st.reports[rec.Key] = r
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// resumedReports returns the reports of the provided checks that
+// finished in a previous execution of the scan, indexed by check ID,
+// and the checks that must be run. It records the keys of the checks
+// that must be run, so their reports can be saved.
+func (st *scanState) resumedReports(checks []check) (resumed Report, pending []check, err error) {
+	resumed = make(Report)
+	for _, check := range checks {
+		key, err := stateKey(check)
+		if err != nil {
+			return nil, nil, fmt.Errorf("state key: %w", err)
+		}
+
+		if r, ok := st.reports[key]; ok {
+			slog.Info("resuming check results",
+				"checktype", check.checktype.Name,
+				"target", check.target.Identifier,
+			)
+			r.CheckID = check.id
+			r.Target = check.target.Identifier
+			resumed[check.id] = r
+			continue
+		}
+
+		st.mu.Lock()
+		st.keys[check.id] = key
+		st.mu.Unlock()
+		pending = append(pending, check)
+	}
+	return resumed, pending, nil
+}
+
+// Save appends the provided report to the state file if the check
+// finished successfully. The report must already reference the
+// original target of the check.
+func (st *scanState) Save(checkID string, r report.Report) error {
+	if r.Status != stateupdater.StatusFinished {
+		return nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	key, ok := st.keys[checkID]
+	if !ok {
+		return nil
+	}
+
+	content, err := r.MarshalJSONTimeAsString()
+	if err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	return st.write(stateRecord{Key: key, Report: content})
+}
+
+// write writes the provided record into the state file.
+func (st *scanState) write(rec stateRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+	if _, err := st.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the state file.
+func (st *scanState) Close() error {
+	return st.f.Close()
+}
+
+// Remove closes and removes the state file. It is called when the
+// scan completes, so the next scan starts from scratch.
+func (st *scanState) Remove() error {
+	if err := st.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("close state file: %w", err)
+	}
+	if err := os.Remove(st.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove state file: %w", err)
+	}
+	return nil
+}
+
+// stateKey returns the key that identifies the provided check across
+// executions of the same scan. It depends on the checktype, the
+// target and the options of the check.
+func stateKey(check check) (string, error) {
+	opts, err := json.Marshal(check.options)
+	if err != nil {
+		return "", fmt.Errorf("encode check options: %w", err)
+	}
+
+	h := sha256.New()
+	for _, s := range []string{check.checktype.Name, check.checktype.Image, string(check.target.AssetType), check.target.Identifier, string(opts)} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
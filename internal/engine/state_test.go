@@ -0,0 +1,144 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+	report "github.com/adevinta/vulcan-report"
+	types "github.com/adevinta/vulcan-types"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestScanState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "scan.json")
+
+	checks := []check{
+		{
+			id:        "check1",
+			checktype: checkcatalog.Checktype{Name: "vulcan-trivy", Image: "vulcansec/vulcan-trivy:1"},
+			target:    config.Target{Identifier: "alpine:latest", AssetType: types.DockerImage},
+			options:   map[string]any{"depth": 1},
+		},
+		{
+			id:        "check2",
+			checktype: checkcatalog.Checktype{Name: "vulcan-semgrep", Image: "vulcansec/vulcan-semgrep:1"},
+			target:    config.Target{Identifier: ".", AssetType: types.GitRepository},
+		},
+		{
+			id:        "check3",
+			checktype: checkcatalog.Checktype{Name: "vulcan-gitleaks", Image: "vulcansec/vulcan-gitleaks:1"},
+			target:    config.Target{Identifier: ".", AssetType: types.GitRepository},
+		},
+	}
+
+	// First execution. Only the first check finishes before the
+	// scan is interrupted.
+	st, err := openScanState(path, false)
+	if err != nil {
+		t.Fatalf("open state error: %v", err)
+	}
+	resumed, pending, err := st.resumedReports(checks)
+	if err != nil {
+		t.Fatalf("resumed reports error: %v", err)
+	}
+	if len(resumed) != 0 || len(pending) != len(checks) {
+		t.Fatalf("unexpected resumed checks: resumed: %v, pending: %v", len(resumed), len(pending))
+	}
+
+	reports := map[string]report.Report{
+		"check1": {CheckData: report.CheckData{CheckID: "check1", Target: "alpine:latest", Status: "FINISHED"}},
+		"check2": {CheckData: report.CheckData{CheckID: "check2", Target: ".", Status: "FAILED"}},
+	}
+	for checkID, r := range reports {
+		if err := st.Save(checkID, r); err != nil {
+			t.Fatalf("save error: %v", err)
+		}
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	// Second execution. The check IDs are generated again.
+	for i := range checks {
+		checks[i].id += "-resumed"
+	}
+
+	st, err = openScanState(path, true)
+	if err != nil {
+		t.Fatalf("open state error: %v", err)
+	}
+	defer st.Close()
+
+	resumed, pending, err = st.resumedReports(checks)
+	if err != nil {
+		t.Fatalf("resumed reports error: %v", err)
+	}
+
+	r, ok := resumed["check1-resumed"]
+	if len(resumed) != 1 || !ok {
+		t.Fatalf("unexpected resumed reports: %v", resumed)
+	}
+	if r.CheckID != "check1-resumed" {
+		t.Errorf("unexpected check ID: %v", r.CheckID)
+	}
+
+	var pendingIDs []string
+	for _, c := range pending {
+		pendingIDs = append(pendingIDs, c.id)
+	}
+	if len(pendingIDs) != 2 || pendingIDs[0] != "check2-resumed" || pendingIDs[1] != "check3-resumed" {
+		t.Errorf("unexpected pending checks: %v", pendingIDs)
+	}
+
+	if err := st.Remove(); err != nil {
+		t.Fatalf("remove error: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("state file was not removed: %v", err)
+	}
+}
+
+func TestScanState_truncated_record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.json")
+
+	chk := check{
+		id:        "check1",
+		checktype: checkcatalog.Checktype{Name: "vulcan-trivy", Image: "vulcansec/vulcan-trivy:1"},
+		target:    config.Target{Identifier: "alpine:latest", AssetType: types.DockerImage},
+	}
+
+	st, err := openScanState(path, false)
+	if err != nil {
+		t.Fatalf("open state error: %v", err)
+	}
+	if _, _, err := st.resumedReports([]check{chk}); err != nil {
+		t.Fatalf("resumed reports error: %v", err)
+	}
+	r := report.Report{CheckData: report.CheckData{CheckID: "check1", Status: "FINISHED"}}
+	if err := st.Save("check1", r); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	if _, err := st.f.WriteString(`{"key":"abc","report":{"check_id"`); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	st, err = openScanState(path, true)
+	if err != nil {
+		t.Fatalf("open state error: %v", err)
+	}
+	defer st.Close()
+
+	if len(st.reports) != 1 {
+		t.Errorf("unexpected number of reports: %v", len(st.reports))
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log/slog"
 	"maps"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -13,37 +15,94 @@ import (
 	report "github.com/adevinta/vulcan-report"
 )
 
-// reportStore stores the reports generated by the Vulcan agent in
-// memory. It implements [storage.Store].
+// StatusMalformed is the status of the checks whose report does not
+// comply with the vulcan-report schema.
+const StatusMalformed = "MALFORMED"
+
+// ReportsDirPattern is the pattern of the temporary directories
+// created to store the reports of the checks while they run.
+const ReportsDirPattern = "lava-reports-*"
+
+// reportStore stores the reports generated by the Vulcan agent. The
+// raw reports are written to a directory indexed by check ID and only
+// the check data of every report is kept in memory while the checks
+// run. Note that the final report is still built in memory once the
+// agent finishes, so this does not reduce the peak memory usage of
+// the scan. It implements [storage.Store].
 type reportStore struct {
-	mu      sync.Mutex
-	reports map[string]report.Report
+	mu        sync.Mutex
+	dir       string
+	checkData map[string]report.CheckData
+
+	// logger is used to log the received data. If nil,
+	// [slog.Default] is used.
+	logger *slog.Logger
+
+	// onReport is called with every received report after
+	// storing it. If nil, it is not called.
+	onReport func(checkID string, r report.Report)
 }
 
 var _ storage.Store = &reportStore{}
 
-// UploadCheckData decodes the provided content and stores it in
-// memory indexed by checkID. If kind is "reports", it decodes content
-// as [report.Report]. If kind is "logs", the data is ignored.
+// newReportStore returns a new [reportStore] that writes the raw
+// reports to a new temporary directory in dir. If dir is the empty
+// string, the default directory for temporary files is used. The
+// caller is responsible for calling [reportStore.Close] to remove
+// the directory.
+func newReportStore(dir string) (*reportStore, error) {
+	tmpDir, err := os.MkdirTemp(dir, ReportsDirPattern)
+	if err != nil {
+		return nil, fmt.Errorf("make temp dir: %w", err)
+	}
+	rs := &reportStore{
+		dir:       tmpDir,
+		checkData: make(map[string]report.CheckData),
+	}
+	return rs, nil
+}
+
+// UploadCheckData decodes the provided content and stores it indexed
+// by checkID. If kind is "reports", it decodes content as
+// [report.Report], writes content to disk and keeps the check data in
+// memory. If kind is "logs", the data is ignored.
 func (rs *reportStore) UploadCheckData(checkID, kind string, startedAt time.Time, content []byte) (link string, err error) {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	logger := slog.With("checkID", checkID)
-
-	if rs.reports == nil {
-		rs.reports = make(map[string]report.Report)
+	logger := rs.logger
+	if logger == nil {
+		logger = slog.Default()
 	}
+	logger = logger.With("checkID", checkID)
 
 	switch kind {
 	case "reports":
 		logger.Debug("received reports from check", "content", fmt.Sprintf("%#q", content))
 
-		var r report.Report
-		if err := r.UnmarshalJSONTimeAsString(content); err != nil {
-			return "", fmt.Errorf("decode content: %w", err)
+		path, err := rs.path(checkID)
+		if err != nil {
+			return "", err
+		}
+
+		r, err := decodeReport(checkID, content)
+		if err != nil {
+			// Malformed reports are replaced with a
+			// report with status [StatusMalformed], so
+			// the scan can continue.
+			logger.Warn("received malformed report from check", "err", err)
+			r = malformedReport(checkID, startedAt, r, err)
+			if content, err = r.MarshalJSONTimeAsString(); err != nil {
+				return "", fmt.Errorf("encode report: %w", err)
+			}
+		}
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			return "", fmt.Errorf("write report: %w", err)
+		}
+		rs.checkData[checkID] = r.CheckData
+		if rs.onReport != nil {
+			rs.onReport(checkID, r)
 		}
-		rs.reports[checkID] = r
 	case "logs":
 		logger.Debug("received logs from check", "content", fmt.Sprintf("%#q", content))
 	default:
@@ -52,23 +111,81 @@ func (rs *reportStore) UploadCheckData(checkID, kind string, startedAt time.Time
 	return "", nil
 }
 
-// Summary returns a human-readable summary per report.
-func (rs *reportStore) Summary() []string {
-	rs.mu.Lock()
-	defer rs.mu.Unlock()
+// decodeReport decodes the provided report and validates it against
+// the vulcan-report schema. It also checks that the report belongs to
+// the specified check. If the report can be decoded but is not
+// valid, it returns the decoded report along with the error.
+func decodeReport(checkID string, content []byte) (report.Report, error) {
+	var r report.Report
+	if err := r.UnmarshalJSONTimeAsString(content); err != nil {
+		return report.Report{}, fmt.Errorf("decode report: %w", err)
+	}
+	if err := r.Validate(); err != nil {
+		return r, fmt.Errorf("validate report: %w", err)
+	}
+	if r.CheckID != checkID {
+		return r, fmt.Errorf("check ID mismatch: %v", r.CheckID)
+	}
+	return r, nil
+}
 
-	var sums []string
-	for _, r := range rs.reports {
-		s := fmt.Sprintf("checktype=%v target=%v start=%v status=%v", r.ChecktypeName, r.Target, r.StartTime, r.Status)
-		sums = append(sums, s)
+// malformedReport returns a report with status [StatusMalformed] for
+// the specified check. The check data of the provided report is kept,
+// but its results are discarded. The error of the returned report is
+// set to the provided error.
+func malformedReport(checkID string, startedAt time.Time, r report.Report, err error) report.Report {
+	cd := r.CheckData
+	cd.CheckID = checkID
+	cd.Status = StatusMalformed
+	if cd.StartTime.IsZero() {
+		cd.StartTime = startedAt
+	}
+	if cd.EndTime.IsZero() {
+		cd.EndTime = time.Now()
+	}
+	return report.Report{
+		CheckData:  cd,
+		ResultData: report.ResultData{Error: err.Error()},
 	}
-	return sums
 }
 
-// Reports returns the stored reports.
-func (rs *reportStore) Reports() map[string]report.Report {
+// CheckData returns the check data of the stored reports.
+func (rs *reportStore) CheckData() map[string]report.CheckData {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	return maps.Clone(rs.reports)
+	return maps.Clone(rs.checkData)
+}
+
+// Report reads from disk the report of the specified check.
+func (rs *reportStore) Report(checkID string) (report.Report, error) {
+	path, err := rs.path(checkID)
+	if err != nil {
+		return report.Report{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return report.Report{}, fmt.Errorf("read report: %w", err)
+	}
+
+	var r report.Report
+	if err := r.UnmarshalJSONTimeAsString(content); err != nil {
+		return report.Report{}, fmt.Errorf("decode report: %w", err)
+	}
+	return r, nil
+}
+
+// path returns the path of the file that contains the report of the
+// specified check.
+func (rs *reportStore) path(checkID string) (string, error) {
+	if checkID == "" || filepath.Base(checkID) != checkID {
+		return "", fmt.Errorf("invalid check ID: %q", checkID)
+	}
+	return filepath.Join(rs.dir, checkID+".json"), nil
+}
+
+// Close removes the directory where the reports are stored.
+func (rs *reportStore) Close() error {
+	return os.RemoveAll(rs.dir)
 }
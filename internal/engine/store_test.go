@@ -3,6 +3,8 @@
 package engine
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"testing"
 	"time"
@@ -44,10 +46,13 @@ func TestReportStoreUploadCheckData(t *testing.T) {
 		},
 	}
 
-	var (
-		want  = make(map[string]report.Report)
-		store reportStore
-	)
+	store, err := newReportStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("error creating report store: %v", err)
+	}
+	defer store.Close()
+
+	want := make(map[string]report.Report)
 	for _, td := range testdata {
 		var (
 			content []byte
@@ -76,74 +81,133 @@ func TestReportStoreUploadCheckData(t *testing.T) {
 		}
 	}
 
-	if diff := cmp.Diff(want, store.reports); diff != "" {
+	got := make(map[string]report.Report)
+	for checkID := range store.CheckData() {
+		r, err := store.Report(checkID)
+		if err != nil {
+			t.Fatalf("error reading report: %v", err)
+		}
+		got[checkID] = r
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
 		t.Errorf("reports mismatch (-want +got):\n%v", diff)
 	}
 }
 
-func TestReportStoreSummary(t *testing.T) {
-	updates := []struct {
-		report report.Report
-		want   []string
+func TestReportStoreClose(t *testing.T) {
+	store, err := newReportStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("error creating report store: %v", err)
+	}
+
+	content, err := os.ReadFile("testdata/store/report.json")
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+
+	var rep report.Report
+	if err := rep.UnmarshalJSONTimeAsString(content); err != nil {
+		t.Fatalf("error decoding report: %v", err)
+	}
+
+	if _, err := store.UploadCheckData(rep.CheckID, "reports", time.Time{}, content); err != nil {
+		t.Fatalf("error uploading report: %v", err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("error closing report store: %v", err)
+	}
+
+	if _, err := os.Stat(store.dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("report directory was not removed: %v", err)
+	}
+}
+
+func TestReportStoreUploadCheckData_malformed(t *testing.T) {
+	startedAt := time.Date(2023, 9, 7, 16, 35, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		checkID string
+		file    string
+		want    report.CheckData
 	}{
 		{
-			report: report.Report{
-				CheckData: report.CheckData{
-					Target:        "https://example.com/",
-					CheckID:       "check1",
-					Status:        "RUNNING",
-					ChecktypeName: "vulcan-semgrep",
-				},
-			},
-			want: []string{
-				`checktype=vulcan-semgrep target=https://example.com/ start=0001-01-01 00:00:00 +0000 UTC status=RUNNING`,
+			name:    "invalid JSON",
+			checkID: "0c233832-9bef-4635-9957-836c78190c2d",
+			file:    "testdata/store/invalid_report.json",
+			want: report.CheckData{
+				CheckID:   "0c233832-9bef-4635-9957-836c78190c2d",
+				Status:    StatusMalformed,
+				StartTime: startedAt,
 			},
 		},
 		{
-			report: report.Report{
-				CheckData: report.CheckData{
-					Target:        "https://example.org/",
-					CheckID:       "check2",
-					Status:        "RUNNING",
-					ChecktypeName: "vulcan-trivy",
-				},
-			},
-			want: []string{
-				`checktype=vulcan-semgrep target=https://example.com/ start=0001-01-01 00:00:00 +0000 UTC status=RUNNING`,
-				`checktype=vulcan-trivy target=https://example.org/ start=0001-01-01 00:00:00 +0000 UTC status=RUNNING`,
+			name:    "invalid vulnerability",
+			checkID: "a7b0d3c2-2b44-4a3e-9d6a-1f5e0b8c9d10",
+			file:    "testdata/store/malformed_report.json",
+			want: report.CheckData{
+				CheckID:          "a7b0d3c2-2b44-4a3e-9d6a-1f5e0b8c9d10",
+				ChecktypeName:    "vulcansec/vulcan-retirejs",
+				ChecktypeVersion: "edge",
+				Status:           StatusMalformed,
+				Target:           "http://example.com/",
+				Options:          "{}",
+				StartTime:        time.Date(2023, 9, 7, 16, 35, 30, 0, time.UTC),
 			},
 		},
 		{
-			report: report.Report{
-				CheckData: report.CheckData{
-					Target:        "https://example.com/",
-					CheckID:       "check1",
-					Status:        "FINISHED",
-					ChecktypeName: "vulcan-semgrep",
-				},
-			},
-			want: []string{
-				`checktype=vulcan-semgrep target=https://example.com/ start=0001-01-01 00:00:00 +0000 UTC status=FINISHED`,
-				`checktype=vulcan-trivy target=https://example.org/ start=0001-01-01 00:00:00 +0000 UTC status=RUNNING`,
+			name:    "check ID mismatch",
+			checkID: "2f6b1c9e-8a4d-4e3b-b5c7-0d9e1f2a3b4c",
+			file:    "testdata/store/report.json",
+			want: report.CheckData{
+				CheckID:          "2f6b1c9e-8a4d-4e3b-b5c7-0d9e1f2a3b4c",
+				ChecktypeName:    "vulcansec/vulcan-nuclei",
+				ChecktypeVersion: "edge",
+				Status:           StatusMalformed,
+				Target:           "http://example.com/",
+				Options:          `{"tag_exclusion_list":["intrusive","dos","fuzz"]}`,
+				StartTime:        time.Date(2023, 9, 7, 16, 35, 31, 0, time.UTC),
 			},
 		},
 	}
 
-	var rs reportStore
-	for _, update := range updates {
-		content, err := update.report.MarshalJSONTimeAsString()
-		if err != nil {
-			t.Fatalf("unexpected marshal error: %v", err)
-		}
-		if _, err := rs.UploadCheckData(update.report.CheckID, "reports", time.Now(), content); err != nil {
-			t.Fatalf("unexpected upload error: %v", err)
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := newReportStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("error creating report store: %v", err)
+			}
+			defer store.Close()
 
-		got := rs.Summary()
+			content, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("error reading file: %v", err)
+			}
 
-		opt := cmpopts.SortSlices(func(a, b string) bool { return a < b })
-		if diff := cmp.Diff(update.want, got, opt); diff != "" {
-			t.Errorf("summaries mismatch (-want +got):\n%v", diff)
-		}
+			if _, err := store.UploadCheckData(tt.checkID, "reports", startedAt, content); err != nil {
+				t.Fatalf("error uploading report: %v", err)
+			}
+
+			got, err := store.Report(tt.checkID)
+			if err != nil {
+				t.Fatalf("error reading report: %v", err)
+			}
+
+			if got.Error == "" {
+				t.Error("empty report error")
+			}
+			if len(got.Vulnerabilities) != 0 {
+				t.Errorf("unexpected vulnerabilities: %v", got.Vulnerabilities)
+			}
+
+			// The end time of the reports that cannot be
+			// decoded is the time they were received.
+			ignoreEndTime := cmpopts.IgnoreFields(report.CheckData{}, "EndTime")
+			if diff := cmp.Diff(tt.want, got.CheckData, ignoreEndTime); diff != "" {
+				t.Errorf("check data mismatch (-want +got):\n%v", diff)
+			}
+		})
 	}
 }
@@ -3,13 +3,16 @@
 package engine
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
 	"net"
+	"net/netip"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,6 +20,7 @@ import (
 
 	types "github.com/adevinta/vulcan-types"
 	"github.com/jroimartin/proxy"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/adevinta/lava/internal/assettypes"
 	"github.com/adevinta/lava/internal/config"
@@ -75,18 +79,53 @@ type targetServer struct {
 	gitAddr string
 	pg      *proxy.Group
 
+	// proxyAllow contains the IP ranges of the non-loopback
+	// services that are proxied.
+	proxyAllow []netip.Prefix
+
+	// sf deduplicates the concurrent requests to prepare the same
+	// resource, like a stream or an image, that is shared by the
+	// checks of different keys. Different resources are prepared
+	// concurrently.
+	sf singleflight.Group
+
 	mu   sync.Mutex
 	maps map[string]targetMap
+
+	// limited contains the rate-limited proxies indexed by
+	// listen address. It is protected by mu.
+	limited map[string]*limitedProxy
+
+	// udp contains the UDP proxies indexed by listen address. It
+	// is protected by mu.
+	udp map[string]*udpProxy
+
+	// images contains the references of the loaded images
+	// indexed by path. It is protected by mu.
+	images map[string]string
 }
 
-// newTargetServer returns a new [targetServer].
-func newTargetServer(rt containers.Runtime) (srv *targetServer, err error) {
-	cli, err := containers.NewDockerdClient(rt)
+// limitedProxy is a proxy that enforces the rate limit of a target.
+type limitedProxy struct {
+	p  *proxy.Proxy
+	ln *limitListener
+}
+
+// newTargetServer returns a new [targetServer]. The targets are
+// served on the gateway of the specified Docker network. If network
+// is the empty string, the default bridge network is used. The
+// repositories served by the internal Git server are stored in
+// tmpDir. If tmpDir is the empty string, the default directory for
+// temporary files is used. Besides loopback services, the services
+// whose address is in one of the proxyAllow ranges are proxied.
+func newTargetServer(rt containers.Runtime, network, tmpDir string, proxyAllow []netip.Prefix) (srv *targetServer, err error) {
+	dockerdCli, err := containers.NewDockerdClient(rt)
 	if err != nil {
 		return nil, fmt.Errorf("new dockerd client: %w", err)
 	}
+	cli := dockerdCli.WithNetwork(network)
 
-	gs, err := gitserver.New()
+	gs, err := gitserver.NewWithDir(tmpDir)
 	if err != nil {
 		return nil, fmt.Errorf("new GitServer: %w", err)
 	}
@@ -114,6 +153,11 @@ func newTargetServer(rt containers.Runtime) (srv *targetServer, err error) {
 		gitAddr: net.JoinHostPort(cli.HostGatewayHostname(), gitPort),
 		pg:      proxy.NewGroup(),
 		maps:    make(map[string]targetMap),
+		limited: make(map[string]*limitedProxy),
+		udp:     make(map[string]*udpProxy),
+		images:  make(map[string]string),
+
+		proxyAllow: proxyAllow,
 	}
 	return srv, nil
 }
@@ -125,55 +169,107 @@ func newTargetServer(rt containers.Runtime) (srv *targetServer, err error) {
 // service. The specified key should be unique and it is used to index
 // the generated target maps. If the key is known, the cached
 // [targetMap] is returned. The returned [targetMap] is the zero value
-// if it is not necessary to map the target.
+// if it is not necessary to map the target. Different keys are
+// handled concurrently, while the resources shared by them, like
+// the proxies of the same listen address, are only prepared once.
 func (srv *targetServer) Handle(key string, target config.Target) (targetMap, error) {
-	srv.mu.Lock()
-	defer srv.mu.Unlock()
-
-	if tm, ok := srv.maps[key]; ok {
+	if tm, ok := srv.TargetMap(key); ok {
 		return tm, nil
 	}
 
-	var (
-		tm  targetMap
-		err error
-	)
+	tm, err := srv.handleTarget(target)
+	if err != nil {
+		return targetMap{}, err
+	}
+
+	if !tm.IsZero() {
+		srv.mu.Lock()
+		srv.maps[key] = tm
+		srv.mu.Unlock()
+	}
+	return tm, nil
+}
+
+// handleTarget handles the provided target according to its asset
+// type.
+func (srv *targetServer) handleTarget(target config.Target) (targetMap, error) {
 	switch target.AssetType {
 	case types.GitRepository:
-		tm, err = srv.handleGitRepo(target)
-	case assettypes.Path:
-		tm, err = srv.handlePath(target)
+		return srv.handleGitRepo(target)
+	case assettypes.Path, assettypes.TerraformModule:
+		return srv.handlePath(target)
+	case assettypes.DockerImageArchive, assettypes.OCILayout:
+		return srv.handleImage(target)
 	case types.IP, types.Hostname, types.WebAddress:
-		tm, err = srv.handle(target)
+		return srv.handle(target)
 	case types.AWSAccount, types.DockerImage, types.IPRange, types.DomainName:
 		// These asset types are not handled by the target
 		// server.
+		return targetMap{}, nil
 	default:
 		return targetMap{}, fmt.Errorf("unsupported asset type: %v", target.AssetType)
 	}
-	if err != nil {
-		return targetMap{}, err
-	}
-
-	if !tm.IsZero() {
-		srv.maps[key] = tm
-	}
-	return tm, err
 }
 
 // handle serves the specified target through an internal proxy, so
-// Vulcan checks can access the service.
+// Vulcan checks can access the service. Only loopback services and
+// services allowed by the proxyAllow ranges are proxied. The UDP
+// ports specified by the [udpPortsOption] check option are also
+// forwarded.
 func (srv *targetServer) handle(target config.Target) (targetMap, error) {
-	stream, loopback, err := srv.mkStream(target)
+	if ports := optionUDPPorts(target.Options); len(ports) > 0 {
+		host, port, err := targetHostPort(target)
+		if err != nil {
+			return targetMap{}, fmt.Errorf("get target host: %w", err)
+		}
+
+		if !srv.isProxied(host) {
+			return targetMap{}, nil
+		}
+
+		if err := srv.serveUDP(host, ports); err != nil {
+			return targetMap{}, fmt.Errorf("serve UDP: %w", err)
+		}
+
+		// Targets without port only expose the UDP ports.
+		if port == "" {
+			return srv.mkTargetMap(target)
+		}
+	}
+
+	stream, proxied, err := srv.mkStream(target)
 	if err != nil {
 		return targetMap{}, fmt.Errorf("generate stream: %w", err)
 	}
 
-	// If the target is not a loopback address, ignore it.
-	if !loopback {
+	// If the target is not a loopback address nor an allowed
+	// address, ignore it.
+	if !proxied {
 		return targetMap{}, nil
 	}
 
+	if rl, ok := optionRateLimit(target.Options); ok && rl.Enforce {
+		if err := srv.serveLimited(stream, rl); err != nil {
+			return targetMap{}, fmt.Errorf("serve rate-limited stream: %w", err)
+		}
+		return srv.mkTargetMap(target)
+	}
+
+	// Different checks can share the same stream. So, the stream
+	// is served using a key that only depends on its listen
+	// address.
+	if _, err, _ := srv.sf.Do("stream:"+stream.ListenAddr, func() (any, error) {
+		return nil, srv.serveStream(stream)
+	}); err != nil {
+		return targetMap{}, err
+	}
+
+	return srv.mkTargetMap(target)
+}
+
+// serveStream serves the provided stream through the proxy group of
+// the target server. It returns when the proxy is listening.
+func (srv *targetServer) serveStream(stream proxy.Stream) error {
 	batch := srv.pg.ListenAndServe(stream)
 	defer func() {
 		// Discard remaining events and errors. So
@@ -181,33 +277,36 @@ func (srv *targetServer) handle(target config.Target) (targetMap, error) {
 		go batch.Flush()
 	}()
 
-loop:
 	for {
 		select {
 		case err, ok := <-batch.Errors():
 			// No listeners.
 			if !ok {
-				break loop
+				return nil
 			}
 
 			// If there is a service already listening on
 			// that address, then assume that it is the
 			// target service and ignore the error.
 			if errors.Is(err, syscall.EADDRINUSE) {
-				break loop
+				return nil
 			}
 
 			// An unexpected error happened in one of the
 			// proxies.
-			return targetMap{}, fmt.Errorf("proxy group: %w", err)
+			return fmt.Errorf("proxy group: %w", err)
 		case ev := <-batch.Events():
 			if ev.Kind == proxy.KindBeforeAccept {
 				// The proxy is listening.
-				break loop
+				return nil
 			}
 		}
 	}
+}
 
+// mkTargetMap returns the [targetMap] of a target served through the
+// internal proxy.
+func (srv *targetServer) mkTargetMap(target config.Target) (targetMap, error) {
 	intIdentifier, err := srv.mkIntIdentifier(target)
 	if err != nil {
 		return targetMap{}, fmt.Errorf("generate internal identifier: %w", err)
@@ -222,8 +321,79 @@ loop:
 	return tm, nil
 }
 
+// serveLimited serves the provided stream through a proxy that
+// enforces the specified rate limit. Streams with the same listen
+// address share the same proxy and, thus, the same limit.
+func (srv *targetServer) serveLimited(stream proxy.Stream, rl config.RateLimit) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if _, ok := srv.limited[stream.ListenAddr]; ok {
+		return nil
+	}
+
+	l, err := net.Listen(stream.ListenNetwork, stream.ListenAddr)
+	if err != nil {
+		// If there is a service already listening on that
+		// address, then assume that it is the target service
+		// and ignore the error.
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return nil
+		}
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	lp := &limitedProxy{
+		p:  proxy.NewProxy(),
+		ln: newLimitListener(l, rl),
+	}
+	go lp.p.Serve(lp.ln, stream.DialNetwork, stream.DialAddr) //nolint:errcheck
+
+	srv.limited[stream.ListenAddr] = lp
+	return nil
+}
+
+// serveUDP forwards the provided UDP ports of the specified host
+// from the Docker bridge network. Ports with the same listen address
+// share the same proxy.
+func (srv *targetServer) serveUDP(host string, ports []int) error {
+	listenHost, err := srv.cli.HostGatewayInterfaceAddr()
+	if err != nil {
+		return fmt.Errorf("get listen host: %w", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for _, port := range ports {
+		listenAddr := net.JoinHostPort(listenHost, strconv.Itoa(port))
+		if _, ok := srv.udp[listenAddr]; ok {
+			continue
+		}
+
+		conn, err := net.ListenPacket("udp", listenAddr)
+		if err != nil {
+			// If there is a service already listening on
+			// that address, then assume that it is the
+			// target service and ignore the error.
+			if errors.Is(err, syscall.EADDRINUSE) {
+				continue
+			}
+			return fmt.Errorf("listen: %w", err)
+		}
+
+		up := newUDPProxy(conn, net.JoinHostPort(host, strconv.Itoa(port)))
+		go up.Serve() //nolint:errcheck
+
+		srv.udp[listenAddr] = up
+	}
+	return nil
+}
+
 // handleGitRepo serves the provided Git repository using Lava's
-// internal Git server.
+// internal Git server. If the check defines the "depth" option, the
+// repository is shallow cloned with the same depth. If it defines the
+// "subdir" option, only the specified subdirectory is served.
 func (srv *targetServer) handleGitRepo(target config.Target) (targetMap, error) {
 	if _, err := os.Stat(target.Identifier); err != nil {
 		// If the path does not exist, assume that the target
@@ -234,7 +404,11 @@ func (srv *targetServer) handleGitRepo(target config.Target) (targetMap, error)
 		return targetMap{}, err
 	}
 
-	repo, err := srv.gs.AddRepository(target.Identifier)
+	opts := gitserver.CloneOptions{
+		Depth:  optionInt(target.Options, "depth"),
+		Subdir: optionString(target.Options, "subdir"),
+	}
+	repo, err := srv.gs.AddRepository(target.Identifier, opts)
 	if err != nil {
 		return targetMap{}, fmt.Errorf("add Git repository: %w", err)
 	}
@@ -248,10 +422,40 @@ func (srv *targetServer) handleGitRepo(target config.Target) (targetMap, error)
 	return tm, nil
 }
 
+// optionInt returns the integer value of the specified option. It
+// returns zero if the option is missing or it is not an integer.
+func optionInt(opts map[string]any, name string) int {
+	switch v := opts[name].(type) {
+	case int:
+		return v
+	case float64:
+		// Options decoded from JSON.
+		return int(v)
+	}
+	return 0
+}
+
+// optionString returns the string value of the specified option. It
+// returns the empty string if the option is missing or it is not a
+// string.
+func optionString(opts map[string]any, name string) string {
+	if v, ok := opts[name].(string); ok {
+		return v
+	}
+	return ""
+}
+
 // handlePath serves the provided path as a Git repository with a
-// single commit.
+// single commit. The "symlinks" option of the check specifies how
+// the symbolic links under the path are handled.
 func (srv *targetServer) handlePath(target config.Target) (targetMap, error) {
-	repo, err := srv.gs.AddPath(target.Identifier)
+	policy, err := gitserver.ParseSymlinkPolicy(optionString(target.Options, "symlinks"))
+	if err != nil {
+		return targetMap{}, fmt.Errorf("parse symlinks option: %w", err)
+	}
+
+	opts := gitserver.PathOptions{Symlinks: policy}
+	repo, err := srv.gs.AddPath(target.Identifier, opts)
 	if err != nil {
 		return targetMap{}, fmt.Errorf("add path: %w", err)
 	}
@@ -265,6 +469,48 @@ func (srv *targetServer) handlePath(target config.Target) (targetMap, error) {
 	return tm, nil
 }
 
+// handleImage loads the provided image tarball or OCI layout into the
+// container runtime, so the checks can scan it as a Docker image.
+// Every path is loaded only once.
+func (srv *targetServer) handleImage(target config.Target) (targetMap, error) {
+	path, err := filepath.Abs(target.Identifier)
+	if err != nil {
+		return targetMap{}, fmt.Errorf("absolute path: %w", err)
+	}
+
+	// Different checks can share the same image. So, the image
+	// is loaded using a key that only depends on its path.
+	v, err, _ := srv.sf.Do("image:"+path, func() (any, error) {
+		srv.mu.Lock()
+		ref, ok := srv.images[path]
+		srv.mu.Unlock()
+		if ok {
+			return ref, nil
+		}
+
+		ref, err := srv.cli.ImageLoad(context.Background(), path)
+		if err != nil {
+			return "", err
+		}
+
+		srv.mu.Lock()
+		srv.images[path] = ref
+		srv.mu.Unlock()
+		return ref, nil
+	})
+	if err != nil {
+		return targetMap{}, fmt.Errorf("load image: %w", err)
+	}
+
+	tm := targetMap{
+		OldIdentifier: target.Identifier,
+		OldAssetType:  target.AssetType,
+		NewIdentifier: v.(string),
+		NewAssetType:  assettypes.ToVulcan(target.AssetType),
+	}
+	return tm, nil
+}
+
 // TargetMap returns the target map corresponding to the specified
 // key. If the target map cannot be found, the returned [targetMap] is
 // the zero value and the boolean is false.
@@ -276,6 +522,24 @@ func (srv *targetServer) TargetMap(key string) (tm targetMap, ok bool) {
 	return
 }
 
+// Reset forgets the handled targets, so they are handled again the
+// next time. The local Git repositories, paths and images are served
+// again with their current contents. The proxies are kept, because
+// they are reused if the same services are handled again. It must
+// not be called while targets are being handled.
+func (srv *targetServer) Reset() error {
+	if err := srv.gs.Reset(); err != nil {
+		return fmt.Errorf("reset Git server: %w", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.maps = make(map[string]targetMap)
+	srv.images = make(map[string]string)
+	return nil
+}
+
 // Close closes the internal Git server and proxy.
 func (srv *targetServer) Close() error {
 	if err := srv.cli.Close(); err != nil {
@@ -290,6 +554,24 @@ func (srv *targetServer) Close() error {
 		return fmt.Errorf("close proxy group: %w", err)
 	}
 
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for _, lp := range srv.limited {
+		// The proxy may not have set its listener yet. So,
+		// the listener is closed explicitly.
+		lp.p.Close()  //nolint:errcheck
+		lp.ln.Close() //nolint:errcheck
+
+		// Discard remaining events, so the proxy can free
+		// resources.
+		go lp.p.Flush()
+	}
+
+	for _, up := range srv.udp {
+		up.Close() //nolint:errcheck
+	}
+
 	return nil
 }
 
@@ -297,16 +579,15 @@ func (srv *targetServer) Close() error {
 // network and the provided target. It uses the same port as the
 // address, so if the target is host:port, the returned stream will be
 // "bridgehost:port,host:port". The returned bool reports whether the
-// target is a loopback address.
-func (srv *targetServer) mkStream(target config.Target) (stream proxy.Stream, loopback bool, err error) {
-	addr, err := getTargetAddr(target)
+// target must be proxied, that is, whether it is a loopback address
+// or an address in one of the proxyAllow ranges.
+func (srv *targetServer) mkStream(target config.Target) (stream proxy.Stream, proxied bool, err error) {
+	host, port, err := targetHostPort(target)
 	if err != nil {
-		return proxy.Stream{}, false, fmt.Errorf("get target addr: %w", err)
+		return proxy.Stream{}, false, err
 	}
-
-	host, port, err := net.SplitHostPort(addr)
-	if err != nil {
-		return proxy.Stream{}, false, fmt.Errorf("split host port: %w", err)
+	if port == "" {
+		return proxy.Stream{}, false, fmt.Errorf("missing port: %v", target.Identifier)
 	}
 
 	listenHost, err := srv.cli.HostGatewayInterfaceAddr()
@@ -314,15 +595,48 @@ func (srv *targetServer) mkStream(target config.Target) (stream proxy.Stream, lo
 		return proxy.Stream{}, false, fmt.Errorf("get listen host: %w", err)
 	}
 
-	listenAddr := net.JoinHostPort(listenHost, port)
-	dialAddr := net.JoinHostPort(host, port)
-	s := fmt.Sprintf("tcp:%v,tcp:%v", listenAddr, dialAddr)
-	stream, err = proxy.ParseStream(s)
+	// The stream is not built with [proxy.ParseStream], so the
+	// IPv6 addresses do not need to be escaped.
+	stream = proxy.Stream{
+		ListenNetwork: "tcp",
+		ListenAddr:    net.JoinHostPort(listenHost, port),
+		DialNetwork:   "tcp",
+		DialAddr:      net.JoinHostPort(host, port),
+	}
+
+	return stream, srv.isProxied(host), nil
+}
+
+// isProxied reports whether the services of the provided host are
+// proxied, that is, whether it is a loopback address or an address
+// in one of the proxyAllow ranges.
+func (srv *targetServer) isProxied(host string) bool {
+	return isLoopback(host) || isAllowed(host, srv.proxyAllow)
+}
+
+// targetHostPort returns the host and port of the network address
+// pointed by a given target. The returned port is empty if the
+// address does not specify a port.
+func targetHostPort(target config.Target) (host, port string, err error) {
+	addr, err := getTargetAddr(target)
 	if err != nil {
-		return proxy.Stream{}, false, fmt.Errorf("parse stream: %w", err)
+		return "", "", fmt.Errorf("get target addr: %w", err)
 	}
 
-	return stream, isLoopback(host), nil
+	// IPv6 addresses without port cannot be split.
+	if net.ParseIP(addr) != nil {
+		return addr, "", nil
+	}
+
+	host, port, err = net.SplitHostPort(addr)
+	if err != nil {
+		var addrErr *net.AddrError
+		if errors.As(err, &addrErr) && addrErr.Err == "missing port in address" {
+			return addr, "", nil
+		}
+		return "", "", fmt.Errorf("split host port: %w", err)
+	}
+	return host, port, nil
 }
 
 // getTargetAddr returns the network address pointed by a given
@@ -444,14 +758,50 @@ func isLoopback(host string) bool {
 	return false
 }
 
+// isAllowed returns whether any of the addresses of host is in one
+// of the provided IP ranges.
+func isAllowed(host string, prefixes []netip.Prefix) bool {
+	if len(prefixes) == 0 {
+		return false
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return false
+	}
+
+	for _, ip := range ips {
+		addr, ok := netip.AddrFromSlice(ip)
+		if !ok {
+			continue
+		}
+		addr = addr.Unmap()
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // parseGitURL parses a Git URL. If gitURL is a scp-like Git URL, it
-// is first converted into a SSH URL.
+// is first converted into a SSH URL. The IPv6 hosts of scp-like Git
+// URLs must be enclosed in square brackets, like "[::1]:repo.git".
 func parseGitURL(gitURL string) (*url.URL, error) {
 	rawURL := gitURL
 	if !strings.Contains(gitURL, "://") {
 		// scp-like syntax is only recognized if there are no
-		// slashes before the first colon.
-		cidx := strings.Index(gitURL, ":")
+		// slashes before the first colon. The colons of
+		// bracketed IPv6 hosts are ignored.
+		start := 0
+		if i := strings.Index(gitURL, "]"); i >= 0 && strings.Contains(gitURL[:i], "[") {
+			start = i
+		}
+		cidx := strings.Index(gitURL[start:], ":")
+		if cidx >= 0 {
+			cidx += start
+		}
 		sidx := strings.Index(gitURL, "/")
 		if cidx >= 0 && (sidx < 0 || cidx < sidx) {
 			rawURL = "ssh://" + gitURL[:cidx] + path.Join("/", gitURL[cidx+1:])
@@ -4,6 +4,7 @@ package engine
 
 import (
 	"fmt"
+	"net/netip"
 	"testing"
 
 	types "github.com/adevinta/vulcan-types"
@@ -64,6 +65,24 @@ func TestGetTargetAddr(t *testing.T) {
 			want:       "example.com:443",
 			wantNilErr: true,
 		},
+		{
+			name: "WebAddress IPv6",
+			target: config.Target{
+				AssetType:  types.WebAddress,
+				Identifier: "http://[::1]:8080/path",
+			},
+			want:       "[::1]:8080",
+			wantNilErr: true,
+		},
+		{
+			name: "WebAddress IPv6 scheme",
+			target: config.Target{
+				AssetType:  types.WebAddress,
+				Identifier: "https://[::1]/path",
+			},
+			want:       "[::1]:443",
+			wantNilErr: true,
+		},
 		{
 			name: "WebAddress unknown scheme",
 			target: config.Target{
@@ -91,6 +110,15 @@ func TestGetTargetAddr(t *testing.T) {
 			want:       "github.com:22",
 			wantNilErr: true,
 		},
+		{
+			name: "GitRepository scp-like syntax IPv6",
+			target: config.Target{
+				AssetType:  types.GitRepository,
+				Identifier: "git@[::1]:adevinta/lava.git",
+			},
+			want:       "[::1]:22",
+			wantNilErr: true,
+		},
 		{
 			name: "GitRepository https",
 			target: config.Target{
@@ -231,6 +259,15 @@ func TestTargetServer_mkIntIdentifier(t *testing.T) {
 			want:       fmt.Sprintf("http://%v:12345/path", hostGatewayHostname),
 			wantNilErr: true,
 		},
+		{
+			name: "local IPv6 WebAddress",
+			target: config.Target{
+				Identifier: "http://[::1]:12345/path",
+				AssetType:  "WebAddress",
+			},
+			want:       fmt.Sprintf("http://%v:12345/path", hostGatewayHostname),
+			wantNilErr: true,
+		},
 		{
 			name: "remote WebAddress",
 			target: config.Target{
@@ -298,7 +335,7 @@ func TestTargetServer_mkIntIdentifier(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			srv, err := newTargetServer(testRuntime)
+			srv, err := newTargetServer(testRuntime, "", "", nil)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -338,6 +375,16 @@ func TestParseGitURL(t *testing.T) {
 			url:      "example.com:/",
 			wantHost: "example.com",
 		},
+		{
+			name:     "scp IPv6",
+			url:      "user@[::1]:/~user/path/to/repo.git/",
+			wantHost: "[::1]",
+		},
+		{
+			name:     "scp IPv6 short",
+			url:      "[fd00::1]:repo.git",
+			wantHost: "[fd00::1]",
+		},
 		{
 			name:     "local path",
 			url:      "/path/to/repo.git/",
@@ -388,3 +435,211 @@ func TestParseGitURL(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionInt(t *testing.T) {
+	tests := []struct {
+		name string
+		opts map[string]any
+		want int
+	}{
+		{
+			name: "nil options",
+			opts: nil,
+			want: 0,
+		},
+		{
+			name: "missing option",
+			opts: map[string]any{"branch": "main"},
+			want: 0,
+		},
+		{
+			name: "int",
+			opts: map[string]any{"depth": 1},
+			want: 1,
+		},
+		{
+			name: "JSON number",
+			opts: map[string]any{"depth": float64(10)},
+			want: 10,
+		},
+		{
+			name: "invalid type",
+			opts: map[string]any{"depth": "1"},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := optionInt(tt.opts, "depth"); got != tt.want {
+				t.Errorf("unexpected value: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAllowed(t *testing.T) {
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("192.168.1.10/32"),
+		netip.MustParsePrefix("172.18.0.0/16"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+
+	tests := []struct {
+		name     string
+		host     string
+		prefixes []netip.Prefix
+		want     bool
+	}{
+		{
+			name:     "allowed address",
+			host:     "192.168.1.10",
+			prefixes: prefixes,
+			want:     true,
+		},
+		{
+			name:     "address in allowed range",
+			host:     "172.18.0.2",
+			prefixes: prefixes,
+			want:     true,
+		},
+		{
+			name:     "IPv6 address in allowed range",
+			host:     "fd00::2",
+			prefixes: prefixes,
+			want:     true,
+		},
+		{
+			name:     "not allowed address",
+			host:     "192.168.1.11",
+			prefixes: prefixes,
+			want:     false,
+		},
+		{
+			name:     "no ranges",
+			host:     "192.168.1.10",
+			prefixes: nil,
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAllowed(tt.host, tt.prefixes); got != tt.want {
+				t.Errorf("unexpected value: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want bool
+	}{
+		{
+			name: "IPv4 loopback",
+			host: "127.0.0.1",
+			want: true,
+		},
+		{
+			name: "IPv6 loopback",
+			host: "::1",
+			want: true,
+		},
+		{
+			name: "IPv4 non-loopback",
+			host: "192.168.1.1",
+			want: false,
+		},
+		{
+			name: "IPv6 non-loopback",
+			host: "fd00::1",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLoopback(tt.host); got != tt.want {
+				t.Errorf("unexpected value: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetHostPort(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     config.Target
+		wantHost   string
+		wantPort   string
+		wantNilErr bool
+	}{
+		{
+			name: "IPv4 without port",
+			target: config.Target{
+				AssetType:  types.IP,
+				Identifier: "127.0.0.1",
+			},
+			wantHost:   "127.0.0.1",
+			wantPort:   "",
+			wantNilErr: true,
+		},
+		{
+			name: "IPv6 without port",
+			target: config.Target{
+				AssetType:  types.IP,
+				Identifier: "::1",
+			},
+			wantHost:   "::1",
+			wantPort:   "",
+			wantNilErr: true,
+		},
+		{
+			name: "Hostname without port",
+			target: config.Target{
+				AssetType:  types.Hostname,
+				Identifier: "localhost",
+			},
+			wantHost:   "localhost",
+			wantPort:   "",
+			wantNilErr: true,
+		},
+		{
+			name: "WebAddress",
+			target: config.Target{
+				AssetType:  types.WebAddress,
+				Identifier: "http://[::1]:8080/path",
+			},
+			wantHost:   "::1",
+			wantPort:   "8080",
+			wantNilErr: true,
+		},
+		{
+			name: "invalid asset type",
+			target: config.Target{
+				AssetType:  types.IPRange,
+				Identifier: "127.0.0.1/8",
+			},
+			wantHost:   "",
+			wantPort:   "",
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, err := targetHostPort(tt.target)
+
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("unexpected address: got: %v %v, want: %v %v", host, port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+)
+
+// checkTiming contains the timing metrics of a check.
+type checkTiming struct {
+	// Key is the stable identifier of the check. See [CheckKey].
+	Key string `json:"key"`
+
+	// Checktype is the name of the checktype.
+	Checktype string `json:"checktype"`
+
+	// Target is the target of the check.
+	Target string `json:"target"`
+
+	// QueueWait is the time in seconds the check waited in the
+	// jobs queue after the agent started running checks.
+	QueueWait float64 `json:"queue_wait"`
+
+	// Pull is the time in seconds spent pulling the check image
+	// before running the check.
+	Pull float64 `json:"pull"`
+}
+
+// timedBackend is a [backend.Backend] that measures the time the
+// checks wait in the jobs queue and the time spent pulling their
+// images.
+type timedBackend struct {
+	backend backend.Backend
+	start   time.Time
+
+	// now is used by tests to set the current time.
+	now func() time.Time
+
+	// mu protects the fields below.
+	mu       sync.Mutex
+	firstRun time.Time
+	checks   map[string]checkTiming
+	pulls    map[string]float64
+}
+
+var _ backend.Backend = &timedBackend{}
+
+// newTimedBackend returns a [timedBackend] that runs checks with the
+// provided backend. It considers that the agent starts when it is
+// called.
+func newTimedBackend(b backend.Backend) *timedBackend {
+	return &timedBackend{
+		backend: b,
+		start:   time.Now(),
+		now:     time.Now,
+		checks:  make(map[string]checkTiming),
+		pulls:   make(map[string]float64),
+	}
+}
+
+// Run runs the check with the underlying backend. The Vulcan agent
+// pulls the check image before returning from Run, so the time spent
+// in Run is considered pull time.
+func (tb *timedBackend) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	runTime := tb.now()
+
+	tb.mu.Lock()
+	if tb.firstRun.IsZero() {
+		tb.firstRun = runTime
+	}
+	queueWait := runTime.Sub(tb.firstRun)
+	tb.mu.Unlock()
+
+	res, err := tb.backend.Run(ctx, params)
+
+	pull := tb.now().Sub(runTime).Seconds()
+
+	tb.mu.Lock()
+	tb.checks[params.CheckID] = checkTiming{
+		Key:       checkKey(params.CheckTypeName, params.ChecktypeVersion, params.Target, params.Options),
+		Checktype: params.CheckTypeName,
+		Target:    params.Target,
+		QueueWait: queueWait.Seconds(),
+		Pull:      pull,
+	}
+	tb.pulls[params.Image] = max(tb.pulls[params.Image], pull)
+	tb.mu.Unlock()
+
+	return res, err
+}
+
+// Collect adds the timing metrics to the provided [scanMetrics].
+func (tb *timedBackend) Collect(sm *scanMetrics) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	sm.addTimings(tb.firstRun.Sub(tb.start).Seconds(), !tb.firstRun.IsZero(), tb.checks, tb.pulls)
+}
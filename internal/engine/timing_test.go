@@ -0,0 +1,80 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+	"github.com/google/go-cmp/cmp"
+)
+
+// backendFunc is a [backend.Backend] that runs checks by calling the
+// function.
+type backendFunc func(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error)
+
+func (f backendFunc) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	return f(ctx, params)
+}
+
+func TestTimedBackend(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// The fake backend advances the clock as if it pulled the
+	// check image.
+	pulls := map[string]time.Duration{
+		"image1": 3 * time.Second,
+		"image2": 5 * time.Second,
+	}
+	b := backendFunc(func(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+		now = now.Add(pulls[params.Image])
+		pulls[params.Image] = 0
+		return make(chan backend.RunResult), nil
+	})
+
+	tb := &timedBackend{
+		backend: b,
+		start:   now,
+		now:     func() time.Time { return now },
+		checks:  make(map[string]checkTiming),
+		pulls:   make(map[string]float64),
+	}
+
+	params := []backend.RunParams{
+		{CheckID: "check1", CheckTypeName: "checktype1", Target: "target1", Image: "image1"},
+		{CheckID: "check2", CheckTypeName: "checktype2", Target: "target2", Image: "image1"},
+		{CheckID: "check3", CheckTypeName: "checktype3", Target: "target3", Image: "image2"},
+	}
+
+	// The agent takes 2 seconds to start.
+	now = now.Add(2 * time.Second)
+	for _, p := range params {
+		if _, err := tb.Run(context.Background(), p); err != nil {
+			t.Fatalf("run error: %v", err)
+		}
+		now = now.Add(time.Second)
+	}
+
+	if got, want := tb.firstRun.Sub(tb.start), 2*time.Second; got != want {
+		t.Errorf("unexpected agent startup duration: got: %v, want: %v", got, want)
+	}
+
+	wantChecks := map[string]checkTiming{
+		"check1": {Key: checkKey("checktype1", "", "target1", ""), Checktype: "checktype1", Target: "target1", QueueWait: 0, Pull: 3},
+		"check2": {Key: checkKey("checktype2", "", "target2", ""), Checktype: "checktype2", Target: "target2", QueueWait: 4, Pull: 0},
+		"check3": {Key: checkKey("checktype3", "", "target3", ""), Checktype: "checktype3", Target: "target3", QueueWait: 5, Pull: 5},
+	}
+	if diff := cmp.Diff(wantChecks, tb.checks); diff != "" {
+		t.Errorf("check timings mismatch (-want +got):\n%v", diff)
+	}
+
+	wantPulls := map[string]float64{
+		"image1": 3,
+		"image2": 5,
+	}
+	if diff := cmp.Diff(wantPulls, tb.pulls); diff != "" {
+		t.Errorf("pull durations mismatch (-want +got):\n%v", diff)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// udpPortsOption is the name of the check option used to pass the
+// UDP ports of the target that are forwarded to the checks.
+const udpPortsOption = "udp_ports"
+
+// optionUDPPorts returns the ports in the [udpPortsOption] check
+// option. The invalid ports are ignored.
+func optionUDPPorts(opts map[string]any) []int {
+	var ports []int
+	switch v := opts[udpPortsOption].(type) {
+	case []int:
+		ports = v
+	case []any:
+		// Options decoded from JSON.
+		for _, p := range v {
+			if f, ok := p.(float64); ok {
+				ports = append(ports, int(f))
+			}
+		}
+	}
+
+	var valid []int
+	for _, p := range ports {
+		if p >= 1 && p <= 65535 {
+			valid = append(valid, p)
+		}
+	}
+	return valid
+}
+
+const (
+	// udpBufferSize is the size of the buffers used to read the
+	// datagrams. It is the maximum size of a UDP datagram.
+	udpBufferSize = 65535
+
+	// udpSessionTimeout is the time after which a session without
+	// responses from the destination is closed.
+	udpSessionTimeout = time.Minute
+)
+
+// udpProxy forwards the UDP datagrams received on a local address to
+// a destination address. Every client gets its own connection to the
+// destination, so the responses are sent back to the right client.
+type udpProxy struct {
+	conn     net.PacketConn
+	dialAddr string
+	timeout  time.Duration
+
+	// mu protects the fields below.
+	mu       sync.Mutex
+	sessions map[string]net.Conn
+	closed   bool
+}
+
+// newUDPProxy returns a [udpProxy] that forwards the datagrams
+// received on conn to dialAddr.
+func newUDPProxy(conn net.PacketConn, dialAddr string) *udpProxy {
+	return &udpProxy{
+		conn:     conn,
+		dialAddr: dialAddr,
+		timeout:  udpSessionTimeout,
+		sessions: make(map[string]net.Conn),
+	}
+}
+
+// Serve forwards the received datagrams until the proxy is closed.
+// The datagrams that cannot be forwarded are dropped, like the
+// network would do.
+func (p *udpProxy) Serve() error {
+	buf := make([]byte, udpBufferSize)
+	for {
+		n, addr, err := p.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("read: %w", err)
+		}
+
+		sess, err := p.session(addr)
+		if err != nil {
+			continue
+		}
+		sess.Write(buf[:n]) //nolint:errcheck
+	}
+}
+
+// session returns the connection to the destination of the
+// specified client. If there is no connection, it is created.
+func (p *udpProxy) session(addr net.Addr) (net.Conn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, net.ErrClosed
+	}
+
+	key := addr.String()
+	if c, ok := p.sessions[key]; ok {
+		return c, nil
+	}
+
+	c, err := net.Dial("udp", p.dialAddr)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	p.sessions[key] = c
+	go p.reply(c, addr)
+	return c, nil
+}
+
+// reply sends the responses received from the destination back to
+// the specified client. The session is closed if no response is
+// received within the timeout of the proxy.
+func (p *udpProxy) reply(c net.Conn, addr net.Addr) {
+	defer func() {
+		p.mu.Lock()
+		if p.sessions[addr.String()] == c {
+			delete(p.sessions, addr.String())
+		}
+		p.mu.Unlock()
+		c.Close() //nolint:errcheck
+	}()
+
+	buf := make([]byte, udpBufferSize)
+	for {
+		if err := c.SetReadDeadline(time.Now().Add(p.timeout)); err != nil {
+			return
+		}
+		n, err := c.Read(buf)
+		if err != nil {
+			return
+		}
+		if _, err := p.conn.WriteTo(buf[:n], addr); err != nil {
+			return
+		}
+	}
+}
+
+// Close closes the proxy and all its sessions.
+func (p *udpProxy) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, c := range p.sessions {
+		c.Close() //nolint:errcheck
+	}
+	return p.conn.Close()
+}
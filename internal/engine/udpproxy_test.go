@@ -0,0 +1,163 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOptionUDPPorts(t *testing.T) {
+	tests := []struct {
+		name string
+		opts map[string]any
+		want []int
+	}{
+		{
+			name: "nil options",
+			opts: nil,
+			want: nil,
+		},
+		{
+			name: "ints",
+			opts: map[string]any{udpPortsOption: []int{53, 161}},
+			want: []int{53, 161},
+		},
+		{
+			name: "JSON numbers",
+			opts: map[string]any{udpPortsOption: []any{float64(53), float64(161)}},
+			want: []int{53, 161},
+		},
+		{
+			name: "invalid ports",
+			opts: map[string]any{udpPortsOption: []any{float64(53), "161", float64(70000)}},
+			want: []int{53},
+		},
+		{
+			name: "invalid type",
+			opts: map[string]any{udpPortsOption: "53"},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := optionUDPPorts(tt.opts); !slices.Equal(got, tt.want) {
+				t.Errorf("unexpected ports: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUDPProxy(t *testing.T) {
+	echo, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer echo.Close()
+
+	go func() {
+		buf := make([]byte, udpBufferSize)
+		for {
+			n, addr, err := echo.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			echo.WriteTo(buf[:n], addr) //nolint:errcheck
+		}
+	}()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	up := newUDPProxy(conn, echo.LocalAddr().String())
+	defer up.Close()
+
+	go up.Serve() //nolint:errcheck
+
+	for _, msg := range []string{"client 1", "client 2"} {
+		c, err := net.Dial("udp", conn.LocalAddr().String())
+		if err != nil {
+			t.Fatalf("could not dial: %v", err)
+		}
+		defer c.Close()
+
+		if _, err := c.Write([]byte(msg)); err != nil {
+			t.Fatalf("could not write: %v", err)
+		}
+
+		if err := c.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatalf("could not set deadline: %v", err)
+		}
+		buf := make([]byte, udpBufferSize)
+		n, err := c.Read(buf)
+		if err != nil {
+			t.Fatalf("could not read: %v", err)
+		}
+		if got := string(buf[:n]); got != msg {
+			t.Errorf("unexpected response: got: %q, want: %q", got, msg)
+		}
+	}
+
+	up.mu.Lock()
+	sessions := len(up.sessions)
+	up.mu.Unlock()
+	if sessions != 2 {
+		t.Errorf("unexpected number of sessions: got: %v, want: 2", sessions)
+	}
+}
+
+func TestUDPProxy_session_timeout(t *testing.T) {
+	dst, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer dst.Close()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	up := newUDPProxy(conn, dst.LocalAddr().String())
+	up.timeout = 500 * time.Millisecond
+	defer up.Close()
+
+	go up.Serve() //nolint:errcheck
+
+	c, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Write([]byte("ping")); err != nil {
+		t.Fatalf("could not write: %v", err)
+	}
+
+	// The destination does not reply, so the session is closed
+	// after the timeout.
+	waitUDPSessions(t, up, 1)
+	waitUDPSessions(t, up, 0)
+}
+
+// waitUDPSessions waits until the provided proxy has n sessions.
+func waitUDPSessions(t *testing.T, up *udpProxy, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		up.mu.Lock()
+		sessions := len(up.sessions)
+		up.mu.Unlock()
+		if sessions == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected number of sessions: got: %v, want: %v", sessions, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/adevinta/vulcan-agent/jobrunner"
+)
+
+var (
+	// ErrMissingVars is returned by [Engine.Run] when the
+	// variables required by the selected checktypes are not
+	// configured.
+	ErrMissingVars = errors.New("missing required vars")
+
+	// ErrInvalidEnv is returned by [Engine.Run] when the "env"
+	// option of a checktype or a target is not a map of strings.
+	ErrInvalidEnv = errors.New("invalid env option")
+)
+
+// checkRequiredVars checks that the provided variables contain all the
+// variables required by the provided jobs. Otherwise, it returns an
+// error wrapping [ErrMissingVars] that lists the missing variables of
+// every checktype. checks is used to get the checktype of the jobs.
+// envs contains the environment variables of the checks indexed by
+// check ID, which are also considered when looking for the required
+// variables.
+func checkRequiredVars(checks []check, jobs []jobrunner.Job, vars map[string]string, envs map[string]map[string]string) error {
+	checktypes := make(map[string]string)
+	for _, c := range checks {
+		checktypes[c.id] = c.checktype.Name
+	}
+
+	missing := make(map[string][]string)
+	for _, job := range jobs {
+		ct := checktypes[job.CheckID]
+
+		checkVars := vars
+		if env, ok := envs[job.CheckID]; ok {
+			checkVars = maps.Clone(vars)
+			if checkVars == nil {
+				checkVars = make(map[string]string)
+			}
+			maps.Copy(checkVars, env)
+		}
+
+		for _, v := range missingVars(job.RequiredVars, checkVars) {
+			if !slices.Contains(missing[ct], v) {
+				missing[ct] = append(missing[ct], v)
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var cts []string
+	for ct := range missing {
+		cts = append(cts, ct)
+	}
+	slices.Sort(cts)
+
+	var msgs []string
+	for _, ct := range cts {
+		slices.Sort(missing[ct])
+		msgs = append(msgs, fmt.Sprintf("%v (%v)", ct, strings.Join(missing[ct], ", ")))
+	}
+	return fmt.Errorf("%w: %v", ErrMissingVars, strings.Join(msgs, "; "))
+}
+
+// missingVars returns the sorted list of required variables that are
+// not set or are empty in the provided variables.
+func missingVars(required []string, vars map[string]string) []string {
+	var missing []string
+	for _, v := range required {
+		if vars[v] == "" && !slices.Contains(missing, v) {
+			missing = append(missing, v)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
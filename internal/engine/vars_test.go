@@ -0,0 +1,99 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/adevinta/vulcan-agent/jobrunner"
+	checkcatalog "github.com/adevinta/vulcan-check-catalog/pkg/model"
+)
+
+func TestCheckRequiredVars(t *testing.T) {
+	checks := []check{
+		{id: "check1", checktype: checkcatalog.Checktype{Name: "checktype1"}},
+		{id: "check2", checktype: checkcatalog.Checktype{Name: "checktype1"}},
+		{id: "check3", checktype: checkcatalog.Checktype{Name: "checktype2"}},
+	}
+
+	tests := []struct {
+		name    string
+		jobs    []jobrunner.Job
+		vars    map[string]string
+		envs    map[string]map[string]string
+		wantErr string
+	}{
+		{
+			name: "all vars",
+			jobs: []jobrunner.Job{
+				{CheckID: "check1", RequiredVars: []string{"VAR_A"}},
+				{CheckID: "check3", RequiredVars: []string{"VAR_A", "VAR_B"}},
+			},
+			vars: map[string]string{"VAR_A": "a", "VAR_B": "b"},
+		},
+		{
+			name: "no required vars",
+			jobs: []jobrunner.Job{
+				{CheckID: "check1"},
+			},
+		},
+		{
+			name: "missing vars",
+			jobs: []jobrunner.Job{
+				{CheckID: "check1", RequiredVars: []string{"VAR_C", "VAR_A"}},
+				{CheckID: "check2", RequiredVars: []string{"VAR_C", "VAR_A"}},
+				{CheckID: "check3", RequiredVars: []string{"VAR_A", "VAR_B"}},
+			},
+			vars:    map[string]string{"VAR_A": "a"},
+			wantErr: "missing required vars: checktype1 (VAR_C); checktype2 (VAR_B)",
+		},
+		{
+			name: "empty var",
+			jobs: []jobrunner.Job{
+				{CheckID: "check1", RequiredVars: []string{"VAR_A"}},
+			},
+			vars:    map[string]string{"VAR_A": ""},
+			wantErr: "missing required vars: checktype1 (VAR_A)",
+		},
+		{
+			name: "check env",
+			jobs: []jobrunner.Job{
+				{CheckID: "check1", RequiredVars: []string{"VAR_A", "VAR_B"}},
+			},
+			vars: map[string]string{"VAR_A": "a"},
+			envs: map[string]map[string]string{
+				"check1": {"VAR_B": "b"},
+			},
+		},
+		{
+			name: "check env of other check",
+			jobs: []jobrunner.Job{
+				{CheckID: "check1", RequiredVars: []string{"VAR_B"}},
+				{CheckID: "check2", RequiredVars: []string{"VAR_B"}},
+			},
+			envs: map[string]map[string]string{
+				"check1": {"VAR_B": "b"},
+			},
+			wantErr: "missing required vars: checktype1 (VAR_B)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRequiredVars(checks, tt.jobs, tt.vars, tt.envs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMissingVars) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, ErrMissingVars)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("unexpected error message: got: %q, want: %q", err, tt.wantErr)
+			}
+		})
+	}
+}
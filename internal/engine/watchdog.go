@@ -0,0 +1,226 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// ErrHostResources is the error of the checks that are not run
+// because the usage of the resources of the host exceeded the
+// configured thresholds.
+var ErrHostResources = errors.New("host resources exhausted")
+
+const (
+	// watchdogInterval is the time between samples of the usage
+	// of the resources of the host.
+	watchdogInterval = 5 * time.Second
+
+	// watchdogPollInterval is the time between checks of the
+	// state of the watchdog when a check is throttled.
+	watchdogPollInterval = time.Second
+)
+
+// watchdog is a [backend.Backend] that monitors the resources of the
+// host and throttles or stops the launch of new checks when the
+// configured thresholds are exceeded.
+type watchdog struct {
+	backend backend.Backend
+	logger  *slog.Logger
+	cfg     config.WatchdogConfig
+	sample  func() (hostUsage, error)
+
+	// skip is called with the checks that are not run because
+	// the watchdog has aborted the scan.
+	skip func(params backend.RunParams, err error)
+
+	// mu protects the fields below.
+	mu        sync.Mutex
+	exceeded  string
+	aborted   bool
+	running   int
+	throttled int
+	skipped   int
+	peak      hostUsage
+}
+
+var _ backend.Backend = &watchdog{}
+
+// newWatchdog returns a [watchdog] that runs checks with the provided
+// backend. The disk usage is sampled from the file system of
+// diskPath. The checks that are not run because the scan has been
+// aborted are passed to skip. If no threshold is configured, the
+// watchdog is disabled and the checks are run without delay.
+func newWatchdog(logger *slog.Logger, b backend.Backend, cfg config.WatchdogConfig, diskPath string, skip func(backend.RunParams, error)) *watchdog {
+	return &watchdog{
+		backend: b,
+		logger:  logger,
+		cfg:     cfg,
+		sample:  newHostSampler(diskPath).Sample,
+		skip:    skip,
+	}
+}
+
+// Run runs the check with the underlying backend. If a threshold is
+// exceeded and the configured action is throttle, it waits until the
+// usage of the resources is below the thresholds. A check is always
+// launched if no other check is running, so the scan progresses even
+// if the resources are used by other processes. If the configured
+// action is abort, it returns an error wrapping [ErrHostResources]
+// once a threshold has been exceeded.
+func (wd *watchdog) Run(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+	throttled := false
+	for {
+		wd.mu.Lock()
+		if wd.aborted {
+			wd.skipped++
+			err := fmt.Errorf("%w: %v", ErrHostResources, wd.exceeded)
+			wd.mu.Unlock()
+			if wd.skip != nil {
+				wd.skip(params, err)
+			}
+			return nil, err
+		}
+		if wd.exceeded == "" || wd.running == 0 {
+			wd.running++
+			wd.mu.Unlock()
+			break
+		}
+		if !throttled {
+			throttled = true
+			wd.throttled++
+			wd.logger.Info("throttling check", "checkID", params.CheckID, "reason", wd.exceeded)
+		}
+		wd.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(watchdogPollInterval):
+		}
+	}
+
+	finished, err := wd.backend.Run(ctx, params)
+	if err != nil {
+		wd.done()
+		return nil, err
+	}
+
+	c := make(chan backend.RunResult, 1)
+	go func() {
+		res := <-finished
+		wd.done()
+		c <- res
+	}()
+	return c, nil
+}
+
+// done records that a check finished.
+func (wd *watchdog) done() {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	wd.running--
+}
+
+// Monitor samples the usage of the resources of the host until done
+// is closed. It returns immediately if the watchdog is disabled.
+func (wd *watchdog) Monitor(done <-chan struct{}) {
+	if !wd.cfg.Enabled() {
+		return
+	}
+
+	var warned bool
+	for {
+		usage, err := wd.sample()
+		if err != nil && !warned {
+			wd.logger.Warn("could not sample host resources", "err", err)
+			warned = true
+		}
+		wd.update(usage)
+
+		select {
+		case <-done:
+			return
+		case <-time.After(watchdogInterval):
+		}
+	}
+}
+
+// update updates the state of the watchdog with the provided usage of
+// the resources of the host.
+func (wd *watchdog) update(usage hostUsage) {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	wd.peak = hostUsage{
+		CPU:    max(wd.peak.CPU, usage.CPU),
+		Memory: max(wd.peak.Memory, usage.Memory),
+		Disk:   max(wd.peak.Disk, usage.Disk),
+	}
+
+	exceeded := exceededThresholds(wd.cfg, usage)
+	switch {
+	case exceeded != "" && wd.exceeded == "":
+		wd.logger.Warn("host resource threshold exceeded", "reason", exceeded, "action", config.Get(wd.cfg.Action))
+	case exceeded == "" && wd.exceeded != "" && !wd.aborted:
+		wd.logger.Info("host resources below thresholds")
+	}
+	if wd.aborted {
+		return
+	}
+	wd.exceeded = exceeded
+	if exceeded != "" && config.Get(wd.cfg.Action) == config.WatchdogActionAbort {
+		wd.aborted = true
+	}
+}
+
+// exceededThresholds returns a description of the thresholds of the
+// provided configuration exceeded by the provided usage. It returns
+// an empty string if no threshold is exceeded.
+func exceededThresholds(cfg config.WatchdogConfig, usage hostUsage) string {
+	resources := []struct {
+		name      string
+		usage     float64
+		threshold *float64
+	}{
+		{"cpu", usage.CPU, cfg.CPU},
+		{"memory", usage.Memory, cfg.Memory},
+		{"disk", usage.Disk, cfg.Disk},
+	}
+
+	var msgs []string
+	for _, r := range resources {
+		if r.threshold != nil && r.usage > *r.threshold {
+			msgs = append(msgs, fmt.Sprintf("%v usage %.1f%% above %v%%", r.name, r.usage, *r.threshold))
+		}
+	}
+	return strings.Join(msgs, ", ")
+}
+
+// Collect adds the metrics of the watchdog to the provided
+// [scanMetrics]. Nothing is collected if the watchdog is disabled.
+func (wd *watchdog) Collect(sm *scanMetrics) {
+	if !wd.cfg.Enabled() {
+		return
+	}
+
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	sm.addWatchdog(watchdogMetrics{
+		peak:      wd.peak,
+		throttled: wd.throttled,
+		skipped:   wd.skipped,
+	})
+}
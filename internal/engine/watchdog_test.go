@@ -0,0 +1,138 @@
+// Copyright 2024 Adevinta
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/adevinta/vulcan-agent/backend"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestExceededThresholds(t *testing.T) {
+	cfg := config.WatchdogConfig{
+		CPU:    ptr(90.0),
+		Memory: ptr(80.0),
+	}
+
+	tests := []struct {
+		name  string
+		usage hostUsage
+		want  string
+	}{
+		{
+			name:  "below thresholds",
+			usage: hostUsage{CPU: 50, Memory: 60, Disk: 99},
+			want:  "",
+		},
+		{
+			name:  "cpu exceeded",
+			usage: hostUsage{CPU: 95, Memory: 60},
+			want:  "cpu usage 95.0% above 90%",
+		},
+		{
+			name:  "cpu and memory exceeded",
+			usage: hostUsage{CPU: 95, Memory: 85.25},
+			want:  "cpu usage 95.0% above 90%, memory usage 85.2% above 80%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exceededThresholds(cfg, tt.usage); got != tt.want {
+				t.Errorf("unexpected result: want: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWatchdog_Run_throttle(t *testing.T) {
+	finished := make(chan backend.RunResult)
+	launched := make(chan string, 2)
+	b := backendFunc(func(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+		launched <- params.CheckID
+		return finished, nil
+	})
+
+	cfg := config.WatchdogConfig{Memory: ptr(80.0)}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	wd := newWatchdog(logger, b, cfg, "", nil)
+
+	// The first check is launched even if the threshold is
+	// exceeded, because no other check is running.
+	wd.update(hostUsage{Memory: 90})
+	if _, err := wd.Run(context.Background(), backend.RunParams{CheckID: "check1"}); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	<-launched
+
+	errc := make(chan error)
+	go func() {
+		_, err := wd.Run(context.Background(), backend.RunParams{CheckID: "check2"})
+		errc <- err
+	}()
+
+	select {
+	case id := <-launched:
+		t.Fatalf("check launched while throttled: %v", id)
+	case <-time.After(2 * watchdogPollInterval):
+	}
+
+	wd.update(hostUsage{Memory: 50})
+	if err := <-errc; err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if id := <-launched; id != "check2" {
+		t.Errorf("unexpected check: want: check2, got: %v", id)
+	}
+	if wd.throttled != 1 {
+		t.Errorf("unexpected number of throttled checks: want: 1, got: %v", wd.throttled)
+	}
+}
+
+func TestWatchdog_Run_abort(t *testing.T) {
+	b := backendFunc(func(ctx context.Context, params backend.RunParams) (<-chan backend.RunResult, error) {
+		return make(chan backend.RunResult), nil
+	})
+
+	var skipped []string
+	skip := func(params backend.RunParams, err error) {
+		if !errors.Is(err, ErrHostResources) {
+			t.Errorf("unexpected skip error: %v", err)
+		}
+		skipped = append(skipped, params.CheckID)
+	}
+
+	cfg := config.WatchdogConfig{
+		Disk:   ptr(90.0),
+		Action: ptr(config.WatchdogActionAbort),
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	wd := newWatchdog(logger, b, cfg, "", skip)
+
+	if _, err := wd.Run(context.Background(), backend.RunParams{CheckID: "check1"}); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+
+	wd.update(hostUsage{Disk: 95})
+
+	// The abort is latched even if the usage goes below the
+	// threshold.
+	wd.update(hostUsage{Disk: 10})
+
+	if _, err := wd.Run(context.Background(), backend.RunParams{CheckID: "check2"}); !errors.Is(err, ErrHostResources) {
+		t.Errorf("unexpected error: want: %v, got: %v", ErrHostResources, err)
+	}
+	if len(skipped) != 1 || skipped[0] != "check2" {
+		t.Errorf("unexpected skipped checks: %v", skipped)
+	}
+	if wd.peak.Disk != 95 {
+		t.Errorf("unexpected peak disk usage: want: 95, got: %v", wd.peak.Disk)
+	}
+}
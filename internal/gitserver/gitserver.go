@@ -4,6 +4,7 @@
 package gitserver
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
 	"errors"
@@ -14,61 +15,332 @@ import (
 	"math/rand"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ErrGit is returned by [New] when the git command cannot be
+	// run.
+	ErrGit = errors.New("git cannot be run")
+
+	// ErrInsufficientSpace is returned when there is not enough
+	// free disk space to serve a repository or path.
+	ErrInsufficientSpace = errors.New("insufficient disk space")
+
+	// ErrSymlink is returned when a served path contains a
+	// symbolic link and the symbolic link policy is
+	// [SymlinkError].
+	ErrSymlink = errors.New("symbolic link found")
+
+	// ErrInvalidSymlinkPolicy is returned when a symbolic link
+	// policy is not valid.
+	ErrInvalidSymlinkPolicy = errors.New("invalid symbolic link policy")
+
+	// ErrInvalidSubdir is returned by [Server.AddRepository] when
+	// the subdirectory specified in the clone options is not
+	// valid.
+	ErrInvalidSubdir = errors.New("invalid subdirectory")
 )
 
-// ErrGit is returned by [New] when the git command cannot be run.
-var ErrGit = errors.New("git cannot be run")
+// TempDirPattern is the pattern of the temporary directories created
+// by the Git server to store the served repositories.
+const TempDirPattern = "lava-gitserver-*"
 
 // Server represents a Git server.
 type Server struct {
 	basePath string
 	httpsrv  *http.Server
 
+	// sf deduplicates concurrent requests to add the same
+	// repository or path, while different ones are prepared
+	// concurrently.
+	sf singleflight.Group
+
 	mu    sync.Mutex
-	repos map[string]string
-	paths map[string]string
+	repos map[repoKey]string
+	paths map[pathKey]string
+}
+
+// repoKey identifies a served repository.
+type repoKey struct {
+	path string
+	opts CloneOptions
+}
+
+// pathKey identifies a served path.
+type pathKey struct {
+	path string
+	opts PathOptions
+}
+
+// CloneOptions are the options used to clone a repository into the
+// Git server.
+type CloneOptions struct {
+	// Depth is the number of commits of the history of the
+	// cloned repository. Zero means full history.
+	Depth int
+
+	// Subdir is the path of a subdirectory of the repository,
+	// relative to its root. If it is not empty, only the
+	// subdirectory at HEAD is served, as a repository with a
+	// single commit whose root is the subdirectory. Depth is
+	// ignored in that case.
+	Subdir string
+}
+
+// PathOptions are the options used to serve a path.
+type PathOptions struct {
+	// Symlinks is the policy applied to the symbolic links
+	// found under the path.
+	Symlinks SymlinkPolicy
+}
+
+// SymlinkPolicy specifies how the symbolic links found under a served
+// path are handled.
+type SymlinkPolicy int
+
+// Symbolic link policies.
+const (
+	// SymlinkSkip ignores symbolic links. It is the default
+	// policy.
+	SymlinkSkip SymlinkPolicy = iota
+
+	// SymlinkFollow follows the symbolic links that point to
+	// files or directories under the served path. Symbolic
+	// links pointing outside of the served path, dangling
+	// symbolic links and symbolic links that would produce a
+	// loop are ignored.
+	SymlinkFollow
+
+	// SymlinkError makes serving the path fail if it contains
+	// symbolic links.
+	SymlinkError
+)
+
+var symlinkPolicyNames = map[SymlinkPolicy]string{
+	SymlinkSkip:   "skip",
+	SymlinkFollow: "follow",
+	SymlinkError:  "error",
+}
+
+// ParseSymlinkPolicy converts a string into a [SymlinkPolicy]. The
+// empty string is parsed as [SymlinkSkip].
+func ParseSymlinkPolicy(s string) (SymlinkPolicy, error) {
+	if s == "" {
+		return SymlinkSkip, nil
+	}
+	for k, v := range symlinkPolicyNames {
+		if v == strings.ToLower(s) {
+			return k, nil
+		}
+	}
+	return SymlinkSkip, fmt.Errorf("%w: %q", ErrInvalidSymlinkPolicy, s)
+}
+
+// String returns the string representation of the symbolic link
+// policy.
+func (p SymlinkPolicy) String() string {
+	if s, ok := symlinkPolicyNames[p]; ok {
+		return s
+	}
+	return strconv.Itoa(int(p))
 }
 
-// New creates a git server, but doesn't start it.
+// New creates a git server, but doesn't start it. The served
+// repositories are stored in the default directory for temporary
+// files.
 func New() (*Server, error) {
+	return NewWithDir("")
+}
+
+// NewWithDir creates a git server, but doesn't start it. The served
+// repositories are stored in a new temporary directory created inside
+// dir. If dir is the empty string, the default directory for
+// temporary files is used.
+func NewWithDir(dir string) (*Server, error) {
 	if err := checkGit(); err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrGit, err)
 	}
 
-	tmpPath, err := os.MkdirTemp("", "")
+	tmpPath, err := os.MkdirTemp(dir, TempDirPattern)
 	if err != nil {
 		return nil, fmt.Errorf("make temp dir: %w", err)
 	}
 
 	srv := &Server{
 		basePath: tmpPath,
-		repos:    make(map[string]string),
-		paths:    make(map[string]string),
+		repos:    make(map[repoKey]string),
+		paths:    make(map[pathKey]string),
 		httpsrv:  &http.Server{Handler: newSmartServer(tmpPath)},
 	}
 	return srv, nil
 }
 
 // AddRepository adds a repository to the Git server. It returns the
-// name of the new served repository.
-func (srv *Server) AddRepository(path string) (string, error) {
-	srv.mu.Lock()
-	defer srv.mu.Unlock()
+// name of the new served repository. If opts.Depth is not zero, the
+// repository is shallow cloned, which reduces disk usage and startup
+// time with big repositories. Bare repositories are served directly,
+// so they are neither cloned nor modified. Linked worktrees are
+// cloned from their Git directory, which shares the objects and refs
+// of the main worktree. If opts.Subdir is not empty, only the
+// contents of the subdirectory are read from the repository, which
+// keeps disk usage bounded with huge monorepos.
+func (srv *Server) AddRepository(path string, opts CloneOptions) (string, error) {
+	key := repoKey{path: path, opts: opts}
+	v, err, _ := srv.sf.Do(fmt.Sprintf("repo:%v:%q:%v", opts.Depth, opts.Subdir, path), func() (any, error) {
+		srv.mu.Lock()
+		repoName, ok := srv.repos[key]
+		srv.mu.Unlock()
+		if ok {
+			return repoName, nil
+		}
+
+		repoName, err := srv.cloneRepository(path, opts)
+		if err != nil {
+			return "", err
+		}
+
+		srv.mu.Lock()
+		srv.repos[key] = repoName
+		srv.mu.Unlock()
 
-	if repoName, ok := srv.repos[path]; ok {
 		return repoName, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// repoLayout is the layout of a Git repository on disk.
+type repoLayout int
+
+// Repository layouts.
+const (
+	layoutWorktree       repoLayout = iota // repository with a .git directory
+	layoutBare                             // bare repository
+	layoutLinkedWorktree                   // worktree created with "git worktree add"
+)
+
+// repoInfo describes a local Git repository.
+type repoInfo struct {
+	// layout is the layout of the repository.
+	layout repoLayout
+
+	// gitDir is the absolute path of the Git directory of the
+	// repository. In the case of linked worktrees, it is the
+	// private directory of the worktree.
+	gitDir string
+
+	// commonDir is the absolute path of the directory that
+	// contains the objects and refs shared by all the worktrees
+	// of the repository.
+	commonDir string
+}
+
+// inspectRepo returns the information of the Git repository at the
+// specified path.
+func inspectRepo(path string) (repoInfo, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command("git", "rev-parse", "--is-bare-repository", "--absolute-git-dir", "--git-common-dir")
+	cmd.Dir = path
+	cmd.Stderr = buf
+	out, err := cmd.Output()
+	if err != nil {
+		return repoInfo{}, fmt.Errorf("git rev-parse: %w: %#q", err, buf)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 3 {
+		return repoInfo{}, fmt.Errorf("unexpected git rev-parse output: %#q", out)
+	}
+
+	// The common dir is relative to the working directory of
+	// git rev-parse unless it is absolute. Symbolic links are
+	// resolved, so the paths can be compared.
+	commonDir := lines[2]
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(path, commonDir)
+	}
+	info := repoInfo{}
+	if info.gitDir, err = filepath.EvalSymlinks(lines[1]); err != nil {
+		return repoInfo{}, fmt.Errorf("eval symlinks: %w", err)
+	}
+	if info.commonDir, err = filepath.EvalSymlinks(commonDir); err != nil {
+		return repoInfo{}, fmt.Errorf("eval symlinks: %w", err)
+	}
+	if info.commonDir, err = filepath.Abs(info.commonDir); err != nil {
+		return repoInfo{}, fmt.Errorf("absolute path: %w", err)
+	}
+
+	switch {
+	case lines[0] == "true":
+		info.layout = layoutBare
+	case info.gitDir != info.commonDir:
+		info.layout = layoutLinkedWorktree
+	default:
+		info.layout = layoutWorktree
+	}
+	return info, nil
+}
+
+// cloneRepository clones the repository into the base path of the
+// Git server. It returns the name of the new repository.
+func (srv *Server) cloneRepository(path string, opts CloneOptions) (repoName string, err error) {
+	info, err := inspectRepo(path)
+	if err != nil {
+		return "", fmt.Errorf("inspect repository: %w", err)
+	}
+
+	if opts.Subdir != "" {
+		return srv.exportSubdir(path, opts.Subdir)
+	}
+
+	if info.layout == layoutBare {
+		return srv.linkRepository(info.gitDir)
+	}
+
+	// The objects of linked worktrees are stored in the common
+	// dir. The Git directory of the worktree points to it, so
+	// cloning it is equivalent to cloning the main worktree with
+	// the HEAD of the linked worktree.
+	src, usagePath := path, path
+	if info.layout == layoutLinkedWorktree {
+		src, usagePath = info.gitDir, info.commonDir
+	}
+
+	// The history of shallow clones is truncated, so the size of
+	// the source repository is not a good estimation.
+	if opts.Depth == 0 {
+		if err := srv.checkDiskSpace(usagePath, true); err != nil {
+			return "", err
+		}
 	}
 
 	dstPath, err := os.MkdirTemp(srv.basePath, "*.git")
 	if err != nil {
 		return "", fmt.Errorf("make temp dir: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			if rmErr := os.RemoveAll(dstPath); rmErr != nil {
+				err = errors.Join(err, fmt.Errorf("remove temp dir %s: %w", dstPath, rmErr))
+			}
+		}
+	}()
 
 	// --mirror implies --bare. Compared to --bare, --mirror not
 	// only maps local branches of the source to local branches of
@@ -76,8 +348,21 @@ func (srv *Server) AddRepository(path string) (string, error) {
 	// branches, notes etc.) and sets up a refspec configuration
 	// such that all these refs are overwritten by a git remote
 	// update in the target repository.
+	args := []string{"clone", "--mirror"}
+	if opts.Depth > 0 {
+		// --depth is ignored in local clones unless the
+		// source is specified as a file:// URL.
+		absPath, err := filepath.Abs(src)
+		if err != nil {
+			return "", fmt.Errorf("absolute path: %w", err)
+		}
+		src = (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String()
+		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+	}
+	args = append(args, src, dstPath)
+
 	buf := &bytes.Buffer{}
-	cmd := exec.Command("git", "clone", "--mirror", path, dstPath)
+	cmd := exec.Command("git", args...)
 	cmd.Stderr = buf
 	if err = cmd.Run(); err != nil {
 		return "", fmt.Errorf("git clone: %w: %#q", err, buf)
@@ -97,45 +382,245 @@ func (srv *Server) AddRepository(path string) (string, error) {
 		return "", fmt.Errorf("git branch: %w: %#q", err, buf)
 	}
 
-	repoName := filepath.Base(dstPath)
-	srv.repos[path] = repoName
+	repoName = filepath.Base(dstPath)
+	return repoName, nil
+}
+
+// linkRepository serves the specified bare repository directly. It
+// creates a symbolic link to the repository in the base path of the
+// Git server and returns the name of the new repository.
+func (srv *Server) linkRepository(gitDir string) (string, error) {
+	tmpPath, err := os.MkdirTemp(srv.basePath, "*.git")
+	if err != nil {
+		return "", fmt.Errorf("make temp dir: %w", err)
+	}
+
+	// Reuse the unique name of the temporary directory for the
+	// link.
+	if err := os.Remove(tmpPath); err != nil {
+		return "", fmt.Errorf("remove temp dir: %w", err)
+	}
+	if err := os.Symlink(gitDir, tmpPath); err != nil {
+		return "", fmt.Errorf("symlink: %w", err)
+	}
+	return filepath.Base(tmpPath), nil
+}
+
+// exportSubdir creates a Git repository with a single commit that
+// contains the specified subdirectory of the repository at path, as
+// it is at HEAD. Only the objects under the subdirectory are read
+// from the source repository. It returns the name of the new
+// repository.
+func (srv *Server) exportSubdir(path, subdir string) (repoName string, err error) {
+	subdir = filepath.Clean(subdir)
+	if filepath.IsAbs(subdir) || !filepath.IsLocal(subdir) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSubdir, subdir)
+	}
+
+	// The subdirectory is not checked out in bare repositories
+	// and it might not be in sparse worktrees. In that case, the
+	// disk usage cannot be estimated.
+	if usagePath := filepath.Join(path, subdir); isDir(usagePath) {
+		if err := srv.checkDiskSpace(usagePath, false); err != nil {
+			return "", err
+		}
+	}
+
+	dstPath, err := os.MkdirTemp(srv.basePath, "*.git")
+	if err != nil {
+		return "", fmt.Errorf("make temp dir: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rmErr := os.RemoveAll(dstPath); rmErr != nil {
+				err = errors.Join(err, fmt.Errorf("remove temp dir %s: %w", dstPath, rmErr))
+			}
+		}
+	}()
+
+	// The tree-ish "HEAD:<subdir>" makes the paths of the
+	// archive relative to the subdirectory.
+	treeish := "HEAD:" + filepath.ToSlash(subdir)
+	buf := &bytes.Buffer{}
+	cmd := exec.Command("git", "archive", "--format=tar", treeish)
+	cmd.Dir = path
+	cmd.Stderr = buf
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("stdout pipe: %w", err)
+	}
+	if err = cmd.Start(); err != nil {
+		return "", fmt.Errorf("git archive: %w", err)
+	}
+	if err = untar(dstPath, stdout); err != nil {
+		// Drain the output, so the command does not block.
+		io.Copy(io.Discard, stdout) //nolint:errcheck
+		cmd.Wait()                  //nolint:errcheck
+		return "", fmt.Errorf("extract archive: %w", err)
+	}
+	if err = cmd.Wait(); err != nil {
+		return "", fmt.Errorf("git archive %v: %w: %#q", treeish, err, buf)
+	}
+
+	if err = commitDir(dstPath); err != nil {
+		return "", err
+	}
+
+	repoName = filepath.Base(dstPath)
 	return repoName, nil
 }
 
+// untar extracts the tar archive read from r into dst. Only
+// directories, regular files and symbolic links are extracted.
+func untar(dst string, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			// End of archive.
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("next entry: %w", err)
+		}
+
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			// git archive stores the commit ID in a
+			// global header.
+			continue
+		}
+
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid path: %q", hdr.Name)
+		}
+		path := filepath.Join(dst, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return fmt.Errorf("make dir: %w", err)
+			}
+		case tar.TypeReg:
+			if err := writeFile(path, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return fmt.Errorf("write file: %w", err)
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, path); err != nil {
+				return fmt.Errorf("symlink: %w", err)
+			}
+		default:
+			slog.Warn("invalid archive entry", "name", hdr.Name, "type", hdr.Typeflag)
+		}
+	}
+}
+
+// writeFile writes the contents read from r into the file path,
+// which is created with the provided permissions.
+func writeFile(path string, r io.Reader, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("copy file: %w", err)
+	}
+	return f.Close()
+}
+
+// isDir reports whether path is an existing directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 // AddPath adds a file path to the Git server. The path is served as a
 // Git repository with a single commit. It returns the name of the new
-// served repository.
-func (srv *Server) AddPath(path string) (string, error) {
-	srv.mu.Lock()
-	defer srv.mu.Unlock()
+// served repository. The symbolic links found under the path are
+// handled according to opts.Symlinks. If the path itself is a
+// symbolic link, it is always resolved.
+func (srv *Server) AddPath(path string, opts PathOptions) (string, error) {
+	key := pathKey{path: path, opts: opts}
+	v, err, _ := srv.sf.Do(fmt.Sprintf("path:%v:%v", opts.Symlinks, path), func() (any, error) {
+		srv.mu.Lock()
+		repoName, ok := srv.paths[key]
+		srv.mu.Unlock()
+		if ok {
+			return repoName, nil
+		}
+
+		repoName, err := srv.initPath(path, opts)
+		if err != nil {
+			return "", err
+		}
+
+		srv.mu.Lock()
+		srv.paths[key] = repoName
+		srv.mu.Unlock()
 
-	if repoName, ok := srv.paths[path]; ok {
 		return repoName, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// initPath creates a Git repository with a single commit that
+// contains the provided path. It returns the name of the new
+// repository.
+func (srv *Server) initPath(path string, opts PathOptions) (repoName string, err error) {
+	if err := srv.checkDiskSpace(path, false); err != nil {
+		return "", err
 	}
 
 	dstPath, err := os.MkdirTemp(srv.basePath, "*.git")
 	if err != nil {
 		return "", fmt.Errorf("make temp dir: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			if rmErr := os.RemoveAll(dstPath); rmErr != nil {
+				err = errors.Join(err, fmt.Errorf("remove temp dir %s: %w", dstPath, rmErr))
+			}
+		}
+	}()
 
-	if err := fscopy(dstPath, path); err != nil {
+	if err := fscopy(dstPath, path, opts.Symlinks); err != nil {
 		return "", fmt.Errorf("copy files: %w", err)
 	}
 
+	if err = commitDir(dstPath); err != nil {
+		return "", err
+	}
+
+	repoName = filepath.Base(dstPath)
+	return repoName, nil
+}
+
+// commitDir creates a Git repository in dir with a single commit
+// that contains all its files.
+func commitDir(dir string) error {
 	buf := &bytes.Buffer{}
 	cmd := exec.Command("git", "init")
 	cmd.Stderr = buf
-	cmd.Dir = dstPath
-	if err = cmd.Run(); err != nil {
-		return "", fmt.Errorf("git init: %w: %#q", err, buf)
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git init: %w: %#q", err, buf)
 	}
 
 	cmd = exec.Command("git", "add", "-f", ".")
 	buf.Reset()
 	cmd.Stderr = buf
-	cmd.Dir = dstPath
-	if err = cmd.Run(); err != nil {
-		return "", fmt.Errorf("git add: %w: %#q", err, buf)
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git add: %w: %#q", err, buf)
 	}
 
 	cmd = exec.Command(
@@ -144,22 +629,135 @@ func (srv *Server) AddPath(path string) (string, error) {
 		"-c", "user.email=lava@lava.local",
 		"commit", "-m", "[auto] lava",
 	)
-	cmd.Dir = dstPath
+	cmd.Dir = dir
 	buf.Reset()
 	cmd.Stderr = buf
-	if err = cmd.Run(); err != nil {
-		return "", fmt.Errorf("git commit: %w: %#q", err, buf)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git commit: %w: %#q", err, buf)
+	}
+	return nil
+}
+
+// checkDiskSpace estimates the disk space needed to serve the
+// specified path and returns an [ErrInsufficientSpace] error if the
+// free space in the base directory of the server is not enough. If
+// withGit is false, .git files and directories are not taken into
+// account.
+func (srv *Server) checkDiskSpace(path string, withGit bool) error {
+	need, err := diskUsage(path, withGit)
+	if err != nil {
+		return fmt.Errorf("estimate disk usage: %w", err)
 	}
 
-	repoName := filepath.Base(dstPath)
-	srv.paths[path] = repoName
-	return repoName, nil
+	free, err := freeSpace(srv.basePath)
+	if err != nil {
+		// Do not fail if the free space cannot be
+		// determined.
+		slog.Warn("unable to get free disk space", "path", srv.basePath, "err", err)
+		return nil
+	}
+
+	slog.Debug("estimated disk needs", "path", path, "need", need, "dir", srv.basePath, "free", free)
+
+	if need > free {
+		return fmt.Errorf("%w: %v requires %v bytes, %v has %v bytes available", ErrInsufficientSpace, path, need, srv.basePath, free)
+	}
+	return nil
+}
+
+// diskUsage returns the total size of the regular files under path.
+// If withGit is false, .git files and directories are ignored.
+func diskUsage(path string, withGit bool) (uint64, error) {
+	var total uint64
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !withGit && d.Name() == ".git" && p != path {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("file info: %w", err)
+		}
+		total += uint64(info.Size())
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("walk dir: %w", err)
+	}
+	return total, nil
+}
+
+// freeSpace returns the free disk space available to unprivileged
+// users in the file system containing path. It is set by tests.
+var freeSpace = func(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("statfs: %w", err)
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
 }
 
 // fscopy copies src to dst recursively. It ignores all .git
-// files and directories.
-func fscopy(dst, src string) error {
-	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+// files and directories. Files are hard linked when possible and
+// copied otherwise. For instance, when src and dst are in different
+// file systems. Files are processed concurrently. Symbolic links are
+// handled according to the provided policy. If src is a symbolic
+// link, it is resolved.
+func fscopy(dst, src string, policy SymlinkPolicy) error {
+	root, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return fmt.Errorf("eval symlinks: %w", err)
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return fmt.Errorf("absolute path: %w", err)
+	}
+
+	c := &copier{
+		root:   root,
+		policy: policy,
+		sem:    make(chan struct{}, runtime.NumCPU()),
+	}
+
+	err = c.walk(dst, root, nil)
+
+	c.wg.Wait()
+
+	if err != nil {
+		return fmt.Errorf("walk dir: %w", err)
+	}
+	if err := errors.Join(c.errs...); err != nil {
+		return fmt.Errorf("copy files: %w", err)
+	}
+	return nil
+}
+
+// copier copies a file tree. It is used by [fscopy].
+type copier struct {
+	root   string
+	policy SymlinkPolicy
+
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+	sem  chan struct{}
+}
+
+// walk copies src to dst. src must be a path without symbolic links.
+// chain contains the directories entered by following symbolic
+// links, which are used to detect loops.
+func (c *copier) walk(dst, src string, chain []string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -200,27 +798,134 @@ func fscopy(dst, src string) error {
 				return nil
 			}
 
-			fsrc, err := os.Open(path)
-			if err != nil {
-				return fmt.Errorf("open source file: %w", err)
-			}
-			defer fsrc.Close()
-			fdst, err := os.Create(filepath.Join(dst, rel))
-			if err != nil {
-				return fmt.Errorf("create destination file: %w", err)
-			}
-			defer fdst.Close()
-			if _, err := io.Copy(fdst, fsrc); err != nil {
-				return fmt.Errorf("copy file: %w", err)
-			}
+			// Parent directories are always visited
+			// before their files, so they already exist.
+			c.copy(filepath.Join(dst, rel), path)
+		case typ&fs.ModeSymlink != 0:
+			return c.symlink(filepath.Join(dst, rel), path, chain)
 		default:
 			slog.Warn("invalid file type", "path", path, "mode", typ)
 		}
 		return nil
 	})
+}
+
+// symlink handles the symbolic link path according to the policy of
+// the copier. dst is the destination path of the symbolic link.
+func (c *copier) symlink(dst, path string, chain []string) error {
+	switch c.policy {
+	case SymlinkSkip:
+		slog.Warn("ignoring symbolic link", "path", path)
+		return nil
+	case SymlinkError:
+		return fmt.Errorf("%w: %v", ErrSymlink, path)
+	case SymlinkFollow:
+		// Handled below.
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidSymlinkPolicy, c.policy)
+	}
 
+	if filepath.Base(path) == ".git" {
+		return nil
+	}
+
+	target, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		return fmt.Errorf("walk dir: %w", err)
+		slog.Warn("ignoring dangling symbolic link", "path", path, "err", err)
+		return nil
+	}
+
+	if !isWithin(c.root, target) {
+		slog.Warn("ignoring symbolic link outside of the root path", "path", path, "target", target, "root", c.root)
+		return nil
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+
+	switch {
+	case info.Mode().IsRegular():
+		c.copy(dst, target)
+	case info.IsDir():
+		// The parent directory of the symbolic link does
+		// not contain symbolic links, because they are not
+		// traversed by the walk.
+		dirs := append([]string{filepath.Dir(path)}, chain...)
+		for _, dir := range dirs {
+			if isWithin(target, dir) {
+				slog.Warn("ignoring symbolic link loop", "path", path, "target", target)
+				return nil
+			}
+		}
+
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return fmt.Errorf("make dir: %w", err)
+		}
+
+		newChain := make([]string, 0, len(chain)+1)
+		newChain = append(newChain, chain...)
+		newChain = append(newChain, target)
+		if err := c.walk(dst, target, newChain); err != nil {
+			return fmt.Errorf("follow %v: %w", path, err)
+		}
+	default:
+		slog.Warn("invalid file type", "path", target, "mode", info.Mode().Type())
+	}
+	return nil
+}
+
+// copy copies src into dst concurrently. Errors are stored in the
+// copier.
+func (c *copier) copy(dst, src string) {
+	c.sem <- struct{}{}
+	c.wg.Add(1)
+	go func() {
+		defer func() {
+			<-c.sem
+			c.wg.Done()
+		}()
+
+		if err := linkOrCopy(dst, src); err != nil {
+			c.mu.Lock()
+			c.errs = append(c.errs, fmt.Errorf("%v: %w", src, err))
+			c.mu.Unlock()
+		}
+	}()
+}
+
+// isWithin reports whether path is parent or one of its
+// descendants. Both paths must be absolute and clean.
+func isWithin(parent, path string) bool {
+	rel, err := filepath.Rel(parent, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// linkOrCopy creates dst as a hard link to src. If the hard link
+// cannot be created, src is copied into dst.
+func linkOrCopy(dst, src string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	fsrc, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open source file: %w", err)
+	}
+	defer fsrc.Close()
+
+	fdst, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create destination file: %w", err)
+	}
+	defer fdst.Close()
+
+	if _, err := io.Copy(fdst, fsrc); err != nil {
+		return fmt.Errorf("copy file: %w", err)
 	}
 	return nil
 }
@@ -252,6 +957,32 @@ func (srv *Server) Serve(l net.Listener) error {
 	return srv.httpsrv.Serve(l)
 }
 
+// Reset removes the served repositories and paths, so they are
+// copied again the next time they are added. It allows to serve the
+// changes made to a repository or path after it was added. It must
+// not be called concurrently with [Server.AddRepository] or
+// [Server.AddPath].
+func (srv *Server) Reset() error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	entries, err := os.ReadDir(srv.basePath)
+	if err != nil {
+		return fmt.Errorf("read base path: %w", err)
+	}
+	for _, e := range entries {
+		// Bare repositories are linked, so only the links are
+		// removed.
+		if err := os.RemoveAll(filepath.Join(srv.basePath, e.Name())); err != nil {
+			return fmt.Errorf("remove repository: %w", err)
+		}
+	}
+
+	srv.repos = make(map[repoKey]string)
+	srv.paths = make(map[pathKey]string)
+	return nil
+}
+
 // Close stops the server and deletes any temporary directory created
 // to store the repositories.
 func (srv *Server) Close() error {
@@ -284,14 +1015,38 @@ func newSmartServer(basePath string) *smartServer {
 // pathRE is used to parse HTTP requests.
 var pathRE = regexp.MustCompile(`^(/.*?)(/.*)$`)
 
-// ServeHTTP implements the smart server router.
+// ServeHTTP implements the smart server router. Requests are logged
+// at debug level.
 func (srv *smartServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	lw := &loggingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+
+	repo := srv.route(lw, r)
+
+	slog.Debug("git server request",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"repo", repo,
+		"status", lw.status,
+		"duration", time.Since(start),
+		"bytes", lw.n,
+	)
+}
+
+// route dispatches the request to the corresponding handler. It
+// returns the name of the requested repository, if any.
+func (srv *smartServer) route(w http.ResponseWriter, r *http.Request) (repo string) {
+	if path.Clean(r.URL.Path) == "/healthz" {
+		srv.handleHealthz(w, r)
+		return ""
+	}
+
 	matches := pathRE.FindStringSubmatch(path.Clean(r.URL.Path))
 	if matches == nil {
 		w.WriteHeader(http.StatusNotFound)
-		return
+		return ""
 	}
-	repo := matches[1]
+	repo = matches[1]
 	endpoint := matches[2]
 
 	switch endpoint {
@@ -302,6 +1057,47 @@ func (srv *smartServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	default:
 		w.WriteHeader(http.StatusNotFound)
 	}
+	return repo
+}
+
+// handleHealthz handles requests to /healthz. It reports whether the
+// server is able to run git.
+func (srv *smartServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := checkGit(); err != nil {
+		slog.Warn("git server health check failed", "err", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	fmt.Fprintln(w, "ok")
+}
+
+// loggingResponseWriter is an [http.ResponseWriter] that records the
+// status code and the number of bytes written.
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	n      int
+}
+
+// WriteHeader records the status code and calls the WriteHeader
+// method of the underlying [http.ResponseWriter].
+func (lw *loggingResponseWriter) WriteHeader(status int) {
+	lw.status = status
+	lw.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written and calls the Write
+// method of the underlying [http.ResponseWriter].
+func (lw *loggingResponseWriter) Write(p []byte) (int, error) {
+	n, err := lw.ResponseWriter.Write(p)
+	lw.n += n
+	return n, err
 }
 
 // handleInfoRefs handles requests to /repo/info/refs.
@@ -317,10 +1113,13 @@ func (srv *smartServer) handleInfoRefs(w http.ResponseWriter, r *http.Request, r
 	}
 
 	buf := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
 	repoPath := filepath.Join(srv.basePath, repo)
 	cmd := exec.Command("git-upload-pack", "--advertise-refs", repoPath)
 	cmd.Stdout = buf
+	cmd.Stderr = stderr
 	if err := cmd.Run(); err != nil {
+		slog.Warn("git-upload-pack --advertise-refs failed", "repo", repo, "err", err, "stderr", stderr.String())
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -339,11 +1138,14 @@ func (srv *smartServer) handleGitUploadPack(w http.ResponseWriter, r *http.Reque
 	}
 
 	buf := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
 	repoPath := filepath.Join(srv.basePath, repo)
 	cmd := exec.Command("git-upload-pack", "--stateless-rpc", repoPath)
 	cmd.Stdin = r.Body
 	cmd.Stdout = buf
+	cmd.Stderr = stderr
 	if err := cmd.Run(); err != nil {
+		slog.Warn("git-upload-pack --stateless-rpc failed", "repo", repo, "err", err, "stderr", stderr.String())
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
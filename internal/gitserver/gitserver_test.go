@@ -3,13 +3,19 @@
 package gitserver
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/jroimartin/clilog"
@@ -56,7 +62,7 @@ func TestServer_AddRepository(t *testing.T) {
 
 	ln := <-lnc
 
-	repoName, err := gs.AddRepository(tmpPath)
+	repoName, err := gs.AddRepository(tmpPath, CloneOptions{})
 	if err != nil {
 		t.Fatalf("unable to add a repository: %v", err)
 	}
@@ -72,6 +78,382 @@ func TestServer_AddRepository(t *testing.T) {
 	}
 }
 
+func TestServer_AddRepository_depth(t *testing.T) {
+	// Not parallel: uses global test hook.
+	defer func() { testHookServerServe = nil }()
+
+	tmpPath := t.TempDir()
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(filepath.Join(tmpPath, "foo.txt"), []byte(fmt.Sprint(i)), 0644); err != nil {
+			t.Fatalf("unable to write file: %v", err)
+		}
+		cmds := [][]string{
+			{"git", "init"},
+			{"git", "add", "."},
+			{"git", "-c", "user.name=lava", "-c", "user.email=lava@lava.local", "commit", "-m", fmt.Sprintf("commit %v", i)},
+		}
+		for _, args := range cmds {
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Dir = tmpPath
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("unable to run %v: %v: %s", args, err, out)
+			}
+		}
+	}
+
+	gs, err := New()
+	if err != nil {
+		t.Fatalf("unable to create a server: %v", err)
+	}
+	defer gs.Close()
+
+	lnc := make(chan net.Listener)
+	testHookServerServe = func(gs *Server, ln net.Listener) {
+		lnc <- ln
+	}
+
+	go gs.ListenAndServe("127.0.0.1:0") //nolint:errcheck
+
+	ln := <-lnc
+
+	repoName, err := gs.AddRepository(tmpPath, CloneOptions{Depth: 1})
+	if err != nil {
+		t.Fatalf("unable to add a repository: %v", err)
+	}
+
+	repoPath, err := gittest.CloneTemp(fmt.Sprintf("http://%v/%s", ln.Addr(), repoName))
+	if err != nil {
+		t.Fatalf("unable to clone the repo %s: %v", repoName, err)
+	}
+	defer os.RemoveAll(repoPath)
+
+	if _, err := os.Stat(filepath.Join(repoPath, "foo.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", repoPath, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("unable to count commits: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "1" {
+		t.Errorf("unexpected number of commits: got: %v, want: 1", got)
+	}
+
+	fullRepoName, err := gs.AddRepository(tmpPath, CloneOptions{})
+	if err != nil {
+		t.Fatalf("unable to add a repository: %v", err)
+	}
+	if fullRepoName == repoName {
+		t.Errorf("full clone shares repository with shallow clone: %v", repoName)
+	}
+}
+
+func TestServer_AddRepository_layouts(t *testing.T) {
+	// Not parallel: uses global test hook.
+	defer func() { testHookServerServe = nil }()
+
+	tmpPath := t.TempDir()
+	mainPath := filepath.Join(tmpPath, "main")
+	barePath := filepath.Join(tmpPath, "bare.git")
+	worktreePath := filepath.Join(tmpPath, "worktree")
+
+	if err := os.Mkdir(mainPath, 0755); err != nil {
+		t.Fatalf("unable to make dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mainPath, "foo.txt"), []byte("foo"), 0644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	runGit(t, mainPath, "init")
+	runGit(t, mainPath, "add", ".")
+	runGit(t, mainPath, "-c", "user.name=lava", "-c", "user.email=lava@lava.local", "commit", "-m", "foo")
+	runGit(t, tmpPath, "clone", "--bare", mainPath, barePath)
+	runGit(t, mainPath, "worktree", "add", "-b", "feature", worktreePath)
+	if err := os.WriteFile(filepath.Join(worktreePath, "bar.txt"), []byte("bar"), 0644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	runGit(t, worktreePath, "add", ".")
+	runGit(t, worktreePath, "-c", "user.name=lava", "-c", "user.email=lava@lava.local", "commit", "-m", "bar")
+
+	gs, err := New()
+	if err != nil {
+		t.Fatalf("unable to create a server: %v", err)
+	}
+	defer gs.Close()
+
+	lnc := make(chan net.Listener)
+	testHookServerServe = func(gs *Server, ln net.Listener) {
+		lnc <- ln
+	}
+
+	go gs.ListenAndServe("127.0.0.1:0") //nolint:errcheck
+
+	ln := <-lnc
+
+	tests := []struct {
+		name      string
+		path      string
+		opts      CloneOptions
+		wantFiles []string
+	}{
+		{
+			name:      "bare",
+			path:      barePath,
+			wantFiles: []string{"foo.txt"},
+		},
+		{
+			name:      "worktree",
+			path:      worktreePath,
+			wantFiles: []string{"foo.txt", "bar.txt"},
+		},
+		{
+			name:      "worktree shallow",
+			path:      worktreePath,
+			opts:      CloneOptions{Depth: 1},
+			wantFiles: []string{"foo.txt", "bar.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoName, err := gs.AddRepository(tt.path, tt.opts)
+			if err != nil {
+				t.Fatalf("unable to add a repository: %v", err)
+			}
+
+			repoPath, err := gittest.CloneTemp(fmt.Sprintf("http://%v/%s", ln.Addr(), repoName))
+			if err != nil {
+				t.Fatalf("unable to clone the repo %s: %v", repoName, err)
+			}
+			defer os.RemoveAll(repoPath)
+
+			for _, f := range tt.wantFiles {
+				if _, err := os.Stat(filepath.Join(repoPath, f)); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+
+	// Bare repositories are served directly, so no branch is
+	// created.
+	entries, err := os.ReadDir(filepath.Join(barePath, "refs", "heads"))
+	if err != nil {
+		t.Fatalf("unable to read refs: %v", err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "lava-") {
+			t.Errorf("bare repository was modified: %v", e.Name())
+		}
+	}
+}
+
+func TestServer_AddRepository_subdir(t *testing.T) {
+	// Not parallel: uses global test hook.
+	defer func() { testHookServerServe = nil }()
+
+	tmpPath := t.TempDir()
+	mainPath := filepath.Join(tmpPath, "main")
+	barePath := filepath.Join(tmpPath, "bare.git")
+
+	files := []string{"foo.txt", "svc/a/bar.txt", "svc/a/sub/baz.txt", "svc/b/qux.txt"}
+	for _, f := range files {
+		p := filepath.Join(mainPath, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("unable to make dir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0644); err != nil {
+			t.Fatalf("unable to write file: %v", err)
+		}
+	}
+	runGit(t, mainPath, "init")
+	runGit(t, mainPath, "add", ".")
+	runGit(t, mainPath, "-c", "user.name=lava", "-c", "user.email=lava@lava.local", "commit", "-m", "files")
+	runGit(t, tmpPath, "clone", "--bare", mainPath, barePath)
+
+	gs, err := New()
+	if err != nil {
+		t.Fatalf("unable to create a server: %v", err)
+	}
+	defer gs.Close()
+
+	lnc := make(chan net.Listener)
+	testHookServerServe = func(gs *Server, ln net.Listener) {
+		lnc <- ln
+	}
+
+	go gs.ListenAndServe("127.0.0.1:0") //nolint:errcheck
+
+	ln := <-lnc
+
+	tests := []struct {
+		name       string
+		path       string
+		subdir     string
+		wantFiles  []string
+		wantAbsent []string
+		wantErr    error
+	}{
+		{
+			name:       "worktree",
+			path:       mainPath,
+			subdir:     "svc/a",
+			wantFiles:  []string{"bar.txt", "sub/baz.txt"},
+			wantAbsent: []string{"foo.txt", "svc", "qux.txt"},
+		},
+		{
+			name:       "bare",
+			path:       barePath,
+			subdir:     "svc/b",
+			wantFiles:  []string{"qux.txt"},
+			wantAbsent: []string{"foo.txt", "bar.txt"},
+		},
+		{
+			name:    "outside",
+			path:    mainPath,
+			subdir:  "../main",
+			wantErr: ErrInvalidSubdir,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoName, err := gs.AddRepository(tt.path, CloneOptions{Subdir: tt.subdir})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			repoPath, err := gittest.CloneTemp(fmt.Sprintf("http://%v/%s", ln.Addr(), repoName))
+			if err != nil {
+				t.Fatalf("unable to clone the repo %s: %v", repoName, err)
+			}
+			defer os.RemoveAll(repoPath)
+
+			for _, f := range tt.wantFiles {
+				if _, err := os.Stat(filepath.Join(repoPath, f)); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			for _, f := range tt.wantAbsent {
+				if _, err := os.Stat(filepath.Join(repoPath, f)); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("%v: file was not excluded: %v", f, err)
+				}
+			}
+		})
+	}
+}
+
+func TestInspectRepo(t *testing.T) {
+	tmpPath := t.TempDir()
+	mainPath := filepath.Join(tmpPath, "main")
+	barePath := filepath.Join(tmpPath, "bare.git")
+	worktreePath := filepath.Join(tmpPath, "worktree")
+
+	if err := os.Mkdir(mainPath, 0755); err != nil {
+		t.Fatalf("unable to make dir: %v", err)
+	}
+	runGit(t, mainPath, "init")
+	runGit(t, mainPath, "-c", "user.name=lava", "-c", "user.email=lava@lava.local", "commit", "--allow-empty", "-m", "empty")
+	runGit(t, tmpPath, "init", "--bare", barePath)
+	runGit(t, mainPath, "worktree", "add", worktreePath)
+
+	// Resolve symbolic links in the temporary directory, so the
+	// paths can be compared with the ones returned by git.
+	mainPath, err := filepath.EvalSymlinks(mainPath)
+	if err != nil {
+		t.Fatalf("eval symlinks: %v", err)
+	}
+	barePath, err = filepath.EvalSymlinks(barePath)
+	if err != nil {
+		t.Fatalf("eval symlinks: %v", err)
+	}
+	worktreePath, err = filepath.EvalSymlinks(worktreePath)
+	if err != nil {
+		t.Fatalf("eval symlinks: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		wantLayout    repoLayout
+		wantCommonDir string
+	}{
+		{
+			name:          "worktree",
+			path:          mainPath,
+			wantLayout:    layoutWorktree,
+			wantCommonDir: filepath.Join(mainPath, ".git"),
+		},
+		{
+			name:          "bare",
+			path:          barePath,
+			wantLayout:    layoutBare,
+			wantCommonDir: barePath,
+		},
+		{
+			name:          "linked worktree",
+			path:          worktreePath,
+			wantLayout:    layoutLinkedWorktree,
+			wantCommonDir: filepath.Join(mainPath, ".git"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := inspectRepo(tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.layout != tt.wantLayout {
+				t.Errorf("unexpected layout: got: %v, want: %v", info.layout, tt.wantLayout)
+			}
+			if info.commonDir != tt.wantCommonDir {
+				t.Errorf("unexpected common dir: got: %v, want: %v", info.commonDir, tt.wantCommonDir)
+			}
+		})
+	}
+}
+
+// runGit runs git with the provided arguments in the specified
+// directory.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("unable to run git %v: %v: %s", args, err, out)
+	}
+}
+
+func TestServer_AddRepository_remove_on_error(t *testing.T) {
+	tmpPath, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatalf("unable to create a temporary dir")
+	}
+	defer os.RemoveAll(tmpPath)
+
+	gs, err := New()
+	if err != nil {
+		t.Fatalf("unable to create a server: %v", err)
+	}
+	defer gs.Close()
+
+	if _, err = gs.AddRepository(tmpPath, CloneOptions{}); err == nil {
+		t.Fatal("expected error adding repository")
+	}
+
+	entries, err := os.ReadDir(gs.basePath)
+	if err != nil {
+		t.Fatalf("unable to read base path: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("temporary files were not removed: %v", entries)
+	}
+}
+
 func TestServer_AddRepository_no_repo(t *testing.T) {
 	tmpPath, err := os.MkdirTemp("", "")
 	if err != nil {
@@ -85,7 +467,7 @@ func TestServer_AddRepository_no_repo(t *testing.T) {
 	}
 	defer gs.Close() //nolint:staticcheck
 
-	if _, err = gs.AddRepository(tmpPath); err == nil {
+	if _, err = gs.AddRepository(tmpPath, CloneOptions{}); err == nil {
 		t.Fatal("expected error adding repository")
 	}
 }
@@ -97,7 +479,7 @@ func TestServer_AddRepository_invalid_dir(t *testing.T) {
 	}
 	defer gs.Close() //nolint:staticcheck
 
-	if _, err = gs.AddRepository("/fakedir"); err == nil {
+	if _, err = gs.AddRepository("/fakedir", CloneOptions{}); err == nil {
 		t.Fatal("expected error adding repository")
 	}
 }
@@ -111,12 +493,12 @@ func TestServer_AddRepository_invalid_dir_2(t *testing.T) {
 
 	gs := &Server{
 		basePath: "testdata/fakedir",
-		repos:    make(map[string]string),
+		repos:    make(map[repoKey]string),
 		httpsrv:  &http.Server{Handler: newSmartServer(tmpPath)},
 	}
 	defer gs.Close() //nolint:staticcheck
 
-	if _, err = gs.AddRepository(tmpPath); err == nil {
+	if _, err = gs.AddRepository(tmpPath, CloneOptions{}); err == nil {
 		t.Fatal("expected error adding repository")
 	}
 }
@@ -128,11 +510,11 @@ func TestServer_AddRepository_do_not_cache_error(t *testing.T) {
 	}
 	defer gs.Close() //nolint:staticcheck
 
-	if _, err = gs.AddRepository("/fakedir"); err == nil {
+	if _, err = gs.AddRepository("/fakedir", CloneOptions{}); err == nil {
 		t.Fatal("expected error adding repository")
 	}
 
-	if _, err = gs.AddRepository("/fakedir"); err == nil {
+	if _, err = gs.AddRepository("/fakedir", CloneOptions{}); err == nil {
 		t.Fatal("expected error adding repository")
 	}
 }
@@ -150,11 +532,11 @@ func TestServer_AddRepository_already_added(t *testing.T) {
 	}
 	defer gs.Close()
 
-	repoName, err := gs.AddRepository(tmpPath)
+	repoName, err := gs.AddRepository(tmpPath, CloneOptions{})
 	if err != nil {
 		t.Fatalf("unable to add a repository: %v", err)
 	}
-	repoName2, err := gs.AddRepository(tmpPath)
+	repoName2, err := gs.AddRepository(tmpPath, CloneOptions{})
 	if err != nil {
 		t.Fatalf("unable to add a repository: %v", err)
 	}
@@ -164,6 +546,42 @@ func TestServer_AddRepository_already_added(t *testing.T) {
 	}
 }
 
+func TestServer_Reset(t *testing.T) {
+	tmpPath, err := gittest.ExtractTemp("testdata/repo.tar")
+	if err != nil {
+		t.Fatalf("unable to create a repository: %v", err)
+	}
+	defer os.RemoveAll(tmpPath)
+
+	gs, err := New()
+	if err != nil {
+		t.Fatalf("unable to create a server: %v", err)
+	}
+	defer gs.Close()
+
+	repoName, err := gs.AddRepository(tmpPath, CloneOptions{})
+	if err != nil {
+		t.Fatalf("unable to add a repository: %v", err)
+	}
+
+	if err := gs.Reset(); err != nil {
+		t.Fatalf("unable to reset the server: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(gs.basePath, repoName)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("repository was not removed: %v", err)
+	}
+
+	repoName2, err := gs.AddRepository(tmpPath, CloneOptions{})
+	if err != nil {
+		t.Fatalf("unable to add a repository: %v", err)
+	}
+
+	if repoName == repoName2 {
+		t.Errorf("repository was not copied again: %v", repoName2)
+	}
+}
+
 func TestServer_AddPath(t *testing.T) {
 	tests := []struct {
 		name string
@@ -228,7 +646,7 @@ func TestServer_AddPath(t *testing.T) {
 
 			ln := <-lnc
 
-			repoName, err := gs.AddPath(path)
+			repoName, err := gs.AddPath(path, PathOptions{})
 			if err != nil {
 				t.Fatalf("unable to add a path: %v", err)
 			}
@@ -253,7 +671,7 @@ func TestServer_AddPath_invalid_path(t *testing.T) {
 	}
 	defer gs.Close() //nolint:staticcheck
 
-	if _, err = gs.AddPath("/fakedir"); err == nil {
+	if _, err = gs.AddPath("/fakedir", PathOptions{}); err == nil {
 		t.Fatal("expected error adding path")
 	}
 }
@@ -265,11 +683,11 @@ func TestServer_AddPath_do_not_cache_error(t *testing.T) {
 	}
 	defer gs.Close() //nolint:staticcheck
 
-	if _, err = gs.AddPath("/fakedir"); err == nil {
+	if _, err = gs.AddPath("/fakedir", PathOptions{}); err == nil {
 		t.Fatal("expected error adding path")
 	}
 
-	if _, err = gs.AddPath("/fakedir"); err == nil {
+	if _, err = gs.AddPath("/fakedir", PathOptions{}); err == nil {
 		t.Fatal("expected error adding path")
 	}
 }
@@ -281,12 +699,12 @@ func TestServer_AddPath_already_added(t *testing.T) {
 	}
 	defer gs.Close()
 
-	repoName, err := gs.AddPath("testdata/dir")
+	repoName, err := gs.AddPath("testdata/dir", PathOptions{})
 	if err != nil {
 		t.Fatalf("unable to add a path: %v", err)
 	}
 
-	repoName2, err := gs.AddPath("testdata/dir")
+	repoName2, err := gs.AddPath("testdata/dir", PathOptions{})
 	if err != nil {
 		t.Fatalf("unable to add a path: %v", err)
 	}
@@ -295,3 +713,409 @@ func TestServer_AddPath_already_added(t *testing.T) {
 		t.Fatalf("%s should be the same as %s", repoName, repoName2)
 	}
 }
+
+func TestFscopy(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"foo.txt":         "foo",
+		"dir/bar.txt":     "bar",
+		"dir/sub/baz.txt": "baz",
+		".git/config":     "ignored",
+	}
+	for name, content := range files {
+		p := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("unable to create dir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write file: %v", err)
+		}
+	}
+
+	dst := t.TempDir()
+	if err := fscopy(dst, src, SymlinkSkip); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, content := range files {
+		b, err := os.ReadFile(filepath.Join(dst, name))
+		if filepath.Dir(name) == ".git" {
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("%v: .git was not ignored: %v", name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unable to read file: %v", err)
+		}
+		if string(b) != content {
+			t.Errorf("%v: unexpected content: got: %q, want: %q", name, b, content)
+		}
+	}
+}
+
+func TestFscopy_symlinks(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "outside.txt"), []byte("outside"), 0644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "dir"), 0755); err != nil {
+		t.Fatalf("unable to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "dir", "foo.txt"), []byte("foo"), 0644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	links := map[string]string{
+		"file.txt":     filepath.Join("dir", "foo.txt"),
+		"linkdir":      "dir",
+		"outside.txt":  filepath.Join(outside, "outside.txt"),
+		"dangling.txt": "missing.txt",
+		"dir/loop":     "..",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(src, name)); err != nil {
+			t.Fatalf("unable to create symlink: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		policy     SymlinkPolicy
+		want       []string
+		wantAbsent []string
+		wantErr    error
+	}{
+		{
+			name:       "skip",
+			policy:     SymlinkSkip,
+			want:       []string{"dir/foo.txt"},
+			wantAbsent: []string{"file.txt", "linkdir", "outside.txt", "dangling.txt", "dir/loop"},
+		},
+		{
+			name:       "follow",
+			policy:     SymlinkFollow,
+			want:       []string{"dir/foo.txt", "file.txt", "linkdir/foo.txt"},
+			wantAbsent: []string{"outside.txt", "dangling.txt", "dir/loop", "linkdir/loop"},
+		},
+		{
+			name:    "error",
+			policy:  SymlinkError,
+			wantErr: ErrSymlink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := t.TempDir()
+			err := fscopy(dst, src, tt.policy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+
+			for _, name := range tt.want {
+				if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+					t.Errorf("%v: unexpected error: %v", name, err)
+				}
+			}
+			for _, name := range tt.wantAbsent {
+				if _, err := os.Lstat(filepath.Join(dst, name)); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("%v: file was not ignored: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSymlinkPolicy(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    SymlinkPolicy
+		wantErr error
+	}{
+		{s: "", want: SymlinkSkip},
+		{s: "skip", want: SymlinkSkip},
+		{s: "follow", want: SymlinkFollow},
+		{s: "Error", want: SymlinkError},
+		{s: "invalid", wantErr: ErrInvalidSymlinkPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseSymlinkPolicy(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got: %v, want: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected policy: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkFscopy(b *testing.B) {
+	src := b.TempDir()
+	for i := 0; i < 1000; i++ {
+		p := filepath.Join(src, fmt.Sprintf("dir%v", i%10), fmt.Sprintf("file%v.txt", i))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			b.Fatalf("unable to create dir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(strings.Repeat("x", 1024)), 0644); err != nil {
+			b.Fatalf("unable to write file: %v", err)
+		}
+	}
+
+	tmpPath := b.TempDir()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		dst, err := os.MkdirTemp(tmpPath, "")
+		if err != nil {
+			b.Fatalf("unable to create temp dir: %v", err)
+		}
+		b.StartTimer()
+
+		if err := fscopy(dst, src, SymlinkSkip); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestLinkOrCopy(t *testing.T) {
+	tmpPath := t.TempDir()
+
+	src := filepath.Join(tmpPath, "src")
+	if err := os.WriteFile(src, []byte("content"), 0644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+
+	t.Run("link", func(t *testing.T) {
+		dst := filepath.Join(tmpPath, "link")
+		if err := linkOrCopy(dst, src); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		srcInfo, err := os.Stat(src)
+		if err != nil {
+			t.Fatalf("unable to stat file: %v", err)
+		}
+		dstInfo, err := os.Stat(dst)
+		if err != nil {
+			t.Fatalf("unable to stat file: %v", err)
+		}
+		if !os.SameFile(srcInfo, dstInfo) {
+			t.Errorf("file was not linked")
+		}
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		// Linking fails because the destination file
+		// already exists.
+		dst := filepath.Join(tmpPath, "copy")
+		if err := os.WriteFile(dst, []byte("old content"), 0644); err != nil {
+			t.Fatalf("unable to write file: %v", err)
+		}
+
+		if err := linkOrCopy(dst, src); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		b, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatalf("unable to read file: %v", err)
+		}
+		if string(b) != "content" {
+			t.Errorf("unexpected content: got: %q, want: %q", b, "content")
+		}
+	})
+}
+
+func TestServer_AddRepository_concurrent(t *testing.T) {
+	tmpPath, err := gittest.ExtractTemp("testdata/repo.tar")
+	if err != nil {
+		t.Fatalf("unable to create a repository: %v", err)
+	}
+	defer os.RemoveAll(tmpPath)
+
+	gs, err := New()
+	if err != nil {
+		t.Fatalf("unable to create a server: %v", err)
+	}
+	defer gs.Close()
+
+	const n = 10
+
+	var wg sync.WaitGroup
+	names := make([]string, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			names[i], errs[i] = gs.AddRepository(tmpPath, CloneOptions{})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("unable to add a repository: %v", errs[i])
+		}
+		if names[i] != names[0] {
+			t.Errorf("unexpected repository name: got: %v, want: %v", names[i], names[0])
+		}
+	}
+
+	entries, err := os.ReadDir(gs.basePath)
+	if err != nil {
+		t.Fatalf("unable to read base path: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("unexpected number of repositories: got: %v, want: 1", len(entries))
+	}
+}
+
+func TestSmartServer_healthz(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "GET",
+			method:     "GET",
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+			wantBody:   "ok\n",
+		},
+		{
+			name:       "POST",
+			method:     "POST",
+			path:       "/healthz",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown path",
+			method:     "GET",
+			path:       "/unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newSmartServer(t.TempDir())
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			srv.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("unexpected status: got: %v, want: %v", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("unexpected body: got: %q, want: %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestLoggingResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	lw := &loggingResponseWriter{ResponseWriter: rec, status: http.StatusOK}
+
+	lw.WriteHeader(http.StatusTeapot)
+	fmt.Fprint(lw, "hello")
+	fmt.Fprint(lw, " world")
+
+	if lw.status != http.StatusTeapot {
+		t.Errorf("unexpected status: got: %v, want: %v", lw.status, http.StatusTeapot)
+	}
+	if lw.n != len("hello world") {
+		t.Errorf("unexpected number of bytes: got: %v, want: %v", lw.n, len("hello world"))
+	}
+}
+
+func TestNewWithDir(t *testing.T) {
+	dir := t.TempDir()
+
+	gs, err := NewWithDir(dir)
+	if err != nil {
+		t.Fatalf("unable to create a server: %v", err)
+	}
+	defer gs.Close()
+
+	if filepath.Dir(gs.basePath) != dir {
+		t.Errorf("unexpected base path: got: %v, want a directory in %v", gs.basePath, dir)
+	}
+}
+
+func TestNewWithDir_invalid_dir(t *testing.T) {
+	if _, err := NewWithDir("/fakedir"); err == nil {
+		t.Fatal("expected error creating server")
+	}
+}
+
+func TestServer_AddPath_insufficient_space(t *testing.T) {
+	oldFreeSpace := freeSpace
+	defer func() { freeSpace = oldFreeSpace }()
+	freeSpace = func(string) (uint64, error) { return 0, nil }
+
+	gs, err := New()
+	if err != nil {
+		t.Fatalf("unable to create a server: %v", err)
+	}
+	defer gs.Close()
+
+	if _, err := gs.AddPath("testdata/dir", PathOptions{}); !errors.Is(err, ErrInsufficientSpace) {
+		t.Errorf("unexpected error: want: %v, got: %v", ErrInsufficientSpace, err)
+	}
+
+	if _, err := gs.AddRepository("testdata/dir", CloneOptions{}); !errors.Is(err, ErrInsufficientSpace) {
+		t.Errorf("unexpected error: want: %v, got: %v", ErrInsufficientSpace, err)
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	tmpPath := t.TempDir()
+	files := map[string]int{
+		"foo.txt":     10,
+		"dir/bar.txt": 20,
+		".git/config": 5,
+	}
+	for name, size := range files {
+		p := filepath.Join(tmpPath, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("unable to create dir: %v", err)
+		}
+		if err := os.WriteFile(p, make([]byte, size), 0644); err != nil {
+			t.Fatalf("unable to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		withGit bool
+		want    uint64
+	}{
+		{name: "with git", withGit: true, want: 35},
+		{name: "without git", withGit: false, want: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diskUsage(tmpPath, tt.withGit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected disk usage: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
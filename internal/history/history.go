@@ -0,0 +1,276 @@
+// Copyright 2024 Adevinta
+
+// Package history implements a local database that keeps track of
+// the results of the scans over time.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+)
+
+// Entry represents the results of a scan.
+type Entry struct {
+	// Time is the time of the scan.
+	Time time.Time `json:"time"`
+
+	// Targets is the list of scanned targets.
+	Targets []string `json:"targets"`
+
+	// Summary is the number of findings grouped by severity.
+	Summary map[string]int `json:"summary"`
+
+	// Findings is the list of findings.
+	Findings []Finding `json:"findings"`
+
+	// Exclusions contains the keys of the exclusions that matched
+	// any finding of the scan.
+	Exclusions []string `json:"exclusions,omitempty"`
+}
+
+// Finding represents a finding detected by a scan.
+type Finding struct {
+	// Target is the target of the check that detected the
+	// finding.
+	Target string `json:"target"`
+
+	// Checktype is the name of the checktype that detected the
+	// finding.
+	Checktype string `json:"checktype"`
+
+	// Fingerprint identifies the finding across scans.
+	Fingerprint string `json:"fingerprint"`
+
+	// Summary is the summary of the finding.
+	Summary string `json:"summary"`
+
+	// Severity is the severity of the finding.
+	Severity string `json:"severity"`
+}
+
+// Key returns the key used to identify the finding across scans. If
+// the finding does not have a fingerprint, its summary is used.
+func (f Finding) Key() string {
+	if f.Fingerprint == "" {
+		return f.Checktype + "/summary/" + f.Summary
+	}
+	return f.Checktype + "/" + f.Fingerprint
+}
+
+// DB represents a history database. It is stored as a file in JSON
+// Lines format where every line is an [Entry].
+type DB struct {
+	path string
+}
+
+// Open opens the history database stored in the specified file. The
+// file is created when the first entry is appended.
+func Open(path string) *DB {
+	return &DB{path: path}
+}
+
+// Append appends the provided entry to the database.
+func (db *DB) Append(e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal entry: %w", err)
+	}
+
+	f, err := os.OpenFile(db.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write entry: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the entries stored in the database sorted by time.
+// If the database does not exist, it returns an empty list.
+func (db *DB) Entries() ([]Entry, error) {
+	f, err := os.Open(db.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 64*1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("unmarshal entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read database: %w", err)
+	}
+
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return entries, nil
+}
+
+// TrendPoint represents the state of a target after a scan.
+type TrendPoint struct {
+	// Time is the time of the scan.
+	Time time.Time
+
+	// Total is the number of findings.
+	Total int
+
+	// New is the number of findings that were not present in the
+	// previous scan of the target.
+	New int
+
+	// Fixed is the number of findings that were present in the
+	// previous scan of the target and are not present anymore.
+	Fixed int
+}
+
+// Trends returns the evolution of the findings of every target
+// across the provided entries, which must be sorted by time. Only the
+// scans that include a given target are considered.
+func Trends(entries []Entry) map[string][]TrendPoint {
+	trends := make(map[string][]TrendPoint)
+	prev := make(map[string]map[string]bool)
+	for _, e := range entries {
+		for target, keys := range findingsByTarget(e) {
+			point := TrendPoint{
+				Time:  e.Time,
+				Total: len(keys),
+			}
+			for k := range keys {
+				if !prev[target][k] {
+					point.New++
+				}
+			}
+			for k := range prev[target] {
+				if !keys[k] {
+					point.Fixed++
+				}
+			}
+			trends[target] = append(trends[target], point)
+			prev[target] = keys
+		}
+	}
+	return trends
+}
+
+// Remediation represents the remediation statistics of a target.
+type Remediation struct {
+	// Fixed is the number of fixed findings.
+	Fixed int
+
+	// MTTR is the mean time to remediate the fixed findings.
+	MTTR time.Duration
+}
+
+// Remediations returns the remediation statistics of every target
+// across the provided entries, which must be sorted by time. A
+// finding is considered fixed when it is not present in a scan of the
+// target after having been detected. The time to remediate is the
+// time between the first scan that detected the finding and the first
+// scan where it was not present.
+func Remediations(entries []Entry) map[string]Remediation {
+	firstSeen := make(map[string]map[string]time.Time)
+	total := make(map[string]time.Duration)
+	rems := make(map[string]Remediation)
+	for _, e := range entries {
+		for target, keys := range findingsByTarget(e) {
+			if firstSeen[target] == nil {
+				firstSeen[target] = make(map[string]time.Time)
+			}
+			for k, t := range firstSeen[target] {
+				if keys[k] {
+					continue
+				}
+				rem := rems[target]
+				rem.Fixed++
+				rems[target] = rem
+				total[target] += e.Time.Sub(t)
+				delete(firstSeen[target], k)
+			}
+			for k := range keys {
+				if _, ok := firstSeen[target][k]; !ok {
+					firstSeen[target][k] = e.Time
+				}
+			}
+		}
+	}
+
+	for target, rem := range rems {
+		rem.MTTR = total[target] / time.Duration(rem.Fixed)
+		rems[target] = rem
+	}
+	return rems
+}
+
+// FirstSeen returns the time when the findings that are still open
+// were first detected, grouped by target and indexed by [Finding.Key].
+// The provided entries must be sorted by time. A finding is open if it
+// was present in the last scan of its target. If a finding was fixed
+// and detected again later, the time of the new detection is used.
+func FirstSeen(entries []Entry) map[string]map[string]time.Time {
+	firstSeen := make(map[string]map[string]time.Time)
+	for _, e := range entries {
+		for target, keys := range findingsByTarget(e) {
+			prev := firstSeen[target]
+			cur := make(map[string]time.Time)
+			for k := range keys {
+				if t, ok := prev[k]; ok {
+					cur[k] = t
+				} else {
+					cur[k] = e.Time
+				}
+			}
+			firstSeen[target] = cur
+		}
+	}
+	return firstSeen
+}
+
+// LastMatched returns the time of the last scan where every
+// exclusion matched a finding, indexed by exclusion key. The provided
+// entries must be sorted by time.
+func LastMatched(entries []Entry) map[string]time.Time {
+	lastMatched := make(map[string]time.Time)
+	for _, e := range entries {
+		for _, key := range e.Exclusions {
+			lastMatched[key] = e.Time
+		}
+	}
+	return lastMatched
+}
+
+// findingsByTarget returns the keys of the findings of the provided
+// entry grouped by target. Every scanned target is included, even if
+// it has no findings.
+func findingsByTarget(e Entry) map[string]map[string]bool {
+	m := make(map[string]map[string]bool)
+	for _, t := range e.Targets {
+		m[t] = make(map[string]bool)
+	}
+	for _, f := range e.Findings {
+		if m[f.Target] == nil {
+			m[f.Target] = make(map[string]bool)
+		}
+		m[f.Target][f.Key()] = true
+	}
+	return m
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 Adevinta
+
+package history
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var (
+	t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 = t0.Add(24 * time.Hour)
+	t2 = t0.Add(72 * time.Hour)
+)
+
+var testEntries = []Entry{
+	{
+		Time:    t0,
+		Targets: []string{"target1", "target2"},
+		Findings: []Finding{
+			{Target: "target1", Checktype: "ct", Fingerprint: "a"},
+			{Target: "target1", Checktype: "ct", Fingerprint: "b"},
+			{Target: "target2", Checktype: "ct", Fingerprint: "c"},
+		},
+		Exclusions: []string{"excl1", "excl2"},
+	},
+	{
+		Time:    t1,
+		Targets: []string{"target1"},
+		Findings: []Finding{
+			{Target: "target1", Checktype: "ct", Fingerprint: "b"},
+			{Target: "target1", Checktype: "ct", Fingerprint: "d"},
+		},
+		Exclusions: []string{"excl1"},
+	},
+	{
+		Time:    t2,
+		Targets: []string{"target1", "target2"},
+	},
+}
+
+func TestDB(t *testing.T) {
+	db := Open(path.Join(t.TempDir(), "history.jsonl"))
+
+	got, err := db.Entries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+
+	// Append in reverse order to check that entries are sorted
+	// by time.
+	for i := len(testEntries) - 1; i >= 0; i-- {
+		if err := db.Append(testEntries[i]); err != nil {
+			t.Fatalf("append error: %v", err)
+		}
+	}
+
+	got, err = db.Entries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(testEntries, got); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestTrends(t *testing.T) {
+	want := map[string][]TrendPoint{
+		"target1": {
+			{Time: t0, Total: 2, New: 2, Fixed: 0},
+			{Time: t1, Total: 2, New: 1, Fixed: 1},
+			{Time: t2, Total: 0, New: 0, Fixed: 2},
+		},
+		"target2": {
+			{Time: t0, Total: 1, New: 1, Fixed: 0},
+			{Time: t2, Total: 0, New: 0, Fixed: 1},
+		},
+	}
+
+	got := Trends(testEntries)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("trends mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestRemediations(t *testing.T) {
+	want := map[string]Remediation{
+		"target1": {
+			// a: t0 -> t1 (24h), b: t0 -> t2 (72h), d: t1 -> t2 (48h).
+			Fixed: 3,
+			MTTR:  48 * time.Hour,
+		},
+		"target2": {
+			// c: t0 -> t2 (72h).
+			Fixed: 1,
+			MTTR:  72 * time.Hour,
+		},
+	}
+
+	got := Remediations(testEntries)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("remediations mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestFirstSeen(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    map[string]map[string]time.Time
+	}{
+		{
+			name:    "open findings",
+			entries: testEntries[:2],
+			want: map[string]map[string]time.Time{
+				"target1": {
+					"ct/b": t0,
+					"ct/d": t1,
+				},
+				"target2": {
+					"ct/c": t0,
+				},
+			},
+		},
+		{
+			name:    "fixed findings",
+			entries: testEntries,
+			want: map[string]map[string]time.Time{
+				"target1": {},
+				"target2": {},
+			},
+		},
+		{
+			name: "detected again",
+			entries: append(testEntries, Entry{
+				Time:    t2.Add(time.Hour),
+				Targets: []string{"target1"},
+				Findings: []Finding{
+					{Target: "target1", Checktype: "ct", Fingerprint: "a"},
+				},
+			}),
+			want: map[string]map[string]time.Time{
+				"target1": {
+					"ct/a": t2.Add(time.Hour),
+				},
+				"target2": {},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FirstSeen(tt.entries)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("first seen mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestLastMatched(t *testing.T) {
+	want := map[string]time.Time{
+		"excl1": t1,
+		"excl2": t0,
+	}
+
+	got := LastMatched(testEntries)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("last matched mismatch (-want +got):\n%v", diff)
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2024 Adevinta
+
+// Package imagetags expands DockerImage targets whose tag is a glob
+// pattern into the matching image tags available in the registry.
+package imagetags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	types "github.com/adevinta/vulcan-types"
+	"github.com/distribution/reference"
+	dockercliconfig "github.com/docker/cli/cli/config"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+const (
+	// dockerHubDomain is the domain of the Docker Hub images.
+	dockerHubDomain = "docker.io"
+
+	// dockerHubRegistry is the host of the Docker Hub registry
+	// API.
+	dockerHubRegistry = "registry-1.docker.io"
+
+	// dockerHubAuthKey is the key of the Docker Hub credentials
+	// in the Docker CLI configuration.
+	dockerHubAuthKey = "https://index.docker.io/v1/"
+)
+
+// These variables are used by tests to replace the HTTP client and
+// the Docker CLI credentials.
+var (
+	httpClient = &http.Client{Timeout: time.Minute}
+
+	dockerCLICredentials = func(server string) (username, password string) {
+		cfg := dockercliconfig.LoadDefaultConfigFile(io.Discard)
+		auth, err := cfg.GetAuthConfig(server)
+		if err != nil {
+			return "", ""
+		}
+		return auth.Username, auth.Password
+	}
+)
+
+// IsPattern reports whether the tag of the provided image reference
+// is a glob pattern.
+func IsPattern(image string) bool {
+	_, tag := splitTag(image)
+	return strings.ContainsAny(tag, "*?[")
+}
+
+// Expand returns the provided targets replacing the DockerImage
+// targets whose tag is a glob pattern with one target per matching
+// tag. The patterns follow the syntax of [path.Match]. The tags are
+// listed using the registry API with the credentials in auths or, if
+// none matches the registry, those of the Docker CLI. The rest of
+// the targets are returned unchanged. It returns an error if a
+// pattern does not match any tag.
+func Expand(targets []config.Target, auths []config.RegistryAuth) ([]config.Target, error) {
+	var expanded []config.Target
+	for _, t := range targets {
+		if t.AssetType != types.DockerImage || !IsPattern(t.Identifier) {
+			expanded = append(expanded, t)
+			continue
+		}
+
+		repo, pattern := splitTag(t.Identifier)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid tag pattern: %v: %w", t, err)
+		}
+
+		tags, err := listTags(repo, auths)
+		if err != nil {
+			return nil, fmt.Errorf("list tags: %v: %w", t, err)
+		}
+
+		var n int
+		for _, tag := range tags {
+			if ok, _ := path.Match(pattern, tag); !ok {
+				continue
+			}
+			et := t
+			et.Identifier = repo + ":" + tag
+			expanded = append(expanded, et)
+			n++
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("no tags matching %v", t)
+		}
+		slog.Info("expanded image target", "target", t, "tags", n)
+	}
+	return expanded, nil
+}
+
+// splitTag splits the provided image reference into repository and
+// tag. The digest, if any, is considered part of the tag.
+func splitTag(image string) (repo, tag string) {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i+1:], "/") {
+		return image, ""
+	}
+	return image[:i], image[i+1:]
+}
+
+// listTags returns the tags of the provided repository.
+func listTags(repo string, auths []config.RegistryAuth) ([]string, error) {
+	named, err := reference.ParseNormalizedNamed(repo)
+	if err != nil {
+		return nil, fmt.Errorf("parse repository: %w", err)
+	}
+	domain := reference.Domain(named)
+	repoPath := reference.Path(named)
+
+	host := domain
+	if domain == dockerHubDomain {
+		host = dockerHubRegistry
+	}
+
+	rc := registryClient{repo: repoPath}
+	rc.username, rc.password = credentials(domain, auths)
+
+	var tags []string
+	next := fmt.Sprintf("https://%v/v2/%v/tags/list", host, repoPath)
+	for next != "" {
+		var page []string
+		page, next, err = rc.tagsPage(next)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page...)
+	}
+	return tags, nil
+}
+
+// credentials returns the credentials of the specified registry
+// domain.
+func credentials(domain string, auths []config.RegistryAuth) (username, password string) {
+	for _, auth := range auths {
+		if normalizeServer(auth.Server) == domain {
+			return auth.Username, auth.Password
+		}
+	}
+
+	key := domain
+	if domain == dockerHubDomain {
+		key = dockerHubAuthKey
+	}
+	return dockerCLICredentials(key)
+}
+
+// normalizeServer returns the registry domain of the provided
+// server.
+func normalizeServer(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	server, _, _ = strings.Cut(server, "/")
+	switch server {
+	case "index.docker.io", dockerHubRegistry:
+		return dockerHubDomain
+	}
+	return server
+}
+
+// registryClient is a minimal client of the Docker Registry HTTP API
+// V2.
+type registryClient struct {
+	repo     string
+	username string
+	password string
+
+	// basic specifies whether the registry requires basic
+	// authentication.
+	basic bool
+
+	// token is the bearer token used to authenticate.
+	token string
+}
+
+// tagsPage returns the tags in the provided tags list URL and the URL
+// of the next page. The returned URL is empty if there are no more
+// pages.
+func (rc *registryClient) tagsPage(u string) (tags []string, next string, err error) {
+	resp, err := rc.get(u)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("HTTP status: %v", resp.Status)
+	}
+
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, "", fmt.Errorf("decode response: %w", err)
+	}
+
+	if link := parseNextLink(resp.Header.Get("Link")); link != "" {
+		base, err := url.Parse(u)
+		if err != nil {
+			return nil, "", fmt.Errorf("parse URL: %w", err)
+		}
+		ref, err := url.Parse(link)
+		if err != nil {
+			return nil, "", fmt.Errorf("parse link: %w", err)
+		}
+		next = base.ResolveReference(ref).String()
+	}
+	return body.Tags, next, nil
+}
+
+// get sends a GET request to the provided URL. If the registry
+// requires authentication, it authenticates and retries the request.
+func (rc *registryClient) get(u string) (*http.Response, error) {
+	resp, err := rc.do(u)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized || rc.basic || rc.token != "" {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		if rc.token, err = rc.fetchToken(params); err != nil {
+			return nil, fmt.Errorf("fetch token: %w", err)
+		}
+	case "basic":
+		if rc.username == "" {
+			return nil, errors.New("registry requires credentials")
+		}
+		rc.basic = true
+	default:
+		return nil, fmt.Errorf("unsupported authentication challenge: %q", challenge)
+	}
+	return rc.do(u)
+}
+
+// do sends a GET request to the provided URL with the current
+// credentials.
+func (rc *registryClient) do(u string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	if rc.basic {
+		req.SetBasicAuth(rc.username, rc.password)
+	} else if rc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+rc.token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request: %w", err)
+	}
+	return resp, nil
+}
+
+// fetchToken requests a bearer token to the authorization service
+// described by the provided challenge parameters.
+func (rc *registryClient) fetchToken(params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid realm: %q", params["realm"])
+	}
+
+	q := realm.Query()
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%v:pull", rc.repo)
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	if rc.username != "" {
+		req.SetBasicAuth(rc.username, rc.password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP status: %v", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+	if token == "" {
+		return "", errors.New("empty token")
+	}
+	return token, nil
+}
+
+// reChallengeParam matches the parameters of a WWW-Authenticate
+// challenge.
+var reChallengeParam = regexp.MustCompile(`([a-zA-Z]+)="([^"]*)"`)
+
+// parseChallenge parses a WWW-Authenticate challenge and returns its
+// scheme and parameters.
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params = make(map[string]string)
+	for _, m := range reChallengeParam.FindAllStringSubmatch(rest, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	return scheme, params
+}
+
+// parseNextLink returns the URL of the "next" relation in the
+// provided Link header. It returns an empty string if there is no
+// such relation.
+func parseNextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok {
+			continue
+		}
+		params = strings.NewReplacer(" ", "", `"`, "").Replace(params)
+		if !slices.Contains(strings.Split(params, ";"), "rel=next") {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+	return ""
+}
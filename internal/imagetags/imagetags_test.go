@@ -0,0 +1,203 @@
+// Copyright 2024 Adevinta
+
+package imagetags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestIsPattern(t *testing.T) {
+	tests := []struct {
+		image string
+		want  bool
+	}{
+		{image: "myapp:release-*", want: true},
+		{image: "example.com:5000/myapp:v1.?", want: true},
+		{image: "myapp:v[12]", want: true},
+		{image: "myapp:latest", want: false},
+		{image: "myapp", want: false},
+		{image: "example.com:5000/myapp", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := IsPattern(tt.image); got != tt.want {
+				t.Errorf("unexpected result: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newRegistry returns a fake registry that serves the provided tags of
+// the repository "myapp" in pages of two elements. It requires a
+// bearer token obtained with the provided credentials.
+func newRegistry(tags []string, username, password string) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if u, p, _ := r.BasicAuth(); u != username || p != password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:myapp:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"token":"token"}`)) //nolint:errcheck
+		case "/v2/myapp/tags/list":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%v/token",service="registry"`, ts.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			var start int
+			if last := r.URL.Query().Get("last"); last != "" {
+				for i, tag := range tags {
+					if tag == last {
+						start = i + 1
+					}
+				}
+			}
+			end := min(start+2, len(tags))
+			if end < len(tags) {
+				w.Header().Set("Link", fmt.Sprintf(`</v2/myapp/tags/list?last=%v&n=2>; rel="next"`, tags[end-1]))
+			}
+			json.NewEncoder(w).Encode(map[string]any{"name": "myapp", "tags": tags[start:end]}) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return ts
+}
+
+func TestExpand(t *testing.T) {
+	tags := []string{"latest", "release-1.0", "release-1.1", "release-2.0", "dev"}
+	ts := newRegistry(tags, "user", "pass")
+	defer ts.Close()
+
+	oldHTTPClient, oldDockerCLICredentials := httpClient, dockerCLICredentials
+	defer func() { httpClient, dockerCLICredentials = oldHTTPClient, oldDockerCLICredentials }()
+	httpClient = ts.Client()
+	dockerCLICredentials = func(string) (string, string) { return "", "" }
+
+	registry := strings.TrimPrefix(ts.URL, "https://")
+	auths := []config.RegistryAuth{{Server: registry, Username: "user", Password: "pass"}}
+
+	tests := []struct {
+		name    string
+		targets []config.Target
+		auths   []config.RegistryAuth
+		want    []config.Target
+		wantErr bool
+	}{
+		{
+			name: "pattern",
+			targets: []config.Target{
+				{
+					Identifier: registry + "/myapp:release-1.*",
+					AssetType:  types.DockerImage,
+					Options:    map[string]any{"option": "value"},
+				},
+				{Identifier: "example.com", AssetType: types.DomainName},
+			},
+			auths: auths,
+			want: []config.Target{
+				{
+					Identifier: registry + "/myapp:release-1.0",
+					AssetType:  types.DockerImage,
+					Options:    map[string]any{"option": "value"},
+				},
+				{
+					Identifier: registry + "/myapp:release-1.1",
+					AssetType:  types.DockerImage,
+					Options:    map[string]any{"option": "value"},
+				},
+				{Identifier: "example.com", AssetType: types.DomainName},
+			},
+		},
+		{
+			name: "no pattern",
+			targets: []config.Target{
+				{Identifier: registry + "/myapp:latest", AssetType: types.DockerImage},
+			},
+			auths: nil,
+			want: []config.Target{
+				{Identifier: registry + "/myapp:latest", AssetType: types.DockerImage},
+			},
+		},
+		{
+			name: "no matching tags",
+			targets: []config.Target{
+				{Identifier: registry + "/myapp:release-3.*", AssetType: types.DockerImage},
+			},
+			auths:   auths,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid credentials",
+			targets: []config.Target{
+				{Identifier: registry + "/myapp:release-*", AssetType: types.DockerImage},
+			},
+			auths:   []config.RegistryAuth{{Server: registry, Username: "user", Password: "invalid"}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.targets, tt.auths)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestParseNextLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{
+			name:   "next",
+			header: `</v2/myapp/tags/list?last=b&n=2>; rel="next"`,
+			want:   "/v2/myapp/tags/list?last=b&n=2",
+		},
+		{
+			name:   "several links",
+			header: `<https://example.com/prev>; rel="prev", <https://example.com/next>; rel=next`,
+			want:   "https://example.com/next",
+		},
+		{
+			name:   "empty",
+			header: "",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNextLink(tt.header); got != tt.want {
+				t.Errorf("unexpected link: got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Adevinta
+
+// Package logfile implements log files that are rotated when they
+// reach a maximum size.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// DefaultMaxSize is the default maximum size in bytes of a log file.
+const DefaultMaxSize = 100 << 20
+
+// File is a log file that is rotated when its size would exceed a
+// maximum size. When a log file is rotated, its contents are moved
+// to a backup file with the same name and the suffix ".1". Only one
+// backup file is kept. It is safe for concurrent use.
+type File struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+}
+
+// Open opens the log file with the provided path. If the file does
+// not exist, it is created. If it exists, new data is appended to
+// it. If maxSize is lower or equal to zero, the file is never
+// rotated.
+func Open(path string, maxSize int64) (*File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+
+	lf := &File{
+		path:    path,
+		maxSize: maxSize,
+		f:       f,
+		size:    fi.Size(),
+	}
+	return lf, nil
+}
+
+// Write writes p to the log file. If the size of the file would
+// exceed the maximum size, the file is rotated before writing.
+func (lf *File) Write(p []byte) (n int, err error) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.maxSize > 0 && lf.size > 0 && lf.size+int64(len(p)) > lf.maxSize {
+		if err := lf.rotate(); err != nil {
+			return 0, fmt.Errorf("rotate: %w", err)
+		}
+	}
+
+	n, err = lf.f.Write(p)
+	lf.size += int64(n)
+	return n, err
+}
+
+// rotate moves the current log file to the backup file and creates
+// a new empty log file.
+func (lf *File) rotate() error {
+	if err := lf.f.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+
+	if err := os.Rename(lf.path, lf.path+".1"); err != nil {
+		return fmt.Errorf("rename file: %w", err)
+	}
+
+	f, err := os.OpenFile(lf.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	lf.f = f
+	lf.size = 0
+	return nil
+}
+
+// Close closes the log file.
+func (lf *File) Close() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	return lf.f.Close()
+}
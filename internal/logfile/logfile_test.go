@@ -0,0 +1,99 @@
+// Copyright 2024 Adevinta
+
+package logfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile_Write(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxSize    int64
+		initial    string
+		writes     []string
+		wantFile   string
+		wantBackup string
+	}{
+		{
+			name:     "no rotation",
+			maxSize:  10,
+			writes:   []string{"abc\n", "def\n"},
+			wantFile: "abc\ndef\n",
+		},
+		{
+			name:       "rotation",
+			maxSize:    10,
+			writes:     []string{"abc\n", "def\n", "ghi\n"},
+			wantFile:   "ghi\n",
+			wantBackup: "abc\ndef\n",
+		},
+		{
+			name:       "append to existing file",
+			maxSize:    10,
+			initial:    "123456\n",
+			writes:     []string{"abc\n", "def\n"},
+			wantFile:   "abc\ndef\n",
+			wantBackup: "123456\n",
+		},
+		{
+			name:     "write bigger than max size",
+			maxSize:  2,
+			writes:   []string{"abc\n"},
+			wantFile: "abc\n",
+		},
+		{
+			name:     "rotation disabled",
+			maxSize:  0,
+			writes:   []string{"abc\n", "def\n", "ghi\n"},
+			wantFile: "abc\ndef\nghi\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "lava.log")
+
+			if tt.initial != "" {
+				if err := os.WriteFile(path, []byte(tt.initial), 0o644); err != nil {
+					t.Fatalf("error writing initial file: %v", err)
+				}
+			}
+
+			lf, err := Open(path, tt.maxSize)
+			if err != nil {
+				t.Fatalf("error opening log file: %v", err)
+			}
+
+			for _, w := range tt.writes {
+				if _, err := lf.Write([]byte(w)); err != nil {
+					t.Fatalf("write error: %v", err)
+				}
+			}
+
+			if err := lf.Close(); err != nil {
+				t.Fatalf("close error: %v", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("error reading log file: %v", err)
+			}
+			if string(got) != tt.wantFile {
+				t.Errorf("unexpected log file: got: %q, want: %q", got, tt.wantFile)
+			}
+
+			gotBackup, err := os.ReadFile(path + ".1")
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("error reading backup file: %v", err)
+			}
+			if string(gotBackup) != tt.wantBackup {
+				t.Errorf("unexpected backup file: got: %q, want: %q", gotBackup, tt.wantBackup)
+			}
+		})
+	}
+}
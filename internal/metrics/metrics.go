@@ -9,6 +9,8 @@ import (
 	"io"
 	"os"
 	"sync"
+
+	"github.com/adevinta/lava/internal/urlutil"
 )
 
 // DefaultCollector is the default [Collector].
@@ -68,3 +70,23 @@ func WriteFile(file string) error {
 
 	return Write(f)
 }
+
+// WriteURL writes the collected metrics into the resource identified
+// by the specified URL using [DefaultCollector]. For more details
+// about the supported URLs, see [urlutil.Create].
+func WriteURL(rawURL string, opts urlutil.UploadOptions) error {
+	w, err := urlutil.Create(rawURL, opts)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	if err := Write(w); err != nil {
+		w.Close()
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
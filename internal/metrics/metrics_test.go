@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/urlutil"
 )
 
 var testdata = []struct {
@@ -116,3 +118,38 @@ func TestWriteFile(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteURL(t *testing.T) {
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDefaultCollector := DefaultCollector
+			defer func() { DefaultCollector = oldDefaultCollector }()
+
+			DefaultCollector = NewCollector()
+
+			file := path.Join(t.TempDir(), "metrics.json")
+
+			for key, value := range tt.metrics {
+				Collect(key, value)
+			}
+
+			if err := WriteURL(file, urlutil.UploadOptions{}); err != nil {
+				t.Fatalf("error writing metrics: %v", err)
+			}
+
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("error reading metrics file: %v", err)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Errorf("error decoding JSON metrics: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("metrics mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
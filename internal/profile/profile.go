@@ -0,0 +1,196 @@
+// Copyright 2024 Adevinta
+
+// Package profile profiles Lava runs. It writes CPU and heap profiles
+// in pprof format and a timing breakdown of the phases of the run.
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// Names of the files written by a [Profiler].
+const (
+	CPUProfileFile  = "cpu.pprof"
+	HeapProfileFile = "heap.pprof"
+	TimingsFile     = "timings.json"
+)
+
+// ErrStarted is returned by [Profiler.Start] when the profiler has
+// already been started.
+var ErrStarted = errors.New("profiler already started")
+
+// DefaultProfiler is the default [Profiler].
+var DefaultProfiler = NewProfiler()
+
+// Phase is a phase of the run.
+type Phase struct {
+	// Name is the name of the phase.
+	Name string `json:"name"`
+
+	// Duration is the duration of the phase in seconds.
+	Duration float64 `json:"duration"`
+}
+
+// Timings is the timing breakdown of a run.
+type Timings struct {
+	// Duration is the duration of the run in seconds.
+	Duration float64 `json:"duration"`
+
+	// Phases is the list of phases of the run in the order they
+	// finished.
+	Phases []Phase `json:"phases"`
+}
+
+// Profiler represents a profiler.
+type Profiler struct {
+	mutex   sync.Mutex
+	dir     string
+	cpuFile *os.File
+	start   time.Time
+	last    time.Time
+	phases  []Phase
+}
+
+// NewProfiler returns a new profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{}
+}
+
+// Start starts profiling. The profiles are written to the provided
+// directory, which is created if it does not exist.
+func (p *Profiler) Start(dir string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.cpuFile != nil {
+		return ErrStarted
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, CPUProfileFile))
+	if err != nil {
+		return fmt.Errorf("create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("start CPU profile: %w", err)
+	}
+
+	now := time.Now()
+	p.dir = dir
+	p.cpuFile = f
+	p.start = now
+	p.last = now
+	p.phases = nil
+	return nil
+}
+
+// EndPhase records the end of the phase with the provided name. The
+// phase started when the previous phase finished or, if it is the
+// first one, when the profiler was started. It does nothing if the
+// profiler has not been started.
+func (p *Profiler) EndPhase(name string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.cpuFile == nil {
+		return
+	}
+
+	now := time.Now()
+	p.phases = append(p.phases, Phase{
+		Name:     name,
+		Duration: now.Sub(p.last).Seconds(),
+	})
+	p.last = now
+}
+
+// Stop stops profiling and writes the heap profile and the timing
+// breakdown of the run. It does nothing if the profiler has not been
+// started.
+func (p *Profiler) Stop() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.cpuFile == nil {
+		return nil
+	}
+
+	pprof.StopCPUProfile()
+	err := p.cpuFile.Close()
+	p.cpuFile = nil
+	if err != nil {
+		return fmt.Errorf("close CPU profile: %w", err)
+	}
+
+	if err := writeHeapProfile(filepath.Join(p.dir, HeapProfileFile)); err != nil {
+		return fmt.Errorf("write heap profile: %w", err)
+	}
+
+	timings := Timings{
+		Duration: time.Since(p.start).Seconds(),
+		Phases:   p.phases,
+	}
+	if err := writeTimings(filepath.Join(p.dir, TimingsFile), timings); err != nil {
+		return fmt.Errorf("write timings: %w", err)
+	}
+	return nil
+}
+
+// writeHeapProfile writes a heap profile into the specified file.
+func writeHeapProfile(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer f.Close()
+
+	// Get up-to-date statistics.
+	runtime.GC()
+
+	return pprof.WriteHeapProfile(f)
+}
+
+// writeTimings writes the provided timings into the specified file
+// in JSON format.
+func writeTimings(file string, timings Timings) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(timings); err != nil {
+		return fmt.Errorf("encode JSON: %w", err)
+	}
+	return nil
+}
+
+// Start starts profiling using [DefaultProfiler].
+func Start(dir string) error {
+	return DefaultProfiler.Start(dir)
+}
+
+// EndPhase records the end of the phase with the provided name using
+// [DefaultProfiler].
+func EndPhase(name string) {
+	DefaultProfiler.EndPhase(name)
+}
+
+// Stop stops profiling using [DefaultProfiler].
+func Stop() error {
+	return DefaultProfiler.Stop()
+}
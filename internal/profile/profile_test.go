@@ -0,0 +1,82 @@
+// Copyright 2024 Adevinta
+
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProfiler(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profile")
+
+	p := NewProfiler()
+
+	// Phases are ignored before starting the profiler.
+	p.EndPhase("ignored")
+
+	if err := p.Start(dir); err != nil {
+		t.Fatalf("start error: %v", err)
+	}
+	if err := p.Start(dir); !errors.Is(err, ErrStarted) {
+		t.Errorf("unexpected error: want: %v, got: %v", ErrStarted, err)
+	}
+
+	p.EndPhase("phase1")
+	p.EndPhase("phase2")
+
+	if err := p.Stop(); err != nil {
+		t.Fatalf("stop error: %v", err)
+	}
+
+	// Stopping a stopped profiler does nothing.
+	if err := p.Stop(); err != nil {
+		t.Fatalf("second stop error: %v", err)
+	}
+
+	for _, file := range []string{CPUProfileFile, HeapProfileFile} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("stat error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, TimingsFile))
+	if err != nil {
+		t.Fatalf("error reading timings: %v", err)
+	}
+	var timings Timings
+	if err := json.Unmarshal(data, &timings); err != nil {
+		t.Fatalf("error decoding timings: %v", err)
+	}
+
+	var got []string
+	var sum float64
+	for _, ph := range timings.Phases {
+		got = append(got, ph.Name)
+		sum += ph.Duration
+	}
+	want := []string{"phase1", "phase2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("phases mismatch (-want +got):\n%v", diff)
+	}
+	if sum > timings.Duration {
+		t.Errorf("phases last longer than the run: %v > %v", sum, timings.Duration)
+	}
+}
+
+func TestProfiler_Start_invalid_dir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	p := NewProfiler()
+	if err := p.Start(file); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"slices"
+
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/engine"
+)
+
+// Coverage status of a control family on a target.
+const (
+	// coverageCovered means that at least one check of the
+	// control family finished successfully against the target.
+	coverageCovered = "covered"
+
+	// coverageFailed means that checks of the control family
+	// were run against the target but none of them finished
+	// successfully.
+	coverageFailed = "failed"
+
+	// coverageNotCovered means that no check of the control
+	// family was run against the target.
+	coverageNotCovered = "not covered"
+)
+
+// controlCoverage is the coverage of a control family on a target.
+type controlCoverage struct {
+	Control string `json:"control"`
+	Status  string `json:"status"`
+}
+
+// targetCoverage is the coverage of all the control families on a
+// target.
+type targetCoverage struct {
+	Target   string            `json:"target"`
+	Controls []controlCoverage `json:"controls"`
+}
+
+// WithControls returns a copy of the writer that includes the control
+// coverage of every target in the report. ctControls contains the
+// control families covered by every checktype indexed by checktype
+// name. See [engine.Engine.Controls].
+func (writer Writer) WithControls(ctControls checktypes.ChecktypeControls) Writer {
+	writer.controls = ctControls
+	return writer
+}
+
+// mkCoverage returns the coverage of every control family on every
+// target of the provided report. The targets are sorted
+// alphabetically and the control families follow the order of
+// [checktypes.Controls].
+func mkCoverage(er engine.Report, ctControls checktypes.ChecktypeControls) []targetCoverage {
+	statuses := make(map[string]map[string]string)
+	for _, r := range er {
+		ts, ok := statuses[r.Target]
+		if !ok {
+			ts = make(map[string]string)
+			statuses[r.Target] = ts
+		}
+		for _, ctrl := range ctControls[r.ChecktypeName] {
+			switch {
+			case r.Status == "FINISHED":
+				ts[ctrl] = coverageCovered
+			case ts[ctrl] != coverageCovered:
+				ts[ctrl] = coverageFailed
+			}
+		}
+	}
+
+	targets := make([]string, 0, len(statuses))
+	for t := range statuses {
+		targets = append(targets, t)
+	}
+	slices.Sort(targets)
+
+	var coverage []targetCoverage
+	for _, t := range targets {
+		tc := targetCoverage{Target: t}
+		for _, ctrl := range checktypes.Controls {
+			status, ok := statuses[t][ctrl]
+			if !ok {
+				status = coverageNotCovered
+			}
+			tc.Controls = append(tc.Controls, controlCoverage{Control: ctrl, Status: status})
+		}
+		coverage = append(coverage, tc)
+	}
+	return coverage
+}
+
+// coverageMetric converts the provided coverage into the format of
+// the "control_coverage" metric, which maps every target to the
+// coverage status of every control family.
+func coverageMetric(coverage []targetCoverage) map[string]map[string]string {
+	m := make(map[string]map[string]string)
+	for _, tc := range coverage {
+		m[tc.Target] = make(map[string]string)
+		for _, cc := range tc.Controls {
+			m[tc.Target][cc.Control] = cc.Status
+		}
+	}
+	return m
+}
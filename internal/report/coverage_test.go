@@ -0,0 +1,122 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/checktypes"
+	"github.com/adevinta/lava/internal/engine"
+)
+
+func TestMkCoverage(t *testing.T) {
+	er := engine.Report{
+		"CheckID1": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "vulcan-semgrep",
+				Target:        "repo1",
+				Status:        "FINISHED",
+			},
+		},
+		"CheckID2": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID2",
+				ChecktypeName: "vulcan-trivy",
+				Target:        "repo1",
+				Status:        "FAILED",
+			},
+		},
+		"CheckID3": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID3",
+				ChecktypeName: "vulcan-gitleaks",
+				Target:        "repo1",
+				Status:        "FINISHED",
+			},
+		},
+		"CheckID4": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID4",
+				ChecktypeName: "vulcan-trivy",
+				Target:        "image1",
+				Status:        "FINISHED",
+			},
+		},
+		"CheckID5": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID5",
+				ChecktypeName: "vulcan-nmap",
+				Target:        "host1",
+				Status:        "FINISHED",
+			},
+		},
+	}
+	ctControls := checktypes.ChecktypeControls{
+		"vulcan-semgrep":  {"sast"},
+		"vulcan-trivy":    {"sca", "secrets"},
+		"vulcan-gitleaks": {"secrets"},
+	}
+
+	want := []targetCoverage{
+		{
+			Target: "host1",
+			Controls: []controlCoverage{
+				{Control: "sast", Status: coverageNotCovered},
+				{Control: "sca", Status: coverageNotCovered},
+				{Control: "dast", Status: coverageNotCovered},
+				{Control: "secrets", Status: coverageNotCovered},
+			},
+		},
+		{
+			Target: "image1",
+			Controls: []controlCoverage{
+				{Control: "sast", Status: coverageNotCovered},
+				{Control: "sca", Status: coverageCovered},
+				{Control: "dast", Status: coverageNotCovered},
+				{Control: "secrets", Status: coverageCovered},
+			},
+		},
+		{
+			Target: "repo1",
+			Controls: []controlCoverage{
+				{Control: "sast", Status: coverageCovered},
+				{Control: "sca", Status: coverageFailed},
+				{Control: "dast", Status: coverageNotCovered},
+				{Control: "secrets", Status: coverageCovered},
+			},
+		},
+	}
+
+	got := mkCoverage(er, ctControls)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("coverage mismatch (-want +got):\n%v", diff)
+	}
+
+	wantMetric := map[string]map[string]string{
+		"host1": {
+			"sast":    coverageNotCovered,
+			"sca":     coverageNotCovered,
+			"dast":    coverageNotCovered,
+			"secrets": coverageNotCovered,
+		},
+		"image1": {
+			"sast":    coverageNotCovered,
+			"sca":     coverageCovered,
+			"dast":    coverageNotCovered,
+			"secrets": coverageCovered,
+		},
+		"repo1": {
+			"sast":    coverageCovered,
+			"sca":     coverageFailed,
+			"dast":    coverageNotCovered,
+			"secrets": coverageCovered,
+		},
+	}
+	if diff := cmp.Diff(wantMetric, coverageMetric(got)); diff != "" {
+		t.Errorf("metric mismatch (-want +got):\n%v", diff)
+	}
+}
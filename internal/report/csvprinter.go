@@ -0,0 +1,114 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/warnings"
+)
+
+// csvPrinter represents a CSV report printer.
+type csvPrinter struct {
+	// columns is the list of columns of the report. If empty,
+	// [config.DefaultCSVColumns] is used.
+	columns []string
+}
+
+// csvFields contains the functions that return the value of every
+// supported column for a given vulnerability. Fields with multiple
+// values are joined with new lines.
+var csvFields = map[string]func(v vulnerability) string{
+	"target":            func(v vulnerability) string { return v.CheckData.Target },
+	"checktype":         func(v vulnerability) string { return v.CheckData.ChecktypeName },
+	"severity":          func(v vulnerability) string { return v.Severity.String() },
+	"score":             func(v vulnerability) string { return strconv.FormatFloat(float64(v.Score), 'f', -1, 32) },
+	"summary":           func(v vulnerability) string { return v.Summary },
+	"affected_resource": func(v vulnerability) string { return v.AffectedResource },
+	"fingerprint":       func(v vulnerability) string { return v.Fingerprint },
+	"check_key":         func(v vulnerability) string { return v.CheckKey },
+	"slug":              func(v vulnerability) string { return v.Slug },
+	"parent":            func(v vulnerability) string { return v.Parent },
+	"cwe": func(v vulnerability) string {
+		if v.CWEID == 0 {
+			return ""
+		}
+		return strconv.FormatUint(uint64(v.CWEID), 10)
+	},
+	"cwe_name": func(v vulnerability) string {
+		if v.CWE == nil {
+			return ""
+		}
+		return v.CWE.String()
+	},
+	"description":     func(v vulnerability) string { return v.Description },
+	"details":         func(v vulnerability) string { return v.Details },
+	"impact_details":  func(v vulnerability) string { return v.ImpactDetails },
+	"recommendations": func(v vulnerability) string { return strings.Join(v.Recommendations, "\n") },
+	"references":      func(v vulnerability) string { return strings.Join(v.References, "\n") },
+	"labels":          func(v vulnerability) string { return strings.Join(v.Labels, "\n") },
+	"due": func(v vulnerability) string {
+		if v.SLA == nil {
+			return ""
+		}
+		return v.SLA.Due.Format(config.ExpirationDateLayout)
+	},
+	"overdue": func(v vulnerability) string {
+		if v.SLA == nil {
+			return ""
+		}
+		return strconv.FormatBool(v.SLA.Overdue)
+	},
+}
+
+// Print renders the scan results in CSV format. The first row is the
+// header with the names of the columns. Then, there is one row per
+// vulnerability.
+func (prn csvPrinter) Print(w io.Writer, vulns []vulnerability, _ summary, _ []checkStatus, _ []staleExclusion, _ []warnings.Warning, _ *Metadata) error {
+	columns := prn.columns
+	if len(columns) == 0 {
+		columns = config.DefaultCSVColumns
+	}
+
+	fields := make([]func(vulnerability) string, len(columns))
+	for i, col := range columns {
+		f, ok := csvFields[col]
+		if !ok {
+			return fmt.Errorf("%w: %v", config.ErrInvalidColumn, col)
+		}
+		fields[i] = f
+	}
+
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+
+	if err := cw.Write(columns); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	record := make([]string, len(fields))
+	for _, v := range vulns {
+		for i, f := range fields {
+			record[i] = f(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("write record: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flush report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestCSVPrinter_Print(t *testing.T) {
+	vulns := []vulnerability{
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary:          "Vulnerability Summary 1",
+				Score:            6.7,
+				AffectedResource: "Affected Resource 1",
+				Fingerprint:      "fp1",
+				CWEID:            79,
+				Recommendations: []string{
+					"Recommendation 1",
+					"Recommendation 2",
+				},
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "lava-check",
+				Target:        "example.com",
+			},
+			Slug:     "slug1",
+			Severity: config.SeverityMedium,
+			CWE:      lookupCWE(79),
+		},
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary:          `Vulnerability "Summary", 2`,
+				Score:            0,
+				AffectedResource: "Affected Resource 2",
+				Fingerprint:      "fp2",
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "lava-check",
+				Target:        "example.com",
+			},
+			Slug:     "slug2",
+			Severity: config.SeverityInfo,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		columns []string
+		vulns   []vulnerability
+		want    string
+	}{
+		{
+			name:    "default columns",
+			columns: nil,
+			vulns:   vulns,
+			want: "" +
+				"target,checktype,severity,score,summary,affected_resource,fingerprint,slug\n" +
+				"example.com,lava-check,medium,6.7,Vulnerability Summary 1,Affected Resource 1,fp1,slug1\n" +
+				"example.com,lava-check,info,0,\"Vulnerability \"\"Summary\"\", 2\",Affected Resource 2,fp2,slug2\n",
+		},
+		{
+			name:    "custom columns",
+			columns: []string{"fingerprint", "cwe", "cwe_name", "recommendations"},
+			vulns:   vulns,
+			want: "" +
+				"fingerprint,cwe,cwe_name,recommendations\n" +
+				"fp1,79,CWE-79: Cross-site Scripting,\"Recommendation 1\nRecommendation 2\"\n" +
+				"fp2,,,\n",
+		},
+		{
+			name:    "no vulnerabilities",
+			columns: []string{"target", "summary"},
+			vulns:   nil,
+			want:    "target,summary\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			prn := csvPrinter{columns: tt.columns}
+			if err := prn.Print(&b, tt.vulns, summary{}, nil, nil, nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestCSVPrinter_Print_invalid_column(t *testing.T) {
+	var b strings.Builder
+	prn := csvPrinter{columns: []string{"target", "unknown"}}
+	if err := prn.Print(&b, nil, summary{}, nil, nil, nil, nil); !errors.Is(err, config.ErrInvalidColumn) {
+		t.Errorf("unexpected error: want: %v, got: %v", config.ErrInvalidColumn, err)
+	}
+}
+
+func TestCSVFields(t *testing.T) {
+	for _, col := range config.CSVColumns {
+		if _, ok := csvFields[col]; !ok {
+			t.Errorf("unsupported column: %v", col)
+		}
+	}
+	if len(csvFields) != len(config.CSVColumns) {
+		t.Errorf("unexpected number of fields: want: %v, got: %v", len(config.CSVColumns), len(csvFields))
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cwe contains the details of a Common Weakness Enumeration entry.
+type cwe struct {
+	// ID is the CWE identifier.
+	ID uint32 `json:"id"`
+
+	// Name is the name of the weakness. It is empty if the
+	// weakness is not in the CWE table.
+	Name string `json:"name,omitempty"`
+
+	// Description is a short description of the weakness. It is
+	// empty if the weakness is not in the CWE table.
+	Description string `json:"description,omitempty"`
+}
+
+// String returns the normalized representation of the weakness, for
+// instance "CWE-79: Cross-site Scripting". If the name of the
+// weakness is unknown, only its identifier is returned, for instance
+// "CWE-79".
+func (c cwe) String() string {
+	if c.Name == "" {
+		return fmt.Sprintf("CWE-%d", c.ID)
+	}
+	return fmt.Sprintf("CWE-%d: %v", c.ID, c.Name)
+}
+
+var (
+	//go:embed cwe.csv
+	cweData string
+
+	// cweTable contains the CWE entries of the embedded CWE table
+	// indexed by identifier. It only contains the weaknesses
+	// commonly reported by the checks.
+	cweTable = mustParseCWETable(cweData)
+)
+
+// mustParseCWETable parses the provided CSV CWE table. The first row
+// is the header and every other row contains the identifier, name and
+// description of a weakness. It panics if the table is not valid.
+func mustParseCWETable(data string) map[uint32]cwe {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("parse CWE table: %v", err))
+	}
+
+	table := make(map[uint32]cwe)
+	for _, rec := range records[1:] {
+		id, err := strconv.ParseUint(rec[0], 10, 32)
+		if err != nil {
+			panic(fmt.Sprintf("invalid CWE ID %q: %v", rec[0], err))
+		}
+		table[uint32(id)] = cwe{ID: uint32(id), Name: rec[1], Description: rec[2]}
+	}
+	return table
+}
+
+// lookupCWE returns the details of the weakness with the provided
+// identifier. If the weakness is not in the CWE table, only the
+// identifier is filled. It returns nil if id is zero, which means
+// that no CWE has been specified.
+func lookupCWE(id uint32) *cwe {
+	if id == 0 {
+		return nil
+	}
+	if c, ok := cweTable[id]; ok {
+		return &c
+	}
+	return &cwe{ID: id}
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLookupCWE(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         uint32
+		want       *cwe
+		wantString string
+	}{
+		{
+			name: "known CWE",
+			id:   79,
+			want: &cwe{
+				ID:          79,
+				Name:        "Cross-site Scripting",
+				Description: "The product does not neutralize user-controllable input before it is placed in output that is used as a web page served to other users.",
+			},
+			wantString: "CWE-79: Cross-site Scripting",
+		},
+		{
+			name:       "unknown CWE",
+			id:         99999,
+			want:       &cwe{ID: 99999},
+			wantString: "CWE-99999",
+		},
+		{
+			name: "no CWE",
+			id:   0,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lookupCWE(tt.id)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("CWE mismatch (-want +got):\n%v", diff)
+			}
+			if got == nil {
+				return
+			}
+			if s := got.String(); s != tt.wantString {
+				t.Errorf("unexpected string: want: %q, got: %q", tt.wantString, s)
+			}
+		})
+	}
+}
+
+func TestCWETable(t *testing.T) {
+	for id, c := range cweTable {
+		if c.ID != id || c.Name == "" || c.Description == "" {
+			t.Errorf("invalid CWE entry: %v: %+v", id, c)
+		}
+	}
+}
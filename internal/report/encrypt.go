@@ -0,0 +1,107 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// createOutput creates the output file with the provided path or URL.
+// If encryption is enabled, the data written to the returned writer
+// is encrypted before being written to the file.
+func createOutput(rawURL string, opts urlutil.UploadOptions, enc config.EncryptionConfig) (io.WriteCloser, error) {
+	f, err := urlutil.Create(rawURL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("create file: %w", err)
+	}
+
+	if !enc.IsEnabled() {
+		return f, nil
+	}
+
+	name, args := encryptCommand(enc)
+	cw, err := newCmdWriter(f, name, args...)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("encrypt output: %w", err)
+	}
+	return cw, nil
+}
+
+// encryptCommand returns the name and the arguments of the command
+// used to encrypt the output file with the provided configuration.
+// The command reads the plaintext from stdin and writes the
+// ciphertext to stdout.
+func encryptCommand(cfg config.EncryptionConfig) (name string, args []string) {
+	if len(cfg.Age) > 0 {
+		for _, r := range cfg.Age {
+			args = append(args, "--recipient", r)
+		}
+		return "age", args
+	}
+
+	args = []string{"--batch", "--yes", "--trust-model", "always", "--encrypt", "--output", "-"}
+	for _, r := range cfg.OpenPGP {
+		args = append(args, "--recipient", r)
+	}
+	return "gpg", args
+}
+
+// cmdWriter is an [io.WriteCloser] that pipes the written data
+// through a command. The output of the command is written to the
+// underlying writer.
+type cmdWriter struct {
+	io.WriteCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	w      io.WriteCloser
+}
+
+// newCmdWriter starts the command name with the provided arguments
+// and returns a [cmdWriter] that writes its output into w.
+func newCmdWriter(w io.WriteCloser, name string, args ...string) (*cmdWriter, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("look path: %w", err)
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = w
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdin pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %v: %w", name, err)
+	}
+
+	cw := &cmdWriter{
+		WriteCloser: stdin,
+		cmd:         cmd,
+		stderr:      stderr,
+		w:           w,
+	}
+	return cw, nil
+}
+
+// Close closes the input of the command, waits for it to exit and
+// closes the underlying writer.
+func (cw *cmdWriter) Close() error {
+	if err := cw.WriteCloser.Close(); err != nil {
+		return fmt.Errorf("close stdin: %w", err)
+	}
+	if err := cw.cmd.Wait(); err != nil {
+		cw.w.Close()
+		return fmt.Errorf("%v: %w: %#q", cw.cmd.Path, err, cw.stderr)
+	}
+	return cw.w.Close()
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+func TestEncryptCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.EncryptionConfig
+		wantName string
+		wantArgs []string
+	}{
+		{
+			name: "age",
+			cfg: config.EncryptionConfig{
+				Age: []string{"age1recipient1", "age1recipient2"},
+			},
+			wantName: "age",
+			wantArgs: []string{"--recipient", "age1recipient1", "--recipient", "age1recipient2"},
+		},
+		{
+			name: "openpgp",
+			cfg: config.EncryptionConfig{
+				OpenPGP: []string{"security@example.com"},
+			},
+			wantName: "gpg",
+			wantArgs: []string{"--batch", "--yes", "--trust-model", "always", "--encrypt", "--output", "-", "--recipient", "security@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, args := encryptCommand(tt.cfg)
+			if name != tt.wantName {
+				t.Errorf("unexpected command: got: %v, want: %v", name, tt.wantName)
+			}
+			if diff := cmp.Diff(tt.wantArgs, args); diff != "" {
+				t.Errorf("arguments mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestCmdWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+
+	cw, err := newCmdWriter(f, "tr", "a-z", "A-Z")
+	if err != nil {
+		t.Fatalf("new command writer: %v", err)
+	}
+	if _, err := cw.Write([]byte("report")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if string(got) != "REPORT" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestCmdWriter_error(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+
+	cw, err := newCmdWriter(f, "false")
+	if err != nil {
+		t.Fatalf("new command writer: %v", err)
+	}
+	if err := cw.Close(); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestCreateOutput_encryption(t *testing.T) {
+	// Fake "age" command that prefixes its input.
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nprintf 'age:'\nexec cat\n"
+	if err := os.WriteFile(filepath.Join(binDir, "age"), []byte(script), 0o755); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	path := filepath.Join(t.TempDir(), "output")
+	w, err := createOutput(path, urlutil.UploadOptions{}, config.EncryptionConfig{Age: []string{"age1recipient"}})
+	if err != nil {
+		t.Fatalf("create output: %v", err)
+	}
+	if _, err := w.Write([]byte("report")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if string(got) != "age:report" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
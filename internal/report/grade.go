@@ -0,0 +1,127 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/adevinta/lava/internal/badge"
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+// maxScore is the score of a scan without findings.
+const maxScore = 100
+
+// defaultGradeWeights is the number of points subtracted from the
+// score for every finding of a given severity if not specified in
+// the configuration.
+var defaultGradeWeights = map[config.Severity]float64{
+	config.SeverityCritical: 40,
+	config.SeverityHigh:     20,
+	config.SeverityMedium:   5,
+	config.SeverityLow:      1,
+	config.SeverityInfo:     0,
+}
+
+// grade represents the security grade of a scan.
+type grade struct {
+	// Score is a number between 0 and 100.
+	Score float64 `json:"score"`
+
+	// Letter is the letter corresponding to the score.
+	Letter string `json:"grade"`
+}
+
+// mkGrade computes the security grade of the provided
+// vulnerabilities. Excluded and advisory vulnerabilities are not
+// considered. Every finding subtracts the weight of its severity from
+// [maxScore]. The weight is multiplied by the highest control
+// multiplier matching the labels of the finding.
+func mkGrade(cfg config.GradeConfig, vulns []vulnerability) grade {
+	weights := make(map[config.Severity]float64)
+	for sev, w := range defaultGradeWeights {
+		weights[sev] = w
+	}
+	for sev, w := range cfg.Weights {
+		weights[sev] = w
+	}
+
+	score := float64(maxScore)
+	for _, v := range vulns {
+		if v.isExcluded() || v.Advisory {
+			continue
+		}
+
+		mult := 1.0
+		found := false
+		for _, label := range v.Labels {
+			if m, ok := cfg.Controls[label]; ok && (!found || m > mult) {
+				mult = m
+				found = true
+			}
+		}
+		score -= weights[v.Severity] * mult
+	}
+	score = max(score, 0)
+
+	return grade{
+		Score:  score,
+		Letter: scoreToLetter(score),
+	}
+}
+
+// scoreToLetter converts a score into a letter.
+func scoreToLetter(score float64) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// badgeEndpoint represents a badge following the [shields.io endpoint]
+// schema.
+//
+// [shields.io endpoint]: https://shields.io/badges/endpoint-badge
+type badgeEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// writeBadge writes a badge-friendly JSON document with the grade to
+// the resource identified by the specified URL.
+func (g grade) writeBadge(rawURL string, opts urlutil.UploadOptions) error {
+	w, err := urlutil.Create(rawURL, opts)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	b := badgeEndpoint{
+		SchemaVersion: 1,
+		Label:         "security",
+		Message:       fmt.Sprintf("%v (%v)", g.Letter, g.Score),
+		Color:         badge.GradeColor(g.Letter),
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(b); err != nil {
+		w.Close()
+		return fmt.Errorf("encode badge: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
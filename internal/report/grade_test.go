@@ -0,0 +1,135 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/urlutil"
+)
+
+func TestMkGrade(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.GradeConfig
+		vulns []vulnerability
+		want  grade
+	}{
+		{
+			name: "no vulnerabilities",
+			want: grade{Score: 100, Letter: "A"},
+		},
+		{
+			name: "default weights",
+			vulns: []vulnerability{
+				{Severity: config.SeverityHigh},
+				{Severity: config.SeverityMedium},
+				{Severity: config.SeverityLow},
+				{Severity: config.SeverityInfo},
+			},
+			want: grade{Score: 74, Letter: "C"},
+		},
+		{
+			name: "excluded vulnerabilities",
+			vulns: []vulnerability{
+				{Severity: config.SeverityCritical, matchedExclusions: []int{0}},
+				{Severity: config.SeverityMedium},
+			},
+			want: grade{Score: 95, Letter: "A"},
+		},
+		{
+			name: "advisory vulnerabilities",
+			vulns: []vulnerability{
+				{Severity: config.SeverityCritical, Advisory: true},
+				{Severity: config.SeverityMedium},
+			},
+			want: grade{Score: 95, Letter: "A"},
+		},
+		{
+			name: "custom weights",
+			cfg: config.GradeConfig{
+				Weights: map[config.Severity]float64{
+					config.SeverityMedium: 15,
+				},
+			},
+			vulns: []vulnerability{
+				{Severity: config.SeverityMedium},
+			},
+			want: grade{Score: 85, Letter: "B"},
+		},
+		{
+			name: "controls",
+			cfg: config.GradeConfig{
+				Controls: map[string]float64{
+					"secret":     2,
+					"dependency": 0.5,
+				},
+			},
+			vulns: []vulnerability{
+				{
+					Vulnerability: vreport.Vulnerability{Labels: []string{"issue", "secret"}},
+					Severity:      config.SeverityMedium,
+				},
+				{
+					Vulnerability: vreport.Vulnerability{Labels: []string{"dependency"}},
+					Severity:      config.SeverityHigh,
+				},
+			},
+			want: grade{Score: 80, Letter: "B"},
+		},
+		{
+			name: "minimum score",
+			vulns: []vulnerability{
+				{Severity: config.SeverityCritical},
+				{Severity: config.SeverityCritical},
+				{Severity: config.SeverityCritical},
+			},
+			want: grade{Score: 0, Letter: "F"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mkGrade(tt.cfg, tt.vulns)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("grade mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestGrade_writeBadge(t *testing.T) {
+	file := path.Join(t.TempDir(), "badge.json")
+
+	g := grade{Score: 85, Letter: "B"}
+	if err := g.writeBadge(file, urlutil.UploadOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read badge: %v", err)
+	}
+
+	var got badgeEndpoint
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal badge: %v", err)
+	}
+
+	want := badgeEndpoint{
+		SchemaVersion: 1,
+		Label:         "security",
+		Message:       "B (85)",
+		Color:         "green",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("badge mismatch (-want +got):\n%v", diff)
+	}
+}
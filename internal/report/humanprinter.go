@@ -3,6 +3,7 @@
 package report
 
 import (
+	"bufio"
 	_ "embed"
 	"fmt"
 	"io"
@@ -12,35 +13,149 @@ import (
 	"github.com/fatih/color"
 
 	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/warnings"
 )
 
 // humanPrinter represents a human-readable report printer.
-type humanPrinter struct{}
+type humanPrinter struct {
+	// theme is the color theme used to render the report.
+	theme config.Theme
+
+	// width is the width of the report in columns. Zero means
+	// that the width is unknown.
+	width int
+}
+
+// style is the list of attributes used to render a text element.
+type style []color.Attribute
+
+// theme contains the styles of the elements of the human-readable
+// report.
+type theme struct {
+	critical style
+	high     style
+	medium   style
+	low      style
+	info     style
+	header   style
+	bold     style
+}
+
+// themes contains the supported color themes. The monochrome theme
+// only uses text attributes, so the severities can be told apart by
+// their labels. All the themes degrade to plain text when colors are
+// disabled, for instance, because the NO_COLOR environment variable
+// is set or the terminal is "dumb".
+var themes = map[config.Theme]theme{
+	config.ThemeDefault: {
+		critical: style{color.Bold, color.FgMagenta},
+		high:     style{color.Bold, color.FgRed},
+		medium:   style{color.Bold, color.FgYellow},
+		low:      style{color.Bold, color.FgCyan},
+		info:     style{color.Bold},
+		header:   style{color.Bold, color.Underline},
+		bold:     style{color.Bold},
+	},
+	config.ThemeHighContrast: {
+		critical: style{color.Bold, color.FgHiWhite, color.BgMagenta},
+		high:     style{color.Bold, color.FgHiWhite, color.BgRed},
+		medium:   style{color.Bold, color.FgHiYellow},
+		low:      style{color.Bold, color.FgHiCyan},
+		info:     style{color.Bold, color.FgHiWhite},
+		header:   style{color.Bold, color.Underline, color.FgHiWhite},
+		bold:     style{color.Bold, color.FgHiWhite},
+	},
+	config.ThemeMonochrome: {
+		critical: style{color.Bold, color.ReverseVideo},
+		high:     style{color.Bold, color.Underline},
+		medium:   style{color.Bold},
+		low:      style{color.Bold},
+		info:     style{color.Bold},
+		header:   style{color.Bold, color.Underline},
+		bold:     style{color.Bold},
+	},
+}
+
+// funcs returns the functions called from the template used to
+// render the human-readable report with the theme.
+func (th theme) funcs() template.FuncMap {
+	sevs := map[string]func(string, ...any) string{
+		"critical": color.New(th.critical...).SprintfFunc(),
+		"high":     color.New(th.high...).SprintfFunc(),
+		"medium":   color.New(th.medium...).SprintfFunc(),
+		"low":      color.New(th.low...).SprintfFunc(),
+		"info":     color.New(th.info...).SprintfFunc(),
+	}
+	return template.FuncMap{
+		"critical": sevs["critical"],
+		"high":     sevs["high"],
+		"medium":   sevs["medium"],
+		"low":      sevs["low"],
+		"info":     sevs["info"],
+		"severity": func(sev, s string) string {
+			if f, ok := sevs[sev]; ok {
+				return f("%s", s)
+			}
+			return s
+		},
+		"header": color.New(th.header...).SprintfFunc(),
+		"bold":   color.New(th.bold...).SprintfFunc(),
+		"upper":  strings.ToUpper,
+		"trim":   strings.TrimSpace,
+	}
+}
 
 var (
 	//go:embed human.tmpl
 	humanReport string
 
-	// humanTmplFuncs stores the functions called from the
-	// template used to render the human-readable report.
-	humanTmplFuncs = template.FuncMap{
-		"magenta":   color.New(color.FgMagenta).SprintfFunc(),
-		"red":       color.New(color.FgRed).SprintfFunc(),
-		"yellow":    color.New(color.FgYellow).SprintfFunc(),
-		"cyan":      color.New(color.FgCyan).SprintfFunc(),
-		"bold":      color.New(color.Bold).SprintfFunc(),
-		"underline": color.New(color.Underline).SprintfFunc(),
-		"upper":     strings.ToUpper,
-		"trim":      strings.TrimSpace,
+	// humanTmpls stores the templates used to render the
+	// human-readable report indexed by color theme.
+	humanTmpls = mkHumanTmpls()
+)
+
+// mkHumanTmpls parses the template used to render the human-readable
+// report once per color theme.
+func mkHumanTmpls() map[config.Theme]*template.Template {
+	tmpls := make(map[config.Theme]*template.Template)
+	for t, th := range themes {
+		tmpls[t] = template.Must(template.New("").Funcs(th.funcs()).Funcs(layout{}.funcs()).Parse(humanReport))
 	}
+	return tmpls
+}
 
-	// humanTmpl is the template used to render the human-readable
-	// report.
-	humanTmpl = template.Must(template.New("").Funcs(humanTmplFuncs).Parse(humanReport))
-)
+// Print renders the scan results in a human-readable format. The
+// report is written incrementally, one vulnerability at a time, so
+// the rendered document is never held in memory.
+func (prn humanPrinter) Print(w io.Writer, vulns []vulnerability, summ summary, status []checkStatus, staleExcls []staleExclusion, warns []warnings.Warning, md *Metadata) error {
+	humanTmpl, err := prn.template()
+	if err != nil {
+		return err
+	}
+	return printText(w, humanTmpl, vulns, summ, status, staleExcls, warns, md)
+}
 
-// Print renders the scan results in a human-readable format.
-func (prn humanPrinter) Print(w io.Writer, vulns []vulnerability, summ summary, status []checkStatus, staleExcls []config.Exclusion) error {
+// template returns the template used to render the human-readable
+// report with the theme and layout of the printer.
+func (prn humanPrinter) template() (*template.Template, error) {
+	themeTmpl, ok := humanTmpls[prn.theme]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", config.ErrInvalidTheme, prn.theme)
+	}
+	humanTmpl, err := themeTmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("clone template: %w", err)
+	}
+	humanTmpl.Funcs(layout{width: prn.width}.funcs())
+	return humanTmpl, nil
+}
+
+// printText renders the scan results with the provided text
+// template. The template must define the "head", "vulnsTitle",
+// "vuln", "staleExcls" and "warnings" templates, which render the
+// sections of the report. The report is written incrementally, one
+// vulnerability at a time.
+func printText(w io.Writer, tmpl *template.Template, vulns []vulnerability, summ summary, status []checkStatus, staleExcls []staleExclusion, warns []warnings.Warning, md *Metadata) error {
 	// count the total non-excluded vulnerabilities found.
 	var total int
 	for _, ss := range summ.count {
@@ -48,30 +163,89 @@ func (prn humanPrinter) Print(w io.Writer, vulns []vulnerability, summ summary,
 	}
 
 	stats := make(map[string]int)
+	var overdue, advisory int
 	for s := config.SeverityCritical; s >= config.SeverityInfo; s-- {
 		stats[s.String()] = summ.count[s]
+		overdue += summ.overdue[s]
+		advisory += summ.advisory[s]
 	}
 
 	data := struct {
-		Stats          map[string]int
-		Total          int
-		Excluded       int
-		Vulns          []vulnerability
-		Status         []checkStatus
-		AllExclMatched bool
-		StaleExcls     []config.Exclusion
+		Stats      map[string]int
+		Total      int
+		Excluded   int
+		Overdue    int
+		Advisory   int
+		Omitted    int
+		Truncated  int
+		Coverage   []targetCoverage
+		Status     []checkStatus
+		StaleExcls []staleExclusion
+		Grade      *grade
+		Warnings   []warnings.Warning
+		Metadata   *Metadata
 	}{
 		Stats:      stats,
 		Total:      total,
 		Excluded:   summ.excluded,
-		Vulns:      vulns,
+		Overdue:    overdue,
+		Advisory:   advisory,
+		Omitted:    summ.omitted,
+		Truncated:  summ.truncated,
+		Coverage:   summ.coverage,
 		Status:     status,
 		StaleExcls: staleExcls,
+		Grade:      summ.grade,
+		Warnings:   warns,
+		Metadata:   md,
+	}
+
+	bw := bufio.NewWriter(w)
+
+	if err := tmpl.ExecuteTemplate(bw, "head", data); err != nil {
+		return fmt.Errorf("execute template head: %w", err)
+	}
+
+	if len(vulns) > 0 {
+		if _, err := io.WriteString(bw, "\n"); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+		if err := tmpl.ExecuteTemplate(bw, "vulnsTitle", nil); err != nil {
+			return fmt.Errorf("execute template vulnsTitle: %w", err)
+		}
+		if _, err := io.WriteString(bw, "\n"); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+		for _, v := range vulns {
+			if _, err := io.WriteString(bw, "\n"); err != nil {
+				return fmt.Errorf("write report: %w", err)
+			}
+			if err := tmpl.ExecuteTemplate(bw, "vuln", v); err != nil {
+				return fmt.Errorf("execute template vuln: %w", err)
+			}
+		}
 	}
 
-	if err := humanTmpl.Execute(w, data); err != nil {
-		return fmt.Errorf("execute template summary: %w", err)
+	if len(staleExcls) > 0 {
+		if _, err := io.WriteString(bw, "\n"); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+		if err := tmpl.ExecuteTemplate(bw, "staleExcls", data); err != nil {
+			return fmt.Errorf("execute template staleExcls: %w", err)
+		}
 	}
 
+	if len(warns) > 0 {
+		if _, err := io.WriteString(bw, "\n"); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+		if err := tmpl.ExecuteTemplate(bw, "warnings", data); err != nil {
+			return fmt.Errorf("execute template warnings: %w", err)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flush report: %w", err)
+	}
 	return nil
 }
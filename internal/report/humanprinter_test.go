@@ -4,12 +4,17 @@ package report
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	vreport "github.com/adevinta/vulcan-report"
+	"github.com/fatih/color"
 
 	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/warnings"
 )
 
 func TestUserFriendlyPrinter_Print(t *testing.T) {
@@ -18,7 +23,8 @@ func TestUserFriendlyPrinter_Print(t *testing.T) {
 		vulnerabilities []vulnerability
 		summ            summary
 		status          []checkStatus
-		staleExcls      []config.Exclusion
+		staleExcls      []staleExclusion
+		warns           []warnings.Warning
 		want            []string
 	}{
 		{
@@ -163,8 +169,15 @@ func TestUserFriendlyPrinter_Print(t *testing.T) {
 					Status:    "FINISHED",
 				},
 			},
-			staleExcls: []config.Exclusion{
-				{Summary: "Unused exclusion"},
+			staleExcls: []staleExclusion{
+				{
+					Exclusion:   config.Exclusion{Summary: "Unused exclusion", Owner: "security-team"},
+					LastMatched: ptr(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				{
+					Exclusion:   config.Exclusion{Summary: "Never used exclusion"},
+					LastMatched: &time.Time{},
+				},
 			},
 			want: []string{
 				"STATUS",
@@ -175,6 +188,10 @@ func TestUserFriendlyPrinter_Print(t *testing.T) {
 				"Vulnerability Summary 1",
 				"STALE EXCLUSIONS",
 				"- Summary: Unused exclusion",
+				"  Owner: security-team",
+				"  Last Matched: 2024/03/01",
+				"- Summary: Never used exclusion",
+				"  Last Matched: never",
 			},
 		},
 		{
@@ -194,12 +211,70 @@ func TestUserFriendlyPrinter_Print(t *testing.T) {
 				"No vulnerabilities found during the scan.",
 			},
 		},
+		{
+			name:            "Security grade",
+			vulnerabilities: nil,
+			summ: summary{
+				grade: &grade{Score: 100, Letter: "A"},
+			},
+			want: []string{
+				"SUMMARY",
+				"Security grade: A (100/100)",
+			},
+		},
+		{
+			name: "SLA",
+			vulnerabilities: []vulnerability{
+				{
+					Vulnerability: vreport.Vulnerability{
+						Summary: "Overdue vulnerability",
+					},
+					Severity: config.SeverityCritical,
+					SLA: &slaStatus{
+						Due:     time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+						Overdue: true,
+					},
+				},
+			},
+			summ: summary{
+				count: map[config.Severity]int{
+					config.SeverityCritical: 1,
+				},
+				overdue: map[config.Severity]int{
+					config.SeverityCritical: 1,
+				},
+			},
+			want: []string{
+				"Number of vulnerabilities not fixed within their deadline: 1",
+				"DEADLINE\n2024/01/08 (overdue)\n",
+			},
+		},
+		{
+			name:            "Warnings",
+			vulnerabilities: nil,
+			warns: []warnings.Warning{
+				{
+					Message: "invalid file type",
+					Attrs:   map[string]string{"path": "socket", "mode": "Srwxr-xr-x"},
+					Count:   2,
+				},
+				{
+					Message: "skipped target",
+					Count:   1,
+				},
+			},
+			want: []string{
+				"WARNINGS",
+				"- invalid file type mode=Srwxr-xr-x path=socket (x2)\n",
+				"- skipped target\n",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var buf bytes.Buffer
 			w := humanPrinter{}
-			if err := w.Print(&buf, tt.vulnerabilities, tt.summ, tt.status, tt.staleExcls); err != nil {
+			if err := w.Print(&buf, tt.vulnerabilities, tt.summ, tt.status, tt.staleExcls, tt.warns, nil); err != nil {
 				t.Errorf("unexpected error value: %v", err)
 			}
 			text := buf.String()
@@ -212,3 +287,213 @@ func TestUserFriendlyPrinter_Print(t *testing.T) {
 		})
 	}
 }
+
+func TestHumanPrinter_Print_themes(t *testing.T) {
+	vulns := []vulnerability{
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary: "Vulnerability Summary",
+			},
+			Severity: config.SeverityCritical,
+		},
+	}
+	summ := summary{
+		count: map[config.Severity]int{
+			config.SeverityCritical: 1,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		theme   config.Theme
+		noColor bool
+		want    string
+	}{
+		{
+			name:  "default",
+			theme: config.ThemeDefault,
+			want:  "\x1b[1;35m=== Vulnerability Summary (CRITICAL) ===",
+		},
+		{
+			name:  "high contrast",
+			theme: config.ThemeHighContrast,
+			want:  "\x1b[1;97;45m=== Vulnerability Summary (CRITICAL) ===",
+		},
+		{
+			name:  "monochrome",
+			theme: config.ThemeMonochrome,
+			want:  "\x1b[1;7m=== Vulnerability Summary (CRITICAL) ===",
+		},
+		{
+			name:    "no color",
+			theme:   config.ThemeHighContrast,
+			noColor: true,
+			want:    "\n=== Vulnerability Summary (CRITICAL) ===\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldNoColor := color.NoColor
+			defer func() { color.NoColor = oldNoColor }()
+
+			color.NoColor = tt.noColor
+
+			var buf bytes.Buffer
+			prn := humanPrinter{theme: tt.theme}
+			if err := prn.Print(&buf, vulns, summ, nil, nil, nil, nil); err != nil {
+				t.Fatalf("unexpected error value: %v", err)
+			}
+			text := buf.String()
+
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("text not found: %q", tt.want)
+			}
+			if tt.noColor && strings.Contains(text, "\x1b[") {
+				t.Errorf("unexpected escape sequence in output: %q", text)
+			}
+		})
+	}
+}
+
+func TestHumanPrinter_Print_metadata(t *testing.T) {
+	oldNoColor := color.NoColor
+	defer func() { color.NoColor = oldNoColor }()
+	color.NoColor = true
+
+	md := &Metadata{
+		LavaVersion: "v1.0.0",
+		ConfigFile:  "lava.yaml",
+		ConfigHash:  "0123",
+		ScanID:      "scan1",
+		StartTime:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:     time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC),
+		OS:          "linux",
+		Arch:        "amd64",
+		Runtime:     "Dockerd",
+		Labels:      map[string]string{"pipeline": "nightly"},
+	}
+
+	var buf bytes.Buffer
+	if err := (humanPrinter{}).Print(&buf, nil, summary{}, nil, nil, nil, md); err != nil {
+		t.Fatalf("unexpected error value: %v", err)
+	}
+	text := buf.String()
+
+	want := []string{
+		"METADATA",
+		"Lava version: v1.0.0",
+		"Scan ID: scan1",
+		"Config file: lava.yaml (sha256:0123)",
+		"Start time: 2024-01-01T00:00:00Z",
+		"End time: 2024-01-01T00:05:00Z",
+		"Host: linux/amd64",
+		"Runtime: Dockerd",
+		"Label: pipeline=nightly",
+	}
+	for _, w := range want {
+		if !strings.Contains(text, w) {
+			t.Errorf("text not found: %q", w)
+		}
+	}
+	if i, j := strings.Index(text, "METADATA"), strings.Index(text, "STATUS"); i > j {
+		t.Errorf("metadata rendered after status")
+	}
+}
+
+func TestHumanPrinter_Print_width(t *testing.T) {
+	vulns := []vulnerability{
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary:     "Vulnerability Summary",
+				Description: "Lorem ipsum dolor sit amet, consectetur adipiscing elit.",
+				Resources: []vreport.ResourcesGroup{
+					{
+						Name:   "Packages",
+						Header: []string{"Name", "Version"},
+						Rows: []map[string]string{
+							{"Name": "openssl", "Version": "1.1.1"},
+						},
+					},
+				},
+			},
+			Severity: config.SeverityHigh,
+		},
+	}
+	summ := summary{
+		count: map[config.Severity]int{
+			config.SeverityHigh: 1,
+		},
+	}
+
+	tests := []struct {
+		name  string
+		width int
+		want  []string
+	}{
+		{
+			name:  "unknown",
+			width: 0,
+			want: []string{
+				"Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n",
+				"- Packages:\n  Name     Version\n  ----     -------\n  openssl  1.1.1\n",
+			},
+		},
+		{
+			name:  "narrow",
+			width: 40,
+			want: []string{
+				"Lorem ipsum dolor sit amet, consectetur\nadipiscing elit.\n",
+				"- Packages:\n  - Name: openssl\n    Version: 1.1.1\n",
+			},
+		},
+		{
+			name:  "wide",
+			width: 120,
+			want: []string{
+				"Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n",
+				"- Packages:\n  Name     Version\n  ----     -------\n  openssl  1.1.1\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			prn := humanPrinter{width: tt.width}
+			if err := prn.Print(&buf, vulns, summ, nil, nil, nil, nil); err != nil {
+				t.Fatalf("unexpected error value: %v", err)
+			}
+			text := buf.String()
+
+			for _, wantText := range tt.want {
+				if !strings.Contains(text, wantText) {
+					t.Errorf("text not found: %q", wantText)
+				}
+			}
+		})
+	}
+}
+
+func TestHumanPrinter_Print_invalid_theme(t *testing.T) {
+	prn := humanPrinter{theme: config.Theme(-1)}
+	if err := prn.Print(io.Discard, nil, summary{}, nil, nil, nil, nil); !errors.Is(err, config.ErrInvalidTheme) {
+		t.Errorf("unexpected error: want: %v, got: %v", config.ErrInvalidTheme, err)
+	}
+}
+
+func BenchmarkHumanPrinter_Print(b *testing.B) {
+	vulns := mkBenchVulns(10000)
+	summ, err := mkSummary(vulns)
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := (humanPrinter{}).Print(io.Discard, vulns, summ, nil, nil, nil, nil); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
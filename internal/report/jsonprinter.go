@@ -3,22 +3,100 @@
 package report
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
 
-	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/warnings"
 )
 
 // jsonPrinter represents a JSON report printer.
 type jsonPrinter struct{}
 
-// Print renders the scan results in JSON format.
-func (prn jsonPrinter) Print(w io.Writer, vulns []vulnerability, _ summary, _ []checkStatus, _ []config.Exclusion) error {
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(vulns); err != nil {
+// Print renders the scan results in JSON format. The vulnerabilities
+// are encoded one at a time, so the rendered document is never held
+// in memory. The output is equivalent to encoding the whole list of
+// vulnerabilities with two-space indentation. If md is not nil, the
+// output is an object with the fields "metadata" and "findings"
+// instead.
+func (prn jsonPrinter) Print(w io.Writer, vulns []vulnerability, _ summary, _ []checkStatus, _ []staleExclusion, _ []warnings.Warning, md *Metadata) error {
+	bw := bufio.NewWriter(w)
+
+	if err := prn.encode(bw, vulns, md); err != nil {
 		return fmt.Errorf("encode report: %w", err)
 	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flush report: %w", err)
+	}
 	return nil
 }
+
+// encode writes the JSON encoding of vulns and md into w.
+func (prn jsonPrinter) encode(w io.Writer, vulns []vulnerability, md *Metadata) error {
+	if md == nil {
+		if err := prn.encodeVulns(w, vulns, ""); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+
+	b, err := json.MarshalIndent(md, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+	if _, err := io.WriteString(w, "{\n  \"metadata\": "); err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, ",\n  \"findings\": "); err != nil {
+		return err
+	}
+	if err := prn.encodeVulns(w, vulns, "  "); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n}\n")
+	return err
+}
+
+// encodeVulns writes the JSON encoding of vulns into w. Every line
+// after the first one begins with prefix.
+func (prn jsonPrinter) encodeVulns(w io.Writer, vulns []vulnerability, prefix string) error {
+	if vulns == nil {
+		_, err := io.WriteString(w, "null")
+		return err
+	}
+
+	if len(vulns) == 0 {
+		_, err := io.WriteString(w, "[]")
+		return err
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	indent := prefix + "  "
+	for i, v := range vulns {
+		sep := ",\n" + indent
+		if i == 0 {
+			sep = "\n" + indent
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(v, indent, "  ")
+		if err != nil {
+			return fmt.Errorf("marshal vulnerability: %w", err)
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "\n"+prefix+"]")
+	return err
+}
@@ -5,10 +5,16 @@ package report
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"testing"
+	"time"
 
 	vreport "github.com/adevinta/vulcan-report"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+	lavareport "github.com/adevinta/lava/report"
 )
 
 func TestJsonPrinter_Print(t *testing.T) {
@@ -163,7 +169,7 @@ func TestJsonPrinter_Print(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var buf bytes.Buffer
 			w := jsonPrinter{}
-			err := w.Print(&buf, tt.vulnerabilities, summary{}, nil, nil)
+			err := w.Print(&buf, tt.vulnerabilities, summary{}, nil, nil, nil, nil)
 			if (err == nil) != tt.wantNilErr {
 				t.Errorf("unexpected error value: %v", err)
 			}
@@ -181,3 +187,195 @@ func TestJsonPrinter_Print(t *testing.T) {
 		})
 	}
 }
+
+func TestJsonPrinter_Print_Encoding(t *testing.T) {
+	tests := []struct {
+		name            string
+		vulnerabilities []vulnerability
+	}{
+		{
+			name:            "nil",
+			vulnerabilities: nil,
+		},
+		{
+			name:            "empty",
+			vulnerabilities: []vulnerability{},
+		},
+		{
+			name:            "multiple vulnerabilities",
+			vulnerabilities: mkBenchVulns(3),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want bytes.Buffer
+			enc := json.NewEncoder(&want)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(tt.vulnerabilities); err != nil {
+				t.Fatalf("encode vulnerabilities: %v", err)
+			}
+
+			var got bytes.Buffer
+			if err := (jsonPrinter{}).Print(&got, tt.vulnerabilities, summary{}, nil, nil, nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestJsonPrinter_Print_public_types(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	vulns := []vulnerability{
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary:          "Vulnerability Summary 1",
+				Score:            6.7,
+				AffectedResource: "Affected Resource 1",
+				Fingerprint:      "Fingerprint 1",
+				Labels:           []string{"Label 1"},
+			},
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "Checktype1",
+				Target:        "Target1",
+				Status:        "FINISHED",
+				StartTime:     t0,
+				EndTime:       t0.Add(time.Minute),
+			},
+			CheckKey: "CheckKey1",
+			Severity: config.SeverityMedium,
+			Advisory: true,
+			Parent:   "Parent 1",
+			SLA: &slaStatus{
+				FirstSeen: t0,
+				Due:       t0.Add(24 * time.Hour),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (jsonPrinter{}).Print(&buf, vulns, summary{}, nil, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := buf.String()
+
+	rep, err := lavareport.Load(&buf)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+
+	// Encoding the public types must produce the same document,
+	// so no field is lost.
+	var got bytes.Buffer
+	enc := json.NewEncoder(&got)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rep); err != nil {
+		t.Fatalf("encode report: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestJsonPrinter_Print_metadata(t *testing.T) {
+	md := &Metadata{
+		LavaVersion: "v1.0.0",
+		ConfigFile:  "lava.yaml",
+		ScanID:      "scan1",
+		StartTime:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:     time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC),
+		OS:          "linux",
+		Arch:        "amd64",
+		Runtime:     "Dockerd",
+		Labels:      map[string]string{"pipeline": "nightly"},
+	}
+
+	tests := []struct {
+		name            string
+		vulnerabilities []vulnerability
+	}{
+		{
+			name:            "nil",
+			vulnerabilities: nil,
+		},
+		{
+			name:            "empty",
+			vulnerabilities: []vulnerability{},
+		},
+		{
+			name:            "multiple vulnerabilities",
+			vulnerabilities: mkBenchVulns(3),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := struct {
+				Metadata *Metadata       `json:"metadata"`
+				Findings []vulnerability `json:"findings"`
+			}{md, tt.vulnerabilities}
+
+			var want bytes.Buffer
+			enc := json.NewEncoder(&want)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(doc); err != nil {
+				t.Fatalf("encode document: %v", err)
+			}
+
+			var got bytes.Buffer
+			if err := (jsonPrinter{}).Print(&got, tt.vulnerabilities, summary{}, nil, nil, nil, md); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%v", diff)
+			}
+
+			if _, err := lavareport.LoadDocument(&got); err != nil {
+				t.Errorf("load document error: %v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkJsonPrinter_Print(b *testing.B) {
+	vulns := mkBenchVulns(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := (jsonPrinter{}).Print(io.Discard, vulns, summary{}, nil, nil, nil, nil); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+// mkBenchVulns returns n vulnerabilities to be used in tests and
+// benchmarks.
+func mkBenchVulns(n int) []vulnerability {
+	vulns := make([]vulnerability, n)
+	for i := range vulns {
+		vulns[i] = vulnerability{
+			Vulnerability: vreport.Vulnerability{
+				Summary:          fmt.Sprintf("Vulnerability Summary %v", i),
+				Description:      "Vulnerability description with <html> & special characters.",
+				AffectedResource: fmt.Sprintf("Affected Resource %v", i),
+				Fingerprint:      fmt.Sprintf("%08x", i),
+				Score:            float32(i % 10),
+				Recommendations:  []string{"Recommendation 1", "Recommendation 2"},
+				References:       []string{"Reference 1"},
+			},
+			CheckData: vreport.CheckData{
+				CheckID:       fmt.Sprintf("check%v", i),
+				ChecktypeName: "vulcan-trivy",
+				Target:        "example.com",
+			},
+			Severity: scoreToSeverity(float32(i % 10)),
+		}
+	}
+	return vulns
+}
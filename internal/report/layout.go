@@ -0,0 +1,137 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+// wideWidth is the minimum width in columns of a wide layout. The
+// resources of the vulnerabilities are rendered as tables in wide
+// layouts and as key-value lists otherwise.
+const wideWidth = 100
+
+// termWidth returns the width of the terminal attached to stdout. It
+// returns zero if stdout is not a terminal. It is set by tests to
+// mock the terminal.
+var termWidth = func() int {
+	w, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// outputWidth returns the width in columns of the human-readable
+// report based on the provided configuration. If no width is
+// configured, the width of the terminal is used. It returns zero if
+// the width is unknown, for instance, because the report is written
+// to a file.
+func outputWidth(cfg config.ReportConfig) int {
+	if w := config.Get(cfg.Width); w > 0 {
+		return w
+	}
+	if config.Get(cfg.OutputFile) != "" {
+		return 0
+	}
+	return termWidth()
+}
+
+// layout represents the layout of the human-readable report.
+type layout struct {
+	// width is the width of the report in columns. Zero means
+	// that the width is unknown, so text is not wrapped.
+	width int
+}
+
+// funcs returns the functions called from the template used to
+// render the human-readable report with the layout.
+func (l layout) funcs() template.FuncMap {
+	return template.FuncMap{
+		"wide":  l.wide,
+		"wrap":  l.wrap,
+		"table": l.table,
+	}
+}
+
+// wide reports whether the layout is wide. Layouts of unknown width
+// are considered wide, so the narrow layout is only used for narrow
+// terminals or when a narrow width is configured explicitly.
+func (l layout) wide() bool {
+	return l.width <= 0 || l.width >= wideWidth
+}
+
+// wrap wraps the lines of the provided text so they fit in the width
+// of the layout. Words longer than the width are not split.
+func (l layout) wrap(s string) string {
+	if l.width <= 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		n := 0
+		for _, word := range strings.Fields(line) {
+			wn := utf8.RuneCountInString(word)
+			switch {
+			case n == 0:
+			case n+1+wn > l.width:
+				b.WriteString("\n")
+				n = 0
+			default:
+				b.WriteString(" ")
+				n++
+			}
+			b.WriteString(word)
+			n += wn
+		}
+	}
+	return b.String()
+}
+
+// table renders the provided rows as a table with the specified
+// header. Every line of the table is indented with two spaces. The
+// returned string does not end with a new line.
+func (l layout) table(header []string, rows []map[string]string) string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	cells := make([]string, len(header))
+	for i, h := range header {
+		cells[i] = cell(h)
+	}
+	fmt.Fprintln(tw, "  "+strings.Join(cells, "\t"))
+
+	for i, c := range cells {
+		cells[i] = strings.Repeat("-", utf8.RuneCountInString(c))
+	}
+	fmt.Fprintln(tw, "  "+strings.Join(cells, "\t"))
+
+	for _, row := range rows {
+		for i, h := range header {
+			cells[i] = cell(row[h])
+		}
+		fmt.Fprintln(tw, "  "+strings.Join(cells, "\t"))
+	}
+
+	tw.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// cell returns the provided value in a form that can be used as the
+// cell of a table.
+func cell(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
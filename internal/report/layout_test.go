@@ -0,0 +1,135 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestLayout_wrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		text  string
+		want  string
+	}{
+		{
+			name:  "unknown width",
+			width: 0,
+			text:  "lorem ipsum dolor sit amet",
+			want:  "lorem ipsum dolor sit amet",
+		},
+		{
+			name:  "wrapped",
+			width: 11,
+			text:  "lorem ipsum dolor sit amet",
+			want:  "lorem ipsum\ndolor sit\namet",
+		},
+		{
+			name:  "long word",
+			width: 5,
+			text:  "a https://example.com b",
+			want:  "a\nhttps://example.com\nb",
+		},
+		{
+			name:  "paragraphs",
+			width: 11,
+			text:  "lorem ipsum dolor\n\nsit amet",
+			want:  "lorem ipsum\ndolor\n\nsit amet",
+		},
+		{
+			name:  "fits",
+			width: 100,
+			text:  "lorem ipsum",
+			want:  "lorem ipsum",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := layout{width: tt.width}.wrap(tt.text)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("text mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestLayout_table(t *testing.T) {
+	header := []string{"Name", "Version"}
+	rows := []map[string]string{
+		{"Name": "openssl", "Version": "1.1.1"},
+		{"Name": "zlib", "Version": "1.2.11\n"},
+	}
+
+	want := "" +
+		"  Name     Version\n" +
+		"  ----     -------\n" +
+		"  openssl  1.1.1\n" +
+		"  zlib     1.2.11"
+
+	got := layout{width: 120}.table(header, rows)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("table mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestOutputWidth(t *testing.T) {
+	oldTermWidth := termWidth
+	defer func() { termWidth = oldTermWidth }()
+
+	termWidth = func() int { return 150 }
+
+	tests := []struct {
+		name string
+		cfg  config.ReportConfig
+		want int
+	}{
+		{
+			name: "terminal",
+			cfg:  config.ReportConfig{},
+			want: 150,
+		},
+		{
+			name: "configured width",
+			cfg: config.ReportConfig{
+				Width: ptr(80),
+			},
+			want: 80,
+		},
+		{
+			name: "zero width",
+			cfg: config.ReportConfig{
+				Width: ptr(0),
+			},
+			want: 150,
+		},
+		{
+			name: "output file",
+			cfg: config.ReportConfig{
+				OutputFile: ptr("report.txt"),
+			},
+			want: 0,
+		},
+		{
+			name: "output file with configured width",
+			cfg: config.ReportConfig{
+				Width:      ptr(80),
+				OutputFile: ptr("report.txt"),
+			},
+			want: 80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputWidth(tt.cfg); got != tt.want {
+				t.Errorf("unexpected width: want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import "time"
+
+// Metadata is the metadata of a scan. It makes archived reports
+// self-describing.
+type Metadata struct {
+	// LavaVersion is the version of Lava that run the scan.
+	LavaVersion string `json:"lava_version"`
+
+	// ConfigFile is the path of the configuration file. If the
+	// configuration is composed of multiple files, it contains
+	// their paths separated by commas.
+	ConfigFile string `json:"config_file,omitempty"`
+
+	// ConfigHash is the SHA-256 hash of the configuration file
+	// encoded in hexadecimal. If the configuration is composed of
+	// multiple files, it is the hash of their concatenated
+	// contents.
+	ConfigHash string `json:"config_hash,omitempty"`
+
+	// ScanID is the unique ID of the scan.
+	ScanID string `json:"scan_id"`
+
+	// StartTime is the time when the scan started.
+	StartTime time.Time `json:"start_time"`
+
+	// EndTime is the time when the scan finished.
+	EndTime time.Time `json:"end_time"`
+
+	// OS is the operating system of the host that run the scan.
+	OS string `json:"os"`
+
+	// Arch is the architecture of the host that run the scan.
+	Arch string `json:"arch"`
+
+	// Runtime is the container runtime used to run the checks.
+	Runtime string `json:"runtime"`
+
+	// Labels are the labels provided by the user.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// WithMetadata returns a copy of the writer that includes the
+// provided metadata in the report.
+func (writer Writer) WithMetadata(md Metadata) Writer {
+	writer.metadata = &md
+	return writer
+}
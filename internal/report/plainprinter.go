@@ -0,0 +1,34 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	_ "embed"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/adevinta/lava/internal/warnings"
+)
+
+// plainPrinter represents a plain-text report printer. Unlike the
+// human-readable printer, it does not use colors, tables nor
+// decorative characters, and renders every element as a linear
+// "label: value" line. This makes the report friendly to screen
+// readers and to tools that do not preserve the layout of the text,
+// like ticketing systems.
+type plainPrinter struct{}
+
+var (
+	//go:embed plain.tmpl
+	plainReport string
+
+	// plainTmpl is the template used to render the plain-text
+	// report.
+	plainTmpl = template.Must(template.New("").Funcs(template.FuncMap{"trim": strings.TrimSpace}).Parse(plainReport))
+)
+
+// Print renders the scan results in plain text.
+func (prn plainPrinter) Print(w io.Writer, vulns []vulnerability, summ summary, status []checkStatus, staleExcls []staleExclusion, warns []warnings.Warning, md *Metadata) error {
+	return printText(w, plainTmpl, vulns, summ, status, staleExcls, warns, md)
+}
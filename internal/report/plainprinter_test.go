@@ -0,0 +1,126 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/warnings"
+)
+
+func TestPlainPrinter_Print(t *testing.T) {
+	vulns := []vulnerability{
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary:          "Vulnerability Summary",
+				AffectedResource: "Affected Resource",
+				Description:      "Vulnerability description.",
+				Recommendations: []string{
+					"Recommendation 1",
+					"Recommendation 2",
+				},
+				References: []string{
+					"https://example.com/ref",
+				},
+				Resources: []vreport.ResourcesGroup{
+					{
+						Name:   "Packages",
+						Header: []string{"Name", "Version"},
+						Rows: []map[string]string{
+							{"Name": "pkg1", "Version": "1.0"},
+							{"Name": "pkg2", "Version": "2.0"},
+						},
+					},
+				},
+			},
+			CheckData: vreport.CheckData{
+				Target: "example.com",
+			},
+			Severity: config.SeverityHigh,
+		},
+	}
+	summ := summary{
+		count: map[config.Severity]int{
+			config.SeverityHigh: 1,
+		},
+		excluded: 2,
+	}
+	status := []checkStatus{
+		{
+			Checktype: "vulcan-trivy",
+			Target:    "example.com",
+			Status:    "FINISHED",
+		},
+	}
+	staleExcls := []staleExclusion{
+		{
+			Exclusion: config.Exclusion{
+				Summary:     "Stale Summary",
+				Description: "Stale description",
+			},
+			LastMatched: ptr(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	warns := []warnings.Warning{
+		{
+			Message: "disk almost full",
+			Count:   2,
+		},
+	}
+
+	want := `Status
+
+Check vulcan-trivy on target example.com: FINISHED
+
+Summary
+
+Critical: 0
+High: 1
+Medium: 0
+Low: 0
+Info: 0
+Excluded: 2
+
+Vulnerabilities
+
+Vulnerability: Vulnerability Summary
+Severity: high
+Target: example.com
+Affected resource: Affected Resource
+Description: Vulnerability description.
+Recommendation: Recommendation 1
+Recommendation: Recommendation 2
+Reference: https://example.com/ref
+Resource Packages: Name: pkg1, Version: 1.0
+Resource Packages: Name: pkg2, Version: 2.0
+
+Stale exclusions
+
+Description: Stale description
+Summary: Stale Summary
+Last matched: 2024-03-01
+
+Warnings
+
+Warning: disk almost full, repeated 2 times
+`
+
+	var buf bytes.Buffer
+	if err := (plainPrinter{}).Print(&buf, vulns, summ, status, staleExcls, warns, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%v", diff)
+	}
+
+	if bytes.ContainsRune(buf.Bytes(), '\x1b') {
+		t.Errorf("output contains escape sequences")
+	}
+}
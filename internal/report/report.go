@@ -6,19 +6,30 @@ package report
 
 import (
 	"cmp"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"regexp"
+	"runtime"
 	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	report "github.com/adevinta/vulcan-report"
 
+	"github.com/adevinta/lava/internal/checktypes"
 	"github.com/adevinta/lava/internal/config"
 	"github.com/adevinta/lava/internal/engine"
+	"github.com/adevinta/lava/internal/history"
 	"github.com/adevinta/lava/internal/metrics"
+	"github.com/adevinta/lava/internal/urlutil"
+	"github.com/adevinta/lava/internal/warnings"
 )
 
 // Writer represents a Lava report writer.
@@ -27,37 +38,152 @@ type Writer struct {
 	w                      io.WriteCloser
 	isStdout               bool
 	minSeverity            config.Severity
+	targetSeverities       map[string]config.Severity
 	showSeverity           config.Severity
-	exclusions             []config.Exclusion
+	exclusions             []exclusion
 	errorOnStaleExclusions bool
+	expiryCfg              config.ExclusionExpiryConfig
+	errorOnInconclusive    bool
+	history                string
+	gradeCfg               config.GradeConfig
+	slaCfg                 config.SLAConfig
+	uploadOpts             urlutil.UploadOptions
+
+	// advisory contains the names of the checktypes whose
+	// results are advisory.
+	advisory map[string]bool
+
+	// severityRules contains the severity rules indexed by
+	// checktype name.
+	severityRules map[string]config.SeverityRule
+
+	// metadata is the metadata of the scan. It is nil if the
+	// report does not include metadata.
+	metadata *Metadata
+
+	// maxFindings is the maximum number of vulnerabilities
+	// rendered in the report. Zero means no limit.
+	maxFindings int
+
+	// maxDetailSize is the maximum size in bytes of the long
+	// texts of the vulnerabilities rendered in the report. Zero
+	// means no limit.
+	maxDetailSize int
+
+	// fullOutput is the path of the file where the report is
+	// written in JSON format without applying its limits. Empty
+	// means that the full report is not written.
+	fullOutput string
+
+	// encryption is the configuration used to encrypt the output
+	// files.
+	encryption config.EncryptionConfig
+
+	// controls contains the control families covered by every
+	// checktype indexed by checktype name. If empty, the control
+	// coverage is not reported.
+	controls checktypes.ChecktypeControls
+
+	// sanitize specifies whether the ANSI escape sequences and
+	// control characters of the text fields of the
+	// vulnerabilities are removed.
+	sanitize bool
 }
 
 // timeNow is set by tests to mock the current time.
 var timeNow = time.Now
 
-// NewWriter creates a new instance of a report writer.
-func NewWriter(cfg config.ReportConfig) (Writer, error) {
+// NewWriter creates a new instance of a report writer. The
+// exclusions and severity thresholds declared under the provided
+// targets only apply to the findings of each target.
+func NewWriter(cfg config.ReportConfig, targets []config.Target) (Writer, error) {
 	var prn printer
 	switch config.Get(cfg.Format) {
 	case config.OutputFormatHuman:
-		prn = humanPrinter{}
+		prn = humanPrinter{
+			theme: config.Get(cfg.Theme),
+			width: outputWidth(cfg),
+		}
 	case config.OutputFormatJSON:
 		prn = jsonPrinter{}
+	case config.OutputFormatTemplate:
+		tp, err := newTemplatePrinter(config.Get(cfg.Template))
+		if err != nil {
+			return Writer{}, fmt.Errorf("template printer: %w", err)
+		}
+		prn = tp
+	case config.OutputFormatCSV:
+		prn = csvPrinter{columns: cfg.Columns}
+	case config.OutputFormatPlain:
+		prn = plainPrinter{}
 	default:
 		return Writer{}, errors.New("unsupported output format")
 	}
 
-	w := os.Stdout
+	var w io.WriteCloser = os.Stdout
 	isStdout := true
 	if outputFile := config.Get(cfg.OutputFile); outputFile != "" {
-		f, err := os.Create(outputFile)
+		f, err := createOutput(outputFile, UploadOptions(cfg.Upload), cfg.Encryption)
 		if err != nil {
-			return Writer{}, fmt.Errorf("create file: %w", err)
+			return Writer{}, err
 		}
 		w = f
 		isStdout = false
 	}
 
+	keys := make([]ed25519.PublicKey, len(cfg.ExclusionKeys))
+	for i, s := range cfg.ExclusionKeys {
+		key, err := config.ParseExclusionKey(s)
+		if err != nil {
+			return Writer{}, fmt.Errorf("exclusion key %v: %w", i, err)
+		}
+		keys[i] = key
+	}
+
+	var excls []exclusion
+	for i, excl := range cfg.Exclusions {
+		if len(keys) > 0 && !excl.VerifySignature(keys) {
+			slog.Warn("ignoring exclusion without a valid signature", "exclusion", i)
+			continue
+		}
+		e, err := compileExclusion(excl)
+		if err != nil {
+			return Writer{}, fmt.Errorf("exclusion %v: %w", i, err)
+		}
+		excls = append(excls, e)
+	}
+
+	targetSeverities := make(map[string]config.Severity)
+	for _, t := range targets {
+		scoped := t.ScopedExclusions()
+		for i, excl := range scoped {
+			// Target exclusions are signed once scoped, so
+			// a signature is only valid for its target.
+			if len(keys) > 0 && !excl.VerifySignature(keys) {
+				slog.Warn("ignoring exclusion without a valid signature", "target", t.String(), "exclusion", i)
+				continue
+			}
+			e, err := compileExclusion(excl)
+			if err != nil {
+				return Writer{}, fmt.Errorf("target %v: exclusion %v: %w", t, i, err)
+			}
+			excls = append(excls, e)
+		}
+		if t.Severity != nil {
+			targetSeverities[t.Identifier] = *t.Severity
+		}
+	}
+
+	advisory := make(map[string]bool)
+	for _, ct := range cfg.Advisory {
+		advisory[ct] = true
+	}
+
+	severityRules := make(map[string]config.SeverityRule)
+	for _, rule := range cfg.SeverityRules {
+		severityRules[rule.Checktype] = rule
+	}
+
 	var showSeverity config.Severity
 	if cfg.ShowSeverity != nil {
 		showSeverity = *cfg.ShowSeverity
@@ -70,9 +196,23 @@ func NewWriter(cfg config.ReportConfig) (Writer, error) {
 		w:                      w,
 		isStdout:               isStdout,
 		minSeverity:            config.Get(cfg.Severity),
+		targetSeverities:       targetSeverities,
 		showSeverity:           showSeverity,
-		exclusions:             cfg.Exclusions,
+		exclusions:             excls,
 		errorOnStaleExclusions: config.Get(cfg.ErrorOnStaleExclusions),
+		expiryCfg:              cfg.ExclusionExpiry,
+		errorOnInconclusive:    cfg.ErrorOnInconclusive == nil || *cfg.ErrorOnInconclusive,
+		history:                config.Get(cfg.History),
+		gradeCfg:               cfg.Grade,
+		slaCfg:                 cfg.SLA,
+		uploadOpts:             UploadOptions(cfg.Upload),
+		advisory:               advisory,
+		severityRules:          severityRules,
+		maxFindings:            config.Get(cfg.MaxFindings),
+		maxDetailSize:          config.Get(cfg.MaxDetailSize),
+		fullOutput:             config.Get(cfg.FullOutput),
+		encryption:             cfg.Encryption,
+		sanitize:               cfg.Sanitize == nil || *cfg.Sanitize,
 	}, nil
 }
 
@@ -81,9 +221,12 @@ func NewWriter(cfg config.ReportConfig) (Writer, error) {
 // passed to [NewWriter]. If the returned error is not nil, the exit code
 // will be zero and should be ignored.
 func (writer Writer) Write(er engine.Report) (ExitCode, error) {
-	vulns, err := writer.parseReport(er)
-	if err != nil {
-		return 0, fmt.Errorf("parse report: %w", err)
+	vulns := writer.parseReport(er)
+
+	if len(writer.slaCfg.Deadlines) > 0 {
+		if err := writer.trackSLA(vulns); err != nil {
+			return 0, fmt.Errorf("track SLA: %w", err)
+		}
 	}
 
 	summ, err := mkSummary(vulns)
@@ -93,22 +236,182 @@ func (writer Writer) Write(er engine.Report) (ExitCode, error) {
 
 	metrics.Collect("excluded_vulnerability_count", summ.excluded)
 	metrics.Collect("vulnerability_count", summ.count)
+	if len(writer.advisory) > 0 {
+		metrics.Collect("advisory_vulnerability_count", summ.advisory)
+	}
+	if len(writer.slaCfg.Deadlines) > 0 {
+		metrics.Collect("overdue_vulnerability_count", summ.overdue)
+	}
+
+	if config.Get(writer.gradeCfg.Enabled) {
+		g := mkGrade(writer.gradeCfg, vulns)
+		summ.grade = &g
+		metrics.Collect("grade", g)
+
+		if badgeFile := config.Get(writer.gradeCfg.Badge); badgeFile != "" {
+			if err := g.writeBadge(badgeFile, writer.uploadOpts); err != nil {
+				return 0, fmt.Errorf("write badge: %w", err)
+			}
+		}
+	}
+
+	if len(writer.controls) > 0 {
+		summ.coverage = mkCoverage(er, writer.controls)
+		metrics.Collect("control_coverage", coverageMetric(summ.coverage))
+	}
 
 	staleExcls := writer.getStaleExclusions(vulns)
+	if writer.history != "" && len(staleExcls) > 0 {
+		if err := writer.setLastMatched(staleExcls); err != nil {
+			return 0, fmt.Errorf("stale exclusions: %w", err)
+		}
+	}
+
+	expiringExcls := writer.getExpiringExclusions()
+	for _, excl := range expiringExcls {
+		slog.Warn("exclusion about to expire", exclusionAttrs(excl)...)
+	}
 
 	fvulns := writer.filterVulns(vulns)
 	status := mkStatus(er)
-	exitCode := writer.calculateExitCode(summ, status, staleExcls)
+	for i, cs := range status {
+		status[i].Advisory = writer.advisory[cs.Checktype]
+	}
+	exitCode := writer.calculateExitCode(writer.exitSummary(vulns), status, staleExcls, expiringExcls)
 
-	if err = writer.prn.Print(writer.w, fvulns, summ, status, staleExcls); err != nil {
+	if writer.fullOutput != "" {
+		if err := writer.writeFullReport(fvulns); err != nil {
+			return exitCode, fmt.Errorf("write full report: %w", err)
+		}
+	}
+
+	fvulns, summ.omitted, summ.truncated = writer.truncate(fvulns)
+	if summ.omitted > 0 || summ.truncated > 0 {
+		slog.Warn("report truncated", "omitted", summ.omitted, "truncated", summ.truncated)
+		metrics.Collect("omitted_vulnerability_count", summ.omitted)
+		metrics.Collect("truncated_text_count", summ.truncated)
+	}
+
+	warns := warnings.Warnings()
+	metrics.Collect("warnings", warns)
+
+	if err = writer.prn.Print(writer.w, fvulns, summ, status, staleExcls, warns, writer.metadata); err != nil {
 		return exitCode, fmt.Errorf("print report: %w", err)
 	}
 
+	if writer.history != "" {
+		if err := writer.appendHistory(vulns, summ, status); err != nil {
+			return exitCode, fmt.Errorf("append history: %w", err)
+		}
+	}
+
 	return exitCode, nil
 }
 
+// appendHistory records the results of the scan in the history
+// database. Excluded vulnerabilities are not recorded, but the keys
+// of the exclusions that matched them are.
+func (writer Writer) appendHistory(vulns []vulnerability, summ summary, status []checkStatus) error {
+	entry := history.Entry{
+		Time:    timeNow(),
+		Summary: make(map[string]int),
+	}
+
+	for _, cs := range status {
+		if !slices.Contains(entry.Targets, cs.Target) {
+			entry.Targets = append(entry.Targets, cs.Target)
+		}
+	}
+
+	for sev, n := range summ.count {
+		entry.Summary[sev.String()] = n
+	}
+
+	matched := make(map[int]bool)
+	for _, v := range vulns {
+		for _, idx := range v.matchedExclusions {
+			matched[idx] = true
+		}
+		if v.isExcluded() {
+			continue
+		}
+		entry.Findings = append(entry.Findings, history.Finding{
+			Target:      v.CheckData.Target,
+			Checktype:   v.CheckData.ChecktypeName,
+			Fingerprint: v.Fingerprint,
+			Summary:     v.Summary,
+			Severity:    v.Severity.String(),
+		})
+	}
+
+	for i, excl := range writer.exclusions {
+		if matched[i] {
+			entry.Exclusions = append(entry.Exclusions, excl.key())
+		}
+	}
+
+	return history.Open(writer.history).Append(entry)
+}
+
+// trackSLA sets the SLA status of the non-excluded vulnerabilities
+// whose severity has a deadline. The time when a vulnerability was
+// first detected is got from the history database. Vulnerabilities
+// not present in the history database are considered detected now.
+func (writer Writer) trackSLA(vulns []vulnerability) error {
+	var firstSeen map[string]map[string]time.Time
+	if writer.history != "" {
+		entries, err := history.Open(writer.history).Entries()
+		if err != nil {
+			return fmt.Errorf("read history: %w", err)
+		}
+		firstSeen = history.FirstSeen(entries)
+	}
+
+	now := timeNow()
+	for i := range vulns {
+		v := &vulns[i]
+		if v.isExcluded() {
+			continue
+		}
+		deadline, ok := writer.slaCfg.Deadlines[v.Severity]
+		if !ok {
+			continue
+		}
+
+		f := history.Finding{
+			Checktype:   v.CheckData.ChecktypeName,
+			Fingerprint: v.Fingerprint,
+			Summary:     v.Summary,
+		}
+		seen, ok := firstSeen[v.CheckData.Target][f.Key()]
+		if !ok {
+			seen = now
+		}
+		due := seen.Add(time.Duration(deadline))
+		v.SLA = &slaStatus{
+			FirstSeen: seen,
+			Due:       due,
+			Overdue:   now.After(due),
+		}
+	}
+	return nil
+}
+
+// staleExclusion is an exclusion that did not match any
+// vulnerability.
+type staleExclusion struct {
+	config.Exclusion
+
+	// LastMatched is the time of the last scan recorded in the
+	// history database where the exclusion matched a finding. It
+	// is the zero time if the exclusion did not match any finding
+	// of the recorded scans. It is nil if the history database is
+	// not configured.
+	LastMatched *time.Time
+}
+
 // getStaleExclusions returns the list of stale exclusions.
-func (writer Writer) getStaleExclusions(vulns []vulnerability) []config.Exclusion {
+func (writer Writer) getStaleExclusions(vulns []vulnerability) []staleExclusion {
 	m := make(map[int]struct{})
 	for _, vuln := range vulns {
 		for _, idx := range vuln.matchedExclusions {
@@ -116,15 +419,78 @@ func (writer Writer) getStaleExclusions(vulns []vulnerability) []config.Exclusio
 		}
 	}
 
-	var staleExcls []config.Exclusion
+	var staleExcls []staleExclusion
 	for i, excl := range writer.exclusions {
 		if _, ok := m[i]; !ok {
-			staleExcls = append(staleExcls, excl)
+			staleExcls = append(staleExcls, staleExclusion{Exclusion: excl.Exclusion})
 		}
 	}
 	return staleExcls
 }
 
+// setLastMatched sets the time when the provided stale exclusions
+// last matched a finding. It is got from the history database.
+func (writer Writer) setLastMatched(staleExcls []staleExclusion) error {
+	entries, err := history.Open(writer.history).Entries()
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+	lastMatched := history.LastMatched(entries)
+
+	for i := range staleExcls {
+		key := exclusion{Exclusion: staleExcls[i].Exclusion}.key()
+		t := lastMatched[key]
+		staleExcls[i].LastMatched = &t
+	}
+	return nil
+}
+
+// getExpiringExclusions returns the list of exclusions that expire
+// within the number of days configured in
+// [config.ExclusionExpiryConfig.WarnDays]. The exclusions that
+// already expired are not returned.
+func (writer Writer) getExpiringExclusions() []config.Exclusion {
+	days := config.Get(writer.expiryCfg.WarnDays)
+	if days <= 0 {
+		return nil
+	}
+
+	now := timeNow()
+	limit := now.AddDate(0, 0, days)
+
+	var expiring []config.Exclusion
+	for _, excl := range writer.exclusions {
+		ed := excl.ExpirationDate
+		if ed.IsZero() || ed.Before(now) || ed.After(limit) {
+			continue
+		}
+		expiring = append(expiring, excl.Exclusion)
+	}
+	return expiring
+}
+
+// exclusionAttrs returns the attributes used to identify the
+// provided exclusion in the log messages. Empty fields are omitted.
+func exclusionAttrs(excl config.Exclusion) []any {
+	attrs := []any{"expiration", excl.ExpirationDate.String()}
+	fields := []struct {
+		key   string
+		value string
+	}{
+		{"target", excl.Target},
+		{"resource", excl.Resource},
+		{"fingerprint", excl.Fingerprint},
+		{"summary", excl.Summary},
+		{"owner", excl.Owner},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			attrs = append(attrs, f.key, f.value)
+		}
+	}
+	return attrs
+}
+
 // Close closes the [Writer].
 func (writer Writer) Close() error {
 	if !writer.isStdout {
@@ -135,84 +501,225 @@ func (writer Writer) Close() error {
 	return nil
 }
 
+// parseWorkerMinVulns is the minimum number of vulnerabilities
+// processed by each parseReport worker. It prevents the overhead of
+// spawning goroutines for small reports.
+const parseWorkerMinVulns = 1024
+
 // parseReport converts the provided [engine.Report] into a list of
 // vulnerabilities. It calculates the severity of each vulnerability
 // based on its score and determines if the vulnerability is excluded
-// according to the [Writer] configuration.
-func (writer Writer) parseReport(er engine.Report) ([]vulnerability, error) {
-	var vulns []vulnerability
+// according to the [Writer] configuration. Vulnerabilities are
+// processed concurrently. The returned list follows the order of
+// [engine.Report.CheckIDs], preserving the order in which the
+// vulnerabilities were reported by each check.
+func (writer Writer) parseReport(er engine.Report) []vulnerability {
+	checkIDs := er.CheckIDs()
+	n := 0
 	for _, r := range er {
-		for _, vuln := range r.ResultData.Vulnerabilities {
-			severity := scoreToSeverity(vuln.Score)
-			excls, err := writer.matchExclusions(vuln, r.Target)
-			if err != nil {
-				return nil, fmt.Errorf("vulnerability exlusion: %w", err)
+		n += countVulns(r.ResultData.Vulnerabilities)
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	vulns := make([]vulnerability, 0, n)
+	for _, checkID := range checkIDs {
+		r := er[checkID]
+		key := engine.CheckKey(r.CheckData)
+		advisory := writer.advisory[r.ChecktypeName]
+		for _, fv := range flattenVulns(r.ResultData.Vulnerabilities, "") {
+			vulns = append(vulns, vulnerability{
+				CheckData:     r.CheckData,
+				CheckKey:      key,
+				Advisory:      advisory,
+				Parent:        fv.parent,
+				Vulnerability: fv.vuln,
+			})
+		}
+	}
+
+	workers := min(runtime.GOMAXPROCS(0), (n+parseWorkerMinVulns-1)/parseWorkerMinVulns)
+	size := (n + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += size {
+		chunk := vulns[start:min(start+size, n)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range chunk {
+				v := &chunk[i]
+				if writer.sanitize {
+					v.Vulnerability = sanitizeVuln(v.Vulnerability)
+					v.Parent = sanitize(v.Parent)
+				}
+				v.Slug = mkSlug(*v)
+				v.CWE = lookupCWE(v.CWEID)
+				v.Severity = writer.severity(v)
+				v.matchedExclusions = writer.matchExclusions(v.Vulnerability, v.CheckData.Target)
 			}
-			v := vulnerability{
-				CheckData:         r.CheckData,
-				Vulnerability:     vuln,
-				Severity:          severity,
-				matchedExclusions: excls,
+		}()
+	}
+	wg.Wait()
+
+	return vulns
+}
+
+// flatVuln is a vulnerability that is not an aggregate of other
+// vulnerabilities.
+type flatVuln struct {
+	vuln   report.Vulnerability
+	parent string
+}
+
+// flattenVulns replaces the aggregate vulnerabilities of the provided
+// list, that is, the vulnerabilities with child vulnerabilities, by
+// their children recursively. Every child keeps the summaries of its
+// ancestors separated by " > " as parent and, if it does not specify
+// an affected resource, inherits the affected resource of its
+// parent.
+func flattenVulns(vulns []report.Vulnerability, parent string) []flatVuln {
+	var flat []flatVuln
+	for _, v := range vulns {
+		if len(v.Vulnerabilities) == 0 {
+			flat = append(flat, flatVuln{vuln: v, parent: parent})
+			continue
+		}
+
+		children := make([]report.Vulnerability, len(v.Vulnerabilities))
+		for i, child := range v.Vulnerabilities {
+			if child.AffectedResource == "" && child.AffectedResourceString == "" {
+				child.AffectedResource = v.AffectedResource
+				child.AffectedResourceString = v.AffectedResourceString
 			}
-			vulns = append(vulns, v)
+			children[i] = child
+		}
+
+		summary := v.Summary
+		if parent != "" {
+			summary = parent + " > " + summary
+		}
+		flat = append(flat, flattenVulns(children, summary)...)
+	}
+	return flat
+}
+
+// countVulns returns the number of vulnerabilities of the provided
+// list once the aggregate vulnerabilities are replaced by their
+// children. See [flattenVulns].
+func countVulns(vulns []report.Vulnerability) int {
+	n := 0
+	for _, v := range vulns {
+		if len(v.Vulnerabilities) == 0 {
+			n++
+			continue
 		}
+		n += countVulns(v.Vulnerabilities)
 	}
-	return vulns, nil
+	return n
+}
+
+// severity returns the severity of the provided vulnerability. It is
+// calculated from the score of the vulnerability and adjusted by the
+// severity rule of its checktype, if any.
+func (writer Writer) severity(v *vulnerability) config.Severity {
+	sev := scoreToSeverity(v.Score)
+	if rule, ok := writer.severityRules[v.CheckData.ChecktypeName]; ok {
+		sev = rule.Apply(sev)
+	}
+	return sev
 }
 
 // matchExclusions is responsible for determining if a given [report.Vulnerability]
 // should be excluded based on predefined exclusion criteria. The method
 // compares the [report.Vulnerability] against a list of exclusions stored
 // in the [Writer] and returns a slice of integers representing the indices of
-// the exclusions that match the vulnerability. If any errors occur during the
-// matching process, an error is returned.
-func (writer Writer) matchExclusions(v report.Vulnerability, target string) (excls []int, err error) {
+// the exclusions that match the vulnerability.
+func (writer Writer) matchExclusions(v report.Vulnerability, target string) []int {
 	var exclusions []int
 	for i, excl := range writer.exclusions {
-		if !excl.ExpirationDate.IsZero() && excl.ExpirationDate.Before(timeNow()) {
-			continue
+		if excl.match(v, target) {
+			exclusions = append(exclusions, i)
 		}
+	}
+	return exclusions
+}
+
+// exclusion is a [config.Exclusion] with its regular expressions
+// already compiled.
+type exclusion struct {
+	config.Exclusion
+	target   *regexp.Regexp
+	resource *regexp.Regexp
+	summary  *regexp.Regexp
+}
 
-		if excl.Fingerprint != "" && v.Fingerprint != excl.Fingerprint {
+// compileExclusion compiles the regular expressions of the provided
+// [config.Exclusion]. Empty expressions are left uncompiled, so they
+// match any value.
+func compileExclusion(excl config.Exclusion) (exclusion, error) {
+	e := exclusion{Exclusion: excl}
+
+	fields := []struct {
+		name string
+		expr string
+		re   **regexp.Regexp
+	}{
+		{"target", excl.Target, &e.target},
+		{"resource", excl.Resource, &e.resource},
+		{"summary", excl.Summary, &e.summary},
+	}
+	for _, f := range fields {
+		if f.expr == "" {
 			continue
 		}
-
-		if excl.Summary != "" {
-			matched, err := regexp.MatchString(excl.Summary, v.Summary)
-			if err != nil {
-				return nil, fmt.Errorf("match string: %w", err)
-			}
-			if !matched {
-				continue
-			}
+		re, err := regexp.Compile(f.expr)
+		if err != nil {
+			return exclusion{}, fmt.Errorf("%w: %v: %w", config.ErrInvalidExclusion, f.name, err)
 		}
+		*f.re = re
+	}
+	return e, nil
+}
 
-		if excl.Target != "" {
-			matched, err := regexp.MatchString(excl.Target, target)
-			if err != nil {
-				return nil, fmt.Errorf("match string: %w", err)
-			}
-			if !matched {
-				continue
-			}
-		}
+// key returns the key used to identify the exclusion across scans.
+// It only depends on the fields used to match vulnerabilities, so
+// updating the description, the owner or the expiration date of an
+// exclusion does not change its key.
+func (excl exclusion) key() string {
+	fields := []string{excl.Target, excl.Resource, excl.Fingerprint, excl.Summary}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
 
-		if excl.Resource != "" {
-			matchedResource, err := regexp.MatchString(excl.Resource, v.AffectedResource)
-			if err != nil {
-				return nil, fmt.Errorf("match string: %w", err)
-			}
-			matchedResourceString, err := regexp.MatchString(excl.Resource, v.AffectedResourceString)
-			if err != nil {
-				return nil, fmt.Errorf("match string: %w", err)
-			}
-			if !matchedResource && !matchedResourceString {
-				continue
-			}
-		}
-		exclusions = append(exclusions, i)
+// match reports whether the provided vulnerability, found in the
+// specified target, matches the exclusion.
+func (excl exclusion) match(v report.Vulnerability, target string) bool {
+	if !excl.ExpirationDate.IsZero() && excl.ExpirationDate.Before(timeNow()) {
+		return false
+	}
+
+	if excl.Fingerprint != "" && v.Fingerprint != excl.Fingerprint {
+		return false
 	}
-	return exclusions, nil
+
+	if excl.summary != nil && !excl.summary.MatchString(v.Summary) {
+		return false
+	}
+
+	if excl.target != nil && !excl.target.MatchString(target) {
+		return false
+	}
+
+	if excl.resource != nil &&
+		!excl.resource.MatchString(v.AffectedResource) &&
+		!excl.resource.MatchString(v.AffectedResourceString) {
+		return false
+	}
+
+	return true
 }
 
 // filterVulns takes a list of vulnerabilities and filters out those
@@ -222,7 +729,7 @@ func (writer Writer) filterVulns(vulns []vulnerability) []vulnerability {
 	// Sort the results by severity in reverse order.
 	vs := make([]vulnerability, len(vulns))
 	copy(vs, vulns)
-	slices.SortFunc(vs, func(a, b vulnerability) int {
+	slices.SortStableFunc(vs, func(a, b vulnerability) int {
 		return cmp.Compare(b.Severity, a.Severity)
 	})
 
@@ -239,13 +746,51 @@ func (writer Writer) filterVulns(vulns []vulnerability) []vulnerability {
 	return fvulns
 }
 
+// exitSummary returns the summary used to calculate the exit code.
+// Excluded vulnerabilities, advisory vulnerabilities and
+// vulnerabilities with a severity lower than the threshold of their
+// target are not counted. If a target has no severity threshold, the
+// min severity configured in the writer is used.
+func (writer Writer) exitSummary(vulns []vulnerability) summary {
+	summ := summary{
+		count:   make(map[config.Severity]int),
+		overdue: make(map[config.Severity]int),
+	}
+	for _, v := range vulns {
+		if v.isExcluded() || v.Advisory {
+			continue
+		}
+		if v.isOverdue() {
+			summ.overdue[v.Severity]++
+		}
+		threshold, ok := writer.targetSeverities[v.CheckData.Target]
+		if !ok {
+			threshold = writer.minSeverity
+		}
+		if v.Severity < threshold {
+			continue
+		}
+		summ.count[v.Severity]++
+	}
+	return summ
+}
+
 // calculateExitCode returns an error code depending on the vulnerabilities found,
 // as long as the severity of the vulnerabilities is higher or equal than the
-// min severity configured in the writer. For that it makes use of the summary.
+// min severity configured in the writer or the severity threshold of
+// their target. For that it makes use of the summary.
+//
+// The status of the advisory checks is ignored.
 //
 // See [ExitCode] for more information about exit codes.
-func (writer Writer) calculateExitCode(summ summary, status []checkStatus, staleExcl []config.Exclusion) ExitCode {
+func (writer Writer) calculateExitCode(summ summary, status []checkStatus, staleExcl []staleExclusion, expiringExcl []config.Exclusion) ExitCode {
 	for _, cs := range status {
+		if cs.Advisory {
+			continue
+		}
+		if cs.Status == engine.StatusInconclusive && !writer.errorOnInconclusive {
+			continue
+		}
 		if cs.Status != "FINISHED" {
 			return ExitCodeCheckError
 		}
@@ -255,7 +800,27 @@ func (writer Writer) calculateExitCode(summ summary, status []checkStatus, stale
 		return ExitCodeStaleExclusions
 	}
 
-	for sev := config.SeverityCritical; sev >= writer.minSeverity; sev-- {
+	if writer.slaCfg.ErrorOnBreach != nil {
+		for sev := config.SeverityCritical; sev >= *writer.slaCfg.ErrorOnBreach; sev-- {
+			if summ.overdue[sev] > 0 {
+				return ExitCodeSLABreach
+			}
+		}
+	}
+
+	if config.Get(writer.expiryCfg.ErrorOnExpiring) && len(expiringExcl) > 0 {
+		return ExitCodeExpiringExclusions
+	}
+
+	// The summary passed by [Writer.Write] only counts the
+	// vulnerabilities above the threshold of their target, which
+	// can be lower than the min severity of the writer.
+	minSeverity := writer.minSeverity
+	for _, sev := range writer.targetSeverities {
+		minSeverity = min(minSeverity, sev)
+	}
+
+	for sev := config.SeverityCritical; sev >= minSeverity; sev-- {
 		if summ.count[sev] > 0 {
 			diff := sev - config.SeverityInfo
 			return ExitCodeInfo + ExitCode(diff)
@@ -268,19 +833,48 @@ func (writer Writer) calculateExitCode(summ summary, status []checkStatus, stale
 type vulnerability struct {
 	report.Vulnerability
 	CheckData         report.CheckData `json:"check_data"`
+	CheckKey          string           `json:"check_key"`
+	Slug              string           `json:"slug"`
 	Severity          config.Severity  `json:"severity"`
+	Advisory          bool             `json:"advisory,omitempty"`
+	Parent            string           `json:"parent,omitempty"`
+	SLA               *slaStatus       `json:"sla,omitempty"`
+	CWE               *cwe             `json:"cwe,omitempty"`
 	matchedExclusions []int
 }
 
+// slaStatus is the status of a vulnerability regarding the deadline
+// configured for its severity.
+type slaStatus struct {
+	// FirstSeen is the time when the vulnerability was first
+	// detected.
+	FirstSeen time.Time `json:"first_seen"`
+
+	// Due is the time when the deadline to fix the vulnerability
+	// expires.
+	Due time.Time `json:"due"`
+
+	// Overdue reports whether the deadline has expired.
+	Overdue bool `json:"overdue"`
+}
+
 // isExclude reports whether the [vulnerability] should be excluded
 // from the report.
 func (vuln vulnerability) isExcluded() bool {
 	return len(vuln.matchedExclusions) > 0
 }
 
-// A printer renders a Vulcan report in a specific format.
+// isOverdue reports whether the deadline to fix the [vulnerability]
+// has expired.
+func (vuln vulnerability) isOverdue() bool {
+	return vuln.SLA != nil && vuln.SLA.Overdue
+}
+
+// A printer renders a Vulcan report in a specific format. Printers
+// stream the rendered report into the provided [io.Writer] instead of
+// building the whole document in memory.
 type printer interface {
-	Print(w io.Writer, vulns []vulnerability, summ summary, status []checkStatus, staleExcls []config.Exclusion) error
+	Print(w io.Writer, vulns []vulnerability, summ summary, status []checkStatus, staleExcls []staleExclusion, warns []warnings.Warning, md *Metadata) error
 }
 
 // scoreToSeverity converts a CVSS score into a [config.Severity].
@@ -306,12 +900,29 @@ func scoreToSeverity(score float32) config.Severity {
 // summary represents the statistics of the results.
 type summary struct {
 	count    map[config.Severity]int
+	overdue  map[config.Severity]int
+	advisory map[config.Severity]int
 	excluded int
+	grade    *grade
+
+	// omitted is the number of vulnerabilities that are not
+	// rendered because of the limits of the report.
+	omitted int
+
+	// truncated is the number of texts of the vulnerabilities
+	// that are truncated because of the limits of the report.
+	truncated int
+
+	// coverage is the coverage of the control families on every
+	// target. It is nil if the control coverage is not reported.
+	coverage []targetCoverage
 }
 
 // mkSummary counts the number vulnerabilities per severity and the
 // number of excluded vulnerabilities. The excluded vulnerabilities are
-// not considered in the count per severity.
+// not considered in the count per severity. The advisory
+// vulnerabilities are counted separately. It also counts the overdue
+// vulnerabilities per severity.
 func mkSummary(vulns []vulnerability) (summary, error) {
 	if len(vulns) == 0 {
 		return summary{}, nil
@@ -326,8 +937,21 @@ func mkSummary(vulns []vulnerability) (summary, error) {
 		}
 		if vuln.isExcluded() {
 			summ.excluded++
-		} else {
-			summ.count[vuln.Severity]++
+			continue
+		}
+		if vuln.Advisory {
+			if summ.advisory == nil {
+				summ.advisory = make(map[config.Severity]int)
+			}
+			summ.advisory[vuln.Severity]++
+			continue
+		}
+		summ.count[vuln.Severity]++
+		if vuln.isOverdue() {
+			if summ.overdue == nil {
+				summ.overdue = make(map[config.Severity]int)
+			}
+			summ.overdue[vuln.Severity]++
 		}
 	}
 	return summ, nil
@@ -336,36 +960,64 @@ func mkSummary(vulns []vulnerability) (summary, error) {
 // checkStatus represents the status of a check after the scan has
 // finished.
 type checkStatus struct {
+	// Key is the stable identifier of the check. See
+	// [engine.CheckKey].
+	Key string
+
 	Checktype string
 	Target    string
 	Status    string
+
+	// Reason is the reason of the status of the checks that did
+	// not finish successfully, if known.
+	Reason string
+
+	// Advisory reports whether the results of the check are
+	// advisory.
+	Advisory bool
 }
 
 // mkStatus returns the status of every check after the scan has
-// finished.
+// finished. The status follows the order of [engine.Report.CheckIDs].
 func mkStatus(er engine.Report) []checkStatus {
 	var status []checkStatus
-	for _, r := range er {
+	for _, checkID := range er.CheckIDs() {
+		r := er[checkID]
 		cs := checkStatus{
+			Key:       engine.CheckKey(r.CheckData),
 			Checktype: r.ChecktypeName,
 			Target:    r.Target,
 			Status:    r.Status,
 		}
+		if r.Status != "FINISHED" {
+			cs.Reason = r.Error
+		}
 		status = append(status, cs)
 	}
 	return status
 }
 
+// UploadOptions returns the [urlutil.UploadOptions] corresponding to
+// the provided [config.UploadConfig].
+func UploadOptions(cfg config.UploadConfig) urlutil.UploadOptions {
+	return urlutil.UploadOptions{
+		SSE:    config.Get(cfg.SSE),
+		KMSKey: config.Get(cfg.KMSKey),
+	}
+}
+
 // ExitCode represents an exit code depending on the vulnerabilities found.
 type ExitCode int
 
 // Exit codes depending on the maximum severity found.
 const (
-	ExitCodeCheckError      ExitCode = 3
-	ExitCodeStaleExclusions ExitCode = 4
-	ExitCodeInfo            ExitCode = 100
-	ExitCodeLow             ExitCode = 101
-	ExitCodeMedium          ExitCode = 102
-	ExitCodeHigh            ExitCode = 103
-	ExitCodeCritical        ExitCode = 104
+	ExitCodeCheckError         ExitCode = 3
+	ExitCodeStaleExclusions    ExitCode = 4
+	ExitCodeSLABreach          ExitCode = 5
+	ExitCodeExpiringExclusions ExitCode = 6
+	ExitCodeInfo               ExitCode = 100
+	ExitCodeLow                ExitCode = 101
+	ExitCodeMedium             ExitCode = 102
+	ExitCodeHigh               ExitCode = 103
+	ExitCodeCritical           ExitCode = 104
 )
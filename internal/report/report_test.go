@@ -3,29 +3,37 @@
 package report
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	vreport "github.com/adevinta/vulcan-report"
+	types "github.com/adevinta/vulcan-types"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 
 	"github.com/adevinta/lava/internal/config"
 	"github.com/adevinta/lava/internal/engine"
+	"github.com/adevinta/lava/internal/history"
 )
 
 func TestWriter_calculateExitCode(t *testing.T) {
 	tests := []struct {
-		name       string
-		summ       summary
-		status     []checkStatus
-		staleExcls []config.Exclusion
-		rConfig    config.ReportConfig
-		want       ExitCode
+		name          string
+		summ          summary
+		status        []checkStatus
+		staleExcls    []staleExclusion
+		expiringExcls []config.Exclusion
+		rConfig       config.ReportConfig
+		want          ExitCode
 	}{
 		{
 			name: "critical",
@@ -212,6 +220,31 @@ func TestWriter_calculateExitCode(t *testing.T) {
 			},
 			want: ExitCodeCheckError,
 		},
+		{
+			name: "inconclusive check (no error)",
+			summ: summary{
+				count: map[config.Severity]int{
+					config.SeverityCritical: 0,
+					config.SeverityHigh:     0,
+					config.SeverityMedium:   1,
+					config.SeverityLow:      1,
+					config.SeverityInfo:     1,
+				},
+			},
+			status: []checkStatus{
+				{
+					Checktype: "Checktype1",
+					Target:    "Target1",
+					Status:    "INCONCLUSIVE",
+					Reason:    "unreachable or non-public target",
+				},
+			},
+			rConfig: config.ReportConfig{
+				Severity:            ptr(config.SeverityHigh),
+				ErrorOnInconclusive: ptr(false),
+			},
+			want: 0,
+		},
 		{
 			name: "stale exclusions (warn)",
 			summ: summary{
@@ -230,9 +263,11 @@ func TestWriter_calculateExitCode(t *testing.T) {
 					Status:    "FINISHED",
 				},
 			},
-			staleExcls: []config.Exclusion{
+			staleExcls: []staleExclusion{
 				{
-					Summary: "Unused exclusion",
+					Exclusion: config.Exclusion{
+						Summary: "Unused exclusion",
+					},
 				},
 			},
 			rConfig: config.ReportConfig{
@@ -263,9 +298,11 @@ func TestWriter_calculateExitCode(t *testing.T) {
 					Status:    "FINISHED",
 				},
 			},
-			staleExcls: []config.Exclusion{
+			staleExcls: []staleExclusion{
 				{
-					Summary: "Unused exclusion",
+					Exclusion: config.Exclusion{
+						Summary: "Unused exclusion",
+					},
 				},
 			},
 			rConfig: config.ReportConfig{
@@ -279,14 +316,57 @@ func TestWriter_calculateExitCode(t *testing.T) {
 			},
 			want: ExitCodeStaleExclusions,
 		},
+		{
+			name: "expiring exclusions",
+			status: []checkStatus{
+				{
+					Checktype: "Checktype1",
+					Target:    "Target1",
+					Status:    "FINISHED",
+				},
+			},
+			expiringExcls: []config.Exclusion{
+				{
+					Summary: "Expiring exclusion",
+				},
+			},
+			rConfig: config.ReportConfig{
+				ExclusionExpiry: config.ExclusionExpiryConfig{
+					WarnDays: ptr(7),
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "expiring exclusions (error)",
+			status: []checkStatus{
+				{
+					Checktype: "Checktype1",
+					Target:    "Target1",
+					Status:    "FINISHED",
+				},
+			},
+			expiringExcls: []config.Exclusion{
+				{
+					Summary: "Expiring exclusion",
+				},
+			},
+			rConfig: config.ReportConfig{
+				ExclusionExpiry: config.ExclusionExpiryConfig{
+					WarnDays:        ptr(7),
+					ErrorOnExpiring: ptr(true),
+				},
+			},
+			want: ExitCodeExpiringExclusions,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			w, err := NewWriter(tt.rConfig)
+			w, err := NewWriter(tt.rConfig, nil)
 			if err != nil {
 				t.Fatalf("unable to create a report writer: %v", err)
 			}
-			got := w.calculateExitCode(tt.summ, tt.status, tt.staleExcls)
+			got := w.calculateExitCode(tt.summ, tt.status, tt.staleExcls, tt.expiringExcls)
 			if got != tt.want {
 				t.Errorf("unexpected exit code: got: %v, want: %v", got, tt.want)
 			}
@@ -338,11 +418,10 @@ func TestScoreToSeverity(t *testing.T) {
 
 func TestWriter_parseReport(t *testing.T) {
 	tests := []struct {
-		name       string
-		report     engine.Report
-		rConfig    config.ReportConfig
-		want       []vulnerability
-		wantNilErr bool
+		name    string
+		report  engine.Report
+		rConfig config.ReportConfig
+		want    []vulnerability
 	}{
 		{
 			name: "all vulnerabilities included",
@@ -399,7 +478,6 @@ func TestWriter_parseReport(t *testing.T) {
 					matchedExclusions: nil,
 				},
 			},
-			wantNilErr: true,
 		},
 		{
 			name: "some vulnerabilities excluded",
@@ -458,7 +536,6 @@ func TestWriter_parseReport(t *testing.T) {
 					matchedExclusions: []int{0},
 				},
 			},
-			wantNilErr: true,
 		},
 		{
 			name: "vulnerability excluded by all the exclusions",
@@ -496,22 +573,138 @@ func TestWriter_parseReport(t *testing.T) {
 					matchedExclusions: []int{0, 1},
 				},
 			},
-			wantNilErr: true,
+		},
+		{
+			name: "child vulnerabilities",
+			report: map[string]vreport.Report{
+				"CheckID1": {
+					CheckData: vreport.CheckData{
+						CheckID: "CheckID1",
+					},
+					ResultData: vreport.ResultData{
+						Vulnerabilities: []vreport.Vulnerability{
+							{
+								Summary: "Aggregate",
+								Vulnerabilities: []vreport.Vulnerability{
+									{
+										Summary: "Child 1",
+										Score:   9.0,
+									},
+									{
+										Summary: "Child 2",
+										Score:   6.7,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			rConfig: config.ReportConfig{
+				Exclusions: []config.Exclusion{
+					{Summary: "Child 2"},
+				},
+			},
+			want: []vulnerability{
+				{
+					CheckData: vreport.CheckData{
+						CheckID: "CheckID1",
+					},
+					Parent: "Aggregate",
+					Vulnerability: vreport.Vulnerability{
+						Summary: "Child 1",
+						Score:   9.0,
+					},
+					Severity: config.SeverityCritical,
+				},
+				{
+					CheckData: vreport.CheckData{
+						CheckID: "CheckID1",
+					},
+					Parent: "Aggregate",
+					Vulnerability: vreport.Vulnerability{
+						Summary: "Child 2",
+						Score:   6.7,
+					},
+					Severity:          config.SeverityMedium,
+					matchedExclusions: []int{0},
+				},
+			},
+		},
+		{
+			name: "severity rules",
+			report: map[string]vreport.Report{
+				"CheckID1": {
+					CheckData: vreport.CheckData{
+						CheckID:       "CheckID1",
+						ChecktypeName: "Checktype1",
+					},
+					ResultData: vreport.ResultData{
+						Vulnerabilities: []vreport.Vulnerability{
+							{
+								Summary: "Vulnerability Summary 1",
+								Score:   9.0,
+							},
+						},
+					},
+				},
+				"CheckID2": {
+					CheckData: vreport.CheckData{
+						CheckID:       "CheckID2",
+						ChecktypeName: "Checktype2",
+					},
+					ResultData: vreport.ResultData{
+						Vulnerabilities: []vreport.Vulnerability{
+							{
+								Summary: "Vulnerability Summary 2",
+								Score:   0,
+							},
+						},
+					},
+				},
+			},
+			rConfig: config.ReportConfig{
+				SeverityRules: []config.SeverityRule{
+					{Checktype: "Checktype1", Max: ptr(config.SeverityMedium)},
+					{Checktype: "Checktype2", Min: ptr(config.SeverityHigh)},
+				},
+			},
+			want: []vulnerability{
+				{
+					CheckData: vreport.CheckData{
+						CheckID:       "CheckID1",
+						ChecktypeName: "Checktype1",
+					},
+					Vulnerability: vreport.Vulnerability{
+						Summary: "Vulnerability Summary 1",
+						Score:   9.0,
+					},
+					Severity: config.SeverityMedium,
+				},
+				{
+					CheckData: vreport.CheckData{
+						CheckID:       "CheckID2",
+						ChecktypeName: "Checktype2",
+					},
+					Vulnerability: vreport.Vulnerability{
+						Summary: "Vulnerability Summary 2",
+					},
+					Severity: config.SeverityHigh,
+				},
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			w, err := NewWriter(tt.rConfig)
+			w, err := NewWriter(tt.rConfig, nil)
 			if err != nil {
 				t.Fatalf("unable to create a report writer: %v", err)
 			}
-			got, err := w.parseReport(tt.report)
-			if (err == nil) != tt.wantNilErr {
-				t.Errorf("unexpected error value: %v", err)
-			}
+			got := w.parseReport(tt.report)
 			diffOpts := []cmp.Option{
 				cmp.AllowUnexported(vulnerability{}),
 				cmpopts.SortSlices(vulnLess),
+				cmpopts.IgnoreFields(vulnerability{}, "CheckKey", "Slug"),
 			}
 			if diff := cmp.Diff(tt.want, got, diffOpts...); diff != "" {
 				t.Errorf("vulnerabilities mismatch (-want +got):\n%v", diff)
@@ -520,6 +713,60 @@ func TestWriter_parseReport(t *testing.T) {
 	}
 }
 
+func TestFlattenVulns(t *testing.T) {
+	vulns := []vreport.Vulnerability{
+		{
+			Summary: "Single",
+		},
+		{
+			Summary:          "Aggregate",
+			AffectedResource: "go.mod",
+			Vulnerabilities: []vreport.Vulnerability{
+				{
+					Summary: "Child 1",
+				},
+				{
+					Summary:          "Child 2",
+					AffectedResource: "go.sum",
+				},
+				{
+					Summary: "Nested aggregate",
+					Vulnerabilities: []vreport.Vulnerability{
+						{Summary: "Grandchild"},
+					},
+				},
+			},
+		},
+	}
+
+	want := []flatVuln{
+		{
+			vuln: vreport.Vulnerability{Summary: "Single"},
+		},
+		{
+			vuln:   vreport.Vulnerability{Summary: "Child 1", AffectedResource: "go.mod"},
+			parent: "Aggregate",
+		},
+		{
+			vuln:   vreport.Vulnerability{Summary: "Child 2", AffectedResource: "go.sum"},
+			parent: "Aggregate",
+		},
+		{
+			vuln:   vreport.Vulnerability{Summary: "Grandchild", AffectedResource: "go.mod"},
+			parent: "Aggregate > Nested aggregate",
+		},
+	}
+
+	got := flattenVulns(vulns, "")
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(flatVuln{})); diff != "" {
+		t.Errorf("vulnerabilities mismatch (-want +got):\n%v", diff)
+	}
+
+	if n := countVulns(vulns); n != len(want) {
+		t.Errorf("unexpected count: got: %v, want: %v", n, len(want))
+	}
+}
+
 func TestWriter_matchExclusions(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -527,7 +774,6 @@ func TestWriter_matchExclusions(t *testing.T) {
 		target        string
 		rConfig       config.ReportConfig
 		want          []int
-		wantNilErr    bool
 	}{
 		{
 			name: "empty exclusions",
@@ -539,8 +785,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 			rConfig: config.ReportConfig{
 				Exclusions: []config.Exclusion{},
 			},
-			want:       []int{},
-			wantNilErr: true,
+			want: []int{},
 		},
 		{
 			name: "exclude by summary",
@@ -557,8 +802,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "not exclude by summary",
@@ -575,8 +819,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{},
-			wantNilErr: true,
+			want: []int{},
 		},
 		{
 			name: "exclude by fingerprint",
@@ -593,8 +836,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "exclude by affected resource",
@@ -611,8 +853,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "exclude by affected resource string",
@@ -629,8 +870,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "exclude by target",
@@ -646,8 +886,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "match all exclusion criteria (resource)",
@@ -668,8 +907,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "match all exclusion criteria (resource string)",
@@ -690,8 +928,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "match all exclusion criteria (resource and resource string)",
@@ -713,8 +950,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "fail an exclusion criteria",
@@ -736,8 +972,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{},
-			wantNilErr: true,
+			want: []int{},
 		},
 		{
 			name: "active exclusion",
@@ -755,8 +990,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{0},
-			wantNilErr: true,
+			want: []int{0},
 		},
 		{
 			name: "expired exclusion",
@@ -774,8 +1008,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					},
 				},
 			},
-			want:       []int{},
-			wantNilErr: true,
+			want: []int{},
 		},
 		{
 			name: "match more than an exclusion",
@@ -791,8 +1024,7 @@ func TestWriter_matchExclusions(t *testing.T) {
 					{Resource: "Resource 1"},
 				},
 			},
-			want:       []int{0, 1},
-			wantNilErr: true,
+			want: []int{0, 1},
 		},
 	}
 	for _, tt := range tests {
@@ -803,14 +1035,11 @@ func TestWriter_matchExclusions(t *testing.T) {
 				tn, _ := time.Parse(time.RFC3339, "2024-01-02T15:04:05Z")
 				return tn
 			}
-			w, err := NewWriter(tt.rConfig)
+			w, err := NewWriter(tt.rConfig, nil)
 			if err != nil {
 				t.Fatalf("unable to create a report writer: %v", err)
 			}
-			got, err := w.matchExclusions(tt.vulnerability, tt.target)
-			if (err == nil) != tt.wantNilErr {
-				t.Errorf("unexpected error value: %v", err)
-			}
+			got := w.matchExclusions(tt.vulnerability, tt.target)
 			if !slices.Equal(tt.want, got) {
 				t.Errorf("unexpected excluded value: got: %v, want: %v", got, tt.want)
 			}
@@ -1019,6 +1248,44 @@ func TestMkStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "reasons",
+			er: engine.Report{
+				"CheckID1": vreport.Report{
+					CheckData: vreport.CheckData{
+						ChecktypeName: "Checktype1",
+						Target:        "Target1",
+						Status:        "INCONCLUSIVE",
+					},
+					ResultData: vreport.ResultData{
+						Error: "missing required vars: VAR1",
+					},
+				},
+				"CheckID2": vreport.Report{
+					CheckData: vreport.CheckData{
+						ChecktypeName: "Checktype2",
+						Target:        "Target2",
+						Status:        "FINISHED",
+					},
+					ResultData: vreport.ResultData{
+						Error: "ignored error",
+					},
+				},
+			},
+			want: []checkStatus{
+				{
+					Checktype: "Checktype1",
+					Target:    "Target1",
+					Status:    "INCONCLUSIVE",
+					Reason:    "missing required vars: VAR1",
+				},
+				{
+					Checktype: "Checktype2",
+					Target:    "Target2",
+					Status:    "FINISHED",
+				},
+			},
+		},
 		{
 			name: "empty",
 			er:   engine.Report{},
@@ -1028,13 +1295,37 @@ func TestMkStatus(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := mkStatus(tt.er)
-			if diff := cmp.Diff(tt.want, got, cmpopts.SortSlices(statusLess)); diff != "" {
+			diffOpts := []cmp.Option{
+				cmpopts.SortSlices(statusLess),
+				cmpopts.IgnoreFields(checkStatus{}, "Key"),
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts...); diff != "" {
 				t.Errorf("status mismatch (-want +got):\n%v", diff)
 			}
 		})
 	}
 }
 
+func TestMkStatus_Order(t *testing.T) {
+	er := engine.Report{
+		"c": {CheckData: vreport.CheckData{ChecktypeName: "Checktype2", Target: "Target1", Status: "FINISHED"}},
+		"a": {CheckData: vreport.CheckData{ChecktypeName: "Checktype1", Target: "Target2", Status: "FINISHED"}},
+		"b": {CheckData: vreport.CheckData{ChecktypeName: "Checktype1", Target: "Target1", Status: "FINISHED"}},
+	}
+
+	want := []checkStatus{
+		{Key: engine.CheckKey(er["b"].CheckData), Checktype: "Checktype1", Target: "Target1", Status: "FINISHED"},
+		{Key: engine.CheckKey(er["a"].CheckData), Checktype: "Checktype1", Target: "Target2", Status: "FINISHED"},
+		{Key: engine.CheckKey(er["c"].CheckData), Checktype: "Checktype2", Target: "Target1", Status: "FINISHED"},
+	}
+
+	for i := 0; i < 10; i++ {
+		if diff := cmp.Diff(want, mkStatus(er)); diff != "" {
+			t.Fatalf("status mismatch (-want +got):\n%v", diff)
+		}
+	}
+}
+
 func TestWriter_filterVulns(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -1418,7 +1709,7 @@ func TestWriter_filterVulns(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			w, err := NewWriter(tt.rConfig)
+			w, err := NewWriter(tt.rConfig, nil)
 			if err != nil {
 				t.Fatalf("unable to create a report writer: %v", err)
 			}
@@ -1590,7 +1881,7 @@ func TestNewWriter_OutputFile(t *testing.T) {
 			defer os.RemoveAll(tmpPath)
 
 			tt.rConfig.OutputFile = ptr(path.Join(tmpPath, config.Get(tt.rConfig.OutputFile)))
-			writer, err := NewWriter(tt.rConfig)
+			writer, err := NewWriter(tt.rConfig, nil)
 			if err != nil {
 				t.Fatalf("unable to create a report writer: %v", err)
 			}
@@ -1615,7 +1906,7 @@ func TestWriter_getStaleExclusions(t *testing.T) {
 		name       string
 		exclusions []config.Exclusion
 		vulns      []vulnerability
-		want       []config.Exclusion
+		want       []staleExclusion
 	}{
 		{
 			name: "without stale exclusions",
@@ -1626,7 +1917,7 @@ func TestWriter_getStaleExclusions(t *testing.T) {
 			vulns: []vulnerability{
 				{matchedExclusions: []int{0, 1}},
 			},
-			want: []config.Exclusion{},
+			want: []staleExclusion{},
 		},
 		{
 			name: "matched all exclusion in different vulnerabilities",
@@ -1638,7 +1929,7 @@ func TestWriter_getStaleExclusions(t *testing.T) {
 				{matchedExclusions: []int{0, 1}},
 				{matchedExclusions: []int{0, 1}},
 			},
-			want: []config.Exclusion{},
+			want: []staleExclusion{},
 		},
 		{
 			name: "one stale exclusions",
@@ -1650,15 +1941,16 @@ func TestWriter_getStaleExclusions(t *testing.T) {
 			vulns: []vulnerability{
 				{matchedExclusions: []int{0, 1}},
 			},
-			want: []config.Exclusion{
-				{Summary: "Stale Exclusion 1"},
+			want: []staleExclusion{
+				{Exclusion: config.Exclusion{Summary: "Stale Exclusion 1"}},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			writer := Writer{
-				exclusions: tt.exclusions,
+			writer, err := NewWriter(config.ReportConfig{Exclusions: tt.exclusions}, nil)
+			if err != nil {
+				t.Fatalf("unable to create a report writer: %v", err)
 			}
 			if got := writer.getStaleExclusions(tt.vulns); !slices.Equal(tt.want, got) {
 				t.Errorf("unexpected list of stale vulnerabilities: got: %v, want: %v", got, tt.want)
@@ -1691,3 +1983,728 @@ func mustParseExpDate(date string) config.ExpirationDate {
 	}
 	return config.ExpirationDate{Time: t}
 }
+
+func TestWriter_getExpiringExclusions(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	tn := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return tn }
+
+	date := func(year int, month time.Month, day int) config.ExpirationDate {
+		return config.ExpirationDate{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+	}
+
+	exclusions := []config.Exclusion{
+		{Summary: "No expiration"},
+		{Summary: "Expired", ExpirationDate: date(2024, 5, 31)},
+		{Summary: "Expires soon", ExpirationDate: date(2024, 6, 5)},
+		{Summary: "Expires later", ExpirationDate: date(2024, 7, 1)},
+	}
+
+	tests := []struct {
+		name     string
+		warnDays *int
+		want     []config.Exclusion
+	}{
+		{
+			name:     "disabled",
+			warnDays: nil,
+			want:     nil,
+		},
+		{
+			name:     "one week",
+			warnDays: ptr(7),
+			want: []config.Exclusion{
+				{Summary: "Expires soon", ExpirationDate: date(2024, 6, 5)},
+			},
+		},
+		{
+			name:     "two months",
+			warnDays: ptr(60),
+			want: []config.Exclusion{
+				{Summary: "Expires soon", ExpirationDate: date(2024, 6, 5)},
+				{Summary: "Expires later", ExpirationDate: date(2024, 7, 1)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rConfig := config.ReportConfig{
+				Exclusions: exclusions,
+				ExclusionExpiry: config.ExclusionExpiryConfig{
+					WarnDays: tt.warnDays,
+				},
+			}
+			writer, err := NewWriter(rConfig, nil)
+			if err != nil {
+				t.Fatalf("unable to create a report writer: %v", err)
+			}
+			got := writer.getExpiringExclusions()
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("expiring exclusions mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWriter_Write_History(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	tn := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	timeNow = func() time.Time { return tn }
+
+	tmpPath := t.TempDir()
+	historyPath := path.Join(tmpPath, "history.jsonl")
+
+	rConfig := config.ReportConfig{
+		OutputFile: ptr(path.Join(tmpPath, "output.txt")),
+		History:    ptr(historyPath),
+		Exclusions: []config.Exclusion{
+			{Summary: "Excluded"},
+		},
+	}
+
+	er := engine.Report{
+		"CheckID1": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "Checktype1",
+				Target:        "Target1",
+				Status:        "FINISHED",
+			},
+			ResultData: vreport.ResultData{
+				Vulnerabilities: []vreport.Vulnerability{
+					{
+						Summary:     "Vulnerability Summary 1",
+						Fingerprint: "fingerprint1",
+						Score:       9.0,
+					},
+					{
+						Summary:     "Excluded",
+						Fingerprint: "fingerprint2",
+						Score:       9.0,
+					},
+				},
+			},
+		},
+	}
+
+	writer, err := NewWriter(rConfig, nil)
+	if err != nil {
+		t.Fatalf("unable to create a report writer: %v", err)
+	}
+	defer writer.Close()
+
+	if _, err := writer.Write(er); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := history.Open(historyPath).Entries()
+	if err != nil {
+		t.Fatalf("could not read history: %v", err)
+	}
+
+	want := []history.Entry{
+		{
+			Time:    tn,
+			Targets: []string{"Target1"},
+			Summary: map[string]int{"critical": 1},
+			Findings: []history.Finding{
+				{
+					Target:      "Target1",
+					Checktype:   "Checktype1",
+					Fingerprint: "fingerprint1",
+					Summary:     "Vulnerability Summary 1",
+					Severity:    "critical",
+				},
+			},
+			Exclusions: []string{
+				exclusion{Exclusion: config.Exclusion{Summary: "Excluded"}}.key(),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("history mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestWriter_Write_stale_exclusions_last_matched(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	tn := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return tn }
+
+	tmpPath := t.TempDir()
+	historyPath := path.Join(tmpPath, "history.jsonl")
+	outputPath := path.Join(tmpPath, "output.txt")
+
+	excls := []config.Exclusion{
+		{Summary: "Matched recently", Description: "Updated description"},
+		{Summary: "Never matched"},
+	}
+
+	entry := history.Entry{
+		Time:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Targets: []string{"Target1"},
+		Exclusions: []string{
+			// The description is not part of the key.
+			exclusion{Exclusion: config.Exclusion{Summary: "Matched recently"}}.key(),
+		},
+	}
+	if err := history.Open(historyPath).Append(entry); err != nil {
+		t.Fatalf("could not write history: %v", err)
+	}
+
+	rConfig := config.ReportConfig{
+		Format:     ptr(config.OutputFormatPlain),
+		OutputFile: ptr(outputPath),
+		History:    ptr(historyPath),
+		Exclusions: excls,
+	}
+
+	er := engine.Report{
+		"CheckID1": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "Checktype1",
+				Target:        "Target1",
+				Status:        "FINISHED",
+			},
+		},
+	}
+
+	writer, err := NewWriter(rConfig, nil)
+	if err != nil {
+		t.Fatalf("unable to create a report writer: %v", err)
+	}
+
+	if _, err := writer.Write(er); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	b, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("could not read output: %v", err)
+	}
+
+	want := []string{
+		"Summary: Matched recently\nLast matched: 2024-03-01",
+		"Summary: Never matched\nLast matched: never",
+	}
+	for _, s := range want {
+		if !strings.Contains(string(b), s) {
+			t.Errorf("output does not contain %q:\n%s", s, b)
+		}
+	}
+}
+
+func TestWriter_Write_targets(t *testing.T) {
+	er := engine.Report{
+		"CheckID1": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "Checktype1",
+				Target:        "example.com",
+				Status:        "FINISHED",
+			},
+			ResultData: vreport.ResultData{
+				Vulnerabilities: []vreport.Vulnerability{
+					{
+						Summary: "Vulnerability Summary 1",
+						Score:   7.5,
+					},
+					{
+						Summary: "Vulnerability Summary 2",
+						Score:   1.0,
+					},
+				},
+			},
+		},
+		"CheckID2": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID2",
+				ChecktypeName: "Checktype1",
+				Target:        "example.com.es",
+				Status:        "FINISHED",
+			},
+			ResultData: vreport.ResultData{
+				Vulnerabilities: []vreport.Vulnerability{
+					{
+						Summary: "Vulnerability Summary 1",
+						Score:   5.0,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		targets []config.Target
+		want    ExitCode
+	}{
+		{
+			name:    "no target config",
+			targets: nil,
+			want:    ExitCodeHigh,
+		},
+		{
+			name: "target exclusions",
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Exclusions: []config.Exclusion{
+						{Summary: "Summary 1"},
+					},
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "target exclusions and severity",
+			targets: []config.Target{
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Severity:   ptr(config.SeverityLow),
+					Exclusions: []config.Exclusion{
+						{Summary: "Summary 1"},
+					},
+				},
+			},
+			want: ExitCodeLow,
+		},
+		{
+			name: "target severity",
+			targets: []config.Target{
+				{
+					Identifier: "example.com.es",
+					AssetType:  types.DomainName,
+					Severity:   ptr(config.SeverityMedium),
+				},
+				{
+					Identifier: "example.com",
+					AssetType:  types.DomainName,
+					Severity:   ptr(config.SeverityCritical),
+				},
+			},
+			want: ExitCodeMedium,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rConfig := config.ReportConfig{
+				Severity:   ptr(config.SeverityHigh),
+				OutputFile: ptr(path.Join(t.TempDir(), "output.txt")),
+			}
+
+			writer, err := NewWriter(rConfig, tt.targets)
+			if err != nil {
+				t.Fatalf("unable to create a report writer: %v", err)
+			}
+			defer writer.Close()
+
+			got, err := writer.Write(er)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected exit code: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_Write_SLA(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tn := t0.Add(10 * 24 * time.Hour)
+	timeNow = func() time.Time { return tn }
+
+	er := engine.Report{
+		"CheckID1": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "Checktype1",
+				Target:        "Target1",
+				Status:        "FINISHED",
+			},
+			ResultData: vreport.ResultData{
+				Vulnerabilities: []vreport.Vulnerability{
+					{
+						Summary:     "Old critical",
+						Fingerprint: "fingerprint1",
+						Score:       9.0,
+					},
+					{
+						Summary:     "New critical",
+						Fingerprint: "fingerprint2",
+						Score:       9.0,
+					},
+					{
+						Summary:     "Old low",
+						Fingerprint: "fingerprint3",
+						Score:       1.0,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		errorOnBreach *config.Severity
+		want          ExitCode
+		wantSLA       map[string]*slaStatus
+	}{
+		{
+			name:          "error on critical breach",
+			errorOnBreach: ptr(config.SeverityCritical),
+			want:          ExitCodeSLABreach,
+			wantSLA: map[string]*slaStatus{
+				"Old critical": {
+					FirstSeen: t0,
+					Due:       t0.Add(7 * 24 * time.Hour),
+					Overdue:   true,
+				},
+				"New critical": {
+					FirstSeen: tn,
+					Due:       tn.Add(7 * 24 * time.Hour),
+					Overdue:   false,
+				},
+				"Old low": nil,
+			},
+		},
+		{
+			name:          "no error on breach",
+			errorOnBreach: nil,
+			want:          ExitCodeCritical,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpPath := t.TempDir()
+			historyPath := path.Join(tmpPath, "history.jsonl")
+
+			entry := history.Entry{
+				Time:    t0,
+				Targets: []string{"Target1"},
+				Findings: []history.Finding{
+					{Target: "Target1", Checktype: "Checktype1", Fingerprint: "fingerprint1"},
+					{Target: "Target1", Checktype: "Checktype1", Fingerprint: "fingerprint3"},
+				},
+			}
+			if err := history.Open(historyPath).Append(entry); err != nil {
+				t.Fatalf("could not write history: %v", err)
+			}
+
+			rConfig := config.ReportConfig{
+				Severity:   ptr(config.SeverityCritical),
+				OutputFile: ptr(path.Join(tmpPath, "output.txt")),
+				History:    ptr(historyPath),
+				SLA: config.SLAConfig{
+					Deadlines: map[config.Severity]config.Duration{
+						config.SeverityCritical: config.Duration(7 * 24 * time.Hour),
+					},
+					ErrorOnBreach: tt.errorOnBreach,
+				},
+			}
+
+			writer, err := NewWriter(rConfig, nil)
+			if err != nil {
+				t.Fatalf("unable to create a report writer: %v", err)
+			}
+			defer writer.Close()
+
+			got, err := writer.Write(er)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected exit code: got: %v, want: %v", got, tt.want)
+			}
+
+			if tt.wantSLA == nil {
+				return
+			}
+
+			vulns := writer.parseReport(er)
+			if err := writer.trackSLA(vulns); err != nil {
+				t.Fatalf("unexpected error tracking SLA: %v", err)
+			}
+			gotSLA := make(map[string]*slaStatus)
+			for _, v := range vulns {
+				gotSLA[v.Summary] = v.SLA
+			}
+			// The history contains the results of the call to
+			// Write, which does not change the time when the
+			// vulnerabilities were first detected.
+			if diff := cmp.Diff(tt.wantSLA, gotSLA); diff != "" {
+				t.Errorf("SLA mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestWriter_Write_advisory(t *testing.T) {
+	er := engine.Report{
+		"CheckID1": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "Checktype1",
+				Target:        "Target1",
+				Status:        "FINISHED",
+			},
+			ResultData: vreport.ResultData{
+				Vulnerabilities: []vreport.Vulnerability{
+					{
+						Summary: "Low",
+						Score:   1.0,
+					},
+				},
+			},
+		},
+		"CheckID2": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID2",
+				ChecktypeName: "Flaky",
+				Target:        "Target1",
+				Status:        "FINISHED",
+			},
+			ResultData: vreport.ResultData{
+				Vulnerabilities: []vreport.Vulnerability{
+					{
+						Summary: "Critical",
+						Score:   9.0,
+					},
+				},
+			},
+		},
+		"CheckID3": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID3",
+				ChecktypeName: "Flaky",
+				Target:        "Target2",
+				Status:        "FAILED",
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		advisory []string
+		want     ExitCode
+	}{
+		{
+			name:     "advisory checktype",
+			advisory: []string{"Flaky"},
+			want:     ExitCodeLow,
+		},
+		{
+			name:     "no advisory checktypes",
+			advisory: nil,
+			want:     ExitCodeCheckError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rConfig := config.ReportConfig{
+				Severity:   ptr(config.SeverityInfo),
+				OutputFile: ptr(path.Join(t.TempDir(), "output.txt")),
+				Advisory:   tt.advisory,
+			}
+
+			writer, err := NewWriter(rConfig, nil)
+			if err != nil {
+				t.Fatalf("unable to create a report writer: %v", err)
+			}
+			defer writer.Close()
+
+			got, err := writer.Write(er)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected exit code: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWriter_InvalidExclusion(t *testing.T) {
+	rConfig := config.ReportConfig{
+		Exclusions: []config.Exclusion{
+			{Summary: "Summary 1"},
+			{Resource: "Dockerfile("},
+		},
+	}
+	_, err := NewWriter(rConfig, nil)
+	if !errors.Is(err, config.ErrInvalidExclusion) {
+		t.Errorf("unexpected error: want: %v, got: %v", config.ErrInvalidExclusion, err)
+	}
+}
+
+func TestNewWriter_ExclusionKeys(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	sign := func(excl config.Exclusion) config.Exclusion {
+		msg, err := excl.SignedMessage()
+		if err != nil {
+			t.Fatalf("error getting message: %v", err)
+		}
+		excl.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, msg))
+		return excl
+	}
+
+	rConfig := config.ReportConfig{
+		ExclusionKeys: []string{base64.StdEncoding.EncodeToString(pub)},
+		Exclusions: []config.Exclusion{
+			sign(config.Exclusion{Summary: "Signed"}),
+			{Summary: "Unsigned"},
+		},
+	}
+	targets := []config.Target{
+		{
+			Identifier: "example.com",
+			AssetType:  types.DomainName,
+			Exclusions: []config.Exclusion{
+				{Summary: "Unsigned target"},
+				sign(config.Exclusion{Target: `^example\.com$`, Summary: "Signed target"}),
+				sign(config.Exclusion{Summary: "Signed unscoped"}),
+			},
+		},
+		{
+			Identifier: "example.org",
+			AssetType:  types.DomainName,
+			Exclusions: []config.Exclusion{
+				// Signed for target example.com.
+				sign(config.Exclusion{Target: `^example\.com$`, Summary: "Signed other target"}),
+			},
+		},
+	}
+	for i, t := range targets {
+		for j, excl := range t.Exclusions {
+			excl.Target = ""
+			targets[i].Exclusions[j] = excl
+		}
+	}
+
+	writer, err := NewWriter(rConfig, targets)
+	if err != nil {
+		t.Fatalf("unable to create a report writer: %v", err)
+	}
+
+	var got []string
+	for _, excl := range writer.exclusions {
+		got = append(got, excl.Target+" "+excl.Summary)
+	}
+	want := []string{
+		" Signed",
+		`^example\.com$ Signed target`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("exclusions mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestWriter_parseReport_Order(t *testing.T) {
+	const nchecks = 10
+
+	er := make(engine.Report)
+	var want []vulnerability
+	for i := 0; i < nchecks; i++ {
+		checkID := fmt.Sprintf("CheckID%02d", i)
+		cd := vreport.CheckData{CheckID: checkID, ChecktypeName: fmt.Sprintf("Checktype%02d", i), Target: "example.com"}
+		key := engine.CheckKey(cd)
+
+		var vs []vreport.Vulnerability
+		for j := 0; j < parseWorkerMinVulns; j++ {
+			v := vreport.Vulnerability{
+				Summary: fmt.Sprintf("Vulnerability Summary %v-%v", i, j),
+				Score:   float32(j % 10),
+			}
+			vs = append(vs, v)
+
+			var excls []int
+			if j%2 == 0 {
+				excls = []int{0}
+			}
+			wv := vulnerability{
+				CheckData:         cd,
+				CheckKey:          key,
+				Vulnerability:     v,
+				Severity:          scoreToSeverity(v.Score),
+				matchedExclusions: excls,
+			}
+			wv.Slug = mkSlug(wv)
+			want = append(want, wv)
+		}
+		er[checkID] = vreport.Report{
+			CheckData:  cd,
+			ResultData: vreport.ResultData{Vulnerabilities: vs},
+		}
+	}
+
+	w, err := NewWriter(config.ReportConfig{
+		Exclusions: []config.Exclusion{
+			{Summary: `-\d*[02468]$`},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create a report writer: %v", err)
+	}
+
+	got := w.parseReport(er)
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(vulnerability{})); diff != "" {
+		t.Errorf("vulnerabilities mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func BenchmarkWriter_parseReport(b *testing.B) {
+	er := make(engine.Report)
+	for i := 0; i < 100; i++ {
+		checkID := fmt.Sprintf("CheckID%v", i)
+		var vs []vreport.Vulnerability
+		for j := 0; j < 1000; j++ {
+			vs = append(vs, vreport.Vulnerability{
+				Summary:          fmt.Sprintf("Vulnerability Summary %v", j),
+				AffectedResource: fmt.Sprintf("path/to/file%v.go", j),
+				Score:            float32(j % 10),
+			})
+		}
+		er[checkID] = vreport.Report{
+			CheckData:  vreport.CheckData{CheckID: checkID, Target: "example.com"},
+			ResultData: vreport.ResultData{Vulnerabilities: vs},
+		}
+	}
+
+	w, err := NewWriter(config.ReportConfig{
+		Exclusions: []config.Exclusion{
+			{Summary: `Summary \d*0$`},
+			{Resource: `^path/to/file1\d*\.go$`},
+			{Target: `example\.org`},
+		},
+	}, nil)
+	if err != nil {
+		b.Fatalf("unable to create a report writer: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.parseReport(er)
+	}
+}
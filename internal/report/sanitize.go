@@ -0,0 +1,90 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// reEscape matches the ANSI escape sequences. That is, CSI
+// sequences, like the ones used to set colors, OSC sequences, like
+// the ones used to set the terminal title or render hyperlinks, and
+// two-character escape sequences.
+var reEscape = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)?|[@-Z\\-_])`)
+
+// sanitize removes the ANSI escape sequences and the control
+// characters of the provided text. New lines and tabs are kept and
+// "\r\n" line endings are converted to "\n".
+func sanitize(s string) string {
+	if !strings.ContainsFunc(s, isUnsafe) {
+		return s
+	}
+
+	s = reEscape.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Map(func(r rune) rune {
+		if isUnsafe(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// isUnsafe reports whether r is a control character other than new
+// line and tab.
+func isUnsafe(r rune) bool {
+	return unicode.IsControl(r) && r != '\n' && r != '\t'
+}
+
+// sanitizeStrings returns a copy of the provided list with every
+// element sanitized. See [sanitize].
+func sanitizeStrings(ss []string) []string {
+	if ss == nil {
+		return nil
+	}
+	sanitized := make([]string, len(ss))
+	for i, s := range ss {
+		sanitized[i] = sanitize(s)
+	}
+	return sanitized
+}
+
+// sanitizeVuln returns a copy of the provided vulnerability with its
+// text fields sanitized. See [sanitize]. The fingerprint is not
+// modified, because it identifies the vulnerability across scans.
+func sanitizeVuln(v report.Vulnerability) report.Vulnerability {
+	v.Summary = sanitize(v.Summary)
+	v.Description = sanitize(v.Description)
+	v.Details = sanitize(v.Details)
+	v.ImpactDetails = sanitize(v.ImpactDetails)
+	v.AffectedResource = sanitize(v.AffectedResource)
+	v.AffectedResourceString = sanitize(v.AffectedResourceString)
+	v.Recommendations = sanitizeStrings(v.Recommendations)
+	v.References = sanitizeStrings(v.References)
+	v.Labels = sanitizeStrings(v.Labels)
+
+	if v.Resources != nil {
+		rscs := make([]report.ResourcesGroup, len(v.Resources))
+		for i, rsc := range v.Resources {
+			rsc.Name = sanitize(rsc.Name)
+			rsc.Header = sanitizeStrings(rsc.Header)
+			if rsc.Rows != nil {
+				rows := make([]map[string]string, len(rsc.Rows))
+				for j, row := range rsc.Rows {
+					rows[j] = make(map[string]string, len(row))
+					for k, val := range row {
+						rows[j][sanitize(k)] = sanitize(val)
+					}
+				}
+				rsc.Rows = rows
+			}
+			rscs[i] = rsc
+		}
+		v.Resources = rscs
+	}
+	return v
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{
+			name: "plain text",
+			s:    "line 1\n\tline 2",
+			want: "line 1\n\tline 2",
+		},
+		{
+			name: "colors",
+			s:    "\x1b[1;31mHIGH\x1b[0m severity",
+			want: "HIGH severity",
+		},
+		{
+			name: "cursor movement",
+			s:    "progress\x1b[2K\x1b[1Gdone",
+			want: "progressdone",
+		},
+		{
+			name: "hyperlink",
+			s:    "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\",
+			want: "link",
+		},
+		{
+			name: "window title",
+			s:    "\x1b]0;title\x07text",
+			want: "text",
+		},
+		{
+			name: "control characters",
+			s:    "bell\x07 backspace\x08 null\x00 del\x7f",
+			want: "bell backspace null del",
+		},
+		{
+			name: "line endings",
+			s:    "line 1\r\nline 2\rline 3",
+			want: "line 1\nline 2line 3",
+		},
+		{
+			name: "unicode",
+			s:    "vulnerabilité → \x1b[32mñ\x1b[m",
+			want: "vulnerabilité → ñ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitize(tt.s); got != tt.want {
+				t.Errorf("unexpected result: got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeVuln(t *testing.T) {
+	v := report.Vulnerability{
+		Summary:         "\x1b[31mSummary\x1b[0m",
+		Fingerprint:     "fingerprint\x1b",
+		Details:         "Details\r\n",
+		Recommendations: []string{"\x1b[1mRecommendation\x1b[0m"},
+		Resources: []report.ResourcesGroup{
+			{
+				Name:   "Resources\x07",
+				Header: []string{"\x1b[1mName\x1b[0m"},
+				Rows: []map[string]string{
+					{"\x1b[1mName\x1b[0m": "\x1b[32mvalue\x1b[0m"},
+				},
+			},
+		},
+	}
+
+	want := report.Vulnerability{
+		Summary:         "Summary",
+		Fingerprint:     "fingerprint\x1b",
+		Details:         "Details\n",
+		Recommendations: []string{"Recommendation"},
+		Resources: []report.ResourcesGroup{
+			{
+				Name:   "Resources",
+				Header: []string{"Name"},
+				Rows: []map[string]string{
+					{"Name": "value"},
+				},
+			},
+		},
+	}
+
+	got := sanitizeVuln(v)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("vulnerability mismatch (-want +got):\n%v", diff)
+	}
+
+	if v.Recommendations[0] != "\x1b[1mRecommendation\x1b[0m" {
+		t.Errorf("original vulnerability was modified")
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+
+	"github.com/adevinta/lava/internal/config"
+	lavareport "github.com/adevinta/lava/report"
+)
+
+// ErrNoFindings is returned by [Show] when no finding of the report
+// matches the filter.
+var ErrNoFindings = errors.New("no findings")
+
+// Filter selects the findings of a report. A finding is selected if
+// it matches all the criteria of the filter.
+type Filter struct {
+	// Slug is the slug of the finding. If empty, the findings are
+	// not filtered by slug.
+	Slug string
+
+	// Severity is the minimum severity of the findings. If nil,
+	// the findings are not filtered by severity.
+	Severity *config.Severity
+
+	// Target matches the target of the findings. If nil, the
+	// findings are not filtered by target.
+	Target *regexp.Regexp
+
+	// Checktype matches the checktype of the findings. If nil,
+	// the findings are not filtered by checktype.
+	Checktype *regexp.Regexp
+}
+
+// match reports whether the provided vulnerability matches the
+// filter.
+func (f Filter) match(v vulnerability) bool {
+	if f.Slug != "" && v.Slug != f.Slug {
+		return false
+	}
+	if f.Severity != nil && v.Severity < *f.Severity {
+		return false
+	}
+	if f.Target != nil && !f.Target.MatchString(v.CheckData.Target) {
+		return false
+	}
+	if f.Checktype != nil && !f.Checktype.MatchString(v.CheckData.ChecktypeName) {
+		return false
+	}
+	return true
+}
+
+// Show reads a report in JSON format from r and renders the findings
+// that match the provided filter in human-readable format into w. The
+// report can be a list of findings or an object with the fields
+// "metadata" and "findings". The findings are printed sorted by
+// severity in descending order, keeping the order of the report for
+// the findings with the same severity. The slug of the findings of reports
+// generated by older versions of Lava is computed on the fly. It
+// returns [ErrNoFindings] if no finding matches the filter.
+func Show(w io.Writer, r io.Reader, filter Filter) error {
+	findings, err := lavareport.Load(r)
+	if err != nil {
+		return fmt.Errorf("read report: %w", err)
+	}
+
+	var matched []vulnerability
+	for i, f := range findings {
+		v, err := fromFinding(f)
+		if err != nil {
+			return fmt.Errorf("finding %v: %w", i, err)
+		}
+		if v.Slug == "" {
+			v.Slug = mkSlug(v)
+		}
+		if filter.match(v) {
+			matched = append(matched, v)
+		}
+	}
+	if len(matched) == 0 {
+		return ErrNoFindings
+	}
+	slices.SortStableFunc(matched, func(a, b vulnerability) int {
+		return cmp.Compare(b.Severity, a.Severity)
+	})
+
+	prn := humanPrinter{theme: config.ThemeDefault, width: termWidth()}
+	return prn.printVulns(w, matched)
+}
+
+// fromFinding converts a finding of the public report into a
+// vulnerability.
+func fromFinding(f lavareport.Finding) (vulnerability, error) {
+	v := vulnerability{
+		Vulnerability: f.Vulnerability,
+		CheckData:     f.CheckData,
+		CheckKey:      f.CheckKey,
+		Slug:          f.Slug,
+		Advisory:      f.Advisory,
+		Parent:        f.Parent,
+	}
+	if f.Severity != "" {
+		if err := v.Severity.UnmarshalText([]byte(f.Severity)); err != nil {
+			return vulnerability{}, err
+		}
+	}
+	if f.SLA != nil {
+		v.SLA = &slaStatus{
+			FirstSeen: f.SLA.FirstSeen,
+			Due:       f.SLA.Due,
+			Overdue:   f.SLA.Overdue,
+		}
+	}
+	if f.CWE != nil {
+		v.CWE = &cwe{
+			ID:          f.CWE.ID,
+			Name:        f.CWE.Name,
+			Description: f.CWE.Description,
+		}
+	}
+	return v, nil
+}
+
+// printVulns renders the provided vulnerabilities in human-readable
+// format without the status and summary sections.
+func (prn humanPrinter) printVulns(w io.Writer, vulns []vulnerability) error {
+	tmpl, err := prn.template()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for i, v := range vulns {
+		if i > 0 {
+			if _, err := io.WriteString(bw, "\n"); err != nil {
+				return fmt.Errorf("write report: %w", err)
+			}
+		}
+		if err := tmpl.ExecuteTemplate(bw, "vuln", v); err != nil {
+			return fmt.Errorf("execute template vuln: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flush report: %w", err)
+	}
+	return nil
+}
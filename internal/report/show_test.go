@@ -0,0 +1,171 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestShow(t *testing.T) {
+	v := vulnerability{
+		Vulnerability: vreport.Vulnerability{
+			Summary:     "Outdated package",
+			Fingerprint: "fp",
+		},
+		CheckData: vreport.CheckData{
+			ChecktypeName: "vulcan-trivy",
+			Target:        "example.com",
+		},
+	}
+	slug := mkSlug(v)
+
+	tests := []struct {
+		name    string
+		report  string
+		filter  Filter
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "list of findings",
+			report: `[{"summary": "Outdated package", "fingerprint": "fp", "check_data": {"checktype_name": "vulcan-trivy", "target": "example.com"}, "severity": "high"}]`,
+			filter: Filter{Slug: slug},
+			want:   "Outdated package",
+		},
+		{
+			name:   "report with metadata",
+			report: `{"metadata": {"lava_version": "v0.8.0"}, "findings": [{"summary": "Outdated package", "fingerprint": "fp", "check_data": {"checktype_name": "vulcan-trivy", "target": "example.com"}, "severity": "high"}]}`,
+			filter: Filter{Slug: slug},
+			want:   "Outdated package",
+		},
+		{
+			name:    "no matching findings",
+			report:  `[{"summary": "Outdated package", "fingerprint": "fp", "check_data": {"checktype_name": "vulcan-trivy", "target": "example.com"}, "severity": "high"}]`,
+			filter:  Filter{Slug: "000000000000"},
+			wantErr: ErrNoFindings,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Show(&buf, strings.NewReader(tt.report), tt.filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			got := buf.String()
+			for _, s := range []string{tt.want, slug} {
+				if !strings.Contains(got, s) {
+					t.Errorf("output does not contain %q:\n%v", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMkSlug(t *testing.T) {
+	v := vulnerability{
+		Vulnerability: vreport.Vulnerability{
+			Summary:     "Outdated package",
+			Fingerprint: "fp",
+		},
+		CheckData: vreport.CheckData{
+			ChecktypeName: "vulcan-trivy",
+			Target:        "example.com",
+		},
+	}
+
+	slug := mkSlug(v)
+	if len(slug) != slugLen {
+		t.Errorf("unexpected slug length: %v", len(slug))
+	}
+	if got := mkSlug(v); got != slug {
+		t.Errorf("slug is not stable: %v != %v", got, slug)
+	}
+
+	v.CheckData.Target = "example.org"
+	if got := mkSlug(v); got == slug {
+		t.Errorf("same slug for different targets: %v", got)
+	}
+}
+
+func TestShow_filter(t *testing.T) {
+	const report = `[
+		{"summary": "Low trivy", "check_data": {"checktype_name": "vulcan-trivy", "target": "api.example.com"}, "severity": "low"},
+		{"summary": "High semgrep", "check_data": {"checktype_name": "vulcan-semgrep", "target": "api.example.com"}, "severity": "high"},
+		{"summary": "Critical trivy", "check_data": {"checktype_name": "vulcan-trivy", "target": "api.example.com"}, "severity": "critical"},
+		{"summary": "High trivy", "check_data": {"checktype_name": "vulcan-trivy", "target": "www.example.com"}, "severity": "high"}
+	]`
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{
+			name:   "no filter",
+			filter: Filter{},
+			want:   []string{"Critical trivy", "High semgrep", "High trivy", "Low trivy"},
+		},
+		{
+			name:   "severity",
+			filter: Filter{Severity: ptr(config.SeverityHigh)},
+			want:   []string{"Critical trivy", "High semgrep", "High trivy"},
+		},
+		{
+			name:   "target",
+			filter: Filter{Target: regexp.MustCompile(`^api\.`)},
+			want:   []string{"Critical trivy", "High semgrep", "Low trivy"},
+		},
+		{
+			name: "all criteria",
+			filter: Filter{
+				Severity:  ptr(config.SeverityHigh),
+				Target:    regexp.MustCompile(`^api\.`),
+				Checktype: regexp.MustCompile("trivy"),
+			},
+			want: []string{"Critical trivy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Show(&buf, strings.NewReader(report), tt.filter); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := buf.String()
+
+			var summaries []string
+			for _, s := range []string{"Critical trivy", "High semgrep", "High trivy", "Low trivy"} {
+				if strings.Contains(got, s) {
+					summaries = append(summaries, s)
+				}
+			}
+			if diff := cmp.Diff(tt.want, summaries); diff != "" {
+				t.Errorf("findings mismatch (-want +got):\n%v", diff)
+			}
+
+			last := -1
+			for _, s := range tt.want {
+				i := strings.Index(got, s)
+				if i < last {
+					t.Errorf("findings are not sorted by severity:\n%v", got)
+				}
+				last = i
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// slugLen is the number of hexadecimal characters of the slugs.
+const slugLen = 12
+
+// mkSlug returns the slug of the provided vulnerability. The slug
+// identifies the vulnerability in a report and it is stable across
+// scans, so it can be used to reference the vulnerability in tickets
+// and as anchor in rendered documents. It is derived from the
+// fingerprint, the summary and the affected resource of the
+// vulnerability, and the checktype and target of the check that
+// found it.
+func mkSlug(v vulnerability) string {
+	h := sha256.New()
+	for _, s := range []string{
+		v.CheckData.ChecktypeName,
+		v.CheckData.Target,
+		v.Parent,
+		v.Summary,
+		v.AffectedResource,
+		v.Fingerprint,
+	} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:slugLen]
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/warnings"
+)
+
+// templatePrinter represents a report printer that renders the scan
+// results using a user-provided Go template.
+type templatePrinter struct {
+	tmpl *template.Template
+}
+
+// templateSummary is the summary of the scan passed to user-provided
+// templates.
+type templateSummary struct {
+	// Count is the number of non-excluded vulnerabilities per
+	// severity.
+	Count map[string]int
+
+	// Total is the total number of non-excluded vulnerabilities.
+	Total int
+
+	// Excluded is the number of excluded vulnerabilities.
+	Excluded int
+
+	// Omitted is the number of vulnerabilities that are not
+	// rendered because of the limits of the report.
+	Omitted int
+
+	// Truncated is the number of texts of the vulnerabilities
+	// that are truncated because of the limits of the report.
+	Truncated int
+
+	// Coverage is the coverage of the control families on every
+	// target. It is nil if the catalog does not label its
+	// checktypes with control families.
+	Coverage []targetCoverage
+
+	// Grade is the security grade of the scan. It is nil if
+	// grading is disabled.
+	Grade *grade
+}
+
+// templateData is the data passed to user-provided templates.
+type templateData struct {
+	Vulnerabilities []vulnerability
+	Summary         templateSummary
+	Status          []checkStatus
+	StaleExclusions []staleExclusion
+	Warnings        []warnings.Warning
+	Metadata        *Metadata
+}
+
+// templateFuncs contains the functions that can be called from
+// user-provided templates.
+var templateFuncs = template.FuncMap{
+	"json":  toJSON,
+	"csv":   toCSV,
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+// newTemplatePrinter returns a [templatePrinter] that renders the
+// template stored in the specified file.
+func newTemplatePrinter(file string) (templatePrinter, error) {
+	if file == "" {
+		return templatePrinter{}, fmt.Errorf("%w: no template", config.ErrInvalidOutputFormat)
+	}
+
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return templatePrinter{}, fmt.Errorf("read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(file)).Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return templatePrinter{}, fmt.Errorf("parse template: %w", err)
+	}
+	return templatePrinter{tmpl: tmpl}, nil
+}
+
+// Print renders the scan results using the template of the printer.
+func (prn templatePrinter) Print(w io.Writer, vulns []vulnerability, summ summary, status []checkStatus, staleExcls []staleExclusion, warns []warnings.Warning, md *Metadata) error {
+	count := make(map[string]int)
+	var total int
+	for s := config.SeverityCritical; s >= config.SeverityInfo; s-- {
+		count[s.String()] = summ.count[s]
+		total += summ.count[s]
+	}
+
+	data := templateData{
+		Vulnerabilities: vulns,
+		Summary: templateSummary{
+			Count:     count,
+			Total:     total,
+			Excluded:  summ.excluded,
+			Omitted:   summ.omitted,
+			Truncated: summ.truncated,
+			Coverage:  summ.coverage,
+			Grade:     summ.grade,
+		},
+		Status:          status,
+		StaleExclusions: staleExcls,
+		Warnings:        warns,
+		Metadata:        md,
+	}
+
+	bw := bufio.NewWriter(w)
+
+	if err := prn.tmpl.Execute(bw, data); err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flush report: %w", err)
+	}
+	return nil
+}
+
+// toJSON returns the JSON encoding of v.
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// toCSV returns the provided fields as a CSV record. The returned
+// string does not end with a new line.
+func toCSV(fields ...string) (string, error) {
+	var b strings.Builder
+	cw := csv.NewWriter(&b)
+	if err := cw.Write(fields); err != nil {
+		return "", err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestTemplatePrinter_Print(t *testing.T) {
+	vulns := []vulnerability{
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary:     "Vulnerability Summary 1",
+				Fingerprint: "fp1",
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "lava-check",
+				Target:        "example.com",
+			},
+			Severity: config.SeverityHigh,
+		},
+		{
+			Vulnerability: vreport.Vulnerability{
+				Summary:     `Vulnerability "Summary", 2`,
+				Fingerprint: "fp2",
+			},
+			CheckData: vreport.CheckData{
+				ChecktypeName: "lava-check",
+				Target:        "example.com",
+			},
+			Severity: config.SeverityLow,
+		},
+	}
+	summ := summary{
+		count: map[config.Severity]int{
+			config.SeverityHigh: 1,
+			config.SeverityLow:  1,
+		},
+		excluded: 3,
+	}
+	status := []checkStatus{
+		{
+			Checktype: "lava-check",
+			Target:    "example.com",
+			Status:    "FINISHED",
+		},
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "csv",
+			tmpl: "" +
+				"{{csv \"target\" \"severity\" \"summary\"}}\n" +
+				"{{range .Vulnerabilities}}" +
+				"{{csv .CheckData.Target .Severity.String .Summary}}\n" +
+				"{{end}}",
+			want: "" +
+				"target,severity,summary\n" +
+				"example.com,high,Vulnerability Summary 1\n" +
+				"example.com,low,\"Vulnerability \"\"Summary\"\", 2\"\n",
+		},
+		{
+			name: "json",
+			tmpl: "" +
+				"{\"count\": {{json .Summary.Count}}, " +
+				"\"fingerprints\": [{{range $i, $v := .Vulnerabilities}}{{if $i}}, {{end}}{{json $v.Fingerprint}}{{end}}]}\n",
+			want: "" +
+				"{\"count\": {\"critical\":0,\"high\":1,\"info\":0,\"low\":1,\"medium\":0}, " +
+				"\"fingerprints\": [\"fp1\", \"fp2\"]}\n",
+		},
+		{
+			name: "summary and status",
+			tmpl: "" +
+				"total={{.Summary.Total}} excluded={{.Summary.Excluded}} grade={{with .Summary.Grade}}{{.Letter}}{{else}}none{{end}}\n" +
+				"{{range .Status}}{{.Checktype | upper}} {{.Target}} {{.Status | lower}}\n{{end}}",
+			want: "" +
+				"total=2 excluded=3 grade=none\n" +
+				"LAVA-CHECK example.com finished\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "report.tmpl")
+			if err := os.WriteFile(file, []byte(tt.tmpl), 0o644); err != nil {
+				t.Fatalf("error writing template: %v", err)
+			}
+
+			prn, err := newTemplatePrinter(file)
+			if err != nil {
+				t.Fatalf("unexpected error creating printer: %v", err)
+			}
+
+			var b strings.Builder
+			if err := prn.Print(&b, vulns, summ, status, nil, nil, nil); err != nil {
+				t.Fatalf("unexpected error printing: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestNewTemplatePrinter_errors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.tmpl")
+	if err := os.WriteFile(invalid, []byte("{{.Vulnerabilities"), 0o644); err != nil {
+		t.Fatalf("error writing template: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr error
+	}{
+		{
+			name:    "no template",
+			file:    "",
+			wantErr: config.ErrInvalidOutputFormat,
+		},
+		{
+			name:    "not found",
+			file:    filepath.Join(dir, "notfound.tmpl"),
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name: "invalid template",
+			file: invalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTemplatePrinter(tt.file)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: want: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// truncatedMarker is appended to the truncated texts.
+const truncatedMarker = " [truncated]"
+
+// truncate applies the limits of the report to the provided
+// vulnerabilities, which must be sorted by severity in reverse
+// order. It returns the vulnerabilities that must be rendered, the
+// number of omitted vulnerabilities and the number of truncated
+// texts. The provided vulnerabilities are not modified.
+func (writer Writer) truncate(vulns []vulnerability) (tvulns []vulnerability, omitted, truncated int) {
+	tvulns = vulns
+	if writer.maxFindings > 0 && len(vulns) > writer.maxFindings {
+		tvulns = vulns[:writer.maxFindings]
+		omitted = len(vulns) - writer.maxFindings
+	}
+
+	if writer.maxDetailSize <= 0 {
+		return tvulns, omitted, 0
+	}
+
+	tvulns = append([]vulnerability(nil), tvulns...)
+	for i := range tvulns {
+		v := &tvulns[i]
+		for _, s := range []*string{&v.Description, &v.Details, &v.ImpactDetails} {
+			var ok bool
+			if *s, ok = truncateText(*s, writer.maxDetailSize); ok {
+				truncated++
+			}
+		}
+	}
+	return tvulns, omitted, truncated
+}
+
+// truncateText truncates s to the specified size in bytes and
+// appends [truncatedMarker] to it. Multi-byte characters are not
+// split. It reports whether s was truncated.
+func truncateText(s string, size int) (string, bool) {
+	if len(s) <= size {
+		return s, false
+	}
+	n := size
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncatedMarker, true
+}
+
+// writeFullReport writes the provided vulnerabilities in JSON format
+// into the full output file. The file is encrypted like the primary
+// output.
+func (writer Writer) writeFullReport(vulns []vulnerability) error {
+	f, err := createOutput(writer.fullOutput, writer.uploadOpts, writer.encryption)
+	if err != nil {
+		return err
+	}
+
+	if err := (jsonPrinter{}).Print(f, vulns, summary{}, nil, nil, nil, writer.metadata); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+	return nil
+}
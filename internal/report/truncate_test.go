@@ -0,0 +1,158 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vreport "github.com/adevinta/vulcan-report"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/engine"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             string
+		size          int
+		want          string
+		wantTruncated bool
+	}{
+		{
+			name:          "short text",
+			s:             "text",
+			size:          4,
+			want:          "text",
+			wantTruncated: false,
+		},
+		{
+			name:          "long text",
+			s:             "long text",
+			size:          4,
+			want:          "long" + truncatedMarker,
+			wantTruncated: true,
+		},
+		{
+			name:          "multi-byte character",
+			s:             "añb",
+			size:          2,
+			want:          "a" + truncatedMarker,
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncateText(tt.s, tt.size)
+			if got != tt.want {
+				t.Errorf("unexpected text: got: %q, want: %q", got, tt.want)
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("unexpected truncated value: got: %v, want: %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestWriter_truncate(t *testing.T) {
+	vulns := []vulnerability{
+		{Vulnerability: vreport.Vulnerability{Summary: "Critical", Description: "Long description"}, Severity: config.SeverityCritical},
+		{Vulnerability: vreport.Vulnerability{Summary: "High", Details: "Short"}, Severity: config.SeverityHigh},
+		{Vulnerability: vreport.Vulnerability{Summary: "Low", Description: "Long description"}, Severity: config.SeverityLow},
+	}
+
+	writer := Writer{maxFindings: 2, maxDetailSize: 5}
+	got, omitted, truncated := writer.truncate(vulns)
+
+	want := []vulnerability{
+		{Vulnerability: vreport.Vulnerability{Summary: "Critical", Description: "Long " + truncatedMarker}, Severity: config.SeverityCritical},
+		{Vulnerability: vreport.Vulnerability{Summary: "High", Details: "Short"}, Severity: config.SeverityHigh},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(vulnerability{})); diff != "" {
+		t.Errorf("vulnerabilities mismatch (-want +got):\n%v", diff)
+	}
+	if omitted != 1 {
+		t.Errorf("unexpected number of omitted vulnerabilities: %v", omitted)
+	}
+	if truncated != 1 {
+		t.Errorf("unexpected number of truncated texts: %v", truncated)
+	}
+	if vulns[0].Description != "Long description" {
+		t.Errorf("original vulnerability was modified")
+	}
+}
+
+func TestWriter_Write_fullOutput(t *testing.T) {
+	er := engine.Report{
+		"CheckID1": {
+			CheckData: vreport.CheckData{
+				CheckID:       "CheckID1",
+				ChecktypeName: "Checktype1",
+				Target:        "Target1",
+				Status:        "FINISHED",
+			},
+			ResultData: vreport.ResultData{
+				Vulnerabilities: []vreport.Vulnerability{
+					{Summary: "Critical", Score: 9.0},
+					{Summary: "High", Score: 7.0},
+					{Summary: "Low", Score: 1.0},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	output := filepath.Join(tmpDir, "output.json")
+	fullOutput := filepath.Join(tmpDir, "full.json")
+
+	rConfig := config.ReportConfig{
+		Severity:    ptr(config.SeverityInfo),
+		Format:      ptr(config.OutputFormatJSON),
+		OutputFile:  ptr(output),
+		FullOutput:  ptr(fullOutput),
+		MaxFindings: ptr(1),
+	}
+
+	writer, err := NewWriter(rConfig, nil)
+	if err != nil {
+		t.Fatalf("unable to create a report writer: %v", err)
+	}
+
+	if _, err := writer.Write(er); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		file string
+		want []string
+	}{
+		{output, []string{"Critical"}},
+		{fullOutput, []string{"Critical", "High", "Low"}},
+	} {
+		b, err := os.ReadFile(tt.file)
+		if err != nil {
+			t.Fatalf("read file error: %v", err)
+		}
+		var vulns []struct {
+			Summary string `json:"summary"`
+		}
+		if err := json.Unmarshal(b, &vulns); err != nil {
+			t.Fatalf("unmarshal error: %v", err)
+		}
+		var got []string
+		for _, v := range vulns {
+			got = append(got, v.Summary)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%v: summaries mismatch (-want +got):\n%v", tt.file, diff)
+		}
+	}
+}
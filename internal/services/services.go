@@ -0,0 +1,310 @@
+// Copyright 2024 Adevinta
+
+// Package services manages the auxiliary containers, like the
+// application under test or a database, that are started before
+// running the checks.
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/google/uuid"
+
+	"github.com/adevinta/lava/internal/config"
+	"github.com/adevinta/lava/internal/containers"
+)
+
+// DefaultStartTimeout is the default maximum time to wait for a
+// service to be healthy.
+const DefaultStartTimeout = 2 * time.Minute
+
+// pollInterval is the time between two consecutive checks of the
+// state of a service.
+var pollInterval = time.Second
+
+// Info contains the information of a running service.
+type Info struct {
+	// Host is the hostname of the service in the Docker network.
+	Host string
+
+	// IP is the IP address of the service in the Docker network.
+	IP string
+}
+
+// Group is a group of running services attached to the same Docker
+// network.
+type Group struct {
+	cli        containers.DockerdClient
+	network    string
+	ownNetwork bool
+	ids        []string
+	services   map[string]Info
+}
+
+// Start starts the provided services and waits for them to be
+// healthy. The services are attached to the specified Docker
+// network. If network is the empty string, a new network is created.
+// If a service fails to start, the services that were already
+// started are removed. The caller is responsible for calling
+// [Group.Close] to remove the services.
+func Start(rt containers.Runtime, dockerNetwork string, svcs []config.Service) (*Group, error) {
+	cli, err := containers.NewDockerdClient(rt)
+	if err != nil {
+		return nil, fmt.Errorf("new dockerd client: %w", err)
+	}
+
+	g := &Group{
+		cli:      cli,
+		network:  dockerNetwork,
+		services: make(map[string]Info),
+	}
+	if err := g.startAll(svcs); err != nil {
+		if closeErr := g.Close(); closeErr != nil {
+			slog.Warn("could not remove services", "err", closeErr)
+		}
+		return nil, err
+	}
+	return g, nil
+}
+
+// startAll creates the Docker network of the group if needed and
+// starts the provided services.
+func (g *Group) startAll(svcs []config.Service) error {
+	ctx := context.Background()
+
+	if g.network == "" {
+		name := "lava-services-" + uuid.New().String()
+		opts := network.CreateOptions{Labels: containers.Labels(nil)}
+		if _, err := g.cli.NetworkCreate(ctx, name, opts); err != nil {
+			return fmt.Errorf("network create: %w", err)
+		}
+		g.network = name
+		g.ownNetwork = true
+	}
+
+	for _, svc := range svcs {
+		slog.Info("starting service", "name", svc.Name, "image", svc.Image)
+
+		info, err := g.start(ctx, svc)
+		if err != nil {
+			return fmt.Errorf("start service %v: %w", svc.Name, err)
+		}
+		g.services[svc.Name] = info
+	}
+	return nil
+}
+
+// start starts a service and waits for it to be healthy.
+func (g *Group) start(ctx context.Context, svc config.Service) (Info, error) {
+	cfg := &container.Config{
+		Image:  svc.Image,
+		Cmd:    svc.Command,
+		Env:    env(svc.Env),
+		Labels: containers.Labels(nil),
+	}
+	if hc := svc.HealthCheck; hc != nil {
+		cfg.Healthcheck = &container.HealthConfig{
+			Test:     hc.Test,
+			Interval: config.Get(hc.Interval),
+			Timeout:  config.Get(hc.Timeout),
+			Retries:  config.Get(hc.Retries),
+		}
+	}
+
+	hostCfg := &container.HostConfig{
+		NetworkMode: container.NetworkMode(g.network),
+	}
+
+	netCfg := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			g.network: {Aliases: []string{svc.Name}},
+		},
+	}
+
+	resp, err := g.cli.ContainerCreate(ctx, cfg, hostCfg, netCfg, nil, "")
+	if errdefs.IsNotFound(err) {
+		if err = g.pull(ctx, svc.Image); err != nil {
+			return Info{}, fmt.Errorf("image pull: %w", err)
+		}
+		resp, err = g.cli.ContainerCreate(ctx, cfg, hostCfg, netCfg, nil, "")
+	}
+	if err != nil {
+		return Info{}, fmt.Errorf("container create: %w", err)
+	}
+	g.ids = append(g.ids, resp.ID)
+
+	if err := g.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return Info{}, fmt.Errorf("container start: %w", err)
+	}
+
+	timeout := DefaultStartTimeout
+	if svc.StartTimeout != nil {
+		timeout = *svc.StartTimeout
+	}
+
+	ip, err := g.wait(ctx, resp.ID, timeout)
+	if err != nil {
+		return Info{}, fmt.Errorf("wait: %w", err)
+	}
+	return Info{Host: svc.Name, IP: ip}, nil
+}
+
+// pull pulls the specified image.
+func (g *Group) pull(ctx context.Context, ref string) error {
+	rc, err := g.cli.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// The pull finishes when the response body has been read.
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		return fmt.Errorf("read pull response: %w", err)
+	}
+	return nil
+}
+
+// wait waits for the specified container to be healthy. If the
+// container does not have a health check, it waits for it to be
+// running. It returns the IP address of the container in the Docker
+// network of the group.
+func (g *Group) wait(ctx context.Context, id string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := g.cli.ContainerInspect(ctx, id)
+		if err != nil {
+			return "", fmt.Errorf("container inspect: %w", err)
+		}
+
+		state := resp.State
+		if state == nil {
+			return "", errors.New("missing container state")
+		}
+		if !state.Running {
+			return "", fmt.Errorf("container is not running: status: %v, exit code: %v", state.Status, state.ExitCode)
+		}
+
+		ready := true
+		if state.Health != nil {
+			switch state.Health.Status {
+			case "healthy":
+			case "unhealthy":
+				return "", errors.New("container is unhealthy")
+			default:
+				ready = false
+			}
+		}
+
+		if ready {
+			var ip string
+			if resp.NetworkSettings != nil {
+				if ep, ok := resp.NetworkSettings.Networks[g.network]; ok {
+					ip = ep.IPAddress
+				}
+			}
+			return ip, nil
+		}
+
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timeout after %v", timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Network returns the name of the Docker network the services are
+// attached to.
+func (g *Group) Network() string {
+	return g.network
+}
+
+// Services returns the information of the running services indexed
+// by name.
+func (g *Group) Services() map[string]Info {
+	return maps.Clone(g.services)
+}
+
+// RenderTargets returns a copy of the provided targets after
+// executing their identifiers as [text/template] templates. The
+// information of the running services is available as the
+// ".Services" map. For instance, "http://{{.Services.app.Host}}:8080".
+func (g *Group) RenderTargets(targets []config.Target) ([]config.Target, error) {
+	return renderTargets(targets, g.services)
+}
+
+// renderTargets renders the identifiers of the provided targets
+// using the specified services. The rendered identifiers are
+// normalized.
+func renderTargets(targets []config.Target, services map[string]Info) ([]config.Target, error) {
+	data := struct {
+		Services map[string]Info
+	}{
+		Services: services,
+	}
+
+	var rendered []config.Target
+	for _, t := range targets {
+		tmpl, err := template.New("").Option("missingkey=error").Parse(t.Identifier)
+		if err != nil {
+			return nil, fmt.Errorf("parse target %v: %w", t, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("render target %v: %w", t, err)
+		}
+
+		t.Identifier = buf.String()
+		rendered = append(rendered, t.Normalize())
+	}
+	return rendered, nil
+}
+
+// Close removes the services and, if it was created by [Start], the
+// Docker network.
+func (g *Group) Close() error {
+	ctx := context.Background()
+
+	var errs []error
+	for _, id := range g.ids {
+		opts := container.RemoveOptions{Force: true, RemoveVolumes: true}
+		if err := g.cli.ContainerRemove(ctx, id, opts); err != nil {
+			errs = append(errs, fmt.Errorf("container remove: %w", err))
+		}
+	}
+
+	if g.ownNetwork {
+		if err := g.cli.NetworkRemove(ctx, g.network); err != nil {
+			errs = append(errs, fmt.Errorf("network remove: %w", err))
+		}
+	}
+
+	if err := g.cli.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close dockerd client: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// env converts the provided environment into the format expected by
+// Docker.
+func env(m map[string]string) []string {
+	var env []string
+	for k, v := range m {
+		env = append(env, k+"="+v)
+	}
+	slices.Sort(env)
+	return env
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Adevinta
+
+package services
+
+import (
+	"testing"
+
+	types "github.com/adevinta/vulcan-types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/adevinta/lava/internal/config"
+)
+
+func TestRenderTargets(t *testing.T) {
+	services := map[string]Info{
+		"app": {Host: "app", IP: "172.20.0.2"},
+		"db":  {Host: "db", IP: "172.20.0.3"},
+	}
+
+	tests := []struct {
+		name       string
+		targets    []config.Target
+		want       []config.Target
+		wantNilErr bool
+	}{
+		{
+			name: "host",
+			targets: []config.Target{
+				{Identifier: "http://{{.Services.app.Host}}:8080", AssetType: types.WebAddress},
+			},
+			want: []config.Target{
+				{Identifier: "http://app:8080", AssetType: types.WebAddress},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "ip",
+			targets: []config.Target{
+				{Identifier: "{{.Services.db.IP}}", AssetType: types.IP},
+			},
+			want: []config.Target{
+				{Identifier: "172.20.0.3", AssetType: types.IP},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "normalized",
+			targets: []config.Target{
+				{Identifier: "http://{{.Services.app.Host}}:80/", AssetType: types.WebAddress},
+			},
+			want: []config.Target{
+				{Identifier: "http://app", AssetType: types.WebAddress},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "no template",
+			targets: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+			},
+			want: []config.Target{
+				{Identifier: "example.com", AssetType: types.DomainName},
+			},
+			wantNilErr: true,
+		},
+		{
+			name: "unknown service",
+			targets: []config.Target{
+				{Identifier: "http://{{.Services.unknown.Host}}", AssetType: types.WebAddress},
+			},
+			want:       nil,
+			wantNilErr: false,
+		},
+		{
+			name: "invalid template",
+			targets: []config.Target{
+				{Identifier: "http://{{.Services.app.Host", AssetType: types.WebAddress},
+			},
+			want:       nil,
+			wantNilErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTargets(tt.targets, services)
+
+			if (err == nil) != tt.wantNilErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("targets mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
@@ -4,21 +4,23 @@
 package urlutil
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 )
 
 var (
-	// ErrInvalidScheme is returned by [Get] when the scheme of
-	// the provided URL is not supported.
+	// ErrInvalidScheme is returned by [Get] and [Create] when the
+	// scheme of the provided URL is not supported.
 	ErrInvalidScheme = errors.New("invalid scheme")
 
-	// ErrInvalidURL is returned by [Get] when the provided URL is
-	// not valid.
+	// ErrInvalidURL is returned by [Get] and [Create] when the
+	// provided URL is not valid.
 	ErrInvalidURL = errors.New("invalid URL")
 )
 
@@ -40,7 +42,9 @@ func Get(rawURL string) ([]byte, error) {
 	case "http", "https":
 		return getHTTP(parsedURL)
 	case "":
-		return os.ReadFile(parsedURL.Path)
+		// File paths are used unchanged, so characters like
+		// "#", "?" or "%" are not interpreted.
+		return os.ReadFile(rawURL)
 	}
 	return nil, fmt.Errorf("%w: %v", ErrInvalidScheme, parsedURL.Scheme)
 }
@@ -58,3 +62,114 @@ func getHTTP(parsedURL *url.URL) ([]byte, error) {
 	}
 	return io.ReadAll(resp.Body)
 }
+
+// UploadOptions are the options used to upload contents to cloud
+// storage.
+type UploadOptions struct {
+	// SSE is the server-side encryption algorithm applied to the
+	// uploaded object.
+	SSE string
+
+	// KMSKey is the identifier of the KMS key used to encrypt the
+	// uploaded object.
+	KMSKey string
+}
+
+// Create creates the resource identified by the provided raw URL and
+// returns an [io.WriteCloser] to write its contents. It returns error
+// if the URL is not valid or if it is not possible to create the
+// resource.
+//
+// It supports the following schemes: s3, gs. If the provided URL does
+// not specify a scheme, it is considered a file path. In the case of
+// s3 and gs, the contents are buffered in a temporary file and
+// uploaded when the returned writer is closed. The upload is done
+// with the "aws" and "gcloud" commands respectively, so the ambient
+// cloud credentials are used. The provided [UploadOptions] are
+// ignored for file paths.
+func Create(rawURL string, opts UploadOptions) (io.WriteCloser, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	switch parsedURL.Scheme {
+	case "s3":
+		args := []string{"s3", "cp", "", parsedURL.String()}
+		sse := opts.SSE
+		if sse == "" && opts.KMSKey != "" {
+			sse = "aws:kms"
+		}
+		if sse != "" {
+			args = append(args, "--sse", sse)
+		}
+		if opts.KMSKey != "" {
+			args = append(args, "--sse-kms-key-id", opts.KMSKey)
+		}
+		return newUploader("aws", args, 2)
+	case "gs":
+		args := []string{"storage", "cp", "", parsedURL.String()}
+		if opts.KMSKey != "" {
+			args = append(args, "--encryption-key", opts.KMSKey)
+		}
+		return newUploader("gcloud", args, 2)
+	case "":
+		// File paths are used unchanged, so characters like
+		// "#", "?" or "%" are not interpreted.
+		return os.Create(rawURL)
+	}
+	return nil, fmt.Errorf("%w: %v", ErrInvalidScheme, parsedURL.Scheme)
+}
+
+// TempFilePattern is the pattern of the temporary files created in
+// the default directory for temporary files to store the data that
+// is uploaded when they are closed.
+const TempFilePattern = "lava-upload-*"
+
+// uploader buffers the written data in a temporary file and uploads
+// it running the configured command when it is closed.
+type uploader struct {
+	*os.File
+	name string
+	args []string
+}
+
+// newUploader returns a new [uploader] that runs the command name
+// with the provided arguments on close. The argument at index srcIdx
+// is replaced with the path of the temporary file.
+func newUploader(name string, args []string, srcIdx int) (*uploader, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("look path: %w", err)
+	}
+
+	f, err := os.CreateTemp("", TempFilePattern)
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	args[srcIdx] = f.Name()
+
+	u := &uploader{
+		File: f,
+		name: name,
+		args: args,
+	}
+	return u, nil
+}
+
+// Close closes the temporary file and uploads it. The temporary file
+// is removed afterwards.
+func (u *uploader) Close() error {
+	defer os.Remove(u.File.Name())
+
+	if err := u.File.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(u.name, u.args...)
+	cmd.Stderr = buf
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %w: %#q", u.name, err, buf)
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -119,3 +121,149 @@ func TestGet_URL(t *testing.T) {
 		})
 	}
 }
+
+func TestCreate_File(t *testing.T) {
+	file := path.Join(t.TempDir(), "output.txt")
+
+	w, err := Create(file, UploadOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fmt.Fprint(w, "file with content")
+	if err := w.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read file error: %v", err)
+	}
+
+	if want := "file with content"; string(got) != want {
+		t.Errorf("content mismatch: want: %q, got: %q", want, got)
+	}
+}
+
+func TestCreate_File_special_chars(t *testing.T) {
+	for _, name := range []string{"output#1.txt", "output?v=1.txt", "output%41.txt"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			file := path.Join(dir, name)
+
+			w, err := Create(file, UploadOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			fmt.Fprint(w, "file with content")
+			if err := w.Close(); err != nil {
+				t.Fatalf("close error: %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("read dir error: %v", err)
+			}
+			if len(entries) != 1 || entries[0].Name() != name {
+				t.Fatalf("unexpected files: %v", entries)
+			}
+
+			got, err := Get(file)
+			if err != nil {
+				t.Fatalf("get error: %v", err)
+			}
+			if want := "file with content"; string(got) != want {
+				t.Errorf("content mismatch: want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+func TestCreate_Upload(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		opts     UploadOptions
+		wantArgs string
+	}{
+		{
+			name:     "s3",
+			url:      "s3://bucket/report.json",
+			wantArgs: "aws s3 cp SRC s3://bucket/report.json",
+		},
+		{
+			name: "s3 AES256",
+			url:  "s3://bucket/report.json",
+			opts: UploadOptions{
+				SSE: "AES256",
+			},
+			wantArgs: "aws s3 cp SRC s3://bucket/report.json --sse AES256",
+		},
+		{
+			name: "s3 KMS key",
+			url:  "s3://bucket/report.json",
+			opts: UploadOptions{
+				KMSKey: "key",
+			},
+			wantArgs: "aws s3 cp SRC s3://bucket/report.json --sse aws:kms --sse-kms-key-id key",
+		},
+		{
+			name: "gs KMS key",
+			url:  "gs://bucket/report.json",
+			opts: UploadOptions{
+				SSE:    "AES256",
+				KMSKey: "key",
+			},
+			wantArgs: "gcloud storage cp SRC gs://bucket/report.json --encryption-key key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpPath := t.TempDir()
+			argsFile := path.Join(tmpPath, "args.txt")
+			contentFile := path.Join(tmpPath, "content.txt")
+
+			// Fake aws and gcloud commands that record
+			// their arguments and the uploaded content.
+			script := fmt.Sprintf("#!/bin/sh\nsrc=$3\necho \"$(basename $0) $@\" | sed \"s|$src|SRC|\" > %v\ncp $src %v\n", argsFile, contentFile)
+			for _, name := range []string{"aws", "gcloud"} {
+				if err := os.WriteFile(path.Join(tmpPath, name), []byte(script), 0755); err != nil {
+					t.Fatalf("write fake command: %v", err)
+				}
+			}
+			t.Setenv("PATH", tmpPath+":"+os.Getenv("PATH"))
+
+			w, err := Create(tt.url, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			fmt.Fprint(w, "uploaded content")
+			if err := w.Close(); err != nil {
+				t.Fatalf("close error: %v", err)
+			}
+
+			gotArgs, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatalf("read args file: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantArgs, strings.TrimSpace(string(gotArgs))); diff != "" {
+				t.Errorf("args mismatch (-want +got):\n%v", diff)
+			}
+
+			gotContent, err := os.ReadFile(contentFile)
+			if err != nil {
+				t.Fatalf("read content file: %v", err)
+			}
+			if want := "uploaded content"; string(gotContent) != want {
+				t.Errorf("content mismatch: want: %q, got: %q", want, gotContent)
+			}
+		})
+	}
+}
+
+func TestCreate_InvalidScheme(t *testing.T) {
+	_, err := Create("invalid://example.com/file.json", UploadOptions{})
+	if !errors.Is(err, ErrInvalidScheme) {
+		t.Errorf("unexpected error: want: %v, got: %v", ErrInvalidScheme, err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Adevinta
+
+// Package warnings collects the warnings logged during a Lava run, so
+// they can be summarized at the end of the run.
+package warnings
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// DefaultCollector is the default [Collector].
+var DefaultCollector = NewCollector()
+
+// Warning is a warning logged during the run.
+type Warning struct {
+	// Message is the message of the warning.
+	Message string `json:"message"`
+
+	// Attrs contains the attributes of the warning. The keys of
+	// the attributes in groups are prefixed with the name of the
+	// groups separated by dots.
+	Attrs map[string]string `json:"attrs,omitempty"`
+
+	// Count is the number of times the warning was logged.
+	Count int `json:"count"`
+}
+
+// Collector represents a warnings collector.
+type Collector struct {
+	mutex    sync.Mutex
+	warnings []Warning
+}
+
+// NewCollector returns a new warnings collector.
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Add records the provided warning. If an equal warning has already
+// been recorded, its count is increased.
+func (c *Collector) Add(message string, attrs map[string]string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, w := range c.warnings {
+		if w.Message == message && maps.Equal(w.Attrs, attrs) {
+			c.warnings[i].Count++
+			return
+		}
+	}
+	c.warnings = append(c.warnings, Warning{
+		Message: message,
+		Attrs:   attrs,
+		Count:   1,
+	})
+}
+
+// Warnings returns the recorded warnings in the order they were
+// first logged.
+func (c *Collector) Warnings() []Warning {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return slices.Clone(c.warnings)
+}
+
+// Add records the provided warning using [DefaultCollector].
+func Add(message string, attrs map[string]string) {
+	DefaultCollector.Add(message, attrs)
+}
+
+// Warnings returns the warnings recorded by [DefaultCollector].
+func Warnings() []Warning {
+	return DefaultCollector.Warnings()
+}
+
+// Handler is a [slog.Handler] that records the warnings in a
+// [Collector]. It ignores the records with a level different from
+// [slog.LevelWarn].
+type Handler struct {
+	collector *Collector
+	attrs     map[string]string
+	prefix    string
+}
+
+// NewHandler returns a [Handler] that records the warnings in the
+// provided collector.
+func NewHandler(c *Collector) *Handler {
+	return &Handler{collector: c}
+}
+
+// Enabled reports whether the handler handles records at the given
+// level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level == slog.LevelWarn
+}
+
+// Handle records the provided warning.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	var attrs map[string]string
+	if len(h.attrs) > 0 || r.NumAttrs() > 0 {
+		attrs = maps.Clone(h.attrs)
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			addAttr(attrs, h.prefix, a)
+			return true
+		})
+	}
+	h.collector.Add(r.Message, attrs)
+	return nil
+}
+
+// WithAttrs returns a new [Handler] whose records include the
+// provided attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = maps.Clone(h.attrs)
+	if h2.attrs == nil {
+		h2.attrs = make(map[string]string)
+	}
+	for _, a := range attrs {
+		addAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+// WithGroup returns a new [Handler] that qualifies the following
+// attributes with the provided group name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// addAttr adds the provided attribute to attrs. The keys of the
+// attributes in groups are prefixed with the group name.
+func addAttr(attrs map[string]string, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addAttr(attrs, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	attrs[prefix+a.Key] = v.String()
+}
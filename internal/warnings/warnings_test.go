@@ -0,0 +1,53 @@
+// Copyright 2024 Adevinta
+
+package warnings
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHandler(t *testing.T) {
+	c := NewCollector()
+	logger := slog.New(NewHandler(c))
+
+	logger.Info("info message", "key", "value")
+	logger.Warn("warn message", "key", "value")
+	logger.Warn("warn message", "key", "value")
+	logger.Warn("warn message", "key", "other")
+	logger.Error("error message")
+	logger.With("target", "example.com").WithGroup("file").Warn("invalid file type", "path", "socket", slog.Group("mode", "type", "socket"))
+	logger.Warn("no attrs")
+
+	want := []Warning{
+		{
+			Message: "warn message",
+			Attrs:   map[string]string{"key": "value"},
+			Count:   2,
+		},
+		{
+			Message: "warn message",
+			Attrs:   map[string]string{"key": "other"},
+			Count:   1,
+		},
+		{
+			Message: "invalid file type",
+			Attrs: map[string]string{
+				"target":         "example.com",
+				"file.path":      "socket",
+				"file.mode.type": "socket",
+			},
+			Count: 1,
+		},
+		{
+			Message: "no attrs",
+			Count:   1,
+		},
+	}
+
+	if diff := cmp.Diff(want, c.Warnings()); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%v", diff)
+	}
+}
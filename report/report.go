@@ -0,0 +1,197 @@
+// Copyright 2024 Adevinta
+
+// Package report defines the structure of the JSON reports generated
+// by Lava. It allows tools, like dashboards or custom gates, to parse
+// the output of "lava scan" and "lava run" with the "json" format.
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+// Severity levels.
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+)
+
+// Report is a Lava report. It contains the findings that were not
+// excluded and whose severity is at least the "show" severity
+// configured in the report configuration, sorted by severity in
+// descending order.
+type Report []Finding
+
+// Finding is a finding reported by a check.
+type Finding struct {
+	// Vulnerability contains the details of the finding as
+	// reported by the check.
+	report.Vulnerability
+
+	// CheckData contains the information of the check that
+	// reported the finding.
+	CheckData report.CheckData `json:"check_data"`
+
+	// CheckKey is the stable identifier of the check. Unlike the
+	// check ID, it does not change between scans.
+	CheckKey string `json:"check_key"`
+
+	// Slug is a short identifier of the finding derived from its
+	// fingerprint, checktype and target. It is stable across
+	// scans. It is empty in the reports generated by older
+	// versions of Lava.
+	Slug string `json:"slug"`
+
+	// Severity is the severity of the finding.
+	Severity Severity `json:"severity"`
+
+	// Advisory reports whether the finding was reported by an
+	// advisory checktype.
+	Advisory bool `json:"advisory,omitempty"`
+
+	// Parent contains the summaries of the aggregate findings
+	// that grouped the finding, if any, separated by " > ".
+	Parent string `json:"parent,omitempty"`
+
+	// SLA is the status of the finding regarding its deadline. It
+	// is nil if no SLA is configured for its severity.
+	SLA *SLAStatus `json:"sla,omitempty"`
+
+	// CWE contains the details of the weakness referenced by the
+	// CWEID field. It is nil if the finding does not reference a
+	// CWE.
+	CWE *CWE `json:"cwe,omitempty"`
+}
+
+// CWE contains the details of a Common Weakness Enumeration entry.
+type CWE struct {
+	// ID is the CWE identifier.
+	ID uint32 `json:"id"`
+
+	// Name is the name of the weakness. It is empty if the
+	// weakness is unknown to Lava.
+	Name string `json:"name,omitempty"`
+
+	// Description is a short description of the weakness. It is
+	// empty if the weakness is unknown to Lava.
+	Description string `json:"description,omitempty"`
+}
+
+// SLAStatus is the status of a finding regarding the deadline
+// configured for its severity.
+type SLAStatus struct {
+	// FirstSeen is the time when the finding was first detected.
+	FirstSeen time.Time `json:"first_seen"`
+
+	// Due is the time when the deadline to fix the finding
+	// expires.
+	Due time.Time `json:"due"`
+
+	// Overdue reports whether the deadline has expired.
+	Overdue bool `json:"overdue"`
+}
+
+// Metadata is the metadata of a scan. It is included in the report
+// if the "report.metadata" setting is enabled.
+type Metadata struct {
+	// LavaVersion is the version of Lava that run the scan.
+	LavaVersion string `json:"lava_version"`
+
+	// ConfigFile is the path of the configuration file.
+	ConfigFile string `json:"config_file,omitempty"`
+
+	// ConfigHash is the SHA-256 hash of the configuration file
+	// encoded in hexadecimal.
+	ConfigHash string `json:"config_hash,omitempty"`
+
+	// ScanID is the unique ID of the scan.
+	ScanID string `json:"scan_id"`
+
+	// StartTime is the time when the scan started.
+	StartTime time.Time `json:"start_time"`
+
+	// EndTime is the time when the scan finished.
+	EndTime time.Time `json:"end_time"`
+
+	// OS is the operating system of the host that run the scan.
+	OS string `json:"os"`
+
+	// Arch is the architecture of the host that run the scan.
+	Arch string `json:"arch"`
+
+	// Runtime is the container runtime used to run the checks.
+	Runtime string `json:"runtime"`
+
+	// Labels are the labels provided by the user.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Document is a JSON report document. If the report does not
+// include metadata, Metadata is nil.
+type Document struct {
+	Metadata *Metadata `json:"metadata"`
+	Findings Report    `json:"findings"`
+}
+
+// Load reads the findings of a JSON report from r. It supports
+// reports with and without metadata.
+func Load(r io.Reader) (Report, error) {
+	doc, err := LoadDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Findings, nil
+}
+
+// LoadDocument reads a JSON report from r. It supports reports with
+// and without metadata.
+func LoadDocument(r io.Reader) (Document, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Document{}, fmt.Errorf("read report: %w", err)
+	}
+
+	var doc Document
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		err = json.Unmarshal(b, &doc)
+	} else {
+		err = json.Unmarshal(b, &doc.Findings)
+	}
+	if err != nil {
+		return Document{}, fmt.Errorf("decode report: %w", err)
+	}
+	return doc, nil
+}
+
+// LoadFile reads the findings of a JSON report from the specified
+// file.
+func LoadFile(path string) (Report, error) {
+	doc, err := LoadDocumentFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Findings, nil
+}
+
+// LoadDocumentFile reads a JSON report from the specified file.
+func LoadDocumentFile(path string) (Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("open report: %w", err)
+	}
+	defer f.Close()
+
+	return LoadDocument(f)
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Adevinta
+
+package report
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFile(t *testing.T) {
+	rep, err := LoadFile("testdata/report.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rep) != 1 {
+		t.Fatalf("unexpected number of findings: %v", len(rep))
+	}
+
+	f := rep[0]
+	if f.Summary != "Secret Leaked in Git Repository" {
+		t.Errorf("unexpected summary: %v", f.Summary)
+	}
+	if f.CheckData.Target != "https://example.com/repo.git" {
+		t.Errorf("unexpected target: %v", f.CheckData.Target)
+	}
+	if f.Severity != SeverityHigh {
+		t.Errorf("unexpected severity: %v", f.Severity)
+	}
+	if f.Parent != "Leaked secrets" {
+		t.Errorf("unexpected parent: %v", f.Parent)
+	}
+	if f.SLA == nil {
+		t.Fatalf("missing SLA status")
+	}
+	if want := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC); !f.SLA.Due.Equal(want) {
+		t.Errorf("unexpected due date: got: %v, want: %v", f.SLA.Due, want)
+	}
+	if f.CWE == nil {
+		t.Fatalf("missing CWE")
+	}
+	if f.CWE.ID != 798 || f.CWE.Name != "Use of Hard-coded Credentials" {
+		t.Errorf("unexpected CWE: %+v", f.CWE)
+	}
+}
+
+func TestLoadFile_not_exist(t *testing.T) {
+	if _, err := LoadFile("testdata/not_exist.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadDocumentFile(t *testing.T) {
+	doc, err := LoadDocumentFile("testdata/report_metadata.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if doc.Metadata == nil {
+		t.Fatalf("missing metadata")
+	}
+	if doc.Metadata.ScanID != "5f2b1b6e-4f5a-4a8e-9d1c-3b7f0e2c9a41" {
+		t.Errorf("unexpected scan ID: %v", doc.Metadata.ScanID)
+	}
+	if got := doc.Metadata.Labels["pipeline"]; got != "nightly" {
+		t.Errorf("unexpected label: %v", got)
+	}
+	if len(doc.Findings) != 1 {
+		t.Fatalf("unexpected number of findings: %v", len(doc.Findings))
+	}
+	if doc.Findings[0].Severity != SeverityHigh {
+		t.Errorf("unexpected severity: %v", doc.Findings[0].Severity)
+	}
+}
+
+func TestLoadDocumentFile_no_metadata(t *testing.T) {
+	doc, err := LoadDocumentFile("testdata/report.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Metadata != nil {
+		t.Errorf("unexpected metadata: %#v", doc.Metadata)
+	}
+	if len(doc.Findings) != 1 {
+		t.Errorf("unexpected number of findings: %v", len(doc.Findings))
+	}
+}
+
+func TestLoad_invalid(t *testing.T) {
+	if _, err := Load(strings.NewReader("not json")); err == nil {
+		t.Errorf("expected error")
+	}
+}